@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// recordActivity adds an entry to the poll's activity feed. Failures are only
+// logged, as the change the entry describes has already been made.
+func (app *application) recordActivity(pollID uuid.UUID, actor string, action string, details map[string]any) {
+	err := app.models.AuditLog.Insert(&data.Activity{
+		PollID:  pollID,
+		Action:  action,
+		Actor:   actor,
+		Details: details,
+	})
+	if err != nil {
+		app.logError(err)
+	}
+}
+
+// actor names who made a request for the activity feed: the API key it was
+// made with, by its prefix, the organization member it was authorized for,
+// or else the poll's owner.
+func (app *application) actor(r *http.Request) string {
+	if key, ok := app.apiKeyFromContext(r.Context()); ok {
+		return "api_key:" + key.Prefix
+	}
+	if member, ok := app.memberFromContext(r.Context()); ok {
+		return member.Name
+	}
+	return data.ActorOwner
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/cdn"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// How long public reads may be cached by clients and CDNs. Active polls
+// change with every vote, closed polls hardly ever do.
+const (
+	cacheTTLActive = 10 * time.Second
+	cacheTTLClosed = time.Hour
+	cacheTTLFeed   = 5 * time.Minute
+)
+
+// cachePolicy describes how a response may be cached.
+type cachePolicy struct {
+	maxAge time.Duration
+	// lastModified is sent as Last-Modified, if set.
+	lastModified time.Time
+	// private responses depend on who makes the request and are only cached
+	// by the client. Responses to requests with a token or an API key always
+	// are.
+	private bool
+	// keys are sent as surrogate keys, so a CDN can purge the response when
+	// what it shows changes.
+	keys []string
+}
+
+// pollCachePolicy caches responses about the poll for long once it has
+// closed. Its last update time is the Last-Modified time, and the responses
+// are tagged with the poll's surrogate key.
+func pollCachePolicy(poll *data.Poll) cachePolicy {
+	policy := cachePolicy{
+		maxAge:       cacheTTLActive,
+		lastModified: poll.UpdatedAt,
+		keys:         []string{cdn.PollKey(poll.ID.String())},
+	}
+	if poll.Expired(time.Now()) {
+		policy.maxAge = cacheTTLClosed
+	}
+	return policy
+}
+
+// writeCachedJSON writes the response like writeJSON, with Cache-Control and
+// ETag headers, and answers conditional requests whose copy is still current
+// with 304 Not Modified.
+func (app *application) writeCachedJSON(w http.ResponseWriter, r *http.Request, data envelope, policy cachePolicy) error {
+	js, err := responseProfile(w.Header()).marshal(data)
+	if err != nil {
+		return err
+	}
+	js = append(js, '\n')
+
+	app.writeCached(w, r, "application/json", js, policy)
+	return nil
+}
+
+// writeCached writes the body with a 200 status, or a 304 if the request's
+// copy is still current. The ETag is derived from the body, so it changes
+// whenever the content does.
+func (app *application) writeCached(w http.ResponseWriter, r *http.Request, contentType string, body []byte, policy cachePolicy) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	scope := "public"
+	if policy.private || r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != "" {
+		scope = "private"
+	}
+
+	h := w.Header()
+	h.Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(policy.maxAge.Seconds())))
+	h.Set("ETag", etag)
+	h.Add("Vary", "Authorization, X-API-Key")
+	if !policy.lastModified.IsZero() {
+		h.Set("Last-Modified", policy.lastModified.UTC().Format(http.TimeFormat))
+	}
+	if len(policy.keys) > 0 {
+		// Fastly reads Surrogate-Key, Cloudflare Cache-Tag
+		h.Set("Surrogate-Key", strings.Join(policy.keys, " "))
+		h.Set("Cache-Tag", strings.Join(policy.keys, ","))
+	}
+
+	if notModified(r, etag, policy.lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// notModified evaluates the request's conditional headers as RFC 9110
+// describes: If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// Last-Modified has a precision of seconds
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
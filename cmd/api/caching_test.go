@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/cdn"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_notModified(t *testing.T) {
+	modified := time.Date(2024, 2, 27, 10, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		expected bool
+	}{
+		{"no conditions", "", "", false},
+		{"matching etag", "If-None-Match", `"abc"`, true},
+		{"weak etag among others", "If-None-Match", `"xyz", W/"abc"`, true},
+		{"any etag", "If-None-Match", "*", true},
+		{"other etag", "If-None-Match", `"xyz"`, false},
+		{"not modified since", "If-Modified-Since", "Tue, 27 Feb 2024 10:00:00 GMT", true},
+		{"modified since", "If-Modified-Since", "Tue, 27 Feb 2024 09:59:59 GMT", false},
+		{"invalid date", "If-Modified-Since", "yesterday", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				req.Header.Set(test.header, test.value)
+			}
+			if got := notModified(req, `"abc"`, modified); got != test.expected {
+				t.Errorf("expected %t, but got %t", test.expected, got)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"xyz"`)
+	req.Header.Set("If-Modified-Since", "Tue, 27 Feb 2024 10:00:00 GMT")
+	if notModified(req, `"abc"`, modified) {
+		t.Error("expected If-None-Match to take precedence over If-Modified-Since")
+	}
+}
+
+func Test_pollCachePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		expected  time.Duration
+	}{
+		{"no expiry", time.Time{}, cacheTTLActive},
+		{"active", time.Now().Add(time.Hour), cacheTTLActive},
+		{"closed", time.Now().Add(-time.Hour), cacheTTLClosed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := pollCachePolicy(&data.Poll{ExpiresAt: data.ExpiresAt{Time: test.expiresAt}})
+			if policy.maxAge != test.expected {
+				t.Errorf("expected max age %s, but got %s", test.expected, policy.maxAge)
+			}
+		})
+	}
+}
+
+func Test_app_showPollHandler_caching(t *testing.T) {
+	get := func(pollID string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", pollID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.showPollHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get(data.ExamplePollIDValid.String(), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "public, max-age=10" {
+		t.Errorf("expected a short public max age for an active poll, but got %q", cc)
+	}
+	if rr.Header().Get("Last-Modified") != "" || rr.Header().Get("Vary") != "Authorization, X-API-Key" {
+		t.Errorf("expected a Vary header without Last-Modified, as the vote counts are shown, but got %v", rr.Header())
+	}
+	if hidden := get(data.ExamplePollIDAfterDeadline.String(), nil); hidden.Header().Get("Last-Modified") == "" {
+		t.Errorf("expected Last-Modified while the vote counts are hidden, but got %v", hidden.Header())
+	}
+	key := "poll-" + data.ExamplePollIDValid.String()
+	if rr.Header().Get("Surrogate-Key") != key || rr.Header().Get("Cache-Tag") != key {
+		t.Errorf("expected the poll's surrogate key, but got %v", rr.Header())
+	}
+
+	// the poll's times don't change between requests
+	rr = get(data.ExamplePollIDDemographics.String(), nil)
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+
+	rr = get(data.ExamplePollIDDemographics.String(), map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected %d without a body, but got %d with %q", http.StatusNotModified, rr.Code, rr.Body)
+	}
+
+	rr = get(data.ExamplePollIDValid.String(), map[string]string{"Authorization": "Bearer " + data.ExampleTokenPreview})
+	if cc := rr.Header().Get("Cache-Control"); cc != "private, max-age=10" {
+		t.Errorf("expected responses to requests with a token to be private, but got %q", cc)
+	}
+
+	rr = get(data.ExamplePollIDValid.String(), map[string]string{"X-API-Key": data.ExampleAPIKey})
+	if cc := rr.Header().Get("Cache-Control"); cc != "private, max-age=10" {
+		t.Errorf("expected responses to requests with an API key to be private, but got %q", cc)
+	}
+	if vary := rr.Header().Get("Vary"); vary != "Authorization, X-API-Key" {
+		t.Errorf("expected responses to vary by API key, but got %q", vary)
+	}
+}
+
+type recordingPurger struct {
+	keys []string
+}
+
+func (p *recordingPurger) Purge(ctx context.Context, keys []string) error {
+	p.keys = append(p.keys, keys...)
+	return nil
+}
+
+func Test_app_purgeOnWrite(t *testing.T) {
+	purger := &recordingPurger{}
+	app.cdn = cdn.NewQueue(purger, time.Hour, func(err error) { t.Error(err) })
+	defer func() {
+		app.cdn.Close()
+		app.cdn = nil
+	}()
+
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		expected bool
+	}{
+		{"read", http.MethodGet, http.StatusOK, false},
+		{"successful write", http.MethodPost, http.StatusCreated, true},
+		{"failed write", http.MethodPatch, http.StatusUnprocessableEntity, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			purger.keys = nil
+
+			mux := chi.NewRouter()
+			mux.Use(app.purgeOnWrite)
+			mux.MethodFunc(test.method, "/v1/polls/{pollID}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+			})
+
+			req, _ := http.NewRequest(test.method, "/v1/polls/"+data.ExamplePollIDValid.String(), nil)
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+			app.cdn.Flush()
+
+			if purged := len(purger.keys) == 1 && purger.keys[0] == "poll-"+data.ExamplePollIDValid.String(); purged != test.expected {
+				t.Errorf("expected purged %t, but got keys %v", test.expected, purger.keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,555 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/cdn"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/geoip"
+	"github.com/ivcp/polls/internal/mailer"
+	"github.com/ivcp/polls/internal/moderation"
+	"github.com/ivcp/polls/internal/seed"
+	"github.com/ivcp/polls/internal/spam"
+	"github.com/ivcp/polls/internal/storage"
+	"github.com/ivcp/polls/internal/telegram"
+	"github.com/ivcp/polls/internal/text"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// command is a subcommand of the binary. setup registers the command's flags
+// and returns the function running it once they are parsed.
+type command struct {
+	name  string
+	usage string
+	setup func(fs *flag.FlagSet, cfg *config) func(app *application) error
+}
+
+var commands = []*command{
+	{"serve", "Run the API server (default)", setupServe},
+	{"migrate", "Run the database migrations", setupMigrate},
+	{"seed", "Create demo polls with votes", setupSeed},
+	{"create-admin-token", "Add an admin to an organization and print their token", setupCreateAdminToken},
+	{"purge-expired", "Delete polls that expired a while ago", setupPurgeExpired},
+	{"dump-polls", "Write the public polls as JSON lines for analytics", setupDumpPolls},
+}
+
+// run runs the command named by the first argument, or serve if the first
+// argument is a flag.
+func run(logger *log.Logger, args []string) error {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == name {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		printCommands(os.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	var cfg config
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	exec := cmd.setup(fs, &cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return err
+	}
+
+	app := &application{config: cfg, logger: logger}
+	return exec(app)
+}
+
+func printCommands(w *os.File) {
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-20s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w, "Run a command with -h to see its flags.")
+}
+
+// openDB connects to the database and sets up the models for commands other
+// than serve. The returned function closes the connection.
+func (app *application) openDB() (func(), error) {
+	db, err := app.connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	app.models = data.NewModels(db)
+	return db.Close, nil
+}
+
+func setupServe(fs *flag.FlagSet, cfg *config) func(app *application) error {
+	fs.BoolVar(&cfg.db.autoMigrate, "auto-migrate", true, "Run the database migrations on startup")
+
+	fs.StringVar(&cfg.baseURL, "base-url", "", "Public base URL of the API, e.g. https://polls.example.com (derived from requests if empty)")
+	fs.StringVar(&cfg.pollURL, "poll-url", "", "Public URL of a poll's page with %s in place of the poll ID (defaults to the API resource)")
+	fs.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests persecond")
+	fs.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
+	fs.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
+	fs.IntVar(&cfg.voteLimiter.attempts, "vote-limiter-attempts", 5, "Maximum vote attempts per poll and IP within the window")
+	fs.IntVar(&cfg.voteLimiter.kioskAttempts, "vote-limiter-kiosk-attempts", 30, "Maximum vote attempts per poll and kiosk token within the window")
+	fs.DurationVar(&cfg.voteLimiter.window, "vote-limiter-window", time.Minute, "Sliding window for vote attempts")
+	fs.BoolVar(&cfg.voteLimiter.enabled, "vote-limiter-enabled", true, "Enable per-poll vote rate limiter")
+	fs.StringVar(&cfg.geoip.db, "geoip-db", "", "Path to MaxMind country database used for geo-restricted polls")
+	fs.StringVar(&cfg.geoip.api, "geoip-api", "", "GeoIP API URL with %s in place of the IP, used if geoip-db is not set")
+	fs.BoolVar(&cfg.spam.enabled, "spam-enabled", true, "Enable screening of votes for abuse")
+	fs.IntVar(&cfg.spam.threshold, "spam-threshold", 50, "Score at which a vote is flagged as suspect")
+	fs.StringVar(&cfg.bots.honeypot, "bot-honeypot", "", "Hidden form field that refuses polls created with it filled in (disabled if empty)")
+	fs.DurationVar(&cfg.bots.minFillTime, "bot-min-fill-time", 0, "Shortest time between getting a form token and creating a poll with it, requires FORM_TOKEN_KEY (disabled if 0)")
+	fs.IntVar(&cfg.bots.uaThreshold, "bot-ua-threshold", 0, "User agent score at which polls are refused as created by a bot (disabled if 0)")
+	fs.StringVar(&cfg.profanity.wordlist, "profanity-wordlist", "", "File with words to mask in questions, descriptions and options, one per line (disabled if empty)")
+	fs.StringVar(&cfg.moderation.wordlist, "moderation-wordlist", "", "File with words moderation flags in questions, descriptions and options, one per line")
+	fs.StringVar(&cfg.moderation.api, "moderation-api", "", "URL of a moderation service checking content instead of moderation-wordlist")
+	fs.IntVar(&cfg.reports.threshold, "report-threshold", 5, "Reports after which a poll is hidden until it is reviewed (never hidden if 0)")
+	fs.StringVar(&cfg.moderation.action, "moderation-action", "flag", "What happens to flagged content: reject, flag for review or mask")
+
+	fs.DurationVar(&cfg.closeInterval, "close-interval", 30*time.Second, "How often expired polls are closed")
+	fs.DurationVar(&cfg.maxExpiresIn, "max-expires-in", 90*24*time.Hour, "Longest expires_in polls can be created with (unlimited if 0)")
+	fs.DurationVar(&cfg.digestInterval, "digest-interval", time.Minute, "How often due vote digests are sent")
+	fs.DurationVar(&cfg.undoWindow, "undo-window", 30*time.Second, "How long deletes of polls and options can be undone (deleted right away if 0)")
+	fs.DurationVar(&cfg.undoInterval, "undo-interval", 5*time.Second, "How often deletes past their undo window are carried out")
+	fs.DurationVar(&cfg.restoreWindow, "restore-window", 7*24*time.Hour, "How long deleted options can be restored with their votes")
+	fs.DurationVar(&cfg.voteConfirmTTL, "vote-confirm-ttl", 24*time.Hour, "How long links confirming votes on polls with confirm_votes are valid")
+	fs.DurationVar(&cfg.liveInterval, "live-interval", 2*time.Second, "How often presentation views check for new results")
+	fs.DurationVar(&cfg.exportInterval, "export-interval", time.Minute, "How often due result exports are made")
+	fs.DurationVar(&cfg.statsTTL, "stats-ttl", time.Minute, "How long the public stats are cached")
+	fs.StringVar(&cfg.events.broker, "events-broker", "", "Broker to publish poll events to: nats or kafka (disabled if empty)")
+	fs.StringVar(&cfg.events.url, "events-url", "", "NATS server URL or comma separated list of Kafka brokers")
+	fs.StringVar(&cfg.events.topic, "events-topic", "polls", "NATS subject prefix or Kafka topic for poll events")
+
+	fs.StringVar(&cfg.analytics.sink, "analytics-sink", "", "Where vote and view events are streamed for analytics: file, kinesis or pubsub (disabled if empty)")
+	fs.StringVar(&cfg.analytics.target, "analytics-target", "", "File path, Kinesis stream name or Pub/Sub topic (projects/{project}/topics/{topic}) for analytics events")
+	fs.StringVar(&cfg.analytics.region, "analytics-region", "us-east-1", "Region of the Kinesis stream")
+	fs.StringVar(&cfg.analytics.endpoint, "analytics-endpoint", "", "Kinesis or Pub/Sub endpoint, e.g. of an emulator (defaults to the service's endpoint)")
+	fs.DurationVar(&cfg.analytics.flushInterval, "analytics-flush-interval", 5*time.Second, "How often analytics events are written to the sink")
+	fs.StringVar(&cfg.cdn.purger, "cdn-purger", "", "CDN whose cache is purged when polls change: fastly or cloudflare (disabled if empty)")
+	fs.StringVar(&cfg.cdn.service, "cdn-service", "", "Fastly service ID or Cloudflare zone ID")
+	fs.DurationVar(&cfg.cdn.purgeInterval, "cdn-purge-interval", time.Second, "How often changed polls are purged from the CDN")
+
+	fs.StringVar(&cfg.smtp.host, "smtp-host", "", "SMTP host for email notifications (disabled if empty)")
+	fs.IntVar(&cfg.smtp.port, "smtp-port", 587, "SMTP port")
+	fs.StringVar(&cfg.smtp.username, "smtp-username", "", "SMTP username, the password is read from SMTP_PASSWORD")
+	fs.StringVar(&cfg.smtp.sender, "smtp-sender", "Polls <no-reply@polls.local>", "SMTP sender")
+
+	fs.StringVar(&cfg.storage.endpoint, "storage-endpoint", "https://s3.amazonaws.com", "S3 compatible endpoint result exports are stored at, e.g. https://storage.googleapis.com")
+	fs.StringVar(&cfg.storage.region, "storage-region", "us-east-1", "Region of the storage bucket (auto for Google Cloud Storage)")
+	fs.StringVar(&cfg.storage.bucket, "storage-bucket", "", "Bucket for result exports, the keys are read from STORAGE_ACCESS_KEY and STORAGE_SECRET_KEY (disabled if empty)")
+
+	fs.StringVar(&cfg.tls.certFile, "tls-cert", "", "TLS certificate file, HTTPS is served if set together with tls-key")
+	fs.StringVar(&cfg.tls.keyFile, "tls-key", "", "TLS private key file")
+	fs.StringVar(&cfg.tls.autocertDomains, "autocert-domains", "", "Comma separated domains to get Let's Encrypt certificates for, instead of tls-cert")
+	fs.StringVar(&cfg.tls.autocertCache, "autocert-cache", "certs", "Directory Let's Encrypt certificates are stored in")
+	fs.StringVar(&cfg.tls.autocertEmail, "autocert-email", "", "Contact email for the Let's Encrypt account")
+	fs.IntVar(&cfg.tls.redirectPort, "redirect-port", 80, "Port redirecting HTTP to HTTPS when TLS is enabled (disabled if 0)")
+	fs.DurationVar(&cfg.tls.hstsMaxAge, "hsts-max-age", 365*24*time.Hour, "Strict-Transport-Security max age when TLS is enabled (disabled if 0)")
+
+	fs.IntVar(&cfg.quotas.activePolls, "quota-active-polls", 0, "Maximum active polls per organization (unlimited if 0)")
+	fs.IntVar(&cfg.quotas.dailyPolls, "quota-daily-polls", 0, "Maximum polls an IP or API key can create a day, unless exempt (unlimited if 0)")
+
+	fs.DurationVar(&cfg.jwt.ttl, "jwt-ttl", 15*time.Minute, "Lifetime of issued JWTs, at most 1h, JWTs are disabled if JWT_KEY is not set")
+
+	fs.Func("json-profile", "How JSON responses are written: snake or camel field names, and envelope or bare, e.g. camel,bare (snake,envelope if empty)", func(s string) error {
+		var err error
+		cfg.json.profile, err = parseJSONProfile(s, jsonProfile{})
+		return err
+	})
+
+	fs.StringVar(&cfg.profile, "profile", "", "Address to serve pprof endpoints on, e.g. localhost:6060 (disabled if empty)")
+	fs.BoolVar(&cfg.loadTest, "load-test", false, "Disable rate limiting and spam screening for load tests, not allowed in production")
+
+	return func(app *application) error {
+		cfg := &app.config
+
+		port, err := strconv.Atoi(os.Getenv("SERVER_PORT"))
+		if err != nil {
+			return err
+		}
+		cfg.port = port
+		if cfg.env == "" {
+			return errors.New("server env not set")
+		}
+
+		if err := cfg.validateTLS(); err != nil {
+			return err
+		}
+		if cfg.loadTest {
+			if err := cfg.enableLoadTestMode(); err != nil {
+				return err
+			}
+			app.logger.Println("Load test mode: rate limiting and spam screening are disabled")
+		}
+
+		app.spam = spam.New(cfg.spam.threshold)
+		if cfg.jwt.ttl <= 0 || cfg.jwt.ttl > time.Hour {
+			return errors.New("jwt-ttl must be more than 0 and at most 1h")
+		}
+		if cfg.bots.minFillTime > 0 && len(cfg.bots.formKey) == 0 {
+			return errors.New("bot-min-fill-time requires FORM_TOKEN_KEY")
+		}
+		if cfg.profanity.wordlist != "" {
+			words, err := text.LoadWordlist(cfg.profanity.wordlist)
+			if err != nil {
+				return err
+			}
+			app.text.Filters = append(app.text.Filters, text.Profanity(words))
+		}
+		if _, err := moderation.ParseAction(cfg.moderation.action); err != nil {
+			return err
+		}
+		switch {
+		case cfg.moderation.wordlist != "":
+			words, err := text.LoadWordlist(cfg.moderation.wordlist)
+			if err != nil {
+				return err
+			}
+			app.moderation = moderation.NewWordlistProvider(words)
+		case cfg.moderation.api != "":
+			app.moderation = moderation.NewAPIProvider(cfg.moderation.api)
+		}
+		app.httpClient = &http.Client{Timeout: 10 * time.Second}
+		app.webhookClient = newWebhookClient()
+		if cfg.telegram.botToken != "" {
+			app.telegram = telegram.NewClient(cfg.telegram.botToken)
+		}
+
+		switch {
+		case cfg.geoip.db != "":
+			provider, err := geoip.NewMaxMindProvider(cfg.geoip.db)
+			if err != nil {
+				return err
+			}
+			defer provider.Close()
+			app.geoip = provider
+		case cfg.geoip.api != "":
+			app.geoip = geoip.NewAPIProvider(cfg.geoip.api)
+		}
+
+		if cfg.smtp.host != "" {
+			app.mailer = mailer.NewSMTPMailer(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender)
+		}
+
+		if cfg.storage.bucket != "" {
+			provider, err := storage.NewS3Provider(
+				cfg.storage.endpoint, cfg.storage.region, cfg.storage.bucket, cfg.storage.accessKey, cfg.storage.secretKey,
+			)
+			if err != nil {
+				return err
+			}
+			app.storage = provider
+		}
+
+		if cfg.analytics.sink != "" {
+			sink, err := newAnalyticsSink(cfg)
+			if err != nil {
+				return err
+			}
+			stream := analytics.NewStream(sink, cfg.analytics.flushInterval, app.logError)
+			defer stream.Close()
+			app.analytics = stream
+		}
+
+		if cfg.cdn.purger != "" {
+			purger, err := newCDNPurger(cfg)
+			if err != nil {
+				return err
+			}
+			queue := cdn.NewQueue(purger, cfg.cdn.purgeInterval, app.logError)
+			defer queue.Close()
+			app.cdn = queue
+		}
+
+		switch cfg.events.broker {
+		case "":
+		case "nats":
+			publisher, err := events.NewNATSPublisher(cfg.events.url, cfg.events.topic)
+			if err != nil {
+				return err
+			}
+			defer publisher.Close()
+			app.events = publisher
+		case "kafka":
+			publisher := events.NewKafkaPublisher(strings.Split(cfg.events.url, ","), cfg.events.topic)
+			defer publisher.Close()
+			app.events = publisher
+		default:
+			return fmt.Errorf("unknown events broker %q", cfg.events.broker)
+		}
+
+		db, err := app.connectToDB()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if cfg.db.autoMigrate {
+			if err = app.runMigrations(db); err != nil {
+				return err
+			}
+		}
+
+		app.models = data.NewModels(db)
+
+		app.setMetrics(db)
+
+		go app.closeExpiredPolls(cfg.closeInterval)
+		go app.sendDigests(cfg.digestInterval)
+		go app.executeStagedActions(cfg.undoInterval)
+		go app.exportResults(cfg.exportInterval)
+		if cfg.profile != "" {
+			go app.serveProfiler()
+		}
+
+		return app.serve()
+	}
+}
+
+// newCDNPurger creates the purger of the configured CDN.
+func newCDNPurger(cfg *config) (cdn.Purger, error) {
+	if cfg.cdn.service == "" || cfg.cdn.token == "" {
+		return nil, errors.New("cdn purging requires -cdn-service and CDN_API_TOKEN")
+	}
+
+	switch cfg.cdn.purger {
+	case "fastly":
+		return cdn.NewFastlyPurger(cfg.cdn.service, cfg.cdn.token), nil
+	case "cloudflare":
+		return cdn.NewCloudflarePurger(cfg.cdn.service, cfg.cdn.token), nil
+	default:
+		return nil, fmt.Errorf("unknown cdn purger %q", cfg.cdn.purger)
+	}
+}
+
+// newAnalyticsSink creates the sink analytics events are streamed to.
+// Kinesis keys are read from ANALYTICS_ACCESS_KEY and ANALYTICS_SECRET_KEY,
+// Pub/Sub credentials from the service account key file at
+// GOOGLE_APPLICATION_CREDENTIALS.
+func newAnalyticsSink(cfg *config) (analytics.Sink, error) {
+	switch cfg.analytics.sink {
+	case "file":
+		return analytics.NewFileSink(cfg.analytics.target)
+	case "kinesis":
+		return analytics.NewKinesisSink(
+			cfg.analytics.endpoint, cfg.analytics.region, cfg.analytics.target, cfg.analytics.accessKey, cfg.analytics.secretKey,
+		)
+	case "pubsub":
+		var credentials []byte
+		if cfg.analytics.credentials != "" {
+			var err error
+			credentials, err = os.ReadFile(cfg.analytics.credentials)
+			if err != nil {
+				return nil, fmt.Errorf("read pubsub credentials: %w", err)
+			}
+		}
+		return analytics.NewPubSubSink(cfg.analytics.endpoint, cfg.analytics.target, credentials)
+	default:
+		return nil, fmt.Errorf("unknown analytics sink %q", cfg.analytics.sink)
+	}
+}
+
+func setupMigrate(fs *flag.FlagSet, cfg *config) func(app *application) error {
+	return func(app *application) error {
+		db, err := app.connectToDB()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return app.runMigrations(db)
+	}
+}
+
+func setupSeed(fs *flag.FlagSet, cfg *config) func(app *application) error {
+	seedCfg := seed.DefaultConfig()
+	fs.IntVar(&seedCfg.Polls, "polls", seedCfg.Polls, "Number of polls to create")
+	fs.IntVar(&seedCfg.MinOptions, "min-options", seedCfg.MinOptions, "Minimum number of options per poll")
+	fs.IntVar(&seedCfg.MaxOptions, "max-options", seedCfg.MaxOptions, "Maximum number of options per poll")
+	fs.IntVar(&seedCfg.VotesPerPoll, "votes", seedCfg.VotesPerPoll, "Average number of votes per poll")
+	fs.Float64Var(&seedCfg.ExpiredRatio, "expired-ratio", seedCfg.ExpiredRatio, "Share of polls whose deadline has passed")
+	fs.Int64Var(&seedCfg.Seed, "seed", seedCfg.Seed, "Random seed, the same seed generates the same data")
+
+	return func(app *application) error {
+		if err := seedCfg.Validate(); err != nil {
+			return err
+		}
+
+		closeDB, err := app.openDB()
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		summary, err := seed.New(app.models, seedCfg).Run()
+		if err != nil {
+			return err
+		}
+
+		app.logger.Printf(
+			"Created %d polls with %d options and %d votes (seed %d)",
+			summary.Polls, summary.Options, summary.Votes, seedCfg.Seed,
+		)
+		return nil
+	}
+}
+
+func setupCreateAdminToken(fs *flag.FlagSet, cfg *config) func(app *application) error {
+	orgID := fs.String("org", "", "ID of the organization")
+	name := fs.String("name", "Admin", "Name of the new admin")
+
+	return func(app *application) error {
+		closeDB, err := app.openDB()
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		token, err := app.createAdminToken(*orgID, *name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(token)
+		return nil
+	}
+}
+
+// createAdminToken adds an admin to the organization and returns their token,
+// for when an organization has lost access to all of its admin tokens.
+func (app *application) createAdminToken(orgID string, name string) (string, error) {
+	id, err := uuid.Parse(orgID)
+	member := &data.Member{OrgID: id, Name: strings.TrimSpace(name), Role: data.RoleAdmin}
+
+	v := validator.New()
+	v.Check(err == nil, "org", "must be an organization ID")
+	if data.ValidateMember(v, member); !v.Valid() {
+		return "", errors.New(formatValidationErrors(v.Errors))
+	}
+
+	_, err = app.models.Orgs.Get(member.OrgID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return "", fmt.Errorf("organization %s not found", member.OrgID)
+		}
+		return "", err
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = app.models.Orgs.InsertMember(member, token.Hash)
+	if err != nil {
+		return "", err
+	}
+
+	return token.Plaintext, nil
+}
+
+func setupPurgeExpired(fs *flag.FlagSet, cfg *config) func(app *application) error {
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "How long ago polls must have expired")
+
+	return func(app *application) error {
+		closeDB, err := app.openDB()
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		deleted, err := app.purgeExpired(*olderThan)
+		if err != nil {
+			return err
+		}
+
+		app.logger.Printf("Deleted %d expired polls", deleted)
+		return nil
+	}
+}
+
+// purgeExpired deletes polls that expired longer ago than olderThan.
+func (app *application) purgeExpired(olderThan time.Duration) (int64, error) {
+	if olderThan < 0 {
+		return 0, errors.New("older-than must not be negative")
+	}
+	return app.models.Polls.DeleteExpired(time.Now().Add(-olderThan))
+}
+
+func setupDumpPolls(fs *flag.FlagSet, cfg *config) func(app *application) error {
+	out := fs.String("out", "-", "File to write the polls to, gzipped if it ends in .gz (- for stdout)")
+	after := fs.String("after", "", "ID of the last poll of a previous dump to resume after")
+
+	return func(app *application) error {
+		var afterID uuid.UUID
+		if *after != "" {
+			var err error
+			if afterID, err = uuid.Parse(*after); err != nil {
+				return errors.New("after must be a poll ID")
+			}
+		}
+
+		closeDB, err := app.openDB()
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		var w io.Writer = os.Stdout
+		if *out != "-" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		buf := bufio.NewWriter(w)
+		flush := buf.Flush
+		if strings.HasSuffix(*out, ".gz") {
+			gz := gzip.NewWriter(buf)
+			flush = func() error {
+				if err := gz.Close(); err != nil {
+					return err
+				}
+				return buf.Flush()
+			}
+			w = gz
+		} else {
+			w = buf
+		}
+
+		// the buffer writes itself out as it fills, so it's flushed once at
+		// the end
+		dumped, err := app.dumpPolls(w, afterID, func() error { return nil })
+		if err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		app.logger.Printf("Dumped %d polls", dumped)
+		return nil
+	}
+}
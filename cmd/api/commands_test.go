@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_run_unknownCommand(t *testing.T) {
+	err := run(log.New(&strings.Builder{}, "", 0), []string{"unknown"})
+	if err == nil || !strings.Contains(err.Error(), `unknown command "unknown"`) {
+		t.Errorf("expected unknown command error, but got %v", err)
+	}
+}
+
+func Test_app_createAdminToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		orgID    string
+		admin    string
+		expected bool
+	}{
+		{"valid", data.ExampleOrgID.String(), "Recovery", true},
+		{"invalid org id", "abc", "Recovery", false},
+		{"org not found", "f2b1c9a4-3d5e-4f60-9a7b-8c1d2e3f4a5b", "Recovery", false},
+		{"empty name", data.ExampleOrgID.String(), " ", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := app.createAdminToken(test.orgID, test.admin)
+			if (err == nil) != test.expected {
+				t.Fatalf("expected success to be %t, but got %v", test.expected, err)
+			}
+			if test.expected && len(token) != 26 {
+				t.Errorf("expected a 26 character token, but got %q", token)
+			}
+		})
+	}
+}
+
+func Test_app_purgeExpired(t *testing.T) {
+	if _, err := app.purgeExpired(30 * 24 * time.Hour); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+	if _, err := app.purgeExpired(-time.Hour); err == nil {
+		t.Error("expected an error for a negative duration")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ivcp/polls/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
@@ -25,13 +26,15 @@ func (app *application) connectToDB() (*pgxpool.Pool, error) {
 	return connPoll, nil
 }
 
-func (app *application) runMigrations(db *pgxpool.Pool, dir string) error {
+// runMigrations applies the migrations embedded in the binary.
+func (app *application) runMigrations(db *pgxpool.Pool) error {
+	goose.SetBaseFS(migrations.FS)
 	if err := goose.SetDialect("postgres"); err != nil {
 		return fmt.Errorf("migrate: %w", err)
 	}
 	database := stdlib.OpenDBFromPool(db)
 
-	if err := goose.Up(database, dir); err != nil {
+	if err := goose.Up(database, "."); err != nil {
 		return fmt.Errorf("migrate: %w", err)
 	}
 
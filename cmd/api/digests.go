@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// sendDigests periodically sends the digests that are due, each summarizing
+// the new votes on all polls of one owner.
+func (app *application) sendDigests(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		digests, err := app.models.Digests.ClaimDue()
+		if err != nil {
+			app.logError(err)
+			continue
+		}
+
+		for _, digest := range digests {
+			app.sendDigest(digest)
+		}
+	}
+}
+
+// sendDigest delivers the digest by email or to the owner's webhook. Digests
+// without new votes are skipped.
+func (app *application) sendDigest(digest *data.Digest) {
+	if digest.NewVotes == 0 {
+		return
+	}
+
+	for _, poll := range digest.Polls {
+		poll.URL = app.pollURL(nil, poll.PollID)
+	}
+
+	switch {
+	case digest.Email != "":
+		if app.mailer == nil {
+			return
+		}
+		app.background(func() {
+			if err := app.mailer.Send(digest.Email, "digest.tmpl", digest); err != nil {
+				app.logError(err)
+			}
+		})
+	case digest.WebhookURL != "":
+		app.background(func() {
+			if err := app.postWebhook(digest.WebhookURL, digest); err != nil {
+				app.logError(err)
+			}
+		})
+	}
+}
+
+// newWebhookClient returns a client for URLs given by users, which refuses to
+// connect to loopback, private, link-local and unspecified addresses, so
+// webhooks can't reach the server's own network. Addresses are checked once
+// the host is resolved, for each address dialed, and redirects aren't
+// followed, so neither DNS nor a redirect can lead the request there.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: publicAddressOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would be dialed instead of the webhook's host
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddressOnly is a net.Dialer Control function refusing addresses that
+// aren't public.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// postWebhook posts the payload as JSON to a URL given by a user. Responses
+// other than 2xx, including redirects, fail.
+func (app *application) postWebhook(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := app.webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("post webhook: unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
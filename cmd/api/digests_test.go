@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func newDigest() *data.Digest {
+	return &data.Digest{
+		Frequency: data.DigestDaily,
+		Since:     time.Now().Add(-24 * time.Hour),
+		Until:     time.Now(),
+		NewVotes:  3,
+		Polls: []*data.DigestPoll{
+			{PollID: data.ExamplePollIDValid, Question: "Test?", NewVotes: 3, TotalVotes: 10},
+		},
+	}
+}
+
+func Test_app_sendDigest_email(t *testing.T) {
+	mailer := &mockMailer{}
+	app.mailer = mailer
+	defer func() { app.mailer = nil }()
+
+	empty := newDigest()
+	empty.Email = "john@example.com"
+	empty.NewVotes = 0
+	app.sendDigest(empty)
+
+	digest := newDigest()
+	digest.Email = "jane@example.com"
+	app.sendDigest(digest)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mailer.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sent := mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 email, but got %d", len(sent))
+	}
+	if sent[0].recipient != "jane@example.com" || sent[0].templateFile != "digest.tmpl" {
+		t.Errorf("unexpected email %+v", sent[0])
+	}
+}
+
+func Test_app_sendDigest_webhook(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer srv.Close()
+
+	app.webhookClient = srv.Client()
+	defer func() { app.webhookClient = nil }()
+
+	digest := newDigest()
+	digest.WebhookURL = srv.URL
+	app.sendDigest(digest)
+
+	select {
+	case body := <-received:
+		if body["new_votes"] != float64(3) {
+			t.Errorf("expected 3 new votes, but got %v", body["new_votes"])
+		}
+		if _, ok := body["webhook_url"]; ok {
+			t.Error("expected webhook_url not to be sent")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expected the webhook to be called")
+	}
+}
+
+func Test_publicAddressOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"10.0.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:80", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			err := publicAddressOnly("tcp", test.address, nil)
+			if allowed := err == nil; allowed != test.allowed {
+				t.Errorf("expected allowed %t, but got error %v", test.allowed, err)
+			}
+		})
+	}
+}
+
+func Test_app_postWebhook_loopback(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	app.webhookClient = newWebhookClient()
+	defer func() { app.webhookClient = nil }()
+
+	if err := app.postWebhook(srv.URL, newDigest()); err == nil {
+		t.Error("expected posting to a loopback address to fail")
+	}
+	if called {
+		t.Error("expected the webhook not to be called")
+	}
+}
@@ -0,0 +1,288 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+// e2e runs the full router against a real database, started once for all
+// end-to-end tests and removed after them.
+var e2e struct {
+	once   sync.Once
+	err    error
+	app    *application
+	db     *pgxpool.Pool
+	server *httptest.Server
+	mailer *mockMailer
+}
+
+func setupE2E(t *testing.T) {
+	t.Helper()
+
+	e2e.once.Do(func() {
+		e2e.err = startE2E()
+	})
+	if e2e.err != nil {
+		t.Fatalf("could not set up e2e server: %s", e2e.err)
+	}
+}
+
+func startE2E() error {
+	pool, err := dockertest.NewPool(os.Getenv("DOCKER_TEST"))
+	if err != nil {
+		return fmt.Errorf("could not connect to docker: %w", err)
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Env: []string{
+			"POSTGRES_USER=postgres",
+			"POSTGRES_PASSWORD=postgres",
+			"POSTGRES_DB=polls_e2e",
+		},
+		ExposedPorts: []string{"5432"},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"5432": {{HostIP: "0.0.0.0", HostPort: "5435"}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not start postgres: %w", err)
+	}
+	testCleanups = append(testCleanups, func() { _ = pool.Purge(resource) })
+
+	e2e.mailer = &mockMailer{}
+	e2e.app = &application{
+		logger: log.New(io.Discard, "", 0),
+		mailer: e2e.mailer,
+	}
+	e2e.app.config.env = "testing"
+	e2e.app.config.db.dsn = "host=localhost port=5435 user=postgres password=postgres dbname=polls_e2e sslmode=disable"
+
+	err = pool.Retry(func() error {
+		var err error
+		e2e.db, err = e2e.app.connectToDB()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	testCleanups = append(testCleanups, e2e.db.Close)
+
+	if err := e2e.app.runMigrations(e2e.db); err != nil {
+		return err
+	}
+	e2e.app.models = data.NewModels(e2e.db)
+
+	e2e.server = httptest.NewServer(e2e.app.routes())
+	testCleanups = append(testCleanups, e2e.server.Close)
+
+	return nil
+}
+
+type e2eRequest struct {
+	method string
+	path   string
+	token  string
+	ip     string
+	body   any
+}
+
+// do sends the request to the e2e server and decodes the JSON response.
+func (req e2eRequest) do(t *testing.T) (int, map[string]any) {
+	t.Helper()
+
+	var body io.Reader
+	if req.body != nil {
+		js, err := json.Marshal(req.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(js)
+	}
+
+	r, err := http.NewRequest(req.method, e2e.server.URL+req.path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.token != "" {
+		r.Header.Set("Authorization", "Bearer "+req.token)
+	}
+	if req.ip != "" {
+		r.Header.Set("X-Forwarded-For", req.ip)
+	}
+
+	resp, err := e2e.server.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil && err != io.EOF {
+		t.Fatalf("%s %s: could not decode response: %s", req.method, req.path, err)
+	}
+
+	return resp.StatusCode, decoded
+}
+
+// expect sends the request and fails the test if the response status differs.
+func (req e2eRequest) expect(t *testing.T, status int) map[string]any {
+	t.Helper()
+
+	got, body := req.do(t)
+	if got != status {
+		t.Fatalf("%s %s: expected status %d, but got %d: %v", req.method, req.path, status, got, body)
+	}
+	return body
+}
+
+func voteCounts(t *testing.T, body map[string]any) map[string]int {
+	t.Helper()
+
+	counts := map[string]int{}
+	for _, result := range body["results"].([]any) {
+		option := result.(map[string]any)
+		counts[option["value"].(string)] = int(option["vote_count"].(float64))
+	}
+	return counts
+}
+
+func TestE2EPollLifecycle(t *testing.T) {
+	setupE2E(t)
+
+	e2eRequest{method: http.MethodGet, path: "/v1/healthcheck"}.expect(t, http.StatusOK)
+
+	body := e2eRequest{method: http.MethodPost, path: "/v1/polls", body: map[string]any{
+		"question":           "Best day for the team meeting?",
+		"options":            []map[string]any{{"value": "Monday", "position": 0}, {"value": "Friday", "position": 1}},
+		"expires_at":         time.Now().Add(time.Hour),
+		"results_visibility": "after_deadline",
+		"notify_email":       "jane@example.com",
+	}}.expect(t, http.StatusCreated)
+
+	poll := body["poll"].(map[string]any)
+	pollID := poll["id"].(string)
+	token := poll["token"].(string)
+	optionIDs := map[string]string{}
+	for _, option := range poll["options"].([]any) {
+		option := option.(map[string]any)
+		optionIDs[option["value"].(string)] = option["id"].(string)
+	}
+	pollPath := "/v1/polls/" + pollID
+
+	body = e2eRequest{method: http.MethodGet, path: pollPath}.expect(t, http.StatusOK)
+	if body["poll"].(map[string]any)["question"] != "Best day for the team meeting?" {
+		t.Errorf("unexpected poll %v", body["poll"])
+	}
+
+	votePath := func(value string) string { return pollPath + "/options/" + optionIDs[value] }
+	e2eRequest{method: http.MethodPost, path: votePath("Monday"), ip: "10.0.0.1"}.expect(t, http.StatusOK)
+	e2eRequest{method: http.MethodPost, path: votePath("Monday"), ip: "10.0.0.2"}.expect(t, http.StatusOK)
+	e2eRequest{method: http.MethodPost, path: votePath("Friday"), ip: "10.0.0.3"}.expect(t, http.StatusOK)
+	e2eRequest{method: http.MethodPost, path: votePath("Friday"), ip: "10.0.0.1"}.expect(t, http.StatusForbidden)
+
+	// results are hidden until the deadline, except from the poll's owner
+	resultsPath := pollPath + "/results"
+	e2eRequest{method: http.MethodGet, path: resultsPath}.expect(t, http.StatusForbidden)
+	body = e2eRequest{method: http.MethodGet, path: resultsPath, token: token}.expect(t, http.StatusOK)
+	if counts := voteCounts(t, body); counts["Monday"] != 2 || counts["Friday"] != 1 {
+		t.Errorf("expected 2 votes for Monday and 1 for Friday, but got %v", counts)
+	}
+
+	e2eRequest{method: http.MethodPatch, path: pollPath, body: map[string]any{"question": "Changed?"}}.
+		expect(t, http.StatusUnauthorized)
+	e2eRequest{method: http.MethodPatch, path: pollPath, token: token, body: map[string]any{"question": "Changed?"}}.
+		expect(t, http.StatusForbidden)
+
+	// let the deadline pass instead of waiting for it
+	_, err := e2e.db.Exec(context.Background(), `UPDATE polls SET expires_at = NOW() - interval '1 second' WHERE id = $1;`, pollID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2e.app.closeExpired()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(e2e.mailer.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sent := e2e.mailer.Sent(); len(sent) != 1 || sent[0].recipient != "jane@example.com" {
+		t.Errorf("expected the results to be emailed once the poll closed, but got %+v", sent)
+	}
+
+	e2eRequest{method: http.MethodPost, path: votePath("Friday"), ip: "10.0.0.4"}.expect(t, http.StatusForbidden)
+	body = e2eRequest{method: http.MethodGet, path: resultsPath}.expect(t, http.StatusOK)
+	if counts := voteCounts(t, body); counts["Monday"] != 2 || counts["Friday"] != 1 {
+		t.Errorf("expected 2 votes for Monday and 1 for Friday, but got %v", counts)
+	}
+
+	e2eRequest{method: http.MethodDelete, path: pollPath}.expect(t, http.StatusUnauthorized)
+	e2eRequest{method: http.MethodDelete, path: pollPath, token: token}.expect(t, http.StatusOK)
+	e2eRequest{method: http.MethodGet, path: pollPath}.expect(t, http.StatusNotFound)
+}
+
+func TestE2EOrganization(t *testing.T) {
+	setupE2E(t)
+
+	body := e2eRequest{method: http.MethodPost, path: "/v1/orgs", body: map[string]any{
+		"name":       "Acme",
+		"admin_name": "Jane",
+	}}.expect(t, http.StatusCreated)
+	orgID := body["organization"].(map[string]any)["id"].(string)
+	adminToken := body["token"].(string)
+	orgPath := "/v1/orgs/" + orgID
+
+	body = e2eRequest{method: http.MethodPost, path: orgPath + "/members", token: adminToken, body: map[string]any{
+		"name": "John",
+		"role": "viewer",
+	}}.expect(t, http.StatusCreated)
+	viewerToken := body["token"].(string)
+
+	newPoll := map[string]any{
+		"question": "Where should the offsite be?",
+		"options":  []map[string]any{{"value": "Lisbon", "position": 0}, {"value": "Prague", "position": 1}},
+	}
+	e2eRequest{method: http.MethodPost, path: orgPath + "/polls", token: viewerToken, body: newPoll}.
+		expect(t, http.StatusForbidden)
+	body = e2eRequest{method: http.MethodPost, path: orgPath + "/polls", token: adminToken, body: newPoll}.
+		expect(t, http.StatusCreated)
+	pollID := body["poll"].(map[string]any)["id"].(string)
+
+	listed := func(body map[string]any) bool {
+		for _, poll := range body["polls"].([]any) {
+			if poll.(map[string]any)["id"] == pollID {
+				return true
+			}
+		}
+		return false
+	}
+
+	body = e2eRequest{method: http.MethodGet, path: orgPath + "/polls", token: viewerToken}.expect(t, http.StatusOK)
+	if !listed(body) {
+		t.Error("expected the poll to be listed for the organization")
+	}
+	body = e2eRequest{method: http.MethodGet, path: "/v1/polls"}.expect(t, http.StatusOK)
+	if listed(body) {
+		t.Error("expected the organization's poll not to be listed publicly")
+	}
+
+	// members edit the organization's polls with their own token
+	e2eRequest{method: http.MethodDelete, path: "/v1/polls/" + pollID, token: viewerToken}.expect(t, http.StatusForbidden)
+	e2eRequest{method: http.MethodDelete, path: "/v1/polls/" + pollID, token: adminToken}.expect(t, http.StatusOK)
+}
@@ -1,17 +1,111 @@
 package main
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) logError(err error) {
 	app.logger.Print(err)
 }
 
-func (app *application) errorJSONResponse(w http.ResponseWriter, status int, message any) {
-	env := envelope{"error": message}
+// errorCode identifies the kind of an error response, so clients can handle
+// errors without matching their messages. Codes are part of the API and must
+// not change once released.
+type errorCode string
+
+const (
+	codeServerError        errorCode = "SERVER_ERROR"
+	codeNotFound           errorCode = "NOT_FOUND"
+	codeBadRequest         errorCode = "BAD_REQUEST"
+	codeValidationFailed   errorCode = "VALIDATION_FAILED"
+	codeContentRejected    errorCode = "CONTENT_REJECTED"
+	codeRateLimited        errorCode = "RATE_LIMITED"
+	codeVoteRateLimited    errorCode = "VOTE_RATE_LIMITED"
+	codeAlreadyVoted       errorCode = "ALREADY_VOTED"
+	codeVotingStarted      errorCode = "VOTING_STARTED"
+	codeEditConflict       errorCode = "EDIT_CONFLICT"
+	codeOptionNotInPoll    errorCode = "OPTION_NOT_IN_POLL"
+	codePollExpired        errorCode = "POLL_EXPIRED"
+	codePollClosed         errorCode = "POLL_CLOSED"
+	codePollPaused         errorCode = "POLL_PAUSED"
+	codeVoteQuotaReached   errorCode = "VOTE_QUOTA_REACHED"
+	codeResultsHidden      errorCode = "RESULTS_HIDDEN"
+	codeInvalidToken       errorCode = "INVALID_TOKEN"
+	codeNotPermitted       errorCode = "NOT_PERMITTED"
+	codeLastAdmin          errorCode = "LAST_ADMIN"
+	codeQuotaExceeded      errorCode = "QUOTA_EXCEEDED"
+	codeCountryNotAllowed  errorCode = "COUNTRY_NOT_ALLOWED"
+	codeInvalidSignature   errorCode = "INVALID_SIGNATURE"
+	codeFormatNotSupported errorCode = "FORMAT_NOT_SUPPORTED"
+	codePollRemoved        errorCode = "POLL_REMOVED"
+	codeBanned             errorCode = "BANNED"
+	codeConfirmationLimit  errorCode = "CONFIRMATION_LIMIT"
+	codeInvalidTransition  errorCode = "INVALID_TRANSITION"
+	codeCreationLimited    errorCode = "CREATION_LIMITED"
+	codeSuspectedBot       errorCode = "SUSPECTED_BOT"
+	codeBallotsIssued      errorCode = "BALLOTS_ISSUED"
+)
+
+// errorCatalog is the status every error code is responded with and what it
+// means. The error codes table in the README is kept in sync with it.
+var errorCatalog = map[errorCode]struct {
+	status      int
+	description string
+}{
+	codeServerError:        {http.StatusInternalServerError, "the server failed to process the request"},
+	codeNotFound:           {http.StatusNotFound, "the resource doesn't exist"},
+	codeBadRequest:         {http.StatusBadRequest, "the request is malformed, e.g. invalid JSON or an invalid ID"},
+	codeValidationFailed:   {http.StatusUnprocessableEntity, "the input is invalid, error is an object of messages by field"},
+	codeContentRejected:    {http.StatusUnprocessableEntity, "the question, description or options contain words moderation doesn't allow"},
+	codeRateLimited:        {http.StatusTooManyRequests, "too many requests were made from the IP"},
+	codeVoteRateLimited:    {http.StatusTooManyRequests, "too many votes were attempted on the poll from the IP"},
+	codeAlreadyVoted:       {http.StatusForbidden, "the IP or vote token has already voted on the poll"},
+	codeVotingStarted:      {http.StatusForbidden, "the poll can't be edited once it has votes"},
+	codeEditConflict:       {http.StatusConflict, "the poll was changed by another request, retry with the latest version"},
+	codeOptionNotInPoll:    {http.StatusNotFound, "the option doesn't belong to the poll"},
+	codePollExpired:        {http.StatusForbidden, "the poll's deadline has passed"},
+	codePollClosed:         {http.StatusForbidden, "the poll has closed and its results are final"},
+	codePollPaused:         {http.StatusLocked, "the poll's owner has paused voting"},
+	codeVoteQuotaReached:   {http.StatusForbidden, "the poll has reached its maximum number of votes and is closed"},
+	codeResultsHidden:      {http.StatusForbidden, "the poll's results are not visible yet"},
+	codeInvalidToken:       {http.StatusUnauthorized, "the token is missing, invalid or lacks the permission"},
+	codeNotPermitted:       {http.StatusForbidden, "the member's role lacks the permission"},
+	codeLastAdmin:          {http.StatusConflict, "the organization's last admin can't be removed or demoted"},
+	codeQuotaExceeded:      {http.StatusForbidden, "the organization has reached a quota"},
+	codeCountryNotAllowed:  {http.StatusForbidden, "the poll doesn't accept votes from the voter's country"},
+	codeInvalidSignature:   {http.StatusUnauthorized, "the signature of an integration request is missing or invalid"},
+	codeFormatNotSupported: {http.StatusNotImplemented, "the requested format is not supported"},
+	codePollRemoved:        {http.StatusGone, "the poll was taken down by an administrator"},
+	codeBanned:             {http.StatusForbidden, "the IP or API key is banned from creating polls"},
+	codeConfirmationLimit:  {http.StatusTooManyRequests, "too many emails confirming the vote were sent, or the last one was sent too recently"},
+	codeInvalidTransition:  {http.StatusConflict, "the poll can't change from its status as requested, e.g. drafts can't be paused"},
+	codeCreationLimited:    {http.StatusTooManyRequests, "the IP or API key has created as many polls as it may today"},
+	codeSuspectedBot:       {http.StatusForbidden, "the poll looks created by a script, e.g. the form was filled in too fast"},
+	codeBallotsIssued:      {http.StatusConflict, "the poll's ballots are for its options as they are, so they can't be merged"},
+}
+
+// errorJSONResponse responds with the error code, its status and a message
+// for people, translated to the response's Content-Language if it is set.
+func (app *application) errorJSONResponse(w http.ResponseWriter, code errorCode, message any) {
+	if lang := w.Header().Get("Content-Language"); lang != "" {
+		switch m := message.(type) {
+		case string:
+			message = validator.Translate(lang, m)
+		case map[string]string:
+			message = validator.TranslateErrors(lang, m)
+		}
+	}
 
-	err := app.writeJSON(w, status, env, nil)
+	env := envelope{"error": message, "code": code}
+
+	err := app.writeJSON(w, errorCatalog[code].status, env, nil)
 	if err != nil {
 		app.logError(err)
 		w.WriteHeader(500)
@@ -21,49 +115,170 @@ func (app *application) errorJSONResponse(w http.ResponseWriter, status int, mes
 func (app *application) serverErrorResponse(w http.ResponseWriter, err error) {
 	app.logError(err)
 	message := "the server encountered a problem and could not process your request"
-	app.errorJSONResponse(w, http.StatusInternalServerError, message)
+	app.errorJSONResponse(w, codeServerError, message)
 }
 
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
 	message := "the requested resource could not be found"
-	app.errorJSONResponse(w, http.StatusNotFound, message)
+	app.errorJSONResponse(w, codeNotFound, message)
 }
 
 func (app *application) badRequestResponse(w http.ResponseWriter, err error) {
-	app.errorJSONResponse(w, http.StatusBadRequest, err.Error())
+	app.errorJSONResponse(w, codeBadRequest, err.Error())
 }
 
 func (app *application) failedValidationResponse(w http.ResponseWriter, errors map[string]string) {
-	app.errorJSONResponse(w, http.StatusUnprocessableEntity, errors)
+	app.errorJSONResponse(w, codeValidationFailed, errors)
+}
+
+// duplicateOptionResponse is the validation error for an option whose value
+// another option got first, when both are saved at once.
+func (app *application) duplicateOptionResponse(w http.ResponseWriter, option *data.PollOption) {
+	app.failedValidationResponse(w, map[string]string{
+		"options": "must not contain duplicate values",
+		fmt.Sprintf("options.%d", option.Position): "must not duplicate another option's value",
+	})
+}
+
+func (app *application) tooManyOptionsResponse(w http.ResponseWriter) {
+	app.failedValidationResponse(w, map[string]string{
+		"options": "must not contain more than 100 options",
+	})
+}
+
+func (app *application) contentRejectedResponse(w http.ResponseWriter) {
+	message := "the content was rejected by moderation"
+	app.errorJSONResponse(w, codeContentRejected, message)
 }
 
 func (app *application) rateLimitExcededResponse(w http.ResponseWriter) {
 	message := "rate limit exceeded"
-	app.errorJSONResponse(w, http.StatusTooManyRequests, message)
+	app.errorJSONResponse(w, codeRateLimited, message)
+}
+
+func (app *application) voteRateLimitExceededResponse(w http.ResponseWriter) {
+	message := "too many vote attempts on this poll, please try again later"
+	app.errorJSONResponse(w, codeVoteRateLimited, message)
 }
 
 func (app *application) cannotVoteResponse(w http.ResponseWriter) {
 	message := "you have already voted on this poll"
-	app.errorJSONResponse(w, http.StatusForbidden, message)
+	app.errorJSONResponse(w, codeAlreadyVoted, message)
 }
 
 func (app *application) cannotEditResponse(w http.ResponseWriter) {
 	message := "editing the poll is not permitted once voting has begun"
-	app.errorJSONResponse(w, http.StatusForbidden, message)
+	app.errorJSONResponse(w, codeVotingStarted, message)
+}
+
+func (app *application) editConflictResponse(w http.ResponseWriter) {
+	message := "unable to update the poll due to an edit conflict, please try again"
+	app.errorJSONResponse(w, codeEditConflict, message)
+}
+
+func (app *application) optionNotInPollResponse(w http.ResponseWriter) {
+	message := "the option does not belong to this poll"
+	app.errorJSONResponse(w, codeOptionNotInPoll, message)
 }
 
 func (app *application) pollExpiredResponse(w http.ResponseWriter) {
 	message := "poll has expired"
-	app.errorJSONResponse(w, http.StatusForbidden, message)
+	app.errorJSONResponse(w, codePollExpired, message)
+}
+
+func (app *application) pollClosedResponse(w http.ResponseWriter) {
+	message := "poll is closed"
+	app.errorJSONResponse(w, codePollClosed, message)
+}
+
+// pollPausedResponse tells voters the poll is paused, with the owner's reason
+// if one was given. The reason isn't translated.
+func (app *application) pollPausedResponse(w http.ResponseWriter, reason string) {
+	message := "voting on this poll is paused"
+	if reason != "" {
+		message = validator.Translate(w.Header().Get("Content-Language"), message) + ": " + reason
+	}
+	app.errorJSONResponse(w, codePollPaused, message)
+}
+
+func (app *application) voteQuotaReachedResponse(w http.ResponseWriter) {
+	message := "this poll has reached its maximum number of votes"
+	app.errorJSONResponse(w, codeVoteQuotaReached, message)
 }
 
 func (app *application) cannotShowResultsResponse(w http.ResponseWriter, msg string) {
 	message := "results will be available " + msg
-	app.errorJSONResponse(w, http.StatusForbidden, message)
+	app.errorJSONResponse(w, codeResultsHidden, message)
 }
 
 func (app *application) invalidTokenResponse(w http.ResponseWriter) {
 	w.Header().Set("WWW-Authenticate", "Bearer")
 	message := "invalid or missing token"
-	app.errorJSONResponse(w, http.StatusUnauthorized, message)
+	app.errorJSONResponse(w, codeInvalidToken, message)
+}
+
+func (app *application) notPermittedResponse(w http.ResponseWriter) {
+	message := "your role does not permit this action"
+	app.errorJSONResponse(w, codeNotPermitted, message)
+}
+
+func (app *application) lastAdminResponse(w http.ResponseWriter) {
+	message := "organization must keep at least one admin"
+	app.errorJSONResponse(w, codeLastAdmin, message)
+}
+
+func (app *application) quotaExceededResponse(w http.ResponseWriter, quota string) {
+	message := "quota of " + quota + " exceeded"
+	app.errorJSONResponse(w, codeQuotaExceeded, message)
+}
+
+func (app *application) countryNotAllowedResponse(w http.ResponseWriter) {
+	message := "voting on this poll is not available in your country"
+	app.errorJSONResponse(w, codeCountryNotAllowed, message)
+}
+
+func (app *application) invalidSignatureResponse(w http.ResponseWriter) {
+	message := "invalid or missing request signature"
+	app.errorJSONResponse(w, codeInvalidSignature, message)
+}
+
+func (app *application) pollRemovedResponse(w http.ResponseWriter) {
+	message := "this poll was removed by an administrator"
+	app.errorJSONResponse(w, codePollRemoved, message)
+}
+
+func (app *application) bannedResponse(w http.ResponseWriter) {
+	message := "you are banned from creating polls"
+	app.errorJSONResponse(w, codeBanned, message)
+}
+
+// creationLimitedResponse asks to retry at midnight UTC, when the daily
+// limits start over.
+func (app *application) creationLimitedResponse(w http.ResponseWriter) {
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tomorrow.Sub(now).Seconds()))))
+
+	message := "you have created as many polls as you may today, please try again tomorrow"
+	app.errorJSONResponse(w, codeCreationLimited, message)
+}
+
+func (app *application) suspectedBotResponse(w http.ResponseWriter) {
+	message := "the request looks automated, please fill in the form yourself"
+	app.errorJSONResponse(w, codeSuspectedBot, message)
+}
+
+func (app *application) confirmationLimitResponse(w http.ResponseWriter) {
+	message := "too many confirmation emails were sent for this vote, please try again later"
+	app.errorJSONResponse(w, codeConfirmationLimit, message)
+}
+
+func (app *application) invalidTransitionResponse(w http.ResponseWriter) {
+	message := "this is not possible in the poll's current status"
+	app.errorJSONResponse(w, codeInvalidTransition, message)
+}
+
+func (app *application) ballotsIssuedResponse(w http.ResponseWriter) {
+	message := "options of verifiable polls can't be merged, as voters' ballots are for the options they voted for"
+	app.errorJSONResponse(w, codeBallotsIssued, message)
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_app_errorJSONResponse(t *testing.T) {
+	for code, info := range errorCatalog {
+		t.Run(string(code), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			app.errorJSONResponse(rr, code, "message")
+
+			if rr.Code != info.status {
+				t.Errorf("expected status %d, but got %d", info.status, rr.Code)
+			}
+
+			var body struct {
+				Code  errorCode `json:"code"`
+				Error string    `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != code || body.Error != "message" {
+				t.Errorf("unexpected body %s", rr.Body)
+			}
+		})
+	}
+}
+
+// Test_errorCatalog_documented makes sure clients can look up every code.
+func Test_errorCatalog_documented(t *testing.T) {
+	readme, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for code, info := range errorCatalog {
+		row := fmt.Sprintf("| `%s` | %d |", code, info.status)
+		if !strings.Contains(string(readme), row) {
+			t.Errorf("expected README to document %s with status %d", code, info.status)
+		}
+	}
+}
+
+func Test_app_errorJSONResponse_translated(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set("Content-Language", "de")
+	app.failedValidationResponse(rr, map[string]string{"question": "must not be empty", "other": "not translated"})
+
+	expected := `{"code":"VALIDATION_FAILED","error":{"other":"not translated","question":"darf nicht leer sein"}}`
+	if got := strings.TrimSpace(rr.Body.String()); got != expected {
+		t.Errorf("expected body %s, but got %s", expected, got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/cdn"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+)
+
+// publishEvent publishes the event in the background so requests don't wait
+// on the broker. It does nothing when no broker is configured.
+func (app *application) publishEvent(event events.Event) {
+	if app.events == nil {
+		return
+	}
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := app.events.Publish(ctx, event); err != nil {
+			app.logError(err)
+		}
+	})
+}
+
+// trackEvent adds the event to the analytics stream. It does nothing when no
+// analytics sink is configured.
+func (app *application) trackEvent(event analytics.Event) {
+	if app.analytics == nil {
+		return
+	}
+
+	app.analytics.Track(event)
+}
+
+// purgePoll queues the poll's cached responses to be purged from the CDN. It
+// does nothing when no CDN purger is configured.
+func (app *application) purgePoll(pollID uuid.UUID) {
+	if app.cdn == nil {
+		return
+	}
+
+	app.cdn.Add(cdn.PollKey(pollID.String()))
+}
+
+// closeExpiredPolls periodically marks expired polls as closed, publishing
+// a poll.closed event and notifying the creator of each.
+func (app *application) closeExpiredPolls(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		app.closeExpired()
+	}
+}
+
+// maintenanceInterval is how often the statistics of the partitioned votes
+// table are updated and past days' counts of created polls are deleted.
+const maintenanceInterval = 6 * time.Hour
+
+// executeStagedActions periodically carries out the staged deletes whose
+// undo window has passed, and deletes options that can no longer be restored
+// for good. Every maintenanceInterval it also updates the statistics of the
+// votes table, which autovacuum doesn't for partitioned tables, and deletes
+// the counts of created polls of past days.
+func (app *application) executeStagedActions(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	maintained := time.Now()
+	for range ticker.C {
+		if time.Since(maintained) >= maintenanceInterval {
+			if err := app.models.Votes.Analyze(); err != nil {
+				app.logError(err)
+			}
+			if _, err := app.models.Creations.Purge(); err != nil {
+				app.logError(err)
+			}
+			maintained = time.Now()
+		}
+		if _, err := app.models.Staged.ExecuteDue(); err != nil {
+			app.logError(err)
+		}
+		if _, err := app.models.Deleted.Purge(app.config.restoreWindow); err != nil {
+			app.logError(err)
+		}
+	}
+}
+
+// closeExpired closes the polls that expired since the last run.
+func (app *application) closeExpired() {
+	polls, err := app.models.Polls.CloseExpired()
+	if err != nil {
+		app.logError(err)
+		return
+	}
+
+	for _, poll := range polls {
+		app.pollClosed(poll)
+	}
+}
+
+func (app *application) pollClosed(poll *data.Poll) {
+	results, err := app.models.PollOptions.GetResults(poll.ID)
+	if err != nil {
+		app.logError(err)
+		return
+	}
+
+	app.publishEvent(events.PollClosed(poll, results))
+	app.purgePoll(poll.ID)
+
+	if poll.NotifyEmail != "" {
+		app.sendPollClosedEmail(poll, results)
+	}
+}
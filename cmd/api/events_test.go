@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+)
+
+type mockPublisher struct {
+	published chan events.Event
+}
+
+func (m mockPublisher) Publish(ctx context.Context, event events.Event) error {
+	m.published <- event
+	return nil
+}
+
+func (m mockPublisher) Close() error {
+	return nil
+}
+
+func Test_app_publishEvent(t *testing.T) {
+	publisher := mockPublisher{published: make(chan events.Event, 1)}
+	app.events = publisher
+	defer func() { app.events = nil }()
+
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Forwarded-For", "5.5.5.5")
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
+	chiCtx.URLParams.Add("optionID", data.ExampleOptionID1.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.voteOptionHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	select {
+	case event := <-publisher.published:
+		if event.Type != events.TypeVoteCast || event.PollID != data.ExamplePollIDValid {
+			t.Errorf("expected %s event for poll %s, but got %+v", events.TypeVoteCast, data.ExamplePollIDValid, event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expected an event to be published")
+	}
+}
+
+func Test_app_trackEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink, err := analytics.NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.analytics = analytics.NewStream(sink, time.Hour, app.logError)
+	defer func() { app.analytics = nil }()
+
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
+	chiCtx.URLParams.Add("optionID", data.ExampleOptionID1.String())
+
+	req, _ := http.NewRequest(http.MethodGet, "/?source=slack", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	http.HandlerFunc(app.showPollHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Forwarded-For", "5.5.5.6")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	http.HandlerFunc(app.voteOptionHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := app.analytics.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	js, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(js)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a view and a vote event, but got %q", js)
+	}
+	for i, expected := range []string{`"event_name":"view"`, `"event_name":"vote"`} {
+		if !strings.Contains(lines[i], expected) || !strings.Contains(lines[i], data.ExamplePollIDValid.String()) {
+			t.Errorf("expected line %d to contain %s, but got %s", i, expected, lines[i])
+		}
+	}
+}
+
+func Test_app_pollClosed(t *testing.T) {
+	mailer := &mockMailer{}
+	app.mailer = mailer
+	defer func() { app.mailer = nil }()
+
+	app.pollClosed(&data.Poll{ID: data.ExamplePollIDVotingStarted, Question: "Test?", NotifyEmail: "jane@example.com"})
+	app.pollClosed(&data.Poll{ID: data.ExamplePollIDValid, Question: "Test?"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mailer.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sent := mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 email, but got %d", len(sent))
+	}
+	if sent[0].recipient != "jane@example.com" || sent[0].templateFile != "poll_closed.tmpl" {
+		t.Errorf("unexpected email %+v", sent[0])
+	}
+
+	email := sent[0].data.(pollClosedEmail)
+	if email.TotalVotes != 1 || email.Results[0].Percent != 100 {
+		t.Errorf("expected 1 vote at 100%% for the first option, but got %+v", email)
+	}
+}
@@ -1,19 +1,28 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
 	"github.com/ivcp/polls/internal/validator"
 )
 
+// optionInput is the body of requests adding an option or changing it. A
+// description, URL or group left out of a change isn't changed.
+type optionInput struct {
+	Value       string  `json:"value" validate:"required,max=500"`
+	Description *string `json:"description" validate:"max=1000"`
+	URL         *string `json:"url" doc:"absolute http or https URL, e.g. a candidate's bio or a product page"`
+	GroupID     *string `json:"group_id" doc:"ID of one of the poll's groups, or empty for none"`
+}
+
 func (app *application) addOptionHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
 
-	var input struct {
-		Value string `json:"value"`
-	}
+	var input optionInput
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -21,26 +30,73 @@ func (app *application) addOptionHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	input.Value = app.text.Line(input.Value)
+
 	newOption := &data.PollOption{
-		Value:    strings.TrimSpace(input.Value),
+		Value:    input.Value,
 		Position: len(poll.Options),
 	}
+	if input.Description != nil {
+		newOption.Description = app.text.Text(*input.Description)
+	}
+	if input.URL != nil {
+		newOption.URL = strings.TrimSpace(*input.URL)
+	}
+	if input.GroupID != nil {
+		newOption.GroupID = parseGroupID(*input.GroupID)
+	}
 
 	poll.Options = append(poll.Options, newOption)
 
+	moderated, err := app.moderate(&newOption.Value, &newOption.Description)
+	if err != nil {
+		app.contentRejectedResponse(w)
+		return
+	}
+
 	v := validator.New()
 
-	if data.ValidatePoll(v, poll); !v.Valid() {
+	data.ValidatePoll(v, poll)
+	if schema.Validate(v, &input); !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
 	err = app.models.PollOptions.Insert(newOption, poll.ID)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.duplicateOptionResponse(w, newOption)
+		case errors.Is(err, data.ErrTooManyOptions):
+			app.tooManyOptionsResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
 		return
 	}
 
+	changes := map[string]any{
+		"option_id": newOption.ID,
+		"value":     newOption.Value,
+	}
+	if newOption.Description != "" {
+		changes["description"] = newOption.Description
+	}
+	if newOption.URL != "" {
+		changes["url"] = newOption.URL
+	}
+	if newOption.GroupID != nil {
+		changes["group_id"] = newOption.GroupID
+	}
+	app.recordActivity(poll.ID, app.actor(r), data.ActionOptionAdded, changes)
+
+	if moderated.Flagged {
+		if err := app.flagPoll(poll, moderated.Terms); err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
 	err = app.writeJSON(w, http.StatusCreated, envelope{"message": "option added successfully"}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
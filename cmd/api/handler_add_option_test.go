@@ -7,34 +7,110 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"go.uber.org/mock/gomock"
 )
 
 func Test_app_addOptionHandler(t *testing.T) {
+	// inserted expects the option with the value to be inserted into the
+	// valid poll, with the error the repository returns
+	inserted := func(value string, err error) func(m repositoryMocks) {
+		return func(m repositoryMocks) {
+			m.options.EXPECT().
+				Insert(gomock.Cond(func(x any) bool { return x.(*data.PollOption).Value == value }), data.ExamplePollIDValid).
+				DoAndReturn(func(option *data.PollOption, pollID uuid.UUID) error {
+					if err == nil {
+						option.ID = uuid.New()
+					}
+					return err
+				})
+		}
+	}
+
 	tests := []struct {
 		name           string
 		json           string
+		expect         func(m repositoryMocks)
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:           "valid add option",
 			json:           `{"value":"test"}`,
+			expect:         inserted("test", nil),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "option added successfully",
+		},
+		{
+			name:           "with description and url",
+			json:           `{"value":"Jane","description":"Former mayor","url":"https://example.com/jane"}`,
+			expect:         inserted("Jane", nil),
 			expectedStatus: http.StatusCreated,
 			expectedBody:   "option added successfully",
 		},
+		{
+			name:           "invalid url",
+			json:           `{"value":"Jane","url":"example.com/jane"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options":"option url must be an absolute http or https URL"`,
+		},
+		{
+			name:           "in a group",
+			json:           `{"value":"Four","group_id":"` + data.ExampleGroupID.String() + `"}`,
+			expect:         inserted("Four", nil),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "option added successfully",
+		},
+		{
+			name:           "unknown group",
+			json:           `{"value":"Four","group_id":"` + data.ExampleOptionID1.String() + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options":"option group must be one of the poll's groups"`,
+		},
+		{
+			name:           "invalid group id",
+			json:           `{"value":"Four","group_id":"abc"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options":"option group must be one of the poll's groups"`,
+		},
 		{
 			name:           "option already exists",
 			json:           `{"value":"Two"}`,
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   "must not contain duplicate values",
 		},
+		{
+			name:           "option exists in another case",
+			json:           `{"value":" two "}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options.3":"must not duplicate another option's value"`,
+		},
+		{
+			name:           "option added concurrently",
+			json:           `{"value":"Taken"}`,
+			expect:         inserted("Taken", data.ErrDuplicateOption),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options.3":"must not duplicate another option's value"`,
+		},
+		{
+			name:           "options added concurrently over the limit",
+			json:           `{"value":"Over limit"}`,
+			expect:         inserted("Over limit", data.ErrTooManyOptions),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options":"must not contain more than 100 options"`,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			mocks := mockRepositories(t)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
 			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll := examplePoll(t, data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.addOptionHandler)
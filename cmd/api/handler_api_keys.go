@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	admin, _ := app.memberFromContext(r.Context())
+
+	keys, err := app.models.APIKeys.GetAll(admin.OrgID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"api_keys": keys}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// apiKeyInput is the body of POST /v1/orgs/{orgID}/api-keys.
+type apiKeyInput struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+// createAPIKeyHandler creates an API key for the organization, which is only
+// shown in the response.
+func (app *application) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	admin, _ := app.memberFromContext(r.Context())
+
+	var input apiKeyInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+
+	key, err := data.GenerateAPIKey()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	key.OrgID = admin.OrgID
+	key.Name = input.Name
+	key.Role = input.Role
+
+	v := validator.New()
+	data.ValidateAPIKey(v, key)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.APIKeys.Insert(key)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"api_key": key, "key": key.Plaintext}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	admin, _ := app.memberFromContext(r.Context())
+
+	keyID, err := app.readInt64Param(r, "keyID")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.APIKeys.Revoke(admin.OrgID, keyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "api key revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
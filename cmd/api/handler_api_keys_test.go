@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_apiKeyHandlers(t *testing.T) {
+	admin, _ := app.models.Orgs.GetMemberForToken(data.ExampleTokenOrgAdmin)
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		keyID          string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"list keys", app.listAPIKeysHandler, "", "", http.StatusOK, `"prefix":"APIKEYAA"`},
+		{"create key", app.createAPIKeyHandler, "", `{"name":"CRM sync","role":"editor"}`, http.StatusCreated, `"key":"polls_`},
+		{"create admin key", app.createAPIKeyHandler, "", `{"name":"CRM sync","role":"admin"}`, http.StatusUnprocessableEntity, `"role":`},
+		{"create unnamed key", app.createAPIKeyHandler, "", `{"name":" ","role":"viewer"}`, http.StatusUnprocessableEntity, `"name":"must not be empty"`},
+		{"revoke key", app.revokeAPIKeyHandler, "1", "", http.StatusOK, `"message":"api key revoked"`},
+		{"revoke missing key", app.revokeAPIKeyHandler, "99", "", http.StatusNotFound, ""},
+		{"revoke invalid id", app.revokeAPIKeyHandler, "x", "", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", data.ExampleOrgID.String())
+			chiCtx.URLParams.Add("keyID", test.keyID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx)
+			ctx = context.WithValue(ctx, ctxMemberKey, admin)
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+			test.handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/ballots"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// publishedBallot is a ballot in GET /v1/polls/{pollID}/ballots.
+type publishedBallot struct {
+	Ballot   string `json:"ballot"`
+	OptionID string `json:"option_id,omitempty"`
+}
+
+// showBallotsHandler publishes the ballots of a verifiable poll and their
+// Merkle root, so auditors can rebuild the tree. Which option a ballot is
+// for is only published once the poll has expired, so ballots don't reveal
+// results early or of polls below their results threshold.
+func (app *application) showBallotsHandler(w http.ResponseWriter, r *http.Request) {
+	poll, votes, tree, ok := app.readBallots(w, r)
+	if !ok {
+		return
+	}
+
+	withOptions := poll.Expired(time.Now()) && len(votes) >= poll.ResultsThreshold
+
+	published := make([]publishedBallot, len(votes))
+	for i, vote := range votes {
+		published[i].Ballot = vote.Ballot
+		if withOptions {
+			published[i].OptionID = vote.OptionID.String()
+		}
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"root": tree.Root(), "ballots": published}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// showBallotProofHandler returns the path from a ballot to the Merkle root,
+// which voters can check against the published root.
+func (app *application) showBallotProofHandler(w http.ResponseWriter, r *http.Request) {
+	_, _, tree, ok := app.readBallots(w, r)
+	if !ok {
+		return
+	}
+
+	proof, ok := tree.Prove(chi.URLParam(r, "ballot"))
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"proof": proof}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// readBallots loads the poll in the URL and the tree of its ballots. Polls
+// that aren't verifiable are not found. It writes the error response and
+// returns false if they can't be loaded.
+func (app *application) readBallots(w http.ResponseWriter, r *http.Request) (*data.Poll, []*data.Vote, *ballots.Tree, bool) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, nil, nil, false
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, nil, nil, false
+	}
+
+	if app.hidden(r, poll) || !poll.Verifiable {
+		app.notFoundResponse(w, r)
+		return nil, nil, nil, false
+	}
+
+	votes, err := app.models.Votes.GetBallots(poll.ID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return nil, nil, nil, false
+	}
+
+	hashes := make([]string, len(votes))
+	for i, vote := range votes {
+		hashes[i] = vote.Ballot
+	}
+	tree, err := ballots.NewTree(hashes)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return nil, nil, nil, false
+	}
+
+	return poll, votes, tree, true
+}
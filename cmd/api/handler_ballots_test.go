@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/ballots"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_voteOptionHandler_ballot(t *testing.T) {
+	tests := []struct {
+		name       string
+		pollID     uuid.UUID
+		wantBallot bool
+	}{
+		{"verifiable poll", data.ExamplePollIDVerifiable, true},
+		{"poll that isn't verifiable", data.ExamplePollIDValid, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("X-Forwarded-For", "0.0.0.0")
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID.String())
+			chiCtx.URLParams.Add("optionID", data.ExampleOptionID1.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.voteOptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+			}
+
+			var body struct {
+				Ballot *ballots.Ballot `json:"ballot"`
+			}
+			_ = json.Unmarshal(rr.Body.Bytes(), &body)
+
+			if !test.wantBallot {
+				if body.Ballot != nil {
+					t.Errorf("expected no ballot, but got %+v", body.Ballot)
+				}
+				return
+			}
+			if body.Ballot == nil {
+				t.Fatal("expected a ballot")
+			}
+			if body.Ballot.Hash != ballots.Hash(test.pollID.String(), data.ExampleOptionID1.String(), body.Ballot.Nonce) {
+				t.Errorf("expected the ballot to commit to option %s, but got %+v", data.ExampleOptionID1, body.Ballot)
+			}
+		})
+	}
+}
+
+// expectBallots expects the poll to be loaded, and its ballots too if it is
+// verifiable.
+func expectBallots(t *testing.T, m repositoryMocks, pollID uuid.UUID) {
+	t.Helper()
+	poll := examplePoll(t, pollID)
+	m.polls.EXPECT().Get(pollID).Return(poll, nil)
+	if poll.Verifiable {
+		m.votes.EXPECT().GetBallots(pollID).Return([]*data.Vote{
+			{PollID: pollID, OptionID: data.ExampleOptionID1, Ballot: data.ExampleBallot1},
+			{PollID: pollID, OptionID: data.ExampleOptionID2, Ballot: data.ExampleBallot2},
+		}, nil)
+	}
+}
+
+func Test_app_showBallotsHandler(t *testing.T) {
+	tree, _ := ballots.NewTree([]string{data.ExampleBallot1, data.ExampleBallot2})
+
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "open poll",
+			pollID:         data.ExamplePollIDVerifiable,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"ballots":[{"ballot":"` + data.ExampleBallot1 + `"},{"ballot":"` + data.ExampleBallot2 +
+				`"}],"root":"` + tree.Root() + `"}`,
+		},
+		{
+			name:           "expired poll",
+			pollID:         data.ExamplePollIDBallotsClosed,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"ballot":"` + data.ExampleBallot1 + `","option_id":"` + data.ExampleOptionID1.String() + `"}`,
+		},
+		{
+			name:           "poll that isn't verifiable",
+			pollID:         data.ExamplePollIDValid,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "draft",
+			pollID:         data.ExamplePollIDDraft,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectBallots(t, mockRepositories(t), test.pollID)
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showBallotsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("unknown poll", func(t *testing.T) {
+		pollID := uuid.New()
+		mockRepositories(t).polls.EXPECT().Get(pollID).Return(nil, data.ErrRecordNotFound)
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", pollID.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(app.showBallotsHandler)
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func Test_app_showBallotProofHandler(t *testing.T) {
+	tree, _ := ballots.NewTree([]string{data.ExampleBallot1, data.ExampleBallot2})
+
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		ballot         string
+		expectedStatus int
+	}{
+		{"ballot in the tree", data.ExamplePollIDVerifiable, data.ExampleBallot2, http.StatusOK},
+		{"ballot not in the tree", data.ExamplePollIDVerifiable, ballots.Hash(data.ExamplePollIDVerifiable.String(), data.ExampleOptionID1.String(), "00"), http.StatusNotFound},
+		{"poll that isn't verifiable", data.ExamplePollIDValid, data.ExampleBallot2, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectBallots(t, mockRepositories(t), test.pollID)
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID.String())
+			chiCtx.URLParams.Add("ballot", test.ballot)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showBallotProofHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Proof *ballots.Proof `json:"proof"`
+			}
+			_ = json.Unmarshal(rr.Body.Bytes(), &body)
+			if body.Proof == nil || body.Proof.Root != tree.Root() || !ballots.Verify(body.Proof) {
+				t.Errorf("expected a valid proof for root %s, but got %+v", tree.Root(), body.Proof)
+			}
+		})
+	}
+}
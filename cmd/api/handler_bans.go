@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listBansHandler(w http.ResponseWriter, r *http.Request) {
+	bans, err := app.models.Bans.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"bans": bans}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// createBanInput is the body of POST /v1/admin/bans.
+type createBanInput struct {
+	IP     string `json:"ip" validate:"ip" doc:"IP to ban, unless api_key is set"`
+	APIKey string `json:"api_key" doc:"API key to ban, 26 bytes long"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// createBanHandler bans an IP or an API key from creating polls.
+func (app *application) createBanHandler(w http.ResponseWriter, r *http.Request) {
+	var input createBanInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Reason = app.text.Line(input.Reason)
+
+	ban := &data.Ban{IP: input.IP, Reason: input.Reason}
+
+	v := validator.New()
+	data.ValidateBan(v, ban, input.APIKey)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if input.APIKey != "" {
+		ban.KeyHash = data.HashToken(input.APIKey)
+	}
+
+	err = app.models.Bans.Insert(ban)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAlreadyBanned):
+			field := "ip"
+			if ban.IP == "" {
+				field = "api_key"
+			}
+			v.AddError(field, "is already banned")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"ban": ban}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deleteBanHandler(w http.ResponseWriter, r *http.Request) {
+	banID, err := app.readInt64Param(r, "banID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Bans.Delete(banID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "ban lifted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
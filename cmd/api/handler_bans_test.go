@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listBansHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listBansHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	expectedBody := `"kind":"ip","ip":"` + data.ExampleBannedIP + `"`
+	if !strings.Contains(rr.Body.String(), expectedBody) {
+		t.Errorf("expected body to contain %q, but got %q", expectedBody, rr.Body)
+	}
+}
+
+func Test_app_createBanHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "ban ip",
+			json:           `{"ip":"198.51.100.4","reason":"spam"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"kind":"ip","ip":"198.51.100.4","reason":"spam"`,
+		},
+		{
+			name:           "ban api key",
+			json:           `{"api_key":"` + data.ExampleTokenOrgEditor + `"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"kind":"api_key"`,
+		},
+		{
+			name:           "nothing to ban",
+			json:           `{"reason":"spam"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ip":"must be provided unless api_key is"`,
+		},
+		{
+			name:           "ip and api key",
+			json:           `{"ip":"198.51.100.4","api_key":"` + data.ExampleTokenOrgEditor + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"api_key":"must not be set together with ip"`,
+		},
+		{
+			name:           "invalid ip",
+			json:           `{"ip":"198.51.100"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ip":"must be a valid IP address"`,
+		},
+		{
+			name:           "invalid api key",
+			json:           `{"api_key":"short"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"api_key":"must be 26 bytes long"`,
+		},
+		{
+			name:           "already banned",
+			json:           `{"ip":"` + data.ExampleBannedIP + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ip":"is already banned"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createBanHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_deleteBanHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		banID          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"lift ban", "1", http.StatusOK, "ban lifted"},
+		{"unknown ban", "2", http.StatusNotFound, "the requested resource could not be found"},
+		{"invalid id", "abc", http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("banID", test.banID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.deleteBanHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -1,26 +1,73 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/moderation"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/spam"
 	"github.com/ivcp/polls/internal/validator"
 )
 
+// createPollInput is the body of POST /v1/polls.
+type createPollInput struct {
+	Question          string                     `json:"question" validate:"required,max=500"`
+	QuestionVariants  []string                   `json:"question_variants" doc:"up to 5 other wordings of the question, one of which voters are shown at random instead"`
+	Description       string                     `json:"description" validate:"max=1000"`
+	Options           []pollOptionInput          `json:"options" validate:"required" doc:"at least two options with unique values and positions"`
+	Groups            []optionGroupInput         `json:"groups" doc:"up to 20 labeled sections the options are listed under"`
+	ExpiresAt         data.ExpiresAt             `json:"expires_at"`
+	ExpiresIn         string                     `json:"expires_in" doc:"duration such as 2h or 7d, instead of expires_at"`
+	ResultsVisibility string                     `json:"results_visibility" validate:"oneof=always after_vote after_deadline"`
+	ResultsThreshold  int                        `json:"results_threshold" validate:"min=0,max=1000" doc:"votes needed before results are shown"`
+	MaxVotes          int                        `json:"max_votes" validate:"min=0" doc:"votes after which the poll closes, unlimited if 0"`
+	TieBreak          string                     `json:"tie_break" validate:"oneof=shared earliest random"`
+	Visibility        string                     `json:"visibility" validate:"oneof=public unlisted private"`
+	IsPrivate         *bool                      `json:"is_private" doc:"deprecated, use visibility; true is unlisted and false public"`
+	IsDraft           bool                       `json:"is_draft"`
+	ShuffleOptions    bool                       `json:"shuffle_options" doc:"show voters the options in a random order, the same for each voter"`
+	Verifiable        bool                       `json:"verifiable" doc:"give voters ballots and publish their Merkle root"`
+	ConfirmVotes      bool                       `json:"confirm_votes" doc:"count votes only once voters confirm them with a link emailed to them"`
+	Anonymity         string                     `json:"anonymity" validate:"oneof=anonymous names_visible_to_owner public"`
+	AllowedCountries  []string                   `json:"allowed_countries" doc:"ISO 3166-1 alpha-2 country codes"`
+	DeniedCountries   []string                   `json:"denied_countries" doc:"ISO 3166-1 alpha-2 country codes"`
+	Tags              []string                   `json:"tags" doc:"up to 10 tags of lowercase letters, digits and hyphens"`
+	Metadata          map[string]string          `json:"metadata" doc:"up to 20 keys of letters, digits, hyphens and underscores, with string values"`
+	ExternalID        string                     `json:"external_id" doc:"ID of the poll in the client's own system; creating a poll again with it updates the poll instead"`
+	Language          string                     `json:"language" validate:"oneof=de en es fr it nl pt" doc:"detected from the text if left out"`
+	NotifyEmail       string                     `json:"notify_email" validate:"email"`
+	PreviousPollID    string                     `json:"previous_poll_id" doc:"poll whose series the poll joins"`
+	Demographics      []data.DemographicQuestion `json:"demographics"`
+}
+
+// pollOptionInput is an option of a poll being created. Options are
+// validated as a whole by data.ValidatePoll.
+type pollOptionInput struct {
+	Value       string `json:"value" doc:"at most 500 bytes long"`
+	Position    int    `json:"position" doc:"from 0 to the number of options - 1"`
+	Description string `json:"description" doc:"at most 1000 bytes long"`
+	URL         string `json:"url" doc:"absolute http or https URL, e.g. a candidate's bio or a product page"`
+	Group       *int   `json:"group" doc:"position of the group the option is listed under"`
+}
+
+// optionGroupInput is a group of options of a poll being created.
+type optionGroupInput struct {
+	Label    string `json:"label" doc:"at most 100 bytes long"`
+	Position int    `json:"position" doc:"from 0 to the number of groups - 1"`
+}
+
 func (app *application) createPollHandler(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Question    string `json:"question"`
-		Description string `json:"description"`
-		Options     []struct {
-			Value    string `json:"value"`
-			Position int    `json:"position"`
-		} `json:"options"`
-		ExpiresAt         data.ExpiresAt `json:"expires_at"`
-		ResultsVisibility string         `json:"results_visibility"`
-		IsPrivate         bool           `json:"is_private"`
-	}
+	var input createPollInput
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -28,33 +75,234 @@ func (app *application) createPollHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	v := validator.New()
+
+	if input.ExpiresIn != "" {
+		v.Check(input.ExpiresAt.IsZero(), "expires_in", "must not be set together with expires_at")
+		input.ExpiresAt = app.expiresIn(v, input.ExpiresIn)
+	}
+
+	// a poll that follows up on a previous one joins its series, if the
+	// request is allowed to edit the previous poll
+	var seriesID *uuid.UUID
+	if input.PreviousPollID != "" {
+		previous, err := app.previousPoll(input.PreviousPollID)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("previous_poll_id", "must be an existing poll")
+		case err != nil:
+			app.serverErrorResponse(w, err)
+			return
+		case !app.can(r, previous.ID, auth.EditPoll):
+			app.invalidTokenResponse(w)
+			return
+		case previous.SeriesID != nil:
+			seriesID = previous.SeriesID
+		default:
+			seriesID = &previous.ID
+		}
+	}
+
+	input.Question = app.text.Line(input.Question)
+	for i, variant := range input.QuestionVariants {
+		input.QuestionVariants[i] = app.text.Line(variant)
+	}
+	input.Description = app.text.Text(input.Description)
+	input.NotifyEmail = strings.TrimSpace(input.NotifyEmail)
+
+	// groups get temporary IDs, by their position, until they are inserted
+	// and get their own
+	groups := []*data.OptionGroup{}
+	groupIDs := make(map[int]uuid.UUID, len(input.Groups))
+	for _, group := range input.Groups {
+		if _, ok := groupIDs[group.Position]; !ok {
+			groupIDs[group.Position] = uuid.New()
+		}
+		groups = append(groups, &data.OptionGroup{
+			ID:       groupIDs[group.Position],
+			Label:    app.text.Line(group.Label),
+			Position: group.Position,
+		})
+	}
+
 	options := []*data.PollOption{}
 	for _, option := range input.Options {
-		options = append(
-			options,
-			&data.PollOption{Value: strings.TrimSpace(option.Value), Position: option.Position},
-		)
+		o := &data.PollOption{
+			Value:       app.text.Line(option.Value),
+			Position:    option.Position,
+			Description: app.text.Text(option.Description),
+			URL:         strings.TrimSpace(option.URL),
+		}
+		if option.Group != nil {
+			// an option in a group that doesn't exist gets an ID no group
+			// has, so that validation rejects it
+			groupID, ok := groupIDs[*option.Group]
+			if !ok {
+				groupID = uuid.New()
+			}
+			o.GroupID = &groupID
+		}
+		options = append(options, o)
+	}
+
+	for i, question := range input.Demographics {
+		input.Demographics[i].Key = strings.TrimSpace(question.Key)
+		input.Demographics[i].Label = strings.TrimSpace(question.Label)
+		for j, choice := range question.Choices {
+			question.Choices[j] = strings.TrimSpace(choice)
+		}
 	}
 
 	if input.ResultsVisibility == "" {
 		input.ResultsVisibility = "always"
 	}
 
+	if input.TieBreak == "" {
+		input.TieBreak = "shared"
+	}
+
+	if input.Anonymity == "" {
+		input.Anonymity = "anonymous"
+	}
+
+	// is_private is still accepted from clients written before visibility
+	// replaced it. Private polls were only left out of listings, as unlisted
+	// polls are now, so it only contradicts whether visibility is public.
+	if input.IsPrivate != nil {
+		if input.Visibility == "" {
+			input.Visibility = data.VisibilityPublic
+			if *input.IsPrivate {
+				input.Visibility = data.VisibilityUnlisted
+			}
+		}
+		v.Check(*input.IsPrivate == (input.Visibility != data.VisibilityPublic), "is_private", "must not contradict visibility")
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = data.VisibilityPublic
+	}
+
 	poll := &data.Poll{
-		Question:          strings.TrimSpace(input.Question),
-		Description:       strings.TrimSpace(input.Description),
+		Question:          input.Question,
+		QuestionVariants:  input.QuestionVariants,
+		Description:       input.Description,
 		Options:           options,
+		Groups:            groups,
 		ExpiresAt:         input.ExpiresAt,
 		ResultsVisibility: input.ResultsVisibility,
-		IsPrivate:         input.IsPrivate,
+		ResultsThreshold:  input.ResultsThreshold,
+		MaxVotes:          input.MaxVotes,
+		TieBreak:          input.TieBreak,
+		Visibility:        input.Visibility,
+		IsDraft:           input.IsDraft,
+		ShuffleOptions:    input.ShuffleOptions,
+		Verifiable:        input.Verifiable,
+		ConfirmVotes:      input.ConfirmVotes,
+		Anonymity:         input.Anonymity,
+		AllowedCountries:  upperAll(input.AllowedCountries),
+		DeniedCountries:   upperAll(input.DeniedCountries),
+		Tags:              lowerAll(input.Tags),
+		Metadata:          input.Metadata,
+		ExternalID:        strings.TrimSpace(input.ExternalID),
+		Language:          input.Language,
+		NotifyEmail:       input.NotifyEmail,
+		SeriesID:          seriesID,
+		Demographics:      input.Demographics,
 	}
 
-	v := validator.New()
-	if data.ValidatePoll(v, poll); !v.Valid() {
+	if member, ok := app.memberFromContext(r.Context()); ok {
+		poll.OrgID = &member.OrgID
+	}
+
+	if poll.Language == "" {
+		poll.Language = pollLanguage(poll)
+	}
+
+	// content is moderated before it is validated, as masking can make
+	// options equal
+	texts := []*string{&poll.Question, &poll.Description}
+	for i := range poll.QuestionVariants {
+		texts = append(texts, &poll.QuestionVariants[i])
+	}
+	for _, option := range poll.Options {
+		texts = append(texts, &option.Value, &option.Description)
+	}
+	for _, group := range poll.Groups {
+		texts = append(texts, &group.Label)
+	}
+	moderated, err := app.moderate(texts...)
+	if err != nil {
+		app.contentRejectedResponse(w)
+		return
+	}
+	if moderated.Flagged {
+		poll.ModerationStatus = data.ModerationFlagged
+		poll.ModerationTerms = moderated.Terms
+	}
+
+	v.Check(
+		app.geoip != nil || !poll.GeoRestricted(),
+		"allowed_countries",
+		"country restrictions are not supported by this server",
+	)
+	v.Check(
+		app.mailer != nil || poll.NotifyEmail == "",
+		"notify_email",
+		"email notifications are not supported by this server",
+	)
+	v.Check(
+		app.mailer != nil || !poll.ConfirmVotes,
+		"confirm_votes",
+		"email confirmation is not supported by this server",
+	)
+	data.ValidatePoll(v, poll)
+	if schema.Validate(v, &input); !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
+	// a poll created again with the same external ID, e.g. when a client
+	// retries, updates the existing poll instead of duplicating it
+	if poll.ExternalID != "" {
+		var orgID uuid.UUID
+		if poll.OrgID != nil {
+			orgID = *poll.OrgID
+		}
+		id, err := app.pollIDByExternalID(r, orgID, poll.ExternalID)
+		switch {
+		case err == nil:
+			app.upsertPoll(w, r, id, &input, poll, moderated)
+			return
+		case !errors.Is(err, data.ErrRecordNotFound):
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
+	headers := make(http.Header)
+
+	// organizations can have a limited number of active polls, which the
+	// database keeps when the poll is inserted. They are counted here for the
+	// quota headers, and to refuse polls over it early.
+	if limit := app.config.quotas.activePolls; poll.OrgID != nil && limit > 0 {
+		poll.ActiveQuota = limit
+
+		active, err := app.models.Usage.ActivePolls(*poll.OrgID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		if active >= limit {
+			app.quotaExceededResponse(w, "active polls")
+			return
+		}
+		setQuotaHeaders(headers, limit, active+1)
+	}
+
+	if !app.allowPollCreation(w, r, poll) {
+		return
+	}
+
 	token, err := data.GenerateToken()
 	if err != nil {
 		app.serverErrorResponse(w, err)
@@ -63,16 +311,238 @@ func (app *application) createPollHandler(w http.ResponseWriter, r *http.Request
 	poll.Token = token.Plaintext
 
 	err = app.models.Polls.Insert(poll, token.Hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateExtID):
+			app.editConflictResponse(w)
+		case errors.Is(err, data.ErrCreationLimited):
+			app.creationLimitedResponse(w)
+		case errors.Is(err, data.ErrQuotaExceeded):
+			app.quotaExceededResponse(w, "active polls")
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.publishEvent(events.PollCreated(poll))
+	app.recordActivity(poll.ID, app.actor(r), data.ActionPollCreated, nil)
+	if moderated.Flagged {
+		app.recordActivity(poll.ID, data.ActorModerator, data.ActionPollFlagged, map[string]any{
+			"terms": moderated.Terms,
+		})
+	}
+
+	headers.Set("Location", fmt.Sprintf("/v1/polls/%s", poll.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"poll": poll}, headers)
 	if err != nil {
 		app.serverErrorResponse(w, err)
+	}
+}
+
+// upsertPoll updates the poll with the external ID of a poll being created
+// again. Only the fields PATCH /v1/polls/{pollID} can change are updated, and
+// the poll is returned unchanged if none of them differ, so that retries are
+// idempotent. A relative expiry time is left out of the comparison, as it
+// ends at a different time on each retry.
+func (app *application) upsertPoll(
+	w http.ResponseWriter,
+	r *http.Request,
+	id uuid.UUID,
+	input *createPollInput,
+	update *data.Poll,
+	moderated moderation.Result,
+) {
+	if !app.can(r, id, auth.EditPoll) {
+		app.invalidTokenResponse(w)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
 		return
 	}
 
+	changes := map[string]any{}
+	if update.Question != poll.Question {
+		changes["question"] = update.Question
+	}
+	if update.Description != poll.Description {
+		changes["description"] = update.Description
+	}
+	if input.ExpiresIn == "" && !update.ExpiresAt.IsZero() && !update.ExpiresAt.Time.Equal(poll.ExpiresAt.Time) {
+		changes["expires_at"] = update.ExpiresAt
+	}
+	if !slices.Equal(update.Tags, poll.Tags) {
+		changes["tags"] = update.Tags
+	}
+	if !maps.Equal(update.Metadata, poll.Metadata) {
+		changes["metadata"] = update.Metadata
+	}
+	// like on PATCH, the language is detected again only if the text changes
+	_, textChanged := changes["question"]
+	if _, ok := changes["description"]; ok {
+		textChanged = true
+	}
+	if (input.Language != "" || textChanged) && update.Language != poll.Language {
+		changes["language"] = update.Language
+	}
+
+	if len(changes) > 0 {
+		if poll.Expired(time.Now()) {
+			app.pollExpiredResponse(w)
+			return
+		}
+
+		started, err := app.votingStarted(poll.ID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		if started {
+			app.cannotEditResponse(w)
+			return
+		}
+
+		poll.Question = update.Question
+		poll.Description = update.Description
+		if _, ok := changes["expires_at"]; ok {
+			poll.ExpiresAt = update.ExpiresAt
+		}
+		poll.Tags = update.Tags
+		poll.Metadata = update.Metadata
+		if _, ok := changes["language"]; ok {
+			poll.Language = update.Language
+		}
+
+		err = app.models.Polls.Update(poll)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w)
+			default:
+				app.serverErrorResponse(w, err)
+			}
+			return
+		}
+
+		app.recordActivity(poll.ID, app.actor(r), data.ActionPollUpdated, changes)
+
+		// options aren't updated, so the poll is only flagged if its text
+		// changed
+		if moderated.Flagged && textChanged {
+			if err := app.flagPoll(poll, moderated.Terms); err != nil {
+				app.serverErrorResponse(w, err)
+				return
+			}
+		}
+	}
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/polls/%s", poll.ID))
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"poll": poll}, headers)
+	err = app.writeJSON(w, http.StatusOK, envelope{"poll": poll}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// expiresIn validates a relative expiry time, e.g. "2h" or "7d", and returns
+// the time it ends at.
+func (app *application) expiresIn(v *validator.Validator, s string) data.ExpiresAt {
+	d, err := data.ParseDuration(s)
+	if err != nil {
+		v.AddError("expires_in", "must be a duration such as 2h or 7d")
+		return data.ExpiresAt{}
+	}
+
+	v.Check(d >= 2*time.Minute, "expires_in", "must be at least 2m")
+	if limit := app.config.maxExpiresIn; limit > 0 {
+		v.Check(d <= limit, "expires_in", fmt.Sprintf("must not be more than %s", formatDays(limit)))
+	}
+	if _, invalid := v.Errors["expires_in"]; invalid {
+		return data.ExpiresAt{}
+	}
+
+	return data.ExpiresAt{Time: time.Now().Add(d).UTC().Truncate(time.Second)}
+}
+
+// formatDays formats whole days as e.g. "90d" and other durations like
+// time.Duration.
+func formatDays(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// previousPoll looks up the poll a new poll follows up on.
+func (app *application) previousPoll(param string) (*data.Poll, error) {
+	id, err := uuid.Parse(param)
+	if err != nil {
+		return nil, data.ErrRecordNotFound
+	}
+	return app.models.Polls.Get(id)
+}
+
+// showFormTokenHandler gives forms creating polls a token signing when they
+// were shown, for POST /v1/polls to tell whether they were filled in too fast.
+// The endpoint is not found when -bot-min-fill-time isn't set.
+func (app *application) showFormTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if app.config.bots.minFillTime <= 0 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	token := spam.FormToken(app.config.bots.formKey, time.Now())
+	err := app.writeJSON(w, http.StatusOK, envelope{"form_token": token}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// allowPollCreation counts a poll created by the request against the daily
+// limit of its API key or organization member's token, or else of its IP,
+// and reports whether it is within it. Otherwise it responds with
+// CREATION_LIMITED and when to retry. Requests made with the admin token
+// aren't limited. The poll records what it was counted for, so the database
+// refuses it if it wasn't.
+func (app *application) allowPollCreation(w http.ResponseWriter, r *http.Request, poll *data.Poll) bool {
+	limit := app.config.quotas.dailyPolls
+	if limit <= 0 || app.isAdmin(r) {
+		return true
+	}
+
+	// only members' tokens are checked, so any other token counts as the IP
+	var keyHash []byte
+	if key, ok := app.apiKeyFromContext(r.Context()); ok {
+		keyHash = key.Hash
+	} else if _, ok := app.memberFromContext(r.Context()); ok {
+		if token, ok := app.readBearerToken(r); ok {
+			keyHash = data.HashToken(token)
+		}
+	}
+
+	ip, _ := clientIP(r)
+	allowed, err := app.models.Creations.Record(ip, keyHash, limit)
 	if err != nil {
 		app.serverErrorResponse(w, err)
+		return false
 	}
+
+	if !allowed {
+		app.creationLimitedResponse(w)
+		return false
+	}
+
+	poll.CreatedBy = data.CreationSubject(ip, keyHash)
+	return true
 }
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/moderation"
 )
 
 func Test_app_createPollHandler(t *testing.T) {
@@ -14,13 +19,12 @@ func Test_app_createPollHandler(t *testing.T) {
 	expiresInvalid := time.Now().Format(time.RFC3339)
 	questionInvalid := strings.Repeat("a", 501)
 	descriptionInvalid := strings.Repeat("a", 1001)
+	var tooManyOptions []string
+	for i := 0; i <= data.MaxOptions; i++ {
+		tooManyOptions = append(tooManyOptions, fmt.Sprintf(`{"value":"option %d","position":%d}`, i, i))
+	}
 
-	tests := []struct {
-		name           string
-		json           string
-		expectedStatus int
-		expectedBody   string
-	}{
+	tests := []createPollTest{
 		{
 			name: "epmty question",
 			json: `{
@@ -28,7 +32,25 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":0},{"value":"second","position":1}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"question":"must not be empty"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"question":"must not be empty"}}`,
+		},
+		{
+			name: "question of whitespace and control characters",
+			json: `{
+				"question":" \t\n\u0000\u200b ",
+				"options":[{"value":"first","position":0},{"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"question":"must not be empty"}}`,
+		},
+		{
+			name: "options only differing in whitespace",
+			json: `{
+				"question":"Test?",
+				"options":[{"value":"first  option","position":0},{"value":"first\u200b option\n","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options.1":"must not duplicate another option's value"`,
 		},
 		{
 			name: "question too long",
@@ -40,7 +62,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				questionInvalid,
 			),
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"question":"must not be more than 500 bytes long"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"question":"must not be more than 500 bytes long"}}`,
 		},
 		{
 			name: "description too long",
@@ -52,7 +74,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				descriptionInvalid,
 			),
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"description":"must not be more than 1000 bytes long"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"description":"must not be more than 1000 bytes long"}}`,
 		},
 		{
 			name: "expires_at invalid",
@@ -65,7 +87,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				expiresInvalid,
 			),
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"expires_at":"must be more than a minute in the future"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"expires_at":"must be more than a minute in the future"}}`,
 		},
 		{
 			name: "only one option provided",
@@ -74,7 +96,13 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":0}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"must contain at least two options"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"must contain at least two options"}}`,
+		},
+		{
+			name:           "too many options",
+			json:           `{"question":"Test?","options":[` + strings.Join(tooManyOptions, ",") + `]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"must not contain more than 100 options"}}`,
 		},
 		{
 			name: "duplicate options",
@@ -83,7 +111,16 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":0},{"value":"first","position":1}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"must not contain duplicate values"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"must not contain duplicate values","options.1":"must not duplicate another option's value"}}`,
+		},
+		{
+			name: "duplicate options differing in case",
+			json: `{
+				"question":"Test?", 
+				"options":[{"value":"Yes","position":1},{"value":"No","position":0},{"value":" yes ","position":2}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"must not contain duplicate values","options.2":"must not duplicate another option's value"}}`,
 		},
 		{
 			name: "duplicate option positions",
@@ -92,7 +129,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":0}, {"value":"second","position":0}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"positions must be unique"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"positions must be unique"}}`,
 		},
 		{
 			name: "invalid option positions",
@@ -101,7 +138,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":2}, {"value":"second","position":0}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"position must not excede the number of options"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"position must not excede the number of options"}}`,
 		},
 		{
 			name: "invalid option positions",
@@ -110,7 +147,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":-1}, {"value":"second","position":0}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"position must be greater or equal to 0"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"position must be greater or equal to 0"}}`,
 		},
 		{
 			name: "invalid empty option",
@@ -119,7 +156,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":" ","position":0}, {"value":"second","position":1}]
 				}`,
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"option values must not be empty"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"option values must not be empty"}}`,
 		},
 		{
 			name: "option value too large",
@@ -131,7 +168,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				questionInvalid,
 			),
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"options":"option value must not be more than 500 bytes long"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"option value must not be more than 500 bytes long"}}`,
 		},
 		{
 			name: "invalid json field type",
@@ -140,7 +177,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				"options":[{"value":"first","position":0}]
 				}`,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"body contains incorrect JSON type for field \"question\""}`,
+			expectedBody:   `{"code":"BAD_REQUEST","error":"body contains incorrect JSON type for field \"question\""}`,
 		},
 		{
 			name: "insert poll valid",
@@ -167,7 +204,7 @@ func Test_app_createPollHandler(t *testing.T) {
 				expiresValid,
 			),
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"error":{"results_visibility":"invalid results_visibility value"}}`,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"results_visibility":"invalid results_visibility value"}}`,
 		},
 		{
 			name: "valid results_visibility",
@@ -183,25 +220,1018 @@ func Test_app_createPollHandler(t *testing.T) {
 			expectedStatus: http.StatusCreated,
 			expectedBody:   `"question":"Test?"`,
 		},
+		{
+			name: "invalid anonymity",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"anonymity": "test"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"anonymity":"invalid anonymity value"}}`,
+		},
+		{
+			name: "negative results_threshold",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"results_threshold": -1
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"results_threshold":"must not be negative"}}`,
+		},
+		{
+			name: "valid results_threshold",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"results_threshold": 5
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"results_threshold":5`,
+		},
+		{
+			name: "negative max_votes",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"max_votes": -1
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"max_votes":"must not be negative"}}`,
+		},
+		{
+			name: "valid max_votes",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"max_votes": 500
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"max_votes":500`,
+		},
+		{
+			name: "invalid tie_break",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"tie_break": "coin flip"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"tie_break":"invalid tie_break value"}}`,
+		},
+		{
+			name: "default tie_break",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"tie_break":"shared"`,
+		},
+		{
+			name: "unlisted",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"visibility": "unlisted"
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"visibility":"unlisted"`,
+		},
+		{
+			name: "invalid visibility",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"visibility": "secret"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"visibility":"invalid visibility value"}}`,
+		},
+		{
+			name: "deprecated is_private",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"is_private": true
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"visibility":"unlisted"`,
+		},
+		{
+			name: "is_private agreeing with visibility",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"is_private": true,
+					"visibility": "private"
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"visibility":"private"`,
+		},
+		{
+			name: "is_private contradicting visibility",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"is_private": false,
+					"visibility": "unlisted"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"is_private":"must not contradict visibility"}}`,
+		},
+		{
+			name: "default visibility",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"visibility":"public"`,
+		},
+		{
+			name: "default anonymity",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"anonymity":"anonymous"`,
+		},
 	}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
-			rr := httptest.NewRecorder()
-			handler := http.HandlerFunc(app.createPollHandler)
-			handler.ServeHTTP(rr, req)
-			if rr.Code != test.expectedStatus {
-				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
-			}
-			if !strings.Contains(rr.Body.String(), test.expectedBody) {
-				t.Errorf("expected body %q, but got %q", test.expectedBody, rr.Body)
-			}
-			if rr.Code == http.StatusCreated && !strings.Contains(
-				rr.Header().Get("Location"),
-				"/v1/polls/",
-			) {
-				t.Errorf("Location does not contain link to created poll")
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_countries(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "country restrictions without geoip provider",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"allowed_countries": ["DE"]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"allowed_countries":"country restrictions are not supported by this server"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+
+	app.geoip = mockGeoIP{}
+	defer func() { app.geoip = nil }()
+
+	tests = []createPollTest{
+		{
+			name: "valid allowed countries",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"allowed_countries": ["de", "AT"]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"allowed_countries":["DE","AT"]`,
+		},
+		{
+			name: "invalid country code",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"denied_countries": ["Germany"]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"denied_countries":"must contain ISO 3166-1 alpha-2 country codes"}}`,
+		},
+		{
+			name: "allowed and denied countries",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"allowed_countries": ["DE"],
+					"denied_countries": ["AT"]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"allowed_countries":"must not be set together with denied_countries"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_tags(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "valid tags",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"tags": [" Work ", "team-2"]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"tags":["work","team-2"]`,
+		},
+		{
+			name: "invalid tag",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"tags": ["team work"]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"tags":"tag must only contain lowercase letters, digits and hyphens"}}`,
+		},
+		{
+			name: "duplicate tags",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"tags": ["work", "WORK"]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"tags":"must not contain duplicate values"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_metadata(t *testing.T) {
+	tooMany := make([]string, data.MaxMetadataKeys+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`"key%d":"value"`, i)
+	}
+
+	tests := []createPollTest{
+		{
+			name: "valid metadata",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"metadata": {"team": "platform", "ticket_id": "OPS-1"}
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"metadata":{"team":"platform","ticket_id":"OPS-1"}`,
+		},
+		{
+			name: "invalid key",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"metadata": {"team name": "platform"}
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"metadata":"key must only contain letters, digits, hyphens and underscores"}}`,
+		},
+		{
+			name: "too many keys",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"metadata": {` + strings.Join(tooMany, ",") + `}
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"metadata":"must not contain more than 20 keys"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_externalID(t *testing.T) {
+	newPoll := func(question string, externalID string) string {
+		return fmt.Sprintf(`{
+					"question":%q, 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"external_id":%q
+					}`, question, externalID)
+	}
+
+	tests := []createPollTest{
+		{
+			name:           "new external id",
+			json:           newPoll("Test?", "crm-43"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"external_id":"crm-43"`,
+		},
+		{
+			name:           "retried",
+			json:           newPoll("Test?", data.ExampleExternalID),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusOK,
+			expectedBody:   fmt.Sprintf(`"id":%q,"question":"Test?"`, data.ExamplePollIDValid),
+		},
+		{
+			name:           "changed",
+			json:           newPoll("Test, again?", data.ExampleExternalID),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusOK,
+			expectedBody:   fmt.Sprintf(`"id":%q,"question":"Test, again?"`, data.ExamplePollIDValid),
+		},
+		{
+			name:           "existing without token",
+			json:           newPoll("Test?", data.ExampleExternalID),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"external_id":"` + data.ExampleExternalID + `"`,
+		},
+		{
+			name:           "existing with another poll's token",
+			json:           newPoll("Test?", data.ExampleExternalID),
+			authHeader:     "Bearer " + data.ExampleTokenShuffled,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"external_id":"` + data.ExampleExternalID + `"`,
+		},
+		{
+			name:           "created at the same time",
+			json:           newPoll("Test?", data.ExampleExternalIDTaken),
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"code":"EDIT_CONFLICT"`,
+		},
+		{
+			name:           "invalid",
+			json:           newPoll("Test?", "crm/42"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"external_id":"must only contain letters, digits, periods, colons, hyphens and underscores"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_optionDetails(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "description and url",
+			json: `{
+					"question":"Mayor?", 
+					"options":[
+						{"value":"Jane","position":0,"description":"Former mayor","url":"https://example.com/jane"},
+						{"value":"John","position":1}
+					]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"value":"Jane","position":0,"description":"Former mayor","url":"https://example.com/jane"},{"id":`,
+		},
+		{
+			name: "description too long",
+			json: `{
+					"question":"Mayor?", 
+					"options":[{"value":"Jane","position":0,"description":"` + strings.Repeat("a", 1001) + `"}, {"value":"John","position":1}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"option description must not be more than 1000 bytes long"}}`,
+		},
+		{
+			name: "relative url",
+			json: `{
+					"question":"Mayor?", 
+					"options":[{"value":"Jane","position":0,"url":"/jane"}, {"value":"John","position":1}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"option url must be an absolute http or https URL"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_questionVariants(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "variants",
+			json: `{
+					"question":"Should taxes be raised?", 
+					"question_variants":["Should  taxes be raised to fund schools? "],
+					"options":[{"value":"Yes","position":0}, {"value":"No","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question_variants":["Should taxes be raised to fund schools?"]`,
+		},
+		{
+			name: "variant like the question",
+			json: `{
+					"question":"Should taxes be raised?", 
+					"question_variants":["should taxes be raised?"],
+					"options":[{"value":"Yes","position":0}, {"value":"No","position":1}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"question_variants":"must differ from the question and each other"}}`,
+		},
+		{
+			name: "too many variants",
+			json: `{
+					"question":"Q?", 
+					"question_variants":["A?", "B?", "C?", "D?", "E?", "F?"],
+					"options":[{"value":"Yes","position":0}, {"value":"No","position":1}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"question_variants":"must not contain more than 5 variants"}}`,
+		},
+		{
+			name: "empty variant",
+			json: `{
+					"question":"Q?", 
+					"question_variants":[" "],
+					"options":[{"value":"Yes","position":0}, {"value":"No","position":1}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"question_variants":"variants must not be empty"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_shuffleOptions(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "shuffled",
+			json: `{
+					"question":"Mayor?", 
+					"options":[{"value":"Jane","position":0}, {"value":"John","position":1}],
+					"shuffle_options":true
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"shuffle_options":true`,
+		},
+		{
+			name: "in order by default",
+			json: `{
+					"question":"Mayor?", 
+					"options":[{"value":"Jane","position":0}, {"value":"John","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"shuffle_options":false`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_verifiable(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "verifiable",
+			json: `{
+					"question":"Chair?", 
+					"options":[{"value":"Jane","position":0}, {"value":"John","position":1}],
+					"verifiable":true
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"verifiable":true`,
+		},
+		{
+			name: "not verifiable by default",
+			json: `{
+					"question":"Chair?", 
+					"options":[{"value":"Jane","position":0}, {"value":"John","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"verifiable":false`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_optionGroups(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "grouped options",
+			json: `{
+					"question":"Dinner?", 
+					"options":[
+						{"value":"Soup","position":0,"group":0},
+						{"value":"Steak","position":1,"group":1},
+						{"value":"Water","position":2}
+					],
+					"groups":[{"label":"Appetizers","position":0}, {"label":"Mains","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"label":"Mains","position":1}]`,
+		},
+		{
+			name: "unknown group",
+			json: `{
+					"question":"Dinner?", 
+					"options":[{"value":"Soup","position":0,"group":1}, {"value":"Steak","position":1}],
+					"groups":[{"label":"Appetizers","position":0}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"options":"option group must be one of the poll's groups"}}`,
+		},
+		{
+			name: "empty label",
+			json: `{
+					"question":"Dinner?", 
+					"options":[{"value":"Soup","position":0}, {"value":"Steak","position":1}],
+					"groups":[{"label":" ","position":0}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"groups":"group labels must not be empty"}}`,
+		},
+		{
+			name: "duplicate positions",
+			json: `{
+					"question":"Dinner?", 
+					"options":[{"value":"Soup","position":0}, {"value":"Steak","position":1}],
+					"groups":[{"label":"Appetizers","position":0}, {"label":"Mains","position":0}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"groups":"positions must be unique"}}`,
+		},
+		{
+			name: "duplicate labels",
+			json: `{
+					"question":"Dinner?", 
+					"options":[{"value":"Soup","position":0}, {"value":"Steak","position":1}],
+					"groups":[{"label":"Mains","position":0}, {"label":"mains","position":1}]
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"groups":"must not contain duplicate values"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_language(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "detected",
+			json: `{
+					"question":"Welcher Tag ist der beste für das Teammeeting?", 
+					"options":[{"value":"Montag","position":0}, {"value":"Freitag","position":1}]
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"language":"de"`,
+		},
+		{
+			name: "set",
+			json: `{
+					"question":"Pizza?", 
+					"options":[{"value":"Sì","position":0}, {"value":"No","position":1}],
+					"language":"it"
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"language":"it"`,
+		},
+		{
+			name: "unsupported",
+			json: `{
+					"question":"Pizza?", 
+					"options":[{"value":"Ja","position":0}, {"value":"Nej","position":1}],
+					"language":"sv"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"language":"must be de, en, es, fr, it, nl or pt"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_timeZones(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	tests := []createPollTest{
+		{
+			name: "utc offset",
+			json: fmt.Sprintf(
+				`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"expires_at":%q
+					}`,
+				expires.In(time.FixedZone("", -5*60*60)).Format(time.RFC3339),
+			),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   fmt.Sprintf(`"expires_at":%q`, expires.UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "local time in time zone",
+			json: fmt.Sprintf(
+				`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"expires_at":{"local":%q,"time_zone":"Europe/Berlin"}
+					}`,
+				expires.In(berlin).Format("2006-01-02T15:04:05"),
+			),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   fmt.Sprintf(`"expires_at":%q`, expires.UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "missing utc offset",
+			json: fmt.Sprintf(
+				`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"expires_at":%q
+					}`,
+				expires.Format("2006-01-02T15:04:05"),
+			),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `expires_at must be an RFC 3339 time with a UTC offset`,
+		},
+		{
+			name: "unknown time zone",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"expires_at":{"local":"2030-01-01T12:00:00","time_zone":"Mars/Olympus"}
+					}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `expires_at time_zone must be an IANA time zone`,
+		},
+		{
+			name: "invalid local time",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"expires_at":{"local":"2030-01-01T12:00:00Z","time_zone":"Europe/Berlin"}
+					}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `expires_at local time must be formatted as 2006-01-02T15:04:05`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_expiresIn(t *testing.T) {
+	app.config.maxExpiresIn = 90 * 24 * time.Hour
+	defer func() { app.config.maxExpiresIn = 0 }()
+
+	newPoll := func(expires string) string {
+		return fmt.Sprintf(`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					%s
+					}`, expires)
+	}
+	in7Days := time.Now().Add(7 * 24 * time.Hour).UTC()
+
+	tests := []createPollTest{
+		{
+			name:           "hours",
+			json:           newPoll(`"expires_in":"2h"`),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question":"Test?"`,
+		},
+		{
+			name:           "days",
+			json:           newPoll(`"expires_in":"7d"`),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"expires_at":"` + in7Days.Format("2006-01-02T"),
+		},
+		{
+			name:           "invalid duration",
+			json:           newPoll(`"expires_in":"a week"`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"expires_in":"must be a duration such as 2h or 7d"}}`,
+		},
+		{
+			name:           "too short",
+			json:           newPoll(`"expires_in":"1m"`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"expires_in":"must be at least 2m"}}`,
+		},
+		{
+			name:           "too long",
+			json:           newPoll(`"expires_in":"91d"`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"expires_in":"must not be more than 90d"}}`,
+		},
+		{
+			name:           "days overflowing a duration",
+			json:           newPoll(`"expires_in":"213504d"`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"expires_in":"must be a duration such as 2h or 7d"}}`,
+		},
+		{
+			name:           "together with expires_at",
+			json:           newPoll(fmt.Sprintf(`"expires_in":"2h", "expires_at":%q`, in7Days.Format(time.RFC3339))),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"expires_in":"must not be set together with expires_at"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_series(t *testing.T) {
+	newPoll := func(previousPollID string) string {
+		return fmt.Sprintf(`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"previous_poll_id":%q
+					}`, previousPollID)
+	}
+
+	tests := []createPollTest{
+		{
+			name:           "follow up on own poll",
+			json:           newPoll(data.ExamplePollIDValid.String()),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   fmt.Sprintf(`"series_id":%q`, data.ExamplePollIDValid),
+		},
+		{
+			name:           "without token",
+			json:           newPoll(data.ExamplePollIDValid.String()),
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"code":"INVALID_TOKEN"`,
+		},
+		{
+			name:           "unknown poll",
+			json:           newPoll(uuid.NewString()),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"previous_poll_id":"must be an existing poll"}}`,
+		},
+		{
+			name:           "invalid poll id",
+			json:           newPoll("last week"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"previous_poll_id":"must be an existing poll"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_draft(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "draft",
+			json: `{
+					"question":"Test?",
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"is_draft":true
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"is_draft":true`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_demographics(t *testing.T) {
+	newPoll := func(demographics string) string {
+		return fmt.Sprintf(`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"demographics":%s
+					}`, demographics)
+	}
+
+	tests := []createPollTest{
+		{
+			name:           "valid demographics",
+			json:           newPoll(`[{"key":"age","label":"Your age","choices":["18-34"," 35+ "]}]`),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"demographics":[{"key":"age","label":"Your age","choices":["18-34","35+"]}]`,
+		},
+		{
+			name:           "invalid key",
+			json:           newPoll(`[{"key":"Age Range","choices":["18-34","35+"]}]`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"demographics":"keys must be lowercase letters, digits and underscores"}}`,
+		},
+		{
+			name:           "duplicate keys",
+			json:           newPoll(`[{"key":"age","choices":["18-34","35+"]},{"key":"age","choices":["a","b"]}]`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"demographics":"keys must be unique"}}`,
+		},
+		{
+			name:           "one choice",
+			json:           newPoll(`[{"key":"age","choices":["18-34"]}]`),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"demographics":"questions must have at least two choices"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_notifyEmail(t *testing.T) {
+	tests := []createPollTest{
+		{
+			name: "notify email without mailer",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"notify_email": "jane@example.com"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"notify_email":"email notifications are not supported by this server"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+
+	app.mailer = &mockMailer{}
+	defer func() { app.mailer = nil }()
+
+	tests = []createPollTest{
+		{
+			name: "valid notify email",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"notify_email": "jane@example.com"
+					}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question":"Test?"`,
+		},
+		{
+			name: "invalid notify email",
+			json: `{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"notify_email": "jane"
+					}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"notify_email":"must be a valid email address"}}`,
+		},
+	}
+	runCreatePollTests(t, tests)
+}
+
+func Test_app_createPollHandler_confirmVotes(t *testing.T) {
+	json := `{
+			"question":"Chair?", 
+			"options":[{"value":"Jane","position":0}, {"value":"John","position":1}],
+			"confirm_votes":true
+			}`
+
+	runCreatePollTests(t, []createPollTest{
+		{
+			name:           "without mailer",
+			json:           json,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"confirm_votes":"email confirmation is not supported by this server"}}`,
+		},
+	})
+
+	app.mailer = &mockMailer{}
+	defer func() { app.mailer = nil }()
+
+	runCreatePollTests(t, []createPollTest{
+		{
+			name:           "with mailer",
+			json:           json,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"confirm_votes":true`,
+		},
+	})
+}
+
+func Test_app_createPollHandler_moderation(t *testing.T) {
+	app.moderation = moderation.NewWordlistProvider([]string{"darn"})
+	defer func() {
+		app.moderation = nil
+		app.config.moderation.action = ""
+	}()
+
+	json := `{
+		"question":"Darn it?",
+		"options":[{"value":"first","position":0},{"value":"second","position":1}]
+		}`
+
+	app.config.moderation.action = string(moderation.Flag)
+	runCreatePollTests(t, []createPollTest{
+		{
+			name:           "flag for review",
+			json:           json,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"moderation_status":"flagged","moderation_terms":["Darn"]`,
+		},
+		{
+			name: "clean content",
+			json: `{
+				"question":"Test?",
+				"options":[{"value":"first","position":0},{"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question":"Test?"`,
+		},
+	})
+
+	app.config.moderation.action = string(moderation.Mask)
+	runCreatePollTests(t, []createPollTest{
+		{
+			name:           "mask",
+			json:           json,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question":"**** it?"`,
+		},
+		{
+			name: "masked options become equal",
+			json: `{
+				"question":"Test?",
+				"options":[{"value":"darn","position":0},{"value":"****","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options.1":"must not duplicate another option's value"`,
+		},
+	})
+
+	app.config.moderation.action = string(moderation.Reject)
+	runCreatePollTests(t, []createPollTest{
+		{
+			name:           "reject",
+			json:           json,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"CONTENT_REJECTED","error":"the content was rejected by moderation"}`,
+		},
+	})
+}
+
+type createPollTest struct {
+	name           string
+	json           string
+	authHeader     string
+	expectedStatus int
+	expectedBody   string
+}
+
+func runCreatePollTests(t *testing.T, tests []createPollTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createPollHandler)
+			handler.ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code == http.StatusCreated && !strings.Contains(
+				rr.Header().Get("Location"),
+				"/v1/polls/",
+			) {
+				t.Errorf("Location does not contain link to created poll")
+			}
+		})
+	}
+}
+
+func Test_app_createPollHandler_dailyLimit(t *testing.T) {
+	adminToken := "0123456789abcdef0123456789abcdef"
+	app.config.admin.token = adminToken
+	defer func() {
+		app.config.quotas.dailyPolls = 0
+		app.config.admin.token = ""
+	}()
+	editor, _ := app.models.Orgs.GetMemberForToken(data.ExampleTokenOrgEditor)
+	apiKey, _ := app.models.APIKeys.GetForKey(data.ExampleAPIKey)
+
+	tests := []struct {
+		name           string
+		limit          int
+		ip             string
+		token          string
+		member         *data.Member
+		apiKey         *data.APIKey
+		expectedStatus int
+	}{
+		{"unlimited", 0, data.ExampleThrottledIP, "", nil, nil, http.StatusCreated},
+		{"within limit", 5, "198.51.100.4", "", nil, nil, http.StatusCreated},
+		{"limit reached", 5, data.ExampleThrottledIP, "", nil, nil, http.StatusTooManyRequests},
+		{"refused by the database", 5, data.ExampleUncountedIP, "", nil, nil, http.StatusTooManyRequests},
+		{"uncounted when unlimited", 0, data.ExampleUncountedIP, "", nil, nil, http.StatusCreated},
+		{"member counted by api key", 5, data.ExampleThrottledIP, data.ExampleTokenOrgEditor, editor, nil, http.StatusCreated},
+		{"counted by organization api key", 5, data.ExampleThrottledIP, "", apiKey.Member(), apiKey, http.StatusCreated},
+		{"admin", 5, data.ExampleThrottledIP, adminToken, nil, nil, http.StatusCreated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.quotas.dailyPolls = test.limit
+
+			body := `{"question":"Test?","options":[{"value":"One","position":0},{"value":"Two","position":1}]}`
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("X-Forwarded-For", test.ip)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			if test.member != nil {
+				req = req.WithContext(context.WithValue(req.Context(), ctxMemberKey, test.member))
+			}
+			if test.apiKey != nil {
+				req = req.WithContext(context.WithValue(req.Context(), ctxAPIKeyKey, test.apiKey))
+			}
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d: %s", test.expectedStatus, rr.Code, rr.Body)
+			}
+			if test.expectedStatus == http.StatusTooManyRequests {
+				if !strings.Contains(rr.Body.String(), `"code":"CREATION_LIMITED"`) {
+					t.Errorf("expected CREATION_LIMITED, but got %q", rr.Body)
+				}
+				if rr.Header().Get("Retry-After") == "" {
+					t.Error("expected a Retry-After header")
+				}
 			}
 		})
 	}
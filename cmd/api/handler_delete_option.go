@@ -1,12 +1,17 @@
 package main
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 
+	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
 	"github.com/ivcp/polls/internal/validator"
 )
 
+// deleteOptionHandler deletes the option once the undo window has passed, or
+// right away if there is none.
 func (app *application) deleteOptionHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
 
@@ -31,7 +36,7 @@ func (app *application) deleteOptionHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	if !match {
-		app.notFoundResponse(w, r)
+		app.optionNotInPollResponse(w)
 		return
 	}
 
@@ -49,20 +54,76 @@ func (app *application) deleteOptionHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = app.models.PollOptions.Delete(optionID)
+	if app.config.undoWindow > 0 {
+		app.stageOptionDelete(w, r, poll, optionID)
+		return
+	}
+
+	err = app.models.PollOptions.Delete(poll.ID, optionID)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrOptionNotInPoll):
+			app.optionNotInPollResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
 		return
 	}
 
-	err = app.models.PollOptions.UpdatePosition(poll.Options)
+	err = app.models.PollOptions.UpdatePosition(poll.ID, poll.Options)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 		return
 	}
 
+	app.recordActivity(poll.ID, app.actor(r), data.ActionOptionDeleted, map[string]any{
+		"option_id": optionToDelete.ID,
+		"value":     optionToDelete.Value,
+	})
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"message": "option deleted successfully"}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 	}
 }
+
+// stageOptionDelete stages the delete of the option, which is left out of the
+// poll's options. Options already staged to be deleted are left out too, so
+// the poll keeps at least two options once they all are.
+func (app *application) stageOptionDelete(w http.ResponseWriter, r *http.Request, poll *data.Poll, optionID uuid.UUID) {
+	staged, err := app.models.Staged.GetOptionDeletes(poll.ID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	remaining := 0
+	for _, option := range poll.Options {
+		if !slices.Contains(staged, option.ID) {
+			remaining++
+		}
+	}
+
+	v := validator.New()
+	if v.Check(remaining >= 2, "options", "must contain at least two options"); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	action := &data.StagedAction{PollID: poll.ID, OptionID: &optionID, Actor: app.actor(r)}
+	err = app.models.Staged.Stage(action, app.config.undoWindow)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.optionNotInPollResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusAccepted, envelope{"message": "option will be deleted", "undo": action}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -6,30 +6,64 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"go.uber.org/mock/gomock"
 )
 
 func Test_app_deleteOptionHandler(t *testing.T) {
+	// the options after the first move up a position once it is deleted
+	deleted := func(m repositoryMocks) {
+		gomock.InOrder(
+			m.options.EXPECT().Delete(data.ExamplePollIDValid, data.ExampleOptionID1).Return(nil),
+			m.options.EXPECT().
+				UpdatePosition(data.ExamplePollIDValid, gomock.Cond(func(x any) bool {
+					for i, option := range x.([]*data.PollOption) {
+						if option.ID == data.ExampleOptionID1 || option.Position != i {
+							return false
+						}
+					}
+					return true
+				})).
+				Return(nil),
+		)
+	}
+
 	tests := []struct {
 		name           string
 		optionID       string
+		expect         func(m repositoryMocks)
 		expectedStatus int
 		expectedBody   string
 	}{
-		{"valid delete", data.ExampleOptionID1, http.StatusOK, "option deleted successfully"},
-		{"invalid id", uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
+		{"valid delete", data.ExampleOptionID1.String(), deleted, http.StatusOK, "option deleted successfully"},
+		{"invalid id", uuid.NewString(), nil, http.StatusNotFound, "the option does not belong to this poll"},
+		{
+			"deleted concurrently",
+			data.ExampleOptionID1.String(),
+			func(m repositoryMocks) {
+				m.options.EXPECT().Delete(data.ExamplePollIDValid, data.ExampleOptionID1).Return(data.ErrOptionNotInPoll)
+			},
+			http.StatusNotFound,
+			"the option does not belong to this poll",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			mocks := mockRepositories(t)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
 			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("optionID", test.optionID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll := examplePoll(t, data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.deleteOptionHandler)
@@ -43,3 +77,55 @@ func Test_app_deleteOptionHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_deleteOptionHandler_undoWindow(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		optionID       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "stage delete",
+			pollID:         data.ExamplePollIDValid,
+			optionID:       data.ExampleOptionID2.String(),
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `"action":"option.delete","poll_id":"` + data.ExamplePollIDValid.String() + `","option_id":"` + data.ExampleOptionID2.String() + `"`,
+		},
+		{
+			name:           "too few options left after staged deletes",
+			pollID:         data.ExamplePollIDValid,
+			optionID:       data.ExampleOptionID1.String(),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options":"must contain at least two options"`,
+		},
+	}
+
+	app.config.undoWindow = time.Minute
+	defer func() { app.config.undoWindow = 0 }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// staged deletes don't touch the options until they are applied
+			mockRepositories(t)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("optionID", test.optionID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			poll := examplePoll(t, test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.deleteOptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -7,10 +7,51 @@ import (
 	"github.com/ivcp/polls/internal/data"
 )
 
+// deletePollHandler deletes the poll once the undo window has passed, or
+// right away if there is none. Deleting a poll with votes destroys its
+// results, so it must be confirmed with the poll's slug.
 func (app *application) deletePollHandler(w http.ResponseWriter, r *http.Request) {
 	id := app.pollIDfromContext(r.Context())
 
-	err := app.models.Polls.Delete(id)
+	poll, err := app.models.Polls.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if poll.TotalVotes() > 0 && r.URL.Query().Get("confirm") != poll.Slug() {
+		app.failedValidationResponse(w, map[string]string{
+			"confirm": "must be the poll's slug to delete a poll with votes",
+		})
+		return
+	}
+
+	if app.config.undoWindow > 0 {
+		action := &data.StagedAction{PollID: id, Actor: app.actor(r)}
+		err = app.models.Staged.Stage(action, app.config.undoWindow)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusAccepted, envelope{"message": "poll will be deleted", "undo": action}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.models.Polls.Delete(id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
@@ -14,17 +15,30 @@ import (
 func Test_app_deletePollHandler(t *testing.T) {
 	tests := []struct {
 		name           string
-		id             string
+		id             uuid.UUID
+		query          string
 		expectedStatus int
 		expectedBody   string
 	}{
-		{"delete a poll", data.ExamplePollIDValid, http.StatusOK, "poll successfully deleted"},
-		{"poll not found", uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
+		{"delete a poll", data.ExamplePollIDValid, "", http.StatusOK, "poll successfully deleted"},
+		{"poll not found", uuid.New(), "", http.StatusNotFound, "the requested resource could not be found"},
+		{
+			"poll with votes", data.ExamplePollIDVotingStarted, "", http.StatusUnprocessableEntity,
+			`"confirm":"must be the poll's slug to delete a poll with votes"`,
+		},
+		{
+			"poll with votes and wrong slug", data.ExamplePollIDVotingStarted, "?confirm=favourite-color",
+			http.StatusUnprocessableEntity, `"confirm":"must be the poll's slug to delete a poll with votes"`,
+		},
+		{
+			"poll with votes confirmed", data.ExamplePollIDVotingStarted, "?confirm=favourite-colour",
+			http.StatusOK, "poll successfully deleted",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			req, _ := http.NewRequest(http.MethodDelete, "/"+test.query, nil)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.id))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.deletePollHandler)
@@ -40,3 +54,22 @@ func Test_app_deletePollHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_deletePollHandler_undoWindow(t *testing.T) {
+	app.config.undoWindow = time.Minute
+	defer func() { app.config.undoWindow = 0 }()
+
+	req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.deletePollHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected status code %d, but got %d", http.StatusAccepted, rr.Code)
+	}
+	expectedBody := `"undo":{"id":"` + data.ExampleStagedActionID.String() + `","action":"poll.delete"`
+	if !strings.Contains(rr.Body.String(), expectedBody) {
+		t.Errorf("expected body to contain %q, but got %q", expectedBody, rr.Body)
+	}
+}
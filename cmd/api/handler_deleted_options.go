@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// listDeletedOptionsHandler lists the poll's deleted options that can still
+// be restored, with the votes they had when they were deleted.
+func (app *application) listDeletedOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	options, err := app.models.Deleted.GetAll(id, app.config.restoreWindow)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"options": options}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// restoreOptionHandler puts a deleted option back in the poll with its votes.
+// Like merging options it is allowed once voting has begun, as it restores
+// votes that were cast.
+func (app *application) restoreOptionHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+
+	optionID, err := app.readIDParam(r, "optionID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	option, err := app.models.Deleted.Restore(poll.ID, optionID, app.config.restoreWindow)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.failedValidationResponse(w, map[string]string{
+				"value": "must not duplicate another option's value",
+			})
+		case errors.Is(err, data.ErrTooManyOptions):
+			app.tooManyOptionsResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(poll.ID, app.actor(r), data.ActionOptionRestored, map[string]any{
+		"option_id": option.ID,
+		"value":     option.Value,
+		"votes":     option.VoteCount,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"message": "option restored successfully",
+		"option":  option,
+		"votes":   option.VoteCount,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
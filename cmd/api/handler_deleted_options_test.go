@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listDeletedOptionsHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           uuid.UUID
+		expectedBody string
+	}{
+		{"deleted options", data.ExamplePollIDValid, `"id":"` + data.ExampleDeletedOptionID.String() + `","value":"Green","position":2,"vote_count":3`},
+		{"none deleted", data.ExamplePollIDVotingStarted, `{"options":[]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.id))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listDeletedOptionsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_restoreOptionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		optionID       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "restore",
+			optionID:       data.ExampleDeletedOptionID.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"votes":3`,
+		},
+		{
+			name:           "value taken",
+			optionID:       data.ExampleDeletedOptionIDTaken.String(),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"value":"must not duplicate another option's value"`,
+		},
+		{
+			name:           "not deleted or window passed",
+			optionID:       uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid id",
+			optionID:       "invalid",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("optionID", test.optionID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.restoreOptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
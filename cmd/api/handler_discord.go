@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/discord"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// discordInteractionHandler handles every interaction of the Discord
+// application: the endpoint verification ping, the /poll command and vote
+// buttons. It is not found when no public key is configured.
+func (app *application) discordInteractionHandler(w http.ResponseWriter, r *http.Request) {
+	if app.config.discord.publicKey == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	body, err := discord.Verify(app.config.discord.publicKey, r)
+	if err != nil {
+		switch {
+		case errors.Is(err, discord.ErrInvalidSignature):
+			app.invalidSignatureResponse(w)
+		default:
+			app.badRequestResponse(w, err)
+		}
+		return
+	}
+
+	var interaction discord.Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		app.badRequestResponse(w, errors.New("body contains badly-formed JSON"))
+		return
+	}
+
+	switch interaction.Type {
+	case discord.InteractionPing:
+		app.writeDiscordResponse(w, discord.Response{Type: discord.ResponsePong})
+	case discord.InteractionApplicationCmd:
+		app.discordCommand(w, interaction)
+	case discord.InteractionMessageComponent:
+		app.discordVote(w, r, interaction)
+	default:
+		app.badRequestResponse(w, errors.New("unsupported interaction type"))
+	}
+}
+
+func (app *application) discordCommand(w http.ResponseWriter, interaction discord.Interaction) {
+	options := []*data.PollOption{}
+	for i, value := range discord.ParseOptions(interaction.Option("options")) {
+		options = append(options, &data.PollOption{Value: app.text.Line(value), Position: i})
+	}
+
+	poll := &data.Poll{
+		Question:          app.text.Line(interaction.Option("question")),
+		Options:           options,
+		ResultsVisibility: "always",
+		TieBreak:          "shared",
+		Anonymity:         "anonymous",
+		Visibility:        data.VisibilityPublic,
+	}
+
+	v := validator.New()
+	v.Check(
+		len(poll.Options) <= discord.MaxOptions,
+		"options",
+		fmt.Sprintf("must not contain more than %d options", discord.MaxOptions),
+	)
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		app.writeDiscordResponse(w, discord.Ephemeral(discordValidationText(v.Errors)))
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Insert(poll, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	app.publishEvent(events.PollCreated(poll))
+	app.recordActivity(poll.ID, data.ActorOwner, data.ActionPollCreated, nil)
+
+	app.writeDiscordResponse(w, discord.Response{
+		Type: discord.ResponseChannelMessage,
+		Data: discord.PollMessage(poll, []*data.PollOption{}),
+	})
+
+	// the token is only shown to the user who created the poll, in a
+	// follow-up as the channel message is visible to everyone
+	content := fmt.Sprintf(
+		"Manage your poll with the token `%s`. Keep it somewhere safe, it won't be shown again.",
+		token.Plaintext,
+	)
+	app.background(func() {
+		if err := discord.FollowUp(app.httpClient, interaction, content); err != nil {
+			app.logError(err)
+		}
+	})
+}
+
+func (app *application) discordVote(w http.ResponseWriter, r *http.Request, interaction discord.Interaction) {
+	pollID, optionID, ok := discord.ParseVoteID(interaction.Data.CustomID)
+	if !ok {
+		app.badRequestResponse(w, errors.New("invalid vote id"))
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.voting().Check(poll); err != nil {
+		message, _ := voteRefusal(err, "from Discord")
+		app.writeDiscordResponse(w, discord.Ephemeral(message))
+		return
+	}
+
+	userID := interaction.UserID()
+	if userID == "" {
+		app.badRequestResponse(w, errors.New("missing user"))
+		return
+	}
+
+	vote := &data.Vote{
+		PollID:        poll.ID,
+		OptionID:      optionID,
+		UserAgent:     "Discord",
+		VoterIdentity: discord.VoterIdentity(userID),
+	}
+
+	err = app.voting().Cast(vote)
+	if err != nil {
+		message, refused := voteRefusal(err, "from Discord")
+		switch {
+		case refused:
+			app.writeDiscordResponse(w, discord.Ephemeral(message))
+		case errors.Is(err, data.ErrRecordNotFound), errors.Is(err, data.ErrOptionNotInPoll):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.publishEvent(events.VoteCast(vote))
+	app.trackEvent(analytics.Vote(vote))
+	app.purgePoll(vote.PollID)
+
+	// the message is visible to the whole channel, voters or not
+	var results []*data.PollOption
+	if poll.ResultsVisibility == "always" {
+		results, err = app.models.PollOptions.GetResults(poll.ID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
+	app.writeDiscordResponse(w, discord.Response{
+		Type: discord.ResponseUpdateMessage,
+		Data: discord.PollMessage(poll, results),
+	})
+}
+
+func (app *application) writeDiscordResponse(w http.ResponseWriter, response discord.Response) {
+	js, err := json.Marshal(response)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(js)
+}
+
+func discordValidationText(errs map[string]string) string {
+	return "Couldn't create the poll:\n" + formatValidationErrors(errs)
+}
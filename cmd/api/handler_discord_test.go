@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/discord"
+)
+
+func newDiscordRequest(body string, key ed25519.PrivateKey) *http.Request {
+	timestamp := "1700000000"
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+	return req
+}
+
+func Test_app_discordInteractionHandler(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	app.config.discord.publicKey = publicKey
+	defer func() { app.config.discord.publicKey = nil }()
+
+	followUps := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		followUps <- string(body)
+	}))
+	defer srv.Close()
+	apiURL := discord.APIURL
+	discord.APIURL = srv.URL
+	defer func() { discord.APIURL = apiURL }()
+
+	command := func(question, options string) string {
+		js, _ := json.Marshal(map[string]any{
+			"type":           discord.InteractionApplicationCmd,
+			"application_id": "1",
+			"token":          "interaction-token",
+			"data": map[string]any{
+				"name": "poll",
+				"options": []map[string]string{
+					{"name": "question", "value": question},
+					{"name": "options", "value": options},
+				},
+			},
+		})
+		return string(js)
+	}
+	vote := func(userID, customID string) string {
+		js, _ := json.Marshal(map[string]any{
+			"type":   discord.InteractionMessageComponent,
+			"member": map[string]any{"user": map[string]string{"id": userID}},
+			"data":   map[string]string{"custom_id": customID},
+		})
+		return string(js)
+	}
+
+	tests := []struct {
+		name             string
+		body             string
+		key              ed25519.PrivateKey
+		expectedStatus   int
+		expectedBody     string
+		expectedFollowUp string
+	}{
+		{
+			name:           "ping",
+			body:           `{"type":1}`,
+			key:            privateKey,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"type":1}`,
+		},
+		{
+			name:             "create poll",
+			body:             command("Lunch?", "Pizza | Sushi"),
+			key:              privateKey,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `"custom_id":"vote:`,
+			expectedFollowUp: "Manage your poll with the token",
+		},
+		{
+			name:           "invalid poll",
+			body:           command("Lunch?", "Pizza"),
+			key:            privateKey,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "must contain at least two options",
+		},
+		{
+			name:           "vote",
+			body:           vote("2", "vote:"+data.ExamplePollIDValid.String()+":"+data.ExampleOptionID1.String()),
+			key:            privateKey,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"type":7`,
+		},
+		{
+			name:           "already voted",
+			body:           vote("1", "vote:"+data.ExamplePollIDValid.String()+":"+data.ExampleOptionID1.String()),
+			key:            privateKey,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "You have already voted",
+		},
+		{
+			name:           "expired poll",
+			body:           vote("2", "vote:"+data.ExamplePollIDExpiredPoll.String()+":"+data.ExampleOptionID1.String()),
+			key:            privateKey,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "This poll has expired",
+		},
+		{
+			name:           "invalid signature",
+			body:           `{"type":1}`,
+			key:            otherKey,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing request signature",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := newDiscordRequest(test.body, test.key)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.discordInteractionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if test.expectedFollowUp != "" {
+				select {
+				case body := <-followUps:
+					if !strings.Contains(body, test.expectedFollowUp) {
+						t.Errorf("expected follow-up to contain %q, but got %q", test.expectedFollowUp, body)
+					}
+				case <-time.After(2 * time.Second):
+					t.Error("expected a follow-up message")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dumpBatchSize is how many polls are read from the database at once while
+// dumping them.
+const dumpBatchSize = 500
+
+// dumpPolls writes the public polls after the poll with the ID after as JSON
+// lines, one poll per line, and returns how many it wrote. flush is called
+// after each batch of polls.
+func (app *application) dumpPolls(w io.Writer, after uuid.UUID, flush func() error) (int, error) {
+	enc := json.NewEncoder(w)
+	written := 0
+
+	for {
+		polls, err := app.models.Polls.GetPublic(after, dumpBatchSize)
+		if err != nil {
+			return written, err
+		}
+
+		for _, poll := range polls {
+			if err := enc.Encode(poll); err != nil {
+				return written, err
+			}
+			written++
+		}
+
+		if err := flush(); err != nil {
+			return written, err
+		}
+		if len(polls) < dumpBatchSize {
+			return written, nil
+		}
+		after = polls[len(polls)-1].ID
+	}
+}
+
+// dumpPollsHandler streams the public polls as newline delimited JSON for
+// analytics, gzipped if the client accepts it. A dump that was cut off can
+// be resumed with the ID of the last poll received as after.
+func (app *application) dumpPollsHandler(w http.ResponseWriter, r *http.Request) {
+	var after uuid.UUID
+	if param := r.URL.Query().Get("after"); param != "" {
+		var err error
+		if after, err = uuid.Parse(param); err != nil {
+			app.failedValidationResponse(w, map[string]string{"after": "must be a poll ID"})
+			return
+		}
+	}
+
+	app.writeNDJSON(w, r, func(out io.Writer, flush func() error) error {
+		_, err := app.dumpPolls(out, after, flush)
+		return err
+	})
+}
+
+// writeNDJSON streams the newline delimited JSON written by write, gzipped if
+// the client accepts it. write is given a function flushing what it wrote to
+// the client, which gets as long to receive each flush as a regular response.
+func (app *application) writeNDJSON(w http.ResponseWriter, r *http.Request, write func(out io.Writer, flush func() error) error) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	rc := http.NewResponseController(w)
+	var out io.Writer = w
+	flush := func() error {
+		err := rc.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	var gz *gzip.Writer
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(w)
+		out = gz
+
+		flushResponse := flush
+		flush = func() error {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+			return flushResponse()
+		}
+	}
+
+	if err := write(out, flush); err != nil {
+		// the response is cut off without its end, so the client doesn't
+		// take a partial stream for a complete one
+		app.logError(err)
+		panic(http.ErrAbortHandler)
+	}
+	if gz != nil {
+		gz.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_dumpPollsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		gzip           bool
+		expectedStatus int
+		expectedLines  int
+		expectedBody   string
+	}{
+		{"all polls", "", false, http.StatusOK, 3, `"total_votes":2,"options":[{"id":"` + data.ExampleOptionID1.String() + `","value":"One","position":0,"votes":2}]`},
+		{"results held back", "", false, http.StatusOK, 3, `"results_threshold":10,"total_votes":null`},
+		{"after", "?after=" + data.ExamplePollIDThreshold.String(), false, http.StatusOK, 1, `"id":"` + data.ExamplePollIDValid.String() + `"`},
+		{"gzip", "", true, http.StatusOK, 3, `"id":"` + data.ExamplePollIDAfterDeadline.String() + `"`},
+		{"invalid after", "?after=1", false, http.StatusUnprocessableEntity, 1, `"after":"must be a poll ID"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
+			if test.gzip {
+				req.Header.Set("Accept-Encoding", "gzip")
+			}
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.dumpPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			var body io.Reader = rr.Body
+			if test.gzip {
+				if rr.Header().Get("Content-Encoding") != "gzip" {
+					t.Fatal("expected the dump to be gzipped")
+				}
+				gz, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gz
+			}
+			b, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+			if len(lines) != test.expectedLines {
+				t.Errorf("expected %d lines, but got %d: %s", test.expectedLines, len(lines), b)
+			}
+			if !strings.Contains(string(b), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+const (
+	embedDefaultWidth  = 400
+	embedDefaultHeight = 320
+)
+
+func (app *application) showPollEmbedHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	// embeds are public, so hidden polls can't be previewed in them, and
+	// private polls can't be shown in them
+	if poll.Hidden() || poll.Visibility == data.VisibilityPrivate {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// embeds are shown to voters, who are asked their wording of the
+	// question
+	poll.ShowVariant(poll.VariantFor(voterIdentity(r)))
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(nonceBytes)
+
+	embedURL := app.externalURL(r, "/v1/polls/"+poll.ID.String()+"/embed")
+
+	var buf bytes.Buffer
+	err = templates.ExecuteTemplate(&buf, "embed.tmpl", map[string]any{
+		"Poll":      poll,
+		"Closed":    !poll.ExpiresAt.IsZero() && poll.ExpiresAt.Before(time.Now()),
+		"Nonce":     nonce,
+		"APIURL":    app.externalURL(r, ""),
+		"OEmbedURL": app.externalURL(r, "/v1/oembed?format=json&url="+url.QueryEscape(embedURL)),
+	})
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", fmt.Sprintf(
+		"default-src 'none'; style-src 'nonce-%[1]s'; script-src 'nonce-%[1]s'; connect-src 'self'; base-uri 'none'; form-action 'none'",
+		nonce,
+	))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+var pollIDInURLRX = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// oEmbedHandler implements the oEmbed provider endpoint (https://oembed.com)
+// for poll URLs, returning an iframe of the poll's embed page.
+func (app *application) oEmbedHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	resourceURL := app.readString(qs, "url", "")
+	format := app.readString(qs, "format", "json")
+	maxWidth := app.readInt(qs, "maxwidth", 0, v)
+	maxHeight := app.readInt(qs, "maxheight", 0, v)
+
+	v.Check(resourceURL != "", "url", "must be provided")
+	v.Check(maxWidth >= 0, "maxwidth", "must not be negative")
+	v.Check(maxHeight >= 0, "maxheight", "must not be negative")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	// only json is supported, as allowed by the spec
+	if format != "json" {
+		app.errorJSONResponse(w, codeFormatNotSupported, "format not supported")
+		return
+	}
+
+	pollID, err := uuid.Parse(pollIDInURLRX.FindString(resourceURL))
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	width, height := embedDefaultWidth, embedDefaultHeight
+	if maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+	if maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+
+	iframe := fmt.Sprintf(
+		`<iframe src="%s" width="%d" height="%d" frameborder="0" title="%s"></iframe>`,
+		template.HTMLEscapeString(app.externalURL(r, "/v1/polls/"+poll.ID.String()+"/embed")),
+		width,
+		height,
+		template.HTMLEscapeString(poll.Question),
+	)
+
+	response := envelope{
+		"version":       "1.0",
+		"type":          "rich",
+		"provider_name": "Polls",
+		"provider_url":  app.externalURL(r, ""),
+		"title":         poll.Question,
+		"html":          iframe,
+		"width":         width,
+		"height":        height,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, response, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
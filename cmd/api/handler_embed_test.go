@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollEmbedHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"valid poll", data.ExamplePollIDValid.String(), http.StatusOK, "<h1>Test?</h1>"},
+		{"unexisting poll", uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
+		{"invalid id", "a", http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showPollEmbedHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			csp := rr.Header().Get("Content-Security-Policy")
+			if !strings.Contains(csp, "script-src 'nonce-") {
+				t.Errorf("expected nonce based script-src, but got CSP %q", csp)
+			}
+			nonce := strings.SplitN(strings.SplitN(csp, "'nonce-", 2)[1], "'", 2)[0]
+			if !strings.Contains(rr.Body.String(), `<script nonce="`+nonce+`">`) {
+				t.Errorf("expected inline script to carry the CSP nonce %q", nonce)
+			}
+			if !strings.Contains(rr.Body.String(), `type="application/json+oembed"`) {
+				t.Errorf("expected oEmbed discovery link in body")
+			}
+		})
+	}
+}
+
+func Test_app_oEmbedHandler(t *testing.T) {
+	pollURL := "https://polls.example.com/v1/polls/" + data.ExamplePollIDValid.String() + "/embed"
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid url",
+			query:          "?url=" + url.QueryEscape(pollURL),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"type":"rich"`,
+		},
+		{
+			name:           "max width",
+			query:          "?maxwidth=200&url=" + url.QueryEscape(pollURL),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"width":200`,
+		},
+		{
+			name:           "missing url",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"url":"must be provided"`,
+		},
+		{
+			name:           "xml format",
+			query:          "?format=xml&url=" + url.QueryEscape(pollURL),
+			expectedStatus: http.StatusNotImplemented,
+		},
+		{
+			name:           "url without poll",
+			query:          "?url=" + url.QueryEscape("https://example.com/"),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unexisting poll",
+			query:          "?url=" + url.QueryEscape("https://example.com/v1/polls/"+uuid.NewString()),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/oembed"+test.query, nil)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.oEmbedHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listExemptionsHandler(w http.ResponseWriter, r *http.Request) {
+	exemptions, err := app.models.Creations.GetExemptions()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exemptions": exemptions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// createExemptionInput is the body of POST /v1/admin/exemptions.
+type createExemptionInput struct {
+	IP     string `json:"ip" validate:"ip" doc:"IP to exempt, unless api_key is set"`
+	APIKey string `json:"api_key" doc:"API key, or member token 26 bytes long, to exempt"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// createExemptionHandler exempts an IP or an API key from the daily limit of
+// created polls.
+func (app *application) createExemptionHandler(w http.ResponseWriter, r *http.Request) {
+	var input createExemptionInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Reason = app.text.Line(input.Reason)
+
+	exemption := &data.Exemption{IP: input.IP, Reason: input.Reason}
+
+	v := validator.New()
+	data.ValidateExemption(v, exemption, input.APIKey)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if input.APIKey != "" {
+		exemption.KeyHash = data.HashToken(input.APIKey)
+	}
+
+	err = app.models.Creations.InsertExemption(exemption)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAlreadyExempt):
+			field := "ip"
+			if exemption.IP == "" {
+				field = "api_key"
+			}
+			v.AddError(field, "is already exempt")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"exemption": exemption}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deleteExemptionHandler(w http.ResponseWriter, r *http.Request) {
+	exemptionID, err := app.readInt64Param(r, "exemptionID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Creations.DeleteExemption(exemptionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "exemption removed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
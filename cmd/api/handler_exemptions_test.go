@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listExemptionsHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listExemptionsHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	expectedBody := `"kind":"ip","ip":"` + data.ExampleExemptIP + `"`
+	if !strings.Contains(rr.Body.String(), expectedBody) {
+		t.Errorf("expected body to contain %q, but got %q", expectedBody, rr.Body)
+	}
+}
+
+func Test_app_createExemptionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "exempt ip",
+			json:           `{"ip":"198.51.100.4","reason":"load test"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"kind":"ip","ip":"198.51.100.4","reason":"load test"`,
+		},
+		{
+			name:           "exempt api key",
+			json:           `{"api_key":"` + data.ExampleTokenOrgEditor + `"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"kind":"api_key"`,
+		},
+		{
+			name:           "exempt organization api key",
+			json:           `{"api_key":"` + data.ExampleAPIKey + `"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"kind":"api_key"`,
+		},
+		{
+			name:           "invalid organization api key",
+			json:           `{"api_key":"polls_APIKEYAA"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"api_key":"must be a valid API key"`,
+		},
+		{
+			name:           "nothing to exempt",
+			json:           `{"reason":"load test"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ip":"must be provided unless api_key is"`,
+		},
+		{
+			name:           "invalid ip",
+			json:           `{"ip":"198.51.100"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ip":"must be a valid IP address"`,
+		},
+		{
+			name:           "already exempt",
+			json:           `{"ip":"` + data.ExampleExemptIP + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ip":"is already exempt"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createExemptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_deleteExemptionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		exemptionID    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"remove exemption", "1", http.StatusOK, "exemption removed"},
+		{"unknown exemption", "2", http.StatusNotFound, "the requested resource could not be found"},
+		{"invalid id", "abc", http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("exemptionID", test.exemptionID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.deleteExemptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
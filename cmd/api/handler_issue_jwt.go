@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// issueJWTInput is the body of POST /v1/polls/{pollID}/jwt. JWTs can't be
+// revoked, so they are only issued for viewing results. Managing a poll must
+// stop once its tokens are revoked or it is transferred, and votes are
+// counted per token.
+type issueJWTInput struct {
+	Scope string `json:"scope" validate:"required,oneof=results"`
+}
+
+// issueJWTHandler exchanges a poll token for a short-lived results JWT. JWTs are verified without a database lookup, which
+// suits high-traffic clients such as result dashboards. The endpoint is not
+// found when no JWT key is configured.
+func (app *application) issueJWTHandler(w http.ResponseWriter, r *http.Request) {
+	if len(app.config.jwt.key) == 0 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input issueJWTInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token, ok := app.readBearerToken(r)
+	if !ok {
+		app.invalidTokenResponse(w)
+		return
+	}
+
+	// the JWT can't grant more than the poll token it is exchanged for
+	tokenPollID, tokenScope, err := app.models.Polls.CheckToken(token)
+	if err != nil || tokenPollID != pollID || !auth.ScopeIncludes(tokenScope, input.Scope) {
+		app.invalidTokenResponse(w)
+		return
+	}
+
+	jwt, expiry, err := auth.IssueJWT(app.config.jwt.key, pollID, input.Scope, app.config.jwt.ttl)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{
+		"jwt":        jwt,
+		"scope":      input.Scope,
+		"expires_at": expiry,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
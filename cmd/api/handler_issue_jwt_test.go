@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/data"
+)
+
+var testJWTKey = []byte("0123456789abcdef0123456789abcdef")
+
+func Test_app_issueJWTHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		disabled       bool
+		pollID         string
+		authHeader     string
+		json           string
+		expectedStatus int
+	}{
+		{
+			name:           "results jwt",
+			pollID:         data.ExamplePollIDValid.String(),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			json:           `{"scope":"results"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "manage jwt",
+			pollID:         data.ExamplePollIDValid.String(),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			json:           `{"scope":"manage"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "results jwt with results token",
+			pollID:         data.ExamplePollIDAfterDeadline.String(),
+			authHeader:     "Bearer " + data.ExampleTokenResults,
+			json:           `{"scope":"results"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "vote jwt",
+			pollID:         data.ExamplePollIDValid.String(),
+			authHeader:     "Bearer " + data.ExampleTokenVote,
+			json:           `{"scope":"vote"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "token for another poll",
+			pollID:         data.ExamplePollIDPublicVoters.String(),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			json:           `{"scope":"results"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "no token",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"scope":"results"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "disabled",
+			disabled:       true,
+			pollID:         data.ExamplePollIDValid.String(),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			json:           `{"scope":"results"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.disabled {
+				app.config.jwt.key = testJWTKey
+				app.config.jwt.ttl = time.Minute
+				defer func() { app.config.jwt.key = nil }()
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.issueJWTHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			if rr.Code == http.StatusCreated {
+				var body struct {
+					JWT string `json:"jwt"`
+				}
+				_ = json.Unmarshal(rr.Body.Bytes(), &body)
+				claims, err := auth.ParseJWT(testJWTKey, body.JWT)
+				if err != nil {
+					t.Fatalf("expected a valid jwt, but got %v", err)
+				}
+				if claims.Subject != test.pollID {
+					t.Errorf("expected jwt for poll %s, but got %s", test.pollID, claims.Subject)
+				}
+			}
+		})
+	}
+}
+
+func Test_app_requirePollPermission_jwt(t *testing.T) {
+	app.config.jwt.key = testJWTKey
+	defer func() { app.config.jwt.key = nil }()
+
+	manage, _, _ := auth.IssueJWT(testJWTKey, data.ExamplePollIDValid, data.ScopeManage, time.Minute)
+	results, _, _ := auth.IssueJWT(testJWTKey, data.ExamplePollIDValid, data.ScopeResults, time.Minute)
+	otherPoll, _, _ := auth.IssueJWT(testJWTKey, data.ExamplePollIDPublicVoters, data.ScopeResults, time.Minute)
+	otherKey, _, _ := auth.IssueJWT([]byte("another key, also 32 bytes long!"), data.ExamplePollIDValid, data.ScopeResults, time.Minute)
+
+	// manage JWTs aren't issued, as JWTs can't be revoked, and are refused
+	tests := []struct {
+		name           string
+		jwt            string
+		expectedStatus int
+	}{
+		{"results jwt", results, http.StatusOK},
+		{"manage jwt", manage, http.StatusUnauthorized},
+		{"jwt for another poll", otherPoll, http.StatusBadRequest},
+		{"jwt signed with another key", otherKey, http.StatusUnauthorized},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handlerToTest := app.requirePollPermission(auth.ViewResults)(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+test.jwt)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -2,14 +2,24 @@ package main
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/cdn"
 	"github.com/ivcp/polls/internal/data"
 	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) listPollsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("ids") {
+		app.listPollsByIDs(w, r)
+		return
+	}
+
 	var input struct {
-		Search string
+		Search   string
+		Metadata map[string]string
 		data.Filters
 	}
 
@@ -18,28 +28,109 @@ func (app *application) listPollsHandler(w http.ResponseWriter, r *http.Request)
 	qs := r.URL.Query()
 
 	input.Search = app.readString(qs, "search", "")
+	input.Metadata = app.readMetadata(qs)
 	input.Filters.Page = app.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
 	input.Filters.Sort = app.readString(qs, "sort", "-created_at")
+	loc := app.readTimeZone(qs, "tz", v)
 	input.Filters.SortSafelist = []string{"created_at", "question", "-created_at", "-question"}
 
+	data.ValidateMetadata(v, input.Metadata)
 	if data.ValidateFilters(v, input.Filters); !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
-	polls, metadata, err := app.models.Polls.GetAll(input.Search, input.Filters)
+	// organization members list their organization's polls
+	var orgID uuid.UUID
+	if member, ok := app.memberFromContext(r.Context()); ok {
+		orgID = member.OrgID
+	}
+
+	polls, metadata, err := app.models.Polls.GetAll(input.Search, orgID, input.Metadata, input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 		return
 	}
 
-	if err := app.writeJSON(
+	for _, poll := range polls {
+		poll.InTimeZone(loc)
+		app.showPollResults(r, poll)
+	}
+
+	applied := map[string]string{
+		"sort":      input.Filters.Sort,
+		"page_size": strconv.Itoa(input.Filters.PageSize),
+	}
+	if input.Search != "" {
+		applied["search"] = input.Search
+	}
+	for key, value := range input.Metadata {
+		applied["metadata."+key] = value
+	}
+
+	if err := app.writeCachedJSON(
 		w,
-		http.StatusOK,
-		envelope{"polls": polls, "metadata": metadata},
-		nil,
+		r,
+		envelope{"polls": polls, "metadata": app.paginate(r, metadata, applied)},
+		cachePolicy{maxAge: cacheTTLActive},
 	); err != nil {
 		app.serverErrorResponse(w, err)
 	}
 }
+
+// listPollsByIDs lists the polls with the IDs in the ids query parameter with
+// their options, in the order they are given, so pages showing several polls
+// get them in one request. Polls that don't exist or that the request may not
+// see are left out, like organization members' polls of other organizations
+// on organization routes. Views aren't counted.
+func (app *application) listPollsByIDs(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+	ids := app.readIDs(qs, "ids", v)
+	loc := app.readTimeZone(qs, "tz", v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	polls, err := app.models.Polls.GetByIDs(ids)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	member, isMember := app.memberFromContext(r.Context())
+
+	policy := cachePolicy{maxAge: cacheTTLActive}
+	shown := make([]*data.Poll, 0, len(polls))
+	for _, poll := range polls {
+		if app.hidden(r, poll) || (isMember && (poll.OrgID == nil || *poll.OrgID != member.OrgID)) {
+			continue
+		}
+
+		app.showPollResults(r, poll)
+
+		// like GET /v1/polls/{pollID}, voters get their own order of the
+		// options and wording of the question
+		if (poll.ShuffleOptions || len(poll.QuestionVariants) > 0) && !app.can(r, poll.ID, auth.EditPoll) {
+			voter := voterIdentity(r)
+			if poll.ShuffleOptions {
+				poll.ShuffleFor(voter)
+			}
+			if len(poll.QuestionVariants) > 0 {
+				poll.ShowVariant(poll.VariantFor(voter))
+			}
+			policy.private = true
+		}
+
+		poll.InTimeZone(loc)
+		policy.keys = append(policy.keys, cdn.PollKey(poll.ID.String()))
+		shown = append(shown, poll)
+	}
+
+	if err := app.writeCachedJSON(w, r, envelope{"polls": shown}, policy); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
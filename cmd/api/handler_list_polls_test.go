@@ -6,6 +6,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/ivcp/polls/internal/data"
 )
 
 func Test_app_listPollsHandler(t *testing.T) {
@@ -90,3 +92,167 @@ func Test_app_listPollsHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_listPollsHandlerPagination(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		expectedMeta string
+	}{
+		{
+			name:         "first page",
+			url:          "/v1/polls?page_size=1",
+			expectedMeta: `"current_page":1,"page_size":1,"first_page":1,"last_page":2,"total_pages":2,"total_records":2,"next":"http://example.com/v1/polls?page=2\u0026page_size=1","filters":{"page_size":"1","sort":"-created_at"}`,
+		},
+		{
+			name:         "last page",
+			url:          "/v1/polls?page=2&page_size=1&sort=question",
+			expectedMeta: `"current_page":2,"page_size":1,"first_page":1,"last_page":2,"total_pages":2,"total_records":2,"prev":"http://example.com/v1/polls?page=1\u0026page_size=1\u0026sort=question","filters":{"page_size":"1","sort":"question"}`,
+		},
+		{
+			name:         "single page",
+			url:          "/v1/polls",
+			expectedMeta: `"current_page":1,"page_size":20,"first_page":1,"last_page":1,"total_pages":1,"total_records":2,"filters":{"page_size":"20","sort":"-created_at"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), `"metadata":{`+test.expectedMeta+`}`) {
+				t.Errorf("expected metadata %q, but got %q", test.expectedMeta, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_listPollsHandlerMetadata(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "matching metadata",
+			url:            "/v1/polls?metadata.team=platform",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"total_records":1,"filters":{"metadata.team":"platform","page_size":"20","sort":"-created_at"}`,
+		},
+		{
+			name:           "no match",
+			url:            "/v1/polls?metadata.team=platform&metadata.ticket_id=OPS-1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"polls":[]`,
+		},
+		{
+			name:           "invalid key",
+			url:            "/v1/polls?metadata.team%20name=platform",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"metadata":"key must only contain letters, digits, hyphens and underscores"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_listPollsHandlerByIDs(t *testing.T) {
+	var tooMany []string
+	for i := 0; i <= 50; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("00000000-0000-4000-8000-%012d", i))
+	}
+
+	tests := []struct {
+		name           string
+		ids            string
+		expectedStatus int
+		expectedIDs    []string
+		unexpectedIDs  []string
+		expectedBody   string
+	}{
+		{
+			name:           "in the given order",
+			ids:            data.ExamplePollIDThreshold.String() + "," + strings.ToUpper(data.ExamplePollIDValid.String()),
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{data.ExamplePollIDThreshold.String(), data.ExamplePollIDValid.String()},
+		},
+		{
+			name:           "missing and hidden left out",
+			ids:            data.ExamplePollIDValid.String() + ",00000000-0000-4000-8000-000000000000," + data.ExamplePollIDDraft.String(),
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{data.ExamplePollIDValid.String()},
+			unexpectedIDs:  []string{data.ExamplePollIDDraft.String()},
+		},
+		{
+			name:           "invalid id",
+			ids:            data.ExamplePollIDValid.String() + ",abc",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ids":"must only contain valid IDs"`,
+		},
+		{
+			name:           "empty",
+			ids:            "",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ids":"must be provided"`,
+		},
+		{
+			name:           "too many",
+			ids:            strings.Join(tooMany, ","),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ids":"must not contain more than 50 IDs"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/polls?ids="+test.ids, nil)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			body := rr.Body.String()
+			if !strings.Contains(body, test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, body)
+			}
+
+			last := -1
+			for _, id := range test.expectedIDs {
+				i := strings.Index(body, `"id":"`+id+`"`)
+				if i == -1 || i < last {
+					t.Errorf("expected poll %s after the polls before it, but got %q", id, body)
+				}
+				last = i
+			}
+			for _, id := range test.unexpectedIDs {
+				if strings.Contains(body, id) {
+					t.Errorf("expected poll %s to be left out, but got %q", id, body)
+				}
+			}
+		})
+	}
+}
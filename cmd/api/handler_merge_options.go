@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// mergeOptionsInput is the body of
+// POST /v1/polls/{pollID}/options/{optionID}/merge.
+type mergeOptionsInput struct {
+	Into *string `json:"into" validate:"required" doc:"ID of the option the votes are merged into"`
+}
+
+// mergeOptionsHandler merges the option into another option of the poll,
+// e.g. "NYC" into "New York". Unlike other option edits it is allowed once
+// voting has begun, as the merged option's votes are kept. Options of
+// verifiable polls can't be merged, as their ballots would no longer match
+// the votes they were given for.
+func (app *application) mergeOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+
+	if poll.Verifiable {
+		app.ballotsIssuedResponse(w)
+		return
+	}
+
+	optionID, err := app.readIDParam(r, "optionID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input mergeOptionsInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	schema.Validate(v, &input)
+
+	// an invalid ID is left as uuid.Nil, which is none of the poll's options
+	var intoID uuid.UUID
+	if input.Into != nil {
+		intoID, _ = uuid.Parse(*input.Into)
+	}
+	v.Check(intoID != optionID, "into", "must not be the option being merged")
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	var merged, into *data.PollOption
+	var newOptions []*data.PollOption
+	for _, option := range poll.Options {
+		switch option.ID {
+		case optionID:
+			merged = option
+		case intoID:
+			into = option
+			newOptions = append(newOptions, option)
+		default:
+			newOptions = append(newOptions, option)
+		}
+	}
+
+	if merged == nil || into == nil {
+		app.optionNotInPollResponse(w)
+		return
+	}
+
+	for _, option := range newOptions {
+		if option.Position > merged.Position {
+			option.Position -= 1
+		}
+	}
+
+	poll.Options = newOptions
+
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	votes, err := app.models.PollOptions.Merge(poll.ID, merged.ID, into.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrOptionNotInPoll):
+			app.optionNotInPollResponse(w)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(poll.ID, app.actor(r), data.ActionOptionsMerged, map[string]any{
+		"option_id": merged.ID,
+		"value":     merged.Value,
+		"into":      into.ID,
+		"votes":     votes,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "options merged successfully", "options": poll.Options}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
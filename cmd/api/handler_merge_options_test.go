@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_mergeOptionsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		optionID       string
+		json           string
+		expect         func(m repositoryMocks)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:     "merge",
+			optionID: data.ExampleOptionID1.String(),
+			json:     `{"into":"` + data.ExampleOptionID3.String() + `"}`,
+			expect: func(m repositoryMocks) {
+				m.options.EXPECT().Merge(data.ExamplePollIDValid, data.ExampleOptionID1, data.ExampleOptionID3).Return(2, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"` + data.ExampleOptionID3.String() + `","value":"Three","position":1}`,
+		},
+		{
+			name:           "into missing",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"into":"must be provided"`,
+		},
+		{
+			name:           "into itself",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"into":"` + data.ExampleOptionID1.String() + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"into":"must not be the option being merged"`,
+		},
+		{
+			name:           "option not in poll",
+			optionID:       uuid.NewString(),
+			json:           `{"into":"` + data.ExampleOptionID1.String() + `"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the option does not belong to this poll",
+		},
+		{
+			name:           "into not in poll",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"into":"` + uuid.NewString() + `"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the option does not belong to this poll",
+		},
+		{
+			name:           "verifiable poll",
+			pollID:         data.ExamplePollIDVerifiable,
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"into":"` + data.ExampleOptionID2.String() + `"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"code":"BALLOTS_ISSUED"`,
+		},
+		{
+			name:     "merged option deleted concurrently",
+			optionID: data.ExampleOptionID1.String(),
+			json:     `{"into":"` + data.ExampleOptionID3.String() + `"}`,
+			expect: func(m repositoryMocks) {
+				m.options.EXPECT().
+					Merge(data.ExamplePollIDValid, data.ExampleOptionID1, data.ExampleOptionID3).
+					Return(0, data.ErrOptionNotInPoll)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the option does not belong to this poll",
+		},
+		{
+			name:           "invalid option id",
+			optionID:       "invalid",
+			json:           `{"into":"` + data.ExampleOptionID1.String() + `"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := mockRepositories(t)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("optionID", test.optionID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.pollID == uuid.Nil {
+				test.pollID = data.ExamplePollIDValid
+			}
+			poll := examplePoll(t, test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.mergeOptionsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
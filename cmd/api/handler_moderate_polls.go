@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listFlaggedPollsHandler(w http.ResponseWriter, r *http.Request) {
+	polls, err := app.models.Polls.GetFlagged()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"polls": polls}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) moderatePollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input moderatePollInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Polls.Moderate(pollID, input.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(pollID, data.ActorModerator, data.ActionPollModerated, map[string]any{
+		"status": input.Status,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll " + input.Status}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
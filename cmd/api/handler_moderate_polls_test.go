@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listFlaggedPollsHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listFlaggedPollsHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	expectedBody := `"moderation_status":"flagged","moderation_terms":["Darn"]`
+	if !strings.Contains(rr.Body.String(), expectedBody) {
+		t.Errorf("expected body to contain %q, but got %q", expectedBody, rr.Body)
+	}
+}
+
+func Test_app_moderatePollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "approve poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"status":"approved"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "poll approved",
+		},
+		{
+			name:           "reject poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"status":"rejected"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "poll rejected",
+		},
+		{
+			name:           "invalid status",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"status":"flagged"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be approved or rejected",
+		},
+		{
+			name:           "unknown poll",
+			pollID:         "3c6a3f2e-64d1-4bd2-9d58-1b0c2e7f4a10",
+			json:           `{"status":"approved"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid poll id",
+			pollID:         "abc",
+			json:           `{"status":"approved"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.moderatePollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
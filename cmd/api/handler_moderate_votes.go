@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listFlaggedVotesHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	votes, err := app.models.Votes.GetFlagged(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"votes": votes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// moderateVoteInput is the body of PATCH /v1/polls/{pollID}/votes/{voteID}.
+type moderateVoteInput struct {
+	Status string `json:"status" validate:"required,oneof=accepted rejected"`
+}
+
+func (app *application) moderateVoteHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	voteID, err := app.readInt64Param(r, "voteID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input moderateVoteInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Votes.Moderate(pollID, voteID, input.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(pollID, app.actor(r), data.ActionVoteModerated, map[string]any{
+		"vote_id": voteID,
+		"status":  input.Status,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "vote " + input.Status}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
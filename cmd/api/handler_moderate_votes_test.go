@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listFlaggedVotesHandler(t *testing.T) {
+	mockRepositories(t).votes.EXPECT().GetFlagged(data.ExamplePollIDValid).Return([]*data.Vote{
+		{
+			ID:        data.ExampleFlaggedVoteID,
+			PollID:    data.ExamplePollIDValid,
+			OptionID:  data.ExampleOptionID1,
+			Score:     50,
+			Status:    data.VoteStatusSuspect,
+			CreatedAt: time.Now(),
+		},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listFlaggedVotesHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	expectedBody := fmt.Sprintf(`"id":%d`, data.ExampleFlaggedVoteID)
+	if !strings.Contains(rr.Body.String(), expectedBody) {
+		t.Errorf("expected body to contain %q, but got %q", expectedBody, rr.Body)
+	}
+}
+
+func Test_app_moderateVoteHandler(t *testing.T) {
+	moderated := func(voteID int64, status string, err error) func(m repositoryMocks) {
+		return func(m repositoryMocks) {
+			m.votes.EXPECT().Moderate(data.ExamplePollIDValid, voteID, status).Return(err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		voteID         string
+		json           string
+		expect         func(m repositoryMocks)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "accept vote",
+			voteID:         fmt.Sprint(data.ExampleFlaggedVoteID),
+			json:           `{"status":"accepted"}`,
+			expect:         moderated(data.ExampleFlaggedVoteID, data.VoteStatusAccepted, nil),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote accepted",
+		},
+		{
+			name:           "reject vote",
+			voteID:         fmt.Sprint(data.ExampleFlaggedVoteID),
+			json:           `{"status":"rejected"}`,
+			expect:         moderated(data.ExampleFlaggedVoteID, data.VoteStatusRejected, nil),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote rejected",
+		},
+		{
+			name:           "invalid status",
+			voteID:         fmt.Sprint(data.ExampleFlaggedVoteID),
+			json:           `{"status":"suspect"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be accepted or rejected",
+		},
+		{
+			name:           "vote not flagged",
+			voteID:         "7",
+			json:           `{"status":"accepted"}`,
+			expect:         moderated(7, data.VoteStatusAccepted, data.ErrRecordNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid vote id",
+			voteID:         "abc",
+			json:           `{"status":"accepted"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := mockRepositories(t)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
+			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("voteID", test.voteID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.moderateVoteHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// createOrgInput is the body of POST /v1/orgs.
+type createOrgInput struct {
+	Name      string `json:"name" validate:"required,max=200"`
+	AdminName string `json:"admin_name" validate:"required,max=100"`
+}
+
+// createOrgHandler creates an organization and its first admin, whose token
+// is only shown in the response.
+func (app *application) createOrgHandler(w http.ResponseWriter, r *http.Request) {
+	var input createOrgInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	input.AdminName = strings.TrimSpace(input.AdminName)
+
+	org := &data.Organization{Name: input.Name}
+	admin := &data.Member{Name: input.AdminName, Role: data.RoleAdmin}
+
+	v := validator.New()
+	data.ValidateOrganization(v, org)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Orgs.Insert(org, admin, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(
+		w,
+		http.StatusCreated,
+		envelope{"organization": org, "member": admin, "token": token.Plaintext},
+		nil,
+	)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) showOrgHandler(w http.ResponseWriter, r *http.Request) {
+	member, _ := app.memberFromContext(r.Context())
+
+	org, err := app.models.Orgs.Get(member.OrgID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"organization": org, "member": member}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) listOrgMembersHandler(w http.ResponseWriter, r *http.Request) {
+	member, _ := app.memberFromContext(r.Context())
+
+	members, err := app.models.Orgs.GetMembers(member.OrgID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"members": members}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// memberInput is the body of POST /v1/orgs/{orgID}/members.
+type memberInput struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Role string `json:"role" validate:"required,oneof=admin editor viewer"`
+}
+
+// addOrgMemberHandler adds a member, whose token is only shown in the
+// response.
+func (app *application) addOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	admin, _ := app.memberFromContext(r.Context())
+
+	var input memberInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+
+	member := &data.Member{
+		OrgID: admin.OrgID,
+		Name:  input.Name,
+		Role:  input.Role,
+	}
+
+	v := validator.New()
+	data.ValidateMember(v, member)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Orgs.InsertMember(member, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"member": member, "token": token.Plaintext}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// memberRoleInput is the body of PATCH /v1/orgs/{orgID}/members/{memberID}.
+type memberRoleInput struct {
+	Role string `json:"role" validate:"required,oneof=admin editor viewer"`
+}
+
+func (app *application) updateOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	admin, _ := app.memberFromContext(r.Context())
+
+	memberID, err := app.readInt64Param(r, "memberID")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input memberRoleInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	member := &data.Member{ID: memberID, OrgID: admin.OrgID, Role: input.Role}
+
+	err = app.models.Orgs.UpdateMemberRole(member)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLastAdmin):
+			app.lastAdminResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"member": member}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deleteOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	admin, _ := app.memberFromContext(r.Context())
+
+	memberID, err := app.readInt64Param(r, "memberID")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Orgs.DeleteMember(admin.OrgID, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLastAdmin):
+			app.lastAdminResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "member removed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_createOrgHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+	}{
+		{"valid", `{"name":"Acme","admin_name":"Jane"}`, http.StatusCreated},
+		{"missing name", `{"admin_name":"Jane"}`, http.StatusUnprocessableEntity},
+		{"missing admin name", `{"name":"Acme"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"name":"Acme","admin_name":"Jane","role":"viewer"}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createOrgHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			if rr.Code == http.StatusCreated {
+				var body struct {
+					Member data.Member `json:"member"`
+					Token  string      `json:"token"`
+				}
+				_ = json.Unmarshal(rr.Body.Bytes(), &body)
+				if body.Member.Role != data.RoleAdmin {
+					t.Errorf("expected admin member, but got %q", body.Member.Role)
+				}
+				if len(body.Token) != 26 {
+					t.Errorf("expected token in response, but got %q", body.Token)
+				}
+			}
+		})
+	}
+}
+
+func Test_app_orgMemberHandlers(t *testing.T) {
+	admin, _ := app.models.Orgs.GetMemberForToken(data.ExampleTokenOrgAdmin)
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		memberID       string
+		json           string
+		expectedStatus int
+	}{
+		{"show organization", app.showOrgHandler, "", "", http.StatusOK},
+		{"list members", app.listOrgMembersHandler, "", "", http.StatusOK},
+		{"add member", app.addOrgMemberHandler, "", `{"name":"Joe","role":"viewer"}`, http.StatusCreated},
+		{"add member with invalid role", app.addOrgMemberHandler, "", `{"name":"Joe","role":"owner"}`, http.StatusUnprocessableEntity},
+		{"promote member", app.updateOrgMemberHandler, "2", `{"role":"admin"}`, http.StatusOK},
+		{"demote last admin", app.updateOrgMemberHandler, "1", `{"role":"editor"}`, http.StatusConflict},
+		{"update missing member", app.updateOrgMemberHandler, "99", `{"role":"viewer"}`, http.StatusNotFound},
+		{"update invalid id", app.updateOrgMemberHandler, "x", `{"role":"viewer"}`, http.StatusNotFound},
+		{"remove member", app.deleteOrgMemberHandler, "2", "", http.StatusOK},
+		{"remove last admin", app.deleteOrgMemberHandler, "1", "", http.StatusConflict},
+		{"remove missing member", app.deleteOrgMemberHandler, "99", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", data.ExampleOrgID.String())
+			chiCtx.URLParams.Add("memberID", test.memberID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx)
+			ctx = context.WithValue(ctx, ctxMemberKey, admin)
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+			test.handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_createPollHandler_org(t *testing.T) {
+	editor, _ := app.models.Orgs.GetMemberForToken(data.ExampleTokenOrgEditor)
+
+	body := `{"question":"Test?","options":[{"value":"One","position":0},{"value":"Two","position":1}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), ctxMemberKey, editor))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.createPollHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, but got %d", http.StatusCreated, rr.Code)
+	}
+
+	var response struct {
+		Poll data.Poll `json:"poll"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Poll.OrgID == nil || *response.Poll.OrgID != data.ExampleOrgID {
+		t.Errorf("expected poll in organization %s, but got %v", data.ExampleOrgID, response.Poll.OrgID)
+	}
+}
+
+func Test_app_createPollHandler_orgQuota(t *testing.T) {
+	editor, _ := app.models.Orgs.GetMemberForToken(data.ExampleTokenOrgEditor)
+	defer func() { app.config.quotas.activePolls = 0 }()
+
+	tests := []struct {
+		name              string
+		limit             int
+		expectedStatus    int
+		expectedRemaining string
+	}{
+		{"unlimited", 0, http.StatusCreated, ""},
+		{"within quota", data.ExampleOrgActivePolls + 2, http.StatusCreated, "1"},
+		{"quota reached", data.ExampleOrgActivePolls, http.StatusForbidden, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.quotas.activePolls = test.limit
+
+			body := `{"question":"Test?","options":[{"value":"One","position":0},{"value":"Two","position":1}]}`
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req = req.WithContext(context.WithValue(req.Context(), ctxMemberKey, editor))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("X-Quota-Remaining"); got != test.expectedRemaining {
+				t.Errorf("expected X-Quota-Remaining %q, but got %q", test.expectedRemaining, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// pausePollInput is the optional body of POST /v1/polls/{pollID}/pause.
+type pausePollInput struct {
+	Reason string `json:"reason" validate:"max=200" doc:"shown to voters while the poll is paused"`
+}
+
+// pausePollHandler stops voting on the poll, e.g. while suspected abuse is
+// looked into, without closing it. Votes are rejected with the reason until
+// the poll is resumed.
+func (app *application) pausePollHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+
+	var input pausePollInput
+
+	if r.ContentLength != 0 {
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+	}
+
+	input.Reason = strings.TrimSpace(input.Reason)
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if !poll.Status(time.Now()).CanTransition(data.StatusPaused) {
+		app.invalidTransitionResponse(w)
+		return
+	}
+
+	pausedAt, err := app.models.Polls.Pause(poll.ID, input.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(poll.ID, app.actor(r), data.ActionPollPaused, map[string]any{"reason": input.Reason})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"paused_at": pausedAt, "pause_reason": input.Reason}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// resumePollHandler lets voting on a paused poll continue.
+func (app *application) resumePollHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+
+	if !poll.Status(time.Now()).CanTransition(data.StatusActive) {
+		app.invalidTransitionResponse(w)
+		return
+	}
+
+	err := app.models.Polls.Resume(poll.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if poll.PausedAt != nil {
+		app.recordActivity(poll.ID, app.actor(r), data.ActionPollResumed, nil)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "voting resumed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
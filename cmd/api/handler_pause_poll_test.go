@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_pausePollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             uuid.UUID
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "with reason",
+			id:             data.ExamplePollIDValid,
+			json:           `{"reason":" suspected abuse "}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"pause_reason":"suspected abuse"`,
+		},
+		{
+			name:           "without reason",
+			id:             data.ExamplePollIDValid,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"paused_at"`,
+		},
+		{
+			name:           "paused with another reason",
+			id:             data.ExamplePollIDPaused,
+			json:           `{"reason":"looking into it"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"pause_reason":"looking into it"`,
+		},
+		{
+			name:           "reason too long",
+			id:             data.ExamplePollIDValid,
+			json:           fmt.Sprintf(`{"reason":%q}`, strings.Repeat("a", 201)),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"reason":"must not be more than 200 bytes long"`,
+		},
+		{
+			name:           "draft",
+			id:             data.ExamplePollIDDraft,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"code":"INVALID_TRANSITION"`,
+		},
+		{
+			name:           "vote cap reached",
+			id:             data.ExamplePollIDVoteCapFull,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"code":"INVALID_TRANSITION"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			poll, _ := app.models.Polls.Get(test.id)
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.pausePollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_resumePollHandler(t *testing.T) {
+	poll, _ := app.models.Polls.Get(data.ExamplePollIDPaused)
+
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.resumePollHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "voting resumed") {
+		t.Errorf("unexpected response %s", rr.Body)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// listPollActivityHandler lists the poll's audit log: votes, without who
+// cast them, and changes to the poll, its options and tokens.
+func (app *application) listPollActivityHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 20, v),
+		Sort:         app.readString(qs, "sort", "-created_at"),
+		SortSafelist: []string{"created_at", "-created_at"},
+	}
+	loc := app.readTimeZone(qs, "tz", v)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	activities, metadata, err := app.models.AuditLog.GetForPoll(pollID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	for _, activity := range activities {
+		activity.CreatedAt = activity.CreatedAt.In(loc)
+	}
+
+	applied := map[string]string{
+		"sort":      filters.Sort,
+		"page_size": strconv.Itoa(filters.PageSize),
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"activity": activities, "metadata": app.paginate(r, metadata, applied)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listPollActivityHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "default page",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"action":"vote.cast","actor":"voter"`,
+		},
+		{
+			name:           "page size",
+			query:          "?page_size=5",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"page_size":5`,
+		},
+		{
+			name:           "applied filters",
+			query:          "?sort=created_at",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"filters":{"page_size":"20","sort":"created_at"}`,
+		},
+		{
+			name:           "page size too large",
+			query:          "?page_size=51",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"page_size":"must be a maximum of 50"`,
+		},
+		{
+			name:           "invalid sort",
+			query:          "?sort=action",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"sort":"invalid sort value"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listPollActivityHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// calendarLimit is the most deadlines a calendar feed lists.
+const calendarLimit = 500
+
+// showPollCalendarHandler serves the expiry times of the organization's open
+// polls as an iCalendar feed, for calendar apps to subscribe to.
+func (app *application) showPollCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	member, _ := app.memberFromContext(r.Context())
+
+	polls, err := app.models.Polls.GetDeadlines(member.OrgID, calendarLimit)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	var cal icalWriter
+	cal.line("BEGIN:VCALENDAR")
+	cal.line("VERSION:2.0")
+	cal.line("PRODID:-//ivcp//polls//EN")
+	cal.line("CALSCALE:GREGORIAN")
+	cal.line("METHOD:PUBLISH")
+	cal.line("X-WR-CALNAME:Poll deadlines")
+	cal.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	cal.line("X-PUBLISHED-TTL:PT1H")
+
+	for _, poll := range polls {
+		summary := "Poll closes: " + poll.Question
+		if poll.IsDraft {
+			summary = "Poll closes (draft): " + poll.Question
+		}
+		url := app.pollURL(r, poll.ID)
+		description := url
+		if poll.Description != "" {
+			description = poll.Description + "\n\n" + url
+		}
+
+		cal.line("BEGIN:VEVENT")
+		cal.line("UID:" + poll.ID.String())
+		cal.line("DTSTAMP:" + icalTime(poll.UpdatedAt))
+		cal.line("DTSTART:" + icalTime(poll.ExpiresAt.Time))
+		cal.line("SUMMARY:" + icalText(summary))
+		cal.line("DESCRIPTION:" + icalText(description))
+		cal.line("URL:" + url)
+		cal.line("END:VEVENT")
+	}
+
+	cal.line("END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="polls.ics"`)
+	w.Write(cal.Bytes())
+}
+
+// icalWriter writes the content lines of an iCalendar object, ending them
+// with CRLF and folding them at 75 octets as RFC 5545 requires.
+type icalWriter struct {
+	bytes.Buffer
+}
+
+func (c *icalWriter) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		// don't split a multi-byte character
+		i := limit
+		for !utf8.RuneStart(s[i]) {
+			i--
+		}
+		c.WriteString(s[:i] + "\r\n ")
+		s = s[i:]
+		// continuation lines start with a space
+		limit = 74
+	}
+	c.WriteString(s + "\r\n")
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icalTextReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// icalText escapes a TEXT property value.
+func icalText(s string) string {
+	return icalTextReplacer.Replace(s)
+}
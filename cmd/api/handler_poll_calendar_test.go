@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollCalendarHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		authHeader     string
+		expectedStatus int
+	}{
+		{"token in header", "", "Bearer " + data.ExampleTokenOrgViewer, http.StatusOK},
+		{"token in query", "?token=" + data.ExampleTokenOrgViewer, "", http.StatusOK},
+		{"no token", "", "", http.StatusUnauthorized},
+		{"poll token", "?token=" + data.ExampleTokenOwnerVoters, "", http.StatusUnauthorized},
+	}
+
+	routes := app.routes()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/polls/mine/calendar.ics"+test.query, nil)
+			req.RemoteAddr = "9.9.9.9:1234"
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d: %s", test.expectedStatus, rr.Code, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+				t.Errorf("expected a calendar, but got %q", ct)
+			}
+
+			body := rr.Body.String()
+			for _, expected := range []string{
+				"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+				"UID:" + data.ExamplePollIDOrg.String() + "\r\n",
+				"DTSTART:20240301T120000Z\r\n",
+				`SUMMARY:Poll closes: Lunch\, Friday?` + "\r\n",
+				`DESCRIPTION:Pizza\; or sushi\nvote now\n\n`,
+				"SUMMARY:Poll closes (draft): Offsite?\r\n",
+				"END:VEVENT\r\nEND:VCALENDAR\r\n",
+			} {
+				if !strings.Contains(body, expected) {
+					t.Errorf("expected calendar to contain %q, but got %q", expected, body)
+				}
+			}
+		})
+	}
+}
+
+func Test_icalWriter_line(t *testing.T) {
+	var cal icalWriter
+	cal.line("SUMMARY:" + strings.Repeat("a", 66) + "ü" + strings.Repeat("b", 80))
+
+	lines := strings.Split(strings.TrimSuffix(cal.String(), "\r\n"), "\r\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected the line to be folded in 3, but got %q", lines)
+	}
+	for i, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("expected line %d to be at most 75 octets, but got %d", i, len(line))
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("expected line %d to start with a space, but got %q", i, line)
+		}
+	}
+	if !strings.HasPrefix(lines[1], " ü") {
+		t.Errorf("expected the character to be kept whole, but got %q", lines[1])
+	}
+
+	unfolded := strings.ReplaceAll(cal.String(), "\r\n ", "")
+	if unfolded != "SUMMARY:"+strings.Repeat("a", 66)+"ü"+strings.Repeat("b", 80)+"\r\n" {
+		t.Errorf("expected folding to be reversible, but got %q", unfolded)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) showPollDigestHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	sub, err := app.models.Digests.GetSubscription(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"digest": sub}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// pollDigestInput is the body of PUT /v1/polls/{pollID}/digest. Digests are
+// sent by email or to a webhook.
+type pollDigestInput struct {
+	Frequency  string `json:"frequency" validate:"required,oneof=hourly daily"`
+	Email      string `json:"email" validate:"max=254,email"`
+	WebhookURL string `json:"webhook_url" validate:"max=2048,url"`
+}
+
+func (app *application) updatePollDigestHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	var input pollDigestInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Email = strings.TrimSpace(input.Email)
+	input.WebhookURL = strings.TrimSpace(input.WebhookURL)
+
+	sub := &data.DigestSubscription{
+		PollID:     id,
+		Frequency:  input.Frequency,
+		Email:      input.Email,
+		WebhookURL: input.WebhookURL,
+	}
+
+	v := validator.New()
+	if sub.Email != "" {
+		v.Check(app.mailer != nil, "email", "email digests are not supported by this server")
+	}
+	data.ValidateDigestSubscription(v, sub)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Digests.Subscribe(sub)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"digest": sub}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deletePollDigestHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	err := app.models.Digests.Unsubscribe(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "digest unsubscribed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
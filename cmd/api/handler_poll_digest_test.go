@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollDigestHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+	}{
+		{"subscribed", data.ExamplePollIDValid, http.StatusOK},
+		{"not subscribed", data.ExamplePollIDVotingStarted, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showPollDigestHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_updatePollDigestHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		mailer         bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "email",
+			json:           `{"frequency":"daily","email":"jane@example.com"}`,
+			mailer:         true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"email":"jane@example.com"`,
+		},
+		{
+			name:           "webhook",
+			json:           `{"frequency":"hourly","webhook_url":"https://example.com/hooks/polls"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"webhook_url":"https://example.com/hooks/polls"`,
+		},
+		{
+			name:           "invalid frequency",
+			json:           `{"frequency":"weekly","webhook_url":"https://example.com/hooks/polls"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be hourly or daily",
+		},
+		{
+			name:           "no recipient",
+			json:           `{"frequency":"daily"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "email or webhook_url must be provided",
+		},
+		{
+			name:           "email and webhook",
+			json:           `{"frequency":"daily","email":"jane@example.com","webhook_url":"https://example.com/hooks/polls"}`,
+			mailer:         true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must not be set together with email",
+		},
+		{
+			name:           "invalid webhook url",
+			json:           `{"frequency":"daily","webhook_url":"ftp://example.com/hooks"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be an absolute http or https URL",
+		},
+		{
+			name:           "no mailer",
+			json:           `{"frequency":"daily","email":"jane@example.com"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "email digests are not supported by this server",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.mailer {
+				app.mailer = &mockMailer{}
+				defer func() { app.mailer = nil }()
+			}
+
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.updatePollDigestHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_deletePollDigestHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+	}{
+		{"subscribed", data.ExamplePollIDValid, http.StatusOK},
+		{"not subscribed", data.ExamplePollIDVotingStarted, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.deletePollDigestHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// exportPollHandler returns a full copy of the poll, with its settings,
+// options and votes, which importPollHandler restores as a new poll.
+func (app *application) exportPollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	votes, err := app.models.Votes.GetAll(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	export := &data.PollExport{
+		Version:    data.ExportVersion,
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+		Poll:       poll,
+		Votes:      votes,
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="poll-%s.json"`, poll.ID))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"export": export}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// importPollInput is the body of POST /v1/polls/import, an export as
+// returned by GET /v1/polls/{pollID}/export.
+type importPollInput struct {
+	Export data.PollExport `json:"export" validate:"required"`
+}
+
+// importPollHandler restores a poll from an export as a new poll, with a new
+// ID and token. The poll doesn't join the exported poll's organization or
+// series, and its content is moderated like a new poll's.
+func (app *application) importPollHandler(w http.ResponseWriter, r *http.Request) {
+	var input importPollInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	export := &input.Export
+
+	v := validator.New()
+	data.ValidatePollExport(v, export)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	poll := export.Poll
+	poll.Question = app.text.Line(poll.Question)
+	poll.Description = app.text.Text(poll.Description)
+	for _, option := range poll.Options {
+		option.Value = app.text.Line(option.Value)
+		option.Description = app.text.Text(option.Description)
+		option.URL = strings.TrimSpace(option.URL)
+	}
+	for i, question := range poll.Demographics {
+		poll.Demographics[i].Key = strings.TrimSpace(question.Key)
+		poll.Demographics[i].Label = strings.TrimSpace(question.Label)
+		for j, choice := range question.Choices {
+			question.Choices[j] = strings.TrimSpace(choice)
+		}
+	}
+	poll.AllowedCountries = upperAll(poll.AllowedCountries)
+	poll.DeniedCountries = upperAll(poll.DeniedCountries)
+	poll.Tags = lowerAll(poll.Tags)
+	if poll.Language == "" {
+		poll.Language = pollLanguage(poll)
+	}
+
+	if poll.ResultsVisibility == "" {
+		poll.ResultsVisibility = "always"
+	}
+	if poll.TieBreak == "" {
+		poll.TieBreak = "shared"
+	}
+	if poll.Anonymity == "" {
+		poll.Anonymity = "anonymous"
+	}
+	if poll.Visibility == "" {
+		poll.Visibility = data.VisibilityPublic
+	}
+	if poll.CreatedAt.IsZero() {
+		poll.CreatedAt = time.Now()
+	}
+	if poll.PausedAt == nil {
+		poll.PauseReason = ""
+	}
+	poll.OrgID = nil
+	poll.SeriesID = nil
+	poll.ModerationStatus = ""
+	poll.ModerationTerms = nil
+
+	texts := []*string{&poll.Question, &poll.Description}
+	for _, option := range poll.Options {
+		texts = append(texts, &option.Value, &option.Description)
+	}
+	moderated, err := app.moderate(texts...)
+	if err != nil {
+		app.contentRejectedResponse(w)
+		return
+	}
+	if moderated.Flagged {
+		poll.ModerationStatus = data.ModerationFlagged
+		poll.ModerationTerms = moderated.Terms
+	}
+
+	v.Check(
+		app.geoip != nil || !poll.GeoRestricted(),
+		"allowed_countries",
+		"country restrictions are not supported by this server",
+	)
+	// backups of polls that have since expired are restored as expired
+	// polls, so the expiry is left out of validation
+	unexpired := *poll
+	unexpired.ExpiresAt = data.ExpiresAt{}
+	data.ValidatePoll(v, &unexpired)
+	data.ValidatePauseReason(v, poll.PauseReason)
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if !app.allowPollCreation(w, r, poll) {
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Import(export, token.Hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrCreationLimited):
+			app.creationLimitedResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	poll.Token = token.Plaintext
+
+	app.publishEvent(events.PollCreated(poll))
+	app.recordActivity(poll.ID, app.actor(r), data.ActionPollImported, map[string]any{
+		"votes": len(export.Votes),
+	})
+	if moderated.Flagged {
+		app.recordActivity(poll.ID, data.ActorModerator, data.ActionPollFlagged, map[string]any{
+			"terms": moderated.Terms,
+		})
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/polls/%s", poll.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"poll": poll}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
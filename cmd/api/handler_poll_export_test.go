@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_exportPollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"export", data.ExamplePollIDValid, http.StatusOK, `"votes":[{"id":1,`},
+		{"poll missing", uuid.Nil, http.StatusNotFound, "could not be found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.exportPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_importPollHandler(t *testing.T) {
+	// an export of the example poll imports as it is
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+	app.exportPollHandler(rr, req)
+	exported := rr.Body.String()
+
+	options := `"options":[{"id":"` + data.ExampleOptionID1.String() + `","value":"One","position":0},` +
+		`{"id":"` + data.ExampleOptionID2.String() + `","value":"Two","position":1}]`
+
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "exported poll",
+			json:           exported,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question":"Test?"`,
+		},
+		{
+			name: "expired poll",
+			json: `{"export":{"version":1,"poll":{"question":"Test?",` + options +
+				`,"expires_at":"2024-02-05T14:00:00Z"},"votes":[{"option_id":"` + data.ExampleOptionID1.String() + `","status":"accepted"}]}}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"expires_at":"2024-02-05T14:00:00Z"`,
+		},
+		{
+			name:           "no expiry",
+			json:           `{"export":{"version":1,"poll":{"question":"Test?",` + options + `,"expires_at":""}}}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"expires_at":""`,
+		},
+		{
+			name:           "unsupported version",
+			json:           `{"export":{"version":2,"poll":{"question":"Test?",` + options + `}}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"version":"unsupported export version"`,
+		},
+		{
+			name:           "poll missing",
+			json:           `{"export":{"version":1}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"poll":"must be provided"`,
+		},
+		{
+			name: "vote for unknown option",
+			json: `{"export":{"version":1,"poll":{"question":"Test?",` + options +
+				`},"votes":[{"option_id":"` + uuid.NewString() + `","status":"accepted"}]}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"votes":"must be for the poll's options"`,
+		},
+		{
+			name: "invalid vote status",
+			json: `{"export":{"version":1,"poll":{"question":"Test?",` + options +
+				`},"votes":[{"option_id":"` + data.ExampleOptionID1.String() + `","status":"counted"}]}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"votes":"invalid vote status"`,
+		},
+		{
+			name: "invalid poll",
+			json: `{"export":{"version":1,"poll":{"question":"",` + options +
+				`}}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"question":"must not be empty"`,
+		},
+		{
+			name:           "private poll exported before visibility",
+			json:           `{"export":{"version":1,"poll":{"question":"Test?",` + options + `,"is_private":true}}}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"visibility":"unlisted"`,
+		},
+		{
+			name: "lifecycle fields",
+			json: `{"export":{"version":1,"poll":{"question":"Test?",` + options +
+				`,"status":"expired","seconds_until_expiry":0,"is_votable":false}}}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"status":"active"`,
+		},
+		{
+			name:           "unknown poll field",
+			json:           `{"export":{"version":1,"poll":{"question":"Test?",` + options + `,"secret":true}}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `body contains unknown key \"secret\"`,
+		},
+		{
+			name:           "unknown field",
+			json:           `{"poll":{}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "body contains unknown key",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.importPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+
+			if rr.Code != http.StatusCreated {
+				return
+			}
+			var body struct {
+				Poll data.Poll `json:"poll"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Poll.ID == data.ExamplePollIDValid || body.Poll.Token == "" {
+				t.Errorf("expected a new poll with a token, but got %+v", body.Poll)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// pollNotificationsInput is the body of PUT /v1/polls/{pollID}/notifications.
+type pollNotificationsInput struct {
+	Email string `json:"email" validate:"required,max=254,email" doc:"notified when the poll closes"`
+}
+
+func (app *application) updatePollNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+
+	var input pollNotificationsInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Email = strings.TrimSpace(input.Email)
+
+	v := validator.New()
+	v.Check(app.mailer != nil, "email", "email notifications are not supported by this server")
+	data.ValidateEmail(v, input.Email, "email")
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Polls.SetNotifyEmail(poll.ID, input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "notifications enabled"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deletePollNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	err := app.models.Polls.SetNotifyEmail(id, "")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "notifications disabled"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
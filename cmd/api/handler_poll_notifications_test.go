@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_updatePollNotificationsHandler(t *testing.T) {
+	poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+
+	tests := []struct {
+		name           string
+		json           string
+		mailer         bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid email",
+			json:           `{"email":"jane@example.com"}`,
+			mailer:         true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "notifications enabled",
+		},
+		{
+			name:           "invalid email",
+			json:           `{"email":"jane@"}`,
+			mailer:         true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be a valid email address",
+		},
+		{
+			name:           "empty email",
+			json:           `{"email":""}`,
+			mailer:         true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be provided",
+		},
+		{
+			name:           "no mailer",
+			json:           `{"email":"jane@example.com"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "email notifications are not supported by this server",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.mailer {
+				app.mailer = &mockMailer{}
+				defer func() { app.mailer = nil }()
+			}
+
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.updatePollNotificationsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_deletePollNotificationsHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.deletePollNotificationsHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "notifications disabled") {
+		t.Errorf("expected body to contain %q, but got %q", "notifications disabled", rr.Body)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+func (app *application) showPollQRHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	format := app.readString(qs, "format", "png")
+	size := app.readInt(qs, "size", 256, v)
+	level := strings.ToUpper(app.readString(qs, "ec", "M"))
+
+	v.Check(validator.PermittedValue(format, "png", "svg"), "format", "must be png or svg")
+	v.Check(size >= 64, "size", "must be at least 64")
+	v.Check(size <= 1024, "size", "must be a maximum of 1024")
+	v.Check(validator.PermittedValue(level, "L", "M", "Q", "H"), "ec", "must be one of L, M, Q, H")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	qr, err := qrcode.New(app.pollURL(r, poll.ID), qrRecoveryLevels[level])
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	var body []byte
+	switch format {
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		body = qrSVG(qr.Bitmap(), size)
+	default:
+		body, err = qr.PNG(size)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// qrSVG draws every dark module of the bitmap as a 1x1 square scaled to size.
+func qrSVG(bitmap [][]bool, size int) []byte {
+	var svg strings.Builder
+
+	fmt.Fprintf(
+		&svg,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap),
+	)
+	svg.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&svg, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	svg.WriteString(`"/></svg>`)
+
+	return []byte(svg.String())
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollQRHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		pollID              string
+		query               string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "default png",
+			pollID:              data.ExamplePollIDValid.String(),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+		},
+		{
+			name:                "svg",
+			pollID:              data.ExamplePollIDValid.String(),
+			query:               "?format=svg&size=128&ec=h",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/svg+xml",
+			expectedBody:        `width="128" height="128"`,
+		},
+		{
+			name:           "invalid format",
+			pollID:         data.ExamplePollIDValid.String(),
+			query:          "?format=gif",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"format":"must be png or svg"`,
+		},
+		{
+			name:           "size too large",
+			pollID:         data.ExamplePollIDValid.String(),
+			query:          "?size=4096",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"size":"must be a maximum of 1024"`,
+		},
+		{
+			name:           "invalid error correction",
+			pollID:         data.ExamplePollIDValid.String(),
+			query:          "?ec=X",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"ec":"must be one of L, M, Q, H"`,
+		},
+		{
+			name:           "unexisting poll",
+			pollID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showPollQRHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if test.expectedContentType != "" && rr.Header().Get("Content-Type") != test.expectedContentType {
+				t.Errorf("expected Content-Type %q, but got %q", test.expectedContentType, rr.Header().Get("Content-Type"))
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if test.expectedContentType == "image/png" {
+				img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+				if err != nil {
+					t.Fatalf("response is not a valid png: %s", err)
+				}
+				if img.Bounds().Dx() != 256 {
+					t.Errorf("expected image width 256, but got %d", img.Bounds().Dx())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) showPollSMSHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	number, err := app.models.SMSNumbers.GetForPoll(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sms": number}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// pollSMSInput is the body of PUT /v1/polls/{pollID}/sms. The number is one
+// rented from Twilio with its messaging webhook pointing to the API.
+type pollSMSInput struct {
+	PhoneNumber string `json:"phone_number" validate:"required,max=16"`
+}
+
+func (app *application) updatePollSMSHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	var input pollSMSInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	number := &data.SMSNumber{
+		PollID:      id,
+		PhoneNumber: strings.TrimSpace(input.PhoneNumber),
+	}
+
+	v := validator.New()
+	v.Check(app.config.twilio.authToken != "", "phone_number", "sms voting is not supported by this server")
+	data.ValidateSMSNumber(v, number)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.SMSNumbers.Bind(number)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNumberTaken):
+			app.failedValidationResponse(w, map[string]string{
+				"phone_number": "is bound to another poll that is still open",
+			})
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sms": number}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deletePollSMSHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	err := app.models.SMSNumbers.Unbind(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "phone number unbound"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
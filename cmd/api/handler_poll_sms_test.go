@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollSMSHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+	}{
+		{"bound", data.ExamplePollIDValid, http.StatusOK},
+		{"not bound", data.ExamplePollIDVotingStarted, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showPollSMSHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_updatePollSMSHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		authToken      string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid",
+			json:           `{"phone_number":"` + data.ExampleSMSNumber + `"}`,
+			authToken:      "authtoken",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"phone_number":"+15550002222"`,
+		},
+		{
+			name:           "invalid number",
+			json:           `{"phone_number":"555-0100"}`,
+			authToken:      "authtoken",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be in E.164 format",
+		},
+		{
+			name:           "no number",
+			json:           `{}`,
+			authToken:      "authtoken",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be provided",
+		},
+		{
+			name:           "number taken",
+			json:           `{"phone_number":"` + data.ExampleSMSNumberTaken + `"}`,
+			authToken:      "authtoken",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "is bound to another poll that is still open",
+		},
+		{
+			name:           "not configured",
+			json:           `{"phone_number":"` + data.ExampleSMSNumber + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "sms voting is not supported by this server",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.twilio.authToken = test.authToken
+			defer func() { app.config.twilio.authToken = "" }()
+
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.updatePollSMSHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_deletePollSMSHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+	}{
+		{"bound", data.ExamplePollIDValid, http.StatusOK},
+		{"not bound", data.ExamplePollIDVotingStarted, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.deletePollSMSHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
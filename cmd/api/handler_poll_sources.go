@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+)
+
+// showPollSourcesHandler shows how often the poll was viewed and voted on
+// from each source, so owners can tell which channels drive engagement.
+func (app *application) showPollSourcesHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	sources, err := app.models.Views.GetSourceCounts(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sources": sources}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
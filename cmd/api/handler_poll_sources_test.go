@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollSourcesHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.showPollSourcesHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	var body struct {
+		Sources []data.SourceCount `json:"sources"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	if len(body.Sources) != 2 || body.Sources[0].Source != "slack" || body.Sources[0].Votes != 4 {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/results"
+)
+
+// showPollStatsHandler shows how often the poll was viewed and what share of
+// the views turned into votes.
+func (app *application) showPollStatsHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	stats, err := app.models.Views.GetStats(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	// conversion can exceed 100%, as votes from chat integrations or other
+	// clients don't come with a view of the poll
+	var conversion *float64
+	if stats.Views > 0 {
+		c := results.Round(float64(stats.Votes) / float64(stats.Views) * 100)
+		conversion = &c
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"stats": envelope{
+		"views":      stats.Views,
+		"votes":      stats.Votes,
+		"conversion": conversion,
+	}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollStatsHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		pollID       uuid.UUID
+		expectedBody string
+	}{
+		{"views and votes", data.ExamplePollIDValid, `{"stats":{"conversion":33.33,"views":15,"votes":5}}`},
+		{"no views", data.ExamplePollIDVotingStarted, `{"stats":{"conversion":null,"views":0,"votes":0}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showPollStatsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+			}
+			if strings.TrimSpace(rr.Body.String()) != test.expectedBody {
+				t.Errorf("expected body %s, but got %s", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
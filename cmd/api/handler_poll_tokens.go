@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// createPollTokenInput is the body of POST /v1/polls/{pollID}/tokens.
+type createPollTokenInput struct {
+	Scope     string    `json:"scope" validate:"required,oneof=manage results vote preview kiosk"`
+	ExpiresAt time.Time `json:"expires_at" doc:"the token never expires if not set"`
+}
+
+// createPollTokenHandler issues an additional token for the poll, e.g. a
+// results token for stakeholders who shouldn't be able to edit the poll.
+func (app *application) createPollTokenHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	var input createPollTokenInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	token.Scope = input.Scope
+	token.Expiry = input.ExpiresAt
+
+	v := validator.New()
+	data.ValidateToken(v, token)
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Tokens.Insert(id, token)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{
+		"token":      token.Plaintext,
+		"scope":      token.Scope,
+		"expires_at": data.ExpiresAt{Time: token.Expiry},
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// rotatePollTokenHandler replaces the token the request was made with by a
+// new one. The old token stops working immediately.
+func (app *application) rotatePollTokenHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	current, ok := app.readBearerToken(r)
+	if !ok {
+		app.invalidTokenResponse(w)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Tokens.Rotate(id, data.HashToken(current), token.Hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidTokenResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(id, app.actor(r), data.ActionTokenRotated, nil)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"token": token.Plaintext}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// revokePollTokensHandler deletes every token of the poll, including the one
+// the request was made with. There is no way to manage the poll afterwards.
+func (app *application) revokePollTokensHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	_, err := app.models.Tokens.RevokeAll(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	app.recordActivity(id, app.actor(r), data.ActionTokensRevoked, nil)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "tokens revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// feedSize is the number of polls in the feed.
+const feedSize = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary,omitempty"`
+}
+
+// listPollsFeedHandler serves the newest public polls as an Atom feed, for
+// sites to syndicate.
+func (app *application) listPollsFeedHandler(w http.ResponseWriter, r *http.Request) {
+	filters := data.Filters{
+		Page:         1,
+		PageSize:     feedSize,
+		Sort:         "-created_at",
+		SortSafelist: []string{"-created_at"},
+	}
+
+	polls, _, err := app.models.Polls.GetAll("", uuid.Nil, nil, filters)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	self := app.externalURL(r, "/v1/polls/feed.atom")
+	feed := atomFeed{
+		ID:     self,
+		Title:  "New polls",
+		Author: atomAuthor{Name: "Polls"},
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: self},
+		},
+	}
+
+	// an empty feed was last updated at an unknown time, the epoch stands in
+	// for it so the feed stays valid
+	updated := time.Unix(0, 0)
+	for _, poll := range polls {
+		if poll.UpdatedAt.After(updated) {
+			updated = poll.UpdatedAt
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        "urn:uuid:" + poll.ID.String(),
+			Title:     poll.Question,
+			Links:     []atomLink{{Rel: "alternate", Href: app.pollURL(r, poll.ID)}},
+			Published: poll.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   poll.UpdatedAt.UTC().Format(time.RFC3339),
+			Summary:   poll.Description,
+		})
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	body = append([]byte(xml.Header), body...)
+	app.writeCached(w, r, "application/atom+xml; charset=utf-8", body, cachePolicy{maxAge: cacheTTLFeed})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listPollsFeedHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/v1/polls/feed.atom", nil)
+	req.Host = "polls.example.com"
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listPollsFeedHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Errorf("expected an Atom feed, but got %q", ct)
+	}
+
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<link rel="self" type="application/atom+xml" href="http://polls.example.com/v1/polls/feed.atom"></link>`,
+		`<updated>2024-02-27T10:00:00Z</updated>`,
+		`<title>Tabs &lt;or&gt; spaces?</title>`,
+		`<link rel="alternate" href="http://polls.example.com/v1/polls/` + data.ExamplePollIDValid.String() + `"></link>`,
+		`<summary>Settle it &amp; move on</summary>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected feed to contain %q, but got %q", expected, body)
+		}
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(rr.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("expected valid XML: %s", err)
+	}
+	if len(feed.Entries) != 2 || feed.Entries[0].ID != "urn:uuid:"+data.ExamplePollIDValid.String() {
+		t.Errorf("expected an entry per poll, newest first, but got %+v", feed.Entries)
+	}
+	if feed.Entries[1].Summary != "" || strings.Count(body, "<summary>") != 1 {
+		t.Error("expected no summary for a poll without a description")
+	}
+}
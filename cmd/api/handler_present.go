@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/results"
+)
+
+// presentation is what the presentation view of a poll shows, sent again
+// whenever it changes.
+type presentation struct {
+	Question   string          `json:"question"`
+	TotalVotes int             `json:"total_votes"`
+	Results    []segmentOption `json:"results"`
+	// Message says why the results are hidden, if they are.
+	Message string `json:"message,omitempty"`
+	Closed  bool   `json:"closed"`
+}
+
+// presentPoll returns the poll's presentation, following its results
+// visibility unless the request is authorized to view the results.
+func (app *application) presentPoll(r *http.Request, poll *data.Poll) (*presentation, error) {
+	p := &presentation{
+		Question: poll.Question,
+		Results:  []segmentOption{},
+		Closed:   poll.Status(time.Now()).Ended(),
+	}
+
+	switch {
+	case app.can(r, poll.ID, auth.ViewResults):
+	case poll.ResultsVisibility == "after_vote":
+		// the screen the poll is presented on doesn't vote
+		p.Message = "Results are shown to voters after they vote."
+		return p, nil
+	case poll.ResultsVisibility == "after_deadline" && !p.Closed:
+		p.Message = "Results are shown when the poll closes."
+		return p, nil
+	}
+
+	options, err := app.models.PollOptions.GetResults(poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	summary := results.Calculate(options, poll.TieBreak, results.Seed(poll.ID))
+	p.TotalVotes = summary.TotalVotes
+
+	if summary.TotalVotes < poll.ResultsThreshold {
+		p.Message = fmt.Sprintf("Results are shown once %d more votes are cast.", poll.ResultsThreshold-summary.TotalVotes)
+		return p, nil
+	}
+
+	for i, opt := range options {
+		p.Results = append(p.Results, segmentOption{
+			ID:        opt.ID,
+			Value:     opt.Value,
+			VoteCount: opt.VoteCount,
+			Percent:   summary.Options[i].Percent,
+		})
+	}
+	return p, nil
+}
+
+// readPresentedPoll reads the poll in the URL, responding with an error if
+// it can't be presented.
+func (app *application) readPresentedPoll(w http.ResponseWriter, r *http.Request) (*data.Poll, bool) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, false
+	}
+
+	if app.hidden(r, poll) {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	return poll, true
+}
+
+// showPollPresentationHandler serves a full screen page of the poll's results
+// for projecting during meetings, which updates itself from
+// presentPollEventsHandler.
+func (app *application) showPollPresentationHandler(w http.ResponseWriter, r *http.Request) {
+	poll, ok := app.readPresentedPoll(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := app.presentPoll(r, poll)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(nonceBytes)
+
+	// the token the page was opened with is passed on to the event stream,
+	// as EventSource can't send headers
+	eventsURL := "/v1/polls/" + poll.ID.String() + "/present/events"
+	if token := r.URL.Query().Get("token"); token != "" {
+		eventsURL += "?token=" + url.QueryEscape(token)
+	}
+
+	var buf bytes.Buffer
+	err = templates.ExecuteTemplate(&buf, "present.tmpl", map[string]any{
+		"Presentation": p,
+		"Nonce":        nonce,
+		"VoteURL":      app.pollURL(r, poll.ID),
+		"EventsURL":    app.externalURL(r, eventsURL),
+	})
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Security-Policy", fmt.Sprintf(
+		"default-src 'none'; style-src 'nonce-%[1]s'; script-src 'nonce-%[1]s'; connect-src 'self'; base-uri 'none'; form-action 'none'",
+		nonce,
+	))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// presentHeartbeat is how often a comment is sent on an event stream without
+// changes, so proxies don't close it as idle.
+const presentHeartbeat = 15 * time.Second
+
+// presentPollEventsHandler streams the poll's presentation as server-sent
+// events, sending it again whenever the results change. The stream ends once
+// the poll has closed.
+func (app *application) presentPollEventsHandler(w http.ResponseWriter, r *http.Request) {
+	poll, ok := app.readPresentedPoll(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// nginx would otherwise buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// each write gets as long as a regular response, however long the
+	// stream is open
+	rc := http.NewResponseController(w)
+	write := func(s string) error {
+		err := rc.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if _, err := fmt.Fprint(w, s); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	ticker := time.NewTicker(app.config.liveInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	lastWrite := time.Now()
+	for {
+		p, err := app.presentPoll(r, poll)
+		if err != nil {
+			app.logError(err)
+			return
+		}
+
+		body, err := json.Marshal(p)
+		if err != nil {
+			app.logError(err)
+			return
+		}
+
+		switch {
+		case !bytes.Equal(body, last):
+			err = write(fmt.Sprintf("event: results\ndata: %s\n\n", body))
+			last, lastWrite = body, time.Now()
+		case time.Since(lastWrite) >= presentHeartbeat:
+			err = write(": heartbeat\n\n")
+			lastWrite = time.Now()
+		}
+		if err != nil || p.Closed {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		// the poll is read again for changes to its expiry and settings
+		poll, err = app.models.Polls.Get(poll.ID)
+		if err != nil {
+			if !errors.Is(err, data.ErrRecordNotFound) {
+				app.logError(err)
+			}
+			return
+		}
+	}
+}
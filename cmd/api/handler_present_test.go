@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPollPresentationHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"results", data.ExamplePollIDDemographics.String(), "", http.StatusOK, "<span>One</span><span>4 (80%)</span>"},
+		{"below threshold", data.ExamplePollIDThreshold.String(), "", http.StatusOK, "Results are shown once 2 more votes are cast."},
+		{"after deadline", data.ExamplePollIDAfterDeadline.String(), "", http.StatusOK, "Results are shown when the poll closes."},
+		{"token passed to events", data.ExamplePollIDDemographics.String(), "?token=" + data.ExampleTokenDemographics, http.StatusOK, "/present/events?token=" + data.ExampleTokenDemographics},
+		{"unexisting poll", uuid.NewString(), "", http.StatusNotFound, "the requested resource could not be found"},
+		{"invalid id", "a", "", http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := app.tokenFromQuery(http.HandlerFunc(app.showPollPresentationHandler))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			csp := rr.Header().Get("Content-Security-Policy")
+			nonce := strings.SplitN(strings.SplitN(csp, "'nonce-", 2)[1], "'", 2)[0]
+			if !strings.Contains(rr.Body.String(), `<script nonce="`+nonce+`">`) {
+				t.Errorf("expected inline script to carry the CSP nonce %q", nonce)
+			}
+		})
+	}
+}
+
+func Test_app_presentPollEventsHandler(t *testing.T) {
+	app.config.liveInterval = 10 * time.Millisecond
+	defer func() { app.config.liveInterval = 0 }()
+
+	tests := []struct {
+		name         string
+		pollID       string
+		expectedBody string
+		// closed polls end the stream, open ones are streamed until the
+		// client disconnects
+		closed bool
+	}{
+		{"open poll", data.ExamplePollIDThreshold.String(), `"message":"Results are shown once 2 more votes are cast."`, false},
+		{"closed poll", data.ExamplePollIDExpiredPoll.String(), `"closed":true`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.presentPollEventsHandler)
+
+			start := time.Now()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+				t.Errorf("expected content type text/event-stream, but got %q", ct)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			// unchanged results aren't sent again
+			if n := strings.Count(rr.Body.String(), "event: results"); n != 1 {
+				t.Errorf("expected one event, but got %d", n)
+			}
+			if test.closed && time.Since(start) >= 100*time.Millisecond {
+				t.Error("expected the stream of a closed poll to end")
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// statsCache holds the public stats for the stats TTL, as computing them
+// scans the day's votes.
+type statsCache struct {
+	mu        sync.Mutex
+	stats     *data.PublicStats
+	expiresAt time.Time
+}
+
+// get returns the cached stats, computing them if they expired. Requests
+// arriving while they are computed wait for them rather than computing them
+// again.
+func (c *statsCache) get(ttl time.Duration, compute func() (*data.PublicStats, error)) (*data.PublicStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats != nil && time.Now().Before(c.expiresAt) {
+		return c.stats, nil
+	}
+
+	stats, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	c.stats = stats
+	c.expiresAt = time.Now().Add(ttl)
+
+	return stats, nil
+}
+
+func (app *application) showPublicStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := app.publicStats.get(app.config.statsTTL, app.models.Polls.GetPublicStats)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeCachedJSON(w, r, envelope{"stats": stats}, cachePolicy{
+		maxAge:       app.config.statsTTL,
+		lastModified: stats.UpdatedAt,
+	})
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showPublicStatsHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/v1/stats", nil)
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.showPublicStatsHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	expected := `"public_polls":2,"votes_today":5,"most_active_poll":{"id":"` + data.ExamplePollIDValid.String() + `","question":"Test?","votes_today":4}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("expected body to contain %q, but got %q", expected, rr.Body)
+	}
+}
+
+func Test_statsCache_get(t *testing.T) {
+	var cache statsCache
+	var calls int
+	var fail bool
+	compute := func() (*data.PublicStats, error) {
+		calls++
+		if fail {
+			return nil, errors.New("database down")
+		}
+		return &data.PublicStats{VotesToday: calls}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		stats, err := cache.get(time.Hour, compute)
+		if err != nil || stats.VotesToday != 1 {
+			t.Fatalf("expected the cached stats, but got %+v, %v", stats, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the stats to be computed once, but got %d", calls)
+	}
+
+	cache.expiresAt = time.Now().Add(-time.Second)
+	fail = true
+	if _, err := cache.get(time.Hour, compute); err == nil {
+		t.Error("expected err for failed computation, but didn't get one")
+	}
+
+	fail = false
+	stats, _ := cache.get(time.Hour, compute)
+	if calls != 3 || stats.VotesToday != 3 {
+		t.Errorf("expected expired stats to be computed again, but got %+v after %d calls", stats, calls)
+	}
+}
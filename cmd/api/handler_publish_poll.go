@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// publishPollHandler makes a draft poll visible and open for voting.
+func (app *application) publishPollHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+
+	err := app.models.Polls.Publish(poll.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if poll.IsDraft {
+		app.recordActivity(poll.ID, app.actor(r), data.ActionPollPublished, nil)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll published"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_publishPollHandler(t *testing.T) {
+	poll, _ := app.models.Polls.Get(data.ExamplePollIDDraft)
+
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.publishPollHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "poll published") {
+		t.Errorf("unexpected response %s", rr.Body)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/receipts"
+)
+
+// showReceiptHandler verifies a vote receipt and reports whether its vote
+// is still recorded. Only the receipt's own vote is looked up, so receipts
+// reveal nothing about other votes. The endpoint is not found when no
+// receipt key is configured.
+func (app *application) showReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	if len(app.config.receipts.key) == 0 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	receipt, err := receipts.Verify(app.config.receipts.key, chi.URLParam(r, "receiptID"))
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	response := struct {
+		*receipts.Receipt
+		Recorded bool   `json:"recorded"`
+		Status   string `json:"status,omitempty"`
+	}{Receipt: receipt}
+
+	// the vote may since have been rejected by moderation, or removed with
+	// its poll
+	vote, err := app.models.Votes.Get(receipt.PollID, receipt.VoteID)
+	switch {
+	case err == nil:
+		response.Recorded = vote.OptionID == receipt.OptionID
+		if response.Recorded {
+			response.Status = vote.Status
+		}
+	case !errors.Is(err, data.ErrRecordNotFound):
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"receipt": response}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
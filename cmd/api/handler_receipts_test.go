@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/receipts"
+)
+
+var testReceiptKey = []byte("fedcba9876543210fedcba9876543210")
+
+func Test_app_voteOptionHandler_receipt(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         []byte
+		wantReceipt bool
+	}{
+		{"receipt key set", testReceiptKey, true},
+		{"no receipt key", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.receipts.key = test.key
+			defer func() { app.config.receipts.key = nil }()
+
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("X-Forwarded-For", "0.0.0.0")
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
+			chiCtx.URLParams.Add("optionID", data.ExampleOptionID1.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.voteOptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+			}
+
+			var body struct {
+				Receipt *receipts.Receipt `json:"receipt"`
+			}
+			_ = json.Unmarshal(rr.Body.Bytes(), &body)
+
+			if !test.wantReceipt {
+				if body.Receipt != nil {
+					t.Errorf("expected no receipt, but got %+v", body.Receipt)
+				}
+				return
+			}
+			if body.Receipt == nil {
+				t.Fatal("expected a receipt")
+			}
+			receipt, err := receipts.Verify(testReceiptKey, body.Receipt.ID)
+			if err != nil {
+				t.Fatalf("expected a valid receipt, but got %v", err)
+			}
+			if receipt.PollID != data.ExamplePollIDValid || receipt.OptionID != data.ExampleOptionID1 {
+				t.Errorf("expected a receipt for option %s, but got %+v", data.ExampleOptionID1, receipt)
+			}
+		})
+	}
+}
+
+func Test_app_showReceiptHandler(t *testing.T) {
+	castAt := time.Date(2024, 2, 5, 14, 0, 0, 0, time.UTC)
+	sign := func(key []byte, pollID, optionID uuid.UUID, voteID int64) string {
+		receipt := &receipts.Receipt{PollID: pollID, OptionID: optionID, VoteID: voteID, CastAt: castAt}
+		receipts.Sign(key, receipt)
+		return receipt.ID
+	}
+
+	tests := []struct {
+		name           string
+		disabled       bool
+		receiptID      string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "recorded vote",
+			receiptID:      sign(testReceiptKey, data.ExamplePollIDValid, data.ExampleOptionID1, 1),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"receipt":{"id":"` + sign(testReceiptKey, data.ExamplePollIDValid, data.ExampleOptionID1, 1) +
+				`","poll_id":"` + data.ExamplePollIDValid.String() + `","option_id":"` + data.ExampleOptionID1.String() +
+				`","cast_at":"2024-02-05T14:00:00Z","recorded":true,"status":"accepted"}}`,
+		},
+		{
+			name:           "vote not recorded",
+			receiptID:      sign(testReceiptKey, data.ExamplePollIDValid, data.ExampleOptionID1, 2),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"recorded":false}}`,
+		},
+		{
+			name:           "vote for another option",
+			receiptID:      sign(testReceiptKey, data.ExamplePollIDValid, data.ExampleOptionID2, 1),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"recorded":false}}`,
+		},
+		{
+			name:           "signed with another key",
+			receiptID:      sign([]byte("another key, also 32 bytes long!"), data.ExamplePollIDValid, data.ExampleOptionID1, 1),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "malformed",
+			receiptID:      "receipt",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "disabled",
+			disabled:       true,
+			receiptID:      sign(testReceiptKey, data.ExamplePollIDValid, data.ExampleOptionID1, 1),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.disabled {
+				app.config.receipts.key = testReceiptKey
+				defer func() { app.config.receipts.key = nil }()
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("receiptID", test.receiptID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showReceiptHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
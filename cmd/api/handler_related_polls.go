@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// showRelatedPollsHandler lists public polls with questions similar to the
+// poll's, for "people also voted on" widgets.
+func (app *application) showRelatedPollsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+	limit := app.readInt(qs, "limit", 5, v)
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= 20, "limit", "must be a maximum of 20")
+	loc := app.readTimeZone(qs, "tz", v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if app.hidden(r, poll) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	polls, err := app.models.Polls.GetRelated(poll.ID, limit)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	type relatedPoll struct {
+		ID        uuid.UUID      `json:"id"`
+		Question  string         `json:"question"`
+		URL       string         `json:"url"`
+		Tags      []string       `json:"tags,omitempty"`
+		CreatedAt time.Time      `json:"created_at"`
+		ExpiresAt data.ExpiresAt `json:"expires_at"`
+	}
+
+	related := make([]relatedPoll, 0, len(polls))
+	for _, p := range polls {
+		p.InTimeZone(loc)
+		related = append(related, relatedPoll{
+			ID:        p.ID,
+			Question:  p.Question,
+			URL:       app.pollURL(r, p.ID),
+			Tags:      p.Tags,
+			CreatedAt: p.CreatedAt,
+			ExpiresAt: p.ExpiresAt,
+		})
+	}
+
+	err = app.writeCachedJSON(w, r, envelope{"polls": related}, cachePolicy{maxAge: cacheTTLFeed})
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showRelatedPollsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "related polls",
+			pollID:         data.ExamplePollIDValid.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"polls":[{"id":"0d5edfad-ba7f-4ddc-a455-4f25ca09bf5e","question":"Tabs or spaces in YAML?","url":"http://example.com/v1/polls/0d5edfad-ba7f-4ddc-a455-4f25ca09bf5e","tags":["code"],"created_at":"2024-02-26T17:00:00Z","expires_at":""},{"id":"6e3e617f-b5e6-4627-a2db-c72e29ec1729","question":"Spaces after periods?"`,
+		},
+		{
+			name:           "limit",
+			pollID:         data.ExamplePollIDValid.String(),
+			query:          "?limit=1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"expires_at":""}]}`,
+		},
+		{
+			name:           "none related",
+			pollID:         data.ExamplePollIDAfterVote.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"polls":[]}`,
+		},
+		{
+			name:           "limit too large",
+			pollID:         data.ExamplePollIDValid.String(),
+			query:          "?limit=21",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"limit":"must be a maximum of 20"}}`,
+		},
+		{
+			name:           "draft",
+			pollID:         data.ExamplePollIDDraft.String(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unknown poll",
+			pollID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			pollID:         "lunch",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `invalid id`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showRelatedPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// reportPollInput is the optional body of POST /v1/polls/{pollID}/report.
+type reportPollInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// reportPollHandler lets anyone report an abusive poll, once per IP until
+// the reports are resolved. Polls with as many reports as the threshold are
+// hidden until an admin reviews them.
+func (app *application) reportPollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input reportPollInput
+
+	if r.ContentLength != 0 {
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+	}
+
+	input.Reason = app.text.Line(input.Reason)
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if poll.Hidden() {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	ip, ok := clientIP(r)
+	if !ok {
+		app.badRequestResponse(w, errNoClientIP)
+		return
+	}
+
+	reporter := data.HashVisitor(app.config.visitors.key, ip)
+	hidden, err := app.models.Reports.Insert(poll.ID, reporter, input.Reason, app.config.reports.threshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if hidden {
+		app.recordActivity(poll.ID, data.ActorModerator, data.ActionPollModerated, map[string]any{
+			"status": data.ModerationReported,
+		})
+	}
+
+	err = app.writeJSON(w, http.StatusAccepted, envelope{"message": "poll reported"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) listReportsHandler(w http.ResponseWriter, r *http.Request) {
+	polls, err := app.models.Reports.GetOpen()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"reports": polls}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// moderatePollInput is the body of requests reviewing a poll.
+type moderatePollInput struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+// resolveReportsHandler closes a poll's open reports by approving the poll,
+// which shows it again if it was hidden, or rejecting it.
+func (app *application) resolveReportsHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input moderatePollInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Reports.Resolve(pollID, input.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(pollID, data.ActorModerator, data.ActionPollModerated, map[string]any{
+		"status": input.Status,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "reports resolved, poll " + input.Status}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_reportPollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		threshold      int
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "report",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"reason":"spam"}`,
+			threshold:      5,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   "poll reported",
+		},
+		{
+			name:           "report without reason",
+			pollID:         data.ExamplePollIDValid.String(),
+			threshold:      5,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   "poll reported",
+		},
+		{
+			name:           "report hiding the poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"reason":"spam"}`,
+			threshold:      1,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   "poll reported",
+		},
+		{
+			name:           "reason too long",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"reason":"` + strings.Repeat("a", 501) + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"reason":"must not be more than 500 bytes long"`,
+		},
+		{
+			name:           "hidden poll",
+			pollID:         data.ExamplePollIDRejected.String(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "unknown poll",
+			pollID:         "3c6a3f2e-64d1-4bd2-9d58-1b0c2e7f4a10",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+	}
+
+	defer func() { app.config.reports.threshold = 0 }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.reports.threshold = test.threshold
+
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			req.Header.Set("X-Forwarded-For", "0.0.0.0")
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.reportPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_listReportsHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listReportsHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	expectedBody := `"moderation_status":"reported","reports":5,"reasons":["spam"]`
+	if !strings.Contains(rr.Body.String(), expectedBody) {
+		t.Errorf("expected body to contain %q, but got %q", expectedBody, rr.Body)
+	}
+}
+
+func Test_app_resolveReportsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "approve poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"status":"approved"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "reports resolved, poll approved",
+		},
+		{
+			name:           "reject poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"status":"rejected"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "reports resolved, poll rejected",
+		},
+		{
+			name:           "invalid status",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"status":"reported"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be approved or rejected",
+		},
+		{
+			name:           "unknown poll",
+			pollID:         "3c6a3f2e-64d1-4bd2-9d58-1b0c2e7f4a10",
+			json:           `{"status":"approved"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.resolveReportsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
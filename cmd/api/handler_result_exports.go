@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) showResultExportHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	export, err := app.models.Exports.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"export": export}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// resultExportInput is the body of PUT /v1/polls/{pollID}/results/export.
+type resultExportInput struct {
+	Frequency string `json:"frequency" validate:"required,oneof=hourly daily"`
+	Format    string `json:"format" validate:"oneof=csv json" doc:"csv if not set"`
+}
+
+func (app *application) updateResultExportHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	var input resultExportInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if input.Format == "" {
+		input.Format = data.ExportCSV
+	}
+
+	v := validator.New()
+	v.Check(app.storage != nil, "frequency", "result exports are not supported by this server")
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	export := &data.ResultExport{
+		PollID:    id,
+		Frequency: input.Frequency,
+		Format:    input.Format,
+	}
+
+	err = app.models.Exports.Schedule(export)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"export": export}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) deleteResultExportHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	err := app.models.Exports.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "result export unscheduled"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
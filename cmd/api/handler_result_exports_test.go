@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showResultExportHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+	}{
+		{"scheduled", data.ExamplePollIDValid, http.StatusOK},
+		{"not scheduled", data.ExamplePollIDVotingStarted, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showResultExportHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_updateResultExportHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		storage        bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "csv by default",
+			json:           `{"frequency":"daily"}`,
+			storage:        true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"format":"csv"`,
+		},
+		{
+			name:           "json",
+			json:           `{"frequency":"hourly","format":"json"}`,
+			storage:        true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"format":"json"`,
+		},
+		{
+			name:           "invalid frequency",
+			json:           `{"frequency":"weekly"}`,
+			storage:        true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be hourly or daily",
+		},
+		{
+			name:           "invalid format",
+			json:           `{"frequency":"daily","format":"xlsx"}`,
+			storage:        true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be csv or json",
+		},
+		{
+			name:           "no storage",
+			json:           `{"frequency":"daily"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "result exports are not supported by this server",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.storage {
+				app.storage = &mockStorage{}
+				defer func() { app.storage = nil }()
+			}
+
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.updateResultExportHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_deleteResultExportHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
+	}{
+		{"scheduled", data.ExamplePollIDValid, http.StatusOK},
+		{"not scheduled", data.ExamplePollIDVotingStarted, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.deleteResultExportHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/search"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// searchPollsHandler searches public polls with the query syntax of the
+// search package. Polls matching the most terms are listed first, unless the
+// search only has qualifiers.
+func (app *application) searchPollsHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	q := app.readString(qs, "q", "")
+	v.Check(q != "", "q", "must be provided")
+	query := search.Parse(v, "q", q)
+
+	defaultSort := "-relevance"
+	if query.TSQuery == "" {
+		defaultSort = "-created_at"
+	}
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 20, v),
+		Sort:         app.readString(qs, "sort", defaultSort),
+		SortSafelist: []string{"-relevance", "created_at", "-created_at"},
+	}
+	loc := app.readTimeZone(qs, "tz", v)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	polls, metadata, err := app.models.Polls.Search(query, filters)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	for _, poll := range polls {
+		poll.InTimeZone(loc)
+		app.showPollResults(r, poll)
+	}
+
+	applied := map[string]string{
+		"q":         q,
+		"sort":      filters.Sort,
+		"page_size": strconv.Itoa(filters.PageSize),
+	}
+
+	if err := app.writeCachedJSON(
+		w,
+		r,
+		envelope{"polls": polls, "metadata": app.paginate(r, metadata, applied)},
+		cachePolicy{maxAge: cacheTTLActive},
+	); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
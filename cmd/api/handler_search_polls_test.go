@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_app_searchPollsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		q              string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "term",
+			q:              "Lunch",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"question":"Lunch?","description":"","options":null,"created_at":"2024-02-26T17:00:00Z"`,
+		},
+		{
+			name:           "ranked by relevance",
+			q:              "lunch",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"filters":{"page_size":"20","q":"lunch","sort":"-relevance"}`,
+		},
+		{
+			name:           "qualifiers only",
+			q:              "tag:Food status:open",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"filters":{"page_size":"20","q":"tag:Food status:open","sort":"-created_at"}`,
+		},
+		{
+			name:           "tags",
+			q:              "tag:food",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"tags":["food"]`,
+		},
+		{
+			name:           "no matches",
+			q:              "lunch tag:work",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"metadata":{"filters":{"page_size":"20","q":"lunch tag:work","sort":"-relevance"}},"polls":[]}`,
+		},
+		{
+			name:           "sort",
+			q:              "lunch",
+			query:          "&sort=created_at",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"sort":"created_at"`,
+		},
+		{
+			name:           "missing query",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"q":"must be provided"`,
+		},
+		{
+			name:           "invalid status",
+			q:              "lunch status:deleted",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"q":"status must be open, closed or paused"`,
+		},
+		{
+			name:           "unsupported language",
+			q:              "lunch lang:sv",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"q":"lang must be de, en, es, fr, it, nl or pt"`,
+		},
+		{
+			name:           "invalid sort",
+			q:              "lunch",
+			query:          "&sort=relevance",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"sort":"invalid sort value"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/polls/search?q="+url.QueryEscape(test.q)+test.query, nil)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.searchPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/results"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) showSeriesResultsHandler(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := app.readIDParam(r, "seriesID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	limit := app.readInt(r.URL.Query(), "limit", 10, v)
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= 100, "limit", "must be a maximum of 100")
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	polls, err := app.models.Polls.GetSeries(seriesID, limit)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if len(polls) == 0 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	type seriesPoll struct {
+		ID         uuid.UUID      `json:"id"`
+		Question   string         `json:"question"`
+		CreatedAt  time.Time      `json:"created_at"`
+		ExpiresAt  data.ExpiresAt `json:"expires_at"`
+		TotalVotes int            `json:"total_votes"`
+	}
+
+	shown := []seriesPoll{}
+	var options [][]*data.PollOption
+
+	for _, poll := range polls {
+		if !resultsPublic(poll) {
+			continue
+		}
+
+		pollOptions, err := app.models.PollOptions.GetResults(poll.ID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+
+		_, total := summarizeResults(pollOptions)
+		if total < poll.ResultsThreshold {
+			continue
+		}
+
+		shown = append(shown, seriesPoll{
+			ID:         poll.ID,
+			Question:   poll.Question,
+			CreatedAt:  poll.CreatedAt,
+			ExpiresAt:  poll.ExpiresAt,
+			TotalVotes: total,
+		})
+		options = append(options, pollOptions)
+	}
+
+	aligned := results.Align(options)
+	if aligned == nil {
+		aligned = []results.SeriesOption{}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"series_id": seriesID,
+		"polls":     shown,
+		"options":   aligned,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// resultsPublic reports whether anyone may see the poll's results, without
+// having voted or holding a token.
+func resultsPublic(poll *data.Poll) bool {
+	if poll.ResultsVisibility == "always" {
+		return true
+	}
+	return !poll.ExpiresAt.IsZero() && poll.ExpiresAt.Before(time.Now())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showSeriesResultsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		seriesID       string
+		query          string
+		expectedStatus int
+		expectedPolls  int
+		expectedBody   string
+	}{
+		{
+			name:           "series results",
+			seriesID:       data.ExampleSeriesID.String(),
+			expectedStatus: http.StatusOK,
+			expectedPolls:  2,
+			expectedBody:   `{"value":"pizza","results":[{"vote_count":3,"percent":75,"delta_votes":null,"delta_percent":null},{"vote_count":2,"percent":50,"delta_votes":-1,"delta_percent":-25}]}`,
+		},
+		{
+			name:           "latest poll only",
+			seriesID:       data.ExampleSeriesID.String(),
+			query:          "?limit=1",
+			expectedStatus: http.StatusOK,
+			expectedPolls:  1,
+			expectedBody:   `"options":[{"value":"pizza","results":[{"vote_count":2,"percent":50,"delta_votes":null,"delta_percent":null}]}`,
+		},
+		{
+			name:           "invalid limit",
+			seriesID:       data.ExampleSeriesID.String(),
+			query:          "?limit=0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"limit":"must be greater than zero"}}`,
+		},
+		{
+			name:           "unknown series",
+			seriesID:       uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			seriesID:       "weekly",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `invalid id`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("seriesID", test.seriesID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showSeriesResultsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+
+			if rr.Code == http.StatusOK {
+				var body struct {
+					Polls []any `json:"polls"`
+				}
+				if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if len(body.Polls) != test.expectedPolls {
+					t.Errorf("expected %d polls, but got %d", test.expectedPolls, len(body.Polls))
+				}
+			}
+		})
+	}
+}
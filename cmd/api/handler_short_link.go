@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func (app *application) createShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	_, err = app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	link, err := app.models.ShortLinks.Insert(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	link.URL = app.externalURL(r, "/p/"+link.Code)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"short_link": link}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) showShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	link, err := app.models.ShortLinks.GetForPoll(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	link.URL = app.externalURL(r, "/p/"+link.Code)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"short_link": link}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) redirectShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if !data.ShortCodeRX.MatchString(code) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	link, err := app.models.ShortLinks.GetByCode(code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	http.Redirect(w, r, app.pollURL(r, link.PollID), http.StatusFound)
+}
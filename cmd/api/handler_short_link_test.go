@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_shortLinkHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		pollID         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "create short link",
+			handler:        app.createShortLinkHandler,
+			pollID:         data.ExamplePollIDValid.String(),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"url":"http://example.com/p/` + data.ExampleShortCode + `"`,
+		},
+		{
+			name:           "create short link unexisting poll",
+			handler:        app.createShortLinkHandler,
+			pollID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "show short link",
+			handler:        app.showShortLinkHandler,
+			pollID:         data.ExamplePollIDValid.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"code":"` + data.ExampleShortCode + `"`,
+		},
+		{
+			name:           "show short link not created",
+			handler:        app.showShortLinkHandler,
+			pollID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid poll id",
+			handler:        app.showShortLinkHandler,
+			pollID:         "a",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			test.handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_redirectShortLinkHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		code             string
+		pollURL          string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:             "redirect to poll",
+			code:             data.ExampleShortCode,
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "http://example.com/v1/polls/" + data.ExamplePollIDValid.String(),
+		},
+		{
+			name:             "redirect to configured poll url",
+			code:             data.ExampleShortCode,
+			pollURL:          "https://polls.example.com/poll/%s",
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://polls.example.com/poll/" + data.ExamplePollIDValid.String(),
+		},
+		{
+			name:           "unknown code",
+			code:           "zzz2345",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid code",
+			code:           "not-a-code",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.pollURL = test.pollURL
+			defer func() { app.config.pollURL = "" }()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("code", test.code)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.redirectShortLinkHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != test.expectedLocation {
+				t.Errorf("expected Location %q, but got %q", test.expectedLocation, location)
+			}
+		})
+	}
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"errors"
 	"net/http"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) showPollHandler(w http.ResponseWriter, r *http.Request) {
@@ -14,6 +20,41 @@ func (app *application) showPollHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	app.showPoll(w, r, id)
+}
+
+// showPollByExternalIDHandler shows the poll with the external ID given to
+// it by an integrator, among the organization's polls on organization routes
+// and among the polls the request's token is for otherwise.
+func (app *application) showPollByExternalIDHandler(w http.ResponseWriter, r *http.Request) {
+	var orgID uuid.UUID
+	if member, ok := app.memberFromContext(r.Context()); ok {
+		orgID = member.OrgID
+	}
+
+	id, err := app.pollIDByExternalID(r, orgID, chi.URLParam(r, "externalID"))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.showPoll(w, r, id)
+}
+
+func (app *application) showPoll(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	v := validator.New()
+	loc := app.readTimeZone(r.URL.Query(), "tz", v)
+	source := app.readSource(r.URL.Query(), v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
 	poll, err := app.models.Polls.Get(id)
 	if err != nil {
 		switch {
@@ -25,8 +66,69 @@ func (app *application) showPollHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"poll": poll}, nil)
+	if app.hidden(r, poll) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// a failure to count the view shouldn't keep anyone from seeing the poll,
+	// and previews of drafts aren't counted
+	if !poll.IsDraft {
+		ip, _ := clientIP(r)
+		visitor := data.HashVisitor(app.config.visitors.key, ip)
+		if err := app.models.Views.Record(poll.ID, source, visitor); err != nil {
+			app.logError(err)
+		}
+		app.trackEvent(analytics.View(poll.ID, source))
+	}
+
+	// results change with votes without the poll being updated, so only the
+	// ETag tells whether they did
+	policy := pollCachePolicy(poll)
+	if app.showPollResults(r, poll) {
+		policy.lastModified = time.Time{}
+	}
+
+	// voters get their own order of the options and wording of the
+	// question, which mustn't be cached for others, and owners get the poll
+	// as it is
+	if (poll.ShuffleOptions || len(poll.QuestionVariants) > 0) && !app.can(r, poll.ID, auth.EditPoll) {
+		voter := voterIdentity(r)
+		if poll.ShuffleOptions {
+			poll.ShuffleFor(voter)
+		}
+		if len(poll.QuestionVariants) > 0 {
+			poll.ShowVariant(poll.VariantFor(voter))
+		}
+		policy.private = true
+	}
+
+	poll.InTimeZone(loc)
+
+	err = app.writeCachedJSON(w, r, envelope{"poll": poll}, policy)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 	}
 }
+
+// showPollResults shows the poll's total votes and its options' vote counts
+// and percents if its results are visible to the request and it has enough
+// votes for its results threshold, and reports whether it did. Results
+// visible after voting differ by voter, so they are only shown by
+// GET /v1/polls/{pollID}/results, unless the request may view the results
+// regardless of the visibility.
+func (app *application) showPollResults(r *http.Request, poll *data.Poll) bool {
+	switch {
+	case poll.ResultsVisibility == "always":
+	case poll.ResultsVisibility == "after_deadline" && (poll.ExpiresAt.IsZero() || poll.Expired(time.Now())):
+	case app.can(r, poll.ID, auth.ViewResults):
+	default:
+		return false
+	}
+
+	if poll.TotalVotes() < poll.ResultsThreshold {
+		return false
+	}
+	poll.ShowResults()
+	return true
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 
@@ -16,12 +19,14 @@ func Test_app_showPollHandler(t *testing.T) {
 	tests := []struct {
 		name           string
 		id             string
+		query          string
+		authHeader     string
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:           "valid id",
-			id:             data.ExamplePollIDValid,
+			id:             data.ExamplePollIDValid.String(),
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"question":"Test?"`,
 		},
@@ -43,14 +48,91 @@ func Test_app_showPollHandler(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `the requested resource could not be found`,
 		},
+		{
+			name:           "times in time zone",
+			id:             data.ExamplePollIDValid.String(),
+			query:          "?tz=Asia/Tokyo",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `+09:00","results_visibility"`,
+		},
+		{
+			name:           "invalid time zone",
+			id:             data.ExamplePollIDValid.String(),
+			query:          "?tz=Mars/Olympus",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"tz":"must be a valid IANA time zone"`,
+		},
+		{
+			name:           "draft",
+			id:             data.ExamplePollIDDraft.String(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "draft with another poll's token",
+			id:             data.ExamplePollIDDraft.String(),
+			authHeader:     "Bearer " + data.ExampleTokenVote,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "draft with preview token",
+			id:             data.ExamplePollIDDraft.String(),
+			authHeader:     "Bearer " + data.ExampleTokenPreview,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"is_draft":true`,
+		},
+		{
+			name:           "private",
+			id:             data.ExamplePollIDPrivate.String(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "private with another poll's vote token",
+			id:             data.ExamplePollIDPrivate.String(),
+			authHeader:     "Bearer " + data.ExampleTokenVote,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "private with vote token",
+			id:             data.ExamplePollIDPrivate.String(),
+			authHeader:     "Bearer " + data.ExampleTokenVotePrivate,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"visibility":"private"`,
+		},
+		{
+			name:           "rejected by moderation",
+			id:             data.ExamplePollIDRejected.String(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "view from source",
+			id:             data.ExamplePollIDValid.String(),
+			query:          "?source=newsletter",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"question":"Test?"`,
+		},
+		{
+			name:           "source too long",
+			id:             data.ExamplePollIDValid.String(),
+			query:          "?source=" + strings.Repeat("a", 51),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"source":"must not be more than 50 bytes long"`,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", test.id)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.showPollHandler)
 			handler.ServeHTTP(rr, req)
@@ -65,3 +147,316 @@ func Test_app_showPollHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_showPollByExternalIDHandler(t *testing.T) {
+	viewer := &data.Member{ID: 4, OrgID: data.ExampleOrgID, Role: data.RoleViewer}
+
+	tests := []struct {
+		name           string
+		externalID     string
+		authHeader     string
+		member         *data.Member
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "poll without organization",
+			externalID:     data.ExampleExternalID,
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":"` + data.ExamplePollIDValid.String() + `"`,
+		},
+		{
+			name:           "poll without organization without token",
+			externalID:     data.ExampleExternalID,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "another poll's token",
+			externalID:     data.ExampleExternalID,
+			authHeader:     "Bearer " + data.ExampleTokenShuffled,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "organization's poll",
+			externalID:     data.ExampleExternalID,
+			member:         viewer,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":"` + data.ExamplePollIDOrg.String() + `"`,
+		},
+		{
+			name:           "unknown",
+			externalID:     "crm-43",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("externalID", test.externalID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx)
+			if test.member != nil {
+				ctx = context.WithValue(ctx, ctxMemberKey, test.member)
+			}
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showPollByExternalIDHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_showPollHandler_shuffleOptions(t *testing.T) {
+	show := func(ip string, authHeader string) ([]string, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", data.ExamplePollIDShuffled.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("X-Forwarded-For", ip)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.showPollHandler).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		var body struct {
+			Poll struct {
+				Options []struct {
+					ID string `json:"id"`
+				} `json:"options"`
+			} `json:"poll"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, option := range body.Poll.Options {
+			ids = append(ids, option.ID)
+		}
+		return ids, rr.Header().Get("Cache-Control")
+	}
+
+	first, cacheControl := show("1.1.1.1", "")
+	if again, _ := show("1.1.1.1", ""); !slices.Equal(first, again) {
+		t.Errorf("expected the same order for the same voter, but got %v and %v", first, again)
+	}
+	if !strings.HasPrefix(cacheControl, "private") {
+		t.Errorf("expected shuffled options not to be cached for others, but got %q", cacheControl)
+	}
+
+	orders := map[string]bool{}
+	for i := 0; i < 10; i++ {
+		ids, _ := show(fmt.Sprintf("10.0.0.%d", i), "")
+		orders[strings.Join(ids, ",")] = true
+	}
+	if len(orders) < 2 {
+		t.Errorf("expected different orders for different voters, but got %v", orders)
+	}
+
+	canonical := []string{data.ExampleOptionID1.String(), data.ExampleOptionID2.String(), data.ExampleOptionID3.String()}
+	for i := 0; i < 10; i++ {
+		ids, _ := show(fmt.Sprintf("10.0.0.%d", i), "Bearer "+data.ExampleTokenShuffled)
+		if !slices.Equal(ids, canonical) {
+			t.Fatalf("expected the owner to see the options in order, but got %v", ids)
+		}
+	}
+}
+
+func Test_app_showPollHandler_questionVariants(t *testing.T) {
+	show := func(ip string, authHeader string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", data.ExamplePollIDVariants.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("X-Forwarded-For", ip)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.showPollHandler).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		return rr
+	}
+
+	poll, _ := app.models.Polls.Get(data.ExamplePollIDVariants)
+	wordings := append([]string{poll.Question}, poll.QuestionVariants...)
+
+	shown := map[int]bool{}
+	for i := 0; i < 10; i++ {
+		ip := fmt.Sprintf("10.0.0.%d", i)
+		rr := show(ip, "")
+
+		var body struct {
+			Poll struct {
+				Question         string   `json:"question"`
+				QuestionVariants []string `json:"question_variants"`
+				Variant          *int     `json:"variant"`
+			} `json:"poll"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Poll.Variant == nil {
+			t.Fatalf("expected the voter's variant, but got none")
+		}
+		variant := *body.Poll.Variant
+		// votes are recorded with the variant picked again for the voter
+		if variant != poll.VariantFor(ip) {
+			t.Errorf("expected variant %d to be picked for the vote too, but got %d", variant, poll.VariantFor(ip))
+		}
+		if body.Poll.Question != wordings[variant] {
+			t.Errorf("expected the question worded like variant %d, but got %q", variant, body.Poll.Question)
+		}
+		if body.Poll.QuestionVariants != nil {
+			t.Errorf("expected the other variants to be hidden from voters, but got %v", body.Poll.QuestionVariants)
+		}
+		if cacheControl := rr.Header().Get("Cache-Control"); !strings.HasPrefix(cacheControl, "private") {
+			t.Errorf("expected the voter's wording not to be cached for others, but got %q", cacheControl)
+		}
+		shown[variant] = true
+	}
+	if len(shown) != len(wordings) {
+		t.Errorf("expected voters to be shown every wording, but got %v", shown)
+	}
+
+	rr := show("10.0.0.1", "Bearer "+data.ExampleTokenVariants)
+	if body := rr.Body.String(); !strings.Contains(body, `"question":"Should taxes be raised?","question_variants":["Should taxes be raised to fund schools?"]`) ||
+		strings.Contains(body, `"variant":`) {
+		t.Errorf("expected the owner to get the question and its variants, but got %q", body)
+	}
+}
+
+func Test_app_showPollHandler_lifecycle(t *testing.T) {
+	tests := []struct {
+		name              string
+		id                string
+		authHeader        string
+		expectedStatus    data.PollStatus
+		expectedCountdown bool
+		expectedIsVotable bool
+	}{
+		{"active", data.ExamplePollIDValid.String(), "", data.StatusActive, true, true},
+		{"without expiry", data.ExamplePollIDDemographics.String(), "", data.StatusActive, false, true},
+		{"expired", data.ExamplePollIDExpiredPoll.String(), "", data.StatusExpired, true, false},
+		{"vote cap reached", data.ExamplePollIDVoteCapFull.String(), "", data.StatusClosed, true, false},
+		{"paused", data.ExamplePollIDPaused.String(), "", data.StatusPaused, false, false},
+		{"draft", data.ExamplePollIDDraft.String(), "Bearer " + data.ExampleTokenPreview, data.StatusDraft, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(app.showPollHandler).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+			}
+			var body struct {
+				Poll struct {
+					Status             data.PollStatus `json:"status"`
+					SecondsUntilExpiry *int            `json:"seconds_until_expiry"`
+					IsVotable          bool            `json:"is_votable"`
+				} `json:"poll"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Poll.Status != test.expectedStatus {
+				t.Errorf("expected status %q, but got %q", test.expectedStatus, body.Poll.Status)
+			}
+			if body.Poll.IsVotable != test.expectedIsVotable {
+				t.Errorf("expected is_votable %t, but got %t", test.expectedIsVotable, body.Poll.IsVotable)
+			}
+			if (body.Poll.SecondsUntilExpiry != nil) != test.expectedCountdown {
+				t.Fatalf("expected a countdown %t, but got %v", test.expectedCountdown, body.Poll.SecondsUntilExpiry)
+			}
+			if body.Poll.SecondsUntilExpiry == nil {
+				return
+			}
+			// the valid poll expires in two minutes, the others have expired
+			seconds := *body.Poll.SecondsUntilExpiry
+			if test.expectedIsVotable && (seconds < 110 || seconds > 120) || !test.expectedIsVotable && seconds != 0 {
+				t.Errorf("expected the seconds until expiry to count down, but got %d", seconds)
+			}
+		})
+	}
+}
+
+func Test_app_showPollHandler_results(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		authHeader   string
+		shown        bool
+		expectedBody string
+	}{
+		{"results always visible", data.ExamplePollIDValid.String(), "", true, `"position":0,"vote_count":0,"percent":0}`},
+		{"before the deadline", data.ExamplePollIDAfterDeadline.String(), "", false, ""},
+		{
+			"before the deadline with results token", data.ExamplePollIDAfterDeadline.String(), "Bearer " + data.ExampleTokenResults, true,
+			`"position":0,"vote_count":2,"percent":100}]`,
+		},
+		{"after voting", data.ExamplePollIDAfterVote.String(), "", false, ""},
+		{"below results threshold", data.ExamplePollIDThreshold.String(), "", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(app.showPollHandler).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+			}
+			body := rr.Body.String()
+			if shown := strings.Contains(body, `"vote_count":`); shown != test.shown {
+				t.Errorf("expected results shown %t, but got %q", test.shown, body)
+			}
+			if shown := strings.Contains(body, `"total_votes":`); shown != test.shown {
+				t.Errorf("expected total votes shown %t, but got %q", test.shown, body)
+			}
+			if !strings.Contains(body, test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, body)
+			}
+		})
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/results"
 )
 
 func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Request) {
@@ -26,12 +29,19 @@ func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	switch poll.ResultsVisibility {
-	case "after_vote":
+	if app.hidden(r, poll) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// whoever may view the results can see them regardless of the visibility
+	switch {
+	case app.can(r, pollID, auth.ViewResults):
+	case poll.ResultsVisibility == "after_vote":
 		if poll.ExpiresAt.Time.Before(time.Now()) {
-			ip := r.Header.Get("X-Forwarded-For")
-			if ip == "" {
-				app.serverErrorResponse(w, errors.New("no ip found"))
+			ip, ok := clientIP(r)
+			if !ok {
+				app.badRequestResponse(w, errNoClientIP)
 				return
 			}
 
@@ -46,39 +56,256 @@ func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Reques
 			}
 		}
 
-	case "after_deadline":
+	case poll.ResultsVisibility == "after_deadline":
 		if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.After(time.Now()) {
 			app.cannotShowResultsResponse(w, "when poll expires")
 			return
 		}
 	}
 
+	// results segmented by demographics are only shown to whoever may
+	// view the results regardless of the visibility
+	segment := r.URL.Query().Get("segment")
+	if segment != "" {
+		if !app.can(r, pollID, auth.ViewResults) {
+			app.invalidTokenResponse(w)
+			return
+		}
+		if _, ok := poll.Demographic(segment); !ok {
+			app.failedValidationResponse(w, map[string]string{
+				"segment": "must be one of the poll's demographic questions",
+			})
+			return
+		}
+	}
+
 	options, err := app.models.PollOptions.GetResults(pollID)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 		return
 	}
 
+	summary := results.Calculate(options, poll.TieBreak, results.Seed(poll.ID))
+
+	// results change with votes without the poll being updated, so only the
+	// ETag tells whether they did
+	cache := pollCachePolicy(poll)
+	cache.lastModified = time.Time{}
+	cache.private = poll.ResultsVisibility == "after_vote"
+
+	// results of small polls could reveal how individuals voted
+	if summary.TotalVotes < poll.ResultsThreshold {
+		metadata := envelope{
+			"hidden":            true,
+			"results_threshold": poll.ResultsThreshold,
+			"votes_needed":      poll.ResultsThreshold - summary.TotalVotes,
+		}
+		err = app.writeCachedJSON(w, r, envelope{"results": []any{}, "metadata": metadata}, cache)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	voteStats, err := app.models.Votes.GetStats(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	// turnout is only known if voters were invited with vote tokens
+	var turnout *float64
+	if voteStats.IssuedVoteTokens > 0 {
+		t := results.Round(float64(summary.TotalVotes) / float64(voteStats.IssuedVoteTokens) * 100)
+		turnout = &t
+	}
+
+	stats := envelope{
+		"turnout":            turnout,
+		"issued_vote_tokens": voteStats.IssuedVoteTokens,
+		"votes_per_hour":     results.Round(voteStats.VotesPerHour),
+		"peak_hour":          voteStats.PeakHour,
+		"peak_hour_votes":    voteStats.PeakHourVotes,
+		"margin":             summary.Margin,
+		"margin_percent":     summary.MarginPercent,
+	}
+
+	var voterNames map[uuid.UUID][]string
+	if poll.Anonymity == "public" ||
+		(poll.Anonymity == "names_visible_to_owner" && app.can(r, pollID, auth.EditPoll)) {
+		voterNames, err = app.models.Votes.GetVoterNames(pollID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
 	type result struct {
-		ID        string `json:"id"`
-		Value     string `json:"value"`
-		Position  int    `json:"position"`
-		VoteCount int    `json:"vote_count"`
+		ID        uuid.UUID `json:"id"`
+		Value     string    `json:"value"`
+		Position  int       `json:"position"`
+		VoteCount int       `json:"vote_count"`
+		Percent   float64   `json:"percent"`
+		Winner    bool      `json:"winner"`
+		Voters    []string  `json:"voters,omitempty"`
 	}
 
-	results := make([]result, 0, len(options))
+	optionResults := make([]result, 0, len(options))
 
-	for _, opt := range options {
-		results = append(results, result{
+	for i, opt := range options {
+		optionResults = append(optionResults, result{
 			ID:        opt.ID,
 			Value:     opt.Value,
 			Position:  opt.Position,
 			VoteCount: opt.VoteCount,
+			Percent:   summary.Options[i].Percent,
+			Winner:    summary.Options[i].Winner,
+			Voters:    voterNames[opt.ID],
 		})
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"results": results}, nil)
+	winners := summary.Winners
+	if winners == nil {
+		winners = []uuid.UUID{}
+	}
+
+	response := envelope{
+		"results":     optionResults,
+		"total_votes": summary.TotalVotes,
+		"winners":     winners,
+		"tie_break":   poll.TieBreak,
+		"stats":       stats,
+	}
+
+	if segment != "" {
+		response["segments"], err = app.segmentResults(poll, options, segment)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
+	// like segments, the results per wording of the question are only shown
+	// to whoever may view the results regardless of the visibility
+	if len(poll.QuestionVariants) > 0 && app.can(r, pollID, auth.ViewResults) {
+		response["variants"], err = app.variantResults(poll, options)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
+	err = app.writeCachedJSON(w, r, response, cache)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 	}
 }
+
+type segmentOption struct {
+	ID        uuid.UUID `json:"id"`
+	Value     string    `json:"value"`
+	VoteCount int       `json:"vote_count"`
+	Percent   float64   `json:"percent"`
+}
+
+type segmentResult struct {
+	// Value is the answer to the demographic question, nil for votes
+	// without an answer.
+	Value      *string         `json:"value"`
+	TotalVotes int             `json:"total_votes"`
+	Hidden     bool            `json:"hidden,omitempty"`
+	Results    []segmentOption `json:"results"`
+}
+
+// segmentResults splits the poll's results by the answers to a demographic
+// question, in the order of the question's choices. Segments with fewer votes
+// than the poll's results threshold are hidden.
+func (app *application) segmentResults(poll *data.Poll, options []*data.PollOption, key string) ([]segmentResult, error) {
+	counts, err := app.models.Votes.GetSegmentCounts(poll.ID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	question, _ := poll.Demographic(key)
+	answers := make([]*string, 0, len(question.Choices)+1)
+	for i := range question.Choices {
+		answers = append(answers, &question.Choices[i])
+	}
+	answers = append(answers, nil)
+
+	segments := make([]segmentResult, 0, len(answers))
+	for _, answer := range answers {
+		var segment segmentResult
+		if answer == nil {
+			segment = countSegment(poll, options, counts[""])
+		} else {
+			segment = countSegment(poll, options, counts[*answer])
+		}
+		segment.Value = answer
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+type variantResult struct {
+	// Variant is the wording of the question the voters were shown, 0 for
+	// the question and 1 on for its variants.
+	Variant    int             `json:"variant"`
+	Question   string          `json:"question"`
+	TotalVotes int             `json:"total_votes"`
+	Hidden     bool            `json:"hidden,omitempty"`
+	Results    []segmentOption `json:"results"`
+}
+
+// variantResults splits the poll's results by the wording of the question the
+// voters were shown. Like segments, variants with fewer votes than the poll's
+// results threshold are hidden.
+func (app *application) variantResults(poll *data.Poll, options []*data.PollOption) ([]variantResult, error) {
+	counts, err := app.models.Votes.GetVariantCounts(poll.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	wordings := append([]string{poll.Question}, poll.QuestionVariants...)
+	variants := make([]variantResult, 0, len(wordings))
+	for i, wording := range wordings {
+		segment := countSegment(poll, options, counts[i])
+		variants = append(variants, variantResult{
+			Variant:    i,
+			Question:   wording,
+			TotalVotes: segment.TotalVotes,
+			Hidden:     segment.Hidden,
+			Results:    segment.Results,
+		})
+	}
+
+	return variants, nil
+}
+
+// countSegment sums up the votes of a part of the poll's voters by option ID.
+// Its results are hidden if it has fewer votes than the poll's results
+// threshold.
+func countSegment(poll *data.Poll, options []*data.PollOption, counts map[uuid.UUID]int) segmentResult {
+	segmentOptions := make([]*data.PollOption, 0, len(options))
+	for _, opt := range options {
+		segmentOptions = append(segmentOptions, &data.PollOption{ID: opt.ID, VoteCount: counts[opt.ID]})
+	}
+	summary := results.Calculate(segmentOptions, poll.TieBreak, 0)
+
+	segment := segmentResult{TotalVotes: summary.TotalVotes, Results: []segmentOption{}}
+	if summary.TotalVotes < poll.ResultsThreshold {
+		segment.Hidden = true
+		return segment
+	}
+
+	for i, opt := range options {
+		segment.Results = append(segment.Results, segmentOption{
+			ID:        opt.ID,
+			Value:     opt.Value,
+			VoteCount: summary.Options[i].VoteCount,
+			Percent:   summary.Options[i].Percent,
+		})
+	}
+	return segment
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -16,11 +18,17 @@ func Test_app_showResultsHandler(t *testing.T) {
 		name           string
 		pollID         string
 		ip             string
+		authHeader     string
 		expectedStatus int
+		expectVoters   bool
+		expectedBody   string
+		// expectedBodyContains is checked instead of the whole body
+		expectedBodyContains string
+		url                  string
 	}{
 		{
 			name:           "show results valid",
-			pollID:         data.ExamplePollIDValid,
+			pollID:         data.ExamplePollIDValid.String(),
 			expectedStatus: http.StatusOK,
 		},
 		{
@@ -30,37 +38,160 @@ func Test_app_showResultsHandler(t *testing.T) {
 		},
 		{
 			name:           "don't show results bofore voting",
-			pollID:         data.ExamplePollIDAfterVote,
+			pollID:         data.ExamplePollIDAfterVote.String(),
 			ip:             "10.10.10.10",
 			expectedStatus: http.StatusForbidden,
 		},
 		{
 			name:           "show results after voting",
-			pollID:         data.ExamplePollIDAfterVote,
+			pollID:         data.ExamplePollIDAfterVote.String(),
 			ip:             "0.0.0.1",
 			expectedStatus: http.StatusOK,
 		},
 		{
 			name:           "don't show results before deadline",
-			pollID:         data.ExamplePollIDAfterDeadline,
+			pollID:         data.ExamplePollIDAfterDeadline.String(),
 			ip:             "0.0.0.1",
 			expectedStatus: http.StatusForbidden,
 		},
+		{
+			name:           "show results before deadline with results token",
+			pollID:         data.ExamplePollIDAfterDeadline.String(),
+			ip:             "0.0.0.1",
+			authHeader:     "Bearer " + data.ExampleTokenResults,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "public voter names",
+			pollID:         data.ExamplePollIDPublicVoters.String(),
+			expectedStatus: http.StatusOK,
+			expectVoters:   true,
+		},
+		{
+			name:           "voter names hidden without owner token",
+			pollID:         data.ExamplePollIDOwnerVoters.String(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "voter names hidden with another poll's token",
+			pollID:         data.ExamplePollIDOwnerVoters.String(),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "voter names visible to owner",
+			pollID:         data.ExamplePollIDOwnerVoters.String(),
+			authHeader:     "Bearer " + data.ExampleTokenOwnerVoters,
+			expectedStatus: http.StatusOK,
+			expectVoters:   true,
+		},
+		{
+			name:           "percentages, winners and stats",
+			pollID:         data.ExamplePollIDOwnerVoters.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody: fmt.Sprintf(
+				`{"results":[{"id":%q,"value":"One","position":0,"vote_count":1,"percent":100,"winner":true},`+
+					`{"id":%q,"value":"Two","position":1,"vote_count":0,"percent":0,"winner":false}],`+
+					`"stats":{"issued_vote_tokens":4,"margin":1,"margin_percent":100,"peak_hour":"2024-02-05T14:00:00Z",`+
+					`"peak_hour_votes":1,"turnout":25,"votes_per_hour":0.5},`+
+					`"tie_break":"shared","total_votes":1,"winners":[%[1]q]}`,
+				data.ExampleOptionID1, data.ExampleOptionID2,
+			),
+		},
+		{
+			name:           "segmented results",
+			pollID:         data.ExamplePollIDDemographics.String(),
+			url:            "/?segment=team",
+			authHeader:     "Bearer " + data.ExampleTokenDemographics,
+			expectedStatus: http.StatusOK,
+			expectedBodyContains: fmt.Sprintf(
+				`"segments":[{"value":"Sales","total_votes":2,"results":[{"id":%q,"value":"One","vote_count":2,"percent":100},`+
+					`{"id":%q,"value":"Two","vote_count":0,"percent":0}]},`+
+					`{"value":"Engineering","total_votes":2,"results":[{"id":%[1]q,"value":"One","vote_count":1,"percent":50},`+
+					`{"id":%[2]q,"value":"Two","vote_count":1,"percent":50}]},`+
+					`{"value":null,"total_votes":1,"results":[{"id":%[1]q,"value":"One","vote_count":1,"percent":100},`+
+					`{"id":%[2]q,"value":"Two","vote_count":0,"percent":0}]}]`,
+				data.ExampleOptionID1, data.ExampleOptionID2,
+			),
+		},
+		{
+			name:                 "segmented results without token",
+			pollID:               data.ExamplePollIDDemographics.String(),
+			url:                  "/?segment=team",
+			expectedStatus:       http.StatusUnauthorized,
+			expectedBodyContains: `"code":"INVALID_TOKEN"`,
+		},
+		{
+			name:                 "unknown segment",
+			pollID:               data.ExamplePollIDDemographics.String(),
+			url:                  "/?segment=age",
+			authHeader:           "Bearer " + data.ExampleTokenDemographics,
+			expectedStatus:       http.StatusUnprocessableEntity,
+			expectedBodyContains: `"segment":"must be one of the poll's demographic questions"`,
+		},
+		{
+			name:           "results per question variant",
+			pollID:         data.ExamplePollIDVariants.String(),
+			authHeader:     "Bearer " + data.ExampleTokenVariants,
+			expectedStatus: http.StatusOK,
+			expectedBodyContains: fmt.Sprintf(
+				`"variants":[{"variant":0,"question":"Should taxes be raised?","total_votes":3,`+
+					`"results":[{"id":%q,"value":"Yes","vote_count":2,"percent":66.67},{"id":%q,"value":"No","vote_count":1,"percent":33.33}]},`+
+					`{"variant":1,"question":"Should taxes be raised to fund schools?","total_votes":3,`+
+					`"results":[{"id":%[1]q,"value":"Yes","vote_count":0,"percent":0},{"id":%[2]q,"value":"No","vote_count":3,"percent":100}]}]`,
+				data.ExampleOptionID1, data.ExampleOptionID2,
+			),
+		},
+		{
+			name:           "results without question variants for voters",
+			pollID:         data.ExamplePollIDVariants.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody: fmt.Sprintf(
+				`{"results":[{"id":%q,"value":"Yes","position":0,"vote_count":2,"percent":33.33,"winner":false},`+
+					`{"id":%q,"value":"No","position":1,"vote_count":4,"percent":66.67,"winner":true}],`+
+					`"stats":{"issued_vote_tokens":4,"margin":2,"margin_percent":33.33,"peak_hour":"2024-02-05T14:00:00Z",`+
+					`"peak_hour_votes":1,"turnout":150,"votes_per_hour":0.5},`+
+					`"tie_break":"shared","total_votes":6,"winners":[%[2]q]}`,
+				data.ExampleOptionID1, data.ExampleOptionID2,
+			),
+		},
+		{
+			name:           "results hidden below threshold",
+			pollID:         data.ExamplePollIDThreshold.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"metadata":{"hidden":true,"results_threshold":3,"votes_needed":2},"results":[]}`,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			url := test.url
+			if url == "" {
+				url = "/"
+			}
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", test.pollID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
 			req.Header.Set("X-Forwarded-For", test.ip)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.showResultsHandler)
 			handler.ServeHTTP(rr, req)
 			if rr.Code != test.expectedStatus {
 				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
 			}
+			if hasVoters := strings.Contains(rr.Body.String(), `"voters":["Jane"]`); hasVoters != test.expectVoters {
+				t.Errorf("expected voters in body to be %t, but got %q", test.expectVoters, rr.Body)
+			}
+			if test.expectedBody != "" && strings.TrimSpace(rr.Body.String()) != test.expectedBody {
+				t.Errorf("expected body %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBodyContains) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBodyContains, rr.Body)
+			}
 		})
 	}
 }
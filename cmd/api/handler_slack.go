@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/slack"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+const slackUsage = "Usage: `/poll Question? | Option one | Option two`"
+
+// verifySlackRequest checks the request signature and responds if it is
+// missing or invalid. Slack endpoints are not found when no signing secret
+// is configured.
+func (app *application) verifySlackRequest(w http.ResponseWriter, r *http.Request) bool {
+	if app.config.slack.signingSecret == "" {
+		app.notFoundResponse(w, r)
+		return false
+	}
+
+	_, err := slack.Verify(app.config.slack.signingSecret, r, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, slack.ErrInvalidSignature):
+			app.invalidSignatureResponse(w)
+		default:
+			app.badRequestResponse(w, err)
+		}
+		return false
+	}
+
+	return true
+}
+
+func (app *application) slackCommandHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.verifySlackRequest(w, r) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	text := strings.TrimSpace(r.PostForm.Get("text"))
+	if text == "" || text == "help" {
+		app.writeSlackMessage(w, slack.Ephemeral(slackUsage))
+		return
+	}
+
+	question, values := slack.ParseCommand(text)
+
+	options := []*data.PollOption{}
+	for i, value := range values {
+		options = append(options, &data.PollOption{Value: app.text.Line(value), Position: i})
+	}
+
+	poll := &data.Poll{
+		Question:          app.text.Line(question),
+		Options:           options,
+		ResultsVisibility: "always",
+		TieBreak:          "shared",
+		Anonymity:         "anonymous",
+		Visibility:        data.VisibilityPublic,
+	}
+
+	v := validator.New()
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		app.writeSlackMessage(w, slack.Ephemeral(slackValidationText(v.Errors)))
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Insert(poll, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	app.publishEvent(events.PollCreated(poll))
+	app.recordActivity(poll.ID, data.ActorOwner, data.ActionPollCreated, nil)
+
+	// the token is only shown to the user who created the poll
+	if responseURL := r.PostForm.Get("response_url"); responseURL != "" {
+		message := slack.Ephemeral(fmt.Sprintf(
+			"Poll created: %s\nManage it with the token `%s`. Keep it somewhere safe, it won't be shown again.",
+			app.pollURL(r, poll.ID), token.Plaintext,
+		))
+		app.background(func() {
+			if err := slack.Respond(app.httpClient, responseURL, message); err != nil {
+				app.logError(err)
+			}
+		})
+	}
+
+	app.writeSlackMessage(w, slack.PollMessage(poll, []*data.PollOption{}))
+}
+
+func (app *application) slackInteractionHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.verifySlackRequest(w, r) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var payload slack.InteractionPayload
+	err := json.Unmarshal([]byte(r.PostForm.Get("payload")), &payload)
+	if err != nil {
+		app.badRequestResponse(w, errors.New("body contains badly-formed payload"))
+		return
+	}
+
+	// only vote buttons are handled, everything else is acknowledged
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 ||
+		!strings.HasPrefix(payload.Actions[0].ActionID, "vote:") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	pollID, optionID, ok := slack.ParseVoteValue(payload.Actions[0].Value)
+	if !ok {
+		app.badRequestResponse(w, errors.New("invalid vote value"))
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	reply := func(message slack.Message) {
+		app.background(func() {
+			if err := slack.Respond(app.httpClient, payload.ResponseURL, message); err != nil {
+				app.logError(err)
+			}
+		})
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if err := app.voting().Check(poll); err != nil {
+		message, _ := voteRefusal(err, "from Slack")
+		reply(slack.Ephemeral(message))
+		return
+	}
+
+	vote := &data.Vote{
+		PollID:        poll.ID,
+		OptionID:      optionID,
+		UserAgent:     "Slack",
+		VoterIdentity: slack.VoterIdentity(payload.Team.ID, payload.User.ID),
+	}
+
+	err = app.voting().Cast(vote)
+	if err != nil {
+		message, refused := voteRefusal(err, "from Slack")
+		switch {
+		case refused:
+			reply(slack.Ephemeral(message))
+		case errors.Is(err, data.ErrRecordNotFound), errors.Is(err, data.ErrOptionNotInPoll):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.publishEvent(events.VoteCast(vote))
+	app.trackEvent(analytics.Vote(vote))
+	app.purgePoll(vote.PollID)
+
+	// the message is visible to the whole channel, voters or not
+	var results []*data.PollOption
+	if poll.ResultsVisibility == "always" {
+		results, err = app.models.PollOptions.GetResults(poll.ID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
+	// results are posted back to the channel by replacing the poll message
+	message := slack.PollMessage(poll, results)
+	message.ReplaceOriginal = true
+	reply(message)
+}
+
+func (app *application) writeSlackMessage(w http.ResponseWriter, message slack.Message) {
+	js, err := json.Marshal(message)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(js)
+}
+
+func slackValidationText(errs map[string]string) string {
+	return "Couldn't create the poll:\n" + formatValidationErrors(errs) + "\n" + slackUsage
+}
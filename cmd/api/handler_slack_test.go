@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/slack"
+)
+
+const testSlackSecret = "slacksecret"
+
+func newSlackRequest(form url.Values, secret string) *http.Request {
+	body := form.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", slack.Sign(secret, timestamp, []byte(body)))
+	return req
+}
+
+// newResponseURL returns a server standing in for a Slack response_url and
+// a channel receiving the bodies posted to it.
+func newResponseURL(t *testing.T) (*httptest.Server, chan string) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func waitForResponse(t *testing.T, received chan string) string {
+	select {
+	case body := <-received:
+		return body
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a message posted to the response_url")
+		return ""
+	}
+}
+
+func Test_app_slackCommandHandler(t *testing.T) {
+	app.config.slack.signingSecret = testSlackSecret
+	defer func() { app.config.slack.signingSecret = "" }()
+
+	tests := []struct {
+		name            string
+		text            string
+		secret          string
+		expectedStatus  int
+		expectedBody    string
+		expectsResponse string
+	}{
+		{
+			name:            "create poll",
+			text:            "Lunch? | Pizza | Sushi",
+			secret:          testSlackSecret,
+			expectedStatus:  http.StatusOK,
+			expectedBody:    `"response_type":"in_channel"`,
+			expectsResponse: "Manage it with the token",
+		},
+		{
+			name:           "help",
+			text:           "",
+			secret:         testSlackSecret,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Usage:",
+		},
+		{
+			name:           "invalid poll",
+			text:           "Lunch? | Pizza",
+			secret:         testSlackSecret,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Couldn't create the poll",
+		},
+		{
+			name:           "invalid signature",
+			text:           "Lunch? | Pizza | Sushi",
+			secret:         "wrong",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing request signature",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, received := newResponseURL(t)
+			form := url.Values{"command": {"/poll"}, "text": {test.text}, "response_url": {srv.URL}}
+			req := newSlackRequest(form, test.secret)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.slackCommandHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if test.expectsResponse != "" {
+				body := waitForResponse(t, received)
+				if !strings.Contains(body, test.expectsResponse) {
+					t.Errorf("expected response to contain %q, but got %q", test.expectsResponse, body)
+				}
+			}
+		})
+	}
+}
+
+func Test_app_slackCommandHandler_notConfigured(t *testing.T) {
+	req := newSlackRequest(url.Values{"text": {"Lunch? | Pizza | Sushi"}}, "")
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.slackCommandHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, but got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func Test_app_slackInteractionHandler(t *testing.T) {
+	app.config.slack.signingSecret = testSlackSecret
+	defer func() { app.config.slack.signingSecret = "" }()
+
+	tests := []struct {
+		name             string
+		userID           string
+		value            string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "vote",
+			userID:           "U0002",
+			value:            data.ExamplePollIDValid.String() + "|" + data.ExampleOptionID1.String(),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `"replace_original":true`,
+		},
+		{
+			name:             "already voted",
+			userID:           "U0001",
+			value:            data.ExamplePollIDValid.String() + "|" + data.ExampleOptionID1.String(),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "You have already voted",
+		},
+		{
+			name:             "already voted concurrently",
+			userID:           "U0003",
+			value:            data.ExamplePollIDValid.String() + "|" + data.ExampleOptionID1.String(),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "You have already voted",
+		},
+		{
+			name:             "expired poll",
+			userID:           "U0002",
+			value:            data.ExamplePollIDExpiredPoll.String() + "|" + data.ExampleOptionID1.String(),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "This poll has expired",
+		},
+		{
+			name:           "poll not found",
+			userID:         "U0002",
+			value:          "00000000-0000-0000-0000-000000000000|" + data.ExampleOptionID1.String(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid value",
+			userID:         "U0002",
+			value:          "nope",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, received := newResponseURL(t)
+			payload, _ := json.Marshal(map[string]any{
+				"type":         "block_actions",
+				"user":         map[string]string{"id": test.userID},
+				"team":         map[string]string{"id": "T0001"},
+				"actions":      []map[string]string{{"action_id": "vote:x", "value": test.value}},
+				"response_url": srv.URL,
+			})
+			req := newSlackRequest(url.Values{"payload": {string(payload)}}, testSlackSecret)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.slackInteractionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if test.expectedResponse != "" {
+				body := waitForResponse(t, received)
+				if !strings.Contains(body, test.expectedResponse) {
+					t.Errorf("expected response to contain %q, but got %q", test.expectedResponse, body)
+				}
+			}
+		})
+	}
+}
+
+func Test_slackValidationText(t *testing.T) {
+	text := slackValidationText(map[string]string{"question": "must be provided"})
+	expected := fmt.Sprintf("• question must be provided\n%s", slackUsage)
+	if !strings.Contains(text, expected) {
+		t.Errorf("expected text to contain %q, but got %q", expected, text)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// streamPollsHandler streams the polls of the member's organization as
+// newline delimited JSON, or every poll for the admin, including drafts and
+// private polls. The polls are read from the database as they are written,
+// so large exports aren't held in memory.
+func (app *application) streamPollsHandler(w http.ResponseWriter, r *http.Request) {
+	var orgID uuid.UUID
+	if member, ok := app.memberFromContext(r.Context()); ok {
+		orgID = member.OrgID
+	}
+
+	app.writeNDJSON(w, r, func(out io.Writer, flush func() error) error {
+		enc := json.NewEncoder(out)
+		written := 0
+
+		err := app.models.Polls.Stream(orgID, func(poll *data.Poll) error {
+			if err := enc.Encode(poll); err != nil {
+				return err
+			}
+			written++
+			if written%dumpBatchSize == 0 {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return flush()
+	})
+}
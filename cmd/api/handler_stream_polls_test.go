@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_streamPollsHandler(t *testing.T) {
+	adminToken := "0123456789abcdef0123456789abcdef"
+	app.config.admin.token = adminToken
+	defer func() { app.config.admin.token = "" }()
+
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		{"org member", "Bearer " + data.ExampleTokenOrgViewer, http.StatusOK, []string{data.ExamplePollIDOrg.String()}},
+		{"admin", "Bearer " + adminToken, http.StatusOK, []string{data.ExamplePollIDOrg.String(), data.ExamplePollIDDraft.String(), data.ExamplePollIDValid.String()}},
+		{"poll token", "Bearer " + data.ExampleTokenOwnerVoters, http.StatusUnauthorized, nil},
+		{"no token", "", http.StatusUnauthorized, nil},
+	}
+
+	routes := app.routes()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/polls/all.ndjson", nil)
+			req.RemoteAddr = "9.9.9.9:1234"
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d: %s", test.expectedStatus, rr.Code, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("expected content type application/x-ndjson, but got %q", ct)
+			}
+			lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+			if len(lines) != len(test.expectedIDs) {
+				t.Fatalf("expected %d lines, but got %d: %s", len(test.expectedIDs), len(lines), rr.Body)
+			}
+			for i, id := range test.expectedIDs {
+				if !strings.Contains(lines[i], `"id":"`+id+`"`) {
+					t.Errorf("expected line %d to be poll %s, but got %s", i+1, id, lines[i])
+				}
+			}
+		})
+	}
+}
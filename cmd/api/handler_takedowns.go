@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// takeDownPollInput is the optional body of PUT /v1/admin/takedowns/{pollID}.
+type takeDownPollInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// takeDownPollHandler removes a poll for everyone, leaving a tombstone.
+func (app *application) takeDownPollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input takeDownPollInput
+
+	if r.ContentLength != 0 {
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+	}
+
+	input.Reason = app.text.Line(input.Reason)
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	takedown := &data.Takedown{PollID: pollID, Reason: input.Reason}
+
+	err = app.models.Takedowns.Insert(takedown)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(pollID, data.ActorModerator, data.ActionPollRemoved, map[string]any{
+		"reason": takedown.Reason,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"takedown": takedown}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) restorePollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Takedowns.Delete(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(pollID, data.ActorModerator, data.ActionPollRestored, nil)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll restored"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_takeDownPollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "take down poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"reason":"spam"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"reason":"spam"`,
+		},
+		{
+			name:           "without reason",
+			pollID:         data.ExamplePollIDValid.String(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"poll_id":"` + data.ExamplePollIDValid.String() + `"`,
+		},
+		{
+			name:           "reason too long",
+			pollID:         data.ExamplePollIDValid.String(),
+			json:           `{"reason":"` + strings.Repeat("a", 501) + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"reason":"must not be more than 500 bytes long"`,
+		},
+		{
+			name:           "unknown poll",
+			pollID:         "3c6a3f2e-64d1-4bd2-9d58-1b0c2e7f4a10",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid id",
+			pollID:         "invalid",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.takeDownPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_restorePollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"restore poll", data.ExamplePollIDRemoved.String(), http.StatusOK, "poll restored"},
+		{"poll not taken down", data.ExamplePollIDValid.String(), http.StatusNotFound, "the requested resource could not be found"},
+		{"invalid id", "invalid", http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.restorePollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
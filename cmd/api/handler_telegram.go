@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/telegram"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+const telegramUsage = "Usage: /poll Question? | Option one | Option two"
+
+// telegramWebhookHandler handles updates sent by Telegram. Replies are sent
+// as a Bot API method call in the webhook response where possible. The
+// endpoint is not found when the bot is not configured.
+func (app *application) telegramWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if app.telegram == nil || app.config.telegram.webhookSecret == "" {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	if !telegram.VerifySecret(app.config.telegram.webhookSecret, r) {
+		app.invalidSignatureResponse(w)
+		return
+	}
+
+	var update telegram.Update
+	err := json.NewDecoder(io.LimitReader(r.Body, 1_048_576)).Decode(&update)
+	if err != nil {
+		app.badRequestResponse(w, errors.New("body contains badly-formed JSON"))
+		return
+	}
+
+	switch {
+	case update.CallbackQuery != nil:
+		app.telegramVote(w, update.CallbackQuery)
+	case update.Message != nil:
+		app.telegramCommand(w, update.Message)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (app *application) telegramCommand(w http.ResponseWriter, message *telegram.Message) {
+	question, values, ok := telegram.ParseCommand(message.Text)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	reply := func(text string) {
+		app.writeTelegramMethod(w, "sendMessage", envelope{"chat_id": message.Chat.ID, "text": text})
+	}
+
+	if question == "" {
+		reply(telegramUsage)
+		return
+	}
+
+	options := []*data.PollOption{}
+	for i, value := range values {
+		options = append(options, &data.PollOption{Value: app.text.Line(value), Position: i})
+	}
+
+	poll := &data.Poll{
+		Question:          app.text.Line(question),
+		Options:           options,
+		ResultsVisibility: "always",
+		TieBreak:          "shared",
+		Anonymity:         "anonymous",
+		Visibility:        data.VisibilityPublic,
+	}
+
+	v := validator.New()
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		reply("Couldn't create the poll:\n" + formatValidationErrors(v.Errors) + "\n" + telegramUsage)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Insert(poll, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	app.publishEvent(events.PollCreated(poll))
+	app.recordActivity(poll.ID, data.ActorOwner, data.ActionPollCreated, nil)
+
+	text, markup := telegram.PollMessage(poll, []*data.PollOption{})
+	app.writeTelegramMethod(w, "sendMessage", envelope{
+		"chat_id":      message.Chat.ID,
+		"text":         text,
+		"reply_markup": markup,
+	})
+
+	// the token is sent in a private chat with the user who created the poll,
+	// which only works if they have started a chat with the bot
+	if message.From != nil {
+		content := fmt.Sprintf(
+			"Manage your poll %q with the token %s. Keep it somewhere safe, it won't be shown again.",
+			poll.Question, token.Plaintext,
+		)
+		app.background(func() {
+			err := app.telegram.Call("sendMessage", map[string]any{"chat_id": message.From.ID, "text": content})
+			if err != nil {
+				app.logError(err)
+			}
+		})
+	}
+}
+
+func (app *application) telegramVote(w http.ResponseWriter, query *telegram.CallbackQuery) {
+	answer := func(text string) {
+		app.writeTelegramMethod(w, "answerCallbackQuery", envelope{"callback_query_id": query.ID, "text": text})
+	}
+
+	pollID, position, ok := telegram.ParseCallbackData(query.Data)
+	if !ok || query.Message == nil {
+		answer("Unknown action.")
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			answer("This poll no longer exists.")
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	var option *data.PollOption
+	for _, opt := range poll.Options {
+		if opt.Position == position {
+			option = opt
+		}
+	}
+	if option == nil {
+		answer("This option no longer exists.")
+		return
+	}
+
+	if err := app.voting().Check(poll); err != nil {
+		message, _ := voteRefusal(err, "from Telegram")
+		answer(message)
+		return
+	}
+
+	vote := &data.Vote{
+		PollID:        poll.ID,
+		OptionID:      option.ID,
+		UserAgent:     "Telegram",
+		VoterIdentity: telegram.VoterIdentity(query.Message.Chat.ID, query.From.ID),
+	}
+
+	err = app.voting().Cast(vote)
+	if err != nil {
+		message, refused := voteRefusal(err, "from Telegram")
+		switch {
+		case refused:
+			answer(message)
+		case errors.Is(err, data.ErrOptionNotInPoll):
+			answer("This option no longer exists.")
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.publishEvent(events.VoteCast(vote))
+	app.trackEvent(analytics.Vote(vote))
+	app.purgePoll(vote.PollID)
+
+	// the message is visible to the whole chat, voters or not
+	if poll.ResultsVisibility == "always" {
+		results, err := app.models.PollOptions.GetResults(poll.ID)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+
+		text, markup := telegram.PollMessage(poll, results)
+		chatID, messageID := query.Message.Chat.ID, query.Message.MessageID
+		app.background(func() {
+			err := app.telegram.Call("editMessageText", map[string]any{
+				"chat_id":      chatID,
+				"message_id":   messageID,
+				"text":         text,
+				"reply_markup": markup,
+			})
+			if err != nil {
+				app.logError(err)
+			}
+		})
+	}
+
+	answer("Vote recorded.")
+}
+
+// writeTelegramMethod responds to a webhook request with a Bot API method
+// call, which Telegram performs on the bot's behalf.
+func (app *application) writeTelegramMethod(w http.ResponseWriter, method string, params envelope) {
+	params["method"] = method
+
+	err := app.writeJSON(w, http.StatusOK, params, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
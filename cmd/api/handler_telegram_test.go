@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/telegram"
+)
+
+func Test_app_telegramWebhookHandler(t *testing.T) {
+	calls := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls <- r.URL.Path + " " + string(body)
+	}))
+	defer srv.Close()
+	apiURL := telegram.APIURL
+	telegram.APIURL = srv.URL
+	defer func() { telegram.APIURL = apiURL }()
+
+	app.telegram = telegram.NewClient("bottoken")
+	app.config.telegram.webhookSecret = "webhooksecret"
+	defer func() {
+		app.telegram = nil
+		app.config.telegram.webhookSecret = ""
+	}()
+
+	callback := func(userID, data string) string {
+		return `{"update_id":1,"callback_query":{"id":"q1","from":{"id":` + userID + `},` +
+			`"message":{"message_id":5,"chat":{"id":-100,"type":"group"}},"data":"` + data + `"}}`
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		secret         string
+		expectedStatus int
+		expectedBody   string
+		expectedCall   string
+	}{
+		{
+			name:           "create poll",
+			body:           `{"update_id":1,"message":{"message_id":1,"from":{"id":1},"chat":{"id":-100,"type":"group"},"text":"/poll Lunch? | Pizza | Sushi"}}`,
+			secret:         "webhooksecret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"method":"sendMessage"`,
+			expectedCall:   "/botbottoken/sendMessage",
+		},
+		{
+			name:           "invalid poll",
+			body:           `{"update_id":1,"message":{"message_id":1,"from":{"id":1},"chat":{"id":-100,"type":"group"},"text":"/poll Lunch? | Pizza"}}`,
+			secret:         "webhooksecret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "must contain at least two options",
+		},
+		{
+			name:           "other message",
+			body:           `{"update_id":1,"message":{"message_id":1,"chat":{"id":-100,"type":"group"},"text":"hello"}}`,
+			secret:         "webhooksecret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "vote",
+			body:           callback("2", "v:"+data.ExamplePollIDValid.String()+":0"),
+			secret:         "webhooksecret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Vote recorded",
+			expectedCall:   "/botbottoken/editMessageText",
+		},
+		{
+			name:           "already voted",
+			body:           callback("1", "v:"+data.ExamplePollIDValid.String()+":0"),
+			secret:         "webhooksecret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "You have already voted",
+		},
+		{
+			name:           "unknown option",
+			body:           callback("2", "v:"+data.ExamplePollIDValid.String()+":9"),
+			secret:         "webhooksecret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "This option no longer exists",
+		},
+		{
+			name:           "invalid secret",
+			body:           `{"update_id":1}`,
+			secret:         "wrong",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing request signature",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", test.secret)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.telegramWebhookHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if test.expectedCall != "" {
+				select {
+				case call := <-calls:
+					if !strings.HasPrefix(call, test.expectedCall) {
+						t.Errorf("expected call to %q, but got %q", test.expectedCall, call)
+					}
+				case <-time.After(2 * time.Second):
+					t.Errorf("expected a call to %q", test.expectedCall)
+				}
+			}
+		})
+	}
+}
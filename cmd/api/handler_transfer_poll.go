@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// transferPollInput is the optional body of POST /v1/polls/{pollID}/transfer.
+type transferPollInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// transferPollHandler invalidates the poll's token and issues a new one, to
+// be handed to the poll's new owner. The request body is optional.
+func (app *application) transferPollHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	var input transferPollInput
+
+	if r.ContentLength != 0 {
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+	}
+
+	input.Reason = strings.TrimSpace(input.Reason)
+
+	v := validator.New()
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	// the transfer is recorded without an IP if the request has none
+	ip, _ := clientIP(r)
+	transfer := &data.Transfer{
+		PollID:    id,
+		Reason:    input.Reason,
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.models.Transfers.Insert(transfer, token.Hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.recordActivity(id, app.actor(r), data.ActionPollTransferred, map[string]any{"reason": transfer.Reason})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"token": token.Plaintext, "transfer": transfer}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+func (app *application) listPollTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	id := app.pollIDfromContext(r.Context())
+
+	transfers, err := app.models.Transfers.GetForPoll(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"transfers": transfers}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func Test_app_transferPollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		json           string
+		expectedStatus int
+	}{
+		{"without body", data.ExamplePollIDValid, "", http.StatusOK},
+		{"with reason", data.ExamplePollIDValid, `{"reason":"Jane left the team"}`, http.StatusOK},
+		{"reason too long", data.ExamplePollIDValid, `{"reason":"` + strings.Repeat("a", 501) + `"}`, http.StatusUnprocessableEntity},
+		{"unknown field", data.ExamplePollIDValid, `{"owner":"john"}`, http.StatusBadRequest},
+		{"poll not found", uuid.Nil, "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.transferPollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			if rr.Code == http.StatusOK {
+				var body struct {
+					Token string `json:"token"`
+				}
+				_ = json.Unmarshal(rr.Body.Bytes(), &body)
+				v := validator.New()
+				if data.ValidateTokenPlaintext(v, body.Token); !v.Valid() {
+					t.Errorf("expected a new token, but got %q", body.Token)
+				}
+			}
+		})
+	}
+}
+
+func Test_app_listPollTransfersHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.listPollTransfersHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"transfers":[{"id":1`) {
+		t.Errorf("expected body to contain the transfer, but got %q", rr.Body)
+	}
+}
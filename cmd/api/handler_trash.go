@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// listTrashHandler lists the polls of the requesting member's organization
+// that are staged to be deleted, with how long they can still be restored
+// by undoing their delete.
+func (app *application) listTrashHandler(w http.ResponseWriter, r *http.Request) {
+	member, _ := app.memberFromContext(r.Context())
+
+	polls, err := app.models.Staged.GetTrash(member.OrgID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	type trashedPoll struct {
+		*data.TrashedPoll
+		SecondsUntilPurge int    `json:"seconds_until_purge"`
+		RestoreURL        string `json:"restore_url"`
+	}
+
+	now := time.Now()
+	trash := make([]trashedPoll, 0, len(polls))
+	for _, p := range polls {
+		trash = append(trash, trashedPoll{
+			TrashedPoll:       p,
+			SecondsUntilPurge: max(int(math.Ceil(p.UndoUntil.Sub(now).Seconds())), 0),
+			RestoreURL:        app.externalURL(r, "/v1/undo/"+p.ActionID.String()),
+		})
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"polls": trash}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
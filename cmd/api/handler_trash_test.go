@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listTrashHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+		expectedPolls  int
+	}{
+		{"org member", "Bearer " + data.ExampleTokenOrgViewer, http.StatusOK, 1},
+		{"no token", "", http.StatusUnauthorized, 0},
+		{"poll token", "Bearer " + data.ExampleTokenOwnerVoters, http.StatusUnauthorized, 0},
+	}
+
+	routes := app.routes()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/polls/trash", nil)
+			req.RemoteAddr = "9.9.9.9:1234"
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d: %s", test.expectedStatus, rr.Code, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Polls []struct {
+					ActionID          uuid.UUID `json:"action_id"`
+					PollID            uuid.UUID `json:"poll_id"`
+					SecondsUntilPurge int       `json:"seconds_until_purge"`
+					RestoreURL        string    `json:"restore_url"`
+				} `json:"polls"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Polls) != test.expectedPolls {
+				t.Fatalf("expected %d polls, but got %s", test.expectedPolls, rr.Body)
+			}
+
+			poll := body.Polls[0]
+			if poll.PollID != data.ExamplePollIDOrg || poll.ActionID != data.ExampleStagedActionID {
+				t.Errorf("unexpected poll %s", rr.Body)
+			}
+			if poll.SecondsUntilPurge < 19 || poll.SecondsUntilPurge > 20 {
+				t.Errorf("expected about 20 seconds until purge, but got %d", poll.SecondsUntilPurge)
+			}
+			if want := "http://" + req.Host + "/v1/undo/" + data.ExampleStagedActionID.String(); poll.RestoreURL != want {
+				t.Errorf("expected restore url %q, but got %q", want, poll.RestoreURL)
+			}
+		})
+	}
+}
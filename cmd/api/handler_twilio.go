@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/twilio"
+)
+
+// twilioSMSHandler handles text messages sent to a phone number bound to a
+// poll, which Twilio forwards as form encoded webhook requests. "VOTE 2"
+// votes for the poll's second option and each phone number can vote once.
+// Replies are sent back as TwiML in the response. The endpoint is not found
+// when Twilio is not configured.
+func (app *application) twilioSMSHandler(w http.ResponseWriter, r *http.Request) {
+	if app.config.twilio.authToken == "" {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1_048_576)
+	if err := r.ParseForm(); err != nil {
+		app.badRequestResponse(w, errors.New("body contains badly-formed form data"))
+		return
+	}
+
+	// Twilio signs the URL it was configured with, which is the external one
+	// behind a proxy
+	url := app.externalURL(r, r.URL.RequestURI())
+	if !twilio.VerifySignature(app.config.twilio.authToken, url, r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		app.invalidSignatureResponse(w)
+		return
+	}
+
+	reply := func(text string) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusOK)
+		w.Write(twilio.Reply(text))
+	}
+
+	pollID, err := app.models.SMSNumbers.GetPollID(r.PostForm.Get("To"))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			reply("This number isn't taking votes right now.")
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			reply("This number isn't taking votes right now.")
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.voting().Check(poll); err != nil {
+		message, _ := voteRefusal(err, "by SMS")
+		reply(message)
+		return
+	}
+
+	// votes can't be confirmed by email from a phone
+	if poll.ConfirmVotes {
+		reply("This poll can't be voted on by SMS.")
+		return
+	}
+
+	number, ok := twilio.ParseVote(r.PostForm.Get("Body"))
+	if !ok || number > len(poll.Options) {
+		reply(smsUsage(poll))
+		return
+	}
+	option := poll.Options[number-1]
+
+	vote := &data.Vote{
+		PollID:        poll.ID,
+		OptionID:      option.ID,
+		UserAgent:     "SMS",
+		VoterIdentity: twilio.VoterIdentity(r.PostForm.Get("From")),
+	}
+
+	err = app.voting().Cast(vote)
+	if err != nil {
+		message, refused := voteRefusal(err, "by SMS")
+		switch {
+		case refused:
+			reply(message)
+		case errors.Is(err, data.ErrOptionNotInPoll):
+			reply(smsUsage(poll))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	app.publishEvent(events.VoteCast(vote))
+	app.trackEvent(analytics.Vote(vote))
+	app.purgePoll(vote.PollID)
+
+	reply(fmt.Sprintf("Vote recorded for %s.", option.Value))
+}
+
+// smsUsage lists the poll's options with the numbers to vote for them by.
+func smsUsage(poll *data.Poll) string {
+	lines := []string{poll.Question, "Reply VOTE and the number of your choice:"}
+	for i, opt := range poll.Options {
+		lines = append(lines, fmt.Sprintf("%d %s", i+1, opt.Value))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/twilio"
+)
+
+func Test_app_twilioSMSHandler(t *testing.T) {
+	app.config.twilio.authToken = "authtoken"
+	defer func() { app.config.twilio.authToken = "" }()
+
+	message := func(from, to, body string) url.Values {
+		return url.Values{"From": {from}, "To": {to}, "Body": {body}, "MessageSid": {"SM1"}}
+	}
+
+	tests := []struct {
+		name           string
+		params         url.Values
+		signature      string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "vote",
+			params:         message("+15550009999", data.ExampleSMSNumber, "VOTE 2"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Vote recorded for Two.",
+		},
+		{
+			name:           "already voted",
+			params:         message("+15550001111", data.ExampleSMSNumber, "vote 1"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "You have already voted",
+		},
+		{
+			name:           "unknown option",
+			params:         message("+15550009999", data.ExampleSMSNumber, "VOTE 9"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "1 One&#xA;2 Two&#xA;3 Three",
+		},
+		{
+			name:           "other message",
+			params:         message("+15550009999", data.ExampleSMSNumber, "hello"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Reply VOTE",
+		},
+		{
+			name:           "paused",
+			params:         message("+15550009999", data.ExampleSMSNumberPaused, "VOTE 1"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Voting on this poll is paused",
+		},
+		{
+			name:           "unbound number",
+			params:         message("+15550009999", "+15550005555", "VOTE 1"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "taking votes right now",
+		},
+		{
+			name:           "invalid signature",
+			params:         message("+15550009999", data.ExampleSMSNumber, "VOTE 2"),
+			signature:      "wrong",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing request signature",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/v1/integrations/twilio/sms", strings.NewReader(test.params.Encode()))
+			req.Host = "polls.example.com"
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			signature := test.signature
+			if signature == "" {
+				signature = twilio.Signature("authtoken", "http://polls.example.com/v1/integrations/twilio/sms", test.params)
+			}
+			req.Header.Set("X-Twilio-Signature", signature)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.twilioSMSHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_twilioSMSHandler_notConfigured(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("Body=VOTE+1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.twilioSMSHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, but got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// undoHandler cancels a staged delete before it takes effect. It takes the
+// same token the delete did.
+func (app *application) undoHandler(w http.ResponseWriter, r *http.Request) {
+	actionID, err := app.readIDParam(r, "actionID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	action, err := app.models.Staged.Get(actionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if !app.can(r, action.PollID, auth.EditPoll) {
+		app.invalidTokenResponse(w)
+		return
+	}
+
+	err = app.models.Staged.Undo(action.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	message := "poll delete undone"
+	if action.Action == data.StagedOptionDelete {
+		message = "option delete undone"
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": message}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_undoHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		actionID       string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "undo",
+			actionID:       data.ExampleStagedActionID.String(),
+			token:          "UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "option delete undone",
+		},
+		{
+			name:           "no token",
+			actionID:       data.ExampleStagedActionID.String(),
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name:           "results token",
+			actionID:       data.ExampleStagedActionID.String(),
+			token:          data.ExampleTokenResults,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name:           "another poll's token",
+			actionID:       data.ExampleStagedActionID.String(),
+			token:          data.ExampleTokenOwnerVoters,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name:           "window passed",
+			actionID:       uuid.NewString(),
+			token:          "UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid id",
+			actionID:       "invalid",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("actionID", test.actionID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.undoHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
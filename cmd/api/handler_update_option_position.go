@@ -5,18 +5,24 @@ import (
 	"net/http"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
 	"github.com/ivcp/polls/internal/validator"
 )
 
+// optionPositionsInput is the body of PATCH /v1/polls/{pollID}/options.
+type optionPositionsInput struct {
+	Options []optionPositionInput `json:"options" validate:"required"`
+}
+
+type optionPositionInput struct {
+	ID       string `json:"id" validate:"required"`
+	Position int    `json:"position" validate:"min=0"`
+}
+
 func (app *application) updateOptionPositionHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
 
-	var input struct {
-		Options []struct {
-			Id       string `json:"id"`
-			Position int    `json:"position"`
-		} `json:"options"`
-	}
+	var input optionPositionsInput
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -27,13 +33,13 @@ func (app *application) updateOptionPositionHandler(w http.ResponseWriter, r *ht
 	optMap := make(map[string]int, len(input.Options))
 
 	for _, inputOpt := range input.Options {
-		optMap[inputOpt.Id] = inputOpt.Position
+		optMap[inputOpt.ID] = inputOpt.Position
 	}
 
 	var optionsToUpdate []*data.PollOption
 
 	for _, option := range poll.Options {
-		if position, ok := optMap[option.ID]; ok {
+		if position, ok := optMap[option.ID.String()]; ok {
 			option.Position = position
 			optionsToUpdate = append(optionsToUpdate, option)
 		}
@@ -46,17 +52,25 @@ func (app *application) updateOptionPositionHandler(w http.ResponseWriter, r *ht
 
 	v := validator.New()
 
-	if data.ValidatePoll(v, poll); !v.Valid() {
+	data.ValidatePoll(v, poll)
+	if schema.Validate(v, &input); !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
-	err = app.models.PollOptions.UpdatePosition(optionsToUpdate)
+	err = app.models.PollOptions.UpdatePosition(poll.ID, optionsToUpdate)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrOptionNotInPoll):
+			app.optionNotInPollResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
 		return
 	}
 
+	app.recordActivity(poll.ID, app.actor(r), data.ActionOptionsReordered, map[string]any{"positions": optMap})
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"message": "options updated successfully"}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
@@ -10,12 +10,31 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"go.uber.org/mock/gomock"
 )
 
 func Test_app_updateOptionPositionHandler(t *testing.T) {
+	// swapped expects the first two options to be saved in each other's
+	// positions, with the error the repository returns
+	swapped := func(err error) func(m repositoryMocks) {
+		return func(m repositoryMocks) {
+			m.options.EXPECT().
+				UpdatePosition(data.ExamplePollIDValid, gomock.Cond(func(x any) bool {
+					positions := make(map[string]int)
+					for _, option := range x.([]*data.PollOption) {
+						positions[option.ID.String()] = option.Position
+					}
+					return len(positions) == 2 &&
+						positions[data.ExampleOptionID1.String()] == 1 && positions[data.ExampleOptionID2.String()] == 0
+				})).
+				Return(err)
+		}
+	}
+
 	tests := []struct {
 		name           string
 		json           string
+		expect         func(m repositoryMocks)
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -25,9 +44,20 @@ func Test_app_updateOptionPositionHandler(t *testing.T) {
 				`{"options":[{"id":%q, "position":1}, {"id":%q, "position":0}]}`,
 				data.ExampleOptionID1, data.ExampleOptionID2,
 			),
+			expect:         swapped(nil),
 			expectedStatus: http.StatusOK,
 			expectedBody:   "options updated successfully",
 		},
+		{
+			name: "option deleted concurrently",
+			json: fmt.Sprintf(
+				`{"options":[{"id":%q, "position":1}, {"id":%q, "position":0}]}`,
+				data.ExampleOptionID1, data.ExampleOptionID2,
+			),
+			expect:         swapped(data.ErrOptionNotInPoll),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the option does not belong to this poll",
+		},
 		{
 			name: "invalid option id",
 			json: fmt.Sprintf(
@@ -53,8 +83,13 @@ func Test_app_updateOptionPositionHandler(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			mocks := mockRepositories(t)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
 			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll := examplePoll(t, data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.updateOptionPositionHandler)
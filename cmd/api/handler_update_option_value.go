@@ -1,19 +1,19 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
 	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) updateOptionValueHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
 
-	var input struct {
-		Value string `json:"value"`
-	}
+	var input optionInput
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -27,35 +27,82 @@ func (app *application) updateOptionValueHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	input.Value = app.text.Line(input.Value)
+
 	var optionToUpdate *data.PollOption
 	match := false
 
 	for _, opt := range poll.Options {
 		if opt.ID == optionID {
-			opt.Value = strings.TrimSpace(input.Value)
+			opt.Value = input.Value
+			if input.Description != nil {
+				opt.Description = app.text.Text(*input.Description)
+			}
+			if input.URL != nil {
+				opt.URL = strings.TrimSpace(*input.URL)
+			}
+			if input.GroupID != nil {
+				opt.GroupID = parseGroupID(*input.GroupID)
+			}
 			optionToUpdate = opt
 			match = true
 		}
 	}
 
 	if !match {
-		app.notFoundResponse(w, r)
+		app.optionNotInPollResponse(w)
+		return
+	}
+
+	moderated, err := app.moderate(&optionToUpdate.Value, &optionToUpdate.Description)
+	if err != nil {
+		app.contentRejectedResponse(w)
 		return
 	}
 
 	v := validator.New()
 
-	if data.ValidatePoll(v, poll); !v.Valid() {
+	data.ValidatePoll(v, poll)
+	if schema.Validate(v, &input); !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
-	err = app.models.PollOptions.UpdateValue(optionToUpdate)
+	err = app.models.PollOptions.UpdateValue(poll.ID, optionToUpdate)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrOptionNotInPoll):
+			app.optionNotInPollResponse(w)
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.duplicateOptionResponse(w, optionToUpdate)
+		default:
+			app.serverErrorResponse(w, err)
+		}
 		return
 	}
 
+	changes := map[string]any{
+		"option_id": optionToUpdate.ID,
+		"value":     optionToUpdate.Value,
+	}
+	if input.Description != nil {
+		changes["description"] = optionToUpdate.Description
+	}
+	if input.URL != nil {
+		changes["url"] = optionToUpdate.URL
+	}
+	if input.GroupID != nil {
+		changes["group_id"] = optionToUpdate.GroupID
+	}
+	app.recordActivity(poll.ID, app.actor(r), data.ActionOptionUpdated, changes)
+
+	if moderated.Flagged {
+		if err := app.flagPoll(poll, moderated.Terms); err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
 	err = app.writeJSON(w, http.StatusCreated, envelope{"message": "option updated successfully"}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
@@ -10,46 +10,106 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"go.uber.org/mock/gomock"
 )
 
 func Test_app_updateOptionValueHandler(t *testing.T) {
+	// updated expects the first option to be saved with the value, with the
+	// error the repository returns
+	updated := func(value string, err error) func(m repositoryMocks) {
+		return func(m repositoryMocks) {
+			m.options.EXPECT().
+				UpdateValue(data.ExamplePollIDValid, gomock.Cond(func(x any) bool {
+					option := x.(*data.PollOption)
+					return option.ID == data.ExampleOptionID1 && option.Value == value
+				})).
+				Return(err)
+		}
+	}
+
 	tests := []struct {
 		name           string
 		optionID       string
 		json           string
+		expect         func(m repositoryMocks)
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:           "valid update",
-			optionID:       data.ExampleOptionID1,
+			optionID:       data.ExampleOptionID1.String(),
 			json:           `{"value":"test"}`,
+			expect:         updated("test", nil),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "option updated successfully",
+		},
+		{
+			name:           "description and url",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"value":"test","description":"Former mayor","url":"https://example.com/jane"}`,
+			expect:         updated("test", nil),
 			expectedStatus: http.StatusCreated,
 			expectedBody:   "option updated successfully",
 		},
+		{
+			name:           "group",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"value":"test","group_id":"` + data.ExampleGroupID.String() + `"}`,
+			expect:         updated("test", nil),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "option updated successfully",
+		},
+		{
+			name:           "unknown group",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"value":"test","group_id":"` + uuid.NewString() + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options":"option group must be one of the poll's groups"`,
+		},
 		{
 			name:           "invalid id",
 			optionID:       uuid.NewString(),
 			json:           `{"value":"test"}`,
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   "the requested resource could not be found",
+			expectedBody:   "the option does not belong to this poll",
 		},
 		{
 			name:           "duplicate option values",
-			optionID:       data.ExampleOptionID1,
+			optionID:       data.ExampleOptionID1.String(),
 			json:           `{"value":"Two"}`,
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   "must not contain duplicate values",
 		},
+		{
+			name:           "value taken concurrently",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"value":"test"}`,
+			expect:         updated("test", data.ErrDuplicateOption),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"options.0":"must not duplicate another option's value"`,
+		},
+		{
+			name:           "option deleted concurrently",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"value":"test"}`,
+			expect:         updated("test", data.ErrOptionNotInPoll),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the option does not belong to this poll",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			mocks := mockRepositories(t)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
 			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("optionID", test.optionID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll := examplePoll(t, data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.updateOptionValueHandler)
@@ -3,20 +3,27 @@ package main
 import (
 	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/schema"
 	"github.com/ivcp/polls/internal/validator"
 )
 
+// updatePollInput is the body of PATCH /v1/polls/{pollID}. Fields that are
+// left out aren't changed.
+type updatePollInput struct {
+	Question    *string           `json:"question" validate:"max=500"`
+	Description *string           `json:"description" validate:"max=1000"`
+	ExpiresAt   data.ExpiresAt    `json:"expires_at"`
+	Tags        *[]string         `json:"tags" doc:"replaces the poll's tags"`
+	Metadata    map[string]string `json:"metadata" doc:"replaces the poll's metadata"`
+	Language    string            `json:"language" validate:"oneof=de en es fr it nl pt" doc:"detected again from the text if left out and the question or description change"`
+}
+
 func (app *application) updatePollHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
 
-	var input struct {
-		Question    *string        `json:"question"`
-		Description *string        `json:"description"`
-		ExpiresAt   data.ExpiresAt `json:"expires_at"`
-	}
+	var input updatePollInput
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -25,35 +32,100 @@ func (app *application) updatePollHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	if input.Question != nil {
-		poll.Question = strings.TrimSpace(*input.Question)
+		*input.Question = app.text.Line(*input.Question)
+		poll.Question = *input.Question
 	}
 
 	if input.Description != nil {
-		poll.Description = strings.TrimSpace(*input.Description)
+		*input.Description = app.text.Text(*input.Description)
+		poll.Description = *input.Description
 	}
 
 	if !input.ExpiresAt.IsZero() {
 		poll.ExpiresAt = input.ExpiresAt
 	}
 
-	if input.Question == nil && input.Description == nil && input.ExpiresAt.IsZero() {
+	if input.Tags != nil {
+		poll.Tags = lowerAll(*input.Tags)
+	}
+
+	if input.Metadata != nil {
+		poll.Metadata = input.Metadata
+	}
+
+	switch {
+	case input.Language != "":
+		poll.Language = input.Language
+	case input.Question != nil || input.Description != nil:
+		poll.Language = pollLanguage(poll)
+	}
+
+	if input.Question == nil && input.Description == nil && input.ExpiresAt.IsZero() && input.Tags == nil &&
+		input.Metadata == nil && input.Language == "" {
 		app.badRequestResponse(w, errors.New("no fields provided for update"))
 		return
 	}
 
+	var texts []*string
+	if input.Question != nil {
+		texts = append(texts, &poll.Question)
+	}
+	if input.Description != nil {
+		texts = append(texts, &poll.Description)
+	}
+	moderated, err := app.moderate(texts...)
+	if err != nil {
+		app.contentRejectedResponse(w)
+		return
+	}
+
 	v := validator.New()
 
-	if data.ValidatePoll(v, poll); !v.Valid() {
+	data.ValidatePoll(v, poll)
+	if schema.Validate(v, &input); !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
 	err = app.models.Polls.Update(poll)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
 		return
 	}
 
+	changes := map[string]any{}
+	if input.Question != nil {
+		changes["question"] = poll.Question
+	}
+	if input.Description != nil {
+		changes["description"] = poll.Description
+	}
+	if !input.ExpiresAt.IsZero() {
+		changes["expires_at"] = poll.ExpiresAt
+	}
+	if input.Tags != nil {
+		changes["tags"] = poll.Tags
+	}
+	if input.Metadata != nil {
+		changes["metadata"] = poll.Metadata
+	}
+	if input.Language != "" {
+		changes["language"] = poll.Language
+	}
+	app.recordActivity(poll.ID, app.actor(r), data.ActionPollUpdated, changes)
+
+	if moderated.Flagged {
+		if err := app.flagPoll(poll, moderated.Terms); err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"poll": poll}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
@@ -9,13 +9,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/moderation"
 )
 
 func Test_app_updatePollHandler(t *testing.T) {
 	tests := []struct {
 		name           string
-		id             string
+		id             uuid.UUID
 		json           string
 		expectedStatus int
 		expectedBody   string
@@ -37,6 +39,34 @@ func Test_app_updatePollHandler(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"question":"changed","description":"added description"`,
 		},
+		{
+			name:           "tags",
+			id:             data.ExamplePollIDValid,
+			json:           `{"tags":["Lunch"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"tags":["lunch"]`,
+		},
+		{
+			name:           "metadata",
+			id:             data.ExamplePollIDValid,
+			json:           `{"metadata":{"team":"platform"}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"metadata":{"team":"platform"}`,
+		},
+		{
+			name:           "language detected again",
+			id:             data.ExamplePollIDValid,
+			json:           `{"question":"Quel est le meilleur jour ?"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"language":"fr"`,
+		},
+		{
+			name:           "language",
+			id:             data.ExamplePollIDValid,
+			json:           `{"language":"nl"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"language":"nl"`,
+		},
 		{
 			name:           "empty json",
 			id:             data.ExamplePollIDValid,
@@ -44,6 +74,13 @@ func Test_app_updatePollHandler(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "no fields provided for update",
 		},
+		{
+			name:           "edit conflict",
+			id:             data.ExamplePollIDEditConflict,
+			json:           `{"question":"changed"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"code":"EDIT_CONFLICT","error":"unable to update the poll due to an edit conflict, please try again"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -64,3 +101,53 @@ func Test_app_updatePollHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_updatePollHandler_moderation(t *testing.T) {
+	app.moderation = moderation.NewWordlistProvider([]string{"darn"})
+	defer func() {
+		app.moderation = nil
+		app.config.moderation.action = ""
+	}()
+
+	tests := []struct {
+		name           string
+		action         moderation.Action
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "flag for review",
+			action:         moderation.Flag,
+			json:           `{"question":"darn?"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"moderation_status":"flagged","moderation_terms":["darn"]`,
+		},
+		{
+			name:           "reject",
+			action:         moderation.Reject,
+			json:           `{"description":"darn"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"code":"CONTENT_REJECTED"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.moderation.action = string(test.action)
+
+			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
+			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.updatePollHandler)
+			handler.ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
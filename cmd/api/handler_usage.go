@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+)
+
+// showUsageHandler shows the usage of the requesting member's organization
+// in the current month, along with its quotas.
+func (app *application) showUsageHandler(w http.ResponseWriter, r *http.Request) {
+	member, _ := app.memberFromContext(r.Context())
+
+	usage, err := app.models.Usage.Get(member.OrgID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	headers := make(http.Header)
+	if limit := app.config.quotas.activePolls; limit > 0 {
+		setQuotaHeaders(headers, limit, usage.ActivePolls)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"usage":  usage,
+		"quotas": envelope{"active_polls": app.config.quotas.activePolls},
+	}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showUsageHandler(t *testing.T) {
+	viewer, _ := app.models.Orgs.GetMemberForToken(data.ExampleTokenOrgViewer)
+
+	app.config.quotas.activePolls = 5
+	defer func() { app.config.quotas.activePolls = 0 }()
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxMemberKey, viewer))
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(app.showUsageHandler)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	if rr.Header().Get("X-Quota-Limit") != "5" || rr.Header().Get("X-Quota-Remaining") != "3" {
+		t.Errorf("unexpected quota headers %v", rr.Header())
+	}
+
+	var body struct {
+		Usage  data.UsageReport `json:"usage"`
+		Quotas struct {
+			ActivePolls int `json:"active_polls"`
+		} `json:"quotas"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	if body.Usage.ActivePolls != data.ExampleOrgActivePolls || body.Quotas.ActivePolls != 5 {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+}
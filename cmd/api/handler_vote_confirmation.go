@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/ballots"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+type voteConfirmationEmail struct {
+	Question   string
+	Option     string
+	ConfirmURL string
+	ExpiresAt  time.Time
+}
+
+// stageVote stores a vote on a poll with confirm_votes until the voter
+// confirms it, and emails them the link to do so. Voting again before
+// confirming replaces the pending vote and sends another link.
+func (app *application) stageVote(w http.ResponseWriter, r *http.Request, poll *data.Poll, vote *data.Vote, email string) {
+	if app.mailer == nil {
+		app.serverErrorResponse(w, errors.New("mailer not configured"))
+		return
+	}
+
+	var option *data.PollOption
+	for _, o := range poll.Options {
+		if o.ID == vote.OptionID {
+			option = o
+		}
+	}
+	if option == nil {
+		app.optionNotInPollResponse(w)
+		return
+	}
+
+	voted, err := app.models.Votes.HasVoted(poll.ID, vote.VoterIdentity)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if voted {
+		app.cannotVoteResponse(w)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	pending := &data.PendingVote{Vote: vote}
+	err = app.models.Pending.Insert(pending, token.Hash, app.config.voteConfirmTTL)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrConfirmationLimit):
+			app.confirmationLimitResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	// the email asks about the wording of the question the voter was shown
+	poll.ShowVariant(vote.Variant)
+	message := voteConfirmationEmail{
+		Question:   poll.Question,
+		Option:     option.Value,
+		ConfirmURL: app.externalURL(r, "/v1/votes/confirm?token="+url.QueryEscape(token.Plaintext)),
+		ExpiresAt:  pending.ExpiresAt,
+	}
+	app.background(func() {
+		if err := app.mailer.Send(email, "vote_confirmation.tmpl", message); err != nil {
+			app.logError(err)
+		}
+	})
+
+	env := envelope{"message": "confirm your vote with the link emailed to you", "expires_at": pending.ExpiresAt}
+	err = app.writeJSON(w, http.StatusAccepted, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// confirmVoteHandler counts the pending vote confirmed with the token in the
+// link emailed to the voter. The poll is checked again, as it may have
+// closed or been paused since the vote was made.
+func (app *application) confirmVoteHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	v := validator.New()
+	if data.ValidateTokenPlaintext(v, token); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	pending, err := app.models.Pending.GetByToken(data.HashToken(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	vote := pending.Vote
+
+	poll, err := app.models.Polls.Get(vote.PollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if poll.Hidden() {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	if poll.PausedAt != nil {
+		app.pollPausedResponse(w, poll.PauseReason)
+		return
+	}
+
+	var ballot *ballots.Ballot
+	if poll.Verifiable {
+		ballot, err = ballots.New(poll.ID.String(), vote.OptionID.String())
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		vote.Ballot = ballot.Hash
+	}
+
+	app.mutex.Lock()
+	if err := app.screenVote(vote); err != nil {
+		app.serverErrorResponse(w, err)
+		app.mutex.Unlock()
+		return
+	}
+
+	// the database rejects votes from voters who already voted, e.g. with
+	// another pending vote confirmed at once
+	err = app.models.PollOptions.Vote(vote)
+	if err != nil {
+		app.voteErrorResponse(w, r, err)
+		app.mutex.Unlock()
+		return
+	}
+	app.mutex.Unlock()
+
+	if err := app.models.Pending.Delete(pending.ID); err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.logError(err)
+	}
+
+	app.publishEvent(events.VoteCast(vote))
+	app.trackEvent(analytics.Vote(vote))
+
+	app.voteSuccessResponse(w, vote, ballot)
+}
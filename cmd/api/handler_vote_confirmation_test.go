@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_voteOptionHandler_confirmVotes(t *testing.T) {
+	tests := []struct {
+		name           string
+		optionID       string
+		json           string
+		expectedStatus int
+		expectedBody   string
+		expectedEmail  bool
+	}{
+		{
+			name:           "pending vote",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"email":" jane@example.com "}`,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `"message":"confirm your vote with the link emailed to you"`,
+			expectedEmail:  true,
+		},
+		{
+			name:           "no email",
+			optionID:       data.ExampleOptionID1.String(),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"VALIDATION_FAILED","error":{"email":"must be provided"}}`,
+		},
+		{
+			name:           "option of another poll",
+			optionID:       data.ExampleOptionID3.String(),
+			json:           `{"email":"jane@example.com"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"code":"OPTION_NOT_IN_POLL"`,
+		},
+		{
+			name:           "too many emails",
+			optionID:       data.ExampleOptionID1.String(),
+			json:           `{"email":"` + data.ExampleEmailResendLimit + `"}`,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `"code":"CONFIRMATION_LIMIT"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailer := &mockMailer{}
+			app.mailer = mailer
+			defer func() { app.mailer = nil }()
+
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			req.Header.Set("X-Forwarded-For", "0.0.0.0")
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDConfirm.String())
+			chiCtx.URLParams.Add("optionID", test.optionID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.voteOptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+
+			if !test.expectedEmail {
+				return
+			}
+			deadline := time.Now().Add(2 * time.Second)
+			for len(mailer.Sent()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			sent := mailer.Sent()
+			if len(sent) != 1 || sent[0].recipient != "jane@example.com" || sent[0].templateFile != "vote_confirmation.tmpl" {
+				t.Fatalf("expected a confirmation email to jane@example.com, but got %+v", sent)
+			}
+			email := sent[0].data.(voteConfirmationEmail)
+			if email.Option != "One" || !strings.Contains(email.ConfirmURL, "/v1/votes/confirm?token=") {
+				t.Errorf("unexpected email %+v", email)
+			}
+		})
+	}
+}
+
+func Test_app_confirmVoteHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "pending vote",
+			token:          data.ExampleConfirmToken,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"vote successful"`,
+		},
+		{
+			name:           "already voted",
+			token:          data.ExampleConfirmTokenVoted,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"code":"ALREADY_VOTED"`,
+		},
+		{
+			name:           "unknown or expired token",
+			token:          "UNKNOWNTOKENAAAAAAAAAAAAAA",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "malformed token",
+			token:          "token",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"token":"must be 26 bytes long"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/?token="+test.token, nil)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.confirmVoteHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -3,11 +3,27 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/ballots"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/receipts"
+	"github.com/ivcp/polls/internal/schema"
+	"github.com/ivcp/polls/internal/service"
+	"github.com/ivcp/polls/internal/validator"
 )
 
+// voteInput is the optional body of POST /v1/polls/{pollID}/options/{optionID}.
+type voteInput struct {
+	VoterName    string            `json:"voter_name" validate:"max=100" doc:"ignored by anonymous polls"`
+	Email        string            `json:"email" doc:"where the confirmation link is sent, required by polls with confirm_votes"`
+	Demographics map[string]string `json:"demographics" doc:"answers to the poll's demographic questions by key"`
+}
+
 func (app *application) voteOptionHandler(w http.ResponseWriter, r *http.Request) {
 	pollID, err := app.readIDParam(r, "pollID")
 	if err != nil {
@@ -32,19 +48,127 @@ func (app *application) voteOptionHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+	// hidden polls can be previewed, but not voted on, and private polls can
+	// only be voted on with a vote token
+	if poll.Hidden() || poll.Visibility == data.VisibilityPrivate && !app.can(r, poll.ID, auth.Vote) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// reaching the cap closes the poll, but voters are told why
+	switch err := service.Votable(poll, time.Now()); {
+	case errors.Is(err, data.ErrVoteQuotaReached):
+		app.voteQuotaReachedResponse(w)
+		return
+	case errors.Is(err, data.ErrPollExpired):
 		app.pollExpiredResponse(w)
 		return
+	case errors.Is(err, service.ErrPollPaused):
+		app.pollPausedResponse(w, poll.PauseReason)
+		return
+	}
+
+	var input voteInput
+
+	if r.ContentLength != 0 {
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+	}
+
+	vote := &data.Vote{
+		PollID:       poll.ID,
+		OptionID:     optionID,
+		Demographics: input.Demographics,
+	}
+
+	v := validator.New()
+	vote.Source = app.readSource(r.URL.Query(), v)
+
+	// names are never stored for anonymous polls
+	input.VoterName = strings.TrimSpace(input.VoterName)
+	if poll.Anonymity == "anonymous" {
+		input.VoterName = ""
+	}
+	vote.VoterName = input.VoterName
+
+	data.ValidateDemographicAnswers(v, poll, vote.Demographics)
+	data.ValidateVote(v, vote)
+	if poll.ConfirmVotes {
+		input.Email = strings.TrimSpace(input.Email)
+		data.ValidateEmail(v, input.Email, "email")
+	}
+	if schema.Validate(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	ip, ok := clientIP(r)
+	if !ok {
+		app.badRequestResponse(w, errNoClientIP)
+		return
+	}
+
+	if poll.GeoRestricted() {
+		if app.geoip == nil {
+			app.serverErrorResponse(w, errors.New("geoip provider not configured"))
+			return
+		}
+		country, err := app.geoip.Country(ip)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		if !poll.CountryAllowed(country) {
+			app.countryNotAllowedResponse(w)
+			return
+		}
 	}
 
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		app.serverErrorResponse(w, errors.New("no ip found"))
+	// votes on polls with confirm_votes are counted once per email address,
+	// once the voter confirms them
+	if poll.ConfirmVotes {
+		vote.VoterIdentity = data.EmailVoterIdentity(input.Email)
+		vote.UserAgent = r.UserAgent()
+		vote.Variant = poll.VariantFor(voterIdentity(r))
+		app.stageVote(w, r, poll, vote, input.Email)
 		return
 	}
 
+	// votes made with a vote token are counted once per token instead of
+	// once per IP, so people sharing an IP can each vote with their own token.
+	// Kiosk tokens are shared by everyone voting on one device, so their
+	// votes aren't deduplicated at all.
+	var voterIdentity string
+	var kiosk bool
+	if r.Header.Get("Authorization") != "" {
+		principal, err := app.principalFromRequest(r)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		if err != nil || !principal.CanOnPoll(auth.Vote, poll.ID, poll.OrgID) {
+			app.invalidTokenResponse(w)
+			return
+		}
+		kiosk = principal.CanOnPoll(auth.KioskVote, poll.ID, poll.OrgID)
+		if !kiosk {
+			token, _ := bearerValue(r)
+			voterIdentity = data.TokenVoterIdentity(token)
+		}
+	}
+
 	app.mutex.Lock()
-	voted, err := app.checkIP(poll.ID, ip)
+	var voted bool
+	switch {
+	case kiosk:
+	case voterIdentity != "":
+		voted, err = app.models.Votes.HasVoted(poll.ID, voterIdentity)
+	default:
+		voted, err = app.checkIP(poll.ID, ip)
+	}
 	if err != nil {
 		app.serverErrorResponse(w, err)
 		app.mutex.Unlock()
@@ -56,21 +180,112 @@ func (app *application) voteOptionHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = app.models.PollOptions.Vote(optionID, poll.ID, ip)
-	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
-		default:
+	// kiosk votes are stored without the kiosk's IP, so they don't count as
+	// votes of others sharing it
+	switch {
+	case voterIdentity != "":
+		vote.VoterIdentity = voterIdentity
+	case !kiosk:
+		vote.IP = ip
+	}
+	vote.UserAgent = r.UserAgent()
+	// the wording is picked again like it was for showing the poll, so
+	// votes are recorded with the question the voter saw
+	if voterIdentity != "" {
+		vote.Variant = poll.VariantFor(voterIdentity)
+	} else {
+		vote.Variant = poll.VariantFor(ip)
+	}
+
+	// the ballot's nonce is only given to the voter, so only they can show
+	// which option their ballot is for
+	var ballot *ballots.Ballot
+	if poll.Verifiable {
+		ballot, err = ballots.New(poll.ID.String(), vote.OptionID.String())
+		if err != nil {
 			app.serverErrorResponse(w, err)
+			app.mutex.Unlock()
+			return
 		}
+		vote.Ballot = ballot.Hash
+	}
+
+	if err := app.screenVote(vote); err != nil {
+		app.serverErrorResponse(w, err)
+		app.mutex.Unlock()
+		return
+	}
+
+	err = app.models.PollOptions.Vote(vote)
+	if err != nil {
+		app.voteErrorResponse(w, r, err)
 		app.mutex.Unlock()
 		return
 	}
 
 	app.mutex.Unlock()
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "vote successful"}, nil)
+	app.publishEvent(events.VoteCast(vote))
+	app.trackEvent(analytics.Vote(vote))
+
+	app.voteSuccessResponse(w, vote, ballot)
+}
+
+// screenVote scores the vote for abuse against the poll's recent votes, if
+// spam screening is enabled.
+func (app *application) screenVote(vote *data.Vote) error {
+	if !app.config.spam.enabled {
+		return nil
+	}
+
+	recent, err := app.models.Votes.GetRecent(vote.PollID, time.Now().Add(-app.spam.Window))
+	if err != nil {
+		return err
+	}
+	app.spam.Screen(vote, recent)
+
+	return nil
+}
+
+// voteErrorResponse responds to an error recording a vote.
+func (app *application) voteErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, data.ErrOptionNotInPoll):
+		app.optionNotInPollResponse(w)
+	case errors.Is(err, data.ErrPollClosed):
+		app.pollClosedResponse(w)
+	case errors.Is(err, data.ErrPollExpired):
+		app.pollExpiredResponse(w)
+	case errors.Is(err, data.ErrVoteQuotaReached):
+		app.voteQuotaReachedResponse(w)
+	case errors.Is(err, data.ErrAlreadyVoted):
+		app.cannotVoteResponse(w)
+	default:
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// voteSuccessResponse responds to a recorded vote, with its receipt if a
+// receipt key is configured and its ballot if the poll is verifiable.
+func (app *application) voteSuccessResponse(w http.ResponseWriter, vote *data.Vote, ballot *ballots.Ballot) {
+	response := envelope{"message": "vote successful"}
+	if len(app.config.receipts.key) > 0 {
+		receipt := &receipts.Receipt{
+			PollID:   vote.PollID,
+			OptionID: vote.OptionID,
+			VoteID:   vote.ID,
+			CastAt:   vote.CreatedAt,
+		}
+		receipts.Sign(app.config.receipts.key, receipt)
+		response["receipt"] = receipt
+	}
+	if ballot != nil {
+		response["ballot"] = ballot
+	}
+
+	err := app.writeJSON(w, http.StatusOK, response, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 	}
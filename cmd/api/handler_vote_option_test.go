@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -10,40 +11,91 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/spam"
 )
 
 func Test_app_voteOptionHandler(t *testing.T) {
+	app.geoip = mockGeoIP{}
+	defer func() { app.geoip = nil }()
+
 	tests := []struct {
 		name           string
 		pollID         string
+		optionID       string
 		ip             string
+		query          string
+		json           string
+		authHeader     string
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:           "valid vote",
-			pollID:         data.ExamplePollIDValid,
+			pollID:         data.ExamplePollIDValid.String(),
 			ip:             "0.0.0.0",
 			expectedStatus: http.StatusOK,
 			expectedBody:   "vote successful",
 		},
 		{
 			name:           "ip already voted",
-			pollID:         data.ExamplePollIDValid,
+			pollID:         data.ExamplePollIDValid.String(),
 			ip:             "0.0.0.1",
 			expectedStatus: http.StatusForbidden,
 			expectedBody:   "you have already voted on this poll",
 		},
 		{
 			name:           "expired poll",
-			pollID:         data.ExamplePollIDExpiredPoll,
+			pollID:         data.ExamplePollIDExpiredPoll.String(),
 			ip:             "0.0.0.0",
 			expectedStatus: http.StatusForbidden,
 			expectedBody:   "poll has expired",
 		},
+		{
+			name:           "draft",
+			pollID:         data.ExamplePollIDDraft.String(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "rejected by moderation",
+			pollID:         data.ExamplePollIDRejected.String(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "paused poll",
+			pollID:         data.ExamplePollIDPaused.String(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusLocked,
+			expectedBody:   `{"code":"POLL_PAUSED","error":"voting on this poll is paused: suspected abuse"}`,
+		},
+		{
+			name:           "option of another poll",
+			pollID:         data.ExamplePollIDValid.String(),
+			optionID:       uuid.NewString(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":"OPTION_NOT_IN_POLL","error":"the option does not belong to this poll"}`,
+		},
+		{
+			name:           "max votes reached",
+			pollID:         data.ExamplePollIDVoteCapFull.String(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"code":"VOTE_QUOTA_REACHED","error":"this poll has reached its maximum number of votes"}`,
+		},
+		{
+			name:           "last vote taken concurrently",
+			pollID:         data.ExamplePollIDVoteCap.String(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"code":"VOTE_QUOTA_REACHED","error":"this poll has reached its maximum number of votes"}`,
+		},
 		{
 			name:           "expired not set",
-			pollID:         data.ExamplePollIDExpiredNotSet,
+			pollID:         data.ExamplePollIDExpiredNotSet.String(),
 			ip:             "0.0.0.0",
 			expectedStatus: http.StatusOK,
 			expectedBody:   "vote successful",
@@ -55,15 +107,169 @@ func Test_app_voteOptionHandler(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   "the requested resource could not be found",
 		},
+		{
+			name:           "vote with voter name",
+			pollID:         data.ExamplePollIDPublicVoters.String(),
+			ip:             "0.0.0.0",
+			json:           `{"voter_name":"Jane"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "vote with demographics",
+			pollID:         data.ExamplePollIDDemographics.String(),
+			ip:             "0.0.0.0",
+			json:           `{"demographics":{"team":"Sales"}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "demographic answer not a choice",
+			pollID:         data.ExamplePollIDDemographics.String(),
+			ip:             "0.0.0.0",
+			json:           `{"demographics":{"team":"Marketing"}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"demographics.team":"must be one of the question's choices"}`,
+		},
+		{
+			name:           "unknown demographic question",
+			pollID:         data.ExamplePollIDDemographics.String(),
+			ip:             "0.0.0.0",
+			json:           `{"demographics":{"age":"18-24"}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"demographics.age":"is not a question of this poll"}`,
+		},
+		{
+			name:           "vote with source",
+			pollID:         data.ExamplePollIDValid.String(),
+			ip:             "0.0.0.0",
+			query:          "?utm_source=Slack",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "invalid source",
+			pollID:         data.ExamplePollIDValid.String(),
+			ip:             "0.0.0.0",
+			query:          "?source=my%20newsletter",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"source":"must only contain letters, digits, dots, dashes and underscores"}`,
+		},
+		{
+			name:           "voter name too long",
+			pollID:         data.ExamplePollIDPublicVoters.String(),
+			ip:             "0.0.0.0",
+			json:           fmt.Sprintf(`{"voter_name":%q}`, strings.Repeat("a", 101)),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must not be more than 100 bytes long",
+		},
+		{
+			name:           "geo restricted country allowed",
+			pollID:         data.ExamplePollIDGeoRestricted.String(),
+			ip:             "1.1.1.1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "geo restricted country not allowed",
+			pollID:         data.ExamplePollIDGeoRestricted.String(),
+			ip:             "8.8.8.8",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "voting on this poll is not available in your country",
+		},
+		{
+			name:           "geo restricted through proxies",
+			pollID:         data.ExamplePollIDGeoRestricted.String(),
+			ip:             "1.1.1.2, 10.0.0.1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "unparsable ip",
+			pollID:         data.ExamplePollIDGeoRestricted.String(),
+			ip:             "unknown, 10.0.0.1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "X-Forwarded-For must start with the client's IP",
+		},
+		{
+			name:           "invalid body",
+			pollID:         data.ExamplePollIDValid.String(),
+			ip:             "0.0.0.0",
+			json:           `{"name":"Jane"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "body contains unknown key",
+		},
+		{
+			name:           "vote token from an ip that already voted",
+			pollID:         data.ExamplePollIDValid.String(),
+			ip:             "0.0.0.1",
+			authHeader:     "Bearer " + data.ExampleTokenVote,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "kiosk token from an ip that already voted",
+			pollID:         data.ExamplePollIDValid.String(),
+			ip:             "0.0.0.1",
+			authHeader:     "Bearer " + data.ExampleTokenKiosk,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "kiosk token for another poll",
+			pollID:         data.ExamplePollIDPublicVoters.String(),
+			ip:             "0.0.0.0",
+			authHeader:     "Bearer " + data.ExampleTokenKiosk,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name:           "vote token for another poll",
+			pollID:         data.ExamplePollIDPublicVoters.String(),
+			ip:             "0.0.0.0",
+			authHeader:     "Bearer " + data.ExampleTokenVote,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name:           "private poll",
+			pollID:         data.ExamplePollIDPrivate.String(),
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "private poll with vote token",
+			pollID:         data.ExamplePollIDPrivate.String(),
+			ip:             "0.0.0.0",
+			authHeader:     "Bearer " + data.ExampleTokenVotePrivate,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "manage token",
+			pollID:         data.ExamplePollIDValid.String(),
+			ip:             "0.0.0.0",
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			req, _ := http.NewRequest(http.MethodPost, "/"+test.query, strings.NewReader(test.json))
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", test.pollID)
-			chiCtx.URLParams.Add("optionID", data.ExampleOptionID1)
+			optionID := test.optionID
+			if optionID == "" {
+				optionID = data.ExampleOptionID1.String()
+			}
+			chiCtx.URLParams.Add("optionID", optionID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
 			req.Header.Set("X-Forwarded-For", test.ip)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.voteOptionHandler)
 			handler.ServeHTTP(rr, req)
@@ -77,3 +283,63 @@ func Test_app_voteOptionHandler(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkVoteOptionHandler(b *testing.B) {
+	newRequest := func(i int) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
+		chiCtx.URLParams.Add("optionID", data.ExampleOptionID1.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255))
+		return req
+	}
+
+	b.Run("serial", func(b *testing.B) {
+		handler := http.HandlerFunc(app.voteOptionHandler)
+		for i := 0; i < b.N; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), newRequest(i))
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		handler := http.HandlerFunc(app.voteOptionHandler)
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), newRequest(i))
+			}
+		})
+	})
+
+	b.Run("spam screening", func(b *testing.B) {
+		app.config.spam.enabled = true
+		app.spam = spam.New(50)
+		defer func() {
+			app.config.spam.enabled = false
+			app.spam = spam.Pipeline{}
+		}()
+
+		handler := http.HandlerFunc(app.voteOptionHandler)
+		for i := 0; i < b.N; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), newRequest(i))
+		}
+	})
+}
+
+// BenchmarkVoteRoute votes through the router, including its middlewares.
+func BenchmarkVoteRoute(b *testing.B) {
+	routes := app.routes()
+	path := fmt.Sprintf("/v1/polls/%s/options/%s", data.ExamplePollIDValid, data.ExampleOptionID1)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			req, _ := http.NewRequest(http.MethodPost, path, nil)
+			req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255))
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				b.Fatalf("expected status %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body)
+			}
+		}
+	})
+}
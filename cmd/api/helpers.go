@@ -11,13 +11,17 @@ import (
 	"net/http"
 	"net/url"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/lang"
 	"github.com/ivcp/polls/internal/validator"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -27,32 +31,334 @@ type contextKey string
 const (
 	ctxPollIDKey contextKey = "pollID"
 	ctxPollKey   contextKey = "poll"
+	ctxMemberKey contextKey = "member"
+	ctxAPIKeyKey contextKey = "apiKey"
 )
 
-func (app *application) pollIDfromContext(ctx context.Context) string {
-	return ctx.Value(ctxPollIDKey).(string)
+func (app *application) pollIDfromContext(ctx context.Context) uuid.UUID {
+	return ctx.Value(ctxPollIDKey).(uuid.UUID)
 }
 
 func (app *application) pollFromContext(ctx context.Context) *data.Poll {
 	return ctx.Value(ctxPollKey).(*data.Poll)
 }
 
-func (app *application) readIDParam(r *http.Request, idKey string) (string, error) {
-	param := chi.URLParam(r, idKey)
-	if param == "" {
-		return "", errors.New("invalid id")
+// memberFromContext returns the organization member the request was
+// authorized for, if any.
+func (app *application) memberFromContext(ctx context.Context) (*data.Member, bool) {
+	member, ok := ctx.Value(ctxMemberKey).(*data.Member)
+	return member, ok
+}
+
+// apiKeyFromContext returns the API key the request was made with, if any.
+func (app *application) apiKeyFromContext(ctx context.Context) (*data.APIKey, bool) {
+	key, ok := ctx.Value(ctxAPIKeyKey).(*data.APIKey)
+	return key, ok
+}
+
+// readIDParam parses the UUID in the URL parameter, in any of the forms
+// uuid.Parse accepts, e.g. upper case or as a URN.
+func (app *application) readIDParam(r *http.Request, idKey string) (uuid.UUID, error) {
+	id, err := uuid.Parse(chi.URLParam(r, idKey))
+	if err != nil {
+		return uuid.Nil, errors.New("invalid id")
+	}
+	return id, nil
+}
+
+// pollIDParam returns the poll ID in the URL in its canonical form, for
+// middleware keying requests by poll. Invalid IDs are returned as they are,
+// to be rejected by the handler.
+func pollIDParam(r *http.Request) string {
+	param := chi.URLParam(r, "pollID")
+	if id, err := uuid.Parse(param); err == nil {
+		return id.String()
+	}
+	return param
+}
+
+// parseGroupID parses the option group ID in a request, where an empty ID
+// means no group. Invalid IDs are returned as uuid.Nil, which is never one of
+// a poll's groups, so validating the poll rejects them.
+func parseGroupID(s string) *uuid.UUID {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return &uuid.UUID{}
+	}
+	return &id
+}
+
+func (app *application) readInt64Param(r *http.Request, key string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, key), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid id")
+	}
+	return id, nil
+}
+
+// bearerValue returns the value of a Bearer Authorization header.
+func bearerValue(r *http.Request) (string, bool) {
+	authorizationHeader := r.Header.Get("Authorization")
+	if authorizationHeader == "" {
+		return "", false
+	}
+
+	headerParts := strings.Split(authorizationHeader, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return "", false
+	}
+
+	return headerParts[1], true
+}
+
+// voterIdentity identifies the voter making the request by their token, or by
+// their IP without one, like votes are counted.
+func voterIdentity(r *http.Request) string {
+	if token, ok := bearerValue(r); ok {
+		return data.TokenVoterIdentity(token)
+	}
+	ip, _ := clientIP(r)
+	return ip
+}
+
+// errNoClientIP is returned for requests that need the client's IP and don't
+// have one.
+var errNoClientIP = errors.New("X-Forwarded-For must start with the client's IP")
+
+// clientIP returns the IP of the client the request was forwarded for, the
+// first hop of X-Forwarded-For, in its canonical form. It reports false if
+// the header is missing or its first hop isn't an IP. The raw header is never
+// used in its place, so values that aren't IPs don't reach the database and
+// other forms of an IP don't count as another client.
+func clientIP(r *http.Request) (string, bool) {
+	hop, _, _ := strings.Cut(r.Header.Get("X-Forwarded-For"), ",")
+	ip := net.ParseIP(strings.TrimSpace(hop))
+	if ip == nil {
+		return "", false
+	}
+	return ip.String(), true
+}
+
+// kioskIdentity returns the identity of the kiosk token the request is made
+// with, if it is one for the poll in the URL.
+func (app *application) kioskIdentity(r *http.Request) (string, bool) {
+	token, ok := bearerValue(r)
+	if !ok {
+		return "", false
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		return "", false
+	}
+
+	principal, err := app.principalFromRequest(r)
+	if err != nil || !principal.CanOnPoll(auth.KioskVote, pollID, nil) {
+		return "", false
+	}
+	return data.TokenVoterIdentity(token), true
+}
+
+// readBearerToken extracts a well-formed poll token from the Authorization
+// header.
+func (app *application) readBearerToken(r *http.Request) (string, bool) {
+	token, ok := bearerValue(r)
+	if !ok {
+		return "", false
+	}
+
+	v := validator.New()
+
+	if data.ValidateTokenPlaintext(v, token); !v.Valid() {
+		return "", false
+	}
+
+	return token, true
+}
+
+// principalFromRequest identifies who the request is made by from its API
+// key, which acts as a member of its organization, or else from its bearer
+// token: a JWT, if a JWT key is configured, a poll token or the token of an
+// organization member. ErrRecordNotFound is returned for a missing or invalid
+// token.
+func (app *application) principalFromRequest(r *http.Request) (*auth.Principal, error) {
+	if key, ok := app.apiKeyFromContext(r.Context()); ok {
+		return &auth.Principal{Member: key.Member()}, nil
+	}
+
+	if len(app.config.jwt.key) > 0 {
+		if value, ok := bearerValue(r); ok && auth.IsJWT(value) {
+			// only results JWTs are issued, as JWTs can't be revoked
+			claims, err := auth.ParseJWT(app.config.jwt.key, value)
+			if err != nil || claims.Scope != data.ScopeResults {
+				return nil, data.ErrRecordNotFound
+			}
+			return &auth.Principal{PollID: claims.PollID(), Scope: claims.Scope}, nil
+		}
+	}
+
+	token, ok := app.readBearerToken(r)
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+
+	pollID, scope, err := app.models.Polls.CheckToken(token)
+	if err == nil {
+		return &auth.Principal{PollID: pollID, Scope: scope}, nil
+	}
+	if !errors.Is(err, data.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	member, err := app.models.Orgs.GetMemberForToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{Member: member}, nil
+}
+
+// canOnPoll reports whether the principal has the permission on the poll.
+// The poll is only looked up for organization members.
+func (app *application) canOnPoll(principal *auth.Principal, pollID uuid.UUID, perm auth.Permission) (bool, error) {
+	if principal.Member == nil {
+		return principal.CanOnPoll(perm, pollID, nil), nil
+	}
+
+	poll, err := app.models.Polls.Get(pollID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return principal.CanOnPoll(perm, pollID, poll.OrgID), nil
+}
+
+// can reports whether the request is authorized for the permission on the
+// poll. It is used on public endpoints where the token is optional.
+func (app *application) can(r *http.Request, pollID uuid.UUID, perm auth.Permission) bool {
+	principal, err := app.principalFromRequest(r)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.logError(err)
+		}
+		return false
+	}
+
+	allowed, err := app.canOnPoll(principal, pollID, perm)
+	if err != nil {
+		app.logError(err)
 	}
-	_, err := uuid.Parse(param)
+	return allowed
+}
+
+// pollIDByExternalID looks up the poll with the external ID among the
+// organization's polls, or among the polls the request's token is for if
+// orgID is nil, so that clients can't find each other's polls by guessing
+// their external IDs.
+func (app *application) pollIDByExternalID(r *http.Request, orgID uuid.UUID, externalID string) (uuid.UUID, error) {
+	if orgID != uuid.Nil {
+		return app.models.Polls.GetIDByExternalID(orgID, uuid.Nil, externalID)
+	}
+
+	principal, err := app.principalFromRequest(r)
 	if err != nil {
-		return "", errors.New("invalid id")
+		return uuid.Nil, err
+	}
+	if principal.PollID == uuid.Nil {
+		return uuid.Nil, data.ErrRecordNotFound
+	}
+	return app.models.Polls.GetIDByExternalID(uuid.Nil, principal.PollID, externalID)
+}
+
+// hidden reports whether the poll is hidden and the request may not preview
+// it. Drafts and polls rejected by moderation are hidden as if they didn't
+// exist. Private polls are hidden the same way from requests without a token
+// for them, and are also shown to voters with a vote token.
+func (app *application) hidden(r *http.Request, poll *data.Poll) bool {
+	switch {
+	case poll.Hidden():
+		return !app.can(r, poll.ID, auth.PreviewPoll)
+	case poll.Visibility == data.VisibilityPrivate:
+		return !app.can(r, poll.ID, auth.PreviewPoll) && !app.can(r, poll.ID, auth.Vote)
+	}
+	return false
+}
+
+// setQuotaHeaders reports a quota and how much of it is left after the
+// request.
+func setQuotaHeaders(headers http.Header, limit int, used int) {
+	headers.Set("X-Quota-Limit", strconv.Itoa(limit))
+	headers.Set("X-Quota-Remaining", strconv.Itoa(max(limit-used, 0)))
+}
+
+// externalURL returns an absolute URL for path, using the configured base URL
+// or, if none is set, the scheme and host the request was made to. Outside of
+// requests r is nil and an empty string is returned if no base URL is set.
+func (app *application) externalURL(r *http.Request, path string) string {
+	if app.config.baseURL != "" {
+		return strings.TrimSuffix(app.config.baseURL, "/") + path
+	}
+	if r == nil {
+		return ""
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, path)
+}
+
+// pollURL returns the public URL where people can view and vote on a poll.
+func (app *application) pollURL(r *http.Request, pollID uuid.UUID) string {
+	if app.config.pollURL != "" {
+		return fmt.Sprintf(app.config.pollURL, pollID)
+	}
+	return app.externalURL(r, "/v1/polls/"+pollID.String())
+}
+
+// listMetadata is the metadata of a list response: the page, links to the
+// pages next to it and the filters that were applied, so clients don't have
+// to reconstruct them.
+type listMetadata struct {
+	data.Metadata
+	Next    string            `json:"next,omitempty"`
+	Prev    string            `json:"prev,omitempty"`
+	Filters map[string]string `json:"filters"`
+}
+
+// paginate adds links to the next and previous pages of the request to the
+// metadata, keeping its other query parameters, and echoes back the filters
+// applied, including defaults.
+func (app *application) paginate(r *http.Request, metadata data.Metadata, filters map[string]string) listMetadata {
+	m := listMetadata{Metadata: metadata, Filters: filters}
+
+	pageURL := func(page int) string {
+		qs := r.URL.Query()
+		qs.Set("page", strconv.Itoa(page))
+		return app.externalURL(r, r.URL.Path+"?"+qs.Encode())
+	}
+	if metadata.CurrentPage < metadata.LastPage {
+		m.Next = pageURL(metadata.CurrentPage + 1)
 	}
-	return param, nil
+	if metadata.CurrentPage > metadata.FirstPage {
+		m.Prev = pageURL(metadata.CurrentPage - 1)
+	}
+
+	return m
 }
 
 type envelope map[string]any
 
 func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
-	j, err := json.Marshal(data)
+	j, err := responseProfile(w.Header()).marshal(data)
 	if err != nil {
 		return err
 	}
@@ -124,6 +430,50 @@ func (app *application) readString(qs url.Values, key string, defaultValue strin
 	return s
 }
 
+// maxIDs is how many IDs readIDs reads at most.
+const maxIDs = 50
+
+// readIDs reads a comma separated list of IDs from the query string, without
+// duplicates, in the order they were first given.
+func (app *application) readIDs(qs url.Values, key string, v *validator.Validator) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, s := range strings.Split(qs.Get(key), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := uuid.Parse(s)
+		if err != nil {
+			v.AddError(key, "must only contain valid IDs")
+			return nil
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	v.Check(len(ids) > 0, key, "must be provided")
+	v.Check(len(ids) <= maxIDs, key, "must not contain more than 50 IDs")
+
+	return ids
+}
+
+// readMetadata reads the metadata.{key} query parameters into a map of the
+// metadata polls are filtered by, or nil if there are none.
+func (app *application) readMetadata(qs url.Values) map[string]string {
+	var metadata map[string]string
+	for param, values := range qs {
+		key, ok := strings.CutPrefix(param, "metadata.")
+		if !ok {
+			continue
+		}
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata[key] = values[0]
+	}
+	return metadata
+}
+
 func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
 	s := qs.Get(key)
 
@@ -140,7 +490,95 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
-func (app *application) checkIP(pollID string, ip string) (bool, error) {
+// readTimeZone reads an IANA time zone name, e.g. Europe/Berlin, from the
+// query string. Times are shown in UTC if it isn't set.
+func (app *application) readTimeZone(qs url.Values, key string, v *validator.Validator) *time.Location {
+	s := qs.Get(key)
+
+	if s == "" {
+		return time.UTC
+	}
+
+	loc, err := data.LoadTimeZone(s)
+	if err != nil {
+		v.AddError(key, "must be a valid IANA time zone")
+		return time.UTC
+	}
+
+	return loc
+}
+
+// readSource reads where a request came from, e.g. "slack", from the source
+// query parameter, or utm_source as used by campaign links.
+func (app *application) readSource(qs url.Values, v *validator.Validator) string {
+	key := "source"
+	if !qs.Has(key) && qs.Has("utm_source") {
+		key = "utm_source"
+	}
+
+	source := data.NormalizeSource(qs.Get(key))
+	data.ValidateSource(v, key, source)
+
+	return source
+}
+
+func upperAll(values []string) []string {
+	upper := make([]string, 0, len(values))
+	for _, value := range values {
+		upper = append(upper, strings.ToUpper(strings.TrimSpace(value)))
+	}
+	return upper
+}
+
+// pollLanguage detects the language of the poll's question, description and
+// options, or returns "" if it can't tell.
+func pollLanguage(poll *data.Poll) string {
+	texts := []string{poll.Question, poll.Description}
+	for _, option := range poll.Options {
+		texts = append(texts, option.Value)
+	}
+	return lang.Detect(texts...)
+}
+
+func lowerAll(values []string) []string {
+	lower := make([]string, 0, len(values))
+	for _, value := range values {
+		lower = append(lower, strings.ToLower(strings.TrimSpace(value)))
+	}
+	return lower
+}
+
+// formatValidationErrors lists validation errors as text, one per line, for
+// integrations that reply with chat messages instead of JSON.
+func formatValidationErrors(errs map[string]string) string {
+	keys := make([]string, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("• %s %s", key, errs[key]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// background runs fn in a goroutine, logging any panic instead of crashing
+// the server.
+func (app *application) background(fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				app.logError(fmt.Errorf("%s", err))
+			}
+		}()
+		fn()
+	}()
+}
+
+func (app *application) checkIP(pollID uuid.UUID, ip string) (bool, error) {
 	ips, err := app.models.Polls.GetVotedIPs(pollID)
 	if err != nil {
 		return false, fmt.Errorf("checkIP %s", err)
@@ -16,12 +16,17 @@ import (
 )
 
 func Test_app_readIDParam(t *testing.T) {
+	id := uuid.New()
+
 	tests := []struct {
 		name        string
 		paramId     string
 		expectError bool
 	}{
-		{"valid id", uuid.NewString(), false},
+		{"valid id", id.String(), false},
+		{"upper case", strings.ToUpper(id.String()), false},
+		{"urn", "urn:uuid:" + id.String(), false},
+		{"braces", "{" + id.String() + "}", false},
 		{"invalid id", "", true},
 		{"invalid id", "test", true},
 	}
@@ -31,13 +36,45 @@ func Test_app_readIDParam(t *testing.T) {
 		chiCtx := chi.NewRouteContext()
 		chiCtx.URLParams.Add("id", test.paramId)
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
-		_, err := app.readIDParam(req, "id")
+		got, err := app.readIDParam(req, "id")
 		if !test.expectError && err != nil {
 			t.Errorf("%s: expected no err, but got one: %q", test.name, err)
 		}
 		if test.expectError && err == nil {
 			t.Errorf("%s: expected err, but didn't get one", test.name)
 		}
+		if !test.expectError && got != id {
+			t.Errorf("%s: expected %s, but got %s", test.name, id, got)
+		}
+	}
+}
+
+func Test_app_externalURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		proto    string
+		expected string
+	}{
+		{"from request", "", "", "http://example.com/p/abc"},
+		{"from request behind tls proxy", "", "https", "https://example.com/p/abc"},
+		{"configured base url", "https://polls.example.org/", "", "https://polls.example.org/p/abc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.baseURL = test.baseURL
+			defer func() { app.config.baseURL = "" }()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", test.proto)
+			}
+
+			if got := app.externalURL(req, "/p/abc"); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
 	}
 }
 
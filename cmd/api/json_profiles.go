@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// jsonProfile is how JSON responses are written. The zero value writes
+// snake_case field names, wrapped in the envelope.
+type jsonProfile struct {
+	camelCase bool
+	bare      bool
+}
+
+// fixedFormatPaths are the paths whose responses have a format set by
+// someone else, which profiles don't apply to.
+var fixedFormatPaths = []string{"/v1/oembed", "/v1/openapi.json", "/v1/integrations/"}
+
+// parseJSONProfile parses a comma separated list of profile options, which
+// change base: snake or camel for the casing of field names, and envelope or
+// bare for whether responses are wrapped in the envelope.
+func parseJSONProfile(s string, base jsonProfile) (jsonProfile, error) {
+	profile := base
+	for _, option := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(option)) {
+		case "":
+		case "snake":
+			profile.camelCase = false
+		case "camel":
+			profile.camelCase = true
+		case "envelope":
+			profile.bare = false
+		case "bare":
+			profile.bare = true
+		default:
+			return base, fmt.Errorf("profile option %q must be snake, camel, envelope or bare", strings.TrimSpace(option))
+		}
+	}
+	return profile, nil
+}
+
+func (p jsonProfile) String() string {
+	casing, wrapping := "snake", "envelope"
+	if p.camelCase {
+		casing = "camel"
+	}
+	if p.bare {
+		wrapping = "bare"
+	}
+	return casing + ", " + wrapping
+}
+
+// responseProfile returns the profile set as the response's Content-Profile
+// by negotiateProfile.
+func responseProfile(h http.Header) jsonProfile {
+	profile, _ := parseJSONProfile(h.Get("Content-Profile"), jsonProfile{})
+	return profile
+}
+
+// marshal encodes the data of a response in the profile. Bare responses are
+// the value of an envelope with a single key. Envelopes with more, like lists
+// with their metadata or errors with their code, are written as they are.
+func (p jsonProfile) marshal(data envelope) ([]byte, error) {
+	var v any = data
+	if p.bare && len(data) == 1 {
+		for _, value := range data {
+			v = value
+		}
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil || !p.camelCase {
+		return js, err
+	}
+	return camelCaseKeys(js)
+}
+
+// camelCaseKeys rewrites the keys of the objects in the JSON document in
+// camelCase, keeping their order.
+func camelCaseKeys(js []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	type container struct {
+		object bool
+		tokens int
+	}
+	var stack []container
+	var out bytes.Buffer
+
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteRune(rune(delim))
+			continue
+		}
+
+		key := false
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			key = top.object && top.tokens%2 == 0
+			switch {
+			case top.object && !key:
+				out.WriteByte(':')
+			case top.tokens > 0:
+				out.WriteByte(',')
+			}
+			top.tokens++
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			out.WriteRune(rune(t))
+			stack = append(stack, container{object: t == '{'})
+		case string:
+			if key {
+				t = camelCase(t)
+			}
+			s, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(s)
+		case json.Number:
+			out.WriteString(t.String())
+		case bool:
+			out.WriteString(strconv.FormatBool(t))
+		case nil:
+			out.WriteString("null")
+		}
+	}
+}
+
+// camelCase turns a snake_case name into camelCase.
+func camelCase(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+
+	var b strings.Builder
+	for i, part := range strings.Split(s, "_") {
+		if i == 0 || part == "" {
+			b.WriteString(part)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[size:])
+	}
+	return b.String()
+}
+
+// negotiateProfile picks how JSON responses are written: the server's
+// -json-profile, changed by the options of the request's Accept-Profile
+// header. Profiles other than the default are set as the response's
+// Content-Profile, which writeJSON writes responses in.
+func (app *application) negotiateProfile(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range fixedFormatPaths {
+			if strings.HasPrefix(r.URL.Path, path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.Header().Add("Vary", "Accept-Profile")
+
+		profile, err := parseJSONProfile(r.Header.Get("Accept-Profile"), app.config.json.profile)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+		if profile != (jsonProfile{}) {
+			w.Header().Set("Content-Profile", profile.String())
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
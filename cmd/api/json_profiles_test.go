@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_parseJSONProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		base     jsonProfile
+		expected jsonProfile
+		err      bool
+	}{
+		{"empty", "", jsonProfile{}, jsonProfile{}, false},
+		{"empty keeps base", "", jsonProfile{camelCase: true}, jsonProfile{camelCase: true}, false},
+		{"camel", "camel", jsonProfile{}, jsonProfile{camelCase: true}, false},
+		{"both", " Camel , bare", jsonProfile{}, jsonProfile{camelCase: true, bare: true}, false},
+		{"overrides base", "snake,envelope", jsonProfile{camelCase: true, bare: true}, jsonProfile{}, false},
+		{"unknown", "camel,kebab", jsonProfile{}, jsonProfile{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile, err := parseJSONProfile(test.s, test.base)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, but got %v", test.err, err)
+			}
+			if profile != test.expected {
+				t.Errorf("expected %+v, but got %+v", test.expected, profile)
+			}
+		})
+	}
+}
+
+func Test_jsonProfile_marshal(t *testing.T) {
+	data := envelope{"poll": map[string]any{
+		"expires_at": "",
+		"options":    []any{map[string]any{"vote_count": 1, "value": "a_b <b>"}},
+		"is_private": false,
+		"paused_at":  nil,
+	}}
+
+	tests := []struct {
+		name     string
+		profile  jsonProfile
+		expected string
+	}{
+		{"default", jsonProfile{}, `{"poll":{"expires_at":"","is_private":false,"options":[{"value":"a_b \u003cb\u003e","vote_count":1}],"paused_at":null}}`},
+		{"camel", jsonProfile{camelCase: true}, `{"poll":{"expiresAt":"","isPrivate":false,"options":[{"value":"a_b \u003cb\u003e","voteCount":1}],"pausedAt":null}}`},
+		{"bare", jsonProfile{bare: true}, `{"expires_at":"","is_private":false,"options":[{"value":"a_b \u003cb\u003e","vote_count":1}],"paused_at":null}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			js, err := test.profile.marshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(js) != test.expected {
+				t.Errorf("expected %s, but got %s", test.expected, js)
+			}
+		})
+	}
+
+	t.Run("bare keeps envelopes with more keys", func(t *testing.T) {
+		js, _ := jsonProfile{bare: true}.marshal(envelope{"polls": []any{}, "metadata": 1})
+		if string(js) != `{"metadata":1,"polls":[]}` {
+			t.Errorf("expected the envelope, but got %s", js)
+		}
+	})
+}
+
+func Test_app_negotiateProfile(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		acceptProfile   string
+		serverProfile   jsonProfile
+		expectedStatus  int
+		expectedProfile string
+		expectedBody    string
+	}{
+		{"default", "/v1/polls/" + data.ExamplePollIDValid.String(), "", jsonProfile{}, http.StatusOK, "", `{"poll":{"id":"` + data.ExamplePollIDValid.String() + `"`},
+		{"camel and bare", "/v1/polls/" + data.ExamplePollIDValid.String(), "camel, bare", jsonProfile{}, http.StatusOK, "camel, bare", `{"id":"` + data.ExamplePollIDValid.String() + `"`},
+		{"server profile", "/v1/polls/" + data.ExamplePollIDValid.String(), "", jsonProfile{camelCase: true}, http.StatusOK, "camel, envelope", `"resultsVisibility"`},
+		{"request overrides server", "/v1/polls/" + data.ExamplePollIDValid.String(), "snake", jsonProfile{camelCase: true}, http.StatusOK, "", `"results_visibility"`},
+		{"errors", "/v1/polls/invalid", "camel", jsonProfile{}, http.StatusBadRequest, "camel, envelope", `"code":"BAD_REQUEST"`},
+		{"unknown option", "/v1/polls/" + data.ExamplePollIDValid.String(), "kebab", jsonProfile{}, http.StatusBadRequest, "", `profile option \"kebab\" must be snake, camel, envelope or bare`},
+		{"fixed format", "/v1/openapi.json", "camel", jsonProfile{}, http.StatusOK, "", `"securitySchemes"`},
+	}
+
+	defer func() { app.config.json.profile = jsonProfile{} }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.json.profile = test.serverProfile
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			req.RemoteAddr = "9.9.9.9:1234"
+			if test.acceptProfile != "" {
+				req.Header.Set("Accept-Profile", test.acceptProfile)
+			}
+			rr := httptest.NewRecorder()
+			app.routes().ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, but got %d: %s", test.expectedStatus, rr.Code, rr.Body)
+			}
+			if profile := rr.Header().Get("Content-Profile"); profile != test.expectedProfile {
+				t.Errorf("expected Content-Profile %q, but got %q", test.expectedProfile, profile)
+			}
+			body := rr.Body.String()
+			if !strings.Contains(body, test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, body)
+			}
+		})
+	}
+}
@@ -1,16 +1,27 @@
 package main
 
 import (
-	"flag"
-	"fmt"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"sync"
 	"time"
 
+	"github.com/ivcp/polls/internal/analytics"
+	"github.com/ivcp/polls/internal/cdn"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/discord"
+	"github.com/ivcp/polls/internal/events"
+	"github.com/ivcp/polls/internal/geoip"
+	"github.com/ivcp/polls/internal/mailer"
+	"github.com/ivcp/polls/internal/moderation"
+	"github.com/ivcp/polls/internal/spam"
+	"github.com/ivcp/polls/internal/storage"
+	"github.com/ivcp/polls/internal/telegram"
+	"github.com/ivcp/polls/internal/text"
 	_ "github.com/jackc/pgx/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
@@ -18,16 +29,140 @@ import (
 var version = "1.0.0"
 
 type config struct {
-	port int
-	env  string
-	db   struct {
-		dsn string
+	port           int
+	env            string
+	baseURL        string
+	pollURL        string
+	closeInterval  time.Duration
+	digestInterval time.Duration
+	undoWindow     time.Duration
+	undoInterval   time.Duration
+	restoreWindow  time.Duration
+	exportInterval time.Duration
+	statsTTL       time.Duration
+	maxExpiresIn   time.Duration
+	voteConfirmTTL time.Duration
+	liveInterval   time.Duration
+	profile        string
+	loadTest       bool
+	db             struct {
+		dsn         string
+		autoMigrate bool
 	}
 	limiter struct {
 		rps     float64
 		burst   int
 		enabled bool
 	}
+	voteLimiter struct {
+		attempts      int
+		kioskAttempts int
+		window        time.Duration
+		enabled       bool
+	}
+	geoip struct {
+		db  string
+		api string
+	}
+	spam struct {
+		enabled   bool
+		threshold int
+	}
+	bots struct {
+		honeypot    string
+		minFillTime time.Duration
+		uaThreshold int
+		formKey     []byte
+	}
+	profanity struct {
+		wordlist string
+	}
+	moderation struct {
+		wordlist string
+		api      string
+		action   string
+	}
+	admin struct {
+		token string
+	}
+	json struct {
+		profile jsonProfile
+	}
+	reports struct {
+		threshold int
+	}
+	slack struct {
+		signingSecret string
+	}
+	discord struct {
+		publicKey ed25519.PublicKey
+	}
+	telegram struct {
+		botToken      string
+		webhookSecret string
+	}
+	twilio struct {
+		authToken string
+	}
+	events struct {
+		broker string
+		url    string
+		topic  string
+	}
+	analytics struct {
+		sink          string
+		target        string
+		region        string
+		endpoint      string
+		flushInterval time.Duration
+		accessKey     string
+		secretKey     string
+		credentials   string
+	}
+	cdn struct {
+		purger        string
+		service       string
+		token         string
+		purgeInterval time.Duration
+	}
+	storage struct {
+		endpoint  string
+		region    string
+		bucket    string
+		accessKey string
+		secretKey string
+	}
+	smtp struct {
+		host     string
+		port     int
+		username string
+		password string
+		sender   string
+	}
+	jwt struct {
+		key []byte
+		ttl time.Duration
+	}
+	receipts struct {
+		key []byte
+	}
+	// visitors holds the key IPs are hashed with to count views and reports.
+	visitors struct {
+		key []byte
+	}
+	quotas struct {
+		activePolls int
+		dailyPolls  int
+	}
+	tls struct {
+		certFile        string
+		keyFile         string
+		autocertDomains string
+		autocertCache   string
+		autocertEmail   string
+		redirectPort    int
+		hstsMaxAge      time.Duration
+	}
 }
 
 type application struct {
@@ -35,61 +170,100 @@ type application struct {
 	logger *log.Logger
 	models data.Models
 	mutex  sync.Mutex
+	geoip  geoip.Provider
+	spam   spam.Pipeline
+	text   text.Pipeline
+	// moderation checks user content, if set.
+	moderation moderation.Provider
+	// httpClient is used for requests to third party services.
+	httpClient *http.Client
+	telegram   *telegram.Client
+	events     events.Publisher
+	mailer     mailer.Mailer
+	// analytics receives vote and view events, if set.
+	analytics *analytics.Stream
+	// storage receives scheduled result exports, if set.
+	storage storage.Provider
+	// cdn purges changed polls from the CDN in front of the API, if set.
+	cdn *cdn.Queue
+	// webhookClient is used for requests to URLs given by users, and only
+	// connects to public addresses.
+	webhookClient *http.Client
+	// publicStats caches the stats of GET /v1/stats.
+	publicStats statsCache
 }
 
 func main() {
-	var cfg config
-	var app application
 	logger := log.New(os.Stdout, "", log.Ldate|log.Ltime)
-	app.logger = logger
 
-	port, err := strconv.Atoi(os.Getenv("SERVER_PORT"))
-	if err != nil {
+	if err := run(logger, os.Args[1:]); err != nil {
 		logger.Fatal(err)
 	}
-	cfg.port = port
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		logger.Fatal("dsn string not set")
+}
+
+// loadEnv reads the configuration shared by all commands from the
+// environment.
+func loadEnv(cfg *config) error {
+	cfg.db.dsn = os.Getenv("DB_DSN")
+	if cfg.db.dsn == "" {
+		return errors.New("dsn string not set")
 	}
-	cfg.db.dsn = dsn
-	env := os.Getenv("SERVER_ENV")
-	if env == "" {
-		logger.Fatal("dsn string not set")
+	cfg.env = os.Getenv("SERVER_ENV")
+	cfg.slack.signingSecret = os.Getenv("SLACK_SIGNING_SECRET")
+	cfg.smtp.password = os.Getenv("SMTP_PASSWORD")
+	cfg.analytics.accessKey = os.Getenv("ANALYTICS_ACCESS_KEY")
+	cfg.analytics.secretKey = os.Getenv("ANALYTICS_SECRET_KEY")
+	cfg.analytics.credentials = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	cfg.cdn.token = os.Getenv("CDN_API_TOKEN")
+	cfg.storage.accessKey = os.Getenv("STORAGE_ACCESS_KEY")
+	cfg.storage.secretKey = os.Getenv("STORAGE_SECRET_KEY")
+	cfg.telegram.botToken = os.Getenv("TELEGRAM_BOT_TOKEN")
+	cfg.telegram.webhookSecret = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	cfg.twilio.authToken = os.Getenv("TWILIO_AUTH_TOKEN")
+	if key := os.Getenv("JWT_KEY"); key != "" {
+		if len(key) < 32 {
+			return errors.New("JWT_KEY must be at least 32 bytes long")
+		}
+		cfg.jwt.key = []byte(key)
 	}
-	cfg.env = env
-
-	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests persecond")
-	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
-	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
-
-	flag.Parse()
-
-	app.config = cfg
-
-	db, err := app.connectToDB()
-	if err != nil {
-		logger.Fatal(err)
+	if key := os.Getenv("RECEIPT_KEY"); key != "" {
+		if len(key) < 32 {
+			return errors.New("RECEIPT_KEY must be at least 32 bytes long")
+		}
+		cfg.receipts.key = []byte(key)
 	}
-	defer db.Close()
-
-	if err = app.runMigrations(db, "../migrations"); err != nil {
-		logger.Fatal(err)
+	if key := os.Getenv("VISITOR_KEY"); key != "" {
+		if len(key) < 32 {
+			return errors.New("VISITOR_KEY must be at least 32 bytes long")
+		}
+		cfg.visitors.key = []byte(key)
+	} else {
+		// without a configured key visitors are only recognized until the
+		// server restarts
+		cfg.visitors.key = make([]byte, 32)
+		if _, err := rand.Read(cfg.visitors.key); err != nil {
+			return err
+		}
 	}
-
-	app.models = data.NewModels(db)
-
-	app.setMetrics(db)
-
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.port),
-		Handler:      app.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+	if key := os.Getenv("FORM_TOKEN_KEY"); key != "" {
+		if len(key) < 32 {
+			return errors.New("FORM_TOKEN_KEY must be at least 32 bytes long")
+		}
+		cfg.bots.formKey = []byte(key)
+	}
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		if len(token) < 32 {
+			return errors.New("ADMIN_TOKEN must be at least 32 bytes long")
+		}
+		cfg.admin.token = token
+	}
+	if key := os.Getenv("DISCORD_PUBLIC_KEY"); key != "" {
+		var err error
+		cfg.discord.publicKey, err = discord.ParsePublicKey(key)
+		if err != nil {
+			return err
+		}
 	}
 
-	logger.Printf("Starting %s server on %s", cfg.env, srv.Addr)
-	err = srv.ListenAndServe()
-	logger.Fatal(err)
+	return nil
 }
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"expvar"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/spam"
 	"github.com/ivcp/polls/internal/validator"
 	"golang.org/x/time/rate"
 )
@@ -39,9 +48,9 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if app.config.limiter.enabled {
 
-			ip := r.Header.Get("X-Forwarded-For")
-			if ip == "" {
-				app.serverErrorResponse(w, errors.New("no ip found"))
+			ip, ok := clientIP(r)
+			if !ok {
+				app.badRequestResponse(w, errNoClientIP)
 				return
 			}
 
@@ -70,49 +79,366 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 	})
 }
 
-func (app *application) requireToken(next http.Handler) http.Handler {
+// voteRateLimit limits vote attempts per poll and IP using a sliding window
+// log, independently of the global rate limiter.
+func (app *application) voteRateLimit(next http.Handler) http.Handler {
+	var mu sync.Mutex
+	attempts := make(map[string][]time.Time)
+
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+			mu.Lock()
+			for key, times := range attempts {
+				if len(times) == 0 || time.Since(times[len(times)-1]) > app.config.voteLimiter.window {
+					delete(attempts, key)
+				}
+			}
+			mu.Unlock()
+		}
+	}()
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authorizationHeader := r.Header.Get("Authorization")
-		if authorizationHeader == "" {
-			app.invalidTokenResponse(w)
+		if app.config.voteLimiter.enabled {
+			ip, ok := clientIP(r)
+			if !ok {
+				app.badRequestResponse(w, errNoClientIP)
+				return
+			}
+
+			key := pollIDParam(r) + "|" + ip
+			limit := app.config.voteLimiter.attempts
+			// a kiosk's voters all share its IP, so kiosks are limited by
+			// their token, with a limit of their own
+			if identity, ok := app.kioskIdentity(r); ok {
+				key = pollIDParam(r) + "|" + identity
+				limit = app.config.voteLimiter.kioskAttempts
+			}
+			now := time.Now()
+			windowStart := now.Add(-app.config.voteLimiter.window)
+
+			mu.Lock()
+			times := attempts[key]
+			i := 0
+			for i < len(times) && !times[i].After(windowStart) {
+				i++
+			}
+			times = times[i:]
+
+			if len(times) >= limit {
+				retryAfter := times[0].Add(app.config.voteLimiter.window).Sub(now)
+				attempts[key] = times
+				mu.Unlock()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				app.voteRateLimitExceededResponse(w)
+				return
+			}
+
+			attempts[key] = append(times, now)
+			mu.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requirePollPermission requires a token granting the permission on the poll
+// in the URL.
+func (app *application) requirePollPermission(perm auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := app.principalFromRequest(r)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					app.invalidTokenResponse(w)
+				default:
+					app.serverErrorResponse(w, err)
+				}
+				return
+			}
+
+			pollID, err := app.readIDParam(r, "pollID")
+			if err != nil {
+				app.badRequestResponse(w, err)
+				return
+			}
+
+			allowed, err := app.canOnPoll(principal, pollID, perm)
+			if err != nil {
+				app.serverErrorResponse(w, err)
+				return
+			}
+
+			if !allowed {
+				switch {
+				case !principal.Has(perm) && principal.Member != nil:
+					app.notPermittedResponse(w)
+				case !principal.Has(perm):
+					app.invalidTokenResponse(w)
+				default:
+					app.badRequestResponse(w, fmt.Errorf("token not valid for this poll"))
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ctxPollIDKey, pollID)
+			if principal.Member != nil {
+				ctx = context.WithValue(ctx, ctxMemberKey, principal.Member)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requireOrgPermission requires the token of a member of the organization in
+// the URL whose role grants the permission. Routes without an organization in
+// the URL act on the member's own organization.
+func (app *application) requireOrgPermission(perm auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := app.principalFromRequest(r)
+			if err != nil || principal.Member == nil {
+				switch {
+				case err == nil, errors.Is(err, data.ErrRecordNotFound):
+					app.invalidTokenResponse(w)
+				default:
+					app.serverErrorResponse(w, err)
+				}
+				return
+			}
+
+			orgID := principal.Member.OrgID
+			if chi.URLParam(r, "orgID") != "" {
+				orgID, err = app.readIDParam(r, "orgID")
+				if err != nil {
+					app.badRequestResponse(w, err)
+					return
+				}
+			}
+
+			if !principal.CanOnOrg(perm, orgID) {
+				switch {
+				case principal.Member.OrgID != orgID:
+					app.badRequestResponse(w, fmt.Errorf("token not valid for this organization"))
+				default:
+					app.notPermittedResponse(w)
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ctxMemberKey, principal.Member)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateAPIKey adds the API key in the X-API-Key header to the request's
+// context, for it to be authorized like a member of the key's organization and
+// counted against quotas by the key. Requests with an invalid or revoked key
+// are refused, rather than treated as anonymous.
+func (app *application) authenticateAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plaintext := r.Header.Get("X-API-Key")
+		if plaintext == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		headerParts := strings.Split(authorizationHeader, " ")
-		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-			app.invalidTokenResponse(w)
+		key, err := app.models.APIKeys.GetForKey(plaintext)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidTokenResponse(w)
+			default:
+				app.serverErrorResponse(w, err)
+			}
 			return
 		}
 
-		token := headerParts[1]
+		ctx := context.WithValue(r.Context(), ctxAPIKeyKey, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		v := validator.New()
+// requireAdmin requires the server's admin token, which is set with the
+// ADMIN_TOKEN environment variable. Without it the admin API doesn't exist.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.config.admin.token == "" {
+			app.notFoundResponse(w, r)
+			return
+		}
 
-		if data.ValidateTokenPlaintext(v, token); !v.Valid() {
+		if !app.isAdmin(r) {
 			app.invalidTokenResponse(w)
 			return
 		}
 
-		pollID, err := app.models.Polls.CheckToken(token)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAdmin reports whether the request is made with the server's admin token.
+func (app *application) isAdmin(r *http.Request) bool {
+	if app.config.admin.token == "" {
+		return false
+	}
+	token, ok := bearerValue(r)
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(app.config.admin.token)) == 1
+}
+
+// adminOr lets requests made with the server's admin token through, and
+// requires the others to pass the middleware. Admin requests have no member
+// in their context.
+func (app *application) adminOr(middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		required := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if app.isAdmin(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			required.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkTakedown responds to requests for a poll that was taken down with a
+// tombstone, whoever makes them. Routes without a poll pass through, as do
+// invalid IDs, which the handlers reject.
+func (app *application) checkTakedown(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pollID, err := app.readIDParam(r, "pollID")
 		if err != nil {
-			app.invalidTokenResponse(w)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		paramPollID, err := app.readIDParam(r, "pollID")
+		_, err = app.models.Takedowns.Get(pollID)
+		switch {
+		case err == nil:
+			app.pollRemovedResponse(w)
+		case errors.Is(err, data.ErrRecordNotFound):
+			next.ServeHTTP(w, r)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+	})
+}
+
+// checkBan refuses requests from a banned IP or made with a banned API key.
+func (app *application) checkBan(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var keyHash []byte
+		if token, ok := app.readBearerToken(r); ok {
+			keyHash = data.HashToken(token)
+		}
+
+		ip, _ := clientIP(r)
+		banned, err := app.models.Bans.Banned(ip, keyHash)
 		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+
+		if banned {
+			app.bannedResponse(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// formTokenTTL is how long after it is got a form token can be used.
+const formTokenTTL = 24 * time.Hour
+
+// screenBots refuses polls that look created by a script rather than with a
+// form: ones whose user agent scores at least -bot-ua-threshold, whose
+// honeypot field, hidden from people, is filled in, or whose form_token was
+// got less than -bot-min-fill-time ago. The honeypot and form_token fields
+// are removed from the body before the handler reads it. Each check is off
+// until it is configured, and requests made with the admin token skip them.
+func (app *application) screenBots(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bots := app.config.bots
+		if app.isAdmin(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if bots.uaThreshold > 0 && spam.UserAgentScore(r.UserAgent()) >= bots.uaThreshold {
+			app.suspectedBotResponse(w)
+			return
+		}
+
+		if bots.honeypot == "" && bots.minFillTime <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1_048_576))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				err = fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+			}
 			app.badRequestResponse(w, err)
 			return
 		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
 
-		if pollID != paramPollID {
-			app.badRequestResponse(w, fmt.Errorf("token not valid for this poll"))
+		// bodies that aren't a JSON object are left to the handler to reject
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), ctxPollIDKey, pollID)
+		if bots.honeypot != "" {
+			if value, ok := fields[bots.honeypot]; ok {
+				if v := string(bytes.TrimSpace(value)); v != `""` && v != "null" {
+					app.suspectedBotResponse(w)
+					return
+				}
+				delete(fields, bots.honeypot)
+			}
+		}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		if bots.minFillTime > 0 {
+			var token string
+			if value, ok := fields["form_token"]; ok {
+				json.Unmarshal(value, &token)
+			}
+			if token == "" {
+				app.failedValidationResponse(w, map[string]string{"form_token": "must be provided"})
+				return
+			}
+
+			shownAt, err := spam.FormShownAt(bots.formKey, token)
+			if err != nil || time.Since(shownAt) > formTokenTTL {
+				app.failedValidationResponse(w, map[string]string{
+					"form_token": "must be a valid form token, get one from /v1/polls/form-token",
+				})
+				return
+			}
+			if time.Since(shownAt) < bots.minFillTime {
+				app.suspectedBotResponse(w)
+				return
+			}
+			delete(fields, "form_token")
+		}
+
+		body, err = json.Marshal(fields)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -133,7 +459,7 @@ func (app *application) checkPollExpired(next http.Handler) http.Handler {
 			return
 		}
 
-		if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		if poll.Expired(time.Now()) {
 			app.pollExpiredResponse(w)
 			return
 		}
@@ -147,19 +473,13 @@ func (app *application) checkVoteStarted(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := app.pollIDfromContext(r.Context())
 
-		results, err := app.models.PollOptions.GetResults(id)
+		started, err := app.votingStarted(id)
 		if err != nil {
 			app.serverErrorResponse(w, err)
 			return
 		}
-		votingStarted := false
-		for _, option := range results {
-			if option.VoteCount > 0 {
-				votingStarted = true
-			}
-		}
 
-		if votingStarted {
+		if started {
 			app.cannotEditResponse(w)
 			return
 		}
@@ -168,6 +488,34 @@ func (app *application) checkVoteStarted(next http.Handler) http.Handler {
 	})
 }
 
+// votingStarted reports whether any votes have been cast on the poll, after
+// which it can no longer be edited.
+func (app *application) votingStarted(pollID uuid.UUID) (bool, error) {
+	results, err := app.models.PollOptions.GetResults(pollID)
+	if err != nil {
+		return false, err
+	}
+	for _, option := range results {
+		if option.VoteCount > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// tokenFromQuery lets the token be sent as the token query parameter, for
+// clients such as calendar apps that can only be given a URL. A token in the
+// Authorization header takes precedence.
+func (app *application) tokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *application) enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Access-Control-Request-Method")
@@ -188,6 +536,36 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 	})
 }
 
+// hsts tells browsers to only use HTTPS for the configured time, when the
+// server serves HTTPS itself.
+func (app *application) hsts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.config.tlsEnabled() && app.config.tls.hstsMaxAge > 0 {
+			w.Header().Set(
+				"Strict-Transport-Security",
+				fmt.Sprintf("max-age=%d; includeSubDomains", int(app.config.tls.hstsMaxAge.Seconds())),
+			)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// negotiateLanguage picks the language of error messages from the request's
+// Accept-Language header. It is set as the response's Content-Language, which
+// errorJSONResponse translates messages to.
+func (app *application) negotiateLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+
+		lang := validator.NegotiateLanguage(r.Header.Get("Accept-Language"))
+		if lang != validator.DefaultLanguage {
+			w.Header().Set("Content-Language", lang)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 type metricsResponseWriter struct {
 	wrapped       http.ResponseWriter
 	statusCode    int
@@ -218,15 +596,17 @@ func (mw *metricsResponseWriter) Unwrap() http.ResponseWriter {
 	return mw.wrapped
 }
 
-func (app *application) metrics(next http.Handler) http.Handler {
-	var (
-		totalRequestsReceived           = expvar.NewInt("total_requests_received")
-		totalResponsesSent              = expvar.NewInt("total_responses_sent")
-		totalProcessingTimeMicroseconds = expvar.NewInt("total_processing_time_μs")
-		totalResponsesSentByStatus      = expvar.NewMap("total_responses_sent_by_status")
-		averageProcessingTimePerRequest = expvar.NewInt("average_processing_time_per_request_μs")
-	)
+// the request metrics are published once per process, as expvar panics if a
+// name is published twice and the routes may be set up more than once
+var (
+	totalRequestsReceived           = expvar.NewInt("total_requests_received")
+	totalResponsesSent              = expvar.NewInt("total_responses_sent")
+	totalProcessingTimeMicroseconds = expvar.NewInt("total_processing_time_μs")
+	totalResponsesSentByStatus      = expvar.NewMap("total_responses_sent_by_status")
+	averageProcessingTimePerRequest = expvar.NewInt("average_processing_time_per_request_μs")
+)
 
+func (app *application) metrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		if r.URL.Path != "/" {
@@ -243,3 +623,26 @@ func (app *application) metrics(next http.Handler) http.Handler {
 		}
 	})
 }
+
+// purgeOnWrite purges a poll's cached responses from the CDN after a request
+// changed it, i.e. a successful request other than a read to a route with a
+// poll ID.
+func (app *application) purgeOnWrite(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mw := &metricsResponseWriter{wrapped: w}
+		next.ServeHTTP(mw, r)
+
+		// the route context is filled in while routing, so the poll ID is
+		// only known once the request was handled
+		pollID, err := app.readIDParam(r, "pollID")
+		if err == nil && mw.statusCode >= 200 && mw.statusCode < 300 {
+			app.purgePoll(pollID)
+		}
+	})
+}
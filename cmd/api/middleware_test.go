@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/spam"
 )
 
 func Test_app_rateLimit(t *testing.T) {
@@ -21,7 +26,7 @@ func Test_app_rateLimit(t *testing.T) {
 	handlerToTest := app.rateLimit(nextHandler)
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
 
-	req.Header.Set("X-Forwarded-For", "0.0.0.0:0000")
+	req.Header.Set("X-Forwarded-For", "203.0.113.1")
 	rr := httptest.NewRecorder()
 	for i := 0; i < 6; i++ {
 		handlerToTest.ServeHTTP(rr, req)
@@ -41,9 +46,123 @@ func Test_app_rateLimit(t *testing.T) {
 			t.Errorf("expected status code %d, but got %d", http.StatusOK, rr.Code)
 		}
 	}
+
+	// requests are limited by the client's IP, not by the raw header
+	app.config.limiter.enabled = true
+	defer func() { app.config.limiter.enabled = false }()
+	req.Header.Set("X-Forwarded-For", "0.0.0.0:0000")
+	rr = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, but got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func Test_app_voteRateLimit(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	app.config.voteLimiter.attempts = 3
+	app.config.voteLimiter.window = time.Minute
+	app.config.voteLimiter.enabled = true
+	defer func() { app.config.voteLimiter.enabled = false }()
+
+	handlerToTest := app.voteRateLimit(nextHandler)
+
+	newRequest := func(pollID, ip string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", pollID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("X-Forwarded-For", ip)
+		return req
+	}
+
+	for i := 0; i < 5; i++ {
+		rr := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(rr, newRequest(data.ExamplePollIDValid.String(), "0.0.0.0"))
+		if i < 3 && rr.Code != http.StatusOK {
+			t.Errorf("attempt %d: expected status code %d, but got %d", i+1, http.StatusOK, rr.Code)
+		}
+		if i >= 3 {
+			if rr.Code != http.StatusTooManyRequests {
+				t.Errorf("attempt %d: expected status code %d, but got %d", i+1, http.StatusTooManyRequests, rr.Code)
+			}
+			if rr.Header().Get("Retry-After") == "" {
+				t.Errorf("attempt %d: expected Retry-After header to be set", i+1)
+			}
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, newRequest(strings.ToUpper(data.ExamplePollIDValid.String()), "0.0.0.0"))
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("upper case poll id: expected status code %d, but got %d", http.StatusTooManyRequests, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, newRequest(data.ExamplePollIDAfterVote.String(), "0.0.0.0"))
+	if rr.Code != http.StatusOK {
+		t.Errorf("other poll: expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, newRequest(data.ExamplePollIDValid.String(), "0.0.0.1"))
+	if rr.Code != http.StatusOK {
+		t.Errorf("other ip: expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func Test_app_voteRateLimit_kiosk(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	app.config.voteLimiter.attempts = 1
+	app.config.voteLimiter.kioskAttempts = 3
+	app.config.voteLimiter.window = time.Minute
+	app.config.voteLimiter.enabled = true
+	defer func() { app.config.voteLimiter.enabled = false }()
+
+	handlerToTest := app.voteRateLimit(nextHandler)
+
+	newRequest := func(token string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("X-Forwarded-For", "0.0.0.0")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return req
+	}
+
+	// the kiosk's limit is its own, so voters sharing its IP aren't limited
+	// by its votes
+	for i := 0; i < 4; i++ {
+		rr := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(rr, newRequest(data.ExampleTokenKiosk))
+		if i < 3 && rr.Code != http.StatusOK {
+			t.Errorf("attempt %d: expected status code %d, but got %d", i+1, http.StatusOK, rr.Code)
+		}
+		if i >= 3 && rr.Code != http.StatusTooManyRequests {
+			t.Errorf("attempt %d: expected status code %d, but got %d", i+1, http.StatusTooManyRequests, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, newRequest(""))
+	if rr.Code != http.StatusOK {
+		t.Errorf("same ip without token: expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	// vote tokens are limited by IP like votes without a token
+	rr = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, newRequest(data.ExampleTokenVote))
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("vote token: expected status code %d, but got %d", http.StatusTooManyRequests, rr.Code)
+	}
 }
 
-func Test_app_requireToken(t *testing.T) {
+func Test_app_requirePollPermission(t *testing.T) {
 	tests := []struct {
 		name           string
 		authHeader     string
@@ -73,16 +192,21 @@ func Test_app_requireToken(t *testing.T) {
 			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "vote token",
+			authHeader:     "Bearer " + data.ExampleTokenVote,
+			expectedStatus: http.StatusUnauthorized,
+		},
 	}
 
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
-	handlerToTest := app.requireToken(nextHandler)
+	handlerToTest := app.requirePollPermission(auth.EditPoll)(nextHandler)
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodGet, "/", nil)
 			chiCtx := chi.NewRouteContext()
-			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid)
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid.String())
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
 			rr := httptest.NewRecorder()
 			if test.authHeader != "" {
@@ -100,15 +224,375 @@ func Test_app_requireToken(t *testing.T) {
 	}
 }
 
-func Test_app_checkPollExpired(t *testing.T) {
+func Test_app_requirePollPermission_orgMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		token          string
+		expectedStatus int
+	}{
+		{"editor", data.ExamplePollIDOrg, data.ExampleTokenOrgEditor, http.StatusOK},
+		{"admin", data.ExamplePollIDOrg, data.ExampleTokenOrgAdmin, http.StatusOK},
+		{"viewer", data.ExamplePollIDOrg, data.ExampleTokenOrgViewer, http.StatusForbidden},
+		{"poll outside the organization", data.ExamplePollIDValid, data.ExampleTokenOrgEditor, http.StatusBadRequest},
+		{"poll token for another poll", data.ExamplePollIDOrg, "UBQ2Z7CLB2SJQBNTUCH4IMRI7A", http.StatusBadRequest},
+	}
+
+	var member *data.Member
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		member, _ = app.memberFromContext(r.Context())
+	})
+	handlerToTest := app.requirePollPermission(auth.EditPoll)(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			member = nil
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if rr.Code == http.StatusOK && member == nil {
+				t.Errorf("expected member in context")
+			}
+		})
+	}
+}
+
+func Test_app_requireOrgPermission(t *testing.T) {
+	tests := []struct {
+		name           string
+		perm           auth.Permission
+		orgID          string
+		authHeader     string
+		expectedStatus int
+	}{
+		{
+			name:           "admin",
+			perm:           auth.ManageMembers,
+			orgID:          data.ExampleOrgID.String(),
+			authHeader:     "Bearer " + data.ExampleTokenOrgAdmin,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "editor viewing polls",
+			perm:           auth.ViewPolls,
+			orgID:          data.ExampleOrgID.String(),
+			authHeader:     "Bearer " + data.ExampleTokenOrgEditor,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "viewer creating a poll",
+			perm:           auth.CreatePoll,
+			orgID:          data.ExampleOrgID.String(),
+			authHeader:     "Bearer " + data.ExampleTokenOrgViewer,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "another organization",
+			perm:           auth.ViewPolls,
+			orgID:          data.ExamplePollIDValid.String(),
+			authHeader:     "Bearer " + data.ExampleTokenOrgAdmin,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "member's own organization",
+			perm:           auth.ViewPolls,
+			authHeader:     "Bearer " + data.ExampleTokenOrgViewer,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "poll token",
+			perm:           auth.ViewPolls,
+			orgID:          data.ExampleOrgID.String(),
+			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "no auth header set",
+			perm:           auth.ViewPolls,
+			orgID:          data.ExampleOrgID.String(),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", test.orgID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			app.requireOrgPermission(test.perm)(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_requireAdmin(t *testing.T) {
+	adminToken := "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name           string
+		configured     bool
+		authHeader     string
+		expectedStatus int
+	}{
+		{
+			name:           "admin token",
+			configured:     true,
+			authHeader:     "Bearer " + adminToken,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong token",
+			configured:     true,
+			authHeader:     "Bearer " + data.ExampleTokenOrgAdmin,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "no auth header set",
+			configured:     true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "admin api disabled",
+			authHeader:     "Bearer " + adminToken,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	defer func() { app.config.admin.token = "" }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.admin.token = ""
+			if test.configured {
+				app.config.admin.token = adminToken
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			app.requireAdmin(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_authenticateAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		apiKey         string
+		perm           auth.Permission
+		expectedStatus int
+		expectedActor  string
+	}{
+		{"no key", "", auth.CreatePoll, http.StatusUnauthorized, ""},
+		{"editor key", data.ExampleAPIKey, auth.CreatePoll, http.StatusOK, "api_key:APIKEYAA"},
+		{"editor key managing members", data.ExampleAPIKey, auth.ManageMembers, http.StatusForbidden, ""},
+		{"revoked key", data.ExampleAPIKeyRevoked, auth.CreatePoll, http.StatusUnauthorized, ""},
+		{"malformed key", "polls_", auth.CreatePoll, http.StatusUnauthorized, ""},
+	}
+
+	var actor string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = app.actor(r)
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actor = ""
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", data.ExampleOrgID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			app.authenticateAPIKey(app.requireOrgPermission(test.perm)(nextHandler)).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if actor != test.expectedActor {
+				t.Errorf("expected actor %q, but got %q", test.expectedActor, actor)
+			}
+		})
+	}
+}
+
+func Test_app_checkTakedown(t *testing.T) {
 	tests := []struct {
 		name           string
 		pollID         string
 		expectedStatus int
+	}{
+		{"poll", data.ExamplePollIDValid.String(), http.StatusOK},
+		{"removed poll", data.ExamplePollIDRemoved.String(), http.StatusGone},
+		{"invalid id", "invalid", http.StatusOK},
+		{"no poll in route", "", http.StatusOK},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			if test.pollID != "" {
+				chiCtx.URLParams.Add("pollID", test.pollID)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			app.checkTakedown(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if test.expectedStatus == http.StatusGone && !strings.Contains(rr.Body.String(), `"code":"POLL_REMOVED"`) {
+				t.Errorf("expected POLL_REMOVED, but got %q", rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_checkBan(t *testing.T) {
+	tests := []struct {
+		name           string
+		ip             string
+		authHeader     string
+		expectedStatus int
+	}{
+		{"ip", "0.0.0.0", "", http.StatusOK},
+		{"banned ip", data.ExampleBannedIP, "", http.StatusForbidden},
+		{"api key", "0.0.0.0", "Bearer " + data.ExampleTokenOrgAdmin, http.StatusOK},
+		{"banned api key", "0.0.0.0", "Bearer " + data.ExampleTokenBanned, http.StatusForbidden},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("X-Forwarded-For", test.ip)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			app.checkBan(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if test.expectedStatus == http.StatusForbidden && !strings.Contains(rr.Body.String(), `"code":"BANNED"`) {
+				t.Errorf("expected BANNED, but got %q", rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_screenBots(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	adminToken := "fedcba9876543210fedcba9876543210"
+	browser := "Mozilla/5.0 (X11; Linux x86_64) Firefox/123.0"
+	filledIn := spam.FormToken(key, time.Now().Add(-time.Minute))
+	tooFast := spam.FormToken(key, time.Now())
+	stale := spam.FormToken(key, time.Now().Add(-2*formTokenTTL))
+
+	tests := []struct {
+		name           string
+		userAgent      string
+		authHeader     string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedBody   string
+	}{
+		{"form filled in", browser, "", `{"question":"Q?","form_token":"` + filledIn + `","website":""}`, http.StatusOK, "", `{"question":"Q?"}`},
+		{"honeypot filled in", browser, "", `{"question":"Q?","form_token":"` + filledIn + `","website":"spam.example"}`, http.StatusForbidden, "SUSPECTED_BOT", ""},
+		{"filled in too fast", browser, "", `{"question":"Q?","form_token":"` + tooFast + `"}`, http.StatusForbidden, "SUSPECTED_BOT", ""},
+		{"no form token", browser, "", `{"question":"Q?"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED", ""},
+		{"stale form token", browser, "", `{"question":"Q?","form_token":"` + stale + `"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED", ""},
+		{"invalid form token", browser, "", `{"question":"Q?","form_token":"invalid"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED", ""},
+		{"script user agent", "curl/8.4.0", "", `{"question":"Q?","form_token":"` + filledIn + `"}`, http.StatusForbidden, "SUSPECTED_BOT", ""},
+		{"admin token", "curl/8.4.0", "Bearer " + adminToken, `{"question":"Q?"}`, http.StatusOK, "", `{"question":"Q?"}`},
+		{"not a JSON object", browser, "", `[]`, http.StatusOK, "", `[]`},
+	}
+
+	app.config.bots.honeypot = "website"
+	app.config.bots.minFillTime = 30 * time.Second
+	app.config.bots.uaThreshold = 40
+	app.config.bots.formKey = key
+	app.config.admin.token = adminToken
+	defer func() {
+		app.config.admin.token = ""
+		app.config.bots.honeypot = ""
+		app.config.bots.minFillTime = 0
+		app.config.bots.uaThreshold = 0
+		app.config.bots.formKey = nil
+	}()
+
+	var received string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received = ""
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			req.Header.Set("User-Agent", test.userAgent)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			app.screenBots(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if test.expectedCode != "" && !strings.Contains(rr.Body.String(), `"code":"`+test.expectedCode+`"`) {
+				t.Errorf("expected %s, but got %q", test.expectedCode, rr.Body)
+			}
+			if received != test.expectedBody {
+				t.Errorf("expected the handler to read %q, but got %q", test.expectedBody, received)
+			}
+		})
+	}
+}
+
+func Test_app_checkPollExpired(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         uuid.UUID
+		expectedStatus int
 	}{
 		{"expired poll", data.ExamplePollIDExpiredPoll, http.StatusForbidden},
 		{"valid poll", data.ExamplePollIDValid, http.StatusOK},
-		{"unexisting poll", uuid.NewString(), http.StatusNotFound},
+		{"unexisting poll", uuid.New(), http.StatusNotFound},
 		{"expired_at not set", data.ExamplePollIDExpiredNotSet, http.StatusOK},
 	}
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -131,11 +615,11 @@ func Test_app_checkPollExpired(t *testing.T) {
 func Test_app_checkVoteStarted(t *testing.T) {
 	tests := []struct {
 		name           string
-		pollID         string
+		pollID         uuid.UUID
 		expectedStatus int
 	}{
 		{"voting started", data.ExamplePollIDVotingStarted, http.StatusForbidden},
-		{"no votes yet", uuid.NewString(), http.StatusOK},
+		{"no votes yet", uuid.New(), http.StatusOK},
 	}
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 	handlerToTest := app.checkVoteStarted(nextHandler)
@@ -200,3 +684,75 @@ func Test_app_enableCORS(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_hsts(t *testing.T) {
+	defer func() {
+		app.config.tls.certFile = ""
+		app.config.tls.hstsMaxAge = 0
+	}()
+
+	tests := []struct {
+		name     string
+		certFile string
+		maxAge   time.Duration
+		expected string
+	}{
+		{"tls disabled", "", time.Hour, ""},
+		{"tls enabled", "cert.pem", time.Hour, "max-age=3600; includeSubDomains"},
+		{"hsts disabled", "cert.pem", 0, ""},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.tls.certFile = test.certFile
+			app.config.tls.hstsMaxAge = test.maxAge
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			rr := httptest.NewRecorder()
+			app.hsts(nextHandler).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Strict-Transport-Security"); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func Test_app_negotiateLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"default", "", http.StatusNotFound, "the requested resource could not be found"},
+		{"german", "de-DE,de;q=0.9,en;q=0.8", http.StatusNotFound, "die angeforderte Ressource wurde nicht gefunden"},
+		{"french", "fr", http.StatusNotFound, "la ressource demandée est introuvable"},
+		{"unsupported", "es", http.StatusNotFound, "the requested resource could not be found"},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.notFoundResponse(w, r)
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", test.acceptLanguage)
+			rr := httptest.NewRecorder()
+			app.negotiateLanguage(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if !strings.Contains(rr.Header().Get("Vary"), "Accept-Language") {
+				t.Error("expected responses to vary by Accept-Language")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/moderation"
+	"github.com/ivcp/polls/internal/text"
+)
+
+var errContentRejected = errors.New("content rejected by moderation")
+
+// moderate checks the texts with the moderation provider and applies the
+// configured action to flagged ones. Masking rewrites the texts in place,
+// rejecting returns errContentRejected and flagging returns a flagged result
+// with the terms the poll is to be reviewed for. Content the provider fails to
+// check is flagged too, so it isn't published unreviewed.
+func (app *application) moderate(texts ...*string) (moderation.Result, error) {
+	var result moderation.Result
+	if app.moderation == nil {
+		return result, nil
+	}
+
+	for _, s := range texts {
+		if *s == "" {
+			continue
+		}
+
+		checked, err := app.moderation.Check(*s)
+		if err != nil {
+			app.logError(err)
+			result.Flagged = true
+			continue
+		}
+		if !checked.Flagged {
+			continue
+		}
+
+		action := moderation.Action(app.config.moderation.action)
+		switch {
+		case action == moderation.Reject:
+			return result, errContentRejected
+		case action == moderation.Mask && len(checked.Terms) > 0:
+			*s = text.Profanity(checked.Terms)(*s)
+		default:
+			// content to mask is reviewed instead if the provider didn't
+			// tell which terms to mask
+			result.Flagged = true
+			result.Terms = append(result.Terms, checked.Terms...)
+		}
+	}
+
+	return result, nil
+}
+
+// flagPoll holds a poll whose edit moderation flagged for review and records
+// why in its activity feed.
+func (app *application) flagPoll(poll *data.Poll, terms []string) error {
+	if err := app.models.Polls.Flag(poll.ID, terms); err != nil {
+		return err
+	}
+	poll.ModerationStatus = data.ModerationFlagged
+	poll.ModerationTerms = terms
+
+	app.recordActivity(poll.ID, data.ActorModerator, data.ActionPollFlagged, map[string]any{
+		"terms": terms,
+	})
+	return nil
+}
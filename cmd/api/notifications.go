@@ -0,0 +1,62 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+type resultSummary struct {
+	Value     string
+	VoteCount int
+	Percent   float64
+}
+
+type pollClosedEmail struct {
+	Question   string
+	ClosedAt   time.Time
+	TotalVotes int
+	Results    []resultSummary
+	PollURL    string
+}
+
+func summarizeResults(results []*data.PollOption) ([]resultSummary, int) {
+	total := 0
+	for _, opt := range results {
+		total += opt.VoteCount
+	}
+
+	summary := make([]resultSummary, 0, len(results))
+	for _, opt := range results {
+		s := resultSummary{Value: opt.Value, VoteCount: opt.VoteCount}
+		if total > 0 {
+			s.Percent = float64(opt.VoteCount) / float64(total) * 100
+		}
+		summary = append(summary, s)
+	}
+
+	return summary, total
+}
+
+// sendPollClosedEmail sends the final results to the address registered for
+// the poll. It does nothing when no mailer is configured.
+func (app *application) sendPollClosedEmail(poll *data.Poll, results []*data.PollOption) {
+	if app.mailer == nil {
+		return
+	}
+
+	summary, total := summarizeResults(results)
+	email := pollClosedEmail{
+		Question:   poll.Question,
+		ClosedAt:   poll.ExpiresAt.Time,
+		TotalVotes: total,
+		Results:    summary,
+		PollURL:    app.pollURL(nil, poll.ID),
+	}
+
+	app.background(func() {
+		if err := app.mailer.Send(poll.NotifyEmail, "poll_closed.tmpl", email); err != nil {
+			app.logError(err)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/schema"
+)
+
+// requestBodies are the inputs of the routes taking a JSON body, by method
+// and route pattern. Their validate tags are checked by the handlers and
+// described in the OpenAPI spec.
+var requestBodies = map[string]any{
+	"POST /v1/polls":                                   createPollInput{},
+	"POST /v1/polls/import":                            importPollInput{},
+	"POST /v1/orgs/{orgID}/polls":                      createPollInput{},
+	"PATCH /v1/polls/{pollID}":                         updatePollInput{},
+	"POST /v1/polls/{pollID}/options":                  optionInput{},
+	"PATCH /v1/polls/{pollID}/options":                 optionPositionsInput{},
+	"PATCH /v1/polls/{pollID}/options/{optionID}":      optionInput{},
+	"POST /v1/polls/{pollID}/options/{optionID}":       voteInput{},
+	"POST /v1/polls/{pollID}/options/{optionID}/merge": mergeOptionsInput{},
+	"POST /v1/polls/{pollID}/jwt":                      issueJWTInput{},
+	"POST /v1/polls/{pollID}/report":                   reportPollInput{},
+	"POST /v1/polls/{pollID}/pause":                    pausePollInput{},
+	"POST /v1/polls/{pollID}/transfer":                 transferPollInput{},
+	"POST /v1/polls/{pollID}/tokens":                   createPollTokenInput{},
+	"PUT /v1/polls/{pollID}/notifications":             pollNotificationsInput{},
+	"PUT /v1/polls/{pollID}/digest":                    pollDigestInput{},
+	"PUT /v1/polls/{pollID}/results/export":            resultExportInput{},
+	"PATCH /v1/polls/{pollID}/votes/{voteID}":          moderateVoteInput{},
+	"POST /v1/orgs":                                    createOrgInput{},
+	"POST /v1/orgs/{orgID}/members":                    memberInput{},
+	"PATCH /v1/orgs/{orgID}/members/{memberID}":        memberRoleInput{},
+	"POST /v1/orgs/{orgID}/api-keys":                   apiKeyInput{},
+	"POST /v1/admin/bans":                              createBanInput{},
+	"POST /v1/admin/exemptions":                        createExemptionInput{},
+	"PATCH /v1/admin/moderation/{pollID}":              moderatePollInput{},
+	"PATCH /v1/admin/reports/{pollID}":                 moderatePollInput{},
+	"PUT /v1/admin/takedowns/{pollID}":                 takeDownPollInput{},
+}
+
+var pathParamRX = regexp.MustCompile(`{([^}]+)}`)
+
+// openAPISpec describes the /v1 routes of the router as an OpenAPI 3.1
+// document. Routes with a request body in requestBodies document it, and
+// that it may fail validation.
+func openAPISpec(routes chi.Routes) (envelope, error) {
+	paths := map[string]map[string]any{}
+
+	err := chi.Walk(routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if !strings.HasPrefix(route, "/v1/") || strings.Contains(route, "*") {
+			return nil
+		}
+
+		operation := map[string]any{
+			"responses": map[string]any{
+				"default": map[string]any{"$ref": "#/components/responses/Error"},
+			},
+		}
+
+		var params []map[string]any
+		for _, match := range pathParamRX.FindAllStringSubmatch(route, -1) {
+			params = append(params, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+
+		if input, ok := requestBodies[method+" "+route]; ok {
+			operation["requestBody"] = map[string]any{
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schema.For(input)},
+				},
+			}
+			operation["responses"].(map[string]any)["422"] = map[string]any{
+				"$ref": "#/components/responses/ValidationFailed",
+			}
+		}
+
+		if paths[route] == nil {
+			paths[route] = map[string]any{}
+		}
+		paths[route][strings.ToLower(method)] = operation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	errorSchema := func(detail map[string]any) map[string]any {
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"code":  map[string]any{"type": "string"},
+				"error": detail,
+			},
+		}
+	}
+
+	return envelope{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Polls API",
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"token": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "error",
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": errorSchema(map[string]any{"type": "string"})},
+					},
+				},
+				"ValidationFailed": map[string]any{
+					"description": "the request body failed validation, with a message per field",
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": errorSchema(map[string]any{
+							"type":                 "object",
+							"additionalProperties": map[string]any{"type": "string"},
+						})},
+					},
+				},
+			},
+		},
+		// requests may be made without a token
+		"security": []map[string]any{{}, {"token": []string{}}},
+	}, nil
+}
+
+// openAPIHandler serves the OpenAPI spec of the routes, which is generated
+// on the first request.
+func (app *application) openAPIHandler(routes chi.Routes) http.HandlerFunc {
+	spec := sync.OnceValues(func() (envelope, error) {
+		return openAPISpec(routes)
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := spec()
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+
+		err = app.writeCachedJSON(w, r, doc, cachePolicy{maxAge: cacheTTLFeed})
+		if err != nil {
+			app.serverErrorResponse(w, err)
+		}
+	}
+}
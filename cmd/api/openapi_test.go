@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Test_openAPISpec(t *testing.T) {
+	spec, err := openAPISpec(app.routes().(chi.Routes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := spec["paths"].(map[string]map[string]any)
+
+	for key := range requestBodies {
+		method, route, _ := strings.Cut(key, " ")
+		operation, ok := paths[route][strings.ToLower(method)].(map[string]any)
+		if !ok {
+			t.Errorf("request body of %q documented for a route that doesn't exist", key)
+			continue
+		}
+		if operation["requestBody"] == nil {
+			t.Errorf("expected %q to have a request body", key)
+		}
+	}
+
+	if _, ok := paths["/v1/polls/{pollID}/results"]["get"]; !ok {
+		t.Error("expected routes without a body to be documented")
+	}
+	if _, ok := paths["/v1/integrations/slack/commands"]; !ok {
+		t.Error("expected integration routes to be documented")
+	}
+}
+
+func Test_app_openAPIHandler(t *testing.T) {
+	srv := httptest.NewServer(app.routes())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, res.StatusCode)
+	}
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Required   []string `json:"required"`
+						Properties map[string]struct {
+							MaxLength int      `json:"maxLength"`
+							Enum      []string `json:"enum"`
+						} `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("expected an OpenAPI 3.1 document, but got %q", spec.OpenAPI)
+	}
+
+	body := spec.Paths["/v1/polls"]["post"].RequestBody.Content["application/json"].Schema
+	if strings.Join(body.Required, ",") != "question,options" {
+		t.Errorf("expected question and options to be required, but got %v", body.Required)
+	}
+	if body.Properties["question"].MaxLength != 500 {
+		t.Errorf("expected the question's max length, but got %d", body.Properties["question"].MaxLength)
+	}
+	if len(body.Properties["tie_break"].Enum) != 3 {
+		t.Errorf("expected the tie_break values, but got %v", body.Properties["tie_break"].Enum)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/results"
+)
+
+// exportResults periodically exports the results of polls whose export is
+// due to the storage bucket.
+func (app *application) exportResults(interval time.Duration) {
+	if app.storage == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		exports, err := app.models.Exports.ClaimDue()
+		if err != nil {
+			app.logError(err)
+			continue
+		}
+
+		for _, export := range exports {
+			export := export
+			app.background(func() {
+				if err := app.exportPollResults(export, *export.LastExportedAt); err != nil {
+					app.logError(err)
+				}
+			})
+		}
+	}
+}
+
+// resultsFile is a poll's results as exported to storage.
+type resultsFile struct {
+	PollID     uuid.UUID           `json:"poll_id"`
+	Question   string              `json:"question"`
+	ExportedAt time.Time           `json:"exported_at"`
+	TotalVotes int                 `json:"total_votes"`
+	Options    []resultsFileOption `json:"options"`
+}
+
+type resultsFileOption struct {
+	ID       uuid.UUID `json:"id"`
+	Value    string    `json:"value"`
+	Position int       `json:"position"`
+	Votes    int       `json:"votes"`
+	Percent  float64   `json:"percent"`
+	Winner   bool      `json:"winner"`
+}
+
+// exportPollResults stores the poll's results as of the export time under
+// polls/{pollID}/results-{time}.{format}, so earlier exports are kept.
+// Results held back by the poll's results threshold aren't exported.
+func (app *application) exportPollResults(export *data.ResultExport, exportedAt time.Time) error {
+	poll, err := app.models.Polls.Get(export.PollID)
+	if err != nil {
+		return err
+	}
+
+	options, err := app.models.PollOptions.GetResults(poll.ID)
+	if err != nil {
+		return err
+	}
+
+	summary := results.Calculate(options, poll.TieBreak, results.Seed(poll.ID))
+	if summary.TotalVotes < poll.ResultsThreshold {
+		return nil
+	}
+
+	file := resultsFile{
+		PollID:     poll.ID,
+		Question:   poll.Question,
+		ExportedAt: exportedAt.UTC().Truncate(time.Second),
+		TotalVotes: summary.TotalVotes,
+	}
+	for i, option := range options {
+		file.Options = append(file.Options, resultsFileOption{
+			ID:       option.ID,
+			Value:    option.Value,
+			Position: option.Position,
+			Votes:    option.VoteCount,
+			Percent:  summary.Options[i].Percent,
+			Winner:   summary.Options[i].Winner,
+		})
+	}
+
+	sort.Slice(file.Options, func(i, j int) bool { return file.Options[i].Position < file.Options[j].Position })
+
+	body, contentType, err := file.render(export.Format)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("polls/%s/results-%s.%s", poll.ID, file.ExportedAt.Format("20060102T150405Z"), export.Format)
+	return app.storage.Put(key, contentType, body)
+}
+
+// render encodes the results as CSV, one option per row, or as JSON.
+func (f *resultsFile) render(format string) ([]byte, string, error) {
+	if format == data.ExportJSON {
+		body, err := json.MarshalIndent(f, "", "\t")
+		return body, "application/json", err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"poll_id", "question", "exported_at", "option_id", "value", "position", "votes", "percent", "winner"})
+	for _, option := range f.Options {
+		w.Write([]string{
+			f.PollID.String(),
+			f.Question,
+			f.ExportedAt.Format(time.RFC3339),
+			option.ID.String(),
+			option.Value,
+			strconv.Itoa(option.Position),
+			strconv.Itoa(option.Votes),
+			strconv.FormatFloat(option.Percent, 'f', -1, 64),
+			strconv.FormatBool(option.Winner),
+		})
+	}
+	w.Flush()
+
+	return buf.Bytes(), "text/csv", w.Error()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_exportPollResults(t *testing.T) {
+	exportedAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		pollID              uuid.UUID
+		format              string
+		expectedKey         string
+		expectedContentType string
+		expectedBody        []string
+	}{
+		{
+			name:                "csv",
+			pollID:              data.ExamplePollIDDemographics,
+			format:              data.ExportCSV,
+			expectedKey:         "polls/" + data.ExamplePollIDDemographics.String() + "/results-20240301T093000Z.csv",
+			expectedContentType: "text/csv",
+			expectedBody: []string{
+				"poll_id,question,exported_at,option_id,value,position,votes,percent,winner\n",
+				data.ExamplePollIDDemographics.String() + ",Test?,2024-03-01T09:30:00Z," + data.ExampleOptionID1.String() + ",One,0,4,80,true\n",
+				data.ExamplePollIDDemographics.String() + ",Test?,2024-03-01T09:30:00Z," + data.ExampleOptionID2.String() + ",Two,1,1,20,false\n",
+			},
+		},
+		{
+			name:                "json",
+			pollID:              data.ExamplePollIDDemographics,
+			format:              data.ExportJSON,
+			expectedKey:         "polls/" + data.ExamplePollIDDemographics.String() + "/results-20240301T093000Z.json",
+			expectedContentType: "application/json",
+			expectedBody:        []string{`"total_votes": 5`, `"votes": 4`, `"winner": true`},
+		},
+		{
+			name:   "below results threshold",
+			pollID: data.ExamplePollIDThreshold,
+			format: data.ExportCSV,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := &mockStorage{}
+			app.storage = storage
+			defer func() { app.storage = nil }()
+
+			export := &data.ResultExport{PollID: test.pollID, Frequency: data.DigestDaily, Format: test.format}
+			if err := app.exportPollResults(export, exportedAt); err != nil {
+				t.Fatalf("export returned an error: %s", err)
+			}
+
+			files := storage.Files()
+			if test.expectedKey == "" {
+				if len(files) != 0 {
+					t.Errorf("expected no files, but got %d", len(files))
+				}
+				return
+			}
+
+			file, ok := files[test.expectedKey]
+			if !ok {
+				t.Fatalf("expected file %q, but got %v", test.expectedKey, files)
+			}
+			if file.contentType != test.expectedContentType {
+				t.Errorf("expected content type %q, but got %q", test.expectedContentType, file.contentType)
+			}
+			for _, expected := range test.expectedBody {
+				if !strings.Contains(string(file.body), expected) {
+					t.Errorf("expected body to contain %q, but got %q", expected, file.body)
+				}
+			}
+		})
+	}
+}
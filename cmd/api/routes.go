@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ivcp/polls/internal/auth"
 )
 
 func (app *application) routes() http.Handler {
@@ -14,20 +15,77 @@ func (app *application) routes() http.Handler {
 	mux.Use(app.metrics)
 	mux.Use(middleware.Recoverer)
 	mux.Use(app.enableCORS)
+	mux.Use(app.hsts)
+	mux.Use(app.negotiateLanguage)
+	mux.Use(app.negotiateProfile)
+	mux.Use(app.purgeOnWrite)
+	mux.Use(app.authenticateAPIKey)
 	mux.NotFound(app.notFoundResponse)
 
 	mux.Group(func(mux chi.Router) {
 		mux.Use(app.rateLimit)
+		mux.Use(app.checkTakedown)
 		mux.Get("/v1/healthcheck", app.healthcheckHandler)
-		mux.Post("/v1/polls", app.createPollHandler)
+		mux.Get("/v1/stats", app.showPublicStatsHandler)
+		mux.With(app.checkBan, app.screenBots).Post("/v1/polls", app.createPollHandler)
+		mux.Get("/v1/polls/form-token", app.showFormTokenHandler)
+		mux.With(app.checkBan).Post("/v1/polls/import", app.importPollHandler)
 		mux.Get("/v1/polls", app.listPollsHandler)
+		mux.Get("/v1/polls/feed.atom", app.listPollsFeedHandler)
+		mux.Get("/v1/polls/search", app.searchPollsHandler)
+		mux.Get("/v1/polls/by-external/{externalID}", app.showPollByExternalIDHandler)
 		mux.Get("/v1/polls/{pollID}", app.showPollHandler)
 		mux.Get("/v1/polls/{pollID}/results", app.showResultsHandler)
-		mux.Post("/v1/polls/{pollID}/options/{optionID}", app.voteOptionHandler)
+		mux.Get("/v1/polls/{pollID}/related", app.showRelatedPollsHandler)
+		mux.Get("/v1/polls/{pollID}/ballots", app.showBallotsHandler)
+		mux.Get("/v1/polls/{pollID}/ballots/{ballot}/proof", app.showBallotProofHandler)
+		mux.Get("/v1/series/{seriesID}/results", app.showSeriesResultsHandler)
+		mux.Get("/v1/polls/{pollID}/qr", app.showPollQRHandler)
+		mux.Get("/v1/polls/{pollID}/embed", app.showPollEmbedHandler)
+		mux.With(app.tokenFromQuery).Get("/v1/polls/{pollID}/present", app.showPollPresentationHandler)
+		mux.With(app.tokenFromQuery).Get("/v1/polls/{pollID}/present/events", app.presentPollEventsHandler)
+		mux.Get("/v1/oembed", app.oEmbedHandler)
+		mux.Get("/v1/polls/{pollID}/shortlink", app.showShortLinkHandler)
+		mux.Post("/v1/polls/{pollID}/shortlink", app.createShortLinkHandler)
+		mux.Get("/p/{code}", app.redirectShortLinkHandler)
+		mux.Post("/v1/polls/{pollID}/jwt", app.issueJWTHandler)
+		mux.Post("/v1/polls/{pollID}/report", app.reportPollHandler)
+		mux.Post("/v1/undo/{actionID}", app.undoHandler)
+		mux.Get("/v1/receipts/{receiptID}", app.showReceiptHandler)
+		mux.Get("/v1/votes/confirm", app.confirmVoteHandler)
+		mux.With(app.voteRateLimit).Post("/v1/polls/{pollID}/options/{optionID}", app.voteOptionHandler)
+		mux.With(app.requirePollPermission(auth.ViewResults)).Get("/v1/polls/{pollID}/sources", app.showPollSourcesHandler)
+		mux.With(app.requirePollPermission(auth.ViewResults)).Get("/v1/polls/{pollID}/stats", app.showPollStatsHandler)
 
 		mux.Group(func(mux chi.Router) {
-			mux.Use(app.requireToken)
+			mux.Use(app.requirePollPermission(auth.EditPoll))
 			mux.Delete("/v1/polls/{pollID}", app.deletePollHandler)
+			mux.Get("/v1/polls/{pollID}/votes/flagged", app.listFlaggedVotesHandler)
+			mux.Patch("/v1/polls/{pollID}/votes/{voteID}", app.moderateVoteHandler)
+			mux.With(app.checkPollExpired).Put("/v1/polls/{pollID}/notifications", app.updatePollNotificationsHandler)
+			mux.Delete("/v1/polls/{pollID}/notifications", app.deletePollNotificationsHandler)
+			mux.With(app.checkPollExpired).Post("/v1/polls/{pollID}/publish", app.publishPollHandler)
+			mux.With(app.checkPollExpired).Post("/v1/polls/{pollID}/pause", app.pausePollHandler)
+			mux.With(app.checkPollExpired).Post("/v1/polls/{pollID}/resume", app.resumePollHandler)
+			mux.Get("/v1/polls/{pollID}/digest", app.showPollDigestHandler)
+			mux.With(app.checkPollExpired).Put("/v1/polls/{pollID}/digest", app.updatePollDigestHandler)
+			mux.Delete("/v1/polls/{pollID}/digest", app.deletePollDigestHandler)
+			mux.Get("/v1/polls/{pollID}/sms", app.showPollSMSHandler)
+			mux.With(app.checkPollExpired).Put("/v1/polls/{pollID}/sms", app.updatePollSMSHandler)
+			mux.Delete("/v1/polls/{pollID}/sms", app.deletePollSMSHandler)
+			mux.Get("/v1/polls/{pollID}/results/export", app.showResultExportHandler)
+			mux.Put("/v1/polls/{pollID}/results/export", app.updateResultExportHandler)
+			mux.Delete("/v1/polls/{pollID}/results/export", app.deleteResultExportHandler)
+			mux.Post("/v1/polls/{pollID}/transfer", app.transferPollHandler)
+			mux.Get("/v1/polls/{pollID}/transfers", app.listPollTransfersHandler)
+			mux.Get("/v1/polls/{pollID}/activity", app.listPollActivityHandler)
+			mux.Get("/v1/polls/{pollID}/export", app.exportPollHandler)
+			mux.Post("/v1/polls/{pollID}/tokens", app.createPollTokenHandler)
+			mux.Post("/v1/polls/{pollID}/tokens/rotate", app.rotatePollTokenHandler)
+			mux.With(app.checkPollExpired).Post("/v1/polls/{pollID}/options/{optionID}/merge", app.mergeOptionsHandler)
+			mux.Get("/v1/polls/{pollID}/options/deleted", app.listDeletedOptionsHandler)
+			mux.With(app.checkPollExpired).Post("/v1/polls/{pollID}/options/{optionID}/restore", app.restoreOptionHandler)
+			mux.Delete("/v1/polls/{pollID}/tokens", app.revokePollTokensHandler)
 			mux.Group(func(mux chi.Router) {
 				mux.Use(app.checkPollExpired)
 				mux.Use(app.checkVoteStarted)
@@ -38,8 +96,58 @@ func (app *application) routes() http.Handler {
 				mux.Delete("/v1/polls/{pollID}/options/{optionID}", app.deleteOptionHandler)
 			})
 		})
+
+		mux.Post("/v1/orgs", app.createOrgHandler)
+		mux.With(app.requireOrgPermission(auth.ViewPolls)).Get("/v1/orgs/{orgID}", app.showOrgHandler)
+		mux.With(app.requireOrgPermission(auth.ViewPolls)).Get("/v1/orgs/{orgID}/polls", app.listPollsHandler)
+		mux.With(app.requireOrgPermission(auth.ViewPolls)).Get("/v1/orgs/{orgID}/polls/by-external/{externalID}", app.showPollByExternalIDHandler)
+		mux.With(app.checkBan, app.requireOrgPermission(auth.CreatePoll)).Post("/v1/orgs/{orgID}/polls", app.createPollHandler)
+		mux.With(app.requireOrgPermission(auth.ViewPolls)).Get("/v1/usage", app.showUsageHandler)
+		mux.With(app.requireOrgPermission(auth.ViewPolls)).Get("/v1/polls/trash", app.listTrashHandler)
+		mux.With(app.tokenFromQuery, app.requireOrgPermission(auth.ViewPolls)).Get("/v1/polls/mine/calendar.ics", app.showPollCalendarHandler)
+		mux.With(app.adminOr(app.requireOrgPermission(auth.ViewPolls))).Get("/v1/polls/all.ndjson", app.streamPollsHandler)
+		mux.Group(func(mux chi.Router) {
+			mux.Use(app.requireOrgPermission(auth.ManageMembers))
+			mux.Get("/v1/orgs/{orgID}/members", app.listOrgMembersHandler)
+			mux.Post("/v1/orgs/{orgID}/members", app.addOrgMemberHandler)
+			mux.Patch("/v1/orgs/{orgID}/members/{memberID}", app.updateOrgMemberHandler)
+			mux.Delete("/v1/orgs/{orgID}/members/{memberID}", app.deleteOrgMemberHandler)
+			mux.Get("/v1/orgs/{orgID}/api-keys", app.listAPIKeysHandler)
+			mux.Post("/v1/orgs/{orgID}/api-keys", app.createAPIKeyHandler)
+			mux.Delete("/v1/orgs/{orgID}/api-keys/{keyID}", app.revokeAPIKeyHandler)
+		})
+	})
+
+	// admin requests are made on polls that may be taken down, so they are
+	// kept out of the group answering for those with tombstones
+	mux.Group(func(mux chi.Router) {
+		mux.Use(app.rateLimit)
+		mux.Use(app.requireAdmin)
+		mux.Get("/v1/admin/moderation", app.listFlaggedPollsHandler)
+		mux.Patch("/v1/admin/moderation/{pollID}", app.moderatePollHandler)
+		mux.Get("/v1/admin/reports", app.listReportsHandler)
+		mux.Patch("/v1/admin/reports/{pollID}", app.resolveReportsHandler)
+		mux.Put("/v1/admin/takedowns/{pollID}", app.takeDownPollHandler)
+		mux.Delete("/v1/admin/takedowns/{pollID}", app.restorePollHandler)
+		mux.Get("/v1/admin/bans", app.listBansHandler)
+		mux.Post("/v1/admin/bans", app.createBanHandler)
+		mux.Delete("/v1/admin/bans/{banID}", app.deleteBanHandler)
+		mux.Get("/v1/admin/exemptions", app.listExemptionsHandler)
+		mux.Post("/v1/admin/exemptions", app.createExemptionHandler)
+		mux.Delete("/v1/admin/exemptions/{exemptionID}", app.deleteExemptionHandler)
+		mux.Get("/v1/admin/polls/dump", app.dumpPollsHandler)
 	})
 
+	// requests from chat platforms and Twilio are authenticated by their
+	// signature and not rate limited, as they all come from the platforms'
+	// servers
+	mux.Post("/v1/integrations/slack/commands", app.slackCommandHandler)
+	mux.Post("/v1/integrations/slack/interactions", app.slackInteractionHandler)
+	mux.Post("/v1/integrations/discord/interactions", app.discordInteractionHandler)
+	mux.Post("/v1/integrations/telegram/webhook", app.telegramWebhookHandler)
+	mux.Post("/v1/integrations/twilio/sms", app.twilioSMSHandler)
+
+	mux.With(app.rateLimit).Get("/v1/openapi.json", app.openAPIHandler(mux))
 	mux.Method(http.MethodGet, "/v1/metrics", expvar.Handler())
 
 	return mux
@@ -14,8 +14,13 @@ func Test_app_routes(t *testing.T) {
 		method string
 	}{
 		{"/v1/healthcheck", http.MethodGet},
+		{"/v1/stats", http.MethodGet},
+		{"/v1/openapi.json", http.MethodGet},
 		{"/v1/polls", http.MethodPost},
 		{"/v1/polls", http.MethodGet},
+		{"/v1/polls/feed.atom", http.MethodGet},
+		{"/v1/polls/search", http.MethodGet},
+		{"/v1/polls/by-external/{externalID}", http.MethodGet},
 		{"/v1/polls/{pollID}", http.MethodGet},
 		{"/v1/polls/{pollID}", http.MethodPatch},
 		{"/v1/polls/{pollID}", http.MethodDelete},
@@ -25,6 +30,77 @@ func Test_app_routes(t *testing.T) {
 		{"/v1/polls/{pollID}/options/{optionID}", http.MethodDelete},
 		{"/v1/polls/{pollID}/options", http.MethodPatch},
 		{"/v1/polls/{pollID}/results", http.MethodGet},
+		{"/v1/polls/{pollID}/related", http.MethodGet},
+		{"/v1/series/{seriesID}/results", http.MethodGet},
+		{"/v1/polls/{pollID}/sources", http.MethodGet},
+		{"/v1/polls/{pollID}/stats", http.MethodGet},
+		{"/v1/polls/{pollID}/activity", http.MethodGet},
+		{"/v1/polls/{pollID}/export", http.MethodGet},
+		{"/v1/polls/import", http.MethodPost},
+		{"/v1/polls/{pollID}/publish", http.MethodPost},
+		{"/v1/polls/{pollID}/pause", http.MethodPost},
+		{"/v1/polls/{pollID}/resume", http.MethodPost},
+		{"/v1/polls/{pollID}/votes/flagged", http.MethodGet},
+		{"/v1/polls/{pollID}/qr", http.MethodGet},
+		{"/v1/polls/{pollID}/embed", http.MethodGet},
+		{"/v1/polls/{pollID}/present", http.MethodGet},
+		{"/v1/polls/{pollID}/present/events", http.MethodGet},
+		{"/v1/oembed", http.MethodGet},
+		{"/v1/polls/{pollID}/shortlink", http.MethodGet},
+		{"/v1/polls/{pollID}/shortlink", http.MethodPost},
+		{"/p/{code}", http.MethodGet},
+		{"/v1/polls/{pollID}/jwt", http.MethodPost},
+		{"/v1/polls/{pollID}/votes/{voteID}", http.MethodPatch},
+		{"/v1/polls/{pollID}/notifications", http.MethodPut},
+		{"/v1/polls/{pollID}/notifications", http.MethodDelete},
+		{"/v1/polls/{pollID}/digest", http.MethodGet},
+		{"/v1/polls/{pollID}/digest", http.MethodPut},
+		{"/v1/polls/{pollID}/digest", http.MethodDelete},
+		{"/v1/polls/{pollID}/sms", http.MethodGet},
+		{"/v1/polls/{pollID}/sms", http.MethodPut},
+		{"/v1/polls/{pollID}/sms", http.MethodDelete},
+		{"/v1/polls/{pollID}/results/export", http.MethodGet},
+		{"/v1/polls/{pollID}/results/export", http.MethodPut},
+		{"/v1/polls/{pollID}/results/export", http.MethodDelete},
+		{"/v1/polls/{pollID}/transfer", http.MethodPost},
+		{"/v1/polls/{pollID}/transfers", http.MethodGet},
+		{"/v1/polls/{pollID}/tokens", http.MethodPost},
+		{"/v1/polls/{pollID}/tokens/rotate", http.MethodPost},
+		{"/v1/polls/{pollID}/options/{optionID}/merge", http.MethodPost},
+		{"/v1/polls/{pollID}/tokens", http.MethodDelete},
+		{"/v1/admin/moderation", http.MethodGet},
+		{"/v1/admin/moderation/{pollID}", http.MethodPatch},
+		{"/v1/admin/reports", http.MethodGet},
+		{"/v1/admin/reports/{pollID}", http.MethodPatch},
+		{"/v1/admin/takedowns/{pollID}", http.MethodPut},
+		{"/v1/admin/takedowns/{pollID}", http.MethodDelete},
+		{"/v1/admin/polls/dump", http.MethodGet},
+		{"/v1/admin/bans", http.MethodGet},
+		{"/v1/admin/bans", http.MethodPost},
+		{"/v1/admin/bans/{banID}", http.MethodDelete},
+		{"/v1/polls/{pollID}/report", http.MethodPost},
+		{"/v1/undo/{actionID}", http.MethodPost},
+		{"/v1/receipts/{receiptID}", http.MethodGet},
+		{"/v1/votes/confirm", http.MethodGet},
+		{"/v1/polls/{pollID}/ballots", http.MethodGet},
+		{"/v1/polls/{pollID}/ballots/{ballot}/proof", http.MethodGet},
+		{"/v1/orgs", http.MethodPost},
+		{"/v1/orgs/{orgID}", http.MethodGet},
+		{"/v1/orgs/{orgID}/polls", http.MethodGet},
+		{"/v1/orgs/{orgID}/polls", http.MethodPost},
+		{"/v1/orgs/{orgID}/polls/by-external/{externalID}", http.MethodGet},
+		{"/v1/orgs/{orgID}/members", http.MethodGet},
+		{"/v1/orgs/{orgID}/members", http.MethodPost},
+		{"/v1/orgs/{orgID}/members/{memberID}", http.MethodPatch},
+		{"/v1/orgs/{orgID}/members/{memberID}", http.MethodDelete},
+		{"/v1/usage", http.MethodGet},
+		{"/v1/polls/mine/calendar.ics", http.MethodGet},
+		{"/v1/polls/all.ndjson", http.MethodGet},
+		{"/v1/integrations/slack/commands", http.MethodPost},
+		{"/v1/integrations/slack/interactions", http.MethodPost},
+		{"/v1/integrations/discord/interactions", http.MethodPost},
+		{"/v1/integrations/telegram/webhook", http.MethodPost},
+		{"/v1/integrations/twilio/sms", http.MethodPost},
 	}
 	testMux := app.routes()
 	chiRoutes := testMux.(chi.Routes)
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsEnabled reports whether the server serves HTTPS itself, with a
+// certificate from files or from Let's Encrypt.
+func (cfg config) tlsEnabled() bool {
+	return cfg.tls.certFile != "" || cfg.tls.autocertDomains != ""
+}
+
+func (cfg config) validateTLS() error {
+	switch {
+	case (cfg.tls.certFile == "") != (cfg.tls.keyFile == ""):
+		return errors.New("tls-cert and tls-key must be set together")
+	case cfg.tls.certFile != "" && cfg.tls.autocertDomains != "":
+		return errors.New("tls-cert can't be used together with autocert-domains")
+	}
+	return nil
+}
+
+// enableLoadTestMode turns off the protections that would reject the traffic
+// of a load test, so throughput of the vote path can be measured.
+func (cfg *config) enableLoadTestMode() error {
+	if cfg.env == "production" {
+		return errors.New("load test mode is not allowed in production")
+	}
+	cfg.limiter.enabled = false
+	cfg.voteLimiter.enabled = false
+	cfg.spam.enabled = false
+	return nil
+}
+
+// serve listens on the configured port, serving HTTPS if TLS is enabled. Plain
+// HTTP requests are then redirected to HTTPS from the redirect port, which
+// also answers Let's Encrypt challenges.
+func (app *application) serve() error {
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", app.config.port),
+		Handler:      app.routes(),
+		IdleTimeout:  time.Minute,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	if !app.config.tlsEnabled() {
+		app.logger.Printf("Starting %s server on %s", app.config.env, srv.Addr)
+		return srv.ListenAndServe()
+	}
+
+	redirect := app.redirectToHTTPS()
+
+	if app.config.tls.autocertDomains != "" {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(strings.Split(app.config.tls.autocertDomains, ",")...),
+			Cache:      autocert.DirCache(app.config.tls.autocertCache),
+			Email:      app.config.tls.autocertEmail,
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		redirect = manager.HTTPHandler(redirect)
+	}
+
+	if app.config.tls.redirectPort > 0 {
+		redirectSrv := &http.Server{
+			Addr:         fmt.Sprintf(":%d", app.config.tls.redirectPort),
+			Handler:      redirect,
+			IdleTimeout:  time.Minute,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 5 * time.Second,
+		}
+		go func() {
+			app.logger.Printf("Redirecting HTTP requests to HTTPS on %s", redirectSrv.Addr)
+			app.logger.Fatal(redirectSrv.ListenAndServe())
+		}()
+	}
+
+	app.logger.Printf("Starting %s server with TLS on %s", app.config.env, srv.Addr)
+	return srv.ListenAndServeTLS(app.config.tls.certFile, app.config.tls.keyFile)
+}
+
+// redirectToHTTPS permanently redirects requests to the same URL on the HTTPS
+// port.
+func (app *application) redirectToHTTPS() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if app.config.port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(app.config.port))
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
+
+// serveProfiler serves the pprof endpoints on their own address, so they are
+// never reachable through the public server.
+func (app *application) serveProfiler() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{
+		Addr:        app.config.profile,
+		Handler:     mux,
+		IdleTimeout: time.Minute,
+		ReadTimeout: 10 * time.Second,
+	}
+
+	app.logger.Printf("Serving pprof endpoints on %s", srv.Addr)
+	app.logError(srv.ListenAndServe())
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_config_validateTLS(t *testing.T) {
+	tests := []struct {
+		name     string
+		cert     string
+		key      string
+		domains  string
+		expected bool
+	}{
+		{"disabled", "", "", "", true},
+		{"cert and key", "cert.pem", "key.pem", "", true},
+		{"autocert", "", "", "polls.example.com", true},
+		{"cert without key", "cert.pem", "", "", false},
+		{"key without cert", "", "key.pem", "", false},
+		{"cert and autocert", "cert.pem", "key.pem", "polls.example.com", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg config
+			cfg.tls.certFile = test.cert
+			cfg.tls.keyFile = test.key
+			cfg.tls.autocertDomains = test.domains
+
+			if err := cfg.validateTLS(); (err == nil) != test.expected {
+				t.Errorf("expected valid to be %t, but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func Test_config_enableLoadTestMode(t *testing.T) {
+	var cfg config
+	cfg.env = "development"
+	cfg.limiter.enabled = true
+	cfg.voteLimiter.enabled = true
+	cfg.spam.enabled = true
+
+	if err := cfg.enableLoadTestMode(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if cfg.limiter.enabled || cfg.voteLimiter.enabled || cfg.spam.enabled {
+		t.Errorf("expected rate limiting and spam screening to be disabled, but got %+v", cfg)
+	}
+
+	cfg.env = "production"
+	if err := cfg.enableLoadTestMode(); err == nil {
+		t.Error("expected load test mode not to be allowed in production")
+	}
+}
+
+func Test_app_redirectToHTTPS(t *testing.T) {
+	defer func(port int) { app.config.port = port }(app.config.port)
+
+	tests := []struct {
+		name     string
+		port     int
+		host     string
+		expected string
+	}{
+		{"default port", 443, "polls.example.com", "https://polls.example.com/v1/polls?page=2"},
+		{"request with port", 443, "polls.example.com:80", "https://polls.example.com/v1/polls?page=2"},
+		{"custom port", 8443, "polls.example.com:8080", "https://polls.example.com:8443/v1/polls?page=2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app.config.port = test.port
+
+			req, _ := http.NewRequest(http.MethodGet, "http://"+test.host+"/v1/polls?page=2", nil)
+			rr := httptest.NewRecorder()
+			app.redirectToHTTPS().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusPermanentRedirect {
+				t.Errorf("expected status %d, but got %d", http.StatusPermanentRedirect, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != test.expected {
+				t.Errorf("expected redirect to %q, but got %q", test.expected, location)
+			}
+		})
+	}
+}
@@ -1,15 +1,138 @@
 package main
 
 import (
+	"io"
+	"log"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/data/mock"
+	"go.uber.org/mock/gomock"
 )
 
 var app application
 
+// repositoryMocks are the generated mocks of the poll, option and vote
+// repositories, which tests set the calls they expect on.
+type repositoryMocks struct {
+	polls   *mock.MockPollRepository
+	options *mock.MockOptionRepository
+	votes   *mock.MockVoteRepository
+}
+
+// mockRepositories replaces the app's poll, option and vote repositories
+// with generated mocks until the test ends, so calls the test doesn't expect
+// fail it. The other models keep their fixtures.
+func mockRepositories(t *testing.T) repositoryMocks {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mocks := repositoryMocks{
+		polls:   mock.NewMockPollRepository(ctrl),
+		options: mock.NewMockOptionRepository(ctrl),
+		votes:   mock.NewMockVoteRepository(ctrl),
+	}
+
+	models := app.models
+	app.models.Polls = mocks.polls
+	app.models.PollOptions = mocks.options
+	app.models.Votes = mocks.votes
+	t.Cleanup(func() { app.models = models })
+
+	return mocks
+}
+
+// examplePoll returns a fresh copy of the poll fixture, for tests whose poll
+// repository is mocked.
+func examplePoll(t *testing.T, id uuid.UUID) *data.Poll {
+	t.Helper()
+	poll, err := data.MockPollModel{}.Get(id)
+	if err != nil {
+		t.Fatalf("no poll fixture %s: %s", id, err)
+	}
+	return poll
+}
+
+// mockGeoIP resolves 1.1.1.x addresses to Germany and everything else to
+// the United States.
+type mockGeoIP struct{}
+
+func (m mockGeoIP) Country(ip string) (string, error) {
+	if strings.HasPrefix(ip, "1.1.1.") {
+		return "DE", nil
+	}
+	return "US", nil
+}
+
+// mockMailer records sent emails instead of sending them.
+type mockMailer struct {
+	mu   sync.Mutex
+	sent []sentEmail
+}
+
+type sentEmail struct {
+	recipient    string
+	templateFile string
+	data         any
+}
+
+func (m *mockMailer) Send(recipient, templateFile string, data any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentEmail{recipient, templateFile, data})
+	return nil
+}
+
+func (m *mockMailer) Sent() []sentEmail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]sentEmail(nil), m.sent...)
+}
+
+// mockStorage records stored files instead of uploading them.
+type mockStorage struct {
+	mu    sync.Mutex
+	files map[string]storedFile
+}
+
+type storedFile struct {
+	contentType string
+	body        []byte
+}
+
+func (m *mockStorage) Put(key, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string]storedFile)
+	}
+	m.files[key] = storedFile{contentType, body}
+	return nil
+}
+
+func (m *mockStorage) Files() map[string]storedFile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make(map[string]storedFile, len(m.files))
+	for key, file := range m.files {
+		files[key] = file
+	}
+	return files
+}
+
+// testCleanups run after all tests, e.g. to remove the e2e database.
+var testCleanups []func()
+
 func TestMain(m *testing.M) {
 	app.models = data.NewMockModels()
-	os.Exit(m.Run())
+	app.logger = log.New(io.Discard, "", 0)
+
+	code := m.Run()
+	for i := len(testCleanups) - 1; i >= 0; i-- {
+		testCleanups[i]()
+	}
+	os.Exit(code)
 }
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"embed"
+	"html/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
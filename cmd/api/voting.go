@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/service"
+)
+
+// voting casts the votes of chat apps and SMS, holding the lock the vote
+// handlers hold.
+func (app *application) voting() service.Voting {
+	return service.Voting{Models: app.models, Lock: &app.mutex}
+}
+
+// voteRefusal is the message telling a voter on a chat app or by SMS why
+// their vote wasn't cast, and false for errors that aren't about the vote.
+// from is how they voted, e.g. "from Slack".
+func voteRefusal(err error, from string) (string, bool) {
+	switch {
+	case errors.Is(err, service.ErrPollUnavailable):
+		return "This poll is no longer available.", true
+	case errors.Is(err, data.ErrVoteQuotaReached):
+		return "This poll has reached its maximum number of votes.", true
+	case errors.Is(err, data.ErrPollClosed), errors.Is(err, data.ErrPollExpired):
+		return "This poll has expired.", true
+	case errors.Is(err, service.ErrPollPaused):
+		return "Voting on this poll is paused.", true
+	case errors.Is(err, service.ErrGeoRestricted):
+		return "This poll can't be voted on " + from + ".", true
+	case errors.Is(err, data.ErrAlreadyVoted):
+		return "You have already voted on this poll.", true
+	}
+	return "", false
+}
@@ -0,0 +1,189 @@
+// Package analytics streams poll activity to a data warehouse. Events follow
+// the layout of Snowplow's enriched events, with the event specific fields
+// in a self-describing unstruct_event, so existing loaders can ingest them.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+const (
+	Vendor  = "io.github.ivcp.polls"
+	Version = "1-0-0"
+
+	NameVote = "vote"
+	NameView = "view"
+)
+
+// BatchSize is the most events a sink is given at once.
+const BatchSize = 500
+
+// maxBuffered is the most events a stream holds between flushes. Events
+// tracked while the buffer is full are dropped.
+const maxBuffered = 10000
+
+// Event is a single poll view or vote.
+type Event struct {
+	AppID           string         `json:"app_id"`
+	Platform        string         `json:"platform"`
+	EventID         string         `json:"event_id"`
+	EventVendor     string         `json:"event_vendor"`
+	EventName       string         `json:"event_name"`
+	EventFormat     string         `json:"event_format"`
+	EventVersion    string         `json:"event_version"`
+	CollectorTstamp time.Time      `json:"collector_tstamp"`
+	PollID          uuid.UUID      `json:"poll_id"`
+	UnstructEvent   SelfDescribing `json:"unstruct_event"`
+}
+
+// SelfDescribing is data together with the Iglu URI of its JSON schema,
+// e.g. "iglu:io.github.ivcp.polls/vote/jsonschema/1-0-0".
+type SelfDescribing struct {
+	Schema string `json:"schema"`
+	Data   any    `json:"data"`
+}
+
+func newEvent(name string, pollID uuid.UUID, data any) Event {
+	return Event{
+		AppID:           "polls",
+		Platform:        "srv",
+		EventID:         uuid.NewString(),
+		EventVendor:     Vendor,
+		EventName:       name,
+		EventFormat:     "jsonschema",
+		EventVersion:    Version,
+		CollectorTstamp: time.Now().UTC(),
+		PollID:          pollID,
+		UnstructEvent: SelfDescribing{
+			Schema: fmt.Sprintf("iglu:%s/%s/jsonschema/%s", Vendor, name, Version),
+			Data:   data,
+		},
+	}
+}
+
+// Vote is tracked for every recorded vote, including votes flagged for
+// moderation. Voter details are left out.
+func Vote(vote *data.Vote) Event {
+	return newEvent(NameVote, vote.PollID, struct {
+		VoteID       int64             `json:"vote_id"`
+		OptionID     uuid.UUID         `json:"option_id"`
+		Status       string            `json:"status"`
+		Source       string            `json:"source,omitempty"`
+		Demographics map[string]string `json:"demographics,omitempty"`
+	}{
+		VoteID:       vote.ID,
+		OptionID:     vote.OptionID,
+		Status:       vote.Status,
+		Source:       vote.Source,
+		Demographics: vote.Demographics,
+	})
+}
+
+// View is tracked every time a poll is shown, unlike the view counts of the
+// API which count each visitor once per hour.
+func View(pollID uuid.UUID, source string) Event {
+	return newEvent(NameView, pollID, struct {
+		Source string `json:"source,omitempty"`
+	}{
+		Source: source,
+	})
+}
+
+// Sink writes events to a file or a data stream.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// Stream buffers events and writes them to its sink in batches, so tracking
+// an event never waits on the sink. Events of a batch the sink fails to
+// write are dropped.
+type Stream struct {
+	sink    Sink
+	onError func(error)
+
+	mu      sync.Mutex
+	buffer  []Event
+	dropped int
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewStream flushes the tracked events to the sink every interval, passing
+// errors to onError.
+func NewStream(sink Sink, interval time.Duration, onError func(error)) *Stream {
+	s := &Stream{
+		sink:    sink,
+		onError: onError,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.Flush()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+func (s *Stream) Track(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buffer) >= maxBuffered {
+		s.dropped++
+		return
+	}
+	s.buffer = append(s.buffer, event)
+}
+
+// Flush writes the buffered events to the sink.
+func (s *Stream) Flush() {
+	s.mu.Lock()
+	events, dropped := s.buffer, s.dropped
+	s.buffer, s.dropped = nil, 0
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		s.onError(fmt.Errorf("analytics: dropped %d events, buffer full", dropped))
+	}
+
+	for len(events) > 0 {
+		n := min(len(events), BatchSize)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := s.sink.Write(ctx, events[:n]); err != nil {
+			s.onError(fmt.Errorf("analytics: write %d events: %w", n, err))
+		}
+		cancel()
+
+		events = events[n:]
+	}
+}
+
+// Close flushes the remaining events and closes the sink.
+func (s *Stream) Close() error {
+	close(s.done)
+	<-s.stopped
+	s.Flush()
+	return s.sink.Close()
+}
@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestVote(t *testing.T) {
+	vote := &data.Vote{ID: 7, PollID: data.ExamplePollIDValid, OptionID: data.ExampleOptionID1, IP: "1.2.3.4", UserAgent: "curl", Status: data.VoteStatusAccepted, Source: "slack"}
+
+	event := Vote(vote)
+	if event.EventName != NameVote || event.PollID != data.ExamplePollIDValid || event.EventID == "" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	js, _ := json.Marshal(event)
+	for _, s := range []string{vote.IP, vote.UserAgent} {
+		if strings.Contains(string(js), s) {
+			t.Errorf("expected event not to contain voter details, but got %s", js)
+		}
+	}
+	for _, s := range []string{
+		`"schema":"iglu:io.github.ivcp.polls/vote/jsonschema/1-0-0"`,
+		`"option_id":"` + data.ExampleOptionID1.String() + `"`,
+		`"source":"slack"`,
+	} {
+		if !strings.Contains(string(js), s) {
+			t.Errorf("expected event to contain %s, but got %s", s, js)
+		}
+	}
+}
+
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+	closed  bool
+}
+
+func (r *recordingSink) Write(ctx context.Context, events []Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func (r *recordingSink) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	sink := &recordingSink{}
+	var errs []error
+	stream := NewStream(sink, time.Hour, func(err error) { errs = append(errs, err) })
+
+	for i := 0; i < BatchSize+1; i++ {
+		stream.Track(View(data.ExamplePollIDValid, ""))
+	}
+	stream.Flush()
+
+	if len(sink.batches) != 2 || len(sink.batches[0]) != BatchSize || len(sink.batches[1]) != 1 {
+		t.Errorf("expected a full batch and a batch of 1, but got %d batches", len(sink.batches))
+	}
+
+	for i := 0; i < maxBuffered+2; i++ {
+		stream.Track(View(data.ExamplePollIDValid, ""))
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !sink.closed {
+		t.Error("expected sink to be closed")
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "dropped 2 events") {
+		t.Errorf("expected the dropped events to be reported, but got %v", errs)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Write(context.Background(), []Event{View(data.ExamplePollIDValid, "slack")}); err != nil {
+			t.Fatalf("write returned an error: %s", err)
+		}
+		sink.Close()
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.EventName != NameView {
+			t.Errorf("unexpected line %s", scanner.Bytes())
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected events to be appended, but got %d lines", lines)
+	}
+}
@@ -0,0 +1,47 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends events to a file as JSON lines, e.g. for a log shipper
+// to pick up.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open analytics file: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (f *FileSink) Write(ctx context.Context, events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write analytics file: %w", err)
+	}
+	return nil
+}
+
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,109 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/sigv4"
+)
+
+// KinesisSink puts events to an Amazon Kinesis data stream, one record per
+// event, partitioned by poll ID so the events of a poll stay in order.
+type KinesisSink struct {
+	Endpoint string
+	Stream   string
+	Client   *http.Client
+	signer   sigv4.Signer
+}
+
+// NewKinesisSink puts records to the stream in the region. An empty endpoint
+// defaults to the region's Kinesis endpoint.
+func NewKinesisSink(endpoint, region, stream, accessKey, secretKey string) (*KinesisSink, error) {
+	if endpoint == "" {
+		endpoint = "https://kinesis." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid kinesis endpoint %q, must be an http or https URL", endpoint)
+	}
+	if stream == "" {
+		return nil, fmt.Errorf("kinesis stream must be set")
+	}
+
+	return &KinesisSink{
+		Endpoint: strings.TrimSuffix(endpoint, "/") + "/",
+		Stream:   stream,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		signer: sigv4.Signer{
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+			Region:    region,
+			Service:   "kinesis",
+		},
+	}, nil
+}
+
+type kinesisRecord struct {
+	Data         []byte `json:"Data"`
+	PartitionKey string `json:"PartitionKey"`
+}
+
+func (k *KinesisSink) Write(ctx context.Context, events []Event) error {
+	input := struct {
+		StreamName string          `json:"StreamName"`
+		Records    []kinesisRecord `json:"Records"`
+	}{StreamName: k.Stream}
+
+	for _, event := range events {
+		js, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		input.Records = append(input.Records, kinesisRecord{Data: js, PartitionKey: event.PollID.String()})
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("kinesis request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Kinesis_20131202.PutRecords")
+	k.signer.Sign(req, sigv4.Hash(body), time.Now())
+
+	res, err := k.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kinesis request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("kinesis request: unexpected status %d", res.StatusCode)
+	}
+
+	var output struct {
+		FailedRecordCount int `json:"FailedRecordCount"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&output); err != nil {
+		return fmt.Errorf("kinesis response: %w", err)
+	}
+	if output.FailedRecordCount > 0 {
+		return fmt.Errorf("kinesis rejected %d of %d records", output.FailedRecordCount, len(events))
+	}
+
+	return nil
+}
+
+func (k *KinesisSink) Close() error {
+	return nil
+}
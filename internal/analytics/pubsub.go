@@ -0,0 +1,200 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var pubSubTopicRX = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// PubSubSink publishes events to a Google Cloud Pub/Sub topic, one message
+// per event, with the event name and poll ID as attributes.
+//
+// Requests are authorized with a self-signed JWT of a service account, so
+// no token has to be fetched from Google first. Without credentials no
+// authorization is sent, as the Pub/Sub emulator expects.
+type PubSubSink struct {
+	Endpoint string
+	Topic    string
+	Client   *http.Client
+	account  *serviceAccount
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type serviceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	key          *rsa.PrivateKey
+}
+
+// NewPubSubSink publishes to the topic, given as
+// "projects/{project}/topics/{topic}". An empty endpoint defaults to
+// https://pubsub.googleapis.com. credentials is a service account key file
+// as downloaded from the Google Cloud console.
+func NewPubSubSink(endpoint, topic string, credentials []byte) (*PubSubSink, error) {
+	if endpoint == "" {
+		endpoint = "https://pubsub.googleapis.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid pubsub endpoint %q, must be an http or https URL", endpoint)
+	}
+	if !pubSubTopicRX.MatchString(topic) {
+		return nil, fmt.Errorf("invalid pubsub topic %q, must be projects/{project}/topics/{topic}", topic)
+	}
+
+	p := &PubSubSink{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Topic:    topic,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if credentials != nil {
+		p.account, err = parseServiceAccount(credentials)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func parseServiceAccount(credentials []byte) (*serviceAccount, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, fmt.Errorf("parse service account: %w", err)
+	}
+	if account.ClientEmail == "" {
+		return nil, errors.New("parse service account: client_email is missing")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("parse service account: private_key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse service account: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("parse service account: private_key is not an RSA key")
+	}
+	account.key = rsaKey
+
+	return &account, nil
+}
+
+type pubSubMessage struct {
+	Data       []byte            `json:"data"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func (p *PubSubSink) Write(ctx context.Context, events []Event) error {
+	var input struct {
+		Messages []pubSubMessage `json:"messages"`
+	}
+
+	for _, event := range events {
+		js, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		input.Messages = append(input.Messages, pubSubMessage{
+			Data:       js,
+			Attributes: map[string]string{"event_name": event.EventName, "poll_id": event.PollID.String()},
+		})
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoint+"/v1/"+p.Topic+":publish", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("pubsub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if p.account != nil {
+		token, err := p.accessToken(time.Now())
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pubsub request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("pubsub request: unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// accessToken returns a JWT signed by the service account for the Pub/Sub
+// API, valid for an hour. It is reused until shortly before it expires.
+func (p *PubSubSink) accessToken(now time.Time) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && now.Before(p.expires.Add(-5*time.Minute)) {
+		return p.token, nil
+	}
+
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": p.account.PrivateKeyID,
+	})
+	expires := now.Add(time.Hour)
+	claims, _ := json.Marshal(map[string]any{
+		"iss": p.account.ClientEmail,
+		"sub": p.account.ClientEmail,
+		"aud": "https://pubsub.googleapis.com/",
+		"iat": now.Unix(),
+		"exp": expires.Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.account.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("sign pubsub token: %w", err)
+	}
+
+	p.token = unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	p.expires = expires
+
+	return p.token, nil
+}
+
+func (p *PubSubSink) Close() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestKinesisSink(t *testing.T) {
+	var target, auth string
+	var input struct {
+		StreamName string          `json:"StreamName"`
+		Records    []kinesisRecord `json:"Records"`
+	}
+	failed := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, auth = r.Header.Get("X-Amz-Target"), r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		_ = json.NewEncoder(w).Encode(map[string]int{"FailedRecordCount": failed})
+	}))
+	defer srv.Close()
+
+	sink, err := NewKinesisSink(srv.URL, "eu-west-1", "polls", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []Event{View(data.ExamplePollIDValid, ""), View(data.ExamplePollIDOrg, "")}
+	if err := sink.Write(context.Background(), events); err != nil {
+		t.Fatalf("write returned an error: %s", err)
+	}
+	if target != "Kinesis_20131202.PutRecords" || input.StreamName != "polls" {
+		t.Errorf("expected records to be put to the stream, but got %q to %q", target, input.StreamName)
+	}
+	if !strings.Contains(auth, "/eu-west-1/kinesis/aws4_request") {
+		t.Errorf("expected a signed request, but got %q", auth)
+	}
+	if len(input.Records) != 2 || input.Records[1].PartitionKey != data.ExamplePollIDOrg.String() ||
+		!strings.Contains(string(input.Records[1].Data), events[1].EventID) {
+		t.Errorf("expected a record per event, keyed by poll, but got %+v", input.Records)
+	}
+
+	failed = 1
+	if err := sink.Write(context.Background(), events); err == nil {
+		t.Error("expected err for rejected records, but didn't get one")
+	}
+
+	if _, err := NewKinesisSink("", "eu-west-1", "", "key", "secret"); err == nil {
+		t.Error("expected err for missing stream")
+	}
+}
+
+func TestPubSubSink(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	credentials, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "polls@example.iam.gserviceaccount.com",
+		"private_key_id": "key1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+
+	var path, auth string
+	var input struct {
+		Messages []pubSubMessage `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		_, _ = w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer srv.Close()
+
+	sink, err := NewPubSubSink(srv.URL, "projects/acme/topics/polls", credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := Vote(&data.Vote{ID: 1, PollID: data.ExamplePollIDValid, OptionID: data.ExampleOptionID1})
+	if err := sink.Write(context.Background(), []Event{event}); err != nil {
+		t.Fatalf("write returned an error: %s", err)
+	}
+	if path != "/v1/projects/acme/topics/polls:publish" {
+		t.Errorf("expected the topic to be published to, but got %q", path)
+	}
+	if len(input.Messages) != 1 || input.Messages[0].Attributes["event_name"] != NameVote ||
+		!strings.Contains(string(input.Messages[0].Data), event.EventID) {
+		t.Errorf("expected a message per event, but got %+v", input.Messages)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected a JWT, but got %q", auth)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+		t.Errorf("expected the token to be signed by the service account: %s", err)
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if !strings.Contains(string(claims), `"aud":"https://pubsub.googleapis.com/"`) {
+		t.Errorf("expected the token to be for pubsub, but got %s", claims)
+	}
+
+	token, _ := sink.accessToken(time.Now())
+	if token != strings.TrimPrefix(auth, "Bearer ") {
+		t.Error("expected the token to be reused")
+	}
+	if renewed, _ := sink.accessToken(time.Now().Add(time.Hour)); renewed == token {
+		t.Error("expected an expiring token to be renewed")
+	}
+
+	if _, err := NewPubSubSink("", "polls", nil); err == nil {
+		t.Error("expected err for topic without project")
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const issuer = "polls"
+
+var ErrInvalidJWT = errors.New("invalid jwt")
+
+// Claims are the claims of a poll JWT. The subject is the poll ID.
+type Claims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// PollID returns the poll the claims are for. ParseJWT only returns claims
+// whose subject is a poll ID.
+func (c *Claims) PollID() uuid.UUID {
+	id, _ := uuid.Parse(c.Subject)
+	return id
+}
+
+// IsJWT reports whether a bearer token looks like a JWT rather than a poll
+// token, which never contains dots.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// IssueJWT returns a JWT granting the scope on the poll until the returned
+// expiry, signed with HMAC-SHA256.
+func IssueJWT(key []byte, pollID uuid.UUID, scope string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiry := now.Add(ttl).Truncate(time.Second)
+
+	claims := Claims{
+		Scope: scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   pollID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiry),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiry, nil
+}
+
+// ParseJWT verifies the token's signature and expiry and returns its claims.
+func ParseJWT(key []byte, token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidJWT
+	}
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return nil, ErrInvalidJWT
+	}
+
+	return &claims, nil
+}
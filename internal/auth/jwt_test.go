@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIssueAndParseJWT(t *testing.T) {
+	token, expiry, err := IssueJWT(testKey, testPollID, "results", time.Minute)
+	if err != nil {
+		t.Fatalf("issue returned an error: %s", err)
+	}
+	if !IsJWT(token) {
+		t.Errorf("expected %q to look like a jwt", token)
+	}
+	if expiry.Before(time.Now()) {
+		t.Errorf("expected expiry in the future, but got %s", expiry)
+	}
+
+	claims, err := ParseJWT(testKey, token)
+	if err != nil {
+		t.Fatalf("parse returned an error: %s", err)
+	}
+	if claims.PollID() != testPollID || claims.Scope != "results" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestParseJWT_invalid(t *testing.T) {
+	valid, _, _ := IssueJWT(testKey, testPollID, "results", time.Minute)
+	expired, _, _ := IssueJWT(testKey, testPollID, "results", -time.Minute)
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{
+		Scope: "manage",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   "e9da0ad7-6065-40de-8398-2514ce9c566f",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	notAPoll, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Scope: "manage",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   "not-a-poll",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString(testKey)
+
+	tests := []struct {
+		name  string
+		key   []byte
+		token string
+	}{
+		{"wrong key", []byte("another key, also 32 bytes long!"), valid},
+		{"expired", testKey, expired},
+		{"unsigned", testKey, none},
+		{"malformed", testKey, "a.b.c"},
+		{"subject isn't a poll", testKey, notAPoll},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseJWT(test.key, test.token); !errors.Is(err, ErrInvalidJWT) {
+				t.Errorf("expected %v, but got %v", ErrInvalidJWT, err)
+			}
+		})
+	}
+
+	if IsJWT("ZLCQIKYQ4MT7K2NJCRQWC4KMMU") {
+		t.Error("expected a poll token not to look like a jwt")
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// Permission is an action a request can be authorized for.
+type Permission string
+
+const (
+	ViewPolls     Permission = "view_polls"
+	CreatePoll    Permission = "create_poll"
+	EditPoll      Permission = "edit_poll"
+	ViewResults   Permission = "view_results"
+	ManageMembers Permission = "manage_members"
+	Vote          Permission = "vote"
+	PreviewPoll   Permission = "preview_poll"
+	KioskVote     Permission = "kiosk_vote"
+)
+
+// scopePermissions are granted by poll tokens and JWTs, on their poll only.
+var scopePermissions = map[string][]Permission{
+	data.ScopeManage:  {EditPoll, ViewResults, PreviewPoll},
+	data.ScopeResults: {ViewResults},
+	data.ScopeVote:    {Vote},
+	data.ScopePreview: {PreviewPoll},
+	data.ScopeKiosk:   {Vote, KioskVote},
+}
+
+// rolePermissions are granted to organization members, on the organization
+// and its polls.
+var rolePermissions = map[string][]Permission{
+	data.RoleViewer: {ViewPolls, ViewResults, PreviewPoll},
+	data.RoleEditor: {ViewPolls, ViewResults, PreviewPoll, CreatePoll, EditPoll},
+	data.RoleAdmin:  {ViewPolls, ViewResults, PreviewPoll, CreatePoll, EditPoll, ManageMembers},
+}
+
+// Principal is who a request is made by: the holder of a poll token or JWT,
+// or a member of an organization.
+type Principal struct {
+	PollID uuid.UUID
+	Scope  string
+	Member *data.Member
+}
+
+// Has reports whether the principal has the permission, regardless of the
+// resource it is wanted on.
+func (p *Principal) Has(perm Permission) bool {
+	granted := scopePermissions[p.Scope]
+	if p.Member != nil {
+		granted = rolePermissions[p.Member.Role]
+	}
+
+	for _, g := range granted {
+		if g == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// CanOnPoll reports whether the principal has the permission on a poll, which
+// belongs to the organization orgID or to none if it is nil.
+func (p *Principal) CanOnPoll(perm Permission, pollID uuid.UUID, orgID *uuid.UUID) bool {
+	if p.Member != nil {
+		return orgID != nil && p.Member.OrgID == *orgID && p.Has(perm)
+	}
+	return p.PollID == pollID && p.Has(perm)
+}
+
+// CanOnOrg reports whether the principal has the permission on an
+// organization. Only its members have permissions on it.
+func (p *Principal) CanOnOrg(perm Permission, orgID uuid.UUID) bool {
+	return p.Member != nil && p.Member.OrgID == orgID && p.Has(perm)
+}
+
+// ScopeIncludes reports whether a token with the scope has every permission
+// of the other scope, so it may be exchanged for a token with that scope.
+func ScopeIncludes(scope string, other string) bool {
+	for _, perm := range scopePermissions[other] {
+		if !(&Principal{Scope: scope}).Has(perm) {
+			return false
+		}
+	}
+	return scopePermissions[other] != nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+var (
+	testPollID = uuid.MustParse("e9da0ad7-6065-40de-8398-2514ce9c566f")
+	testOrgID  = uuid.MustParse("9b4e2c1a-7f3d-4e8b-a6c5-1d0f2e3b4a59")
+)
+
+func TestPrincipalCanOnPoll(t *testing.T) {
+	member := func(role string) *Principal {
+		return &Principal{Member: &data.Member{OrgID: testOrgID, Role: role}}
+	}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		perm      Permission
+		pollID    uuid.UUID
+		orgID     *uuid.UUID
+		expected  bool
+	}{
+		{"manage token edits", &Principal{PollID: testPollID, Scope: data.ScopeManage}, EditPoll, testPollID, nil, true},
+		{"manage token views results", &Principal{PollID: testPollID, Scope: data.ScopeManage}, ViewResults, testPollID, nil, true},
+		{"manage token doesn't vote", &Principal{PollID: testPollID, Scope: data.ScopeManage}, Vote, testPollID, nil, false},
+		{"manage token for another poll", &Principal{PollID: testPollID, Scope: data.ScopeManage}, EditPoll, testOrgID, nil, false},
+		{"results token views results", &Principal{PollID: testPollID, Scope: data.ScopeResults}, ViewResults, testPollID, nil, true},
+		{"results token doesn't edit", &Principal{PollID: testPollID, Scope: data.ScopeResults}, EditPoll, testPollID, nil, false},
+		{"vote token votes", &Principal{PollID: testPollID, Scope: data.ScopeVote}, Vote, testPollID, nil, true},
+		{"vote token isn't a kiosk", &Principal{PollID: testPollID, Scope: data.ScopeVote}, KioskVote, testPollID, nil, false},
+		{"kiosk token votes", &Principal{PollID: testPollID, Scope: data.ScopeKiosk}, Vote, testPollID, nil, true},
+		{"kiosk token votes repeatedly", &Principal{PollID: testPollID, Scope: data.ScopeKiosk}, KioskVote, testPollID, nil, true},
+		{"kiosk token doesn't view results", &Principal{PollID: testPollID, Scope: data.ScopeKiosk}, ViewResults, testPollID, nil, false},
+		{"preview token previews", &Principal{PollID: testPollID, Scope: data.ScopePreview}, PreviewPoll, testPollID, nil, true},
+		{"preview token doesn't view results", &Principal{PollID: testPollID, Scope: data.ScopePreview}, ViewResults, testPollID, nil, false},
+		{"manage token previews", &Principal{PollID: testPollID, Scope: data.ScopeManage}, PreviewPoll, testPollID, nil, true},
+		{"unknown scope", &Principal{PollID: testPollID, Scope: "admin"}, EditPoll, testPollID, nil, false},
+		{"viewer views results", member(data.RoleViewer), ViewResults, testPollID, &testOrgID, true},
+		{"viewer doesn't edit", member(data.RoleViewer), EditPoll, testPollID, &testOrgID, false},
+		{"editor edits", member(data.RoleEditor), EditPoll, testPollID, &testOrgID, true},
+		{"admin edits", member(data.RoleAdmin), EditPoll, testPollID, &testOrgID, true},
+		{"editor on a poll of another organization", member(data.RoleEditor), EditPoll, testPollID, &testPollID, false},
+		{"editor on a poll without organization", member(data.RoleEditor), EditPoll, testPollID, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.principal.CanOnPoll(test.perm, test.pollID, test.orgID); got != test.expected {
+				t.Errorf("expected %t, but got %t", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrincipalCanOnOrg(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *Principal
+		perm      Permission
+		orgID     uuid.UUID
+		expected  bool
+	}{
+		{"admin manages members", &Principal{Member: &data.Member{OrgID: testOrgID, Role: data.RoleAdmin}}, ManageMembers, testOrgID, true},
+		{"editor doesn't manage members", &Principal{Member: &data.Member{OrgID: testOrgID, Role: data.RoleEditor}}, ManageMembers, testOrgID, false},
+		{"editor creates polls", &Principal{Member: &data.Member{OrgID: testOrgID, Role: data.RoleEditor}}, CreatePoll, testOrgID, true},
+		{"another organization", &Principal{Member: &data.Member{OrgID: testOrgID, Role: data.RoleAdmin}}, ViewPolls, testPollID, false},
+		{"poll token", &Principal{PollID: testPollID, Scope: data.ScopeManage}, ViewPolls, testOrgID, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.principal.CanOnOrg(test.perm, test.orgID); got != test.expected {
+				t.Errorf("expected %t, but got %t", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestScopeIncludes(t *testing.T) {
+	tests := []struct {
+		scope    string
+		other    string
+		expected bool
+	}{
+		{data.ScopeManage, data.ScopeManage, true},
+		{data.ScopeManage, data.ScopeResults, true},
+		{data.ScopeManage, data.ScopeVote, false},
+		{data.ScopeResults, data.ScopeManage, false},
+		{data.ScopeVote, data.ScopeVote, true},
+		{data.ScopeManage, "unknown", false},
+	}
+
+	for _, test := range tests {
+		if got := ScopeIncludes(test.scope, test.other); got != test.expected {
+			t.Errorf("ScopeIncludes(%q, %q): expected %t, but got %t", test.scope, test.other, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package ballots commits to the votes on verifiable polls. Each vote gets a
+// ballot, a salted hash of what was voted for, and the ballots of a poll
+// form a Merkle tree whose root is published, so voters can prove their
+// ballot was counted and auditors can check that none were changed.
+package ballots
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidBallot = errors.New("invalid ballot")
+
+// Ballot is given to voters when they vote. Only the hash is stored, so
+// only the voter can show which option it is for, by hashing the poll ID,
+// option ID and nonce again.
+type Ballot struct {
+	Hash  string `json:"hash"`
+	Nonce string `json:"nonce"`
+}
+
+// New creates the ballot of a vote for the option.
+func New(pollID, optionID string) (*Ballot, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("new ballot: %w", err)
+	}
+
+	ballot := &Ballot{Nonce: hex.EncodeToString(nonce)}
+	ballot.Hash = Hash(pollID, optionID, ballot.Nonce)
+	return ballot, nil
+}
+
+// Hash is the hex encoded SHA-256 of a 0 byte followed by
+// "<poll ID>:<option ID>:<nonce>". The prefix keeps ballots apart from the
+// tree's nodes.
+func Hash(pollID, optionID, nonce string) string {
+	sum := sha256.Sum256([]byte("\x00" + pollID + ":" + optionID + ":" + nonce))
+	return hex.EncodeToString(sum[:])
+}
+
+// Tree is the Merkle tree of a poll's ballots. Each node is the SHA-256 of
+// a 1 byte followed by its children, and the last node of a level with an
+// odd number of nodes is carried up to the next level as it is.
+type Tree struct {
+	// levels holds the nodes from the ballots up to the root.
+	levels [][][]byte
+}
+
+// NewTree builds the tree of the hex encoded ballot hashes, in the order
+// they are given.
+func NewTree(hashes []string) (*Tree, error) {
+	leaves := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		leaf, err := hex.DecodeString(hash)
+		if err != nil || len(leaf) != sha256.Size {
+			return nil, ErrInvalidBallot
+		}
+		leaves[i] = leaf
+	}
+
+	tree := &Tree{levels: [][][]byte{leaves}}
+	for level := leaves; len(level) > 1; {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, node(level[i], level[i+1]))
+		}
+		tree.levels = append(tree.levels, next)
+		level = next
+	}
+
+	return tree, nil
+}
+
+// Root is the hex encoded root of the tree. The root of a tree without
+// ballots is the SHA-256 of nothing.
+func (t *Tree) Root() string {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:])
+	}
+	return hex.EncodeToString(top[0])
+}
+
+// Step is a sibling on the path from a ballot to the root.
+type Step struct {
+	Hash string `json:"hash"`
+	// Side is which side the sibling is on, "left" or "right".
+	Side string `json:"side"`
+}
+
+// Proof shows that a ballot is in a tree with the root.
+type Proof struct {
+	Ballot string `json:"ballot"`
+	// Index is the position of the ballot in the tree, starting at 0.
+	Index int    `json:"index"`
+	Root  string `json:"root"`
+	Path  []Step `json:"path"`
+}
+
+// Prove returns the proof for the hex encoded ballot hash, or false if the
+// ballot is not in the tree.
+func (t *Tree) Prove(hash string) (*Proof, bool) {
+	index := -1
+	for i, leaf := range t.levels[0] {
+		if hex.EncodeToString(leaf) == hash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, false
+	}
+
+	proof := &Proof{Ballot: hash, Index: index, Root: t.Root(), Path: []Step{}}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		switch {
+		case index%2 == 1:
+			proof.Path = append(proof.Path, Step{Hash: hex.EncodeToString(level[index-1]), Side: "left"})
+		case index+1 < len(level):
+			proof.Path = append(proof.Path, Step{Hash: hex.EncodeToString(level[index+1]), Side: "right"})
+		}
+		index /= 2
+	}
+
+	return proof, true
+}
+
+// Verify reports whether the proof's path leads from its ballot to its
+// root.
+func Verify(proof *Proof) bool {
+	current, err := hex.DecodeString(proof.Ballot)
+	if err != nil {
+		return false
+	}
+	for _, step := range proof.Path {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false
+		}
+		switch step.Side {
+		case "left":
+			current = node(sibling, current)
+		case "right":
+			current = node(current, sibling)
+		default:
+			return false
+		}
+	}
+	return hex.EncodeToString(current) == proof.Root
+}
+
+func node(left, right []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{0x01}, left...), right...))
+	return sum[:]
+}
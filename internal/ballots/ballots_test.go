@@ -0,0 +1,95 @@
+package ballots
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	ballot, err := New("poll", "option")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ballot.Hash != Hash("poll", "option", ballot.Nonce) {
+		t.Errorf("expected the hash of the poll, option and nonce, but got %s", ballot.Hash)
+	}
+
+	other, _ := New("poll", "option")
+	if other.Hash == ballot.Hash {
+		t.Error("expected ballots for the same option to differ")
+	}
+}
+
+func TestTree(t *testing.T) {
+	for n := 0; n <= 9; n++ {
+		t.Run(fmt.Sprintf("%d ballots", n), func(t *testing.T) {
+			hashes := make([]string, n)
+			for i := range hashes {
+				hashes[i] = Hash("poll", "option", fmt.Sprint(i))
+			}
+
+			tree, err := NewTree(hashes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, hash := range hashes {
+				proof, ok := tree.Prove(hash)
+				if !ok {
+					t.Fatalf("expected a proof for ballot %d", i)
+				}
+				if proof.Index != i || proof.Root != tree.Root() {
+					t.Errorf("expected ballot %d under the root, but got %+v", i, proof)
+				}
+				if !Verify(proof) {
+					t.Errorf("expected the proof of ballot %d to verify", i)
+				}
+			}
+
+			if _, ok := tree.Prove(Hash("poll", "option", "missing")); ok {
+				t.Error("expected no proof for a ballot not in the tree")
+			}
+		})
+	}
+}
+
+func TestTree_root(t *testing.T) {
+	a, b, c := Hash("p", "o", "a"), Hash("p", "o", "b"), Hash("p", "o", "c")
+
+	abc, _ := NewTree([]string{a, b, c})
+	acb, _ := NewTree([]string{a, c, b})
+	ab, _ := NewTree([]string{a, b})
+
+	if abc.Root() == acb.Root() {
+		t.Error("expected the root to change with the order of the ballots")
+	}
+	if abc.Root() == ab.Root() {
+		t.Error("expected the root to change with the ballots")
+	}
+}
+
+func TestVerify_tampered(t *testing.T) {
+	hashes := []string{Hash("p", "o", "a"), Hash("p", "o", "b"), Hash("p", "o", "c")}
+	tree, _ := NewTree(hashes)
+
+	proof, _ := tree.Prove(hashes[2])
+	proof.Ballot = Hash("p", "other", "c")
+	if Verify(proof) {
+		t.Error("expected a changed ballot not to verify")
+	}
+
+	proof, _ = tree.Prove(hashes[0])
+	proof.Path[0].Side = "left"
+	if Verify(proof) {
+		t.Error("expected a changed path not to verify")
+	}
+}
+
+func TestNewTree_invalid(t *testing.T) {
+	for _, hash := range []string{"not hex", "abcd"} {
+		if _, err := NewTree([]string{hash}); !errors.Is(err, ErrInvalidBallot) {
+			t.Errorf("expected %v for %q, but got %v", ErrInvalidBallot, hash, err)
+		}
+	}
+}
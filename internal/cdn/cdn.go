@@ -0,0 +1,116 @@
+// Package cdn purges cached responses from a CDN by surrogate key, also
+// known as cache tag, so changed polls are fetched from the API again.
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Purger removes the responses tagged with any of the keys from a CDN's
+// cache.
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// PollKey is the surrogate key of the responses about a poll.
+func PollKey(pollID string) string {
+	return "poll-" + pollID
+}
+
+// Queue collects keys and purges them together every interval, so a poll
+// receiving many votes is purged once per interval rather than once per
+// vote.
+type Queue struct {
+	purger  Purger
+	onError func(error)
+
+	mu   sync.Mutex
+	keys map[string]bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewQueue purges the queued keys every interval, passing errors to onError.
+func NewQueue(purger Purger, interval time.Duration, onError func(error)) *Queue {
+	q := &Queue{
+		purger:  purger,
+		onError: onError,
+		keys:    make(map[string]bool),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(q.stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				q.Flush()
+			case <-q.done:
+				return
+			}
+		}
+	}()
+
+	return q
+}
+
+func (q *Queue) Add(keys ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, key := range keys {
+		q.keys[key] = true
+	}
+}
+
+// Flush purges the queued keys.
+func (q *Queue) Flush() {
+	q.mu.Lock()
+	keys := make([]string, 0, len(q.keys))
+	for key := range q.keys {
+		keys = append(keys, key)
+	}
+	q.keys = make(map[string]bool)
+	q.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := q.purger.Purge(ctx, keys); err != nil {
+		q.onError(fmt.Errorf("cdn: purge %d keys: %w", len(keys), err))
+	}
+}
+
+// Close purges the remaining keys.
+func (q *Queue) Close() {
+	close(q.done)
+	<-q.stopped
+	q.Flush()
+}
+
+// chunks splits the keys into slices of at most size keys, as CDN APIs
+// limit how many keys one request may purge.
+func chunks(keys []string, size int) [][]string {
+	var out [][]string
+	for len(keys) > size {
+		out = append(out, keys[:size])
+		keys = keys[size:]
+	}
+	if len(keys) > 0 {
+		out = append(out, keys)
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingPurger struct {
+	mu     sync.Mutex
+	purges [][]string
+}
+
+func (r *recordingPurger) Purge(ctx context.Context, keys []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sort.Strings(keys)
+	r.purges = append(r.purges, keys)
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	purger := &recordingPurger{}
+	queue := NewQueue(purger, time.Hour, func(err error) { t.Error(err) })
+
+	queue.Add(PollKey("a"))
+	queue.Add(PollKey("b"), PollKey("a"))
+	queue.Flush()
+	queue.Flush()
+
+	if len(purger.purges) != 1 || strings.Join(purger.purges[0], " ") != "poll-a poll-b" {
+		t.Errorf("expected the keys to be purged once together, but got %v", purger.purges)
+	}
+
+	queue.Add(PollKey("c"))
+	queue.Close()
+	if len(purger.purges) != 2 || purger.purges[1][0] != "poll-c" {
+		t.Errorf("expected the remaining keys to be purged on close, but got %v", purger.purges)
+	}
+}
+
+func keys(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = PollKey(fmt.Sprint(i))
+	}
+	return out
+}
+
+func TestFastlyPurger(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.Header.Get("Fastly-Key") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	purger := NewFastlyPurger("svc1", "token")
+	purger.Endpoint = srv.URL
+
+	if err := purger.Purge(context.Background(), keys(300)); err != nil {
+		t.Fatalf("purge returned an error: %s", err)
+	}
+	if len(requests) != 2 || requests[0].URL.Path != "/service/svc1/purge" {
+		t.Fatalf("expected 2 purge requests to the service, but got %d", len(requests))
+	}
+	if n := len(strings.Fields(requests[0].Header.Get("Surrogate-Key"))); n != 256 {
+		t.Errorf("expected 256 keys in the first request, but got %d", n)
+	}
+
+	purger.Token = "wrong"
+	if err := purger.Purge(context.Background(), keys(1)); err == nil {
+		t.Error("expected err for bad status, but didn't get one")
+	}
+}
+
+func TestCloudflarePurger(t *testing.T) {
+	var tags [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/zones/zone1/purge_cache" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"success":false}`))
+			return
+		}
+		var input struct {
+			Tags []string `json:"tags"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		tags = append(tags, input.Tags)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	purger := NewCloudflarePurger("zone1", "token")
+	purger.Endpoint = srv.URL
+
+	if err := purger.Purge(context.Background(), keys(31)); err != nil {
+		t.Fatalf("purge returned an error: %s", err)
+	}
+	if len(tags) != 2 || len(tags[0]) != 30 || tags[1][0] != PollKey("30") {
+		t.Errorf("expected the tags to be purged 30 at a time, but got %v", tags)
+	}
+
+	purger.Token = "wrong"
+	if err := purger.Purge(context.Background(), keys(1)); err == nil {
+		t.Error("expected err for failed purge, but didn't get one")
+	}
+}
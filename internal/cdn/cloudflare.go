@@ -0,0 +1,62 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CloudflarePurger purges cache tags from a Cloudflare zone.
+type CloudflarePurger struct {
+	Endpoint string
+	ZoneID   string
+	Token    string
+	Client   *http.Client
+}
+
+func NewCloudflarePurger(zoneID, token string) *CloudflarePurger {
+	return &CloudflarePurger{
+		Endpoint: "https://api.cloudflare.com/client/v4",
+		ZoneID:   zoneID,
+		Token:    token,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *CloudflarePurger) Purge(ctx context.Context, keys []string) error {
+	// Cloudflare purges up to 30 tags per request
+	for _, chunk := range chunks(keys, 30) {
+		body, err := json.Marshal(map[string][]string{"tags": chunk})
+		if err != nil {
+			return err
+		}
+
+		u := c.Endpoint + "/zones/" + url.PathEscape(c.ZoneID) + "/purge_cache"
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("cloudflare request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := c.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("cloudflare request: %w", err)
+		}
+
+		var output struct {
+			Success bool `json:"success"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&output)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK || err != nil || !output.Success {
+			return fmt.Errorf("cloudflare request: purge failed with status %d", res.StatusCode)
+		}
+	}
+
+	return nil
+}
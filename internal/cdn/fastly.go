@@ -0,0 +1,53 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FastlyPurger purges surrogate keys from a Fastly service.
+type FastlyPurger struct {
+	Endpoint  string
+	ServiceID string
+	Token     string
+	Client    *http.Client
+}
+
+func NewFastlyPurger(serviceID, token string) *FastlyPurger {
+	return &FastlyPurger{
+		Endpoint:  "https://api.fastly.com",
+		ServiceID: serviceID,
+		Token:     token,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (f *FastlyPurger) Purge(ctx context.Context, keys []string) error {
+	// Fastly purges up to 256 keys per request
+	for _, chunk := range chunks(keys, 256) {
+		u := f.Endpoint + "/service/" + url.PathEscape(f.ServiceID) + "/purge"
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+		if err != nil {
+			return fmt.Errorf("fastly request: %w", err)
+		}
+		req.Header.Set("Fastly-Key", f.Token)
+		req.Header.Set("Surrogate-Key", strings.Join(chunk, " "))
+		req.Header.Set("Accept", "application/json")
+
+		res, err := f.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("fastly request: %w", err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("fastly request: unexpected status %d", res.StatusCode)
+		}
+	}
+
+	return nil
+}
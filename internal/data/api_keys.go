@@ -0,0 +1,221 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apiKeyPrefix starts every API key, so they can be told apart from tokens
+// and found by secret scanners.
+const apiKeyPrefix = "polls_"
+
+// APIKey lets a server act for an organization with a role, like a member,
+// without a person's token. Keys are "polls_", their prefix, "_" and a secret.
+// The prefix is stored to look the key up, and the whole key only as a hash.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	OrgID      uuid.UUID  `json:"org_id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Plaintext  string     `json:"-"`
+	Hash       []byte     `json:"-"`
+}
+
+// GenerateAPIKey returns a new key with its plaintext, prefix and hash set.
+func GenerateAPIKey() (*APIKey, error) {
+	randomBytes := make([]byte, 25)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	key := &APIKey{Prefix: encoding.EncodeToString(randomBytes[:5])}
+	key.Plaintext = apiKeyPrefix + key.Prefix + "_" + encoding.EncodeToString(randomBytes[5:])
+	key.Hash = HashToken(key.Plaintext)
+
+	return key, nil
+}
+
+// APIKeyPrefix returns the prefix of the API key, and whether it is shaped
+// like one.
+func APIKeyPrefix(plaintext string) (string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 8 || len(secret) != 32 {
+		return "", false
+	}
+	return prefix, true
+}
+
+// Member returns the organization member the key acts as. Members of keys
+// have no ID, and are named after the key.
+func (k *APIKey) Member() *Member {
+	return &Member{OrgID: k.OrgID, Name: k.Name, Role: k.Role, CreatedAt: k.CreatedAt}
+}
+
+// ValidateAPIKey checks the key's name and role. Keys can't manage members
+// or other keys, so they can only be editors or viewers.
+func ValidateAPIKey(v *validator.Validator, key *APIKey) {
+	v.Check(key.Name != "", "name", "must not be empty")
+	v.Check(len(key.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(validator.PermittedValue(key.Role, RoleEditor, RoleViewer), "role", "must be editor or viewer")
+}
+
+type APIKeyModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert stores the key's hash and prefix for its organization.
+func (m APIKeyModel) Insert(key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (org_id, name, role, prefix, hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, query, key.OrgID, key.Name, key.Role, key.Prefix, key.Hash).Scan(
+		&key.ID,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetForKey returns the unrevoked key the plaintext is, looked up by its
+// prefix, and records that it was used. Uses are recorded at most once a
+// minute, so busy keys don't write on every request.
+func (m APIKeyModel) GetForKey(plaintext string) (*APIKey, error) {
+	prefix, ok := APIKeyPrefix(plaintext)
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		WITH found AS (
+			SELECT id, org_id, name, role, prefix, created_at, last_used_at
+			FROM api_keys
+			WHERE prefix = $1 AND hash = $2 AND revoked_at IS NULL
+		), used AS (
+			UPDATE api_keys
+			SET last_used_at = NOW()
+			FROM found
+			WHERE api_keys.id = found.id
+			AND (api_keys.last_used_at IS NULL OR api_keys.last_used_at < NOW() - INTERVAL '1 minute')
+		)
+		SELECT id, org_id, name, role, prefix, created_at, last_used_at
+		FROM found;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	key := APIKey{Plaintext: plaintext, Hash: HashToken(plaintext)}
+	err := m.DB.QueryRow(ctx, query, prefix, key.Hash).Scan(
+		&key.ID,
+		&key.OrgID,
+		&key.Name,
+		&key.Role,
+		&key.Prefix,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// GetAll lists the organization's unrevoked keys, newest first.
+func (m APIKeyModel) GetAll(orgID uuid.UUID) ([]*APIKey, error) {
+	query := `
+		SELECT id, org_id, name, role, prefix, created_at, last_used_at
+		FROM api_keys
+		WHERE org_id = $1 AND revoked_at IS NULL
+		ORDER BY id DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*APIKey{}
+	for rows.Next() {
+		var key APIKey
+		err := rows.Scan(
+			&key.ID,
+			&key.OrgID,
+			&key.Name,
+			&key.Role,
+			&key.Prefix,
+			&key.CreatedAt,
+			&key.LastUsedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get api keys - scan: %w", err)
+		}
+		keys = append(keys, &key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke stops the organization's key from authenticating. ErrRecordNotFound
+// is returned if the organization has no such unrevoked key.
+func (m APIKeyModel) Revoke(orgID uuid.UUID, id int64) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND org_id = $2 AND revoked_at IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, id, orgID)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Actions recorded in a poll's audit log.
+const (
+	ActionPollCreated      = "poll.created"
+	ActionPollImported     = "poll.imported"
+	ActionPollUpdated      = "poll.updated"
+	ActionPollTransferred  = "poll.transferred"
+	ActionPollPublished    = "poll.published"
+	ActionPollPaused       = "poll.paused"
+	ActionPollResumed      = "poll.resumed"
+	ActionPollFlagged      = "poll.flagged"
+	ActionPollModerated    = "poll.moderated"
+	ActionPollRemoved      = "poll.removed"
+	ActionPollRestored     = "poll.restored"
+	ActionOptionAdded      = "option.added"
+	ActionOptionUpdated    = "option.updated"
+	ActionOptionsReordered = "options.reordered"
+	ActionOptionDeleted    = "option.deleted"
+	ActionOptionRestored   = "option.restored"
+	ActionOptionsMerged    = "options.merged"
+	ActionVoteCast         = "vote.cast"
+	ActionVoteModerated    = "vote.moderated"
+	ActionTokenRotated     = "token.rotated"
+	ActionTokensRevoked    = "tokens.revoked"
+)
+
+// Actors of audit log entries that weren't made by an organization member.
+const (
+	ActorOwner     = "owner"
+	ActorVoter     = "voter"
+	ActorModerator = "moderator"
+)
+
+// Activity is an entry of a poll's audit log. Actor is "owner" for changes
+// made with the poll's token, the name of the organization member who made
+// them, "voter" or "moderator". Votes are recorded without anything
+// identifying the voter.
+type Activity struct {
+	ID        int64          `json:"id"`
+	PollID    uuid.UUID      `json:"poll_id"`
+	Action    string         `json:"action"`
+	Actor     string         `json:"actor"`
+	Details   map[string]any `json:"details"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type AuditLogModel struct {
+	DB *pgxpool.Pool
+}
+
+func (a AuditLogModel) Insert(activity *Activity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return insertActivity(ctx, a.DB, activity)
+}
+
+// GetForPoll returns a page of the poll's audit log.
+func (a AuditLogModel) GetForPoll(pollID uuid.UUID, filters Filters) ([]*Activity, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, poll_id, action, actor, details, created_at
+		FROM audit_log
+		WHERE poll_id = $1
+		ORDER BY %s %s, id %[2]s
+		LIMIT $2 OFFSET $3;
+	`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := a.DB.Query(ctx, query, pollID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	activities := []*Activity{}
+	for rows.Next() {
+		var activity Activity
+		err := rows.Scan(
+			&totalRecords,
+			&activity.ID,
+			&activity.PollID,
+			&activity.Action,
+			&activity.Actor,
+			&activity.Details,
+			&activity.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("get audit log - scan: %w", err)
+		}
+		activities = append(activities, &activity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, fmt.Errorf("get audit log: %w", err)
+	}
+
+	return activities, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+func insertActivity(ctx context.Context, db querier, activity *Activity) error {
+	query := `
+		INSERT INTO audit_log (poll_id, action, actor, details)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	details := activity.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	err := db.QueryRow(ctx, query, activity.PollID, activity.Action, activity.Actor, details).
+		Scan(&activity.ID, &activity.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert activity: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Kinds of bans.
+const (
+	BanIP     = "ip"
+	BanAPIKey = "api_key"
+)
+
+// Ban stops an IP, or anyone using an API key, from creating polls. API keys
+// are the tokens of organization members and only their hash is stored, so
+// the key of a ban can't be listed.
+type Ban struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	IP        string    `json:"ip,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	KeyHash   []byte    `json:"-"`
+}
+
+// ValidateBan checks the ban's IP, or the plaintext of the API key to ban.
+func ValidateBan(v *validator.Validator, ban *Ban, apiKey string) {
+	v.Check(ban.IP != "" || apiKey != "", "ip", "must be provided unless api_key is")
+	v.Check(ban.IP == "" || apiKey == "", "api_key", "must not be set together with ip")
+	if ban.IP != "" {
+		v.Check(net.ParseIP(ban.IP) != nil, "ip", "must be a valid IP address")
+	}
+	if apiKey != "" {
+		v.Check(len(apiKey) == 26, "api_key", "must be 26 bytes long")
+	}
+	v.Check(len(ban.Reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+// NormalizeIP returns the IP in the form bans store it, so the same address
+// matches however it was written.
+func NormalizeIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+type BanModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert bans the IP or the API key hash of the ban. ErrAlreadyBanned is
+// returned if it is banned already.
+func (m BanModel) Insert(ban *Ban) error {
+	query := `
+		INSERT INTO bans (ip, key_hash, reason)
+		VALUES (NULLIF($1, ''), $2, $3)
+		RETURNING id, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if ban.IP != "" {
+		ban.Kind = BanIP
+		ban.IP = NormalizeIP(ban.IP)
+	} else {
+		ban.Kind = BanAPIKey
+	}
+
+	err := m.DB.QueryRow(ctx, query, ban.IP, ban.KeyHash, ban.Reason).Scan(&ban.ID, &ban.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err, "bans_ip_key") || uniqueViolation(err, "bans_key_hash_key") {
+			return ErrAlreadyBanned
+		}
+		return fmt.Errorf("insert ban: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll lists the bans, newest first.
+func (m BanModel) GetAll() ([]*Ban, error) {
+	query := `
+		SELECT id, COALESCE(ip, ''), reason, created_at
+		FROM bans
+		ORDER BY id DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get bans: %w", err)
+	}
+	defer rows.Close()
+
+	bans := []*Ban{}
+	for rows.Next() {
+		var ban Ban
+		err := rows.Scan(&ban.ID, &ban.IP, &ban.Reason, &ban.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get bans - scan: %w", err)
+		}
+		ban.Kind = BanIP
+		if ban.IP == "" {
+			ban.Kind = BanAPIKey
+		}
+		bans = append(bans, &ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+// Banned reports whether the IP or the API key hash is banned. Either may be
+// empty.
+func (m BanModel) Banned(ip string, keyHash []byte) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM bans
+			WHERE ip = NULLIF($1, '') OR key_hash = $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var banned bool
+	err := m.DB.QueryRow(ctx, query, NormalizeIP(ip), keyHash).Scan(&banned)
+	if err != nil {
+		return false, fmt.Errorf("check ban: %w", err)
+	}
+
+	return banned, nil
+}
+
+// Delete lifts the ban.
+func (m BanModel) Delete(id int64) error {
+	query := `
+		DELETE FROM bans
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete ban: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -8,11 +8,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/search"
+	"github.com/ivcp/polls/internal/validator"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/ory/dockertest/v3"
@@ -33,6 +40,8 @@ var (
 	pool       *dockertest.Pool
 	testDB     *pgxpool.Pool
 	testModels Models
+	// visitorKey is the key views and reports hash IPs with.
+	visitorKey = []byte("test visitor key")
 )
 
 func TestMain(m *testing.M) {
@@ -110,7 +119,7 @@ func Test_pingDB(t *testing.T) {
 	}
 }
 
-func createPollAndGenerateToken(t *testing.T) (*Poll, *Token) {
+func createPollAndGenerateToken(t testing.TB) (*Poll, *Token) {
 	t.Helper()
 	poll := Poll{
 		Question: "Test?",
@@ -136,7 +145,7 @@ func TestPollsInsert(t *testing.T) {
 		t.Errorf("insert poll returned an error: %s", err)
 	}
 
-	if poll.ID == "" {
+	if poll.ID == uuid.Nil {
 		t.Errorf("expected id not to be zero value but got %s", poll.ID)
 	}
 
@@ -145,12 +154,12 @@ func TestPollsInsert(t *testing.T) {
 	}
 
 	for _, opt := range poll.Options {
-		if opt.ID == "" {
+		if opt.ID == uuid.Nil {
 			t.Errorf("expected option id not to be zero: %s %s", opt.Value, opt.ID)
 		}
 	}
 
-	_, err := testModels.Polls.CheckToken(token.Plaintext)
+	_, _, err := testModels.Polls.CheckToken(token.Plaintext)
 	if err != nil {
 		if errors.Is(err, ErrRecordNotFound) {
 			t.Errorf("token hash not inserted")
@@ -164,8 +173,68 @@ func TestPollsInsert(t *testing.T) {
 	}
 }
 
+func TestPollsInsertTimeOrderedIDs(t *testing.T) {
+	var ids []uuid.UUID
+	for i := 0; i < 2; i++ {
+		poll, token := createPollAndGenerateToken(t)
+		_ = testModels.Polls.Insert(poll, token.Hash)
+		defer testModels.Polls.Delete(poll.ID)
+
+		for _, id := range []uuid.UUID{poll.ID, poll.Options[0].ID} {
+			if v := id.Version(); v != 7 {
+				t.Errorf("expected a version 7 id, but got version %d: %s", v, id)
+			}
+		}
+		ids = append(ids, poll.ID)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if ids[0].String() >= ids[1].String() {
+		t.Errorf("expected ids to sort in the order polls were created, but got %s and %s", ids[0], ids[1])
+	}
+}
+
+func TestPollsGetByIDs(t *testing.T) {
+	var ids []uuid.UUID
+	for i := 0; i < 2; i++ {
+		poll, token := createPollAndGenerateToken(t)
+		_ = testModels.Polls.Insert(poll, token.Hash)
+		defer testModels.Polls.Delete(poll.ID)
+		ids = append(ids, poll.ID)
+	}
+
+	polls, err := testModels.Polls.GetByIDs([]uuid.UUID{ids[1], uuid.MustParse("00000000-0000-4000-8000-000000000000"), ids[0]})
+	if err != nil {
+		t.Fatalf("get polls by ids returned an error: %s", err)
+	}
+
+	if len(polls) != 2 {
+		t.Fatalf("expected 2 polls, but got %d", len(polls))
+	}
+	if polls[0].ID != ids[1] || polls[1].ID != ids[0] {
+		t.Errorf("expected polls in the order of the ids, but got %s and %s", polls[0].ID, polls[1].ID)
+	}
+	for _, poll := range polls {
+		if len(poll.Options) != 3 {
+			t.Errorf("expected 3 options of poll %s, but got %d", poll.ID, len(poll.Options))
+		}
+		for i, option := range poll.Options {
+			if option.Position != i {
+				t.Errorf("expected option at position %d, but got %d", i, option.Position)
+			}
+		}
+	}
+
+	polls, err = testModels.Polls.GetByIDs(nil)
+	if err != nil || len(polls) != 0 {
+		t.Errorf("expected no polls without ids, but got %d and error %v", len(polls), err)
+	}
+}
+
 func TestPollsGet(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
+	poll.ResultsThreshold = 5
+	poll.TieBreak = "earliest"
 	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
 		t.Errorf("insert poll returned an error: %s", err)
 	}
@@ -178,18 +247,34 @@ func TestPollsGet(t *testing.T) {
 	if p.Question != "Test?" {
 		t.Errorf("get poll returned wrong question: expected 'Test?' but got %s", poll.Question)
 	}
+	if p.ResultsThreshold != 5 {
+		t.Errorf("expected results threshold 5, but got %d", p.ResultsThreshold)
+	}
+	if p.TieBreak != "earliest" {
+		t.Errorf("expected tie break earliest, but got %q", p.TieBreak)
+	}
 
-	_, err = testModels.Polls.Get("badID")
-	if err == nil {
-		t.Errorf("expected error on bad id")
+	err = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.0"})
+	if err != nil {
+		t.Fatalf("vote option returned an error: %s", err)
+	}
+	p, err = testModels.Polls.Get(poll.ID)
+	if err != nil {
+		t.Fatalf("get poll returned an error: %s", err)
+	}
+	if p.TotalVotes() != 1 {
+		t.Errorf("expected the options' vote counts to be read, but got %d votes", p.TotalVotes())
+	}
+	if p.Options[0].Percent != 100 || p.Options[1].Percent != 0 {
+		t.Errorf("expected the options' percents to be calculated, but got %v and %v", p.Options[0].Percent, p.Options[1].Percent)
 	}
 
-	_, err = testModels.Polls.Get("")
+	_, err = testModels.Polls.Get(uuid.Nil)
 	if err == nil {
-		t.Errorf("expected error on empty string id")
+		t.Errorf("expected error on nil id")
 	}
 
-	_, err = testModels.Polls.Get(uuid.New().String())
+	_, err = testModels.Polls.Get(uuid.New())
 	if !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on non-existent poll")
 	}
@@ -236,6 +321,13 @@ func TestPollsUpdate(t *testing.T) {
 	if updatedPoll.UpdatedAt.Equal(oldUpdatedAt) {
 		t.Errorf("expected updated at to be changed")
 	}
+
+	// the poll read before the update is stale
+	stale, _ := testModels.Polls.Get(p.ID)
+	stale.Version = updatedPoll.Version - 1
+	if err := testModels.Polls.Update(stale); !errors.Is(err, ErrEditConflict) {
+		t.Errorf("expected ErrEditConflict, but got %v", err)
+	}
 	_ = testModels.Polls.Delete(updatedPoll.ID)
 }
 
@@ -244,10 +336,10 @@ func TestPollsDelete(t *testing.T) {
 	_ = testModels.Polls.Insert(poll, token.Hash)
 	p, _ := testModels.Polls.Get(poll.ID)
 
-	if err := testModels.Polls.Delete(uuid.New().String()); !errors.Is(err, ErrRecordNotFound) {
+	if err := testModels.Polls.Delete(uuid.New()); !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on non-existent poll")
 	}
-	if err := testModels.Polls.Delete(""); !errors.Is(err, ErrRecordNotFound) {
+	if err := testModels.Polls.Delete(uuid.Nil); !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on bad poll id")
 	}
 
@@ -260,6 +352,69 @@ func TestPollsDelete(t *testing.T) {
 	}
 }
 
+func TestPollsExportImport(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+	p, _ := testModels.Polls.Get(poll.ID)
+
+	votes := []*Vote{
+		{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.0", VoterName: "Jane"},
+		{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.1", Demographics: map[string]string{"team": "Sales"}},
+		{OptionID: p.Options[1].ID, PollID: p.ID, IP: "0.0.0.2", Status: VoteStatusSuspect},
+	}
+	for _, vote := range votes {
+		if err := testModels.PollOptions.Vote(vote); err != nil {
+			t.Fatalf("vote option returned an error: %s", err)
+		}
+	}
+
+	exported, err := testModels.Votes.GetAll(p.ID)
+	if err != nil {
+		t.Fatalf("get all votes returned an error: %s", err)
+	}
+	if len(exported) != 3 || exported[0].VoterName != "Jane" || exported[1].Demographics["team"] != "Sales" {
+		t.Fatalf("expected the 3 votes in order, but got %+v", exported)
+	}
+
+	export := &PollExport{Version: ExportVersion, Poll: p, Votes: exported}
+	newToken, _ := GenerateToken()
+	if err := testModels.Polls.Import(export, newToken.Hash); err != nil {
+		t.Fatalf("import returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(p.ID)
+	if p.ID == poll.ID {
+		t.Fatal("expected the imported poll to get a new id")
+	}
+
+	imported, err := testModels.Polls.Get(p.ID)
+	if err != nil {
+		t.Fatalf("get imported poll returned an error: %s", err)
+	}
+	if imported.Question != poll.Question || len(imported.Options) != 3 || imported.VotesCast != 3 {
+		t.Errorf("expected the imported poll to match the export, but got %+v", imported)
+	}
+
+	options, _ := testModels.PollOptions.GetResults(p.ID)
+	counts := map[int]int{}
+	for _, option := range options {
+		counts[option.Position] = option.VoteCount
+	}
+	if counts[0] != 2 || counts[1] != 0 {
+		t.Errorf("expected the accepted votes to be counted, but got %v", counts)
+	}
+
+	importedVotes, _ := testModels.Votes.GetAll(p.ID)
+	if len(importedVotes) != 3 || importedVotes[2].Status != VoteStatusSuspect {
+		t.Errorf("expected the votes to be imported, but got %+v", importedVotes)
+	}
+
+	pollID, _, err := testModels.Polls.CheckToken(newToken.Plaintext)
+	if err != nil || pollID != p.ID {
+		t.Errorf("expected the new token to be valid for the imported poll, but got %q, %v", pollID, err)
+	}
+}
+
 func TestPollOptionsInsert(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
 	_ = testModels.Polls.Insert(poll, token.Hash)
@@ -298,9 +453,143 @@ func TestPollOptionsInsert(t *testing.T) {
 	if updatedPoll.UpdatedAt.Equal(oldUpdatedAt) {
 		t.Errorf("expected poll updated at to be changed")
 	}
+	if option.ID == uuid.Nil {
+		t.Error("expected the option's ID to be set")
+	}
+
+	duplicate := PollOption{Value: " four ", Position: 4}
+	if err := testModels.PollOptions.Insert(&duplicate, p.ID); !errors.Is(err, ErrDuplicateOption) {
+		t.Errorf("expected ErrDuplicateOption, but got %v", err)
+	}
+	duplicate.ID = p.Options[0].ID
+	if err := testModels.PollOptions.UpdateValue(p.ID, &duplicate); !errors.Is(err, ErrDuplicateOption) {
+		t.Errorf("expected ErrDuplicateOption, but got %v", err)
+	}
 	_ = testModels.Polls.Delete(updatedPoll.ID)
 }
 
+func TestPollOptionsInsertOverLimit(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	for i := len(poll.Options); i < MaxOptions; i++ {
+		option := PollOption{Value: fmt.Sprintf("option %d", i), Position: i}
+		if err := testModels.PollOptions.Insert(&option, poll.ID); err != nil {
+			t.Fatalf("insert option %d returned an error: %s", i, err)
+		}
+	}
+
+	option := PollOption{Value: "one too many", Position: MaxOptions}
+	if err := testModels.PollOptions.Insert(&option, poll.ID); !errors.Is(err, ErrTooManyOptions) {
+		t.Errorf("expected ErrTooManyOptions, but got %v", err)
+	}
+}
+
+func TestPollOptionsDetails(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Options[0].Description = "Former mayor"
+	poll.Options[0].URL = "https://example.com/jane"
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(poll.ID)
+
+	got, _ := testModels.Polls.Get(poll.ID)
+	if got.Options[0].Description != "Former mayor" || got.Options[0].URL != "https://example.com/jane" {
+		t.Errorf("expected the option's description and url, but got %+v", got.Options[0])
+	}
+
+	added := &PollOption{Value: "Four", Position: 3, Description: "Added later", URL: "https://example.com/four"}
+	if err := testModels.PollOptions.Insert(added, poll.ID); err != nil {
+		t.Fatalf("insert option returned an error: %s", err)
+	}
+
+	option := *got.Options[0]
+	option.Description = ""
+	option.URL = "https://example.com/jane-doe"
+	if err := testModels.PollOptions.UpdateValue(poll.ID, &option); err != nil {
+		t.Fatalf("update option returned an error: %s", err)
+	}
+
+	got, _ = testModels.Polls.Get(poll.ID)
+	for _, opt := range got.Options {
+		switch opt.ID {
+		case option.ID:
+			if opt.Description != "" || opt.URL != option.URL {
+				t.Errorf("expected the option's description and url to change, but got %+v", opt)
+			}
+		case added.ID:
+			if opt.Description != added.Description || opt.URL != added.URL {
+				t.Errorf("expected the added option's description and url, but got %+v", opt)
+			}
+		}
+	}
+}
+
+func TestPollShuffleOptions(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.ShuffleOptions = true
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(poll.ID)
+
+	got, _ := testModels.Polls.Get(poll.ID)
+	if !got.ShuffleOptions {
+		t.Errorf("expected the poll's options to be shuffled")
+	}
+}
+
+func TestPollOptionGroups(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	mains, appetizers := uuid.New(), uuid.New()
+	poll.Groups = []*OptionGroup{
+		{ID: mains, Label: "Mains", Position: 1},
+		{ID: appetizers, Label: "Appetizers", Position: 0},
+	}
+	poll.Options[0].GroupID = &mains
+	poll.Options[1].GroupID = &appetizers
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(poll.ID)
+
+	got, _ := testModels.Polls.Get(poll.ID)
+	if len(got.Groups) != 2 || got.Groups[0].Label != "Appetizers" || got.Groups[1].Label != "Mains" {
+		t.Fatalf("expected the groups ordered by position, but got %+v", got.Groups)
+	}
+	groups := map[string]uuid.UUID{}
+	for _, opt := range got.Options {
+		if opt.GroupID != nil {
+			groups[opt.Value] = *opt.GroupID
+		}
+	}
+	if groups["One"] != got.Groups[1].ID || groups["Two"] != got.Groups[0].ID || groups["Three"] != uuid.Nil {
+		t.Errorf("expected the options in their groups, but got %v", groups)
+	}
+
+	option := PollOption{ID: poll.Options[0].ID, Value: "One"}
+	if err := testModels.PollOptions.UpdateValue(poll.ID, &option); err != nil {
+		t.Fatalf("update option returned an error: %s", err)
+	}
+
+	polls, _, err := testModels.Polls.GetAll("", uuid.Nil, nil, Filters{Page: 1, PageSize: 100, Sort: "-created_at", SortSafelist: []string{"-created_at"}})
+	if err != nil {
+		t.Fatalf("get all returned an error: %s", err)
+	}
+	for _, p := range polls {
+		if p.ID != poll.ID {
+			continue
+		}
+		// options are listed by position
+		if len(p.Groups) != 2 || p.Options[0].GroupID != nil || p.Options[1].GroupID == nil ||
+			*p.Options[1].GroupID != p.Groups[0].ID {
+			t.Errorf("expected the listed poll's groups, but got %+v", p)
+		}
+	}
+}
+
 func TestPollOptionsUpdateValue(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
 	_ = testModels.Polls.Insert(poll, token.Hash)
@@ -313,10 +602,17 @@ func TestPollOptionsUpdateValue(t *testing.T) {
 		Value: newValue,
 	}
 
-	if err := testModels.PollOptions.UpdateValue(&option); err != nil {
+	if err := testModels.PollOptions.UpdateValue(p.ID, &option); err != nil {
 		t.Errorf("update option value returned an error: %s", err)
 	}
 
+	other, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(other, token.Hash)
+	defer testModels.Polls.Delete(other.ID)
+	if err := testModels.PollOptions.UpdateValue(other.ID, &option); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected ErrOptionNotInPoll for an option of another poll, but got %v", err)
+	}
+
 	updatedPoll, _ := testModels.Polls.Get(p.ID)
 
 	match := false
@@ -343,10 +639,28 @@ func TestPollOptionsUpdatePosition(t *testing.T) {
 		{ID: p.Options[1].ID, Position: 2},
 	}
 
-	if err := testModels.PollOptions.UpdatePosition(options); err != nil {
+	if err := testModels.PollOptions.UpdatePosition(p.ID, options); err != nil {
 		t.Errorf("update option value returned an error: %s", err)
 	}
 
+	// none of the options move if one is of another poll
+	other, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(other, token.Hash)
+	defer testModels.Polls.Delete(other.ID)
+	mixed := []*PollOption{
+		{ID: p.Options[2].ID, Position: 0},
+		{ID: other.Options[0].ID, Position: 2},
+	}
+	if err := testModels.PollOptions.UpdatePosition(p.ID, mixed); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected ErrOptionNotInPoll for an option of another poll, but got %v", err)
+	}
+	moved, _ := testModels.Polls.Get(p.ID)
+	for _, opt := range moved.Options {
+		if opt.ID == p.Options[2].ID && opt.Position != 1 {
+			t.Errorf("expected option not to move, but it's at position %d", opt.Position)
+		}
+	}
+
 	updatedPoll, _ := testModels.Polls.Get(p.ID)
 
 	for _, opt := range updatedPoll.Options {
@@ -377,7 +691,7 @@ func TestPollOptionsDelete(t *testing.T) {
 	_ = testModels.Polls.Insert(poll, token.Hash)
 	p, _ := testModels.Polls.Get(poll.ID)
 
-	if err := testModels.PollOptions.Delete(p.Options[2].ID); err != nil {
+	if err := testModels.PollOptions.Delete(p.ID, p.Options[2].ID); err != nil {
 		t.Errorf("delete option value returned an error: %s", err)
 	}
 
@@ -387,119 +701,276 @@ func TestPollOptionsDelete(t *testing.T) {
 		t.Errorf("expected len of options to be 2 but got %d", len(poll.Options))
 	}
 
-	if err := testModels.PollOptions.Delete(uuid.New().String()); !errors.Is(err, ErrRecordNotFound) {
+	if err := testModels.PollOptions.Delete(p.ID, uuid.New()); !errors.Is(err, ErrOptionNotInPoll) {
 		t.Errorf("expected error on non-existent option")
 	}
 
+	other, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(other, token.Hash)
+	defer testModels.Polls.Delete(other.ID)
+	if err := testModels.PollOptions.Delete(p.ID, other.Options[0].ID); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected ErrOptionNotInPoll for an option of another poll, but got %v", err)
+	}
+	if o, _ := testModels.Polls.Get(other.ID); len(o.Options) != len(other.Options) {
+		t.Error("expected the other poll's option not to be deleted")
+	}
+
 	_ = testModels.Polls.Delete(updatedPoll.ID)
 }
 
-func TestPollOptionsVote(t *testing.T) {
+func TestPollOptionsMerge(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
 	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
 	p, _ := testModels.Polls.Get(poll.ID)
+	merged, into, last := p.Options[0], p.Options[1], p.Options[2]
 
-	err := testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
-	if err != nil {
-		t.Errorf("vote option returned an error: %s", err)
+	for _, option := range []*PollOption{merged, merged, into} {
+		if err := testModels.PollOptions.Vote(&Vote{OptionID: option.ID, PollID: p.ID, IP: "0.0.0.0"}); err != nil {
+			t.Fatalf("vote option returned an error: %s", err)
+		}
 	}
 
-	options, err := testModels.PollOptions.GetResults(p.ID)
+	votes, err := testModels.PollOptions.Merge(p.ID, merged.ID, into.ID)
 	if err != nil {
-		t.Errorf("getting votes returned an error: %s", err)
+		t.Fatalf("merge returned an error: %s", err)
+	}
+	if votes != 2 {
+		t.Errorf("expected 2 votes to be moved, but got %d", votes)
 	}
 
+	options, _ := testModels.PollOptions.GetResults(p.ID)
+	if len(options) != 2 {
+		t.Fatalf("expected 2 options after merging, but got %d", len(options))
+	}
 	for _, opt := range options {
-		if opt.ID == p.Options[0].ID && opt.VoteCount != 1 {
-			t.Errorf(
-				"expected vote count to increase by one, but it didn't: vote_count %d",
-				opt.VoteCount,
-			)
+		switch opt.ID {
+		case into.ID:
+			if opt.VoteCount != 3 || opt.Position != 0 {
+				t.Errorf("expected the option to have 3 votes at position 0, but got %d at %d", opt.VoteCount, opt.Position)
+			}
+		case last.ID:
+			if opt.Position != 1 {
+				t.Errorf("expected the last option to move to position 1, but got %d", opt.Position)
+			}
+		default:
+			t.Errorf("expected the merged option to be deleted, but got %s", opt.ID)
+		}
+	}
+	recent, err := testModels.Votes.GetRecent(p.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("get recent votes returned an error: %s", err)
+	}
+	for _, vote := range recent {
+		if vote.OptionID != into.ID {
+			t.Errorf("expected every vote to be for the merged into option, but got %s", vote.OptionID)
 		}
 	}
 
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
+	if _, err := testModels.PollOptions.Merge(p.ID, into.ID, into.ID); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected %v merging an option into itself, but got %v", ErrOptionNotInPoll, err)
+	}
 
-	options, _ = testModels.PollOptions.GetResults(p.ID)
+	other, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(other, token.Hash)
+	defer testModels.Polls.Delete(other.ID)
+	if _, err := testModels.PollOptions.Merge(p.ID, last.ID, other.Options[0].ID); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected %v merging into an option of another poll, but got %v", ErrOptionNotInPoll, err)
+	}
+	if options, _ := testModels.PollOptions.GetResults(p.ID); len(options) != 2 {
+		t.Errorf("expected the failed merge to be rolled back, but got %d options", len(options))
+	}
+}
+
+func TestStagedActions(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+	p, _ := testModels.Polls.Get(poll.ID)
+
+	action := &StagedAction{PollID: p.ID, OptionID: &p.Options[0].ID, Actor: ActorOwner}
+	if err := testModels.Staged.Stage(action, time.Minute); err != nil {
+		t.Fatalf("stage returned an error: %s", err)
+	}
+	if action.Action != StagedOptionDelete || action.UndoUntil.Before(time.Now()) {
+		t.Errorf("expected an option delete that can be undone, but got %+v", action)
+	}
+
+	again := &StagedAction{PollID: p.ID, OptionID: &p.Options[0].ID, Actor: ActorOwner}
+	if err := testModels.Staged.Stage(again, time.Minute); err != nil {
+		t.Fatalf("stage again returned an error: %s", err)
+	}
+	if again.ID != action.ID {
+		t.Errorf("expected staging again to return %s, but got %s", action.ID, again.ID)
+	}
+
+	staged, err := testModels.Staged.GetOptionDeletes(p.ID)
+	if err != nil {
+		t.Fatalf("get option deletes returned an error: %s", err)
+	}
+	if len(staged) != 1 || staged[0] != p.Options[0].ID {
+		t.Errorf("expected the option to be staged for delete, but got %v", staged)
+	}
+
+	if err := testModels.Staged.Undo(action.ID); err != nil {
+		t.Fatalf("undo returned an error: %s", err)
+	}
+	if _, err := testModels.Staged.Get(action.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v after undoing, but got %v", ErrRecordNotFound, err)
+	}
+
+	// a negative window makes the action due right away
+	action = &StagedAction{PollID: p.ID, OptionID: &p.Options[0].ID, Actor: ActorOwner}
+	if err := testModels.Staged.Stage(action, -time.Second); err != nil {
+		t.Fatalf("stage returned an error: %s", err)
+	}
+	if err := testModels.Staged.Undo(action.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v undoing a due action, but got %v", ErrRecordNotFound, err)
+	}
+	if _, err := testModels.Staged.ExecuteDue(); err != nil {
+		t.Fatalf("execute due returned an error: %s", err)
+	}
+
+	options, _ := testModels.PollOptions.GetResults(p.ID)
+	if len(options) != 2 {
+		t.Fatalf("expected 2 options after the delete, but got %d", len(options))
+	}
 	for _, opt := range options {
-		if opt.ID == p.Options[0].ID && opt.VoteCount != 3 {
-			t.Errorf("expected vote count to be 3, but got %d", opt.VoteCount)
+		if opt.ID == p.Options[1].ID && opt.Position != 0 {
+			t.Errorf("expected the next option to move to position 0, but got %d", opt.Position)
 		}
 	}
-
-	if err := testModels.PollOptions.Vote(
-		uuid.New().String(),
-		p.ID,
-		"0.0.0.0",
-	); !errors.Is(err, ErrRecordNotFound) {
-		t.Errorf("expected error on non-existent option")
+	activities, _, _ := testModels.AuditLog.GetForPoll(p.ID, Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}})
+	if len(activities) == 0 || activities[0].Action != ActionOptionDeleted {
+		t.Errorf("expected the delete to be recorded, but got %+v", activities)
 	}
 
-	poll2, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll2, token.Hash)
-	p2, _ := testModels.Polls.Get(poll2.ID)
+	// the poll would be left with one option, so the delete is dropped
+	action = &StagedAction{PollID: p.ID, OptionID: &p.Options[1].ID, Actor: ActorOwner}
+	_ = testModels.Staged.Stage(action, -time.Second)
+	_, _ = testModels.Staged.ExecuteDue()
+	if options, _ := testModels.PollOptions.GetResults(p.ID); len(options) != 2 {
+		t.Errorf("expected the poll to keep 2 options, but got %d", len(options))
+	}
 
-	if err = testModels.PollOptions.Vote(
-		p.Options[0].ID,
-		p2.ID,
-		"0.0.0.0",
-	); !errors.Is(err, ErrRecordNotFound) {
-		t.Errorf("expected error on post and option id mismatch")
+	action = &StagedAction{PollID: p.ID, Actor: ActorOwner}
+	_ = testModels.Staged.Stage(action, -time.Second)
+	if action.Action != StagedPollDelete {
+		t.Errorf("expected a poll delete, but got %q", action.Action)
+	}
+	_, _ = testModels.Staged.ExecuteDue()
+	if _, err := testModels.Polls.Get(p.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the poll to be deleted, but got %v", err)
 	}
-	_ = testModels.Polls.Delete(p.ID)
-	_ = testModels.Polls.Delete(p2.ID)
 }
 
-func TestPollGetVotedIPs(t *testing.T) {
+func TestStagedActionsGetTrash(t *testing.T) {
+	adminToken, _ := GenerateToken()
+	org := &Organization{Name: "Acme"}
+	if err := testModels.Orgs.Insert(org, &Member{Name: "Jane"}, adminToken.Hash); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	defer testDB.Exec(context.Background(), "DELETE FROM organizations WHERE id = $1", org.ID)
+
 	poll, token := createPollAndGenerateToken(t)
+	poll.OrgID = &org.ID
 	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
 	p, _ := testModels.Polls.Get(poll.ID)
 
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.1")
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.2")
-	_ = testModels.PollOptions.Vote(p.Options[1].ID, p.ID, "0.0.0.3")
+	// option deletes aren't in the trash
+	_ = testModels.Staged.Stage(&StagedAction{PollID: p.ID, OptionID: &p.Options[0].ID, Actor: "Jane"}, time.Minute)
+	action := &StagedAction{PollID: p.ID, Actor: "Jane"}
+	if err := testModels.Staged.Stage(action, time.Minute); err != nil {
+		t.Fatalf("stage returned an error: %s", err)
+	}
 
-	ips, err := testModels.Polls.GetVotedIPs(p.ID)
+	trash, err := testModels.Staged.GetTrash(org.ID)
 	if err != nil {
-		t.Errorf("get ips returned an error: %s", err)
+		t.Fatalf("get trash returned an error: %s", err)
+	}
+	if len(trash) != 1 || trash[0].ActionID != action.ID || trash[0].PollID != p.ID ||
+		trash[0].Question != p.Question || trash[0].DeletedBy != "Jane" {
+		t.Fatalf("expected the staged poll delete, but got %+v", trash)
 	}
 
-	if len(ips) != 3 {
-		t.Errorf("expected 3 ips to be stored, but got %d", len(ips))
+	_ = testModels.Staged.Undo(action.ID)
+	if trash, _ := testModels.Staged.GetTrash(org.ID); len(trash) != 0 {
+		t.Errorf("expected restored polls to be left out, but got %d", len(trash))
 	}
+}
+
+func TestDeletedOptions(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+	p, _ := testModels.Polls.Get(poll.ID)
+	deleted := p.Options[0]
 
-	ips, err = testModels.Polls.GetVotedIPs(uuid.New().String())
+	err := testModels.PollOptions.Vote(&Vote{OptionID: deleted.ID, PollID: p.ID, IP: "0.0.0.0"})
 	if err != nil {
-		t.Errorf("get ips returned an error: %s", err)
+		t.Fatalf("vote returned an error: %s", err)
 	}
-	if len(ips) != 0 {
-		t.Errorf("expected empty slice on non existent poll, but got %s", ips)
+
+	action := &StagedAction{PollID: p.ID, OptionID: &deleted.ID, Actor: ActorOwner}
+	_ = testModels.Staged.Stage(action, -time.Second)
+	if _, err := testModels.Staged.ExecuteDue(); err != nil {
+		t.Fatalf("execute due returned an error: %s", err)
 	}
 
-	poll, token = createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p2, _ := testModels.Polls.Get(poll.ID)
+	options, err := testModels.Deleted.GetAll(p.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("get all returned an error: %s", err)
+	}
+	if len(options) != 1 || options[0].ID != deleted.ID || options[0].Value != deleted.Value || options[0].VoteCount != 1 {
+		t.Fatalf("expected the deleted option with its vote, but got %+v", options)
+	}
+	if options, _ := testModels.Deleted.GetAll(p.ID, 0); len(options) != 0 {
+		t.Errorf("expected no options once the window passed, but got %d", len(options))
+	}
+	if _, err := testModels.Deleted.Restore(p.ID, deleted.ID, 0); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v restoring after the window, but got %v", ErrRecordNotFound, err)
+	}
 
-	ips, err = testModels.Polls.GetVotedIPs(p2.ID)
+	restored, err := testModels.Deleted.Restore(p.ID, deleted.ID, time.Hour)
 	if err != nil {
-		t.Errorf("get ips returned an error: %s", err)
+		t.Fatalf("restore returned an error: %s", err)
 	}
-	if len(ips) != 0 {
-		t.Errorf("expected empty slice if poll without votes, but got %s", ips)
+	if restored.Position != deleted.Position || restored.VoteCount != 1 {
+		t.Errorf("expected the option back at its position with its vote, but got %+v", restored)
 	}
 
-	_ = testModels.Polls.Delete(p.ID)
-	_ = testModels.Polls.Delete(p2.ID)
+	results, _ := testModels.PollOptions.GetResults(p.ID)
+	if len(results) != len(p.Options) {
+		t.Fatalf("expected %d options after restoring, but got %d", len(p.Options), len(results))
+	}
+	for _, opt := range results {
+		if opt.ID == deleted.ID && opt.VoteCount != 1 {
+			t.Errorf("expected the restored option to have 1 vote, but got %d", opt.VoteCount)
+		}
+	}
+	if votes, _ := testModels.Votes.GetAll(p.ID); len(votes) != 1 || votes[0].OptionID != deleted.ID {
+		t.Errorf("expected the vote to be restored, but got %+v", votes)
+	}
+	if options, _ := testModels.Deleted.GetAll(p.ID, time.Hour); len(options) != 0 {
+		t.Errorf("expected restored options to be left out, but got %d", len(options))
+	}
+
+	_ = testModels.PollOptions.Delete(p.ID, deleted.ID)
+	if purged, err := testModels.Deleted.Purge(0); err != nil || purged == 0 {
+		t.Errorf("expected the deleted option to be purged, but got %d, %v", purged, err)
+	}
 }
 
-func TestGetResults(t *testing.T) {
+func TestPollOptionsVote(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
 	_ = testModels.Polls.Insert(poll, token.Hash)
 	p, _ := testModels.Polls.Get(poll.ID)
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
-	_ = testModels.PollOptions.Vote(p.Options[1].ID, p.ID, "0.0.0.0")
-	_ = testModels.PollOptions.Vote(p.Options[1].ID, p.ID, "0.0.0.0")
+
+	err := testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.0"})
+	if err != nil {
+		t.Errorf("vote option returned an error: %s", err)
+	}
 
 	options, err := testModels.PollOptions.GetResults(p.ID)
 	if err != nil {
@@ -508,259 +979,2596 @@ func TestGetResults(t *testing.T) {
 
 	for _, opt := range options {
 		if opt.ID == p.Options[0].ID && opt.VoteCount != 1 {
-			t.Errorf("expected vote count to be 1, but got %d", opt.VoteCount)
-		}
-		if opt.ID == p.Options[1].ID && opt.VoteCount != 2 {
-			t.Errorf("expected vote count to be 2, but got %d", opt.VoteCount)
+			t.Errorf(
+				"expected vote count to increase by one, but it didn't: vote_count %d",
+				opt.VoteCount,
+			)
 		}
 	}
 
-	options, err = testModels.PollOptions.GetResults(uuid.New().String())
-	if err != nil {
-		t.Errorf("getting votes returned an error: %s", err)
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.0"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.0"})
+
+	options, _ = testModels.PollOptions.GetResults(p.ID)
+	for _, opt := range options {
+		if opt.ID == p.Options[0].ID && opt.VoteCount != 3 {
+			t.Errorf("expected vote count to be 3, but got %d", opt.VoteCount)
+		}
 	}
-	if len(options) != 0 {
-		t.Errorf("expected len of options to be 0, but got %d", len(options))
+
+	if err := testModels.PollOptions.Vote(&Vote{
+		OptionID: uuid.New(),
+		PollID:   p.ID,
+		IP:       "0.0.0.0",
+	}); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected error on non-existent option")
 	}
 
-	_ = testModels.Polls.Delete(p.ID)
-}
+	poll2, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll2, token.Hash)
+	p2, _ := testModels.Polls.Get(poll2.ID)
 
-func TestPollGetAll(t *testing.T) {
-	var poll Poll
-	for i := 1; i <= 10; i++ {
-		// sleep to delay inserting the last record
-		if i == 10 {
-			time.Sleep(1 * time.Second)
-		}
+	if err = testModels.PollOptions.Vote(&Vote{
+		OptionID: p.Options[0].ID,
+		PollID:   p2.ID,
+		IP:       "0.0.0.0",
+	}); !errors.Is(err, ErrOptionNotInPoll) {
+		t.Errorf("expected error on post and option id mismatch")
+	}
 
-		poll.Question = fmt.Sprintf("%c question", 96+i)
-		poll.Options = []*PollOption{
-			{Value: fmt.Sprintf("Option one, poll %c", 96+i), Position: 0},
-			{Value: fmt.Sprintf("Option two, poll %c", 96+i), Position: 1},
-			{Value: fmt.Sprintf("Option three, poll %c", 96+i), Position: 2},
-		}
-		token, _ := GenerateToken()
-		if err := testModels.Polls.Insert(&poll, token.Hash); err != nil {
-			t.Fatalf("get all polls - insert poll returned an error: %s", err)
-		}
+	if err = testModels.PollOptions.Vote(&Vote{
+		OptionID: p.Options[0].ID,
+		PollID:   uuid.New(),
+		IP:       "0.0.0.0",
+	}); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on non-existent poll")
 	}
 
-	// insert one private poll
-	pollPrivate := Poll{
-		Question: "private test",
-		Options: []*PollOption{
-			{Value: "One", Position: 0},
-			{Value: "Two", Position: 1},
-		},
-		IsPrivate: true,
+	// the deadline passes between reading the poll and voting
+	_, err = testDB.Exec(context.Background(), `UPDATE polls SET expires_at = NOW() - interval '1 second' WHERE id = $1;`, p2.ID)
+	if err != nil {
+		t.Fatal(err)
 	}
-	token, _ := GenerateToken()
-	if err := testModels.Polls.Insert(&pollPrivate, token.Hash); err != nil {
-		t.Fatalf("get all polls - insert poll returned an error: %s", err)
+	if err = testModels.PollOptions.Vote(&Vote{OptionID: p2.Options[0].ID, PollID: p2.ID, IP: "0.0.0.0"}); !errors.Is(err, ErrPollExpired) {
+		t.Errorf("expected ErrPollExpired, but got %v", err)
 	}
-
-	tests := []struct {
-		name             string
-		search           string
-		page             int
-		pageSize         int
-		sort             string
-		expectedRecords  int
-		expectedTotal    int
-		expectedLastPage int
-	}{
-		{
-			name:             "default settings",
-			page:             1,
-			pageSize:         20,
-			sort:             "-created_at",
-			expectedRecords:  10,
-			expectedTotal:    10,
-			expectedLastPage: 1,
-		},
-		{
-			name:             "page size",
-			page:             1,
-			pageSize:         2,
-			sort:             "-created_at",
-			expectedRecords:  2,
-			expectedTotal:    10,
-			expectedLastPage: 5,
-		},
-		{
-			name:             "page",
-			page:             2,
-			pageSize:         5,
-			sort:             "-created_at",
-			expectedRecords:  5,
-			expectedTotal:    10,
-			expectedLastPage: 2,
-		},
-		{
-			name:             "sort by question asc",
-			page:             1,
-			pageSize:         20,
-			sort:             "question",
-			expectedRecords:  10,
-			expectedTotal:    10,
-			expectedLastPage: 1,
-		},
-		{
-			name:             "sort by question desc",
-			page:             1,
-			pageSize:         20,
-			sort:             "-question",
-			expectedRecords:  10,
-			expectedTotal:    10,
-			expectedLastPage: 1,
-		},
-		{
-			name:             "sort by created asc",
-			page:             1,
-			pageSize:         20,
-			sort:             "created_at",
-			expectedRecords:  10,
-			expectedTotal:    10,
-			expectedLastPage: 1,
-		},
-		{
-			name:             "search",
-			search:           "d",
-			page:             1,
-			pageSize:         20,
-			sort:             "-created_at",
-			expectedRecords:  1,
-			expectedTotal:    1,
-			expectedLastPage: 1,
-		},
-		{
-			name:             "no matches",
-			search:           "test",
-			page:             1,
-			pageSize:         20,
-			sort:             "-created_at",
-			expectedRecords:  0,
-			expectedTotal:    0,
-			expectedLastPage: 0,
-		},
-		{
-			name:             "page value too high",
-			page:             42,
-			pageSize:         20,
-			sort:             "-created_at",
-			expectedRecords:  0,
-			expectedTotal:    0,
-			expectedLastPage: 0,
-		},
-		{
-			name:             "private poll not listed",
-			search:           "private test",
-			page:             1,
-			pageSize:         20,
-			sort:             "-created_at",
-			expectedRecords:  0,
-			expectedTotal:    0,
-			expectedLastPage: 0,
-		},
+	_, _ = testModels.Polls.CloseExpired()
+	if err = testModels.PollOptions.Vote(&Vote{OptionID: p2.Options[0].ID, PollID: p2.ID, IP: "0.0.0.0"}); !errors.Is(err, ErrPollClosed) {
+		t.Errorf("expected ErrPollClosed, but got %v", err)
 	}
+	_ = testModels.Polls.Delete(p.ID)
+	_ = testModels.Polls.Delete(p2.ID)
+}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			polls, metadata, err := testModels.Polls.GetAll(test.search, Filters{
-				Page:         test.page,
-				PageSize:     test.pageSize,
-				Sort:         test.sort,
-				SortSafelist: []string{"created_at", "question", "-created_at", "-question"},
+func TestPollOptionsVoteMaxVotes(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.ExpiresAt = ExpiresAt{time.Now().Add(time.Hour)}
+	poll.MaxVotes = 5
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	// more voters than the cap allows vote at once
+	var accepted, rejected atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := testModels.PollOptions.Vote(&Vote{
+				OptionID: poll.Options[i%len(poll.Options)].ID,
+				PollID:   poll.ID,
+				IP:       fmt.Sprintf("10.0.0.%d", i+1),
 			})
-			if err != nil {
-				t.Errorf("get all polls returned an error: %s", err)
-			}
-
-			if len(polls) != test.expectedRecords {
-				t.Errorf("expected to get %d records but got %d", test.expectedRecords, len(polls))
+			switch {
+			case err == nil:
+				accepted.Add(1)
+			case errors.Is(err, ErrVoteQuotaReached):
+				rejected.Add(1)
+			default:
+				t.Errorf("vote returned an error: %s", err)
 			}
+		}(i)
+	}
+	wg.Wait()
 
-			if metadata.TotalRecords != test.expectedTotal {
-				t.Errorf(
-					"expected total records in Metadata to be %d records but got %d",
-					test.expectedTotal,
-					metadata.TotalRecords,
-				)
-			}
-			if metadata.LastPage != test.expectedLastPage {
-				t.Errorf(
-					"expected last page in Metadata to be %d records but got %d",
-					test.expectedLastPage,
-					metadata.LastPage,
-				)
-			}
+	if accepted.Load() != 5 || rejected.Load() != 15 {
+		t.Errorf("expected 5 accepted and 15 rejected votes, but got %d and %d", accepted.Load(), rejected.Load())
+	}
 
-			if test.search == "" {
-				switch test.sort {
-				case "-created_at":
-					if metadata.CurrentPage == 1 {
-						if polls[0].Question != "j question" {
-							t.Errorf("sorting: expected first poll to be the last one iserted but got, %q", polls[0].Question)
-						}
+	p, _ := testModels.Polls.Get(poll.ID)
+	if p.VotesCast != 5 || !p.VoteQuotaReached() {
+		t.Errorf("expected 5 votes cast, but got %d", p.VotesCast)
+	}
+	if p.ExpiresAt.After(time.Now()) {
+		t.Errorf("expected the poll to close when the cap was reached, but it expires at %s", p.ExpiresAt)
+	}
 
-						if polls[0].Options[0].Value != "Option one, poll j" {
-							t.Errorf("options: expected option to be in first poll but got, %q", polls[0].Options[0].Value)
-						}
+	options, _ := testModels.PollOptions.GetResults(poll.ID)
+	var total int
+	for _, option := range options {
+		total += option.VoteCount
+	}
+	if total != 5 {
+		t.Errorf("expected the options to have 5 votes, but got %d", total)
+	}
 
-					}
-				case "created_at":
-					if metadata.CurrentPage == 1 {
-						if polls[9].Question != "j question" {
-							t.Errorf("sorting: expected last poll to be the last one iserted but got, %q", polls[9].Question)
-						}
+	closed, _ := testModels.Polls.CloseExpired()
+	found := false
+	for _, c := range closed {
+		found = found || c.ID == poll.ID
+	}
+	if !found {
+		t.Error("expected the poll to be closed once its deadline moved")
+	}
+}
 
-						if polls[9].Options[0].Value != "Option one, poll j" {
-							t.Errorf("options: expected option to be in first poll but got, %q", polls[9].Options[0].Value)
-						}
-					}
-				case "question":
-					if metadata.CurrentPage == 1 {
-						if polls[0].Question != "a question" {
-							t.Errorf("sorting by question: expected first poll question to start witn 'a', but got %q", polls[0].Question)
-						}
-						if polls[9].Question != "j question" {
-							t.Errorf("sorting by question: expected last poll question to start witn 'j', but got %q", polls[9].Question)
-						}
-					}
-				case "-question":
-					if metadata.CurrentPage == 1 {
-						if polls[0].Question != "j question" {
-							t.Errorf("sorting by question: expected first poll question to start witn 'j', but got %q", polls[0].Question)
-						}
-						if polls[9].Question != "a question" {
-							t.Errorf("sorting by question: expected last poll question to start witn 'a', but got %q", polls[9].Question)
-						}
-					}
-				default:
-					t.Fatal("unknown sort value")
+func TestPollOptionsVoteConcurrent(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	// every fifth vote is held for moderation and only counted once accepted
+	const voters, votesEach = 20, 10
+	var wg sync.WaitGroup
+	for i := 0; i < voters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < votesEach; j++ {
+				vote := &Vote{
+					OptionID: poll.Options[(i+j)%len(poll.Options)].ID,
+					PollID:   poll.ID,
+					IP:       fmt.Sprintf("10.0.%d.%d", i, j+1),
 				}
-			}
-
-			if test.search != "" && test.expectedRecords != 0 {
-				if !strings.Contains(polls[0].Question, test.search) {
-					t.Errorf("expected found poll question to contain %q but got %q", test.search, polls[0].Question)
+				if j%5 == 0 {
+					vote.Status = VoteStatusSuspect
 				}
-				opt := fmt.Sprintf("Option one, poll %s", test.search)
-				if polls[0].Options[0].Value != opt {
-					t.Errorf("expected option %q to be in poll but got, %q", opt, polls[0].Options[0].Value)
+				if err := testModels.PollOptions.Vote(vote); err != nil {
+					t.Errorf("vote returned an error: %s", err)
 				}
 			}
-		})
+		}(i)
 	}
+	wg.Wait()
 
-	t.Run("private poll available with Get", func(t *testing.T) {
-		poll, err := testModels.Polls.Get(pollPrivate.ID)
-		if err != nil {
-			t.Errorf("get private poll returned an error: %s", err)
-		}
-		if poll.Question != "private test" {
-			t.Errorf("expected to get question 'private poll', but got %s", poll.Question)
+	options, _ := testModels.PollOptions.GetResults(poll.ID)
+	var counted int
+	for _, option := range options {
+		counted += option.VoteCount
+	}
+	if want := voters * votesEach * 4 / 5; counted != want {
+		t.Errorf("expected the options to have %d votes, but got %d", want, counted)
+	}
+
+	var recorded int
+	err := testDB.QueryRow(context.Background(), `SELECT count(*) FROM votes WHERE poll_id = $1;`, poll.ID).Scan(&recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := testModels.Polls.Get(poll.ID)
+	if recorded != voters*votesEach || p.VotesCast != recorded {
+		t.Errorf("expected %d votes recorded and cast, but got %d and %d", voters*votesEach, recorded, p.VotesCast)
+	}
+}
+
+func BenchmarkPollOptionsVote(b *testing.B) {
+	poll, token := createPollAndGenerateToken(b)
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		b.Fatal(err)
+	}
+	defer testModels.Polls.Delete(poll.ID)
+
+	var n atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := n.Add(1)
+			vote := &Vote{
+				OptionID: poll.Options[i%int64(len(poll.Options))].ID,
+				PollID:   poll.ID,
+				IP:       fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255),
+			}
+			if err := testModels.PollOptions.Vote(vote); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 }
+
+func TestPollGetVotedIPs(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	p, _ := testModels.Polls.Get(poll.ID)
+
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.1"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.2"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[1].ID, PollID: p.ID, IP: "0.0.0.3"})
+
+	ips, err := testModels.Polls.GetVotedIPs(p.ID)
+	if err != nil {
+		t.Errorf("get ips returned an error: %s", err)
+	}
+
+	if len(ips) != 3 {
+		t.Errorf("expected 3 ips to be stored, but got %d", len(ips))
+	}
+
+	ips, err = testModels.Polls.GetVotedIPs(uuid.New())
+	if err != nil {
+		t.Errorf("get ips returned an error: %s", err)
+	}
+	if len(ips) != 0 {
+		t.Errorf("expected empty slice on non existent poll, but got %s", ips)
+	}
+
+	poll, token = createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	p2, _ := testModels.Polls.Get(poll.ID)
+
+	ips, err = testModels.Polls.GetVotedIPs(p2.ID)
+	if err != nil {
+		t.Errorf("get ips returned an error: %s", err)
+	}
+	if len(ips) != 0 {
+		t.Errorf("expected empty slice if poll without votes, but got %s", ips)
+	}
+
+	_ = testModels.Polls.Delete(p.ID)
+	_ = testModels.Polls.Delete(p2.ID)
+}
+
+func TestGetResults(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.0"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[1].ID, PollID: p.ID, IP: "0.0.0.0"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[1].ID, PollID: p.ID, IP: "0.0.0.0"})
+
+	options, err := testModels.PollOptions.GetResults(p.ID)
+	if err != nil {
+		t.Errorf("getting votes returned an error: %s", err)
+	}
+
+	for _, opt := range options {
+		if opt.ID == p.Options[0].ID && opt.VoteCount != 1 {
+			t.Errorf("expected vote count to be 1, but got %d", opt.VoteCount)
+		}
+		if opt.ID == p.Options[1].ID && opt.VoteCount != 2 {
+			t.Errorf("expected vote count to be 2, but got %d", opt.VoteCount)
+		}
+	}
+
+	options, err = testModels.PollOptions.GetResults(uuid.New())
+	if err != nil {
+		t.Errorf("getting votes returned an error: %s", err)
+	}
+	if len(options) != 0 {
+		t.Errorf("expected len of options to be 0, but got %d", len(options))
+	}
+
+	_ = testModels.Polls.Delete(p.ID)
+}
+
+func TestVotesGetStats(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	stats, err := testModels.Votes.GetStats(poll.ID)
+	if err != nil {
+		t.Fatalf("get stats returned an error: %s", err)
+	}
+	if stats.PeakHour != nil || stats.VotesPerHour != 0 || stats.IssuedVoteTokens != 0 {
+		t.Errorf("expected empty stats without votes, but got %+v", stats)
+	}
+
+	for i := 0; i < 2; i++ {
+		voteToken, _ := GenerateToken()
+		voteToken.Scope = ScopeVote
+		if err := testModels.Tokens.Insert(poll.ID, voteToken); err != nil {
+			t.Fatalf("insert token returned an error: %s", err)
+		}
+	}
+	for _, status := range []string{VoteStatusAccepted, VoteStatusAccepted, VoteStatusSuspect} {
+		vote := &Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, Status: status}
+		if err := testModels.PollOptions.Vote(vote); err != nil {
+			t.Fatalf("vote returned an error: %s", err)
+		}
+	}
+	// a vote from three hours ago, in an hour of its own
+	_, _ = testDB.Exec(context.Background(),
+		`UPDATE polls SET created_at = NOW() - interval '4 hours' WHERE id = $1`, poll.ID)
+	_, _ = testDB.Exec(context.Background(),
+		`INSERT INTO votes (poll_id, option_id, created_at) VALUES ($1, $2, NOW() - interval '3 hours')`,
+		poll.ID, poll.Options[1].ID)
+
+	stats, err = testModels.Votes.GetStats(poll.ID)
+	if err != nil {
+		t.Fatalf("get stats returned an error: %s", err)
+	}
+	if stats.IssuedVoteTokens != 2 {
+		t.Errorf("expected 2 issued vote tokens, but got %d", stats.IssuedVoteTokens)
+	}
+	if stats.VotesPerHour < 0.7 || stats.VotesPerHour > 0.8 {
+		t.Errorf("expected 3 votes in 4 hours, but got %f votes per hour", stats.VotesPerHour)
+	}
+	if stats.PeakHourVotes != 2 || stats.PeakHour == nil || time.Since(*stats.PeakHour) > time.Hour {
+		t.Errorf("expected 2 votes in the current hour, but got %d in %v", stats.PeakHourVotes, stats.PeakHour)
+	}
+
+	if _, err := testModels.Votes.GetStats(uuid.New()); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, but got %v", err)
+	}
+}
+
+func TestPollsGetSeries(t *testing.T) {
+	first, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(first, token.Hash)
+	defer testModels.Polls.Delete(first.ID)
+
+	var ids []uuid.UUID
+	for i := 0; i < 2; i++ {
+		next, token := createPollAndGenerateToken(t)
+		next.SeriesID = &first.ID
+		if err := testModels.Polls.Insert(next, token.Hash); err != nil {
+			t.Fatalf("insert poll returned an error: %s", err)
+		}
+		defer testModels.Polls.Delete(next.ID)
+		ids = append(ids, next.ID)
+	}
+	// created_at only has a precision of seconds
+	for i, id := range []uuid.UUID{first.ID, ids[0]} {
+		_, _ = testDB.Exec(context.Background(),
+			`UPDATE polls SET created_at = NOW() - $2 * interval '1 hour' WHERE id = $1`, id, 2-i)
+	}
+
+	p, err := testModels.Polls.Get(first.ID)
+	if err != nil {
+		t.Fatalf("get poll returned an error: %s", err)
+	}
+	if p.SeriesID == nil || *p.SeriesID != first.ID {
+		t.Errorf("expected the first poll to start the series, but got series %v", p.SeriesID)
+	}
+
+	series, err := testModels.Polls.GetSeries(first.ID, 10)
+	if err != nil {
+		t.Fatalf("get series returned an error: %s", err)
+	}
+	if len(series) != 3 || series[0].ID != first.ID || series[2].ID != ids[1] {
+		t.Errorf("expected the series' polls oldest first, but got %d polls", len(series))
+	}
+
+	series, _ = testModels.Polls.GetSeries(first.ID, 2)
+	if len(series) != 2 || series[0].ID != ids[0] || series[1].ID != ids[1] {
+		t.Errorf("expected the latest 2 polls of the series, but got %d polls", len(series))
+	}
+
+	// taken down, rejected and private polls aren't shown with the series
+	private, token := createPollAndGenerateToken(t)
+	private.SeriesID = &first.ID
+	private.Visibility = VisibilityPrivate
+	if err := testModels.Polls.Insert(private, token.Hash); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(private.ID)
+	if err := testModels.Takedowns.Insert(&Takedown{PollID: ids[1], Reason: "spam"}); err != nil {
+		t.Fatalf("insert takedown returned an error: %s", err)
+	}
+	if err := testModels.Polls.Moderate(ids[0], ModerationRejected); err != nil {
+		t.Fatalf("moderate returned an error: %s", err)
+	}
+
+	series, err = testModels.Polls.GetSeries(first.ID, 2)
+	if err != nil {
+		t.Fatalf("get series returned an error: %s", err)
+	}
+	if len(series) != 1 || series[0].ID != first.ID {
+		t.Errorf("expected only the first poll of the series, but got %d polls", len(series))
+	}
+}
+
+func TestVotesGetSegmentCounts(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Demographics = []DemographicQuestion{{Key: "team", Choices: []string{"Sales", "Engineering"}}}
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	p, err := testModels.Polls.Get(poll.ID)
+	if err != nil {
+		t.Fatalf("get poll returned an error: %s", err)
+	}
+	if len(p.Demographics) != 1 || p.Demographics[0].Key != "team" || len(p.Demographics[0].Choices) != 2 {
+		t.Errorf("expected the poll's demographic question, but got %+v", p.Demographics)
+	}
+
+	answers := []map[string]string{{"team": "Sales"}, {"team": "Sales"}, {"team": "Engineering"}, nil}
+	for _, answer := range answers {
+		vote := &Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, Status: VoteStatusAccepted, Demographics: answer}
+		if err := testModels.PollOptions.Vote(vote); err != nil {
+			t.Fatalf("vote returned an error: %s", err)
+		}
+	}
+
+	counts, err := testModels.Votes.GetSegmentCounts(poll.ID, "team")
+	if err != nil {
+		t.Fatalf("get segment counts returned an error: %s", err)
+	}
+	optionID := poll.Options[0].ID
+	if counts["Sales"][optionID] != 2 || counts["Engineering"][optionID] != 1 || counts[""][optionID] != 1 {
+		t.Errorf("expected 2 votes from Sales, 1 from Engineering and 1 unanswered, but got %v", counts)
+	}
+}
+
+func TestVotesGetVariantCounts(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.QuestionVariants = []string{"Test, really?"}
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	p, err := testModels.Polls.Get(poll.ID)
+	if err != nil {
+		t.Fatalf("get poll returned an error: %s", err)
+	}
+	if !slices.Equal(p.QuestionVariants, poll.QuestionVariants) {
+		t.Errorf("expected the poll's question variants, but got %v", p.QuestionVariants)
+	}
+
+	for _, variant := range []int{0, 1, 1} {
+		vote := &Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, Status: VoteStatusAccepted, Variant: variant}
+		if err := testModels.PollOptions.Vote(vote); err != nil {
+			t.Fatalf("vote returned an error: %s", err)
+		}
+	}
+
+	counts, err := testModels.Votes.GetVariantCounts(poll.ID)
+	if err != nil {
+		t.Fatalf("get variant counts returned an error: %s", err)
+	}
+	optionID := poll.Options[0].ID
+	if counts[0][optionID] != 1 || counts[1][optionID] != 2 {
+		t.Errorf("expected 1 vote for the question and 2 for its variant, but got %v", counts)
+	}
+
+	votes, _ := testModels.Votes.GetAll(poll.ID)
+	if len(votes) != 3 || votes[1].Variant != 1 {
+		t.Errorf("expected the votes' variants to be exported, but got %+v", votes)
+	}
+}
+
+func TestViewsGetSourceCounts(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	views := []struct{ source, ip string }{
+		{"slack", "10.0.0.1"}, {"slack", "10.0.0.2"}, {"newsletter", "10.0.0.3"}, {"", "10.0.0.4"},
+	}
+	for _, view := range views {
+		if err := testModels.Views.Record(poll.ID, view.source, HashVisitor(visitorKey, view.ip)); err != nil {
+			t.Fatalf("record view returned an error: %s", err)
+		}
+	}
+	for _, source := range []string{"slack", "twitter"} {
+		vote := &Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, Status: VoteStatusAccepted, Source: source}
+		if err := testModels.PollOptions.Vote(vote); err != nil {
+			t.Fatalf("vote returned an error: %s", err)
+		}
+	}
+
+	counts, err := testModels.Views.GetSourceCounts(poll.ID)
+	if err != nil {
+		t.Fatalf("get source counts returned an error: %s", err)
+	}
+
+	got := map[string]SourceCount{}
+	for _, count := range counts {
+		got[count.Source] = *count
+	}
+	expected := map[string]SourceCount{
+		"slack":      {Source: "slack", Views: 2, Votes: 1},
+		"twitter":    {Source: "twitter", Views: 0, Votes: 1},
+		"newsletter": {Source: "newsletter", Views: 1, Votes: 0},
+		"":           {Source: "", Views: 1, Votes: 0},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected source counts %v, but got %v", expected, got)
+	}
+	if counts[0].Source != "slack" {
+		t.Errorf("expected the source with the most votes and views first, but got %q", counts[0].Source)
+	}
+}
+
+func TestViewsRecordAndGetStats(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	// the second view from 10.0.0.1 in the same hour isn't counted
+	for _, ip := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2"} {
+		if err := testModels.Views.Record(poll.ID, "", HashVisitor(visitorKey, ip)); err != nil {
+			t.Fatalf("record view returned an error: %s", err)
+		}
+	}
+	// views in earlier hours are forgotten once a view is recorded
+	_, _ = testDB.Exec(context.Background(),
+		`UPDATE poll_view_visitors SET hour = hour - interval '1 hour' WHERE poll_id = $1`, poll.ID)
+	_ = testModels.Views.Record(poll.ID, "", HashVisitor(visitorKey, "10.0.0.1"))
+
+	var visitors int
+	_ = testDB.QueryRow(context.Background(),
+		`SELECT count(*) FROM poll_view_visitors WHERE poll_id = $1`, poll.ID).Scan(&visitors)
+	if visitors != 1 {
+		t.Errorf("expected only the current hour's visitor to be kept, but got %d", visitors)
+	}
+
+	vote := &Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, Status: VoteStatusAccepted}
+	if err := testModels.PollOptions.Vote(vote); err != nil {
+		t.Fatalf("vote returned an error: %s", err)
+	}
+
+	stats, err := testModels.Views.GetStats(poll.ID)
+	if err != nil {
+		t.Fatalf("get stats returned an error: %s", err)
+	}
+	if stats.Views != 3 || stats.Votes != 1 {
+		t.Errorf("expected 3 views and 1 vote, but got %+v", stats)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	err := testModels.AuditLog.Insert(&Activity{PollID: poll.ID, Action: ActionPollCreated, Actor: ActorOwner})
+	if err != nil {
+		t.Fatalf("insert activity returned an error: %s", err)
+	}
+	vote := &Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, Status: VoteStatusAccepted, IP: "10.0.0.1"}
+	if err := testModels.PollOptions.Vote(vote); err != nil {
+		t.Fatalf("vote returned an error: %s", err)
+	}
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}}
+	activities, metadata, err := testModels.AuditLog.GetForPoll(poll.ID, filters)
+	if err != nil {
+		t.Fatalf("get audit log returned an error: %s", err)
+	}
+	if metadata.TotalRecords != 2 || len(activities) != 2 {
+		t.Fatalf("expected 2 entries, but got %d", len(activities))
+	}
+
+	cast := activities[0]
+	if cast.Action != ActionVoteCast || cast.Actor != ActorVoter || cast.Details["option_id"] != poll.Options[0].ID {
+		t.Errorf("expected the vote first, but got %+v", cast)
+	}
+	if _, ok := cast.Details["ip"]; ok {
+		t.Error("expected the vote's entry not to identify the voter")
+	}
+
+	filters.PageSize = 1
+	filters.Page = 2
+	activities, _, _ = testModels.AuditLog.GetForPoll(poll.ID, filters)
+	if len(activities) != 1 || activities[0].Action != ActionPollCreated {
+		t.Errorf("expected the poll's creation on the second page, but got %+v", activities)
+	}
+}
+
+func TestPollsPauseAndResume(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	pausedAt, err := testModels.Polls.Pause(poll.ID, "suspected abuse")
+	if err != nil {
+		t.Fatalf("pause returned an error: %s", err)
+	}
+	// pausing again keeps the time the poll was first paused
+	if again, _ := testModels.Polls.Pause(poll.ID, "still looking"); !again.Equal(pausedAt) {
+		t.Errorf("expected the poll to stay paused since %s, but got %s", pausedAt, again)
+	}
+
+	p, _ := testModels.Polls.Get(poll.ID)
+	if p.PausedAt == nil || p.PauseReason != "still looking" {
+		t.Errorf("expected the poll to be paused with the latest reason, but got %v %q", p.PausedAt, p.PauseReason)
+	}
+
+	if err := testModels.Polls.Resume(poll.ID); err != nil {
+		t.Fatalf("resume returned an error: %s", err)
+	}
+	p, _ = testModels.Polls.Get(poll.ID)
+	if p.PausedAt != nil || p.PauseReason != "" {
+		t.Errorf("expected the poll to be resumed, but got %v %q", p.PausedAt, p.PauseReason)
+	}
+
+	if _, err := testModels.Polls.Pause(uuid.New(), ""); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, but got %v", err)
+	}
+}
+
+func TestPollsPublish(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Question = "Unpublished draft?"
+	poll.IsDraft = true
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	listed := func() bool {
+		polls, _, err := testModels.Polls.GetAll("Unpublished draft", uuid.Nil, nil, filters)
+		if err != nil {
+			t.Fatalf("get all returned an error: %s", err)
+		}
+		return len(polls) == 1
+	}
+
+	if p, _ := testModels.Polls.Get(poll.ID); !p.IsDraft {
+		t.Error("expected the poll to be a draft")
+	}
+	if listed() {
+		t.Error("expected the draft not to be listed")
+	}
+
+	if err := testModels.Polls.Publish(poll.ID); err != nil {
+		t.Fatalf("publish returned an error: %s", err)
+	}
+	if p, _ := testModels.Polls.Get(poll.ID); p.IsDraft {
+		t.Error("expected the poll to be published")
+	}
+	if !listed() {
+		t.Error("expected the published poll to be listed")
+	}
+}
+
+func TestPollsModeration(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Question = "Moderated poll?"
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	listed := func() bool {
+		polls, _, err := testModels.Polls.GetAll("Moderated poll", uuid.Nil, nil, filters)
+		if err != nil {
+			t.Fatalf("get all returned an error: %s", err)
+		}
+		return len(polls) == 1
+	}
+	queued := func() bool {
+		polls, err := testModels.Polls.GetFlagged()
+		if err != nil {
+			t.Fatalf("get flagged returned an error: %s", err)
+		}
+		for _, p := range polls {
+			if p.ID == poll.ID {
+				return len(p.Options) == 3
+			}
+		}
+		return false
+	}
+
+	if err := testModels.Polls.Flag(poll.ID, []string{"darn"}); err != nil {
+		t.Fatalf("flag returned an error: %s", err)
+	}
+	p, _ := testModels.Polls.Get(poll.ID)
+	if p.ModerationStatus != ModerationFlagged || len(p.ModerationTerms) != 1 {
+		t.Errorf("expected the poll to be flagged for darn, but got %q %v", p.ModerationStatus, p.ModerationTerms)
+	}
+	if listed() {
+		t.Error("expected the flagged poll not to be listed")
+	}
+	if !queued() {
+		t.Error("expected the flagged poll to be queued for review")
+	}
+
+	if err := testModels.Polls.Moderate(poll.ID, ModerationApproved); err != nil {
+		t.Fatalf("moderate returned an error: %s", err)
+	}
+	if !listed() {
+		t.Error("expected the approved poll to be listed")
+	}
+	if queued() {
+		t.Error("expected the approved poll to leave the queue")
+	}
+
+	if err := testModels.Polls.Moderate(uuid.New(), ModerationApproved); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for unknown poll, but got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestReports(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	open := func() *ReportedPoll {
+		reports, err := testModels.Reports.GetOpen()
+		if err != nil {
+			t.Fatalf("get open returned an error: %s", err)
+		}
+		for _, r := range reports {
+			if r.PollID == poll.ID {
+				return r
+			}
+		}
+		return nil
+	}
+
+	for _, ip := range []string{"0.0.0.1", "0.0.0.1", "0.0.0.2"} {
+		hidden, err := testModels.Reports.Insert(poll.ID, HashVisitor(visitorKey, ip), "spam", 3)
+		if err != nil {
+			t.Fatalf("insert returned an error: %s", err)
+		}
+		if hidden {
+			t.Error("expected the poll not to be hidden below the threshold")
+		}
+	}
+	if r := open(); r == nil || r.Reports != 2 || len(r.Reasons) != 2 {
+		t.Errorf("expected 2 open reports counting each reporter once, but got %+v", r)
+	}
+
+	hidden, err := testModels.Reports.Insert(poll.ID, HashVisitor(visitorKey, "0.0.0.3"), "", 3)
+	if err != nil {
+		t.Fatalf("insert returned an error: %s", err)
+	}
+	if !hidden {
+		t.Error("expected the poll to be hidden at the threshold")
+	}
+	p, _ := testModels.Polls.Get(poll.ID)
+	if p.ModerationStatus != ModerationReported || !p.Hidden() {
+		t.Errorf("expected the poll to be reported, but got %q", p.ModerationStatus)
+	}
+	if r := open(); r == nil || r.Reports != 3 || len(r.Reasons) != 2 {
+		t.Errorf("expected 3 open reports with 2 reasons, but got %+v", r)
+	}
+
+	if err := testModels.Reports.Resolve(poll.ID, ModerationApproved); err != nil {
+		t.Fatalf("resolve returned an error: %s", err)
+	}
+	p, _ = testModels.Polls.Get(poll.ID)
+	if p.Hidden() {
+		t.Errorf("expected the approved poll to be shown, but got %q", p.ModerationStatus)
+	}
+	if r := open(); r != nil {
+		t.Errorf("expected no open reports after resolving, but got %+v", r)
+	}
+
+	// resolved reports don't stop the same reporter from reporting again
+	if _, err := testModels.Reports.Insert(poll.ID, HashVisitor(visitorKey, "0.0.0.1"), "", 3); err != nil {
+		t.Fatalf("insert returned an error: %s", err)
+	}
+	if r := open(); r == nil || r.Reports != 1 {
+		t.Errorf("expected 1 new open report, but got %+v", r)
+	}
+
+	if _, err := testModels.Reports.Insert(uuid.New(), HashVisitor(visitorKey, "0.0.0.1"), "", 3); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for unknown poll, but got %v", ErrRecordNotFound, err)
+	}
+	if err := testModels.Reports.Resolve(uuid.New(), ModerationApproved); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for unknown poll, but got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestTakedowns(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Question = "Taken down poll?"
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	listed := func() bool {
+		polls, _, err := testModels.Polls.GetAll("Taken down poll", uuid.Nil, nil, filters)
+		if err != nil {
+			t.Fatalf("get all returned an error: %s", err)
+		}
+		return len(polls) == 1
+	}
+
+	takedown := &Takedown{PollID: poll.ID, Reason: "spam"}
+	if err := testModels.Takedowns.Insert(takedown); err != nil {
+		t.Fatalf("insert returned an error: %s", err)
+	}
+	takedown.Reason = "abuse"
+	if err := testModels.Takedowns.Insert(takedown); err != nil {
+		t.Fatalf("insert again returned an error: %s", err)
+	}
+
+	got, err := testModels.Takedowns.Get(poll.ID)
+	if err != nil {
+		t.Fatalf("get returned an error: %s", err)
+	}
+	if got.Reason != "abuse" {
+		t.Errorf("expected the reason to be updated to abuse, but got %q", got.Reason)
+	}
+	p, _ := testModels.Polls.Get(poll.ID)
+	if p.RemovedAt == nil || !p.Hidden() {
+		t.Error("expected the poll to be hidden")
+	}
+	if listed() {
+		t.Error("expected the poll not to be listed")
+	}
+
+	if err := testModels.Takedowns.Delete(poll.ID); err != nil {
+		t.Fatalf("delete returned an error: %s", err)
+	}
+	if _, err := testModels.Takedowns.Get(poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v after restoring, but got %v", ErrRecordNotFound, err)
+	}
+	if !listed() {
+		t.Error("expected the restored poll to be listed")
+	}
+
+	if err := testModels.Takedowns.Insert(&Takedown{PollID: uuid.New()}); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for unknown poll, but got %v", ErrRecordNotFound, err)
+	}
+	if err := testModels.Takedowns.Delete(poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for poll not taken down, but got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestPollCreations(t *testing.T) {
+	ip := "192.0.2.44"
+	for i, expected := range []bool{true, true, false} {
+		allowed, err := testModels.Creations.Record(ip, nil, 2)
+		if err != nil {
+			t.Fatalf("record returned an error: %s", err)
+		}
+		if allowed != expected {
+			t.Errorf("expected poll %d allowed to be %t, but got %t", i+1, expected, allowed)
+		}
+	}
+
+	// polls can only be inserted for the creations counted within the limit
+	for i, expected := range []error{nil, nil, ErrCreationLimited} {
+		poll, token := createPollAndGenerateToken(t)
+		poll.CreatedBy = CreationSubject(ip, nil)
+		err := testModels.Polls.Insert(poll, token.Hash)
+		if !errors.Is(err, expected) {
+			t.Errorf("expected inserting poll %d to return %v, but got %v", i+1, expected, err)
+		}
+		if err == nil {
+			defer testModels.Polls.Delete(poll.ID)
+		}
+	}
+
+	keyHash := HashToken("CREATIONSTOKENAAAAAAAAAAAA")
+	allowed, _ := testModels.Creations.Record(ip, keyHash, 2)
+	if !allowed {
+		t.Error("expected polls created with an api key to be counted apart from the ip")
+	}
+
+	exemption := &Exemption{IP: ip, Reason: "load test"}
+	if err := testModels.Creations.InsertExemption(exemption); err != nil {
+		t.Fatalf("insert exemption returned an error: %s", err)
+	}
+	if err := testModels.Creations.InsertExemption(&Exemption{IP: ip}); !errors.Is(err, ErrAlreadyExempt) {
+		t.Errorf("expected ErrAlreadyExempt, but got %v", err)
+	}
+
+	allowed, _ = testModels.Creations.Record(ip, nil, 2)
+	if !allowed {
+		t.Error("expected exempt ip to be allowed over the limit")
+	}
+	exempt, token := createPollAndGenerateToken(t)
+	exempt.CreatedBy = CreationSubject(ip, nil)
+	if err := testModels.Polls.Insert(exempt, token.Hash); err != nil {
+		t.Errorf("expected exempt ip's poll to be inserted, but got %v", err)
+	} else {
+		defer testModels.Polls.Delete(exempt.ID)
+	}
+
+	exemptions, _ := testModels.Creations.GetExemptions()
+	if len(exemptions) != 1 || exemptions[0].Kind != BanIP || exemptions[0].IP != ip {
+		t.Errorf("expected the ip's exemption, but got %+v", exemptions)
+	}
+
+	if err := testModels.Creations.DeleteExemption(exemption.ID); err != nil {
+		t.Errorf("delete exemption returned an error: %s", err)
+	}
+	if err := testModels.Creations.DeleteExemption(exemption.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, but got %v", err)
+	}
+
+	if _, err := testModels.Creations.Purge(); err != nil {
+		t.Errorf("purge returned an error: %s", err)
+	}
+	allowed, _ = testModels.Creations.Record(ip, nil, 2)
+	if allowed {
+		t.Error("expected today's count to be kept by purge")
+	}
+}
+
+func TestBans(t *testing.T) {
+	ipBan := &Ban{IP: "2001:db8:0::1", Reason: "spam"}
+	if err := testModels.Bans.Insert(ipBan); err != nil {
+		t.Fatalf("insert returned an error: %s", err)
+	}
+	defer testModels.Bans.Delete(ipBan.ID)
+	if ipBan.Kind != BanIP || ipBan.IP != "2001:db8::1" {
+		t.Errorf("expected a normalized ip ban, but got %q %q", ipBan.Kind, ipBan.IP)
+	}
+
+	keyHash := HashToken("BANNEDKEYAAAAAAAAAAAAAAAAA")
+	keyBan := &Ban{KeyHash: keyHash}
+	if err := testModels.Bans.Insert(keyBan); err != nil {
+		t.Fatalf("insert returned an error: %s", err)
+	}
+	defer testModels.Bans.Delete(keyBan.ID)
+
+	if err := testModels.Bans.Insert(&Ban{IP: "2001:db8::1"}); !errors.Is(err, ErrAlreadyBanned) {
+		t.Errorf("expected %v for a banned ip, but got %v", ErrAlreadyBanned, err)
+	}
+	if err := testModels.Bans.Insert(&Ban{KeyHash: keyHash}); !errors.Is(err, ErrAlreadyBanned) {
+		t.Errorf("expected %v for a banned api key, but got %v", ErrAlreadyBanned, err)
+	}
+
+	tests := []struct {
+		ip       string
+		keyHash  []byte
+		expected bool
+	}{
+		{"2001:db8:0:0::1", nil, true},
+		{"", keyHash, true},
+		{"198.51.100.4", HashToken("OTHERKEYAAAAAAAAAAAAAAAAAA"), false},
+		{"", nil, false},
+	}
+	for _, test := range tests {
+		banned, err := testModels.Bans.Banned(test.ip, test.keyHash)
+		if err != nil {
+			t.Fatalf("banned returned an error: %s", err)
+		}
+		if banned != test.expected {
+			t.Errorf("banned %q %x: expected %t, but got %t", test.ip, test.keyHash, test.expected, banned)
+		}
+	}
+
+	bans, err := testModels.Bans.GetAll()
+	if err != nil {
+		t.Fatalf("get all returned an error: %s", err)
+	}
+	if len(bans) < 2 || bans[0].ID != keyBan.ID || bans[0].Kind != BanAPIKey || bans[1].IP != "2001:db8::1" {
+		t.Errorf("expected the bans newest first, but got %+v", bans)
+	}
+
+	if err := testModels.Bans.Delete(ipBan.ID); err != nil {
+		t.Fatalf("delete returned an error: %s", err)
+	}
+	if banned, _ := testModels.Bans.Banned("2001:db8::1", nil); banned {
+		t.Error("expected the lifted ban not to apply")
+	}
+	if err := testModels.Bans.Delete(ipBan.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for a lifted ban, but got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestPollsCloseExpired(t *testing.T) {
+	expired, token := createPollAndGenerateToken(t)
+	expired.ExpiresAt = ExpiresAt{time.Now().Add(-time.Minute)}
+	_ = testModels.Polls.Insert(expired, token.Hash)
+	if err := testModels.Polls.SetNotifyEmail(expired.ID, "jane@example.com"); err != nil {
+		t.Errorf("set notify email returned an error: %s", err)
+	}
+
+	open, token := createPollAndGenerateToken(t)
+	open.ExpiresAt = ExpiresAt{time.Now().Add(time.Hour)}
+	_ = testModels.Polls.Insert(open, token.Hash)
+
+	noExpiry, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(noExpiry, token.Hash)
+
+	closed, err := testModels.Polls.CloseExpired()
+	if err != nil {
+		t.Fatalf("close expired returned an error: %s", err)
+	}
+
+	var found bool
+	for _, poll := range closed {
+		if poll.ID == expired.ID {
+			found = true
+			if poll.NotifyEmail != "jane@example.com" {
+				t.Errorf("expected notify email to be returned, but got %q", poll.NotifyEmail)
+			}
+		}
+		if poll.ID == open.ID || poll.ID == noExpiry.ID {
+			t.Errorf("expected poll %s not to be closed", poll.ID)
+		}
+	}
+	if !found {
+		t.Error("expected expired poll to be closed")
+	}
+
+	closed, _ = testModels.Polls.CloseExpired()
+	for _, poll := range closed {
+		if poll.ID == expired.ID {
+			t.Error("expected expired poll to be closed only once")
+		}
+	}
+
+	_ = testModels.Polls.Delete(expired.ID)
+	_ = testModels.Polls.Delete(open.ID)
+	_ = testModels.Polls.Delete(noExpiry.ID)
+}
+
+func TestPollsDeleteExpired(t *testing.T) {
+	old, token := createPollAndGenerateToken(t)
+	old.ExpiresAt = ExpiresAt{time.Now().Add(-48 * time.Hour)}
+	_ = testModels.Polls.Insert(old, token.Hash)
+
+	recent, token := createPollAndGenerateToken(t)
+	recent.ExpiresAt = ExpiresAt{time.Now().Add(-time.Minute)}
+	_ = testModels.Polls.Insert(recent, token.Hash)
+
+	noExpiry, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(noExpiry, token.Hash)
+
+	deleted, err := testModels.Polls.DeleteExpired(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("delete expired returned an error: %s", err)
+	}
+	if deleted < 1 {
+		t.Errorf("expected at least 1 poll to be deleted, but got %d", deleted)
+	}
+
+	if _, err := testModels.Polls.Get(old.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected poll that expired long ago to be deleted, but got %v", err)
+	}
+	for _, id := range []uuid.UUID{recent.ID, noExpiry.ID} {
+		if _, err := testModels.Polls.Get(id); err != nil {
+			t.Errorf("expected poll %s to be kept, but got %v", id, err)
+		}
+	}
+
+	_ = testModels.Polls.Delete(recent.ID)
+	_ = testModels.Polls.Delete(noExpiry.ID)
+}
+
+func TestPollGetAll(t *testing.T) {
+	var poll Poll
+	for i := 1; i <= 10; i++ {
+		// sleep to delay inserting the last record
+		if i == 10 {
+			time.Sleep(1 * time.Second)
+		}
+
+		poll.Question = fmt.Sprintf("%c question", 96+i)
+		poll.Options = []*PollOption{
+			{Value: fmt.Sprintf("Option one, poll %c", 96+i), Position: 0},
+			{Value: fmt.Sprintf("Option two, poll %c", 96+i), Position: 1},
+			{Value: fmt.Sprintf("Option three, poll %c", 96+i), Position: 2},
+		}
+		token, _ := GenerateToken()
+		if err := testModels.Polls.Insert(&poll, token.Hash); err != nil {
+			t.Fatalf("get all polls - insert poll returned an error: %s", err)
+		}
+	}
+
+	// insert one private and one unlisted poll
+	pollPrivate := Poll{
+		Question: "private test",
+		Options: []*PollOption{
+			{Value: "One", Position: 0},
+			{Value: "Two", Position: 1},
+		},
+		Visibility: VisibilityPrivate,
+	}
+	token, _ := GenerateToken()
+	if err := testModels.Polls.Insert(&pollPrivate, token.Hash); err != nil {
+		t.Fatalf("get all polls - insert poll returned an error: %s", err)
+	}
+	pollUnlisted := Poll{
+		Question: "unlisted test",
+		Options: []*PollOption{
+			{Value: "One", Position: 0},
+			{Value: "Two", Position: 1},
+		},
+		Visibility: VisibilityUnlisted,
+	}
+	token, _ = GenerateToken()
+	if err := testModels.Polls.Insert(&pollUnlisted, token.Hash); err != nil {
+		t.Fatalf("get all polls - insert poll returned an error: %s", err)
+	}
+
+	tests := []struct {
+		name             string
+		search           string
+		page             int
+		pageSize         int
+		sort             string
+		expectedRecords  int
+		expectedTotal    int
+		expectedLastPage int
+	}{
+		{
+			name:             "default settings",
+			page:             1,
+			pageSize:         20,
+			sort:             "-created_at",
+			expectedRecords:  10,
+			expectedTotal:    10,
+			expectedLastPage: 1,
+		},
+		{
+			name:             "page size",
+			page:             1,
+			pageSize:         2,
+			sort:             "-created_at",
+			expectedRecords:  2,
+			expectedTotal:    10,
+			expectedLastPage: 5,
+		},
+		{
+			name:             "page",
+			page:             2,
+			pageSize:         5,
+			sort:             "-created_at",
+			expectedRecords:  5,
+			expectedTotal:    10,
+			expectedLastPage: 2,
+		},
+		{
+			name:             "sort by question asc",
+			page:             1,
+			pageSize:         20,
+			sort:             "question",
+			expectedRecords:  10,
+			expectedTotal:    10,
+			expectedLastPage: 1,
+		},
+		{
+			name:             "sort by question desc",
+			page:             1,
+			pageSize:         20,
+			sort:             "-question",
+			expectedRecords:  10,
+			expectedTotal:    10,
+			expectedLastPage: 1,
+		},
+		{
+			name:             "sort by created asc",
+			page:             1,
+			pageSize:         20,
+			sort:             "created_at",
+			expectedRecords:  10,
+			expectedTotal:    10,
+			expectedLastPage: 1,
+		},
+		{
+			name:             "search",
+			search:           "d",
+			page:             1,
+			pageSize:         20,
+			sort:             "-created_at",
+			expectedRecords:  1,
+			expectedTotal:    1,
+			expectedLastPage: 1,
+		},
+		{
+			name:             "no matches",
+			search:           "test",
+			page:             1,
+			pageSize:         20,
+			sort:             "-created_at",
+			expectedRecords:  0,
+			expectedTotal:    0,
+			expectedLastPage: 0,
+		},
+		{
+			name:             "page value too high",
+			page:             42,
+			pageSize:         20,
+			sort:             "-created_at",
+			expectedRecords:  0,
+			expectedTotal:    0,
+			expectedLastPage: 0,
+		},
+		{
+			name:             "private poll not listed",
+			search:           "private test",
+			page:             1,
+			pageSize:         20,
+			sort:             "-created_at",
+			expectedRecords:  0,
+			expectedTotal:    0,
+			expectedLastPage: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			polls, metadata, err := testModels.Polls.GetAll(test.search, uuid.Nil, nil, Filters{
+				Page:         test.page,
+				PageSize:     test.pageSize,
+				Sort:         test.sort,
+				SortSafelist: []string{"created_at", "question", "-created_at", "-question"},
+			})
+			if err != nil {
+				t.Errorf("get all polls returned an error: %s", err)
+			}
+
+			if len(polls) != test.expectedRecords {
+				t.Errorf("expected to get %d records but got %d", test.expectedRecords, len(polls))
+			}
+
+			if metadata.TotalRecords != test.expectedTotal {
+				t.Errorf(
+					"expected total records in Metadata to be %d records but got %d",
+					test.expectedTotal,
+					metadata.TotalRecords,
+				)
+			}
+			if metadata.LastPage != test.expectedLastPage {
+				t.Errorf(
+					"expected last page in Metadata to be %d records but got %d",
+					test.expectedLastPage,
+					metadata.LastPage,
+				)
+			}
+
+			if test.search == "" {
+				switch test.sort {
+				case "-created_at":
+					if metadata.CurrentPage == 1 {
+						if polls[0].Question != "j question" {
+							t.Errorf("sorting: expected first poll to be the last one iserted but got, %q", polls[0].Question)
+						}
+
+						if polls[0].Options[0].Value != "Option one, poll j" {
+							t.Errorf("options: expected option to be in first poll but got, %q", polls[0].Options[0].Value)
+						}
+
+					}
+				case "created_at":
+					if metadata.CurrentPage == 1 {
+						if polls[9].Question != "j question" {
+							t.Errorf("sorting: expected last poll to be the last one iserted but got, %q", polls[9].Question)
+						}
+
+						if polls[9].Options[0].Value != "Option one, poll j" {
+							t.Errorf("options: expected option to be in first poll but got, %q", polls[9].Options[0].Value)
+						}
+					}
+				case "question":
+					if metadata.CurrentPage == 1 {
+						if polls[0].Question != "a question" {
+							t.Errorf("sorting by question: expected first poll question to start witn 'a', but got %q", polls[0].Question)
+						}
+						if polls[9].Question != "j question" {
+							t.Errorf("sorting by question: expected last poll question to start witn 'j', but got %q", polls[9].Question)
+						}
+					}
+				case "-question":
+					if metadata.CurrentPage == 1 {
+						if polls[0].Question != "j question" {
+							t.Errorf("sorting by question: expected first poll question to start witn 'j', but got %q", polls[0].Question)
+						}
+						if polls[9].Question != "a question" {
+							t.Errorf("sorting by question: expected last poll question to start witn 'a', but got %q", polls[9].Question)
+						}
+					}
+				default:
+					t.Fatal("unknown sort value")
+				}
+			}
+
+			if test.search != "" && test.expectedRecords != 0 {
+				if !strings.Contains(polls[0].Question, test.search) {
+					t.Errorf("expected found poll question to contain %q but got %q", test.search, polls[0].Question)
+				}
+				opt := fmt.Sprintf("Option one, poll %s", test.search)
+				if polls[0].Options[0].Value != opt {
+					t.Errorf("expected option %q to be in poll but got, %q", opt, polls[0].Options[0].Value)
+				}
+			}
+		})
+	}
+
+	t.Run("private poll available with Get", func(t *testing.T) {
+		poll, err := testModels.Polls.Get(pollPrivate.ID)
+		if err != nil {
+			t.Errorf("get private poll returned an error: %s", err)
+		}
+		if poll.Question != "private test" {
+			t.Errorf("expected to get question 'private poll', but got %s", poll.Question)
+		}
+	})
+}
+
+func TestPollsMetadata(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Question = "Metadata test?"
+	poll.Metadata = map[string]string{"team": "platform", "ticket_id": "OPS-1"}
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(poll.ID)
+
+	got, err := testModels.Polls.Get(poll.ID)
+	if err != nil {
+		t.Fatalf("get poll returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(got.Metadata, poll.Metadata) {
+		t.Errorf("expected metadata %v, but got %v", poll.Metadata, got.Metadata)
+	}
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}}
+	listed := func(metadata map[string]string) bool {
+		polls, _, err := testModels.Polls.GetAll("Metadata test", uuid.Nil, metadata, filters)
+		if err != nil {
+			t.Fatalf("get all returned an error: %s", err)
+		}
+		return len(polls) == 1 && polls[0].Metadata["team"] == "platform"
+	}
+	if !listed(map[string]string{"team": "platform"}) {
+		t.Error("expected the poll to be listed by one of its metadata keys")
+	}
+	if !listed(map[string]string{"team": "platform", "ticket_id": "OPS-1"}) {
+		t.Error("expected the poll to be listed by all of its metadata keys")
+	}
+	if listed(map[string]string{"team": "platform", "ticket_id": "OPS-2"}) {
+		t.Error("expected the poll not to be listed by a different value")
+	}
+
+	got.Metadata = map[string]string{"team": "growth"}
+	if err := testModels.Polls.Update(got); err != nil {
+		t.Fatalf("update poll returned an error: %s", err)
+	}
+	updated, _ := testModels.Polls.Get(poll.ID)
+	if !reflect.DeepEqual(updated.Metadata, got.Metadata) {
+		t.Errorf("expected the metadata to be replaced with %v, but got %v", got.Metadata, updated.Metadata)
+	}
+}
+
+func TestPollsExternalID(t *testing.T) {
+	externalID := "crm-" + uuid.NewString()
+
+	poll, token := createPollAndGenerateToken(t)
+	poll.ExternalID = externalID
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(poll.ID)
+
+	id, err := testModels.Polls.GetIDByExternalID(uuid.Nil, poll.ID, externalID)
+	if err != nil {
+		t.Fatalf("get id by external id returned an error: %s", err)
+	}
+	if id != poll.ID {
+		t.Errorf("expected poll %s, but got %s", poll.ID, id)
+	}
+
+	got, _ := testModels.Polls.Get(poll.ID)
+	if got.ExternalID != externalID {
+		t.Errorf("expected external id %q, but got %q", externalID, got.ExternalID)
+	}
+
+	if _, err := testModels.Polls.GetIDByExternalID(uuid.New(), uuid.Nil, externalID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the external id not to be found in an organization, but got %v", err)
+	}
+
+	// polls without an organization of other tokens can have the same one
+	other, token := createPollAndGenerateToken(t)
+	other.ExternalID = externalID
+	if err := testModels.Polls.Insert(other, token.Hash); err != nil {
+		t.Fatalf("insert poll with the same external id returned an error: %s", err)
+	}
+	defer testModels.Polls.Delete(other.ID)
+
+	id, err = testModels.Polls.GetIDByExternalID(uuid.Nil, other.ID, externalID)
+	if err != nil || id != other.ID {
+		t.Errorf("expected poll %s, but got %s and error %v", other.ID, id, err)
+	}
+}
+
+func TestPollsGetAllQueryPlan(t *testing.T) {
+	ctx := context.Background()
+	conn, err := testDB.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire connection returned an error: %s", err)
+	}
+	defer conn.Release()
+
+	// the test table is too small for the planner to prefer indexes
+	if _, err := conn.Exec(ctx, "SET enable_seqscan = off"); err != nil {
+		t.Fatalf("disable seq scans returned an error: %s", err)
+	}
+	defer conn.Exec(ctx, "RESET enable_seqscan")
+
+	filters := Filters{
+		Page:         3,
+		PageSize:     20,
+		Sort:         "-created_at",
+		SortSafelist: []string{"created_at", "-created_at", "question"},
+	}
+	tests := []struct {
+		name     string
+		search   string
+		orgID    uuid.UUID
+		metadata map[string]string
+		sort     string
+		indexes  []string // any of them
+	}{
+		{"listed by created_at", "", uuid.Nil, nil, "-created_at", []string{"polls_listed_created_at_idx"}},
+		{"listed by question", "", uuid.Nil, nil, "question", []string{"polls_listed_question_idx"}},
+		{"search", "question", uuid.Nil, nil, "-created_at", []string{"polls_listed_search_idx", "polls_question_idx"}},
+		{"organization", "", uuid.New(), nil, "created_at", []string{"polls_org_id_created_at_idx"}},
+		{
+			"metadata", "", uuid.Nil, map[string]string{"team": "platform"}, "-created_at",
+			[]string{"polls_metadata_idx", "polls_listed_created_at_idx"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filters.Sort = test.sort
+			query, args := getAllQuery(test.search, test.orgID, test.metadata, filters)
+
+			rows, err := conn.Query(ctx, "EXPLAIN "+query, args...)
+			if err != nil {
+				t.Fatalf("explain returned an error: %s", err)
+			}
+			var plan []string
+			for rows.Next() {
+				var line string
+				if err := rows.Scan(&line); err != nil {
+					t.Fatalf("scan plan returned an error: %s", err)
+				}
+				plan = append(plan, line)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatalf("explain returned an error: %s", err)
+			}
+			explained := strings.Join(plan, "\n")
+
+			if !slices.ContainsFunc(test.indexes, func(index string) bool { return strings.Contains(explained, index) }) {
+				t.Errorf("expected the plan to use one of %v, but got:\n%s", test.indexes, explained)
+			}
+			if strings.Contains(explained, "Seq Scan on polls") {
+				t.Errorf("expected no seq scan of polls, but got:\n%s", explained)
+			}
+			if strings.Contains(explained, "WindowAgg") {
+				t.Errorf("expected the count not to be a window over the rows, but got:\n%s", explained)
+			}
+		})
+	}
+}
+
+func TestPollsSearch(t *testing.T) {
+	polls := map[string]*Poll{
+		"meeting": {Question: "Team meeting on Monday?", Tags: []string{"search-test", "work"}, Language: "en"},
+		"lunch":   {Question: "Lunch after the meeting?", Description: "team lunch", Tags: []string{"search-test", "food"}, Language: "en"},
+		"german":  {Question: "Mittagessen nach den Meetings?", Tags: []string{"search-test"}, Language: "de"},
+		"cafe":    {Question: "Où boire un café ?", Tags: []string{"search-test"}, Language: "fr"},
+		"dessert": {
+			Question: "Dessert?",
+			Options:  []*PollOption{{Value: "Crème brûlée", Position: 0}, {Value: "Tiramisù", Position: 1}},
+			Tags:     []string{"search-test"},
+		},
+		"standup":  {Question: "Monday standup?", Tags: []string{"search-test"}, ExpiresAt: ExpiresAt{time.Now().Add(-time.Hour)}},
+		"paused":   {Question: "Monday retro?", Tags: []string{"search-test"}},
+		"private":  {Question: "Private team meeting?", Tags: []string{"search-test"}, Visibility: VisibilityPrivate},
+		"unlisted": {Question: "Unlisted team meeting?", Tags: []string{"search-test"}, Visibility: VisibilityUnlisted},
+	}
+	ids := map[uuid.UUID]string{}
+	for name, poll := range polls {
+		if poll.Options == nil {
+			poll.Options = []*PollOption{{Value: "Yes", Position: 0}, {Value: "No", Position: 1}}
+		}
+		token, _ := GenerateToken()
+		if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+			t.Fatalf("search polls - insert poll returned an error: %s", err)
+		}
+		defer testModels.Polls.Delete(poll.ID)
+		ids[poll.ID] = name
+	}
+	if _, err := testModels.Polls.Pause(polls["paused"].ID, ""); err != nil {
+		t.Fatalf("search polls - pause returned an error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		q        string
+		sort     string
+		expected []string
+	}{
+		{"question ranked above description", "team", "-relevance", []string{"meeting", "lunch"}},
+		{"phrase", `"team meeting"`, "-relevance", []string{"meeting"}},
+		{"or", "lunch OR standup", "-created_at", []string{"lunch", "standup"}},
+		{"and", "monday standup", "-relevance", []string{"standup"}},
+		{"tag", "tag:food", "-created_at", []string{"lunch"}},
+		{"closed", "status:closed", "-created_at", []string{"standup"}},
+		{"paused", "status:paused", "-created_at", []string{"paused"}},
+		{"open", "monday status:open", "-relevance", []string{"meeting"}},
+		{"open in any language", "status:open", "-created_at", []string{"cafe", "dessert", "german", "lunch", "meeting"}},
+		{"stemmed in the poll's language", "meetings", "-created_at", []string{"german", "lunch", "meeting"}},
+		{"language", "lang:de", "-created_at", []string{"german"}},
+		{"without accents", "cafe", "-relevance", []string{"cafe"}},
+		{"with accents", "Café", "-relevance", []string{"cafe"}},
+		{"options", "creme OR tiramisu", "-relevance", []string{"dessert"}},
+		{"no matches", "dinner", "-relevance", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := validator.New()
+			query := search.Parse(v, "q", test.q+" tag:search-test")
+			got, metadata, err := testModels.Polls.Search(query, Filters{
+				Page:         1,
+				PageSize:     20,
+				Sort:         test.sort,
+				SortSafelist: []string{"-relevance", "-created_at"},
+			})
+			if err != nil {
+				t.Fatalf("search polls returned an error: %s", err)
+			}
+
+			var names []string
+			for _, poll := range got {
+				names = append(names, ids[poll.ID])
+			}
+			if test.sort == "-created_at" {
+				sort.Strings(names)
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("expected %v, but got %v", test.expected, names)
+			}
+			if metadata.TotalRecords != len(test.expected) {
+				t.Errorf("expected %d total records, but got %d", len(test.expected), metadata.TotalRecords)
+			}
+		})
+	}
+
+	// changed options are searched
+	dessert := polls["dessert"]
+	dessert.Options[1].Value = "Panna cotta"
+	if err := testModels.PollOptions.UpdateValue(dessert.ID, dessert.Options[1]); err != nil {
+		t.Fatalf("search polls - update option returned an error: %s", err)
+	}
+	filters := Filters{Page: 1, PageSize: 20, Sort: "-relevance", SortSafelist: []string{"-relevance"}}
+	v := validator.New()
+	for q, expected := range map[string]int{"tiramisu": 0, "panna": 1} {
+		got, _, err := testModels.Polls.Search(search.Parse(v, "q", q+" tag:search-test"), filters)
+		if err != nil {
+			t.Fatalf("search polls returned an error: %s", err)
+		}
+		if len(got) != expected {
+			t.Errorf("expected %d polls for %q after updating the option, but got %d", expected, q, len(got))
+		}
+	}
+
+	// listed polls are searched without accents too
+	got, _, err := testModels.Polls.GetAll("cafe", uuid.Nil, nil, Filters{
+		Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"},
+	})
+	if err != nil {
+		t.Fatalf("get all polls returned an error: %s", err)
+	}
+	if len(got) != 1 || got[0].ID != polls["cafe"].ID {
+		t.Errorf("expected the café poll, but got %d polls", len(got))
+	}
+}
+
+func TestPollsGetRelated(t *testing.T) {
+	polls := map[string]*Poll{
+		"source":    {Question: "Which programming languages do you use?", Tags: []string{"related-test"}},
+		"similar":   {Question: "What programming language did you learn first?"},
+		"word":      {Question: "Do you speak other languages?"},
+		"tagged":    {Question: "Tabs or spaces?", Tags: []string{"related-test"}},
+		"common":    {Question: "Which of these do you prefer?"},
+		"private":   {Question: "Programming languages at work?", Visibility: VisibilityPrivate},
+		"unlisted":  {Question: "Programming languages at school?", Visibility: VisibilityUnlisted},
+		"unrelated": {Question: "Pizza for lunch?"},
+	}
+	ids := map[uuid.UUID]string{}
+	for name, poll := range polls {
+		poll.Options = []*PollOption{{Value: "Yes", Position: 0}, {Value: "No", Position: 1}}
+		poll.Language = "en"
+		token, _ := GenerateToken()
+		if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+			t.Fatalf("get related - insert poll returned an error: %s", err)
+		}
+		defer testModels.Polls.Delete(poll.ID)
+		ids[poll.ID] = name
+	}
+
+	related, err := testModels.Polls.GetRelated(polls["source"].ID, 10)
+	if err != nil {
+		t.Fatalf("get related returned an error: %s", err)
+	}
+
+	rank := map[string]int{}
+	for i, poll := range related {
+		rank[ids[poll.ID]] = i + 1
+	}
+	// polls sharing only words like "which" and "you", which the english
+	// configuration leaves out, aren't related
+	for _, name := range []string{"similar", "word", "tagged"} {
+		if rank[name] == 0 {
+			t.Errorf("expected %q poll to be related", name)
+		}
+	}
+	if len(related) != 3 {
+		t.Errorf("expected 3 related polls, but got %d", len(related))
+	}
+	if rank["similar"] > rank["word"] {
+		t.Errorf("expected the poll sharing more words to rank higher, but got %v", rank)
+	}
+
+	related, err = testModels.Polls.GetRelated(polls["source"].ID, 1)
+	if err != nil {
+		t.Fatalf("get related returned an error: %s", err)
+	}
+	if len(related) != 1 {
+		t.Errorf("expected 1 related poll, but got %d", len(related))
+	}
+}
+
+func TestPollsGetPublicStats(t *testing.T) {
+	before, err := testModels.Polls.GetPublicStats()
+	if err != nil {
+		t.Fatalf("get public stats returned an error: %s", err)
+	}
+
+	public, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(public, token.Hash)
+	defer testModels.Polls.Delete(public.ID)
+	private, token := createPollAndGenerateToken(t)
+	private.Visibility = VisibilityUnlisted
+	_ = testModels.Polls.Insert(private, token.Hash)
+	defer testModels.Polls.Delete(private.ID)
+
+	// more votes than any other poll today, to be the most active
+	votes := before.VotesToday + 1
+	for i := 0; i < votes; i++ {
+		for _, poll := range []*Poll{public, private} {
+			err := testModels.PollOptions.Vote(&Vote{PollID: poll.ID, OptionID: poll.Options[0].ID, IP: fmt.Sprintf("10.0.%d.%d", i/256, i%256)})
+			if err != nil {
+				t.Fatalf("vote returned an error: %s", err)
+			}
+		}
+	}
+
+	stats, err := testModels.Polls.GetPublicStats()
+	if err != nil {
+		t.Fatalf("get public stats returned an error: %s", err)
+	}
+	if stats.PublicPolls != before.PublicPolls+1 || stats.VotesToday != before.VotesToday+votes {
+		t.Errorf("expected only the public poll and its votes to be counted, but got %+v before %+v", stats, before)
+	}
+	if stats.MostActivePoll == nil || stats.MostActivePoll.ID != public.ID || stats.MostActivePoll.VotesToday != votes {
+		t.Errorf("expected the public poll to be the most active, but got %+v", stats.MostActivePoll)
+	}
+}
+
+func TestPollsStream(t *testing.T) {
+	private, token := createPollAndGenerateToken(t)
+	private.Visibility = VisibilityPrivate
+	_ = testModels.Polls.Insert(private, token.Hash)
+	defer testModels.Polls.Delete(private.ID)
+
+	streamed := map[uuid.UUID]*Poll{}
+	err := testModels.Polls.Stream(uuid.Nil, func(poll *Poll) error {
+		streamed[poll.ID] = poll
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream polls returned an error: %s", err)
+	}
+	poll, ok := streamed[private.ID]
+	if !ok {
+		t.Fatal("expected the private poll to be streamed")
+	}
+	if poll.Visibility != VisibilityPrivate || len(poll.Options) != len(private.Options) {
+		t.Errorf("expected the private poll with its options, but got %+v", poll)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = testModels.Polls.Stream(uuid.Nil, func(poll *Poll) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected streaming to stop with the first error, but got %v after %d calls", err, calls)
+	}
+
+	err = testModels.Polls.Stream(uuid.New(), func(poll *Poll) error {
+		t.Errorf("expected no polls of an unknown organization, but got %s", poll.ID)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("stream organization polls returned an error: %s", err)
+	}
+}
+
+func TestPollsGetPublic(t *testing.T) {
+	public := map[uuid.UUID]bool{}
+	for i := 0; i < 3; i++ {
+		poll, token := createPollAndGenerateToken(t)
+		if i == 2 {
+			poll.ResultsVisibility = "after_deadline"
+			poll.ExpiresAt = ExpiresAt{time.Now().Add(time.Hour)}
+		}
+		_ = testModels.Polls.Insert(poll, token.Hash)
+		defer testModels.Polls.Delete(poll.ID)
+		public[poll.ID] = i == 2
+	}
+	private, token := createPollAndGenerateToken(t)
+	private.Visibility = VisibilityPrivate
+	_ = testModels.Polls.Insert(private, token.Hash)
+	defer testModels.Polls.Delete(private.ID)
+
+	seen := map[uuid.UUID]bool{}
+	after := uuid.Nil
+	for {
+		polls, err := testModels.Polls.GetPublic(after, 2)
+		if err != nil {
+			t.Fatalf("get public polls returned an error: %s", err)
+		}
+		for _, poll := range polls {
+			if poll.ID.String() <= after.String() {
+				t.Fatalf("expected polls ordered after %s, but got %s", after, poll.ID)
+			}
+			if seen[poll.ID] {
+				t.Errorf("expected poll %s once, but got it again", poll.ID)
+			}
+			seen[poll.ID] = true
+
+			heldBack, ok := public[poll.ID]
+			if ok && heldBack != (poll.TotalVotes == nil) {
+				t.Errorf("expected results held back to be %t, but got total votes %v", heldBack, poll.TotalVotes)
+			}
+			if ok && len(poll.Options) != 3 {
+				t.Errorf("expected 3 options, but got %d", len(poll.Options))
+			}
+		}
+		if len(polls) < 2 {
+			break
+		}
+		after = polls[len(polls)-1].ID
+	}
+
+	for id := range public {
+		if !seen[id] {
+			t.Errorf("expected public poll %s to be returned", id)
+		}
+	}
+	if seen[private.ID] {
+		t.Error("expected private poll not to be returned")
+	}
+}
+
+func TestVotesGetVoterNames(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	p, _ := testModels.Polls.Get(poll.ID)
+
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.1", VoterName: "Jane"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.2"})
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[1].ID, PollID: p.ID, IP: "0.0.0.3", VoterName: "John"})
+
+	names, err := testModels.Votes.GetVoterNames(p.ID)
+	if err != nil {
+		t.Errorf("get voter names returned an error: %s", err)
+	}
+
+	if len(names[p.Options[0].ID]) != 1 || names[p.Options[0].ID][0] != "Jane" {
+		t.Errorf("expected only 'Jane' to be listed for first option, but got %v", names[p.Options[0].ID])
+	}
+	if len(names[p.Options[1].ID]) != 1 || names[p.Options[1].ID][0] != "John" {
+		t.Errorf("expected only 'John' to be listed for second option, but got %v", names[p.Options[1].ID])
+	}
+	if len(names[p.Options[2].ID]) != 0 {
+		t.Errorf("expected no names for third option, but got %v", names[p.Options[2].ID])
+	}
+
+	_ = testModels.Polls.Delete(p.ID)
+}
+
+func TestVotesHasVoted(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	p, _ := testModels.Polls.Get(poll.ID)
+
+	err := testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, VoterIdentity: "slack:T1:U1"})
+	if err != nil {
+		t.Fatalf("vote without ip returned an error: %s", err)
+	}
+
+	voted, err := testModels.Votes.HasVoted(p.ID, "slack:T1:U1")
+	if err != nil {
+		t.Errorf("has voted returned an error: %s", err)
+	}
+	if !voted {
+		t.Error("expected identity to have voted")
+	}
+
+	voted, _ = testModels.Votes.HasVoted(p.ID, "slack:T1:U2")
+	if voted {
+		t.Error("expected other identity not to have voted")
+	}
+
+	ips, _ := testModels.Polls.GetVotedIPs(p.ID)
+	if len(ips) != 0 {
+		t.Errorf("expected no voted ips, but got %d", len(ips))
+	}
+
+	_ = testModels.Polls.Delete(p.ID)
+}
+
+func TestPollOptionsVoteDuplicateIdentity(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	// the same voter votes from several places at once
+	var accepted, duplicates atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := testModels.PollOptions.Vote(&Vote{
+				OptionID:      poll.Options[i%len(poll.Options)].ID,
+				PollID:        poll.ID,
+				VoterIdentity: "slack:T1:U1",
+			})
+			switch {
+			case err == nil:
+				accepted.Add(1)
+			case errors.Is(err, ErrAlreadyVoted):
+				duplicates.Add(1)
+			default:
+				t.Errorf("vote returned an error: %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if accepted.Load() != 1 || duplicates.Load() != 9 {
+		t.Errorf("expected 1 accepted and 9 duplicate votes, but got %d and %d", accepted.Load(), duplicates.Load())
+	}
+
+	options, _ := testModels.PollOptions.GetResults(poll.ID)
+	var counted int
+	for _, option := range options {
+		counted += option.VoteCount
+	}
+	p, _ := testModels.Polls.Get(poll.ID)
+	if counted != 1 || p.VotesCast != 1 {
+		t.Errorf("expected duplicates not to be counted, but got %d counted and %d cast", counted, p.VotesCast)
+	}
+
+	// the same identity can vote on other polls
+	other, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(other, token.Hash)
+	defer testModels.Polls.Delete(other.ID)
+	err := testModels.PollOptions.Vote(&Vote{OptionID: other.Options[0].ID, PollID: other.ID, VoterIdentity: "slack:T1:U1"})
+	if err != nil {
+		t.Errorf("vote on another poll returned an error: %s", err)
+	}
+}
+
+func TestVotesPartitioned(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	err := testModels.PollOptions.Vote(&Vote{OptionID: poll.Options[0].ID, PollID: poll.ID, VoterIdentity: "slack:T1:U1"})
+	if err != nil {
+		t.Fatalf("vote returned an error: %s", err)
+	}
+
+	var partition string
+	err = testDB.QueryRow(context.Background(),
+		`SELECT tableoid::regclass::text FROM votes WHERE poll_id = $1;`, poll.ID).Scan(&partition)
+	if err != nil {
+		t.Fatalf("get vote partition returned an error: %s", err)
+	}
+	if !strings.HasPrefix(partition, "votes_p") {
+		t.Errorf("expected the vote in a partition of votes, but got %s", partition)
+	}
+
+	err = testModels.PollOptions.Vote(&Vote{OptionID: poll.Options[1].ID, PollID: poll.ID, VoterIdentity: "slack:T1:U1"})
+	if !errors.Is(err, ErrAlreadyVoted) {
+		t.Errorf("expected ErrAlreadyVoted voting twice, but got %v", err)
+	}
+
+	if err := testModels.Votes.Analyze(); err != nil {
+		t.Errorf("analyze returned an error: %s", err)
+	}
+}
+
+func TestVotesModerate(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	p, _ := testModels.Polls.Get(poll.ID)
+
+	suspect := Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.1", Status: VoteStatusSuspect}
+	if err := testModels.PollOptions.Vote(&suspect); err != nil {
+		t.Fatalf("vote option returned an error: %s", err)
+	}
+	rejected := Vote{OptionID: p.Options[0].ID, PollID: p.ID, IP: "0.0.0.2", Status: VoteStatusSuspect}
+	_ = testModels.PollOptions.Vote(&rejected)
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[1].ID, PollID: p.ID, IP: "0.0.0.3"})
+
+	recent, err := testModels.Votes.GetRecent(p.ID, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Errorf("get recent votes returned an error: %s", err)
+	}
+	if len(recent) != 3 {
+		t.Errorf("expected 3 recent votes, but got %d", len(recent))
+	}
+
+	flagged, err := testModels.Votes.GetFlagged(p.ID)
+	if err != nil {
+		t.Errorf("get flagged votes returned an error: %s", err)
+	}
+	if len(flagged) != 2 {
+		t.Errorf("expected 2 flagged votes, but got %d", len(flagged))
+	}
+
+	options, _ := testModels.PollOptions.GetResults(p.ID)
+	for _, opt := range options {
+		if opt.ID == p.Options[0].ID && opt.VoteCount != 0 {
+			t.Errorf("expected suspect votes not to be counted, but got %d", opt.VoteCount)
+		}
+	}
+
+	if err := testModels.Votes.Moderate(p.ID, suspect.ID, VoteStatusAccepted); err != nil {
+		t.Errorf("moderate vote returned an error: %s", err)
+	}
+	if err := testModels.Votes.Moderate(p.ID, rejected.ID, VoteStatusRejected); err != nil {
+		t.Errorf("moderate vote returned an error: %s", err)
+	}
+	if err := testModels.Votes.Moderate(p.ID, suspect.ID, VoteStatusAccepted); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on moderating a vote twice")
+	}
+
+	options, _ = testModels.PollOptions.GetResults(p.ID)
+	for _, opt := range options {
+		if opt.ID == p.Options[0].ID && opt.VoteCount != 1 {
+			t.Errorf("expected accepted vote to be counted, but got %d", opt.VoteCount)
+		}
+	}
+
+	_ = testModels.Polls.Delete(p.ID)
+}
+
+func TestShortLinks(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+
+	if _, err := testModels.ShortLinks.GetForPoll(poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on poll without short link")
+	}
+
+	link, err := testModels.ShortLinks.Insert(poll.ID)
+	if err != nil {
+		t.Fatalf("insert short link returned an error: %s", err)
+	}
+	if !ShortCodeRX.MatchString(link.Code) {
+		t.Errorf("generated code %q has an invalid format", link.Code)
+	}
+
+	again, err := testModels.ShortLinks.Insert(poll.ID)
+	if err != nil {
+		t.Errorf("insert short link returned an error: %s", err)
+	}
+	if again.Code != link.Code {
+		t.Errorf("expected existing code %q to be returned, but got %q", link.Code, again.Code)
+	}
+
+	found, err := testModels.ShortLinks.GetByCode(link.Code)
+	if err != nil {
+		t.Errorf("get short link by code returned an error: %s", err)
+	}
+	if found.PollID != poll.ID {
+		t.Errorf("expected short link to point to poll %s, but got %s", poll.ID, found.PollID)
+	}
+
+	_ = testModels.Polls.Delete(poll.ID)
+
+	if _, err := testModels.ShortLinks.GetByCode(link.Code); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected short link to be deleted with poll")
+	}
+}
+
+func TestDigests(t *testing.T) {
+	webhookURL := "https://example.com/hooks/" + t.Name()
+
+	var polls []*Poll
+	for i := 0; i < 2; i++ {
+		poll, token := createPollAndGenerateToken(t)
+		_ = testModels.Polls.Insert(poll, token.Hash)
+		err := testModels.Digests.Subscribe(&DigestSubscription{
+			PollID:     poll.ID,
+			Frequency:  DigestHourly,
+			WebhookURL: webhookURL,
+		})
+		if err != nil {
+			t.Fatalf("subscribe returned an error: %s", err)
+		}
+		polls = append(polls, poll)
+	}
+
+	sub, err := testModels.Digests.GetSubscription(polls[0].ID)
+	if err != nil {
+		t.Fatalf("get subscription returned an error: %s", err)
+	}
+	if sub.Frequency != DigestHourly || sub.WebhookURL != webhookURL {
+		t.Errorf("unexpected subscription %+v", sub)
+	}
+
+	p, _ := testModels.Polls.Get(polls[0].ID)
+	_ = testModels.PollOptions.Vote(&Vote{OptionID: p.Options[0].ID, PollID: p.ID, VoterIdentity: "slack:T1:U1"})
+
+	ctx := context.Background()
+	_, _ = testDB.Exec(ctx, `UPDATE votes SET created_at = NOW() - interval '1 minute' WHERE poll_id = $1`, p.ID)
+
+	digests, _ := testModels.Digests.ClaimDue()
+	for _, digest := range digests {
+		if digest.WebhookURL == webhookURL {
+			t.Error("expected digest not to be due yet")
+		}
+	}
+
+	_, _ = testDB.Exec(ctx, `
+		UPDATE digest_subscriptions SET last_sent_at = NOW() - interval '2 hours'
+		WHERE webhook_url = $1`, webhookURL)
+
+	digests, err = testModels.Digests.ClaimDue()
+	if err != nil {
+		t.Fatalf("claim due returned an error: %s", err)
+	}
+
+	var digest *Digest
+	for _, d := range digests {
+		if d.WebhookURL == webhookURL {
+			if digest != nil {
+				t.Fatal("expected polls of one owner to be in a single digest")
+			}
+			digest = d
+		}
+	}
+	if digest == nil {
+		t.Fatal("expected digest to be due")
+	}
+	if len(digest.Polls) != 2 || digest.NewVotes != 1 {
+		t.Errorf("expected 1 new vote across 2 polls, but got %d across %d", digest.NewVotes, len(digest.Polls))
+	}
+
+	digests, _ = testModels.Digests.ClaimDue()
+	for _, d := range digests {
+		if d.WebhookURL == webhookURL {
+			t.Error("expected digest to be claimed only once")
+		}
+	}
+
+	if err := testModels.Digests.Unsubscribe(polls[0].ID); err != nil {
+		t.Errorf("unsubscribe returned an error: %s", err)
+	}
+	if _, err := testModels.Digests.GetSubscription(polls[0].ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected subscription to be deleted")
+	}
+
+	for _, poll := range polls {
+		_ = testModels.Polls.Delete(poll.ID)
+	}
+}
+
+func TestResultExports(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	export := &ResultExport{PollID: poll.ID, Frequency: DigestDaily, Format: ExportCSV}
+	if err := testModels.Exports.Schedule(export); err != nil {
+		t.Fatalf("schedule returned an error: %s", err)
+	}
+	if export.LastExportedAt != nil || export.CreatedAt.IsZero() {
+		t.Errorf("unexpected export %+v", export)
+	}
+
+	export.Frequency, export.Format = DigestHourly, ExportJSON
+	if err := testModels.Exports.Schedule(export); err != nil {
+		t.Fatalf("reschedule returned an error: %s", err)
+	}
+	got, err := testModels.Exports.Get(poll.ID)
+	if err != nil {
+		t.Fatalf("get returned an error: %s", err)
+	}
+	if got.Frequency != DigestHourly || got.Format != ExportJSON {
+		t.Errorf("expected schedule to be replaced, but got %+v", got)
+	}
+
+	claimed := func() bool {
+		exports, err := testModels.Exports.ClaimDue()
+		if err != nil {
+			t.Fatalf("claim due returned an error: %s", err)
+		}
+		for _, e := range exports {
+			if e.PollID == poll.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if claimed() {
+		t.Error("expected export not to be due yet")
+	}
+
+	_, _ = testDB.Exec(context.Background(), `
+		UPDATE result_exports SET created_at = NOW() - interval '2 hours'
+		WHERE poll_id = $1`, poll.ID)
+
+	if !claimed() {
+		t.Fatal("expected export to be due")
+	}
+	if claimed() {
+		t.Error("expected export to be claimed only once")
+	}
+	if got, _ := testModels.Exports.Get(poll.ID); got == nil || got.LastExportedAt == nil {
+		t.Error("expected last export time to be set")
+	}
+
+	if err := testModels.Exports.Delete(poll.ID); err != nil {
+		t.Errorf("delete returned an error: %s", err)
+	}
+	if _, err := testModels.Exports.Get(poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Error("expected export to be deleted")
+	}
+}
+
+func TestPendingVotes(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	identity := EmailVoterIdentity("Jane@example.com ")
+	if identity != EmailVoterIdentity("jane@example.com") {
+		t.Error("expected email identities to ignore case and space")
+	}
+
+	confirm, _ := GenerateToken()
+	pending := &PendingVote{Vote: &Vote{PollID: poll.ID, OptionID: poll.Options[0].ID, VoterIdentity: identity}}
+	if err := testModels.Pending.Insert(pending, confirm.Hash, time.Hour); err != nil {
+		t.Fatalf("insert pending vote returned an error: %s", err)
+	}
+
+	// voting again right away doesn't send another email
+	resend, _ := GenerateToken()
+	again := &PendingVote{Vote: &Vote{PollID: poll.ID, OptionID: poll.Options[1].ID, VoterIdentity: identity}}
+	if err := testModels.Pending.Insert(again, resend.Hash, time.Hour); !errors.Is(err, ErrConfirmationLimit) {
+		t.Errorf("expected %v, but got %v", ErrConfirmationLimit, err)
+	}
+
+	_, _ = testDB.Exec(context.Background(), `UPDATE pending_votes SET sent_at = NOW() - interval '2 minutes'`)
+	if err := testModels.Pending.Insert(again, resend.Hash, time.Hour); err != nil {
+		t.Fatalf("resend returned an error: %s", err)
+	}
+	if again.ID != pending.ID {
+		t.Errorf("expected the pending vote to be replaced, but got a new one")
+	}
+
+	if _, err := testModels.Pending.GetByToken(confirm.Hash); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the previous token to stop working, but got %v", err)
+	}
+	got, err := testModels.Pending.GetByToken(resend.Hash)
+	if err != nil {
+		t.Fatalf("get pending vote returned an error: %s", err)
+	}
+	if got.Vote.OptionID != poll.Options[1].ID || got.Vote.VoterIdentity != identity {
+		t.Errorf("expected the latest vote, but got %+v", got.Vote)
+	}
+
+	if err := testModels.Pending.Delete(got.ID); err != nil {
+		t.Errorf("delete pending vote returned an error: %s", err)
+	}
+
+	expired, _ := GenerateToken()
+	pending = &PendingVote{Vote: &Vote{PollID: poll.ID, OptionID: poll.Options[0].ID, VoterIdentity: identity}}
+	_ = testModels.Pending.Insert(pending, expired.Hash, -time.Minute)
+	if _, err := testModels.Pending.GetByToken(expired.Hash); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected expired tokens not to be found, but got %v", err)
+	}
+}
+
+func TestSMSNumbers(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+	defer testModels.Polls.Delete(poll.ID)
+
+	other, otherToken := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(other, otherToken.Hash)
+	defer testModels.Polls.Delete(other.ID)
+
+	number := &SMSNumber{PollID: poll.ID, PhoneNumber: "+15550002222"}
+	if err := testModels.SMSNumbers.Bind(number); err != nil {
+		t.Fatalf("bind sms number returned an error: %s", err)
+	}
+
+	pollID, err := testModels.SMSNumbers.GetPollID("+15550002222")
+	if err != nil || pollID != poll.ID {
+		t.Errorf("expected the number to be bound to %s, but got %q, %v", poll.ID, pollID, err)
+	}
+
+	// a number is only taken over from a closed poll
+	taken := &SMSNumber{PollID: other.ID, PhoneNumber: "+15550002222"}
+	if err := testModels.SMSNumbers.Bind(taken); !errors.Is(err, ErrNumberTaken) {
+		t.Errorf("expected %v, but got %v", ErrNumberTaken, err)
+	}
+
+	_, _ = testDB.Exec(context.Background(), `UPDATE polls SET closed_at = NOW() WHERE id = $1`, poll.ID)
+	if err := testModels.SMSNumbers.Bind(taken); err != nil {
+		t.Fatalf("bind number of a closed poll returned an error: %s", err)
+	}
+	if _, err := testModels.SMSNumbers.GetForPoll(poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the closed poll to lose its number, but got %v", err)
+	}
+
+	// binding another number replaces the poll's number
+	replaced := &SMSNumber{PollID: other.ID, PhoneNumber: "+15550003333"}
+	if err := testModels.SMSNumbers.Bind(replaced); err != nil {
+		t.Fatalf("replace sms number returned an error: %s", err)
+	}
+	got, err := testModels.SMSNumbers.GetForPoll(other.ID)
+	if err != nil || got.PhoneNumber != "+15550003333" {
+		t.Errorf("expected the replaced number, but got %+v, %v", got, err)
+	}
+	if _, err := testModels.SMSNumbers.GetPollID("+15550002222"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the previous number to be unbound, but got %v", err)
+	}
+
+	if err := testModels.SMSNumbers.Unbind(other.ID); err != nil {
+		t.Errorf("unbind sms number returned an error: %s", err)
+	}
+	if err := testModels.SMSNumbers.Unbind(other.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v, but got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestTransfers(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+
+	newToken, _ := GenerateToken()
+	transfer := &Transfer{PollID: poll.ID, Reason: "handover", IP: "1.1.1.1", UserAgent: "test"}
+	if err := testModels.Transfers.Insert(transfer, newToken.Hash); err != nil {
+		t.Fatalf("transfer returned an error: %s", err)
+	}
+
+	if _, _, err := testModels.Polls.CheckToken(token.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Error("expected previous token to be invalidated")
+	}
+	pollID, _, err := testModels.Polls.CheckToken(newToken.Plaintext)
+	if err != nil || pollID != poll.ID {
+		t.Errorf("expected new token to be valid for poll %s, but got %q (%v)", poll.ID, pollID, err)
+	}
+
+	transfers, err := testModels.Transfers.GetForPoll(poll.ID)
+	if err != nil {
+		t.Errorf("get transfers returned an error: %s", err)
+	}
+	if len(transfers) != 1 || transfers[0].Reason != "handover" {
+		t.Errorf("expected the transfer to be recorded, but got %+v", transfers)
+	}
+
+	missing := &Transfer{PollID: uuid.Nil}
+	if err := testModels.Transfers.Insert(missing, newToken.Hash); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected %v for a missing poll, but got %v", ErrRecordNotFound, err)
+	}
+
+	_ = testModels.Polls.Delete(poll.ID)
+}
+
+func TestTokensRotateAndRevoke(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+
+	rotated, _ := GenerateToken()
+	if err := testModels.Tokens.Rotate(poll.ID, token.Hash, rotated.Hash); err != nil {
+		t.Fatalf("rotate returned an error: %s", err)
+	}
+	if _, _, err := testModels.Polls.CheckToken(token.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Error("expected old token to be invalidated")
+	}
+	if pollID, _, _ := testModels.Polls.CheckToken(rotated.Plaintext); pollID != poll.ID {
+		t.Error("expected new token to be valid")
+	}
+
+	other, _ := GenerateToken()
+	if err := testModels.Tokens.Rotate(poll.ID, token.Hash, other.Hash); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected rotating an old token to return %v, but got %v", ErrRecordNotFound, err)
+	}
+
+	revoked, err := testModels.Tokens.RevokeAll(poll.ID)
+	if err != nil {
+		t.Errorf("revoke returned an error: %s", err)
+	}
+	if revoked != 1 {
+		t.Errorf("expected 1 token to be revoked, but got %d", revoked)
+	}
+	if _, _, err := testModels.Polls.CheckToken(rotated.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Error("expected token to be revoked")
+	}
+
+	_ = testModels.Polls.Delete(poll.ID)
+}
+
+func TestTokensScopeAndExpiry(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(poll, token.Hash)
+
+	results, _ := GenerateToken()
+	results.Scope = ScopeResults
+	if err := testModels.Tokens.Insert(poll.ID, results); err != nil {
+		t.Fatalf("insert token returned an error: %s", err)
+	}
+
+	expired, _ := GenerateToken()
+	if err := testModels.Tokens.Insert(poll.ID, expired); err != nil {
+		t.Fatalf("insert token returned an error: %s", err)
+	}
+	_, _ = testDB.Exec(context.Background(),
+		`UPDATE tokens SET expires_at = NOW() - interval '1 minute' WHERE hash = $1`, expired.Hash)
+
+	tests := []struct {
+		name  string
+		token string
+		scope string
+		valid bool
+	}{
+		{"manage token", token.Plaintext, ScopeManage, true},
+		{"results token", results.Plaintext, ScopeResults, true},
+		{"expired token", expired.Plaintext, ScopeManage, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pollID, scope, err := testModels.Polls.CheckToken(test.token)
+			if test.valid && (err != nil || pollID != poll.ID || scope != test.scope) {
+				t.Errorf("expected %s token to be valid, but got %q %q (%v)", test.scope, pollID, scope, err)
+			}
+			if !test.valid && !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("expected %v, but got %v", ErrRecordNotFound, err)
+			}
+		})
+	}
+
+	_ = testModels.Polls.Delete(poll.ID)
+}
+
+func TestOrganizations(t *testing.T) {
+	adminToken, _ := GenerateToken()
+	org := &Organization{Name: "Acme"}
+	admin := &Member{Name: "Jane"}
+	if err := testModels.Orgs.Insert(org, admin, adminToken.Hash); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	defer testDB.Exec(context.Background(), "DELETE FROM organizations WHERE id = $1", org.ID)
+
+	member, err := testModels.Orgs.GetMemberForToken(adminToken.Plaintext)
+	if err != nil || member.ID != admin.ID || member.Role != RoleAdmin {
+		t.Fatalf("expected admin for token, but got %+v (%v)", member, err)
+	}
+
+	editorToken, _ := GenerateToken()
+	editor := &Member{OrgID: org.ID, Name: "John", Role: RoleEditor}
+	if err := testModels.Orgs.InsertMember(editor, editorToken.Hash); err != nil {
+		t.Fatalf("insert member returned an error: %s", err)
+	}
+
+	if err := testModels.Orgs.UpdateMemberRole(&Member{ID: admin.ID, OrgID: org.ID, Role: RoleViewer}); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected demoting the last admin to return %v, but got %v", ErrLastAdmin, err)
+	}
+	if err := testModels.Orgs.DeleteMember(org.ID, admin.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected removing the last admin to return %v, but got %v", ErrLastAdmin, err)
+	}
+
+	promoted := &Member{ID: editor.ID, OrgID: org.ID, Role: RoleAdmin}
+	if err := testModels.Orgs.UpdateMemberRole(promoted); err != nil || promoted.Name != "John" {
+		t.Errorf("expected member to be promoted, but got %+v (%v)", promoted, err)
+	}
+	if err := testModels.Orgs.DeleteMember(org.ID, admin.ID); err != nil {
+		t.Errorf("delete member returned an error: %s", err)
+	}
+	if _, err := testModels.Orgs.GetMemberForToken(adminToken.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Error("expected removed member's token to be invalidated")
+	}
+
+	members, err := testModels.Orgs.GetMembers(org.ID)
+	if err != nil || len(members) != 1 {
+		t.Errorf("expected 1 member, but got %d (%v)", len(members), err)
+	}
+
+	poll, token := createPollAndGenerateToken(t)
+	poll.OrgID = &org.ID
+	_ = testModels.Polls.Insert(poll, token.Hash)
+
+	got, err := testModels.Polls.Get(poll.ID)
+	if err != nil || got.OrgID == nil || *got.OrgID != org.ID {
+		t.Errorf("expected poll in organization %s, but got %+v (%v)", org.ID, got, err)
+	}
+
+	polls, _, err := testModels.Polls.GetAll("", org.ID, nil, Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}})
+	if err != nil || len(polls) != 1 || polls[0].ID != poll.ID {
+		t.Errorf("expected the organization's poll to be listed, but got %v (%v)", polls, err)
+	}
+	public, _, _ := testModels.Polls.GetAll("", uuid.Nil, nil, Filters{Page: 1, PageSize: 100, Sort: "-created_at", SortSafelist: []string{"-created_at"}})
+	for _, p := range public {
+		if p.ID == poll.ID {
+			t.Error("expected the organization's poll not to be listed publicly")
+		}
+	}
+}
+
+func TestPollsGetDeadlines(t *testing.T) {
+	adminToken, _ := GenerateToken()
+	org := &Organization{Name: "Acme"}
+	if err := testModels.Orgs.Insert(org, &Member{Name: "Jane"}, adminToken.Hash); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	defer testDB.Exec(context.Background(), "DELETE FROM organizations WHERE id = $1", org.ID)
+
+	var polls []*Poll
+	for _, expiresIn := range []time.Duration{48 * time.Hour, 0, time.Hour} {
+		poll, token := createPollAndGenerateToken(t)
+		poll.OrgID = &org.ID
+		if expiresIn > 0 {
+			poll.ExpiresAt = ExpiresAt{time.Now().Add(expiresIn)}
+		}
+		_ = testModels.Polls.Insert(poll, token.Hash)
+		defer testModels.Polls.Delete(poll.ID)
+		polls = append(polls, poll)
+	}
+
+	deadlines, err := testModels.Polls.GetDeadlines(org.ID, 10)
+	if err != nil {
+		t.Fatalf("get deadlines returned an error: %s", err)
+	}
+	if len(deadlines) != 2 || deadlines[0].ID != polls[2].ID || deadlines[1].ID != polls[0].ID {
+		t.Fatalf("expected the polls with an expiry time, soonest first, but got %d", len(deadlines))
+	}
+
+	_, _ = testDB.Exec(context.Background(), `UPDATE polls SET closed_at = NOW() WHERE id = $1`, polls[2].ID)
+
+	deadlines, _ = testModels.Polls.GetDeadlines(org.ID, 10)
+	if len(deadlines) != 1 || deadlines[0].ID != polls[0].ID {
+		t.Errorf("expected closed polls to be left out, but got %d", len(deadlines))
+	}
+}
+
+func TestUsage(t *testing.T) {
+	adminToken, _ := GenerateToken()
+	org := &Organization{Name: "Acme"}
+	if err := testModels.Orgs.Insert(org, &Member{Name: "Jane"}, adminToken.Hash); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	defer testDB.Exec(context.Background(), "DELETE FROM organizations WHERE id = $1", org.ID)
+
+	usage, err := testModels.Usage.Get(org.ID)
+	if err != nil {
+		t.Fatalf("get usage returned an error: %s", err)
+	}
+	if usage.PollsCreated != 0 || usage.VotesReceived != 0 || usage.ActivePolls != 0 {
+		t.Errorf("expected no usage, but got %+v", usage)
+	}
+
+	poll, token := createPollAndGenerateToken(t)
+	poll.OrgID = &org.ID
+	_ = testModels.Polls.Insert(poll, token.Hash)
+
+	err = testModels.PollOptions.Vote(&Vote{PollID: poll.ID, OptionID: poll.Options[0].ID, IP: "1.1.1.1"})
+	if err != nil {
+		t.Fatalf("vote returned an error: %s", err)
+	}
+
+	if err := testModels.Polls.Delete(poll.ID); err != nil {
+		t.Fatalf("delete poll returned an error: %s", err)
+	}
+
+	usage, _ = testModels.Usage.Get(org.ID)
+	if usage.PollsCreated != 1 || usage.VotesReceived != 1 {
+		t.Errorf("expected usage to be kept after deleting the poll, but got %+v", usage)
+	}
+	if usage.ActivePolls != 0 {
+		t.Errorf("expected no active polls, but got %d", usage.ActivePolls)
+	}
+}
+
+func TestPollsInsertActiveQuota(t *testing.T) {
+	adminToken, _ := GenerateToken()
+	org := &Organization{Name: "Acme"}
+	if err := testModels.Orgs.Insert(org, &Member{Name: "Jane"}, adminToken.Hash); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	defer testDB.Exec(context.Background(), "DELETE FROM organizations WHERE id = $1", org.ID)
+	defer testDB.Exec(context.Background(), "DELETE FROM polls WHERE org_id = $1", org.ID)
+
+	// polls created at the same time are counted in turn, so only as many as
+	// the quota allows are inserted
+	var inserted, refused atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		poll, token := createPollAndGenerateToken(t)
+		poll.OrgID = &org.ID
+		poll.ActiveQuota = 3
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := testModels.Polls.Insert(poll, token.Hash)
+			switch {
+			case err == nil:
+				inserted.Add(1)
+			case errors.Is(err, ErrQuotaExceeded):
+				refused.Add(1)
+			default:
+				t.Errorf("insert poll returned an error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inserted.Load() != 3 || refused.Load() != 7 {
+		t.Errorf("expected 3 polls inserted and 7 refused, but got %d and %d", inserted.Load(), refused.Load())
+	}
+
+	// polls without a quota aren't limited
+	poll, token := createPollAndGenerateToken(t)
+	poll.OrgID = &org.ID
+	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+		t.Errorf("insert poll without a quota returned an error: %s", err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	adminToken, _ := GenerateToken()
+	org := &Organization{Name: "Acme"}
+	if err := testModels.Orgs.Insert(org, &Member{Name: "Jane"}, adminToken.Hash); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	defer testDB.Exec(context.Background(), "DELETE FROM organizations WHERE id = $1", org.ID)
+
+	key, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("generate api key returned an error: %s", err)
+	}
+	key.OrgID, key.Name, key.Role = org.ID, "CRM sync", RoleEditor
+	if err := testModels.APIKeys.Insert(key); err != nil {
+		t.Fatalf("insert returned an error: %s", err)
+	}
+
+	got, err := testModels.APIKeys.GetForKey(key.Plaintext)
+	if err != nil {
+		t.Fatalf("get for key returned an error: %s", err)
+	}
+	if got.ID != key.ID || got.OrgID != org.ID || got.Role != RoleEditor {
+		t.Errorf("expected key %d of %s, but got %+v", key.ID, org.ID, got)
+	}
+
+	// a key with the right prefix but another secret must not be found
+	forged := key.Plaintext[:len(key.Plaintext)-1] + "A"
+	if forged == key.Plaintext {
+		forged = key.Plaintext[:len(key.Plaintext)-1] + "B"
+	}
+	if _, err := testModels.APIKeys.GetForKey(forged); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for a forged key, but got %v", err)
+	}
+
+	keys, _ := testModels.APIKeys.GetAll(org.ID)
+	if len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Errorf("expected the key with when it was last used, but got %+v", keys)
+	}
+
+	if err := testModels.APIKeys.Revoke(org.ID, key.ID); err != nil {
+		t.Errorf("revoke returned an error: %s", err)
+	}
+	if err := testModels.APIKeys.Revoke(org.ID, key.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, but got %v", err)
+	}
+	if _, err := testModels.APIKeys.GetForKey(key.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected revoked key not to be found, but got %v", err)
+	}
+}
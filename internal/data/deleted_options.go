@@ -0,0 +1,228 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeletedOption is an option deleted from a poll, kept with the votes it had
+// so it can be restored until RestoreUntil.
+type DeletedOption struct {
+	ID           uuid.UUID `json:"id"`
+	Value        string    `json:"value"`
+	Description  string    `json:"description,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	Position     int       `json:"position"`
+	VoteCount    int       `json:"vote_count"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	RestoreUntil time.Time `json:"restore_until"`
+}
+
+// archiveDeletedOption keeps the options deleted by the statement's deleted
+// CTE, with their votes. The votes are read before the delete cascades to
+// them, as all of a statement's parts see the same snapshot.
+const archiveDeletedOption = `
+	INSERT INTO deleted_options (id, poll_id, value, description, url, group_id, position, vote_count, votes)
+	SELECT d.id, d.poll_id, d.value, d.description, d.url, d.group_id, d.position, d.vote_count,
+	(SELECT COALESCE(jsonb_agg(to_jsonb(v)), '[]') FROM votes v WHERE v.poll_id = d.poll_id AND v.option_id = d.id)
+	FROM deleted d
+`
+
+type DeletedOptionModel struct {
+	DB *pgxpool.Pool
+}
+
+// GetAll returns the poll's options that were deleted within window and can
+// still be restored, last deleted first.
+func (m DeletedOptionModel) GetAll(pollID uuid.UUID, window time.Duration) ([]*DeletedOption, error) {
+	query := `
+		SELECT id, value, description, url, position, vote_count, deleted_at
+		FROM deleted_options
+		WHERE poll_id = $1 AND deleted_at > $2
+		ORDER BY deleted_at DESC, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query, pollID, time.Now().Add(-window))
+	if err != nil {
+		return nil, fmt.Errorf("get deleted options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []*DeletedOption{}
+	for rows.Next() {
+		var option DeletedOption
+		err := rows.Scan(
+			&option.ID,
+			&option.Value,
+			&option.Description,
+			&option.URL,
+			&option.Position,
+			&option.VoteCount,
+			&option.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get deleted options - scan: %w", err)
+		}
+		option.RestoreUntil = option.DeletedAt.Add(window)
+		options = append(options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get deleted options: %w", err)
+	}
+
+	return options, nil
+}
+
+// Restore puts the option deleted within window back in the poll with its
+// votes, at its old position or last if the poll has fewer options now. Votes
+// of voters who have voted again since aren't restored, so the option's vote
+// count is recounted. It fails with ErrRecordNotFound if the option can't be
+// restored, with ErrDuplicateOption if the poll has an option with its value
+// again, and with ErrTooManyOptions if the poll has MaxOptions options.
+func (m DeletedOptionModel) Restore(pollID uuid.UUID, optionID uuid.UUID, window time.Duration) (*PollOption, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("restore option: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the poll is locked like when voting, so the positions and votes
+	// don't change while the option is put back
+	queryPoll := `
+		SELECT (SELECT count(*) FROM poll_options WHERE poll_id = p.id)
+		FROM polls p
+		WHERE p.id = $1
+		FOR UPDATE;
+	`
+	var optionCount int
+	if err := tx.QueryRow(ctx, queryPoll, pollID).Scan(&optionCount); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("restore option - lock poll: %w", err)
+	}
+
+	queryDeleted := `
+		SELECT id, value, description, url, group_id, position
+		FROM deleted_options
+		WHERE id = $1 AND poll_id = $2 AND deleted_at > $3;
+	`
+	var option PollOption
+	err = tx.QueryRow(ctx, queryDeleted, optionID, pollID, time.Now().Add(-window)).Scan(
+		&option.ID,
+		&option.Value,
+		&option.Description,
+		&option.URL,
+		&option.GroupID,
+		&option.Position,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("restore option - get deleted option: %w", err)
+	}
+	option.Position = min(option.Position, optionCount)
+
+	queryPositions := `
+		UPDATE poll_options
+		SET position = position + 1
+		WHERE poll_id = $1 AND position >= $2;
+	`
+	if _, err := tx.Exec(ctx, queryPositions, pollID, option.Position); err != nil {
+		return nil, fmt.Errorf("restore option - update positions: %w", err)
+	}
+
+	// the option's group may have been deleted since
+	queryOption := `
+		INSERT INTO poll_options (id, poll_id, value, position, description, url, group_id)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT id FROM option_groups WHERE id = $7 AND poll_id = $2))
+		RETURNING group_id;
+	`
+	err = tx.QueryRow(
+		ctx, queryOption, option.ID, pollID, option.Value, option.Position, option.Description, option.URL,
+		option.GroupID,
+	).Scan(&option.GroupID)
+	if err != nil {
+		if uniqueViolation(err, "poll_options_poll_id_value_key") {
+			return nil, ErrDuplicateOption
+		}
+		if checkViolation(err, "poll_options_max_options") {
+			return nil, ErrTooManyOptions
+		}
+		return nil, fmt.Errorf("restore option - insert option: %w", err)
+	}
+
+	queryVotes := `
+		WITH restored AS (
+			INSERT INTO votes
+			SELECT v.*
+			FROM deleted_options d, jsonb_populate_recordset(NULL::votes, d.votes) v
+			WHERE d.id = $1
+			ON CONFLICT DO NOTHING
+			RETURNING status
+		)
+		UPDATE poll_options
+		SET vote_count = (SELECT count(*) FROM restored WHERE status = $2)
+		WHERE id = $1
+		RETURNING vote_count;
+	`
+	if err := tx.QueryRow(ctx, queryVotes, option.ID, VoteStatusAccepted).Scan(&option.VoteCount); err != nil {
+		return nil, fmt.Errorf("restore option - restore votes: %w", err)
+	}
+
+	queryArchive := `
+		DELETE FROM deleted_options
+		WHERE id = $1;
+	`
+	if _, err := tx.Exec(ctx, queryArchive, option.ID); err != nil {
+		return nil, fmt.Errorf("restore option - delete archived option: %w", err)
+	}
+
+	queryUpdatedAt := `
+		UPDATE polls
+		SET updated_at = NOW()
+		WHERE id = $1;
+	`
+	if _, err := tx.Exec(ctx, queryUpdatedAt, pollID); err != nil {
+		return nil, fmt.Errorf("restore option - set updated_at: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("restore option: %w", err)
+	}
+
+	return &option, nil
+}
+
+// Purge deletes the options deleted longer ago than window for good, and
+// returns how many there were.
+func (m DeletedOptionModel) Purge(window time.Duration) (int64, error) {
+	query := `
+		DELETE FROM deleted_options
+		WHERE deleted_at <= $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, time.Now().Add(-window))
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted options: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
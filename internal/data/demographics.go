@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+var demographicKeyRX = regexp.MustCompile("^[a-z][a-z0-9_]*$")
+
+// DemographicQuestion is an optional question voters answer along with their
+// vote, e.g. their age range, so results can be segmented by the answers.
+type DemographicQuestion struct {
+	Key     string   `json:"key"`
+	Label   string   `json:"label,omitempty"`
+	Choices []string `json:"choices"`
+}
+
+// Demographic returns the demographic question with the key.
+func (p *Poll) Demographic(key string) (DemographicQuestion, bool) {
+	for _, question := range p.Demographics {
+		if question.Key == key {
+			return question, true
+		}
+	}
+	return DemographicQuestion{}, false
+}
+
+func ValidateDemographics(v *validator.Validator, questions []DemographicQuestion) {
+	v.Check(len(questions) <= 5, "demographics", "must not contain more than 5 questions")
+
+	keys := make([]string, 0, len(questions))
+	for _, question := range questions {
+		keys = append(keys, question.Key)
+
+		v.Check(validator.Matches(question.Key, demographicKeyRX), "demographics", "keys must be lowercase letters, digits and underscores")
+		v.Check(len(question.Key) <= 50, "demographics", "keys must not be more than 50 bytes long")
+		v.Check(len(question.Label) <= 200, "demographics", "labels must not be more than 200 bytes long")
+		v.Check(len(question.Choices) >= 2, "demographics", "questions must have at least two choices")
+		v.Check(len(question.Choices) <= 20, "demographics", "questions must not have more than 20 choices")
+		v.Check(validator.Unique(question.Choices), "demographics", "choices must not contain duplicate values")
+		for _, choice := range question.Choices {
+			v.Check(choice != "", "demographics", "choices must not be empty")
+			v.Check(len(choice) <= 100, "demographics", "choices must not be more than 100 bytes long")
+		}
+	}
+	v.Check(validator.Unique(keys), "demographics", "keys must be unique")
+}
+
+// ValidateDemographicAnswers checks a voter's answers to the poll's
+// demographic questions. Answering is optional.
+func ValidateDemographicAnswers(v *validator.Validator, poll *Poll, answers map[string]string) {
+	for key, answer := range answers {
+		question, ok := poll.Demographic(key)
+		if !ok {
+			v.AddError("demographics."+key, "is not a question of this poll")
+			continue
+		}
+		v.Check(validator.PermittedValue(answer, question.Choices...), "demographics."+key, "must be one of the question's choices")
+	}
+}
+
+func answersOrEmpty(answers map[string]string) map[string]string {
+	if answers == nil {
+		return map[string]string{}
+	}
+	return answers
+}
+
+// GetSegmentCounts counts the accepted votes on a poll per answer to a
+// demographic question and option ID. Votes without an answer are counted
+// under "".
+func (v VoteModel) GetSegmentCounts(pollID uuid.UUID, key string) (map[string]map[uuid.UUID]int, error) {
+	query := `
+		SELECT COALESCE(demographics->>$2, ''), option_id, count(*)
+		FROM votes
+		WHERE poll_id = $1 AND status = 'accepted'
+		GROUP BY 1, 2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID, key)
+	if err != nil {
+		return nil, fmt.Errorf("get segment counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]map[uuid.UUID]int{}
+	for rows.Next() {
+		var answer string
+		var optionID uuid.UUID
+		var count int
+		if err := rows.Scan(&answer, &optionID, &count); err != nil {
+			return nil, fmt.Errorf("get segment counts - scan: %w", err)
+		}
+		if counts[answer] == nil {
+			counts[answer] = map[uuid.UUID]int{}
+		}
+		counts[answer][optionID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get segment counts: %w", err)
+	}
+
+	return counts, nil
+}
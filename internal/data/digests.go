@@ -0,0 +1,240 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// DigestSubscription subscribes a poll to a periodic summary of its new votes,
+// sent by email or to a webhook. Polls subscribed with the same frequency and
+// recipient belong to the same owner and are summarized together.
+type DigestSubscription struct {
+	PollID     uuid.UUID `json:"poll_id"`
+	Frequency  string    `json:"frequency"`
+	Email      string    `json:"email,omitempty"`
+	WebhookURL string    `json:"webhook_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Digest summarizes the votes cast between Since and Until on all polls of
+// one owner.
+type Digest struct {
+	Frequency  string        `json:"frequency"`
+	Email      string        `json:"-"`
+	WebhookURL string        `json:"-"`
+	Since      time.Time     `json:"since"`
+	Until      time.Time     `json:"until"`
+	NewVotes   int           `json:"new_votes"`
+	Polls      []*DigestPoll `json:"polls"`
+}
+
+type DigestPoll struct {
+	PollID     uuid.UUID `json:"poll_id"`
+	Question   string    `json:"question"`
+	NewVotes   int       `json:"new_votes"`
+	TotalVotes int       `json:"total_votes"`
+	URL        string    `json:"url,omitempty"`
+}
+
+type DigestModel struct {
+	DB *pgxpool.Pool
+}
+
+// Subscribe creates or replaces the poll's subscription. A poll joining an
+// owner's existing digest picks up its schedule, so all their polls keep
+// being summarized in one message.
+func (d DigestModel) Subscribe(sub *DigestSubscription) error {
+	query := `
+		INSERT INTO digest_subscriptions (poll_id, frequency, email, webhook_url, last_sent_at)
+		VALUES ($1, $2, $3, $4, COALESCE((
+			SELECT max(last_sent_at) FROM digest_subscriptions
+			WHERE frequency = $2 AND email = $3 AND webhook_url = $4
+		), NOW()))
+		ON CONFLICT (poll_id) DO UPDATE
+		SET frequency = EXCLUDED.frequency,
+			email = EXCLUDED.email,
+			webhook_url = EXCLUDED.webhook_url,
+			last_sent_at = EXCLUDED.last_sent_at
+		RETURNING created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := d.DB.QueryRow(ctx, query, sub.PollID, sub.Frequency, sub.Email, sub.WebhookURL).Scan(&sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("subscribe to digest: %w", err)
+	}
+
+	return nil
+}
+
+func (d DigestModel) GetSubscription(pollID uuid.UUID) (*DigestSubscription, error) {
+	query := `
+		SELECT poll_id, frequency, email, webhook_url, created_at
+		FROM digest_subscriptions
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var sub DigestSubscription
+	err := d.DB.QueryRow(ctx, query, pollID).Scan(
+		&sub.PollID,
+		&sub.Frequency,
+		&sub.Email,
+		&sub.WebhookURL,
+		&sub.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get digest subscription: %w", err)
+	}
+
+	return &sub, nil
+}
+
+func (d DigestModel) Unsubscribe(pollID uuid.UUID) error {
+	query := `
+		DELETE FROM digest_subscriptions
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := d.DB.Exec(ctx, query, pollID)
+	if err != nil {
+		return fmt.Errorf("unsubscribe from digest: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue returns the digests whose period has passed, one per owner, and
+// starts their next period. Each digest is returned once, even with several
+// servers sending digests concurrently.
+func (d DigestModel) ClaimDue() ([]*Digest, error) {
+	query := `
+		WITH due AS (
+			SELECT poll_id, last_sent_at
+			FROM digest_subscriptions
+			WHERE last_sent_at <= NOW() - CASE frequency
+				WHEN 'hourly' THEN interval '1 hour'
+				ELSE interval '1 day'
+			END
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE digest_subscriptions s
+			SET last_sent_at = NOW()
+			FROM due
+			WHERE s.poll_id = due.poll_id
+			RETURNING s.poll_id, s.frequency, s.email, s.webhook_url, due.last_sent_at AS since, s.last_sent_at AS until
+		)
+		SELECT c.frequency, c.email, c.webhook_url, c.since, c.until, p.id, p.question,
+			(SELECT count(*) FROM votes v
+				WHERE v.poll_id = p.id AND v.status = 'accepted'
+				AND v.created_at > c.since AND v.created_at <= c.until),
+			(SELECT COALESCE(sum(o.vote_count), 0) FROM poll_options o WHERE o.poll_id = p.id)
+		FROM claimed c
+		INNER JOIN polls p ON p.id = c.poll_id
+		ORDER BY c.frequency, c.email, c.webhook_url, p.created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := d.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("claim due digests: %w", err)
+	}
+	defer rows.Close()
+
+	var digests []*Digest
+	var current *Digest
+
+	for rows.Next() {
+		var digest Digest
+		var poll DigestPoll
+		err := rows.Scan(
+			&digest.Frequency,
+			&digest.Email,
+			&digest.WebhookURL,
+			&digest.Since,
+			&digest.Until,
+			&poll.PollID,
+			&poll.Question,
+			&poll.NewVotes,
+			&poll.TotalVotes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("claim due digests - scan: %w", err)
+		}
+
+		// rows are ordered by owner, so a new owner starts a new digest
+		if current == nil || current.Frequency != digest.Frequency ||
+			current.Email != digest.Email || current.WebhookURL != digest.WebhookURL {
+			current = &digest
+			digests = append(digests, current)
+		}
+		current.NewVotes += poll.NewVotes
+		current.Polls = append(current.Polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due digests: %w", err)
+	}
+
+	return digests, nil
+}
+
+func ValidateDigestSubscription(v *validator.Validator, sub *DigestSubscription) {
+	v.Check(validator.PermittedValue(
+		sub.Frequency, DigestHourly, DigestDaily,
+	), "frequency", "must be hourly or daily")
+	v.Check(
+		sub.Email == "" || sub.WebhookURL == "",
+		"webhook_url",
+		"must not be set together with email",
+	)
+
+	switch {
+	case sub.Email != "":
+		ValidateEmail(v, sub.Email, "email")
+	case sub.WebhookURL != "":
+		ValidateWebhookURL(v, sub.WebhookURL, "webhook_url")
+	default:
+		v.AddError("email", "email or webhook_url must be provided")
+	}
+}
+
+func ValidateWebhookURL(v *validator.Validator, webhookURL string, key string) {
+	v.Check(len(webhookURL) <= 2048, key, "must not be more than 2048 bytes long")
+	v.Check(absoluteHTTPURL(webhookURL), key, "must be an absolute http or https URL")
+}
+
+// absoluteHTTPURL reports whether s is an http or https URL with a host.
+func absoluteHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,220 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PublicPoll is a public poll in a dump of the database for analytics, with
+// its results if anyone can see them yet. Results held back until the poll's
+// deadline or results threshold are null.
+type PublicPoll struct {
+	ID                uuid.UUID       `json:"id"`
+	Question          string          `json:"question"`
+	Description       string          `json:"description"`
+	CreatedAt         time.Time       `json:"created_at"`
+	ExpiresAt         ExpiresAt       `json:"expires_at"`
+	ResultsVisibility string          `json:"results_visibility"`
+	ResultsThreshold  int             `json:"results_threshold"`
+	TotalVotes        *int            `json:"total_votes"`
+	Options           []*PublicOption `json:"options"`
+}
+
+type PublicOption struct {
+	ID       uuid.UUID `json:"id"`
+	Value    string    `json:"value"`
+	Position int       `json:"position"`
+	Votes    *int      `json:"votes"`
+}
+
+// GetPublic returns up to limit public polls, the polls listed by GetAll
+// without an organization, ordered by ID and starting after the poll with the
+// ID after, so large dumps can be read in batches and resumed. A nil after
+// starts with the first poll.
+func (p PollModel) GetPublic(after uuid.UUID, limit int) ([]*PublicPoll, error) {
+	query := `
+		SELECT p.id, p.question, p.description, p.created_at, p.expires_at, p.results_visibility,
+		p.results_threshold,
+		jsonb_agg(jsonb_build_object(
+			'id', po.id, 'value', po.value, 'position', po.position, 'votes', po.vote_count
+		) ORDER BY po.position) AS options
+		FROM polls p
+		JOIN poll_options po ON po.poll_id = p.id
+		WHERE p.id > $1::uuid
+		AND p.org_id IS NULL AND p.visibility = 'public' AND p.is_draft = false
+		AND p.moderation_status NOT IN ('flagged', 'reported', 'rejected')
+		AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)
+		GROUP BY p.id
+		ORDER BY p.id
+		LIMIT $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get public polls: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []*PublicPoll{}
+
+	for rows.Next() {
+		var poll PublicPoll
+		var optionsJson string
+		err := rows.Scan(
+			&poll.ID,
+			&poll.Question,
+			&poll.Description,
+			&poll.CreatedAt,
+			&poll.ExpiresAt.Time,
+			&poll.ResultsVisibility,
+			&poll.ResultsThreshold,
+			&optionsJson,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get public polls - scan: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(optionsJson), &poll.Options); err != nil {
+			return nil, fmt.Errorf("get public polls - unmarshal options: %w", err)
+		}
+		poll.holdBackResults()
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get public polls: %w", err)
+	}
+
+	return polls, nil
+}
+
+// holdBackResults sets the poll's total votes and clears the options' votes
+// if the results can't be seen by anyone yet.
+func (p *PublicPoll) holdBackResults() {
+	total := 0
+	for _, option := range p.Options {
+		if option.Votes != nil {
+			total += *option.Votes
+		}
+	}
+
+	beforeDeadline := p.ResultsVisibility == "after_deadline" && !p.ExpiresAt.IsZero() &&
+		p.ExpiresAt.After(time.Now())
+	if beforeDeadline || total < p.ResultsThreshold {
+		for _, option := range p.Options {
+			option.Votes = nil
+		}
+		return
+	}
+
+	p.TotalVotes = &total
+}
+
+// streamTimeout is how long a stream of polls may take, as the client reads
+// it while it is streamed.
+const streamTimeout = 10 * time.Minute
+
+// Stream calls fn with each of the organization's polls, or with every poll
+// if orgID is nil, ordered by ID. Rows are read from the database as they
+// are needed, so the polls are never all held in memory. Streaming stops
+// with the first error fn returns.
+func (p PollModel) Stream(orgID uuid.UUID, fn func(*Poll) error) error {
+	args := []any{}
+	condition := "true"
+	if orgID != uuid.Nil {
+		args = append(args, orgID)
+		condition = `p.org_id = $1 AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)`
+	}
+
+	query := `
+		SELECT p.id, p.question, p.question_variants, p.description, p.created_at, p.updated_at, p.expires_at,
+		p.results_visibility, p.visibility, p.is_draft, p.shuffle_options, p.verifiable, p.confirm_votes,
+		p.anonymity, p.allowed_countries, p.denied_countries, p.org_id, p.results_threshold,
+		p.tie_break, p.series_id, p.max_votes, p.paused_at, p.pause_reason,
+		p.moderation_status, p.tags, p.language, p.metadata, COALESCE(p.external_id, ''),
+		COALESCE((
+			SELECT jsonb_agg(jsonb_build_object(
+				'id', po.id, 'value', po.value, 'position', po.position,
+				'description', po.description, 'url', po.url, 'group_id', po.group_id
+			) ORDER BY po.position)
+			FROM poll_options po
+			WHERE po.poll_id = p.id
+		), '[]') AS options,
+		` + optionGroups + ` AS groups
+		FROM polls p
+		WHERE ` + condition + `
+		ORDER BY p.id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), streamTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("stream polls: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var poll Poll
+		var optionsJson, groupsJson string
+		err := rows.Scan(
+			&poll.ID,
+			&poll.Question,
+			&poll.QuestionVariants,
+			&poll.Description,
+			&poll.CreatedAt,
+			&poll.UpdatedAt,
+			&poll.ExpiresAt.Time,
+			&poll.ResultsVisibility,
+			&poll.Visibility,
+			&poll.IsDraft,
+			&poll.ShuffleOptions,
+			&poll.Verifiable,
+			&poll.ConfirmVotes,
+			&poll.Anonymity,
+			&poll.AllowedCountries,
+			&poll.DeniedCountries,
+			&poll.OrgID,
+			&poll.ResultsThreshold,
+			&poll.TieBreak,
+			&poll.SeriesID,
+			&poll.MaxVotes,
+			&poll.PausedAt,
+			&poll.PauseReason,
+			&poll.ModerationStatus,
+			&poll.Tags,
+			&poll.Language,
+			&poll.Metadata,
+			&poll.ExternalID,
+			&optionsJson,
+			&groupsJson,
+		)
+		if err != nil {
+			return fmt.Errorf("stream polls - scan: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(optionsJson), &poll.Options); err != nil {
+			return fmt.Errorf("stream polls - unmarshal options: %w", err)
+		}
+		if err := json.Unmarshal([]byte(groupsJson), &poll.Groups); err != nil {
+			return fmt.Errorf("stream polls - unmarshal groups: %w", err)
+		}
+		if err := fn(&poll); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("stream polls: %w", err)
+	}
+
+	return nil
+}
@@ -2,7 +2,16 @@ package data
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/ivcp/polls/internal/schema"
+
+	// time zones are loaded by name, also where the system has no database
+	_ "time/tzdata"
 )
 
 type ExpiresAt struct{ time.Time }
@@ -14,3 +23,83 @@ func (e ExpiresAt) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(e.Time)
 }
+
+// UnmarshalJSON accepts an RFC 3339 time with a UTC offset, e.g.
+// "2024-02-05T14:48:00+01:00", or a local time in an IANA time zone, e.g.
+// {"local": "2024-02-05T14:48:00", "time_zone": "Europe/Berlin"}. The time is
+// normalized to UTC. An empty string, which polls without an expiry are
+// marshaled as, means none.
+func (e *ExpiresAt) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" || string(b) == `""` {
+		return nil
+	}
+
+	if strings.HasPrefix(string(b), "{") {
+		var input struct {
+			Local    string `json:"local"`
+			TimeZone string `json:"time_zone"`
+		}
+		if err := json.Unmarshal(b, &input); err != nil {
+			return errors.New("expires_at must have a local time and a time_zone")
+		}
+
+		loc, err := LoadTimeZone(input.TimeZone)
+		if err != nil {
+			return errors.New("expires_at time_zone must be an IANA time zone, e.g. Europe/Berlin")
+		}
+
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", input.Local, loc)
+		if err != nil {
+			return errors.New("expires_at local time must be formatted as 2006-01-02T15:04:05")
+		}
+
+		e.Time = t.UTC()
+		return nil
+	}
+
+	var t time.Time
+	if err := t.UnmarshalJSON(b); err != nil {
+		return errors.New("expires_at must be an RFC 3339 time with a UTC offset, e.g. 2006-01-02T15:04:05+01:00")
+	}
+
+	e.Time = t.UTC()
+	return nil
+}
+
+// JSONSchema describes the forms UnmarshalJSON accepts.
+func (e ExpiresAt) JSONSchema() *schema.Schema {
+	return &schema.Schema{OneOf: []*schema.Schema{
+		{Type: "string", Format: "date-time"},
+		{
+			Type: "object",
+			Properties: map[string]*schema.Schema{
+				"local":     {Type: "string", Description: "local time, e.g. 2024-02-05T14:48:00"},
+				"time_zone": {Type: "string", Description: "IANA time zone, e.g. Europe/Berlin"},
+			},
+			Required: []string{"local", "time_zone"},
+		},
+	}}
+}
+
+// LoadTimeZone returns the location of an IANA time zone name. Unlike
+// time.LoadLocation it doesn't accept an empty name or "Local".
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("unknown time zone " + name)
+	}
+	return time.LoadLocation(name)
+}
+
+// ParseDuration parses a duration such as "90m" or "2h", like
+// time.ParseDuration, or a number of days such as "7d". Like
+// time.ParseDuration it fails for days that overflow a time.Duration.
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil || n < 0 || n > math.MaxInt64/int64(24*time.Hour) {
+			return 0, errors.New("invalid duration " + s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,300 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// ExportVersion is the version of the export format, which imports must
+// match.
+const ExportVersion = 1
+
+// PollExport is a full copy of a poll with its settings, options and votes,
+// to back it up or move it to another server. Options' vote counts aren't
+// exported, as they follow from the accepted votes. Voters' IPs and
+// identities are left out, so an imported poll doesn't know who voted.
+type PollExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Poll       *Poll     `json:"poll"`
+	Votes      []*Vote   `json:"votes"`
+}
+
+// UnmarshalJSON reads polls like the default decoding, rejecting unknown
+// keys, and also reads exports made before is_private was replaced by
+// visibility. Private polls were then only left out of listings, as unlisted
+// polls are now. The lifecycle fields added by MarshalJSON are ignored, as
+// they follow from the poll's settings.
+func (p *Poll) UnmarshalJSON(b []byte) error {
+	type poll Poll
+	input := struct {
+		*poll
+		IsPrivate          *bool           `json:"is_private"`
+		Status             json.RawMessage `json:"status"`
+		SecondsUntilExpiry json.RawMessage `json:"seconds_until_expiry"`
+		IsVotable          json.RawMessage `json:"is_votable"`
+	}{poll: (*poll)(p)}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		return err
+	}
+
+	if input.IsPrivate != nil && p.Visibility == "" {
+		p.Visibility = VisibilityPublic
+		if *input.IsPrivate {
+			p.Visibility = VisibilityUnlisted
+		}
+	}
+	return nil
+}
+
+func ValidatePollExport(v *validator.Validator, export *PollExport) {
+	v.Check(export.Version == ExportVersion, "version", "unsupported export version")
+	if export.Poll == nil {
+		v.AddError("poll", "must be provided")
+		return
+	}
+
+	// options of exports written by hand may leave out their IDs, which
+	// only votes need
+	optionIDs := make([]uuid.UUID, 0, len(export.Poll.Options))
+	for _, option := range export.Poll.Options {
+		if option.ID != uuid.Nil {
+			optionIDs = append(optionIDs, option.ID)
+		}
+	}
+	v.Check(validator.Unique(optionIDs), "options", "ids must be unique")
+
+	for _, vote := range export.Votes {
+		v.Check(validator.PermittedValue(vote.OptionID, optionIDs...), "votes", "must be for the poll's options")
+		v.Check(validator.PermittedValue(
+			vote.Status, VoteStatusAccepted, VoteStatusSuspect, VoteStatusRejected,
+		), "votes", "invalid vote status")
+		v.Check(
+			vote.Variant >= 0 && vote.Variant <= len(export.Poll.QuestionVariants),
+			"votes",
+			"must be for the poll's question variants",
+		)
+		ValidateVote(v, vote)
+	}
+}
+
+// GetAll returns all votes on a poll, oldest first, for exports.
+func (v VoteModel) GetAll(pollID uuid.UUID) ([]*Vote, error) {
+	query := `
+		SELECT id, option_id, voter_name, user_agent, score, status, demographics, source, variant, ballot,
+		created_at
+		FROM votes
+		WHERE poll_id = $1
+		ORDER BY created_at, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get all votes: %w", err)
+	}
+	defer rows.Close()
+
+	votes := []*Vote{}
+
+	for rows.Next() {
+		vote := Vote{PollID: pollID}
+		err := rows.Scan(
+			&vote.ID,
+			&vote.OptionID,
+			&vote.VoterName,
+			&vote.UserAgent,
+			&vote.Score,
+			&vote.Status,
+			&vote.Demographics,
+			&vote.Source,
+			&vote.Variant,
+			&vote.Ballot,
+			&vote.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get all votes - scan: %w", err)
+		}
+		votes = append(votes, &vote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get all votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+// Import creates a new poll from an export in one transaction, with the
+// export's settings, options and votes and a new token. The poll, its
+// options, option groups and votes get new IDs, which are set on the export.
+// Options' vote counts are recounted from the accepted votes.
+// ErrCreationLimited is returned if the poll's CreatedBy wasn't counted
+// within today's limit.
+func (p PollModel) Import(export *PollExport, tokenHash []byte) error {
+	poll := export.Poll
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("import poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// polls imported after they expired are closed right away, so they
+	// aren't announced as closing again
+	queryPoll := `
+		INSERT INTO polls (question, description, expires_at, results_visibility, visibility, anonymity,
+		allowed_countries, denied_countries, results_threshold, tie_break, demographics, is_draft,
+		max_votes, votes_cast, paused_at, pause_reason, moderation_status, moderation_terms, created_at,
+		tags, language, metadata, shuffle_options, question_variants, verifiable, confirm_votes, created_by, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+		$20, $21, $22, $23, $24, $25, $26, $27, CASE WHEN $3 > '0001-01-02'::timestamptz AND $3 <= NOW() THEN NOW() END)
+		RETURNING id, updated_at;
+	`
+
+	args := []any{
+		poll.Question,
+		poll.Description,
+		poll.ExpiresAt.Time,
+		poll.ResultsVisibility,
+		visibilityOrPublic(poll.Visibility),
+		poll.Anonymity,
+		countriesOrEmpty(poll.AllowedCountries),
+		countriesOrEmpty(poll.DeniedCountries),
+		poll.ResultsThreshold,
+		poll.TieBreak,
+		demographicsOrEmpty(poll.Demographics),
+		poll.IsDraft,
+		poll.MaxVotes,
+		len(export.Votes),
+		poll.PausedAt,
+		poll.PauseReason,
+		poll.ModerationStatus,
+		termsOrEmpty(poll.ModerationTerms),
+		poll.CreatedAt,
+		tagsOrEmpty(poll.Tags),
+		poll.Language,
+		metadataOrEmpty(poll.Metadata),
+		poll.ShuffleOptions,
+		variantsOrEmpty(poll.QuestionVariants),
+		poll.Verifiable,
+		poll.ConfirmVotes,
+		nullIfEmpty(poll.CreatedBy),
+	}
+
+	err = tx.QueryRow(ctx, queryPoll, args...).Scan(&poll.ID, &poll.UpdatedAt)
+	if err != nil {
+		if checkViolation(err, "polls_daily_limit") {
+			return ErrCreationLimited
+		}
+		return fmt.Errorf("import poll: %w", err)
+	}
+
+	if err := insertOptionGroups(ctx, tx, poll); err != nil {
+		return fmt.Errorf("import poll: %w", err)
+	}
+
+	voteCounts := make(map[uuid.UUID]int)
+	for _, vote := range export.Votes {
+		if vote.Status == VoteStatusAccepted {
+			voteCounts[vote.OptionID]++
+		}
+	}
+
+	queryOption := `
+		INSERT INTO poll_options (value, poll_id, position, vote_count, description, url, group_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;
+	`
+
+	optionIDs := make(map[uuid.UUID]uuid.UUID, len(poll.Options))
+	for _, option := range poll.Options {
+		option.VoteCount = voteCounts[option.ID]
+		oldID := option.ID
+
+		err := tx.QueryRow(
+			ctx, queryOption, option.Value, poll.ID, option.Position, option.VoteCount, option.Description, option.URL,
+			option.GroupID,
+		).Scan(&option.ID)
+		if err != nil {
+			return fmt.Errorf("import poll - insert option: %w", err)
+		}
+		optionIDs[oldID] = option.ID
+	}
+
+	if len(export.Votes) > 0 {
+		// the votes are inserted in one statement, as exports can have
+		// thousands of them
+		var (
+			options      = make([]uuid.UUID, len(export.Votes))
+			names        = make([]string, len(export.Votes))
+			userAgents   = make([]string, len(export.Votes))
+			scores       = make([]int, len(export.Votes))
+			statuses     = make([]string, len(export.Votes))
+			demographics = make([]string, len(export.Votes))
+			sources      = make([]string, len(export.Votes))
+			variants     = make([]int, len(export.Votes))
+			ballots      = make([]string, len(export.Votes))
+			createdAt    = make([]time.Time, len(export.Votes))
+		)
+		for i, vote := range export.Votes {
+			vote.PollID = poll.ID
+			vote.OptionID = optionIDs[vote.OptionID]
+
+			answers, err := json.Marshal(answersOrEmpty(vote.Demographics))
+			if err != nil {
+				return fmt.Errorf("import poll - encode demographics: %w", err)
+			}
+
+			options[i] = vote.OptionID
+			names[i] = vote.VoterName
+			userAgents[i] = vote.UserAgent
+			scores[i] = vote.Score
+			statuses[i] = vote.Status
+			demographics[i] = string(answers)
+			sources[i] = vote.Source
+			variants[i] = vote.Variant
+			ballots[i] = vote.Ballot
+			createdAt[i] = vote.CreatedAt
+		}
+
+		queryVotes := `
+			INSERT INTO votes (poll_id, option_id, voter_name, user_agent, score, status, demographics,
+			source, variant, ballot, created_at)
+			SELECT $1, o, n, u, s, st, d::jsonb, src, var, b, c
+			FROM unnest($2::uuid[], $3::text[], $4::text[], $5::int[], $6::text[], $7::text[], $8::text[],
+			$9::int[], $10::text[], $11::timestamptz[]) AS v(o, n, u, s, st, d, src, var, b, c);
+		`
+
+		_, err = tx.Exec(ctx, queryVotes, poll.ID, options, names, userAgents, scores, statuses,
+			demographics, sources, variants, ballots, createdAt)
+		if err != nil {
+			return fmt.Errorf("import poll - insert votes: %w", err)
+		}
+	}
+
+	queryToken := `
+		INSERT INTO tokens (hash, poll_id)
+		VALUES ($1, $2);
+	`
+
+	if _, err := tx.Exec(ctx, queryToken, tokenHash, poll.ID); err != nil {
+		return fmt.Errorf("import poll - insert token: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
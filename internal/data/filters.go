@@ -19,6 +19,7 @@ type Metadata struct {
 	PageSize     int `json:"page_size,omitempty"`
 	FirstPage    int `json:"first_page,omitempty"`
 	LastPage     int `json:"last_page,omitempty"`
+	TotalPages   int `json:"total_pages,omitempty"`
 	TotalRecords int `json:"total_records,omitempty"`
 }
 
@@ -26,11 +27,13 @@ func calculateMetadata(totalRecords, page, pageSize int) Metadata {
 	if totalRecords == 0 {
 		return Metadata{}
 	}
+	lastPage := int(math.Ceil(float64(totalRecords) / float64(pageSize)))
 	return Metadata{
 		CurrentPage:  page,
 		PageSize:     pageSize,
 		FirstPage:    1,
-		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		LastPage:     lastPage,
+		TotalPages:   lastPage,
 		TotalRecords: totalRecords,
 	}
 }
@@ -0,0 +1,751 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ivcp/polls/internal/data (interfaces: PollRepository,OptionRepository,VoteRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mock/repositories.go -package=mock . PollRepository,OptionRepository,VoteRepository
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	net "net"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	data "github.com/ivcp/polls/internal/data"
+	search "github.com/ivcp/polls/internal/search"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPollRepository is a mock of PollRepository interface.
+type MockPollRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPollRepositoryMockRecorder
+}
+
+// MockPollRepositoryMockRecorder is the mock recorder for MockPollRepository.
+type MockPollRepositoryMockRecorder struct {
+	mock *MockPollRepository
+}
+
+// NewMockPollRepository creates a new mock instance.
+func NewMockPollRepository(ctrl *gomock.Controller) *MockPollRepository {
+	mock := &MockPollRepository{ctrl: ctrl}
+	mock.recorder = &MockPollRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPollRepository) EXPECT() *MockPollRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CheckToken mocks base method.
+func (m *MockPollRepository) CheckToken(arg0 string) (uuid.UUID, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckToken", arg0)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CheckToken indicates an expected call of CheckToken.
+func (mr *MockPollRepositoryMockRecorder) CheckToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckToken", reflect.TypeOf((*MockPollRepository)(nil).CheckToken), arg0)
+}
+
+// CloseExpired mocks base method.
+func (m *MockPollRepository) CloseExpired() ([]*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseExpired")
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseExpired indicates an expected call of CloseExpired.
+func (mr *MockPollRepositoryMockRecorder) CloseExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseExpired", reflect.TypeOf((*MockPollRepository)(nil).CloseExpired))
+}
+
+// Delete mocks base method.
+func (m *MockPollRepository) Delete(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPollRepositoryMockRecorder) Delete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPollRepository)(nil).Delete), arg0)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockPollRepository) DeleteExpired(arg0 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockPollRepositoryMockRecorder) DeleteExpired(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockPollRepository)(nil).DeleteExpired), arg0)
+}
+
+// Flag mocks base method.
+func (m *MockPollRepository) Flag(arg0 uuid.UUID, arg1 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flag", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flag indicates an expected call of Flag.
+func (mr *MockPollRepositoryMockRecorder) Flag(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flag", reflect.TypeOf((*MockPollRepository)(nil).Flag), arg0, arg1)
+}
+
+// Get mocks base method.
+func (m *MockPollRepository) Get(arg0 uuid.UUID) (*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0)
+	ret0, _ := ret[0].(*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPollRepositoryMockRecorder) Get(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPollRepository)(nil).Get), arg0)
+}
+
+// GetAll mocks base method.
+func (m *MockPollRepository) GetAll(arg0 string, arg1 uuid.UUID, arg2 map[string]string, arg3 data.Filters) ([]*data.Poll, data.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockPollRepositoryMockRecorder) GetAll(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockPollRepository)(nil).GetAll), arg0, arg1, arg2, arg3)
+}
+
+// GetByIDs mocks base method.
+func (m *MockPollRepository) GetByIDs(arg0 []uuid.UUID) ([]*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", arg0)
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockPollRepositoryMockRecorder) GetByIDs(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockPollRepository)(nil).GetByIDs), arg0)
+}
+
+// GetDeadlines mocks base method.
+func (m *MockPollRepository) GetDeadlines(arg0 uuid.UUID, arg1 int) ([]*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadlines", arg0, arg1)
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadlines indicates an expected call of GetDeadlines.
+func (mr *MockPollRepositoryMockRecorder) GetDeadlines(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadlines", reflect.TypeOf((*MockPollRepository)(nil).GetDeadlines), arg0, arg1)
+}
+
+// GetFlagged mocks base method.
+func (m *MockPollRepository) GetFlagged() ([]*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFlagged")
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFlagged indicates an expected call of GetFlagged.
+func (mr *MockPollRepositoryMockRecorder) GetFlagged() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFlagged", reflect.TypeOf((*MockPollRepository)(nil).GetFlagged))
+}
+
+// GetIDByExternalID mocks base method.
+func (m *MockPollRepository) GetIDByExternalID(arg0, arg1 uuid.UUID, arg2 string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIDByExternalID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIDByExternalID indicates an expected call of GetIDByExternalID.
+func (mr *MockPollRepositoryMockRecorder) GetIDByExternalID(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIDByExternalID", reflect.TypeOf((*MockPollRepository)(nil).GetIDByExternalID), arg0, arg1, arg2)
+}
+
+// GetPublic mocks base method.
+func (m *MockPollRepository) GetPublic(arg0 uuid.UUID, arg1 int) ([]*data.PublicPoll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublic", arg0, arg1)
+	ret0, _ := ret[0].([]*data.PublicPoll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublic indicates an expected call of GetPublic.
+func (mr *MockPollRepositoryMockRecorder) GetPublic(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublic", reflect.TypeOf((*MockPollRepository)(nil).GetPublic), arg0, arg1)
+}
+
+// GetPublicStats mocks base method.
+func (m *MockPollRepository) GetPublicStats() (*data.PublicStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublicStats")
+	ret0, _ := ret[0].(*data.PublicStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublicStats indicates an expected call of GetPublicStats.
+func (mr *MockPollRepositoryMockRecorder) GetPublicStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicStats", reflect.TypeOf((*MockPollRepository)(nil).GetPublicStats))
+}
+
+// GetRelated mocks base method.
+func (m *MockPollRepository) GetRelated(arg0 uuid.UUID, arg1 int) ([]*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRelated", arg0, arg1)
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelated indicates an expected call of GetRelated.
+func (mr *MockPollRepositoryMockRecorder) GetRelated(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRelated", reflect.TypeOf((*MockPollRepository)(nil).GetRelated), arg0, arg1)
+}
+
+// GetSeries mocks base method.
+func (m *MockPollRepository) GetSeries(arg0 uuid.UUID, arg1 int) ([]*data.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeries", arg0, arg1)
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeries indicates an expected call of GetSeries.
+func (mr *MockPollRepositoryMockRecorder) GetSeries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeries", reflect.TypeOf((*MockPollRepository)(nil).GetSeries), arg0, arg1)
+}
+
+// GetVotedIPs mocks base method.
+func (m *MockPollRepository) GetVotedIPs(arg0 uuid.UUID) ([]*net.IP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVotedIPs", arg0)
+	ret0, _ := ret[0].([]*net.IP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVotedIPs indicates an expected call of GetVotedIPs.
+func (mr *MockPollRepositoryMockRecorder) GetVotedIPs(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVotedIPs", reflect.TypeOf((*MockPollRepository)(nil).GetVotedIPs), arg0)
+}
+
+// Import mocks base method.
+func (m *MockPollRepository) Import(arg0 *data.PollExport, arg1 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockPollRepositoryMockRecorder) Import(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockPollRepository)(nil).Import), arg0, arg1)
+}
+
+// Insert mocks base method.
+func (m *MockPollRepository) Insert(arg0 *data.Poll, arg1 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockPollRepositoryMockRecorder) Insert(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockPollRepository)(nil).Insert), arg0, arg1)
+}
+
+// Moderate mocks base method.
+func (m *MockPollRepository) Moderate(arg0 uuid.UUID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Moderate indicates an expected call of Moderate.
+func (mr *MockPollRepositoryMockRecorder) Moderate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*MockPollRepository)(nil).Moderate), arg0, arg1)
+}
+
+// Pause mocks base method.
+func (m *MockPollRepository) Pause(arg0 uuid.UUID, arg1 string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pause indicates an expected call of Pause.
+func (mr *MockPollRepositoryMockRecorder) Pause(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockPollRepository)(nil).Pause), arg0, arg1)
+}
+
+// Publish mocks base method.
+func (m *MockPollRepository) Publish(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockPollRepositoryMockRecorder) Publish(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockPollRepository)(nil).Publish), arg0)
+}
+
+// Resume mocks base method.
+func (m *MockPollRepository) Resume(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockPollRepositoryMockRecorder) Resume(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockPollRepository)(nil).Resume), arg0)
+}
+
+// Search mocks base method.
+func (m *MockPollRepository) Search(arg0 search.Query, arg1 data.Filters) ([]*data.Poll, data.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1)
+	ret0, _ := ret[0].([]*data.Poll)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPollRepositoryMockRecorder) Search(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPollRepository)(nil).Search), arg0, arg1)
+}
+
+// SetNotifyEmail mocks base method.
+func (m *MockPollRepository) SetNotifyEmail(arg0 uuid.UUID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotifyEmail indicates an expected call of SetNotifyEmail.
+func (mr *MockPollRepositoryMockRecorder) SetNotifyEmail(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotifyEmail", reflect.TypeOf((*MockPollRepository)(nil).SetNotifyEmail), arg0, arg1)
+}
+
+// Stream mocks base method.
+func (m *MockPollRepository) Stream(arg0 uuid.UUID, arg1 func(*data.Poll) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockPollRepositoryMockRecorder) Stream(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockPollRepository)(nil).Stream), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockPollRepository) Update(arg0 *data.Poll) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockPollRepositoryMockRecorder) Update(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPollRepository)(nil).Update), arg0)
+}
+
+// MockOptionRepository is a mock of OptionRepository interface.
+type MockOptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOptionRepositoryMockRecorder
+}
+
+// MockOptionRepositoryMockRecorder is the mock recorder for MockOptionRepository.
+type MockOptionRepositoryMockRecorder struct {
+	mock *MockOptionRepository
+}
+
+// NewMockOptionRepository creates a new mock instance.
+func NewMockOptionRepository(ctrl *gomock.Controller) *MockOptionRepository {
+	mock := &MockOptionRepository{ctrl: ctrl}
+	mock.recorder = &MockOptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOptionRepository) EXPECT() *MockOptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockOptionRepository) Delete(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOptionRepositoryMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOptionRepository)(nil).Delete), arg0, arg1)
+}
+
+// GetResults mocks base method.
+func (m *MockOptionRepository) GetResults(arg0 uuid.UUID) ([]*data.PollOption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResults", arg0)
+	ret0, _ := ret[0].([]*data.PollOption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResults indicates an expected call of GetResults.
+func (mr *MockOptionRepositoryMockRecorder) GetResults(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResults", reflect.TypeOf((*MockOptionRepository)(nil).GetResults), arg0)
+}
+
+// Insert mocks base method.
+func (m *MockOptionRepository) Insert(arg0 *data.PollOption, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockOptionRepositoryMockRecorder) Insert(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockOptionRepository)(nil).Insert), arg0, arg1)
+}
+
+// Merge mocks base method.
+func (m *MockOptionRepository) Merge(arg0, arg1, arg2 uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockOptionRepositoryMockRecorder) Merge(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockOptionRepository)(nil).Merge), arg0, arg1, arg2)
+}
+
+// UpdatePosition mocks base method.
+func (m *MockOptionRepository) UpdatePosition(arg0 uuid.UUID, arg1 []*data.PollOption) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePosition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePosition indicates an expected call of UpdatePosition.
+func (mr *MockOptionRepositoryMockRecorder) UpdatePosition(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePosition", reflect.TypeOf((*MockOptionRepository)(nil).UpdatePosition), arg0, arg1)
+}
+
+// UpdateValue mocks base method.
+func (m *MockOptionRepository) UpdateValue(arg0 uuid.UUID, arg1 *data.PollOption) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateValue", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateValue indicates an expected call of UpdateValue.
+func (mr *MockOptionRepositoryMockRecorder) UpdateValue(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateValue", reflect.TypeOf((*MockOptionRepository)(nil).UpdateValue), arg0, arg1)
+}
+
+// Vote mocks base method.
+func (m *MockOptionRepository) Vote(arg0 *data.Vote) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockOptionRepositoryMockRecorder) Vote(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockOptionRepository)(nil).Vote), arg0)
+}
+
+// MockVoteRepository is a mock of VoteRepository interface.
+type MockVoteRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockVoteRepositoryMockRecorder
+}
+
+// MockVoteRepositoryMockRecorder is the mock recorder for MockVoteRepository.
+type MockVoteRepositoryMockRecorder struct {
+	mock *MockVoteRepository
+}
+
+// NewMockVoteRepository creates a new mock instance.
+func NewMockVoteRepository(ctrl *gomock.Controller) *MockVoteRepository {
+	mock := &MockVoteRepository{ctrl: ctrl}
+	mock.recorder = &MockVoteRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVoteRepository) EXPECT() *MockVoteRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Analyze mocks base method.
+func (m *MockVoteRepository) Analyze() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Analyze")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Analyze indicates an expected call of Analyze.
+func (mr *MockVoteRepositoryMockRecorder) Analyze() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyze", reflect.TypeOf((*MockVoteRepository)(nil).Analyze))
+}
+
+// Get mocks base method.
+func (m *MockVoteRepository) Get(arg0 uuid.UUID, arg1 int64) (*data.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*data.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockVoteRepositoryMockRecorder) Get(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockVoteRepository)(nil).Get), arg0, arg1)
+}
+
+// GetAll mocks base method.
+func (m *MockVoteRepository) GetAll(arg0 uuid.UUID) ([]*data.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0)
+	ret0, _ := ret[0].([]*data.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockVoteRepositoryMockRecorder) GetAll(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockVoteRepository)(nil).GetAll), arg0)
+}
+
+// GetBallots mocks base method.
+func (m *MockVoteRepository) GetBallots(arg0 uuid.UUID) ([]*data.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBallots", arg0)
+	ret0, _ := ret[0].([]*data.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBallots indicates an expected call of GetBallots.
+func (mr *MockVoteRepositoryMockRecorder) GetBallots(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBallots", reflect.TypeOf((*MockVoteRepository)(nil).GetBallots), arg0)
+}
+
+// GetFlagged mocks base method.
+func (m *MockVoteRepository) GetFlagged(arg0 uuid.UUID) ([]*data.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFlagged", arg0)
+	ret0, _ := ret[0].([]*data.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFlagged indicates an expected call of GetFlagged.
+func (mr *MockVoteRepositoryMockRecorder) GetFlagged(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFlagged", reflect.TypeOf((*MockVoteRepository)(nil).GetFlagged), arg0)
+}
+
+// GetRecent mocks base method.
+func (m *MockVoteRepository) GetRecent(arg0 uuid.UUID, arg1 time.Time) ([]*data.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecent", arg0, arg1)
+	ret0, _ := ret[0].([]*data.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecent indicates an expected call of GetRecent.
+func (mr *MockVoteRepositoryMockRecorder) GetRecent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecent", reflect.TypeOf((*MockVoteRepository)(nil).GetRecent), arg0, arg1)
+}
+
+// GetSegmentCounts mocks base method.
+func (m *MockVoteRepository) GetSegmentCounts(arg0 uuid.UUID, arg1 string) (map[string]map[uuid.UUID]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSegmentCounts", arg0, arg1)
+	ret0, _ := ret[0].(map[string]map[uuid.UUID]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSegmentCounts indicates an expected call of GetSegmentCounts.
+func (mr *MockVoteRepositoryMockRecorder) GetSegmentCounts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSegmentCounts", reflect.TypeOf((*MockVoteRepository)(nil).GetSegmentCounts), arg0, arg1)
+}
+
+// GetStats mocks base method.
+func (m *MockVoteRepository) GetStats(arg0 uuid.UUID) (*data.VoteStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", arg0)
+	ret0, _ := ret[0].(*data.VoteStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockVoteRepositoryMockRecorder) GetStats(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockVoteRepository)(nil).GetStats), arg0)
+}
+
+// GetVariantCounts mocks base method.
+func (m *MockVoteRepository) GetVariantCounts(arg0 uuid.UUID) (map[int]map[uuid.UUID]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVariantCounts", arg0)
+	ret0, _ := ret[0].(map[int]map[uuid.UUID]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVariantCounts indicates an expected call of GetVariantCounts.
+func (mr *MockVoteRepositoryMockRecorder) GetVariantCounts(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVariantCounts", reflect.TypeOf((*MockVoteRepository)(nil).GetVariantCounts), arg0)
+}
+
+// GetVoterNames mocks base method.
+func (m *MockVoteRepository) GetVoterNames(arg0 uuid.UUID) (map[uuid.UUID][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVoterNames", arg0)
+	ret0, _ := ret[0].(map[uuid.UUID][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVoterNames indicates an expected call of GetVoterNames.
+func (mr *MockVoteRepositoryMockRecorder) GetVoterNames(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVoterNames", reflect.TypeOf((*MockVoteRepository)(nil).GetVoterNames), arg0)
+}
+
+// HasVoted mocks base method.
+func (m *MockVoteRepository) HasVoted(arg0 uuid.UUID, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasVoted", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasVoted indicates an expected call of HasVoted.
+func (mr *MockVoteRepositoryMockRecorder) HasVoted(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasVoted", reflect.TypeOf((*MockVoteRepository)(nil).HasVoted), arg0, arg1)
+}
+
+// Moderate mocks base method.
+func (m *MockVoteRepository) Moderate(arg0 uuid.UUID, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Moderate indicates an expected call of Moderate.
+func (mr *MockVoteRepositoryMockRecorder) Moderate(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*MockVoteRepository)(nil).Moderate), arg0, arg1, arg2)
+}
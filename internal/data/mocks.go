@@ -1,10 +1,14 @@
 package data
 
 import (
+	"bytes"
+	"errors"
 	"net"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/search"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -13,23 +17,122 @@ type MockPollModel struct {
 }
 
 var (
-	ExamplePollIDValid         = "e9da0ad7-6065-40de-8398-2514ce9c566f"
-	ExamplePollIDExpiredPoll   = "7a818efb-b94d-49ea-af0e-5f1c8999c1b5"
-	ExamplePollIDExpiredNotSet = "e4dd6db9-fa83-45d2-81dd-1f93019a25a2"
-	ExamplePollIDAfterVote     = "6e3e617f-b5e6-4627-a2db-c72e29ec1729"
-	ExamplePollIDAfterDeadline = "0d5edfad-ba7f-4ddc-a455-4f25ca09bfdd"
-	ExamplePollIDVotingStarted = "0d5edfad-ba7f-4ddc-a455-4f25ca09bfss"
-	ExampleOptionID1           = "65d7c012-f3f9-43f5-a62c-12ab516c6124"
-	ExampleOptionID2           = "b85b14b5-7da6-47d0-8518-07033e199a50"
-	ExampleOptionID3           = "b8168cce-4044-4c23-9506-b41915784166"
+	ExamplePollIDValid         = uuid.MustParse("e9da0ad7-6065-40de-8398-2514ce9c566f")
+	ExamplePollIDExpiredPoll   = uuid.MustParse("7a818efb-b94d-49ea-af0e-5f1c8999c1b5")
+	ExamplePollIDExpiredNotSet = uuid.MustParse("e4dd6db9-fa83-45d2-81dd-1f93019a25a2")
+	ExamplePollIDAfterVote     = uuid.MustParse("6e3e617f-b5e6-4627-a2db-c72e29ec1729")
+	ExamplePollIDAfterDeadline = uuid.MustParse("0d5edfad-ba7f-4ddc-a455-4f25ca09bfdd")
+	ExamplePollIDVotingStarted = uuid.MustParse("0d5edfad-ba7f-4ddc-a455-4f25ca09bf5e")
+	ExamplePollIDPublicVoters  = uuid.MustParse("3f9c1a52-7d1e-4b8e-9a43-0c2b6f7d8e15")
+	ExamplePollIDOwnerVoters   = uuid.MustParse("a1c4e2b7-58f0-4d3a-b6e9-7f2d1c0b9a84")
+	ExamplePollIDGeoRestricted = uuid.MustParse("5b2f8d4e-1c6a-4e7b-9f30-8a4d2c7e6b91")
+	ExamplePollIDOrg           = uuid.MustParse("c7e1b9d2-4a3f-4f6e-8b05-2d9a6e1f3c48")
+	ExamplePollIDThreshold     = uuid.MustParse("9d4b7e21-6f3a-4c85-b1e0-3a7c5d2f8e96")
+	ExamplePollIDDemographics  = uuid.MustParse("8b6f2d94-5e1c-4a7d-b3f8-0d9e6c2a1b75")
+	ExamplePollIDPaused        = uuid.MustParse("4e7a1c93-2d8b-4f60-a5e2-9c3b7d1f0e48")
+	ExamplePollIDDraft         = uuid.MustParse("b2d5f8a1-7c4e-4a93-8e16-5f0c3d9b2a77")
+	ExamplePollIDVoteCap       = uuid.MustParse("6c1e9a47-3b5d-4f82-a7c0-2e8d4b6f9a13")
+	ExamplePollIDVoteCapFull   = uuid.MustParse("d8a3f6b0-9e2c-4d71-b5a4-7f1c3e0d2b86")
+	ExamplePollIDEditConflict  = uuid.MustParse("1f7c3a95-6d2e-4b80-9e4a-c5b8d0f2e631")
+	ExamplePollIDRejected      = uuid.MustParse("7d2b9e64-1a8f-4c35-b0d7-e3f6a2c9b548")
+	ExamplePollIDRemoved       = uuid.MustParse("2a9f6c18-5b3e-4d07-8c41-f6e2b7a0d953")
+	ExamplePollIDPrivate       = uuid.MustParse("5e8c2a71-9d4f-4b36-a0e7-3c1f6d2b8e94")
+	ExamplePollIDShuffled      = uuid.MustParse("a3d91f6e-27c4-4e8b-b5f0-8c6e2d4a7b13")
+	ExamplePollIDVariants      = uuid.MustParse("c7e2b94a-51d8-4f3e-8a6b-2d9f0e7c4a15")
+	ExamplePollIDVerifiable    = uuid.MustParse("d8a5e9c1-3f47-4b2e-9a61-7c0e5b2f8d34")
+	ExamplePollIDBallotsClosed = uuid.MustParse("0e6b3d82-4c1a-4f95-b7e0-9a2d5c8f1b46")
+	ExamplePollIDConfirm       = uuid.MustParse("6f1d8b3a-2e9c-4a57-b0d4-8c3e7a1f5b92")
+	ExampleBallot1             = "70602ebb2b75b7b4cab68b9b32c1f99c23489f5f86a17641bd7fc289b93e8ce0"
+	ExampleBallot2             = "ef2d73d491c2efbbaaf98cc391e89e454a14c512c172a0bc0b2754f999fa1dd9"
+	ExampleSeriesID            = uuid.MustParse("2c8e5a17-93d4-4f6b-a0e2-6b1d7c9f4a53")
+	ExampleSeriesPollID2       = uuid.MustParse("f3a6d9c2-0b7e-4e18-9d5a-4c2e8b1f7a60")
+	ExampleTokenOwnerVoters    = "OWNERVOTERSTOKENAAAAAAAAAA"
+	ExampleTokenResults        = "RESULTSTOKENAAAAAAAAAAAAAA"
+	ExampleTokenVote           = "VOTETOKENAAAAAAAAAAAAAAAAA"
+	ExampleTokenDemographics   = "DEMOGRAPHICSTOKENAAAAAAAAA"
+	ExampleTokenPreview        = "PREVIEWTOKENAAAAAAAAAAAAAA"
+	ExampleTokenVotePrivate    = "PRIVATEVOTETOKENAAAAAAAAAA"
+	ExampleTokenKiosk          = "KIOSKTOKENAAAAAAAAAAAAAAAA"
+	ExampleTokenShuffled       = "SHUFFLEDTOKENAAAAAAAAAAAAA"
+	ExampleTokenVariants       = "VARIANTSTOKENAAAAAAAAAAAAA"
+	ExampleExternalID          = "crm-42"
+	ExampleExternalIDTaken     = "crm-taken"
+	ExampleOptionID1           = uuid.MustParse("65d7c012-f3f9-43f5-a62c-12ab516c6124")
+	ExampleOptionID2           = uuid.MustParse("b85b14b5-7da6-47d0-8518-07033e199a50")
+	ExampleOptionID3           = uuid.MustParse("b8168cce-4044-4c23-9506-b41915784166")
+	ExampleGroupID             = uuid.MustParse("0c4f6a2e-8d1b-4f7a-9e3c-5b2d7a9f1e84")
 )
 
+// Insert fails for ExampleExternalIDTaken, as if another request had
+// created a poll with it after it was looked up, and for polls created by
+// ExampleUncountedIP.
 func (p MockPollModel) Insert(poll *Poll, tokenHash []byte) error {
-	poll.ID = uuid.NewString()
+	if poll.ExternalID == ExampleExternalIDTaken {
+		return ErrDuplicateExtID
+	}
+	if poll.CreatedBy == CreationSubject(ExampleUncountedIP, nil) {
+		return ErrCreationLimited
+	}
+	if poll.ActiveQuota > 0 && poll.ActiveQuota <= ExampleOrgActivePolls {
+		return ErrQuotaExceeded
+	}
+	poll.ID = uuid.New()
+	return nil
+}
+
+func (p MockPollModel) Import(export *PollExport, tokenHash []byte) error {
+	export.Poll.ID = uuid.New()
+	for _, option := range export.Poll.Options {
+		option.ID = uuid.New()
+	}
+	return nil
+}
+
+// GetPublic pages through three public polls ordered by ID, the second of
+// which holds its results back.
+func (p MockPollModel) GetPublic(after uuid.UUID, limit int) ([]*PublicPoll, error) {
+	votes := 2
+	polls := []*PublicPoll{
+		{
+			ID:                ExamplePollIDAfterDeadline,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			TotalVotes:        &votes,
+			Options:           []*PublicOption{{ID: ExampleOptionID1, Value: "One", Votes: &votes}},
+		},
+		{ID: ExamplePollIDThreshold, Question: "Test?", ResultsVisibility: "always", ResultsThreshold: 10},
+		{ID: ExamplePollIDValid, Question: "Test?", ResultsVisibility: "always"},
+	}
+
+	public := []*PublicPoll{}
+	for _, poll := range polls {
+		if poll.ID.String() > after.String() && len(public) < limit {
+			public = append(public, poll)
+		}
+	}
+	return public, nil
+}
+
+// Stream streams the poll of the example organization, or it and two public
+// polls without one.
+func (p MockPollModel) Stream(orgID uuid.UUID, fn func(*Poll) error) error {
+	polls := []*Poll{
+		{ID: ExamplePollIDOrg, Question: "Org?", OrgID: &ExampleOrgID, Visibility: VisibilityPrivate},
+		{ID: ExamplePollIDDraft, Question: "Draft?", IsDraft: true},
+		{ID: ExamplePollIDValid, Question: "Test?", Options: []*PollOption{{ID: ExampleOptionID1, Value: "One"}}},
+	}
+	for _, poll := range polls {
+		if orgID != uuid.Nil && (poll.OrgID == nil || *poll.OrgID != orgID) {
+			continue
+		}
+		if err := fn(poll); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (p MockPollModel) Get(id string) (*Poll, error) {
+func (p MockPollModel) Get(id uuid.UUID) (*Poll, error) {
 	if id == ExamplePollIDValid {
 		poll := Poll{
 			ID:                ExamplePollIDValid,
@@ -38,11 +141,15 @@ func (p MockPollModel) Get(id string) (*Poll, error) {
 			UpdatedAt:         time.Now(),
 			ExpiresAt:         ExpiresAt{time.Now().Add(2 * time.Minute)},
 			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
 			Options: []*PollOption{
 				{ID: ExampleOptionID1, Value: "One", Position: 0},
 				{ID: ExampleOptionID2, Value: "Two", Position: 1},
 				{ID: ExampleOptionID3, Value: "Three", Position: 2},
 			},
+			Groups: []*OptionGroup{{ID: ExampleGroupID, Label: "Numbers", Position: 0}},
 		}
 		return &poll, nil
 	}
@@ -67,40 +174,532 @@ func (p MockPollModel) Get(id string) (*Poll, error) {
 	// results after deadline
 	if id == ExamplePollIDAfterDeadline {
 		return &Poll{
+			ID:                ExamplePollIDAfterDeadline,
+			UpdatedAt:         time.Now(),
 			ExpiresAt:         ExpiresAt{time.Now().Add(1 * time.Minute)},
 			ResultsVisibility: "after_deadline",
+			Options:           []*PollOption{{ID: ExampleOptionID1, Value: "One", Position: 0, VoteCount: 2, Percent: 100}},
+		}, nil
+	}
+
+	// voter names public
+	if id == ExamplePollIDPublicVoters {
+		return &Poll{
+			ID:                ExamplePollIDPublicVoters,
+			ResultsVisibility: "always",
+			Anonymity:         "public",
+		}, nil
+	}
+
+	// voter names visible to owner
+	if id == ExamplePollIDOwnerVoters {
+		return &Poll{
+			ID:                ExamplePollIDOwnerVoters,
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "names_visible_to_owner",
+		}, nil
+	}
+
+	// voting allowed from Germany only
+	if id == ExamplePollIDOrg {
+		return &Poll{
+			ID:                ExamplePollIDOrg,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			OrgID:             &ExampleOrgID,
+			Options:           []*PollOption{},
+		}, nil
+	}
+	if id == ExamplePollIDDemographics {
+		return &Poll{
+			ID:                ExamplePollIDDemographics,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			Demographics: []DemographicQuestion{
+				{Key: "team", Label: "Your team", Choices: []string{"Sales", "Engineering"}},
+			},
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// one vote cast
+	if id == ExamplePollIDVotingStarted {
+		return &Poll{
+			ID:                ExamplePollIDVotingStarted,
+			Question:          "Favourite colour?",
+			ResultsVisibility: "always",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "Red", Position: 0, VoteCount: 1, Percent: 100},
+				{ID: ExampleOptionID2, Value: "Blue", Position: 1},
+			},
+		}, nil
+	}
+	// results hidden until 3 votes are cast
+	if id == ExamplePollIDThreshold {
+		return &Poll{
+			ID:                ExamplePollIDThreshold,
+			ResultsVisibility: "always",
+			ResultsThreshold:  3,
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			Options:           []*PollOption{{ID: ExampleOptionID1, Value: "One", Position: 0, VoteCount: 1, Percent: 100}},
+		}, nil
+	}
+	// draft, only visible with a preview or manage token
+	if id == ExamplePollIDDraft {
+		return &Poll{
+			ID:                ExamplePollIDDraft,
+			Question:          "Draft?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			IsDraft:           true,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	if id == ExamplePollIDShuffled {
+		return &Poll{
+			ID:                ExamplePollIDShuffled,
+			Question:          "Shuffled?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			ShuffleOptions:    true,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+				{ID: ExampleOptionID3, Value: "Three", Position: 2},
+			},
+		}, nil
+	}
+	if id == ExamplePollIDConfirm {
+		return &Poll{
+			ID:                ExamplePollIDConfirm,
+			Question:          "Confirmed?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			ConfirmVotes:      true,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	if id == ExamplePollIDVerifiable || id == ExamplePollIDBallotsClosed {
+		poll := &Poll{
+			ID:                id,
+			Question:          "Verifiable?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			Verifiable:        true,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}
+		if id == ExamplePollIDBallotsClosed {
+			poll.ExpiresAt.Time = time.Now().Add(-time.Hour)
+		}
+		return poll, nil
+	}
+	if id == ExamplePollIDVariants {
+		return &Poll{
+			ID:                ExamplePollIDVariants,
+			Question:          "Should taxes be raised?",
+			QuestionVariants:  []string{"Should taxes be raised to fund schools?"},
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "Yes", Position: 0},
+				{ID: ExampleOptionID2, Value: "No", Position: 1},
+			},
+		}, nil
+	}
+	if id == ExamplePollIDPrivate {
+		return &Poll{
+			ID:                ExamplePollIDPrivate,
+			Question:          "Private?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPrivate,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// rejected by moderation, hidden from everyone
+	if id == ExamplePollIDRejected {
+		return &Poll{
+			ID:                ExamplePollIDRejected,
+			Question:          "Rejected?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			ModerationStatus:  ModerationRejected,
+			ModerationTerms:   []string{"darn"},
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// updated by another request since it was read
+	if id == ExamplePollIDEditConflict {
+		return &Poll{
+			ID:                ExamplePollIDEditConflict,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			TieBreak:          "shared",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// capped at 2 votes, with one left
+	if id == ExamplePollIDVoteCap {
+		return &Poll{
+			ID:                ExamplePollIDVoteCap,
+			ResultsVisibility: "always",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			MaxVotes:          2,
+			VotesCast:         1,
+		}, nil
+	}
+	// capped at 2 votes and closed by the second
+	if id == ExamplePollIDVoteCapFull {
+		return &Poll{
+			ID:                ExamplePollIDVoteCapFull,
+			ExpiresAt:         ExpiresAt{time.Now().Add(-time.Minute)},
+			ResultsVisibility: "always",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			MaxVotes:          2,
+			VotesCast:         2,
+		}, nil
+	}
+	// voting paused by the owner
+	if id == ExamplePollIDPaused {
+		pausedAt := time.Now().Add(-time.Hour)
+		return &Poll{
+			ID:                ExamplePollIDPaused,
+			ResultsVisibility: "always",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			PausedAt:          &pausedAt,
+			PauseReason:       "suspected abuse",
+		}, nil
+	}
+	if id == ExamplePollIDGeoRestricted {
+		return &Poll{
+			ID:                ExamplePollIDGeoRestricted,
+			ResultsVisibility: "always",
+			Anonymity:         "anonymous",
+			Visibility:        VisibilityPublic,
+			AllowedCountries:  []string{"DE"},
 		}, nil
 	}
 	return nil, ErrRecordNotFound
 }
 
+// GetByIDs gets each of the polls Get finds, leaving out the others.
+func (p MockPollModel) GetByIDs(ids []uuid.UUID) ([]*Poll, error) {
+	polls := []*Poll{}
+	for _, id := range ids {
+		poll, err := p.Get(id)
+		if errors.Is(err, ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		polls = append(polls, poll)
+	}
+	return polls, nil
+}
+
 func (p MockPollModel) Update(poll *Poll) error {
 	if poll.ID == ExamplePollIDValid {
 		return nil
 	}
+	if poll.ID == ExamplePollIDEditConflict {
+		return ErrEditConflict
+	}
 	return ErrRecordNotFound
 }
 
-func (p MockPollModel) Delete(id string) error {
-	if id == ExamplePollIDValid {
+func (p MockPollModel) Delete(id uuid.UUID) error {
+	if id == ExamplePollIDValid || id == ExamplePollIDVotingStarted {
 		return nil
 	}
 	return ErrRecordNotFound
 }
 
-func (p MockPollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, error) {
-	return nil, Metadata{}, nil
+// GetAll lists two public polls, newest first, and no organization polls.
+// GetAll lists two public polls, the first of which has the metadata
+// team=platform.
+func (p MockPollModel) GetAll(search string, orgID uuid.UUID, metadata map[string]string, filters Filters) ([]*Poll, Metadata, error) {
+	if orgID != uuid.Nil {
+		return []*Poll{}, Metadata{}, nil
+	}
+	polls := []*Poll{
+		{
+			ID:          ExamplePollIDValid,
+			Question:    "Tabs <or> spaces?",
+			Description: "Settle it & move on",
+			CreatedAt:   time.Date(2024, 2, 27, 9, 0, 0, 0, time.UTC),
+			UpdatedAt:   time.Date(2024, 2, 27, 10, 0, 0, 0, time.UTC),
+			Metadata:    map[string]string{"team": "platform"},
+		},
+		{
+			ID:        ExamplePollIDVotingStarted,
+			Question:  "Lunch?",
+			CreatedAt: time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+		},
+	}
+	polls = slices.DeleteFunc(polls, func(poll *Poll) bool {
+		for key, value := range metadata {
+			if poll.Metadata[key] != value {
+				return true
+			}
+		}
+		return false
+	})
+	return polls, calculateMetadata(len(polls), filters.Page, filters.PageSize), nil
+}
+
+// Search finds a lunch poll tagged "food" by the term lunch, by its tag or by
+// status:open, and nothing else.
+func (p MockPollModel) Search(q search.Query, filters Filters) ([]*Poll, Metadata, error) {
+	matches := q.TSQuery == "lunch" || (q.TSQuery == "" && (len(q.Tags) > 0 || len(q.Statuses) > 0))
+	for _, tag := range q.Tags {
+		matches = matches && tag == "food"
+	}
+	if len(q.Statuses) > 0 {
+		matches = matches && slices.Contains(q.Statuses, search.StatusOpen)
+	}
+	if !matches {
+		return []*Poll{}, Metadata{}, nil
+	}
+
+	polls := []*Poll{
+		{
+			ID:        ExamplePollIDVotingStarted,
+			Question:  "Lunch?",
+			Tags:      []string{"food"},
+			CreatedAt: time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+		},
+	}
+	return polls, calculateMetadata(len(polls), filters.Page, filters.PageSize), nil
+}
+
+func (p MockPollModel) GetPublicStats() (*PublicStats, error) {
+	return &PublicStats{
+		PublicPolls: 2,
+		VotesToday:  5,
+		MostActivePoll: &ActivePoll{
+			ID:         ExamplePollIDValid,
+			Question:   "Test?",
+			VotesToday: 4,
+		},
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+func (p MockPollModel) GetSeries(seriesID uuid.UUID, limit int) ([]*Poll, error) {
+	if seriesID != ExampleSeriesID {
+		return nil, nil
+	}
+	polls := []*Poll{
+		{ID: ExampleSeriesID, SeriesID: &ExampleSeriesID, Question: "Lunch?", ResultsVisibility: "always"},
+		{ID: ExampleSeriesPollID2, SeriesID: &ExampleSeriesID, Question: "Lunch?", ResultsVisibility: "always"},
+	}
+	return polls[max(len(polls)-limit, 0):], nil
+}
+
+// GetRelated finds two polls related to the valid example poll, and none for
+// others.
+func (p MockPollModel) GetRelated(pollID uuid.UUID, limit int) ([]*Poll, error) {
+	if pollID != ExamplePollIDValid {
+		return []*Poll{}, nil
+	}
+	polls := []*Poll{
+		{
+			ID:        ExamplePollIDVotingStarted,
+			Question:  "Tabs or spaces in YAML?",
+			Tags:      []string{"code"},
+			CreatedAt: time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        ExamplePollIDAfterVote,
+			Question:  "Spaces after periods?",
+			CreatedAt: time.Date(2024, 2, 25, 17, 0, 0, 0, time.UTC),
+		},
+	}
+	return polls[:min(limit, len(polls))], nil
+}
+
+// GetIDByExternalID resolves ExampleExternalID to the valid poll if it is
+// looked up for it, or to the organization's poll within the example
+// organization.
+func (p MockPollModel) GetIDByExternalID(orgID uuid.UUID, pollID uuid.UUID, externalID string) (uuid.UUID, error) {
+	switch {
+	case externalID != ExampleExternalID:
+		return uuid.Nil, ErrRecordNotFound
+	case orgID == ExampleOrgID:
+		return ExamplePollIDOrg, nil
+	case orgID == uuid.Nil && pollID == ExamplePollIDValid:
+		return ExamplePollIDValid, nil
+	}
+	return uuid.Nil, ErrRecordNotFound
+}
+
+func (p MockPollModel) GetDeadlines(orgID uuid.UUID, limit int) ([]*Poll, error) {
+	if orgID != ExampleOrgID {
+		return nil, nil
+	}
+	created := time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC)
+	polls := []*Poll{
+		{
+			ID:          ExamplePollIDOrg,
+			OrgID:       &orgID,
+			Question:    "Lunch, Friday?",
+			Description: "Pizza; or sushi\nvote now",
+			CreatedAt:   created,
+			UpdatedAt:   created,
+			ExpiresAt:   ExpiresAt{time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			ID:        ExamplePollIDDraft,
+			OrgID:     &orgID,
+			Question:  "Offsite?",
+			CreatedAt: created,
+			UpdatedAt: created,
+			ExpiresAt: ExpiresAt{time.Date(2024, 3, 8, 9, 30, 0, 0, time.UTC)},
+			IsDraft:   true,
+		},
+	}
+	return polls[:min(len(polls), limit)], nil
 }
 
-func (p MockPollModel) GetVotedIPs(pollID string) ([]*net.IP, error) {
+func (p MockPollModel) GetVotedIPs(pollID uuid.UUID) ([]*net.IP, error) {
 	var ips []*net.IP
 	i := net.IPv4(0, 0, 0, 1)
 	ips = append(ips, &i)
 	return ips, nil
 }
 
-func (p MockPollModel) CheckToken(tokenPlaintext string) (string, error) {
-	return ExamplePollIDValid, nil
+func (p MockPollModel) SetNotifyEmail(id uuid.UUID, email string) error {
+	return nil
+}
+
+func (p MockPollModel) Pause(id uuid.UUID, reason string) (time.Time, error) {
+	if id == ExamplePollIDValid || id == ExamplePollIDPaused {
+		return time.Now(), nil
+	}
+	return time.Time{}, ErrRecordNotFound
+}
+
+func (p MockPollModel) Resume(id uuid.UUID) error {
+	if id == ExamplePollIDValid || id == ExamplePollIDPaused {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) Publish(id uuid.UUID) error {
+	if id == ExamplePollIDValid || id == ExamplePollIDDraft {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) Flag(id uuid.UUID, terms []string) error {
+	if id == ExamplePollIDValid {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) Moderate(id uuid.UUID, status string) error {
+	if id == ExamplePollIDValid || id == ExamplePollIDRejected {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) GetFlagged() ([]*Poll, error) {
+	return []*Poll{
+		{
+			ID:               ExamplePollIDValid,
+			Question:         "Darn?",
+			ModerationStatus: ModerationFlagged,
+			ModerationTerms:  []string{"Darn"},
+		},
+	}, nil
+}
+
+func (p MockPollModel) CloseExpired() ([]*Poll, error) {
+	return nil, nil
+}
+
+func (p MockPollModel) DeleteExpired(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (p MockPollModel) CheckToken(tokenPlaintext string) (uuid.UUID, string, error) {
+	switch tokenPlaintext {
+	case ExampleTokenOwnerVoters:
+		return ExamplePollIDOwnerVoters, ScopeManage, nil
+	case ExampleTokenResults:
+		return ExamplePollIDAfterDeadline, ScopeResults, nil
+	case ExampleTokenDemographics:
+		return ExamplePollIDDemographics, ScopeResults, nil
+	case ExampleTokenVote:
+		return ExamplePollIDValid, ScopeVote, nil
+	case ExampleTokenPreview:
+		return ExamplePollIDDraft, ScopePreview, nil
+	case ExampleTokenVotePrivate:
+		return ExamplePollIDPrivate, ScopeVote, nil
+	case ExampleTokenKiosk:
+		return ExamplePollIDValid, ScopeKiosk, nil
+	case ExampleTokenShuffled:
+		return ExamplePollIDShuffled, ScopeManage, nil
+	case ExampleTokenVariants:
+		return ExamplePollIDVariants, ScopeManage, nil
+	case ExampleTokenOrgAdmin, ExampleTokenOrgEditor, ExampleTokenOrgViewer:
+		return uuid.Nil, "", ErrRecordNotFound
+	}
+	return ExamplePollIDValid, ScopeManage, nil
 }
 
 // PollOption
@@ -109,32 +708,891 @@ type MockPollOptionModel struct {
 	DB *pgxpool.Pool
 }
 
-func (p MockPollOptionModel) Insert(option *PollOption, pollID string) error {
+func (p MockPollOptionModel) Insert(option *PollOption, pollID uuid.UUID) error {
 	return nil
 }
 
-func (p MockPollOptionModel) UpdateValue(option *PollOption) error {
+func (p MockPollOptionModel) UpdateValue(pollID uuid.UUID, option *PollOption) error {
 	return nil
 }
 
-func (p MockPollOptionModel) UpdatePosition(options []*PollOption) error {
+func (p MockPollOptionModel) UpdatePosition(pollID uuid.UUID, options []*PollOption) error {
 	return nil
 }
 
-func (p MockPollOptionModel) Delete(optionID string) error {
+func (p MockPollOptionModel) Delete(pollID uuid.UUID, optionID uuid.UUID) error {
 	return nil
 }
 
-func (p MockPollOptionModel) Vote(optionID string, pollID string, ip string) error {
+func (p MockPollOptionModel) Merge(pollID uuid.UUID, optionID uuid.UUID, intoID uuid.UUID) (int, error) {
+	return 2, nil
+}
+
+func (p MockPollOptionModel) Vote(vote *Vote) error {
+	switch vote.OptionID {
+	case ExampleOptionID1, ExampleOptionID2, ExampleOptionID3:
+	default:
+		return ErrOptionNotInPoll
+	}
+	// the last vote is taken by a concurrent voter
+	if vote.PollID == ExamplePollIDVoteCap {
+		return ErrVoteQuotaReached
+	}
+	if vote.VoterIdentity == ExampleRacingVoterIdentity {
+		return ErrAlreadyVoted
+	}
 	return nil
 }
 
-func (p MockPollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
+func (p MockPollOptionModel) GetResults(pollID uuid.UUID) ([]*PollOption, error) {
 	if pollID == ExamplePollIDVotingStarted {
 		return []*PollOption{
-			{ID: "1", Value: "One", Position: 0, VoteCount: 1},
-			{ID: "2", Value: "Two", Position: 1, VoteCount: 0},
+			{ID: ExampleOptionID1, Value: "One", Position: 0, VoteCount: 1},
+			{ID: ExampleOptionID2, Value: "Two", Position: 1, VoteCount: 0},
+		}, nil
+	}
+	if pollID == ExamplePollIDPublicVoters || pollID == ExamplePollIDOwnerVoters || pollID == ExamplePollIDThreshold {
+		return []*PollOption{
+			{ID: ExampleOptionID1, Value: "One", Position: 0, VoteCount: 1},
+			{ID: ExampleOptionID2, Value: "Two", Position: 1, VoteCount: 0},
+		}, nil
+	}
+	if pollID == ExamplePollIDVariants {
+		return []*PollOption{
+			{ID: ExampleOptionID1, Value: "Yes", Position: 0, VoteCount: 2},
+			{ID: ExampleOptionID2, Value: "No", Position: 1, VoteCount: 4},
+		}, nil
+	}
+	if pollID == ExamplePollIDDemographics {
+		return []*PollOption{
+			{ID: ExampleOptionID1, Value: "One", Position: 0, VoteCount: 4},
+			{ID: ExampleOptionID2, Value: "Two", Position: 1, VoteCount: 1},
+		}, nil
+	}
+	// the second poll of the series renamed Pizza and replaced Salad
+	if pollID == ExampleSeriesID {
+		return []*PollOption{
+			{ID: uuid.MustParse("4a1e7c93-0d2b-4f58-9e6a-3c8b1d5f7a20"), Value: "Pizza", Position: 0, VoteCount: 3},
+			{ID: uuid.MustParse("7b3d9f15-2e8a-4c61-a0d4-6f1c9e2b8d57"), Value: "Salad", Position: 1, VoteCount: 1},
+		}, nil
+	}
+	if pollID == ExampleSeriesPollID2 {
+		return []*PollOption{
+			{ID: uuid.MustParse("c2f8a6d4-5b1e-4d97-8a3c-0e6d2b9f4c18"), Value: " pizza", Position: 0, VoteCount: 2},
+			{ID: uuid.MustParse("e9d4b2a7-6c3f-4e05-b8a1-5d7f0c3e9b62"), Value: "Sushi", Position: 1, VoteCount: 2},
 		}, nil
 	}
 	return nil, nil
 }
+
+// Vote
+
+type MockVoteModel struct {
+	DB *pgxpool.Pool
+}
+
+const (
+	ExampleFlaggedVoteID int64 = 42
+	ExampleVoterIdentity       = "slack:T0001:U0001"
+	// ExampleRacingVoterIdentity votes twice at once, so its second vote
+	// passes HasVoted but is rejected by the database
+	ExampleRacingVoterIdentity = "slack:T0001:U0003"
+	ExampleDiscordIdentity     = "discord:1"
+	ExampleTelegramIdentity    = "telegram:-100:1"
+	// ExampleSMSIdentity is the identity of +15550001111
+	ExampleSMSIdentity = "sms:7a8e1bad42416f9a"
+)
+
+func (v MockVoteModel) HasVoted(pollID uuid.UUID, voterIdentity string) (bool, error) {
+	switch voterIdentity {
+	case ExampleVoterIdentity, ExampleDiscordIdentity, ExampleTelegramIdentity, ExampleSMSIdentity:
+		return true, nil
+	}
+	return false, nil
+}
+
+func (v MockVoteModel) Get(pollID uuid.UUID, id int64) (*Vote, error) {
+	if pollID != ExamplePollIDValid || id != 1 {
+		return nil, ErrRecordNotFound
+	}
+	return &Vote{
+		ID:        1,
+		PollID:    pollID,
+		OptionID:  ExampleOptionID1,
+		Status:    VoteStatusAccepted,
+		CreatedAt: time.Date(2024, 2, 5, 14, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (v MockVoteModel) GetBallots(pollID uuid.UUID) ([]*Vote, error) {
+	if pollID != ExamplePollIDVerifiable && pollID != ExamplePollIDBallotsClosed {
+		return []*Vote{}, nil
+	}
+	return []*Vote{
+		{PollID: pollID, OptionID: ExampleOptionID1, Ballot: ExampleBallot1},
+		{PollID: pollID, OptionID: ExampleOptionID2, Ballot: ExampleBallot2},
+	}, nil
+}
+
+func (v MockVoteModel) GetRecent(pollID uuid.UUID, since time.Time) ([]*Vote, error) {
+	return nil, nil
+}
+
+func (v MockVoteModel) GetFlagged(pollID uuid.UUID) ([]*Vote, error) {
+	return []*Vote{
+		{
+			ID:        ExampleFlaggedVoteID,
+			PollID:    pollID,
+			OptionID:  ExampleOptionID1,
+			Score:     50,
+			Status:    VoteStatusSuspect,
+			CreatedAt: time.Now(),
+		},
+	}, nil
+}
+
+func (v MockVoteModel) Moderate(pollID uuid.UUID, voteID int64, status string) error {
+	if voteID == ExampleFlaggedVoteID {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (v MockVoteModel) GetStats(pollID uuid.UUID) (*VoteStats, error) {
+	peak := time.Date(2024, 2, 5, 14, 0, 0, 0, time.UTC)
+	return &VoteStats{IssuedVoteTokens: 4, VotesPerHour: 0.5, PeakHour: &peak, PeakHourVotes: 1}, nil
+}
+
+func (v MockVoteModel) GetSegmentCounts(pollID uuid.UUID, key string) (map[string]map[uuid.UUID]int, error) {
+	return map[string]map[uuid.UUID]int{
+		"Sales":       {ExampleOptionID1: 2},
+		"Engineering": {ExampleOptionID1: 1, ExampleOptionID2: 1},
+		"":            {ExampleOptionID1: 1},
+	}, nil
+}
+
+func (v MockVoteModel) GetVariantCounts(pollID uuid.UUID) (map[int]map[uuid.UUID]int, error) {
+	return map[int]map[uuid.UUID]int{
+		0: {ExampleOptionID1: 2, ExampleOptionID2: 1},
+		1: {ExampleOptionID2: 3},
+	}, nil
+}
+
+func (v MockVoteModel) Analyze() error {
+	return nil
+}
+
+func (v MockVoteModel) GetAll(pollID uuid.UUID) ([]*Vote, error) {
+	return []*Vote{
+		{
+			ID:        1,
+			PollID:    pollID,
+			OptionID:  ExampleOptionID1,
+			VoterName: "Jane",
+			Status:    VoteStatusAccepted,
+			CreatedAt: time.Date(2024, 2, 5, 14, 0, 0, 0, time.UTC),
+		},
+	}, nil
+}
+
+func (v MockVoteModel) GetVoterNames(pollID uuid.UUID) (map[uuid.UUID][]string, error) {
+	return map[uuid.UUID][]string{ExampleOptionID1: {"Jane"}}, nil
+}
+
+// ShortLink
+
+type MockShortLinkModel struct {
+	DB *pgxpool.Pool
+}
+
+const ExampleShortCode = "abc2345"
+
+func (s MockShortLinkModel) Insert(pollID uuid.UUID) (*ShortLink, error) {
+	return &ShortLink{Code: ExampleShortCode, PollID: pollID, CreatedAt: time.Now()}, nil
+}
+
+func (s MockShortLinkModel) GetForPoll(pollID uuid.UUID) (*ShortLink, error) {
+	if pollID == ExamplePollIDValid {
+		return &ShortLink{Code: ExampleShortCode, PollID: pollID, CreatedAt: time.Now()}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (s MockShortLinkModel) GetByCode(code string) (*ShortLink, error) {
+	if code == ExampleShortCode {
+		return &ShortLink{Code: ExampleShortCode, PollID: ExamplePollIDValid, CreatedAt: time.Now()}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+// Digest
+
+type MockDigestModel struct {
+	DB *pgxpool.Pool
+}
+
+func (d MockDigestModel) Subscribe(sub *DigestSubscription) error {
+	sub.CreatedAt = time.Now()
+	return nil
+}
+
+func (d MockDigestModel) GetSubscription(pollID uuid.UUID) (*DigestSubscription, error) {
+	if pollID == ExamplePollIDValid {
+		return &DigestSubscription{
+			PollID:    pollID,
+			Frequency: DigestDaily,
+			Email:     "jane@example.com",
+			CreatedAt: time.Now(),
+		}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (d MockDigestModel) Unsubscribe(pollID uuid.UUID) error {
+	if pollID == ExamplePollIDValid {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (d MockDigestModel) ClaimDue() ([]*Digest, error) {
+	return nil, nil
+}
+
+// Result export
+
+type MockResultExportModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockResultExportModel) Schedule(export *ResultExport) error {
+	export.CreatedAt = time.Now()
+	return nil
+}
+
+func (m MockResultExportModel) Get(pollID uuid.UUID) (*ResultExport, error) {
+	if pollID == ExamplePollIDValid {
+		return &ResultExport{
+			PollID:    pollID,
+			Frequency: DigestDaily,
+			Format:    ExportCSV,
+			CreatedAt: time.Now(),
+		}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (m MockResultExportModel) Delete(pollID uuid.UUID) error {
+	if pollID == ExamplePollIDValid {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (m MockResultExportModel) ClaimDue() ([]*ResultExport, error) {
+	return nil, nil
+}
+
+// Transfer
+
+type MockTransferModel struct {
+	DB *pgxpool.Pool
+}
+
+func (t MockTransferModel) Insert(transfer *Transfer, tokenHash []byte) error {
+	if transfer.PollID != ExamplePollIDValid {
+		return ErrRecordNotFound
+	}
+	transfer.ID = 1
+	transfer.CreatedAt = time.Now()
+	return nil
+}
+
+func (t MockTransferModel) GetForPoll(pollID uuid.UUID) ([]*Transfer, error) {
+	if pollID == ExamplePollIDValid {
+		return []*Transfer{{ID: 1, PollID: pollID, CreatedAt: time.Now()}}, nil
+	}
+	return []*Transfer{}, nil
+}
+
+// Token
+
+type MockTokenModel struct {
+	DB *pgxpool.Pool
+}
+
+func (t MockTokenModel) Insert(pollID uuid.UUID, token *Token) error {
+	return nil
+}
+
+func (t MockTokenModel) Rotate(pollID uuid.UUID, oldHash, newHash []byte) error {
+	if pollID != ExamplePollIDValid {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (t MockTokenModel) RevokeAll(pollID uuid.UUID) (int64, error) {
+	if pollID == ExamplePollIDValid {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Organization
+
+type MockOrgModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ExampleOrgID          = uuid.MustParse("9b4e2c1a-7f3d-4e8b-a6c5-1d0f2e3b4a59")
+	ExampleTokenOrgAdmin  = "ORGADMINTOKENAAAAAAAAAAAAA"
+	ExampleTokenOrgEditor = "ORGEDITORTOKENAAAAAAAAAAAA"
+	ExampleTokenOrgViewer = "ORGVIEWERTOKENAAAAAAAAAAAA"
+	ExampleMemberIDAdmin  = int64(1)
+	ExampleMemberIDEditor = int64(2)
+)
+
+func (o MockOrgModel) Insert(org *Organization, admin *Member, tokenHash []byte) error {
+	org.ID = uuid.New()
+	org.CreatedAt = time.Now()
+	admin.ID = ExampleMemberIDAdmin
+	admin.OrgID = org.ID
+	admin.Role = RoleAdmin
+	admin.CreatedAt = time.Now()
+	return nil
+}
+
+func (o MockOrgModel) Get(id uuid.UUID) (*Organization, error) {
+	if id == ExampleOrgID {
+		return &Organization{ID: ExampleOrgID, Name: "Acme", CreatedAt: time.Now()}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (o MockOrgModel) InsertMember(member *Member, tokenHash []byte) error {
+	member.ID = 3
+	member.CreatedAt = time.Now()
+	return nil
+}
+
+func (o MockOrgModel) GetMembers(orgID uuid.UUID) ([]*Member, error) {
+	if orgID != ExampleOrgID {
+		return []*Member{}, nil
+	}
+	return []*Member{
+		{ID: ExampleMemberIDAdmin, OrgID: orgID, Name: "Jane", Role: RoleAdmin, CreatedAt: time.Now()},
+		{ID: ExampleMemberIDEditor, OrgID: orgID, Name: "John", Role: RoleEditor, CreatedAt: time.Now()},
+	}, nil
+}
+
+func (o MockOrgModel) GetMemberForToken(tokenPlaintext string) (*Member, error) {
+	member := &Member{OrgID: ExampleOrgID, CreatedAt: time.Now()}
+	switch tokenPlaintext {
+	case ExampleTokenOrgAdmin:
+		member.ID, member.Name, member.Role = ExampleMemberIDAdmin, "Jane", RoleAdmin
+	case ExampleTokenOrgEditor:
+		member.ID, member.Name, member.Role = ExampleMemberIDEditor, "John", RoleEditor
+	case ExampleTokenOrgViewer:
+		member.ID, member.Name, member.Role = 4, "Joe", RoleViewer
+	default:
+		return nil, ErrRecordNotFound
+	}
+	return member, nil
+}
+
+// UpdateMemberRole and DeleteMember treat the example admin as the
+// organization's only admin.
+func (o MockOrgModel) UpdateMemberRole(member *Member) error {
+	if member.OrgID != ExampleOrgID || member.ID > ExampleMemberIDEditor {
+		return ErrRecordNotFound
+	}
+	if member.ID == ExampleMemberIDAdmin && member.Role != RoleAdmin {
+		return ErrLastAdmin
+	}
+	member.Name = "John"
+	member.CreatedAt = time.Now()
+	return nil
+}
+
+func (o MockOrgModel) DeleteMember(orgID uuid.UUID, memberID int64) error {
+	if orgID != ExampleOrgID || memberID > ExampleMemberIDEditor {
+		return ErrRecordNotFound
+	}
+	if memberID == ExampleMemberIDAdmin {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// Usage
+
+type MockUsageModel struct {
+	DB *pgxpool.Pool
+}
+
+// ExampleOrgActivePolls is the number of active polls of the example
+// organization.
+const ExampleOrgActivePolls = 2
+
+func (u MockUsageModel) Get(orgID uuid.UUID) (*UsageReport, error) {
+	return &UsageReport{
+		Period:        time.Now(),
+		PollsCreated:  3,
+		VotesReceived: 10,
+		ActivePolls:   ExampleOrgActivePolls,
+	}, nil
+}
+
+func (u MockUsageModel) ActivePolls(orgID uuid.UUID) (int, error) {
+	return ExampleOrgActivePolls, nil
+}
+
+// View
+
+type MockViewModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockViewModel) Record(pollID uuid.UUID, source string, visitor []byte) error {
+	return nil
+}
+
+func (m MockViewModel) GetSourceCounts(pollID uuid.UUID) ([]*SourceCount, error) {
+	return []*SourceCount{
+		{Source: "slack", Views: 10, Votes: 4},
+		{Source: "", Views: 5, Votes: 1},
+	}, nil
+}
+
+func (m MockViewModel) GetStats(pollID uuid.UUID) (*ViewStats, error) {
+	if pollID == ExamplePollIDVotingStarted {
+		return &ViewStats{}, nil
+	}
+	return &ViewStats{Views: 15, Votes: 5}, nil
+}
+
+// AuditLog
+
+type MockAuditLogModel struct {
+	DB *pgxpool.Pool
+}
+
+func (a MockAuditLogModel) Insert(activity *Activity) error {
+	activity.ID = 1
+	activity.CreatedAt = time.Now()
+	return nil
+}
+
+func (a MockAuditLogModel) GetForPoll(pollID uuid.UUID, filters Filters) ([]*Activity, Metadata, error) {
+	activities := []*Activity{
+		{ID: 3, PollID: pollID, Action: ActionVoteCast, Actor: ActorVoter, Details: map[string]any{"option_id": ExampleOptionID1}},
+		{ID: 2, PollID: pollID, Action: ActionOptionAdded, Actor: ActorOwner, Details: map[string]any{"value": "Third"}},
+		{ID: 1, PollID: pollID, Action: ActionPollCreated, Actor: ActorOwner},
+	}
+	return activities, Metadata{CurrentPage: 1, PageSize: filters.PageSize, FirstPage: 1, LastPage: 1, TotalPages: 1, TotalRecords: 3}, nil
+}
+
+// Reports
+
+type MockReportModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert hides the poll when a single report is enough.
+func (m MockReportModel) Insert(pollID uuid.UUID, reporter []byte, reason string, threshold int) (bool, error) {
+	if pollID != ExamplePollIDValid {
+		return false, ErrRecordNotFound
+	}
+	return threshold == 1, nil
+}
+
+func (m MockReportModel) GetOpen() ([]*ReportedPoll, error) {
+	return []*ReportedPoll{
+		{
+			PollID:           ExamplePollIDValid,
+			Question:         "Test?",
+			ModerationStatus: ModerationReported,
+			Reports:          5,
+			Reasons:          []string{"spam"},
+			FirstReportedAt:  time.Now().Add(-time.Hour),
+		},
+	}, nil
+}
+
+func (m MockReportModel) Resolve(pollID uuid.UUID, status string) error {
+	if pollID == ExamplePollIDValid {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+// Takedowns
+
+type MockTakedownModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockTakedownModel) Insert(takedown *Takedown) error {
+	if takedown.PollID != ExamplePollIDValid && takedown.PollID != ExamplePollIDRemoved {
+		return ErrRecordNotFound
+	}
+	takedown.CreatedAt = time.Now()
+	return nil
+}
+
+func (m MockTakedownModel) Get(pollID uuid.UUID) (*Takedown, error) {
+	if pollID != ExamplePollIDRemoved {
+		return nil, ErrRecordNotFound
+	}
+	return &Takedown{PollID: pollID, Reason: "spam", CreatedAt: time.Now().Add(-time.Hour)}, nil
+}
+
+func (m MockTakedownModel) Delete(pollID uuid.UUID) error {
+	if pollID != ExamplePollIDRemoved {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Bans
+
+var (
+	ExampleBannedIP    = "203.0.113.7"
+	ExampleTokenBanned = "BANNEDTOKENAAAAAAAAAAAAAAA"
+	ExampleBanID       = int64(1)
+)
+
+type MockBanModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockBanModel) Insert(ban *Ban) error {
+	if ban.IP == ExampleBannedIP {
+		return ErrAlreadyBanned
+	}
+	ban.ID = ExampleBanID + 1
+	ban.Kind = BanIP
+	if ban.IP == "" {
+		ban.Kind = BanAPIKey
+	}
+	ban.CreatedAt = time.Now()
+	return nil
+}
+
+func (m MockBanModel) GetAll() ([]*Ban, error) {
+	return []*Ban{
+		{ID: ExampleBanID, Kind: BanIP, IP: ExampleBannedIP, Reason: "spam", CreatedAt: time.Now()},
+	}, nil
+}
+
+func (m MockBanModel) Banned(ip string, keyHash []byte) (bool, error) {
+	return ip == ExampleBannedIP || bytes.Equal(keyHash, HashToken(ExampleTokenBanned)), nil
+}
+
+func (m MockBanModel) Delete(id int64) error {
+	if id != ExampleBanID {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Staged actions
+
+var ExampleStagedActionID = uuid.MustParse("5e8c2f71-0a4d-4b96-8d3e-7f1a9c6b2e04")
+
+type MockStagedActionModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockStagedActionModel) Stage(action *StagedAction, window time.Duration) error {
+	action.setAction()
+	action.ID = ExampleStagedActionID
+	action.UndoUntil = time.Now().Add(window)
+	return nil
+}
+
+// Get returns the staged delete of ExamplePollIDValid's option 2.
+func (m MockStagedActionModel) Get(id uuid.UUID) (*StagedAction, error) {
+	if id != ExampleStagedActionID {
+		return nil, ErrRecordNotFound
+	}
+	optionID := ExampleOptionID2
+	return &StagedAction{
+		ID:        id,
+		Action:    StagedOptionDelete,
+		PollID:    ExamplePollIDValid,
+		OptionID:  &optionID,
+		UndoUntil: time.Now().Add(time.Minute),
+	}, nil
+}
+
+func (m MockStagedActionModel) GetOptionDeletes(pollID uuid.UUID) ([]uuid.UUID, error) {
+	if pollID == ExamplePollIDValid {
+		return []uuid.UUID{ExampleOptionID2}, nil
+	}
+	return nil, nil
+}
+
+// GetTrash returns a staged delete of ExamplePollIDOrg.
+func (m MockStagedActionModel) GetTrash(orgID uuid.UUID) ([]*TrashedPoll, error) {
+	if orgID != ExampleOrgID {
+		return []*TrashedPoll{}, nil
+	}
+	return []*TrashedPoll{{
+		ActionID:  ExampleStagedActionID,
+		PollID:    ExamplePollIDOrg,
+		Question:  "Org?",
+		DeletedBy: "Alice",
+		DeletedAt: time.Now().Add(-10 * time.Second),
+		UndoUntil: time.Now().Add(20 * time.Second),
+	}}, nil
+}
+
+func (m MockStagedActionModel) Undo(id uuid.UUID) error {
+	if _, err := m.Get(id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m MockStagedActionModel) ExecuteDue() (int, error) {
+	return 0, nil
+}
+
+// Deleted options
+
+var (
+	ExampleDeletedOptionID = uuid.MustParse("3f6b1c9e-8d2a-4e7f-b5c0-9a1d4e2f7b63")
+	// ExampleDeletedOptionIDTaken has the value of an option added since
+	ExampleDeletedOptionIDTaken = uuid.MustParse("8a2d5e7f-1b3c-4d9e-a6f0-2c4b7e9d1a35")
+)
+
+type MockDeletedOptionModel struct {
+	DB *pgxpool.Pool
+}
+
+// GetAll returns an option deleted from ExamplePollIDValid with 3 votes.
+func (m MockDeletedOptionModel) GetAll(pollID uuid.UUID, window time.Duration) ([]*DeletedOption, error) {
+	if pollID != ExamplePollIDValid {
+		return []*DeletedOption{}, nil
+	}
+	deletedAt := time.Now().Add(-time.Hour)
+	return []*DeletedOption{{
+		ID:           ExampleDeletedOptionID,
+		Value:        "Green",
+		Position:     2,
+		VoteCount:    3,
+		DeletedAt:    deletedAt,
+		RestoreUntil: deletedAt.Add(window),
+	}}, nil
+}
+
+func (m MockDeletedOptionModel) Restore(pollID uuid.UUID, optionID uuid.UUID, window time.Duration) (*PollOption, error) {
+	if pollID != ExamplePollIDValid {
+		return nil, ErrRecordNotFound
+	}
+	switch optionID {
+	case ExampleDeletedOptionID:
+		return &PollOption{ID: optionID, Value: "Green", Position: 2, VoteCount: 3}, nil
+	case ExampleDeletedOptionIDTaken:
+		return nil, ErrDuplicateOption
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (m MockDeletedOptionModel) Purge(window time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// Pending votes
+
+const (
+	ExampleConfirmToken      = "CONFIRMTOKENAAAAAAAAAAAAAA"
+	ExampleConfirmTokenVoted = "CONFIRMVOTEDTOKENAAAAAAAAA"
+	// ExampleEmailResendLimit has been sent too many confirmation emails
+	ExampleEmailResendLimit = "limit@example.com"
+)
+
+type MockPendingVoteModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockPendingVoteModel) Insert(pending *PendingVote, tokenHash []byte, ttl time.Duration) error {
+	if pending.Vote.VoterIdentity == EmailVoterIdentity(ExampleEmailResendLimit) {
+		return ErrConfirmationLimit
+	}
+	pending.ID = 1
+	pending.ExpiresAt = time.Now().Add(ttl)
+	return nil
+}
+
+func (m MockPendingVoteModel) GetByToken(tokenHash []byte) (*PendingVote, error) {
+	var identity string
+	switch string(tokenHash) {
+	case string(HashToken(ExampleConfirmToken)):
+		identity = EmailVoterIdentity("jane@example.com")
+	case string(HashToken(ExampleConfirmTokenVoted)):
+		identity = ExampleRacingVoterIdentity
+	default:
+		return nil, ErrRecordNotFound
+	}
+	return &PendingVote{
+		ID: 1,
+		Vote: &Vote{
+			PollID:        ExamplePollIDConfirm,
+			OptionID:      ExampleOptionID1,
+			VoterIdentity: identity,
+		},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (m MockPendingVoteModel) Delete(id int64) error {
+	return nil
+}
+
+// SMS numbers
+
+type MockSMSNumberModel struct {
+	DB *pgxpool.Pool
+}
+
+const (
+	ExampleSMSNumber       = "+15550002222"
+	ExampleSMSNumberPaused = "+15550003333"
+	ExampleSMSNumberTaken  = "+15550004444"
+)
+
+func (m MockSMSNumberModel) Bind(number *SMSNumber) error {
+	if number.PhoneNumber == ExampleSMSNumberTaken {
+		return ErrNumberTaken
+	}
+	number.CreatedAt = time.Now()
+	return nil
+}
+
+func (m MockSMSNumberModel) GetForPoll(pollID uuid.UUID) (*SMSNumber, error) {
+	if pollID == ExamplePollIDValid {
+		return &SMSNumber{PollID: pollID, PhoneNumber: ExampleSMSNumber, CreatedAt: time.Now()}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (m MockSMSNumberModel) GetPollID(phoneNumber string) (uuid.UUID, error) {
+	switch phoneNumber {
+	case ExampleSMSNumber:
+		return ExamplePollIDValid, nil
+	case ExampleSMSNumberPaused:
+		return ExamplePollIDPaused, nil
+	}
+	return uuid.Nil, ErrRecordNotFound
+}
+
+func (m MockSMSNumberModel) Unbind(pollID uuid.UUID) error {
+	if pollID == ExamplePollIDValid {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+// Poll creations
+
+var (
+	// ExampleThrottledIP has created as many polls today as it may.
+	ExampleThrottledIP = "203.0.113.9"
+	// ExampleUncountedIP is let through by Record, but its polls are refused
+	// by the database, as if the day ended in between.
+	ExampleUncountedIP = "203.0.113.10"
+	ExampleExemptIP    = "198.51.100.7"
+	ExampleExemptionID = int64(1)
+)
+
+type MockPollCreationModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockPollCreationModel) Record(ip string, keyHash []byte, limit int) (bool, error) {
+	return keyHash != nil || ip != ExampleThrottledIP, nil
+}
+
+func (m MockPollCreationModel) Purge() (int64, error) {
+	return 0, nil
+}
+
+func (m MockPollCreationModel) InsertExemption(exemption *Exemption) error {
+	if exemption.IP == ExampleExemptIP {
+		return ErrAlreadyExempt
+	}
+	exemption.ID = ExampleExemptionID + 1
+	exemption.Kind = BanIP
+	if exemption.IP == "" {
+		exemption.Kind = BanAPIKey
+	}
+	exemption.CreatedAt = time.Now()
+	return nil
+}
+
+func (m MockPollCreationModel) GetExemptions() ([]*Exemption, error) {
+	return []*Exemption{
+		{ID: ExampleExemptionID, Kind: BanIP, IP: ExampleExemptIP, Reason: "load test", CreatedAt: time.Now()},
+	}, nil
+}
+
+func (m MockPollCreationModel) DeleteExemption(id int64) error {
+	if id != ExampleExemptionID {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// API keys
+
+const (
+	// ExampleAPIKey is an editor's key of the example organization.
+	ExampleAPIKey        = "polls_APIKEYAA_APIKEYSECRETAAAAAAAAAAAAAAAAAAAA"
+	ExampleAPIKeyRevoked = "polls_REVOKEDA_APIKEYSECRETAAAAAAAAAAAAAAAAAAAA"
+	ExampleAPIKeyID      = int64(1)
+)
+
+type MockAPIKeyModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m MockAPIKeyModel) Insert(key *APIKey) error {
+	key.ID = ExampleAPIKeyID + 1
+	key.CreatedAt = time.Now()
+	return nil
+}
+
+func (m MockAPIKeyModel) GetForKey(plaintext string) (*APIKey, error) {
+	if plaintext != ExampleAPIKey {
+		return nil, ErrRecordNotFound
+	}
+	return &APIKey{
+		ID:        ExampleAPIKeyID,
+		OrgID:     ExampleOrgID,
+		Name:      "CRM sync",
+		Role:      RoleEditor,
+		Prefix:    "APIKEYAA",
+		CreatedAt: time.Now(),
+		Plaintext: plaintext,
+		Hash:      HashToken(plaintext),
+	}, nil
+}
+
+func (m MockAPIKeyModel) GetAll(orgID uuid.UUID) ([]*APIKey, error) {
+	if orgID != ExampleOrgID {
+		return []*APIKey{}, nil
+	}
+	key, _ := m.GetForKey(ExampleAPIKey)
+	return []*APIKey{key}, nil
+}
+
+func (m MockAPIKeyModel) Revoke(orgID uuid.UUID, id int64) error {
+	if orgID != ExampleOrgID || id != ExampleAPIKeyID {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -1,44 +1,298 @@
 package data
 
 import (
+	"context"
 	"errors"
 	"net"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/search"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrRecordNotFound = errors.New("record not found")
+// Errors returned by models, so callers can respond to them without matching
+// messages. ErrEditConflict means the record changed since it was read.
+var (
+	ErrRecordNotFound   = errors.New("record not found")
+	ErrEditConflict     = errors.New("edit conflict")
+	ErrPollExpired      = errors.New("poll has expired")
+	ErrPollClosed       = errors.New("poll is closed")
+	ErrOptionNotInPoll  = errors.New("option does not belong to the poll")
+	ErrDuplicateOption  = errors.New("poll already has an option with the value")
+	ErrTooManyOptions   = errors.New("poll has reached its maximum number of options")
+	ErrAlreadyVoted     = errors.New("voter has already voted on the poll")
+	ErrVoteQuotaReached = errors.New("poll has reached its maximum number of votes")
+	ErrAlreadyBanned    = errors.New("already banned")
+	ErrAlreadyExempt    = errors.New("already exempt")
+	ErrCreationLimited  = errors.New("creator has created as many polls as it may today")
+	ErrQuotaExceeded    = errors.New("organization has as many active polls as its quota allows")
+	ErrDuplicateExtID   = errors.New("another poll has the external id")
+	ErrNumberTaken      = errors.New("phone number is bound to another poll")
+)
+
+// querier runs queries on the pool or in a transaction, for helpers used by
+// both.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// uniqueViolation reports whether a statement failed because it violated the
+// unique constraint or index.
+func uniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// checkViolation reports whether err is a violation of the check, including
+// the checks of triggers.
+func checkViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514" && pgErr.ConstraintName == constraint
+}
+
+// transientError reports whether a transaction failed because of concurrent
+// transactions, and would likely succeed if tried again.
+func transientError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	// serialization_failure and deadlock_detected
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
 
 const dbTimeout = time.Second * 3
 
+// Models holds the data layer behind interfaces, so handlers don't depend on
+// how records are stored. NewModels backs them with Postgres and
+// NewMockModels with the fixtures in mocks.go, and other backends only need
+// to implement the interfaces. The repositories of polls, options and votes
+// also have mocks generated into package mock, for tests that set their own
+// expectations.
 type Models struct {
-	Polls       Polls
-	PollOptions PollOptions
+	Polls       PollRepository
+	PollOptions OptionRepository
+	Votes       VoteRepository
+	ShortLinks  ShortLinks
+	Digests     Digests
+	Transfers   Transfers
+	Tokens      Tokens
+	Orgs        Orgs
+	Usage       Usage
+	Views       Views
+	AuditLog    AuditLog
+	Reports     Reports
+	Takedowns   Takedowns
+	Bans        Bans
+	Staged      StagedActions
+	Exports     ResultExports
+	Pending     PendingVotes
+	SMSNumbers  SMSNumbers
+	Deleted     DeletedOptions
+	Creations   PollCreations
+	APIKeys     APIKeys
 }
 
-type Polls interface {
+//go:generate go run go.uber.org/mock/mockgen -destination=mock/repositories.go -package=mock . PollRepository,OptionRepository,VoteRepository
+
+type PollRepository interface {
 	Insert(poll *Poll, tokenHash []byte) error
-	Get(id string) (*Poll, error)
+	Import(export *PollExport, tokenHash []byte) error
+	Get(id uuid.UUID) (*Poll, error)
+	GetByIDs(ids []uuid.UUID) ([]*Poll, error)
 	Update(poll *Poll) error
-	Delete(id string) error
-	GetAll(search string, filters Filters) ([]*Poll, Metadata, error)
-	GetVotedIPs(pollID string) ([]*net.IP, error)
-	CheckToken(tokenPlaintext string) (string, error)
+	Delete(id uuid.UUID) error
+	GetAll(search string, orgID uuid.UUID, metadata map[string]string, filters Filters) ([]*Poll, Metadata, error)
+	Search(q search.Query, filters Filters) ([]*Poll, Metadata, error)
+	GetPublic(after uuid.UUID, limit int) ([]*PublicPoll, error)
+	Stream(orgID uuid.UUID, fn func(*Poll) error) error
+	GetPublicStats() (*PublicStats, error)
+	GetSeries(seriesID uuid.UUID, limit int) ([]*Poll, error)
+	GetRelated(pollID uuid.UUID, limit int) ([]*Poll, error)
+	GetDeadlines(orgID uuid.UUID, limit int) ([]*Poll, error)
+	GetIDByExternalID(orgID uuid.UUID, pollID uuid.UUID, externalID string) (uuid.UUID, error)
+	GetVotedIPs(pollID uuid.UUID) ([]*net.IP, error)
+	CheckToken(tokenPlaintext string) (uuid.UUID, string, error)
+	CloseExpired() ([]*Poll, error)
+	DeleteExpired(before time.Time) (int64, error)
+	SetNotifyEmail(id uuid.UUID, email string) error
+	Pause(id uuid.UUID, reason string) (time.Time, error)
+	Resume(id uuid.UUID) error
+	Publish(id uuid.UUID) error
+	Flag(id uuid.UUID, terms []string) error
+	Moderate(id uuid.UUID, status string) error
+	GetFlagged() ([]*Poll, error)
+}
+type OptionRepository interface {
+	Insert(option *PollOption, pollID uuid.UUID) error
+	UpdateValue(pollID uuid.UUID, option *PollOption) error
+	UpdatePosition(pollID uuid.UUID, options []*PollOption) error
+	Vote(vote *Vote) error
+	Delete(pollID uuid.UUID, optionID uuid.UUID) error
+	Merge(pollID uuid.UUID, optionID uuid.UUID, intoID uuid.UUID) (int, error)
+	GetResults(pollID uuid.UUID) ([]*PollOption, error)
+}
+type VoteRepository interface {
+	GetVoterNames(pollID uuid.UUID) (map[uuid.UUID][]string, error)
+	GetAll(pollID uuid.UUID) ([]*Vote, error)
+	Get(pollID uuid.UUID, id int64) (*Vote, error)
+	GetBallots(pollID uuid.UUID) ([]*Vote, error)
+	HasVoted(pollID uuid.UUID, voterIdentity string) (bool, error)
+	GetRecent(pollID uuid.UUID, since time.Time) ([]*Vote, error)
+	GetFlagged(pollID uuid.UUID) ([]*Vote, error)
+	Moderate(pollID uuid.UUID, voteID int64, status string) error
+	GetStats(pollID uuid.UUID) (*VoteStats, error)
+	GetSegmentCounts(pollID uuid.UUID, key string) (map[string]map[uuid.UUID]int, error)
+	GetVariantCounts(pollID uuid.UUID) (map[int]map[uuid.UUID]int, error)
+	Analyze() error
+}
+
+type ShortLinks interface {
+	Insert(pollID uuid.UUID) (*ShortLink, error)
+	GetForPoll(pollID uuid.UUID) (*ShortLink, error)
+	GetByCode(code string) (*ShortLink, error)
+}
+
+type Digests interface {
+	Subscribe(sub *DigestSubscription) error
+	GetSubscription(pollID uuid.UUID) (*DigestSubscription, error)
+	Unsubscribe(pollID uuid.UUID) error
+	ClaimDue() ([]*Digest, error)
+}
+
+type Transfers interface {
+	Insert(transfer *Transfer, tokenHash []byte) error
+	GetForPoll(pollID uuid.UUID) ([]*Transfer, error)
+}
+
+type Tokens interface {
+	Insert(pollID uuid.UUID, token *Token) error
+	Rotate(pollID uuid.UUID, oldHash, newHash []byte) error
+	RevokeAll(pollID uuid.UUID) (int64, error)
+}
+
+type Orgs interface {
+	Insert(org *Organization, admin *Member, tokenHash []byte) error
+	Get(id uuid.UUID) (*Organization, error)
+	InsertMember(member *Member, tokenHash []byte) error
+	GetMembers(orgID uuid.UUID) ([]*Member, error)
+	GetMemberForToken(tokenPlaintext string) (*Member, error)
+	UpdateMemberRole(member *Member) error
+	DeleteMember(orgID uuid.UUID, memberID int64) error
+}
+
+type Usage interface {
+	Get(orgID uuid.UUID) (*UsageReport, error)
+	ActivePolls(orgID uuid.UUID) (int, error)
+}
+
+type AuditLog interface {
+	Insert(activity *Activity) error
+	GetForPoll(pollID uuid.UUID, filters Filters) ([]*Activity, Metadata, error)
+}
+
+type Views interface {
+	Record(pollID uuid.UUID, source string, visitor []byte) error
+	GetSourceCounts(pollID uuid.UUID) ([]*SourceCount, error)
+	GetStats(pollID uuid.UUID) (*ViewStats, error)
+}
+
+type Reports interface {
+	Insert(pollID uuid.UUID, reporter []byte, reason string, threshold int) (bool, error)
+	GetOpen() ([]*ReportedPoll, error)
+	Resolve(pollID uuid.UUID, status string) error
+}
+
+type Takedowns interface {
+	Insert(takedown *Takedown) error
+	Get(pollID uuid.UUID) (*Takedown, error)
+	Delete(pollID uuid.UUID) error
+}
+
+type StagedActions interface {
+	Stage(action *StagedAction, window time.Duration) error
+	Get(id uuid.UUID) (*StagedAction, error)
+	GetOptionDeletes(pollID uuid.UUID) ([]uuid.UUID, error)
+	GetTrash(orgID uuid.UUID) ([]*TrashedPoll, error)
+	Undo(id uuid.UUID) error
+	ExecuteDue() (int, error)
+}
+
+type DeletedOptions interface {
+	GetAll(pollID uuid.UUID, window time.Duration) ([]*DeletedOption, error)
+	Restore(pollID uuid.UUID, optionID uuid.UUID, window time.Duration) (*PollOption, error)
+	Purge(window time.Duration) (int64, error)
+}
+
+type PollCreations interface {
+	Record(ip string, keyHash []byte, limit int) (bool, error)
+	Purge() (int64, error)
+	InsertExemption(exemption *Exemption) error
+	GetExemptions() ([]*Exemption, error)
+	DeleteExemption(id int64) error
+}
+
+type APIKeys interface {
+	Insert(key *APIKey) error
+	GetForKey(plaintext string) (*APIKey, error)
+	GetAll(orgID uuid.UUID) ([]*APIKey, error)
+	Revoke(orgID uuid.UUID, id int64) error
+}
+
+type ResultExports interface {
+	Schedule(export *ResultExport) error
+	Get(pollID uuid.UUID) (*ResultExport, error)
+	Delete(pollID uuid.UUID) error
+	ClaimDue() ([]*ResultExport, error)
+}
+
+type PendingVotes interface {
+	Insert(pending *PendingVote, tokenHash []byte, ttl time.Duration) error
+	GetByToken(tokenHash []byte) (*PendingVote, error)
+	Delete(id int64) error
 }
-type PollOptions interface {
-	Insert(option *PollOption, pollID string) error
-	UpdateValue(option *PollOption) error
-	UpdatePosition(options []*PollOption) error
-	Vote(optionID string, pollID string, ip string) error
-	Delete(optionID string) error
-	GetResults(pollID string) ([]*PollOption, error)
+
+type SMSNumbers interface {
+	Bind(number *SMSNumber) error
+	GetForPoll(pollID uuid.UUID) (*SMSNumber, error)
+	GetPollID(phoneNumber string) (uuid.UUID, error)
+	Unbind(pollID uuid.UUID) error
+}
+
+type Bans interface {
+	Insert(ban *Ban) error
+	GetAll() ([]*Ban, error)
+	Banned(ip string, keyHash []byte) (bool, error)
+	Delete(id int64) error
 }
 
 func NewModels(db *pgxpool.Pool) Models {
 	return Models{
 		Polls:       PollModel{DB: db},
 		PollOptions: PollOptionModel{DB: db},
+		Votes:       VoteModel{DB: db},
+		ShortLinks:  ShortLinkModel{DB: db},
+		Digests:     DigestModel{DB: db},
+		Transfers:   TransferModel{DB: db},
+		Tokens:      TokenModel{DB: db},
+		Orgs:        OrgModel{DB: db},
+		Usage:       UsageModel{DB: db},
+		Views:       ViewModel{DB: db},
+		AuditLog:    AuditLogModel{DB: db},
+		Reports:     ReportModel{DB: db},
+		Takedowns:   TakedownModel{DB: db},
+		Bans:        BanModel{DB: db},
+		Staged:      StagedActionModel{DB: db},
+		Exports:     ResultExportModel{DB: db},
+		Pending:     PendingVoteModel{DB: db},
+		SMSNumbers:  SMSNumberModel{DB: db},
+		Deleted:     DeletedOptionModel{DB: db},
+		Creations:   PollCreationModel{DB: db},
+		APIKeys:     APIKeyModel{DB: db},
 	}
 }
 
@@ -46,5 +300,24 @@ func NewMockModels() Models {
 	return Models{
 		Polls:       MockPollModel{},
 		PollOptions: MockPollOptionModel{},
+		Votes:       MockVoteModel{},
+		ShortLinks:  MockShortLinkModel{},
+		Digests:     MockDigestModel{},
+		Transfers:   MockTransferModel{},
+		Tokens:      MockTokenModel{},
+		Orgs:        MockOrgModel{},
+		Usage:       MockUsageModel{},
+		Views:       MockViewModel{},
+		AuditLog:    MockAuditLogModel{},
+		Reports:     MockReportModel{},
+		Takedowns:   MockTakedownModel{},
+		Bans:        MockBanModel{},
+		Staged:      MockStagedActionModel{},
+		Exports:     MockResultExportModel{},
+		Pending:     MockPendingVoteModel{},
+		SMSNumbers:  MockSMSNumberModel{},
+		Deleted:     MockDeletedOptionModel{},
+		Creations:   MockPollCreationModel{},
+		APIKeys:     MockAPIKeyModel{},
 	}
 }
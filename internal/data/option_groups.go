@@ -0,0 +1,60 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// OptionGroup is a labeled section of a poll's options, e.g. "Appetizers"
+// and "Mains" on a menu. Options can also be left out of groups.
+type OptionGroup struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	// Position of the group in the list, starting at 0
+	Position int `json:"position"`
+}
+
+// MaxOptionGroups is how many groups a poll's options can be divided into.
+const MaxOptionGroups = 20
+
+// optionGroups selects the poll's groups as a JSON array ordered by their
+// position, for queries of p in polls.
+const optionGroups = `COALESCE((
+			SELECT jsonb_agg(jsonb_build_object(
+				'id', g.id, 'label', g.label, 'position', g.position
+			) ORDER BY g.position)
+			FROM option_groups g
+			WHERE g.poll_id = p.id
+		), '[]')`
+
+// insertOptionGroups inserts the poll's groups, which get new IDs. The IDs
+// they had only tie them to their options, whose group IDs are changed to
+// the new ones.
+func insertOptionGroups(ctx context.Context, q querier, poll *Poll) error {
+	query := `
+		INSERT INTO option_groups (poll_id, label, position)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`
+
+	groupIDs := make(map[uuid.UUID]uuid.UUID, len(poll.Groups))
+	for _, group := range poll.Groups {
+		oldID := group.ID
+		err := q.QueryRow(ctx, query, poll.ID, group.Label, group.Position).Scan(&group.ID)
+		if err != nil {
+			return fmt.Errorf("insert option group: %w", err)
+		}
+		groupIDs[oldID] = group.ID
+	}
+
+	for _, option := range poll.Options {
+		if option.GroupID != nil {
+			groupID := groupIDs[*option.GroupID]
+			option.GroupID = &groupID
+		}
+	}
+
+	return nil
+}
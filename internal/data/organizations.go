@@ -0,0 +1,296 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Member roles. Admins manage the organization's members, editors manage its
+// polls and viewers see them and their results.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var ErrLastAdmin = errors.New("organization must keep at least one admin")
+
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Member belongs to an organization and authenticates with their own token.
+type Member struct {
+	ID        int64     `json:"id"`
+	OrgID     uuid.UUID `json:"org_id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrgModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert creates the organization together with its first admin.
+func (o OrgModel) Insert(org *Organization, admin *Member, tokenHash []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert organization: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO organizations (name)
+		VALUES ($1)
+		RETURNING id, created_at;
+	`
+	err = tx.QueryRow(ctx, query, org.Name).Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert organization: %w", err)
+	}
+
+	admin.OrgID = org.ID
+	admin.Role = RoleAdmin
+	if err := insertMember(ctx, tx, admin, tokenHash); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (o OrgModel) Get(id uuid.UUID) (*Organization, error) {
+	query := `
+		SELECT id, name, created_at
+		FROM organizations
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var org Organization
+	err := o.DB.QueryRow(ctx, query, id).Scan(&org.ID, &org.Name, &org.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get organization: %w", err)
+	}
+
+	return &org, nil
+}
+
+func (o OrgModel) InsertMember(member *Member, tokenHash []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return insertMember(ctx, o.DB, member, tokenHash)
+}
+
+// queryRower is implemented by both pools and transactions.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertMember(ctx context.Context, db queryRower, member *Member, tokenHash []byte) error {
+	query := `
+		INSERT INTO org_members (org_id, name, role, token_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	err := db.QueryRow(ctx, query, member.OrgID, member.Name, member.Role, tokenHash).Scan(
+		&member.ID,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert member: %w", err)
+	}
+
+	return nil
+}
+
+func (o OrgModel) GetMembers(orgID uuid.UUID) ([]*Member, error) {
+	query := `
+		SELECT id, org_id, name, role, created_at
+		FROM org_members
+		WHERE org_id = $1
+		ORDER BY created_at, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := o.DB.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("get members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*Member{}
+
+	for rows.Next() {
+		var member Member
+		err := rows.Scan(&member.ID, &member.OrgID, &member.Name, &member.Role, &member.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get members - scan: %w", err)
+		}
+		members = append(members, &member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get members: %w", err)
+	}
+
+	return members, nil
+}
+
+// GetMemberForToken returns the member the token belongs to.
+func (o OrgModel) GetMemberForToken(tokenPlaintext string) (*Member, error) {
+	query := `
+		SELECT id, org_id, name, role, created_at
+		FROM org_members
+		WHERE token_hash = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var member Member
+	err := o.DB.QueryRow(ctx, query, HashToken(tokenPlaintext)).Scan(
+		&member.ID,
+		&member.OrgID,
+		&member.Name,
+		&member.Role,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get member for token: %w", err)
+	}
+
+	return &member, nil
+}
+
+// UpdateMemberRole changes a member's role. ErrLastAdmin is returned if that
+// would leave the organization without an admin.
+func (o OrgModel) UpdateMemberRole(member *Member) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update member role: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if member.Role != RoleAdmin {
+		if err := checkNotLastAdmin(ctx, tx, member.OrgID, member.ID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE org_members
+		SET role = $1
+		WHERE id = $2 AND org_id = $3
+		RETURNING name, created_at;
+	`
+	err = tx.QueryRow(ctx, query, member.Role, member.ID, member.OrgID).Scan(&member.Name, &member.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("update member role: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteMember removes a member and invalidates their token. ErrLastAdmin is
+// returned for the organization's only admin.
+func (o OrgModel) DeleteMember(orgID uuid.UUID, memberID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("delete member: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkNotLastAdmin(ctx, tx, orgID, memberID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM org_members WHERE id = $1 AND org_id = $2;`, memberID, orgID)
+	if err != nil {
+		return fmt.Errorf("delete member: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+// checkNotLastAdmin returns ErrLastAdmin if the member is the organization's
+// only admin. The organization is locked until the transaction ends, so
+// concurrent changes can't remove the last two admins at once.
+func checkNotLastAdmin(ctx context.Context, tx pgx.Tx, orgID uuid.UUID, memberID int64) error {
+	_, err := tx.Exec(ctx, `SELECT 1 FROM organizations WHERE id = $1 FOR UPDATE;`, orgID)
+	if err != nil {
+		return fmt.Errorf("lock organization: %w", err)
+	}
+
+	query := `
+		SELECT count(*) FILTER (WHERE role = $3),
+			count(*) FILTER (WHERE role = $3 AND id = $2)
+		FROM org_members
+		WHERE org_id = $1;
+	`
+
+	var admins, isAdmin int
+	err = tx.QueryRow(ctx, query, orgID, memberID, RoleAdmin).Scan(&admins, &isAdmin)
+	if err != nil {
+		return fmt.Errorf("count admins: %w", err)
+	}
+
+	if isAdmin == 1 && admins == 1 {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
+
+func ValidateOrganization(v *validator.Validator, org *Organization) {
+	v.Check(org.Name != "", "name", "must not be empty")
+	v.Check(len(org.Name) <= 200, "name", "must not be more than 200 bytes long")
+}
+
+func ValidateMember(v *validator.Validator, member *Member) {
+	v.Check(member.Name != "", "name", "must not be empty")
+	v.Check(len(member.Name) <= 100, "name", "must not be more than 100 bytes long")
+	ValidateRole(v, member.Role)
+}
+
+func ValidateRole(v *validator.Validator, role string) {
+	v.Check(validator.PermittedValue(
+		role, RoleAdmin, RoleEditor, RoleViewer,
+	), "role", "must be admin, editor or viewer")
+}
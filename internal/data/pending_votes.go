@@ -0,0 +1,151 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Limits on emails confirming a pending vote. Voting again while a vote is
+// pending sends another email, at most MaxConfirmationSends times and not
+// more often than every ConfirmationResendInterval, until the link expires.
+const (
+	MaxConfirmationSends       = 3
+	ConfirmationResendInterval = time.Minute
+)
+
+var ErrConfirmationLimit = errors.New("too many confirmation emails")
+
+// PendingVote is a vote on a poll with confirm_votes, waiting for the voter
+// to confirm it with the link emailed to them.
+type PendingVote struct {
+	ID        int64
+	Vote      *Vote
+	ExpiresAt time.Time
+}
+
+// EmailVoterIdentity returns the identity votes confirmed by email are
+// deduplicated by. Only a hash of the address is stored.
+func EmailVoterIdentity(email string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "email:" + hex.EncodeToString(hash[:8])
+}
+
+type PendingVoteModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert stores the pending vote until ttl has passed, confirmed with the
+// token. A vote already pending for the voter is replaced, which counts as
+// another confirmation email, unless ErrConfirmationLimit is returned.
+func (m PendingVoteModel) Insert(pending *PendingVote, tokenHash []byte, ttl time.Duration) error {
+	query := `
+		INSERT INTO pending_votes (poll_id, option_id, voter_identity, voter_name, user_agent, demographics,
+		source, variant, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (poll_id, voter_identity) DO UPDATE
+		SET option_id = EXCLUDED.option_id, voter_name = EXCLUDED.voter_name,
+		user_agent = EXCLUDED.user_agent, demographics = EXCLUDED.demographics, source = EXCLUDED.source,
+		variant = EXCLUDED.variant, token_hash = EXCLUDED.token_hash, sent_at = NOW(),
+		sends = CASE WHEN pending_votes.expires_at <= NOW() THEN 1 ELSE pending_votes.sends + 1 END,
+		expires_at = EXCLUDED.expires_at
+		WHERE pending_votes.expires_at <= NOW()
+		OR (pending_votes.sends < $11 AND pending_votes.sent_at <= $12)
+		RETURNING id, expires_at;
+	`
+
+	vote := pending.Vote
+	args := []any{
+		vote.PollID,
+		vote.OptionID,
+		vote.VoterIdentity,
+		vote.VoterName,
+		vote.UserAgent,
+		answersOrEmpty(vote.Demographics),
+		vote.Source,
+		vote.Variant,
+		tokenHash,
+		time.Now().Add(ttl),
+		MaxConfirmationSends,
+		time.Now().Add(-ConfirmationResendInterval),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, query, args...).Scan(&pending.ID, &pending.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrConfirmationLimit
+		}
+		return fmt.Errorf("insert pending vote: %w", err)
+	}
+
+	return nil
+}
+
+// GetByToken returns the pending vote confirmed with the token, unless it
+// expired.
+func (m PendingVoteModel) GetByToken(tokenHash []byte) (*PendingVote, error) {
+	query := `
+		SELECT id, poll_id, option_id, voter_identity, voter_name, user_agent, demographics, source, variant,
+		expires_at
+		FROM pending_votes
+		WHERE token_hash = $1 AND expires_at > NOW();
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	pending := PendingVote{Vote: &Vote{}}
+	vote := pending.Vote
+	err := m.DB.QueryRow(ctx, query, tokenHash).Scan(
+		&pending.ID,
+		&vote.PollID,
+		&vote.OptionID,
+		&vote.VoterIdentity,
+		&vote.VoterName,
+		&vote.UserAgent,
+		&vote.Demographics,
+		&vote.Source,
+		&vote.Variant,
+		&pending.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get pending vote: %w", err)
+	}
+
+	return &pending, nil
+}
+
+// Delete removes a pending vote once it is confirmed.
+func (m PendingVoteModel) Delete(id int64) error {
+	query := `
+		DELETE FROM pending_votes
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete pending vote: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Exemption lets an IP, or anyone using an API key, create more polls a day
+// than the daily limit. Like with bans, only the hash of API keys is stored.
+type Exemption struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	IP        string    `json:"ip,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	KeyHash   []byte    `json:"-"`
+}
+
+// ValidateExemption checks the exemption's IP, or the plaintext of the API
+// key or member token to exempt.
+func ValidateExemption(v *validator.Validator, exemption *Exemption, apiKey string) {
+	v.Check(exemption.IP != "" || apiKey != "", "ip", "must be provided unless api_key is")
+	v.Check(exemption.IP == "" || apiKey == "", "api_key", "must not be set together with ip")
+	if exemption.IP != "" {
+		v.Check(net.ParseIP(exemption.IP) != nil, "ip", "must be a valid IP address")
+	}
+	switch {
+	case strings.HasPrefix(apiKey, apiKeyPrefix):
+		_, ok := APIKeyPrefix(apiKey)
+		v.Check(ok, "api_key", "must be a valid API key")
+	case apiKey != "":
+		v.Check(len(apiKey) == 26, "api_key", "must be 26 bytes long")
+	}
+	v.Check(len(exemption.Reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+// CreationSubject is what polls created by the IP, or by the API key hash if
+// it is set, are counted by: "key:" followed by the hex encoded hash, or
+// "ip:" followed by the IP. Polls record it as their CreatedBy, so the
+// database can refuse polls that weren't counted.
+func CreationSubject(ip string, keyHash []byte) string {
+	if keyHash != nil {
+		return "key:" + hex.EncodeToString(keyHash)
+	}
+	return "ip:" + NormalizeIP(ip)
+}
+
+type PollCreationModel struct {
+	DB *pgxpool.Pool
+}
+
+// Record counts a poll created today, by UTC date, by the API key hash if it
+// is set, or else by the IP, and reports whether it is within the daily limit.
+// Polls over the limit aren't counted, and polls created by an exempt IP or
+// API key are always within it.
+func (m PollCreationModel) Record(ip string, keyHash []byte, limit int) (bool, error) {
+	query := `
+		WITH exempt AS (
+			SELECT EXISTS (
+				SELECT 1 FROM creation_exemptions
+				WHERE ip = NULLIF($1, '') OR key_hash = $2
+			) AS exempt
+		), counted AS (
+			INSERT INTO poll_creations (day, subject, count)
+			SELECT (NOW() AT TIME ZONE 'UTC')::date, $3, 1
+			FROM exempt
+			WHERE NOT exempt
+			ON CONFLICT (day, subject) DO UPDATE
+			SET count = poll_creations.count + 1
+			WHERE poll_creations.count < $4
+			RETURNING count
+		)
+		SELECT (SELECT exempt FROM exempt) OR EXISTS (SELECT 1 FROM counted);
+	`
+
+	subject := CreationSubject(ip, keyHash)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var allowed bool
+	err := m.DB.QueryRow(ctx, query, NormalizeIP(ip), keyHash, subject, limit).Scan(&allowed)
+	if err != nil {
+		return false, fmt.Errorf("record poll creation: %w", err)
+	}
+
+	return allowed, nil
+}
+
+// Purge deletes the counts of the days before today, and returns how many
+// there were.
+func (m PollCreationModel) Purge() (int64, error) {
+	query := `
+		DELETE FROM poll_creations
+		WHERE day < (NOW() AT TIME ZONE 'UTC')::date;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("purge poll creations: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// InsertExemption exempts the IP or the API key hash of the exemption from
+// the daily limit. ErrAlreadyExempt is returned if it is exempt already.
+func (m PollCreationModel) InsertExemption(exemption *Exemption) error {
+	query := `
+		INSERT INTO creation_exemptions (ip, key_hash, reason)
+		VALUES (NULLIF($1, ''), $2, $3)
+		RETURNING id, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if exemption.IP != "" {
+		exemption.Kind = BanIP
+		exemption.IP = NormalizeIP(exemption.IP)
+	} else {
+		exemption.Kind = BanAPIKey
+	}
+
+	err := m.DB.QueryRow(ctx, query, exemption.IP, exemption.KeyHash, exemption.Reason).Scan(
+		&exemption.ID,
+		&exemption.CreatedAt,
+	)
+	if err != nil {
+		if uniqueViolation(err, "creation_exemptions_ip_key") || uniqueViolation(err, "creation_exemptions_key_hash_key") {
+			return ErrAlreadyExempt
+		}
+		return fmt.Errorf("insert exemption: %w", err)
+	}
+
+	return nil
+}
+
+// GetExemptions lists the exemptions, newest first.
+func (m PollCreationModel) GetExemptions() ([]*Exemption, error) {
+	query := `
+		SELECT id, COALESCE(ip, ''), reason, created_at
+		FROM creation_exemptions
+		ORDER BY id DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get exemptions: %w", err)
+	}
+	defer rows.Close()
+
+	exemptions := []*Exemption{}
+	for rows.Next() {
+		var exemption Exemption
+		err := rows.Scan(&exemption.ID, &exemption.IP, &exemption.Reason, &exemption.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get exemptions - scan: %w", err)
+		}
+		exemption.Kind = BanIP
+		if exemption.IP == "" {
+			exemption.Kind = BanAPIKey
+		}
+		exemptions = append(exemptions, &exemption)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get exemptions: %w", err)
+	}
+
+	return exemptions, nil
+}
+
+// DeleteExemption subjects the exempt IP or API key to the daily limit again.
+func (m PollCreationModel) DeleteExemption(id int64) error {
+	query := `
+		DELETE FROM creation_exemptions
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete exemption: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -2,150 +2,454 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PollOption struct {
-	ID    string `json:"id"`
-	Value string `json:"value"`
+	ID    uuid.UUID `json:"id"`
+	Value string    `json:"value"`
 	// Position of option in the list, starting at 0
-	Position  int `json:"position"`
-	VoteCount int `json:"-"`
+	Position int `json:"position"`
+	// Description and URL tell voters more about the option, e.g. a
+	// candidate's bio or a product page
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	// GroupID is the ID of the group the option is listed under, if any
+	GroupID   *uuid.UUID `json:"group_id,omitempty"`
+	VoteCount int        `json:"-"`
+	// Percent of the poll's votes the option has, rounded to two decimals
+	// by the database
+	Percent float64 `json:"-"`
+	// resultShown writes the vote count and percent with the option, see
+	// Poll.ShowResults
+	resultShown bool
+}
+
+// MarshalJSON writes the option like the default encoding, with its vote
+// count and percent if they are shown.
+func (o PollOption) MarshalJSON() ([]byte, error) {
+	type option PollOption
+	if !o.resultShown {
+		return json.Marshal(option(o))
+	}
+	return json.Marshal(struct {
+		option
+		VoteCount int     `json:"vote_count"`
+		Percent   float64 `json:"percent"`
+	}{option(o), o.VoteCount, o.Percent})
+}
+
+// optionPercent is the percentage of its poll's votes the option po has,
+// rounded to two decimals like results.Round, and 0 if the poll has no votes.
+const optionPercent = `COALESCE(ROUND(po.vote_count * 100.0 / NULLIF((
+			SELECT SUM(o.vote_count) FROM poll_options o WHERE o.poll_id = po.poll_id
+		), 0), 2), 0)::float8`
+
+// unmarshalOptions reads options aggregated as JSON by queries, with their
+// vote counts and percents, which aren't part of options' JSON.
+func unmarshalOptions(js string) ([]*PollOption, error) {
+	var rows []struct {
+		PollOption
+		VoteCount int     `json:"vote_count"`
+		Percent   float64 `json:"percent"`
+	}
+	if err := json.Unmarshal([]byte(js), &rows); err != nil {
+		return nil, err
+	}
+
+	options := make([]*PollOption, len(rows))
+	for i := range rows {
+		rows[i].PollOption.VoteCount = rows[i].VoteCount
+		rows[i].PollOption.Percent = rows[i].Percent
+		options[i] = &rows[i].PollOption
+	}
+	return options, nil
 }
 
 type PollOptionModel struct {
 	DB *pgxpool.Pool
 }
 
-func (p PollOptionModel) Insert(option *PollOption, pollID string) error {
+func (p PollOptionModel) Insert(option *PollOption, pollID uuid.UUID) error {
 	query := `
-		INSERT INTO poll_options (poll_id, value, position, vote_count)
-		VALUES ($1, $2, $3, $4);		
+		INSERT INTO poll_options (poll_id, value, position, vote_count, description, url, group_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;		
 	`
 
-	args := []any{pollID, option.Value, option.Position, option.VoteCount}
+	args := []any{
+		pollID, option.Value, option.Position, option.VoteCount, option.Description, option.URL,
+		option.GroupID,
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
-	_, err := p.DB.Exec(ctx, query, args...)
+	err := p.DB.QueryRow(ctx, query, args...).Scan(&option.ID)
 	if err != nil {
+		if uniqueViolation(err, "poll_options_poll_id_value_key") {
+			return ErrDuplicateOption
+		}
+		if checkViolation(err, "poll_options_max_options") {
+			return ErrTooManyOptions
+		}
 		return fmt.Errorf("insert poll option: %w", err)
 	}
 
 	return p.setUpdatedAt(pollID)
 }
 
-func (p PollOptionModel) UpdateValue(option *PollOption) error {
+// UpdateValue changes the option's value, description, URL and group. Options are scoped by poll, so it
+// fails with ErrOptionNotInPoll for an option of another poll.
+func (p PollOptionModel) UpdateValue(pollID uuid.UUID, option *PollOption) error {
 	query := `
 		UPDATE poll_options 
-		SET value = $1
-		WHERE id = $2
-		RETURNING poll_id;	
+		SET value = $1, description = $4, url = $5, group_id = $6
+		WHERE id = $2 AND poll_id = $3;	
 	`
 
-	var pollID string
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
-	err := p.DB.QueryRow(
-		ctx, query, option.Value, option.ID,
-	).Scan(&pollID)
+	result, err := p.DB.Exec(
+		ctx, query, option.Value, option.ID, pollID, option.Description, option.URL, option.GroupID,
+	)
 	if err != nil {
+		if uniqueViolation(err, "poll_options_poll_id_value_key") {
+			return ErrDuplicateOption
+		}
 		return fmt.Errorf("update poll option: %w", err)
 	}
 
+	if result.RowsAffected() == 0 {
+		return ErrOptionNotInPoll
+	}
+
 	return p.setUpdatedAt(pollID)
 }
 
-func (p PollOptionModel) UpdatePosition(options []*PollOption) error {
+// UpdatePosition moves the poll's options to their positions, all or none of
+// them. It fails with ErrOptionNotInPoll if any option is of another poll.
+func (p PollOptionModel) UpdatePosition(pollID uuid.UUID, options []*PollOption) error {
 	query := `
 		UPDATE poll_options 
 		SET position = $1
-		WHERE id = $2
-		RETURNING poll_id;	
+		WHERE id = $2 AND poll_id = $3;	
 	`
 
-	var pollID string
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update option position: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	for _, option := range options {
-		ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-		defer cancel()
-		err := p.DB.QueryRow(
-			ctx, query, option.Position, option.ID,
-		).Scan(&pollID)
+		result, err := tx.Exec(ctx, query, option.Position, option.ID, pollID)
 		if err != nil {
 			return fmt.Errorf("update option position: %w", err)
 		}
+		if result.RowsAffected() == 0 {
+			return ErrOptionNotInPoll
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("update option position: %w", err)
 	}
 
 	return p.setUpdatedAt(pollID)
 }
 
-func (p PollOptionModel) Delete(optionID string) error {
-	if optionID == "" {
-		return ErrRecordNotFound
+// Delete removes the option, keeping it with its votes so it can be
+// restored. It fails with ErrOptionNotInPoll for an option of another poll.
+func (p PollOptionModel) Delete(pollID uuid.UUID, optionID uuid.UUID) error {
+	if optionID == uuid.Nil {
+		return ErrOptionNotInPoll
 	}
 
 	query := `
-		DELETE FROM poll_options
+		WITH deleted AS (
+			DELETE FROM poll_options
+			WHERE id = $1 AND poll_id = $2
+			RETURNING *
+		)` + archiveDeletedOption + `;`
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, optionID, pollID)
+	if err != nil {
+		return fmt.Errorf("delete option: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrOptionNotInPoll
+	}
+
+	return p.setUpdatedAt(pollID)
+}
+
+// Merge moves the votes of the option into the other option and deletes it,
+// closing the gap it leaves in the positions. It returns how many counted
+// votes were moved, and fails with ErrOptionNotInPoll if either option isn't
+// the poll's.
+func (p PollOptionModel) Merge(pollID uuid.UUID, optionID uuid.UUID, intoID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("merge options: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the poll is locked like when voting, so no vote is cast for the merged
+	// option while its votes are moved
+	queryPoll := `
+		SELECT id
+		FROM polls
 		WHERE id = $1
-		RETURNING poll_id;	
+		FOR UPDATE;
+	`
+	if err := tx.QueryRow(ctx, queryPoll, pollID).Scan(new(string)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, fmt.Errorf("merge options - lock poll: %w", err)
+	}
+
+	// deleting the option would delete its votes too, so they are moved
+	// first, and everything is rolled back if either option isn't found
+	queryVotes := `
+		UPDATE votes
+		SET option_id = $1
+		WHERE option_id = $2 AND poll_id = $3;
+	`
+	if _, err := tx.Exec(ctx, queryVotes, intoID, optionID, pollID); err != nil {
+		return 0, fmt.Errorf("merge options - move votes: %w", err)
+	}
+
+	queryDelete := `
+		DELETE FROM poll_options
+		WHERE id = $1 AND poll_id = $2 AND id <> $3
+		RETURNING vote_count, position;
+	`
+
+	var voteCount, position int
+	err = tx.QueryRow(ctx, queryDelete, optionID, pollID, intoID).Scan(&voteCount, &position)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrOptionNotInPoll
+		}
+		return 0, fmt.Errorf("merge options - delete option: %w", err)
+	}
+
+	queryCount := `
+		UPDATE poll_options
+		SET vote_count = vote_count + $1
+		WHERE id = $2 AND poll_id = $3;
 	`
+	result, err := tx.Exec(ctx, queryCount, voteCount, intoID, pollID)
+	if err != nil {
+		return 0, fmt.Errorf("merge options - count votes: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return 0, ErrOptionNotInPoll
+	}
+
+	queryPositions := `
+		UPDATE poll_options
+		SET position = position - 1
+		WHERE poll_id = $1 AND position > $2;
+	`
+	if _, err := tx.Exec(ctx, queryPositions, pollID, position); err != nil {
+		return 0, fmt.Errorf("merge options - update positions: %w", err)
+	}
+
+	queryUpdatedAt := `
+		UPDATE polls
+		SET updated_at = NOW()
+		WHERE id = $1;
+	`
+	if _, err := tx.Exec(ctx, queryUpdatedAt, pollID); err != nil {
+		return 0, fmt.Errorf("merge options - set updated_at: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("merge options: %w", err)
+	}
+
+	return voteCount, nil
+}
+
+// Vote records the vote and counts it for its option once accepted. Every
+// vote counts toward the poll's max_votes, and the vote reaching it closes
+// the poll by moving its deadline to now. Votes beyond the cap fail with
+// ErrVoteQuotaReached, however many are made concurrently, and votes made
+// as the poll expires with ErrPollExpired or ErrPollClosed.
+func (p PollOptionModel) Vote(vote *Vote) error {
+	if vote.Status == "" {
+		vote.Status = VoteStatusAccepted
+	}
+
+	// a vote aborted by a deadlock or serialization failure never happened,
+	// so it can be tried again
+	var err error
+	for i := 1; i <= 3; i++ {
+		err = p.vote(vote)
+		if !transientError(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// vote records the vote in one transaction, so the counts always match the
+// recorded votes.
+func (p PollOptionModel) vote(vote *Vote) error {
+	// suspect votes are recorded but only counted once accepted
+	increment := 0
+	if vote.Status == VoteStatusAccepted {
+		increment = 1
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	var pollID string
-	err := p.DB.QueryRow(ctx, query, optionID).Scan(&pollID)
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("vote option: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the poll is locked first, like when it's deleted, so concurrent votes
+	// on it wait for each other and see every vote before them
+	queryPoll := `
+		SELECT votes_cast, max_votes, closed_at IS NOT NULL,
+		expires_at > '0001-01-02' AND expires_at <= NOW()
+		FROM polls
+		WHERE id = $1
+		FOR UPDATE;
+	`
+
+	var votesCast, maxVotes int
+	var closed, expired bool
+	err = tx.QueryRow(ctx, queryPoll, vote.PollID).Scan(&votesCast, &maxVotes, &closed, &expired)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrRecordNotFound
 		}
-		return fmt.Errorf("delete option: %w", err)
+		return fmt.Errorf("vote option - lock poll: %w", err)
 	}
 
-	return p.setUpdatedAt(pollID)
-}
+	// a poll closed by its cap has also expired, so the cap is checked first
+	switch {
+	case maxVotes > 0 && votesCast >= maxVotes:
+		return ErrVoteQuotaReached
+	case closed:
+		return ErrPollClosed
+	case expired:
+		return ErrPollExpired
+	}
+
+	queryCount := `
+		UPDATE polls
+		SET votes_cast = votes_cast + 1,
+		expires_at = CASE WHEN votes_cast + 1 = max_votes THEN NOW() ELSE expires_at END
+		WHERE id = $1;
+	`
+	if _, err := tx.Exec(ctx, queryCount, vote.PollID); err != nil {
+		return fmt.Errorf("vote option - count vote: %w", err)
+	}
 
-func (p PollOptionModel) Vote(optionID string, pollID string, ip string) error {
 	query := `
 		UPDATE poll_options 
-		SET vote_count = vote_count + 1
+		SET vote_count = vote_count + $3
 		WHERE id = $1 AND poll_id = $2;
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-
-	result, err := p.DB.Exec(ctx, query, optionID, pollID)
+	result, err := tx.Exec(ctx, query, vote.OptionID, vote.PollID, increment)
 	if err != nil {
 		return fmt.Errorf("vote option: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return ErrRecordNotFound
+		return ErrOptionNotInPoll
 	}
 
-	var paramIP pgtype.Inet
-	err = paramIP.Set(ip)
-	if err != nil {
-		return fmt.Errorf("vote option - set ip: %w", err)
+	// votes from integrations are identified by voter identity instead of IP
+	paramIP := pgtype.Inet{Status: pgtype.Null}
+	if vote.IP != "" {
+		err = paramIP.Set(vote.IP)
+		if err != nil {
+			return fmt.Errorf("vote option - set ip: %w", err)
+		}
+		queryIP := `
+			INSERT INTO ips (ip, poll_id)
+			VALUES ($1, $2); 		
+		`
+		_, err = tx.Exec(ctx, queryIP, paramIP, vote.PollID)
+		if err != nil {
+			return fmt.Errorf("vote option - insert ip: %w", err)
+		}
 	}
-	queryIP := `
-		INSERT INTO ips (ip, poll_id)
-		VALUES ($1, $2); 		
+
+	queryVote := `
+		INSERT INTO votes (poll_id, option_id, voter_name, ip, user_agent, score, status, voter_identity,
+		demographics, source, variant, ballot)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (poll_id, voter_identity) WHERE voter_identity <> '' DO NOTHING
+		RETURNING id, created_at;
 	`
-	_, err = p.DB.Exec(ctx, queryIP, paramIP, pollID)
+	args := []any{
+		vote.PollID,
+		vote.OptionID,
+		vote.VoterName,
+		paramIP,
+		vote.UserAgent,
+		vote.Score,
+		vote.Status,
+		vote.VoterIdentity,
+		answersOrEmpty(vote.Demographics),
+		vote.Source,
+		vote.Variant,
+		vote.Ballot,
+	}
+	// the unique index stops a voter identity from voting twice, even when
+	// both votes are made at once
+	err = tx.QueryRow(ctx, queryVote, args...).Scan(&vote.ID, &vote.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("vote option - insert ip: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAlreadyVoted
+		}
+		return fmt.Errorf("vote option - insert vote: %w", err)
 	}
 
-	return nil
+	err = insertActivity(ctx, tx, &Activity{
+		PollID:  vote.PollID,
+		Action:  ActionVoteCast,
+		Actor:   ActorVoter,
+		Details: map[string]any{"option_id": vote.OptionID, "status": vote.Status},
+	})
+	if err != nil {
+		return fmt.Errorf("vote option - %w", err)
+	}
+
+	if err := recordVoteUsage(ctx, tx, vote.PollID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (p PollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
+func (p PollOptionModel) GetResults(pollID uuid.UUID) ([]*PollOption, error) {
 	query := `
 		SELECT id, value, position, vote_count
 		FROM poll_options
@@ -181,7 +485,7 @@ func (p PollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
 	return options, nil
 }
 
-func (p PollOptionModel) setUpdatedAt(pollID string) error {
+func (p PollOptionModel) setUpdatedAt(pollID uuid.UUID) error {
 	query := `
 		UPDATE polls
 		SET updated_at = NOW()
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"slices"
+	"time"
+)
+
+// PollStatus is where a poll is in its lifecycle. Drafts become active when
+// they are published, active polls can be paused and resumed, and polls end
+// when they reach their vote cap, which closes them, or their expiry passes.
+// Ended polls don't change anymore.
+type PollStatus string
+
+const (
+	StatusDraft   PollStatus = "draft"
+	StatusActive  PollStatus = "active"
+	StatusPaused  PollStatus = "paused"
+	StatusClosed  PollStatus = "closed"
+	StatusExpired PollStatus = "expired"
+)
+
+// pollTransitions are the statuses polls can change to from each status.
+// Paused polls can't reach their vote cap, as they take no votes, but can
+// expire.
+var pollTransitions = map[PollStatus][]PollStatus{
+	StatusDraft:  {StatusActive},
+	StatusActive: {StatusPaused, StatusClosed, StatusExpired},
+	StatusPaused: {StatusActive, StatusExpired},
+}
+
+// CanTransition reports whether a poll in the status can change to the other
+// status. Polls can stay in their status, e.g. to pause a paused poll with
+// another reason, unless they have ended.
+func (s PollStatus) CanTransition(to PollStatus) bool {
+	if s == to {
+		return !s.Ended()
+	}
+	return slices.Contains(pollTransitions[s], to)
+}
+
+// Ended reports whether polls in the status have closed or expired.
+func (s PollStatus) Ended() bool {
+	return s == StatusClosed || s == StatusExpired
+}
+
+// Votable reports whether polls in the status take votes, which only active
+// polls do.
+func (s PollStatus) Votable() bool {
+	return s == StatusActive
+}
+
+// Status returns where the poll is in its lifecycle at the given time. Drafts
+// stay drafts whatever their settings, as they can't be voted on.
+func (p *Poll) Status(now time.Time) PollStatus {
+	switch {
+	case p.IsDraft:
+		return StatusDraft
+	case p.VoteQuotaReached():
+		return StatusClosed
+	case p.Expired(now):
+		return StatusExpired
+	case p.PausedAt != nil:
+		return StatusPaused
+	}
+	return StatusActive
+}
+
+// Expired reports whether the poll's expiry has passed at the given time.
+// Polls without an expiry never expire. Unlike Status, drafts can expire,
+// which keeps them from being published or edited.
+func (p *Poll) Expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(now)
+}
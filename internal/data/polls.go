@@ -2,40 +2,258 @@ package data
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
 	"net"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/search"
+	"github.com/ivcp/polls/internal/validator"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Poll struct {
-	ID                string        `json:"id"`
-	Question          string        `json:"question"`
-	Description       string        `json:"description"`
-	Options           []*PollOption `json:"options"`
-	CreatedAt         time.Time     `json:"created_at"`
-	UpdatedAt         time.Time     `json:"updated_at"`
-	ExpiresAt         ExpiresAt     `json:"expires_at"`
-	ResultsVisibility string        `json:"results_visibility"`
-	IsPrivate         bool          `json:"is_private"`
-	Token             string        `json:"token,omitempty"`
+	ID                uuid.UUID             `json:"id"`
+	Question          string                `json:"question"`
+	QuestionVariants  []string              `json:"question_variants,omitempty"`
+	Variant           *int                  `json:"variant,omitempty"`
+	Description       string                `json:"description"`
+	Options           []*PollOption         `json:"options"`
+	Groups            []*OptionGroup        `json:"groups,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at"`
+	ExpiresAt         ExpiresAt             `json:"expires_at"`
+	ResultsVisibility string                `json:"results_visibility"`
+	ResultsThreshold  int                   `json:"results_threshold"`
+	MaxVotes          int                   `json:"max_votes"`
+	VotesCast         int                   `json:"-"`
+	TieBreak          string                `json:"tie_break"`
+	Visibility        string                `json:"visibility"`
+	ShuffleOptions    bool                  `json:"shuffle_options"`
+	Verifiable        bool                  `json:"verifiable"`
+	ConfirmVotes      bool                  `json:"confirm_votes"`
+	IsDraft           bool                  `json:"is_draft"`
+	Anonymity         string                `json:"anonymity"`
+	AllowedCountries  []string              `json:"allowed_countries"`
+	DeniedCountries   []string              `json:"denied_countries"`
+	Tags              []string              `json:"tags,omitempty"`
+	Language          string                `json:"language,omitempty"`
+	Metadata          map[string]string     `json:"metadata,omitempty"`
+	ExternalID        string                `json:"external_id,omitempty"`
+	NotifyEmail       string                `json:"-"`
+	CreatedBy         string                `json:"-"`
+	ActiveQuota       int                   `json:"-"`
+	OrgID             *uuid.UUID            `json:"org_id,omitempty"`
+	SeriesID          *uuid.UUID            `json:"series_id,omitempty"`
+	Demographics      []DemographicQuestion `json:"demographics,omitempty"`
+	PausedAt          *time.Time            `json:"paused_at,omitempty"`
+	PauseReason       string                `json:"pause_reason,omitempty"`
+	Version           int                   `json:"-"`
+	ModerationStatus  string                `json:"moderation_status,omitempty"`
+	ModerationTerms   []string              `json:"moderation_terms,omitempty"`
+	RemovedAt         *time.Time            `json:"-"`
+	Token             string                `json:"token,omitempty"`
+	// resultsShown writes the total votes with the poll, see ShowResults
+	resultsShown bool
+}
+
+// Visibilities of polls. Public polls are listed and searchable, unlisted
+// polls can be seen and voted on by anyone with the link, and private polls
+// only with a token for the poll.
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
+// Moderation statuses of polls. Polls that were never flagged or reported
+// have none.
+const (
+	ModerationFlagged  = "flagged"
+	ModerationReported = "reported"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// InTimeZone converts the poll's times to the location for responses.
+func (p *Poll) InTimeZone(loc *time.Location) {
+	p.CreatedAt = p.CreatedAt.In(loc)
+	p.UpdatedAt = p.UpdatedAt.In(loc)
+	if !p.ExpiresAt.IsZero() {
+		p.ExpiresAt.Time = p.ExpiresAt.In(loc)
+	}
+	if p.PausedAt != nil {
+		pausedAt := p.PausedAt.In(loc)
+		p.PausedAt = &pausedAt
+	}
+}
+
+// ShuffleFor puts the poll's options in a random order for the voter, to
+// reduce the bias towards the first options. A voter always gets the same
+// order, which is different on each poll.
+func (p *Poll) ShuffleFor(voter string) {
+	slices.SortFunc(p.Options, func(a, b *PollOption) int { return a.Position - b.Position })
+
+	h := fnv.New64a()
+	h.Write([]byte(p.ID.String()))
+	h.Write([]byte(voter))
+	rand.New(rand.NewSource(int64(h.Sum64()))).Shuffle(len(p.Options), func(i, j int) {
+		p.Options[i], p.Options[j] = p.Options[j], p.Options[i]
+	})
+}
+
+// VariantFor picks the wording of the question the voter is shown at random,
+// 0 for the question and 1 on for its variants. A voter is always shown the
+// same wording.
+func (p *Poll) VariantFor(voter string) int {
+	if len(p.QuestionVariants) == 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte("variant:" + p.ID.String()))
+	h.Write([]byte(voter))
+	return int(h.Sum64() % uint64(len(p.QuestionVariants)+1))
+}
+
+// ShowVariant words the poll's question like the variant, and hides the
+// other variants.
+func (p *Poll) ShowVariant(variant int) {
+	if variant > 0 && variant <= len(p.QuestionVariants) {
+		p.Question = p.QuestionVariants[variant-1]
+	}
+	p.QuestionVariants = nil
+	p.Variant = &variant
+}
+
+// Hidden reports whether the poll is hidden as if it didn't exist, which
+// drafts, polls reported until they are reviewed, polls rejected by
+// moderation and polls taken down are.
+func (p *Poll) Hidden() bool {
+	return p.IsDraft || p.ModerationStatus == ModerationReported || p.ModerationStatus == ModerationRejected ||
+		p.RemovedAt != nil
+}
+
+// VoteQuotaReached reports whether the poll has as many votes as its cap
+// allows.
+func (p *Poll) VoteQuotaReached() bool {
+	return p.MaxVotes > 0 && p.VotesCast >= p.MaxVotes
+}
+
+// CountryAllowed reports whether voters from the given country may vote.
+// An unknown country (empty code) only passes when no allow list is set.
+func (p *Poll) CountryAllowed(country string) bool {
+	if len(p.AllowedCountries) > 0 {
+		return validator.PermittedValue(country, p.AllowedCountries...)
+	}
+	return !validator.PermittedValue(country, p.DeniedCountries...)
+}
+
+// GeoRestricted reports whether the poll limits voting by country.
+func (p *Poll) GeoRestricted() bool {
+	return len(p.AllowedCountries) > 0 || len(p.DeniedCountries) > 0
+}
+
+// Slug returns the poll's question in lowercase, with anything but letters
+// and digits between them replaced by dashes, e.g. "favourite-colour" for
+// "Favourite colour?". Polls whose question has no letters or digits have
+// their ID as their slug.
+func (p *Poll) Slug() string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(p.Question) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+		dash = false
+	}
+
+	if b.Len() == 0 {
+		return p.ID.String()
+	}
+	return b.String()
+}
+
+// ShowResults shows the poll's total votes, and its options with their vote
+// counts and percents, when it is written as JSON. Results are left out by
+// default, as whether they may be shown depends on the poll's results
+// visibility and who asks.
+func (p *Poll) ShowResults() {
+	p.resultsShown = true
+	for _, option := range p.Options {
+		option.resultShown = true
+	}
+}
+
+// TotalVotes returns the sum of the vote counts of the poll's options.
+func (p *Poll) TotalVotes() int {
+	total := 0
+	for _, option := range p.Options {
+		total += option.VoteCount
+	}
+	return total
+}
+
+// MarshalJSON writes the poll like the default encoding, with its status,
+// the seconds until it expires and whether it takes votes at the time of the
+// response, so clients don't have to work them out from its settings.
+func (p Poll) MarshalJSON() ([]byte, error) {
+	type poll Poll
+	now := time.Now()
+
+	// polls without an expiry have no countdown
+	var secondsUntilExpiry *int
+	if !p.ExpiresAt.IsZero() {
+		seconds := max(int(math.Ceil(p.ExpiresAt.Sub(now).Seconds())), 0)
+		secondsUntilExpiry = &seconds
+	}
+
+	var totalVotes *int
+	if p.resultsShown {
+		total := p.TotalVotes()
+		totalVotes = &total
+	}
+
+	status := p.Status(now)
+	return json.Marshal(struct {
+		poll
+		TotalVotes         *int       `json:"total_votes,omitempty"`
+		Status             PollStatus `json:"status"`
+		SecondsUntilExpiry *int       `json:"seconds_until_expiry"`
+		IsVotable          bool       `json:"is_votable"`
+	}{poll(p), totalVotes, status, secondsUntilExpiry, status.Votable()})
 }
 
 type PollModel struct {
 	DB *pgxpool.Pool
 }
 
+// Insert adds the poll with its options and token. If the poll has an
+// ActiveQuota, the number of active polls its organization may have, it is
+// refused with ErrQuotaExceeded once the organization has as many.
 func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
 	query := `
-		INSERT INTO polls (question, description, expires_at, results_visibility, is_private)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO polls (question, description, expires_at, results_visibility, visibility, anonymity,
+		allowed_countries, denied_countries, notify_email, org_id, results_threshold, tie_break, series_id,
+		demographics, is_draft, max_votes, moderation_status, moderation_terms, tags, language, metadata,
+		external_id, shuffle_options, question_variants, verifiable, confirm_votes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+		$22, $23, $24, $25, $26, $27)
 		RETURNING id, created_at, updated_at;				
 		`
 
@@ -44,24 +262,63 @@ func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
 		poll.Description,
 		poll.ExpiresAt.Time,
 		poll.ResultsVisibility,
-		poll.IsPrivate,
+		visibilityOrPublic(poll.Visibility),
+		poll.Anonymity,
+		countriesOrEmpty(poll.AllowedCountries),
+		countriesOrEmpty(poll.DeniedCountries),
+		poll.NotifyEmail,
+		poll.OrgID,
+		poll.ResultsThreshold,
+		poll.TieBreak,
+		poll.SeriesID,
+		demographicsOrEmpty(poll.Demographics),
+		poll.IsDraft,
+		poll.MaxVotes,
+		poll.ModerationStatus,
+		termsOrEmpty(poll.ModerationTerms),
+		tagsOrEmpty(poll.Tags),
+		poll.Language,
+		metadataOrEmpty(poll.Metadata),
+		nullIfEmpty(poll.ExternalID),
+		poll.ShuffleOptions,
+		variantsOrEmpty(poll.QuestionVariants),
+		poll.Verifiable,
+		poll.ConfirmVotes,
+		nullIfEmpty(poll.CreatedBy),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	err := p.DB.QueryRow(
-		ctx, query, args...,
-	).Scan(&poll.ID, &poll.CreatedAt, &poll.UpdatedAt)
+	var err error
+	if poll.OrgID != nil && poll.ActiveQuota > 0 {
+		err = p.insertWithinQuota(ctx, poll, query, args)
+	} else {
+		err = p.DB.QueryRow(
+			ctx, query, args...,
+		).Scan(&poll.ID, &poll.CreatedAt, &poll.UpdatedAt)
+	}
 	if err != nil {
+		switch {
+		case errors.Is(err, ErrQuotaExceeded):
+			return err
+		case uniqueViolation(err, "polls_org_id_external_id_idx"):
+			return ErrDuplicateExtID
+		case checkViolation(err, "polls_daily_limit"):
+			return ErrCreationLimited
+		}
+		return fmt.Errorf("insert poll: %w", err)
+	}
+
+	if err := insertOptionGroups(ctx, p.DB, poll); err != nil {
 		return fmt.Errorf("insert poll: %w", err)
 	}
 
 	var queryOptionsString strings.Builder
 	queryOptionsString.WriteString(
-		"INSERT INTO poll_options (value, poll_id, position, vote_count) VALUES ",
+		"INSERT INTO poll_options (value, poll_id, position, vote_count, description, url, group_id) VALUES ",
 	)
-	values := make([]any, 0, len(poll.Options)*4)
+	values := make([]any, 0, len(poll.Options)*7)
 	count := 1
 
 	for i, opt := range poll.Options {
@@ -71,11 +328,14 @@ func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
 			comma = ""
 		}
 		str = fmt.Sprintf(
-			"($%d, $%d, $%d, $%d)%s ", count, count+1, count+2, count+3, comma,
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d)%s ",
+			count, count+1, count+2, count+3, count+4, count+5, count+6, comma,
 		)
 		queryOptionsString.WriteString(str)
-		values = append(values, opt.Value, poll.ID, opt.Position, opt.VoteCount)
-		count += 4
+		values = append(
+			values, opt.Value, poll.ID, opt.Position, opt.VoteCount, opt.Description, opt.URL, opt.GroupID,
+		)
+		count += 7
 	}
 	queryOptionsString.WriteString(" RETURNING id;")
 
@@ -109,21 +369,84 @@ func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
 	defer cancel()
 
 	_, err = p.DB.Exec(ctx, queryToken, tokenHash, poll.ID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// the first poll of a series joins it once a second poll is added
+	if poll.SeriesID != nil {
+		querySeries := `
+			UPDATE polls SET series_id = $1
+			WHERE id = $1 AND series_id IS NULL;
+		`
+		if _, err := p.DB.Exec(ctx, querySeries, poll.SeriesID); err != nil {
+			return fmt.Errorf("insert poll - start series: %w", err)
+		}
+	}
+
+	if poll.OrgID != nil {
+		return recordPollUsage(ctx, p.DB, *poll.OrgID)
+	}
+
+	return nil
 }
 
-func (p PollModel) Get(id string) (*Poll, error) {
-	if id == "" {
+// insertWithinQuota runs the query inserting the poll if its organization has
+// fewer active polls than the poll's ActiveQuota. The organization's polls are
+// counted and inserted under an advisory lock on it, so polls created at the
+// same time are counted in turn.
+func (p PollModel) insertWithinQuota(ctx context.Context, poll *Poll, query string, args []any) error {
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0));`, poll.OrgID)
+	if err != nil {
+		return fmt.Errorf("lock organization: %w", err)
+	}
+
+	var active int
+	if err := tx.QueryRow(ctx, activePollsQuery, poll.OrgID).Scan(&active); err != nil {
+		return fmt.Errorf("count active polls: %w", err)
+	}
+	if active >= poll.ActiveQuota {
+		return ErrQuotaExceeded
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&poll.ID, &poll.CreatedAt, &poll.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// pollWithOptionColumns are the columns of a poll p, its takedown t and one
+// of its options po that Get and GetByIDs read a poll with its options from.
+const pollWithOptionColumns = `
+	p.id, p. question, p.description, p.created_at, 
+	p.updated_at, p.expires_at, p.results_visibility, p.visibility,
+	p.anonymity, p.allowed_countries, p.denied_countries, p.org_id,
+	p.results_threshold, p.tie_break, p.series_id, p.shuffle_options,
+	p.verifiable, p.confirm_votes, p.question_variants,
+	p.demographics, p.paused_at, p.pause_reason, p.is_draft, p.max_votes, p.votes_cast,
+	p.version, p.moderation_status, p.moderation_terms, p.tags, p.language, p.metadata,
+	COALESCE(p.external_id, ''), t.created_at, ` + optionGroups + `,
+	po.id, po.value, po.position, po.description, po.url, po.group_id, po.vote_count,
+	` + optionPercent
+
+func (p PollModel) Get(id uuid.UUID) (*Poll, error) {
+	if id == uuid.Nil {
 		return nil, ErrRecordNotFound
 	}
 
 	query := `
-		SELECT p.id, p. question, p.description, p.created_at, 
-		p.updated_at, p.expires_at, p.results_visibility, p.is_private,
-		po.id, po.value, po.position
+		SELECT ` + pollWithOptionColumns + `
 		FROM polls p
 		JOIN poll_options po ON po.poll_id = p.id 
+		LEFT JOIN takedowns t ON t.poll_id = p.id
 		WHERE p.id = $1;
 	`
 
@@ -138,6 +461,7 @@ func (p PollModel) Get(id string) (*Poll, error) {
 
 	var poll Poll
 	var options []*PollOption
+	var groupsJson string
 
 	first := true
 	for rows.Next() {
@@ -154,13 +478,68 @@ func (p PollModel) Get(id string) (*Poll, error) {
 				&poll.UpdatedAt,
 				&poll.ExpiresAt.Time,
 				&poll.ResultsVisibility,
-				&poll.IsPrivate,
+				&poll.Visibility,
+				&poll.Anonymity,
+				&poll.AllowedCountries,
+				&poll.DeniedCountries,
+				&poll.OrgID,
+				&poll.ResultsThreshold,
+				&poll.TieBreak,
+				&poll.SeriesID,
+				&poll.ShuffleOptions,
+				&poll.Verifiable,
+				&poll.ConfirmVotes,
+				&poll.QuestionVariants,
+				&poll.Demographics,
+				&poll.PausedAt,
+				&poll.PauseReason,
+				&poll.IsDraft,
+				&poll.MaxVotes,
+				&poll.VotesCast,
+				&poll.Version,
+				&poll.ModerationStatus,
+				&poll.ModerationTerms,
+				&poll.Tags,
+				&poll.Language,
+				&poll.Metadata,
+				&poll.ExternalID,
+				&poll.RemovedAt,
+				&groupsJson,
 				&option.ID,
 				&option.Value,
 				&option.Position,
+				&option.Description,
+				&option.URL,
+				&option.GroupID,
+				&option.VoteCount,
+				&option.Percent,
 			)
 		default:
 			err = rows.Scan(
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
 				nil,
 				nil,
 				nil,
@@ -172,6 +551,11 @@ func (p PollModel) Get(id string) (*Poll, error) {
 				&option.ID,
 				&option.Value,
 				&option.Position,
+				&option.Description,
+				&option.URL,
+				&option.GroupID,
+				&option.VoteCount,
+				&option.Percent,
 			)
 		}
 
@@ -191,18 +575,122 @@ func (p PollModel) Get(id string) (*Poll, error) {
 		return nil, ErrRecordNotFound
 	}
 
+	if err := json.Unmarshal([]byte(groupsJson), &poll.Groups); err != nil {
+		return nil, fmt.Errorf("get poll - unmarshal groups: %w", err)
+	}
 	poll.Options = options
 
 	return &poll, nil
 }
 
+// GetByIDs returns the polls with the IDs with their options in one query,
+// in the order of ids. Polls that don't exist are left out.
+func (p PollModel) GetByIDs(ids []uuid.UUID) ([]*Poll, error) {
+	if len(ids) == 0 {
+		return []*Poll{}, nil
+	}
+
+	query := `
+		SELECT ` + pollWithOptionColumns + `
+		FROM polls p
+		JOIN poll_options po ON po.poll_id = p.id
+		LEFT JOIN takedowns t ON t.poll_id = p.id
+		WHERE p.id = ANY($1::uuid[])
+		ORDER BY array_position($1::uuid[], p.id), po.position;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get polls by ids: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []*Poll{}
+	var poll *Poll
+	for rows.Next() {
+		var row Poll
+		var option PollOption
+		var groupsJson string
+
+		err := rows.Scan(
+			&row.ID,
+			&row.Question,
+			&row.Description,
+			&row.CreatedAt,
+			&row.UpdatedAt,
+			&row.ExpiresAt.Time,
+			&row.ResultsVisibility,
+			&row.Visibility,
+			&row.Anonymity,
+			&row.AllowedCountries,
+			&row.DeniedCountries,
+			&row.OrgID,
+			&row.ResultsThreshold,
+			&row.TieBreak,
+			&row.SeriesID,
+			&row.ShuffleOptions,
+			&row.Verifiable,
+			&row.ConfirmVotes,
+			&row.QuestionVariants,
+			&row.Demographics,
+			&row.PausedAt,
+			&row.PauseReason,
+			&row.IsDraft,
+			&row.MaxVotes,
+			&row.VotesCast,
+			&row.Version,
+			&row.ModerationStatus,
+			&row.ModerationTerms,
+			&row.Tags,
+			&row.Language,
+			&row.Metadata,
+			&row.ExternalID,
+			&row.RemovedAt,
+			&groupsJson,
+			&option.ID,
+			&option.Value,
+			&option.Position,
+			&option.Description,
+			&option.URL,
+			&option.GroupID,
+			&option.VoteCount,
+			&option.Percent,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get polls by ids - scan: %w", err)
+		}
+
+		// a poll's rows are consecutive, one per option
+		if poll == nil || poll.ID != row.ID {
+			if err := json.Unmarshal([]byte(groupsJson), &row.Groups); err != nil {
+				return nil, fmt.Errorf("get polls by ids - unmarshal groups: %w", err)
+			}
+			poll = &row
+			polls = append(polls, poll)
+		}
+		poll.Options = append(poll.Options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get polls by ids: %w", err)
+	}
+
+	return polls, nil
+}
+
+// Update saves the poll's question, description, expiry, tags, language and
+// metadata.
+// It fails with ErrEditConflict if the poll was updated since it was read.
 func (p PollModel) Update(poll *Poll) error {
 	queryPoll := `
 		UPDATE polls
 		SET question = $1, description = $2, 
-		expires_at = $3, updated_at = NOW()
-		WHERE id = $4
-		RETURNING updated_at;
+		expires_at = $3, tags = $6, language = $7, metadata = $8, updated_at = NOW(), version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING updated_at, version;
 	`
 
 	args := []any{
@@ -210,16 +698,28 @@ func (p PollModel) Update(poll *Poll) error {
 		poll.Description,
 		poll.ExpiresAt.Time,
 		poll.ID,
+		poll.Version,
+		tagsOrEmpty(poll.Tags),
+		poll.Language,
+		metadataOrEmpty(poll.Metadata),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	return p.DB.QueryRow(ctx, queryPoll, args...).Scan(&poll.UpdatedAt)
+	err := p.DB.QueryRow(ctx, queryPoll, args...).Scan(&poll.UpdatedAt, &poll.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEditConflict
+		}
+		return fmt.Errorf("update poll: %w", err)
+	}
+
+	return nil
 }
 
-func (p PollModel) Delete(id string) error {
-	if id == "" {
+func (p PollModel) Delete(id uuid.UUID) error {
+	if id == uuid.Nil {
 		return ErrRecordNotFound
 	}
 
@@ -243,26 +743,543 @@ func (p PollModel) Delete(id string) error {
 	return nil
 }
 
-func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, error) {
-	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), p.id, p.question, p.description, 
-		p.created_at, p.updated_at, p.expires_at, p.results_visibility,
-	    jsonb_agg(jsonb_build_object(
-			'id', po.id, 'value', po.value, 'position', po.position
-			)) AS options
+// DeleteExpired deletes polls that expired before the given time and returns
+// how many were deleted.
+func (p PollModel) DeleteExpired(before time.Time) (int64, error) {
+	query := `
+		DELETE FROM polls
+		WHERE expires_at > '0001-01-02' AND expires_at < $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired polls: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// SetNotifyEmail sets the address notified when the poll closes. An empty
+// email turns notifications off.
+func (p PollModel) SetNotifyEmail(id uuid.UUID, email string) error {
+	query := `
+		UPDATE polls
+		SET notify_email = $1
+		WHERE id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, email, id)
+	if err != nil {
+		return fmt.Errorf("set notify email: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Pause stops voting on the poll until it is resumed. Pausing a paused poll
+// only replaces the reason.
+func (p PollModel) Pause(id uuid.UUID, reason string) (time.Time, error) {
+	query := `
+		UPDATE polls
+		SET paused_at = COALESCE(paused_at, NOW()), pause_reason = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING paused_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var pausedAt time.Time
+	err := p.DB.QueryRow(ctx, query, reason, id).Scan(&pausedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return time.Time{}, ErrRecordNotFound
+		default:
+			return time.Time{}, fmt.Errorf("pause poll: %w", err)
+		}
+	}
+
+	return pausedAt, nil
+}
+
+// Resume lets voting on a paused poll continue.
+func (p PollModel) Resume(id uuid.UUID) error {
+	query := `
+		UPDATE polls
+		SET paused_at = NULL, pause_reason = '', updated_at = NOW()
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("resume poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Publish makes a draft poll visible and open for voting.
+func (p PollModel) Publish(id uuid.UUID) error {
+	query := `
+		UPDATE polls
+		SET is_draft = false, updated_at = NOW()
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("publish poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Flag holds the poll for review because of the terms moderation found in
+// it. Flagged polls are left out of the public listing until approved.
+func (p PollModel) Flag(id uuid.UUID, terms []string) error {
+	query := `
+		UPDATE polls
+		SET moderation_status = $1, moderation_terms = $2
+		WHERE id = $3;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, ModerationFlagged, termsOrEmpty(terms), id)
+	if err != nil {
+		return fmt.Errorf("flag poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Moderate approves or rejects a poll.
+func (p PollModel) Moderate(id uuid.UUID, status string) error {
+	query := `
+		UPDATE polls
+		SET moderation_status = $1
+		WHERE id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, status, id)
+	if err != nil {
+		return fmt.Errorf("moderate poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetFlagged lists the polls waiting for review, oldest first.
+func (p PollModel) GetFlagged() ([]*Poll, error) {
+	query := `
+		SELECT p.id, p.question, p.description, p.created_at, p.updated_at,
+		p.visibility, p.org_id, p.moderation_status, p.moderation_terms,
+		p.expires_at, p.is_draft, p.paused_at, p.max_votes, p.votes_cast,
+		jsonb_agg(jsonb_build_object(
+			'id', po.id, 'value', po.value, 'position', po.position,
+			'description', po.description, 'url', po.url, 'group_id', po.group_id,
+			'vote_count', po.vote_count, 'percent', ` + optionPercent + `
+			) ORDER BY po.position) AS options,
+		` + optionGroups + ` AS groups
 		FROM polls p
-		JOIN poll_options po ON po.poll_id = p.id 
-		WHERE (to_tsvector('simple', question) @@ plainto_tsquery('simple', $1) OR $1 = '') 
-		AND p.is_private = false
+		JOIN poll_options po ON po.poll_id = p.id
+		WHERE p.moderation_status = $1
 		GROUP BY p.id
-		ORDER BY %s %s, id ASC
-		LIMIT $2 OFFSET $3;
-	`, filters.sortColumn(), filters.sortDirection())
+		ORDER BY p.created_at, p.id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, ModerationFlagged)
+	if err != nil {
+		return nil, fmt.Errorf("get flagged polls: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []*Poll{}
+
+	for rows.Next() {
+		var poll Poll
+		var optionsJson, groupsJson string
+		err := rows.Scan(
+			&poll.ID,
+			&poll.Question,
+			&poll.Description,
+			&poll.CreatedAt,
+			&poll.UpdatedAt,
+			&poll.Visibility,
+			&poll.OrgID,
+			&poll.ModerationStatus,
+			&poll.ModerationTerms,
+			&poll.ExpiresAt.Time,
+			&poll.IsDraft,
+			&poll.PausedAt,
+			&poll.MaxVotes,
+			&poll.VotesCast,
+			&optionsJson,
+			&groupsJson,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get flagged polls - scan: %w", err)
+		}
+
+		poll.Options, err = unmarshalOptions(optionsJson)
+		if err != nil {
+			return nil, fmt.Errorf("get flagged polls - unmarshal options: %w", err)
+		}
+		if err := json.Unmarshal([]byte(groupsJson), &poll.Groups); err != nil {
+			return nil, fmt.Errorf("get flagged polls - unmarshal groups: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get flagged polls: %w", err)
+	}
+
+	return polls, nil
+}
+
+// CloseExpired marks polls whose expiry has passed as closed and returns
+// them. Each poll is returned once, even with several servers closing polls
+// concurrently.
+func (p PollModel) CloseExpired() ([]*Poll, error) {
+	query := `
+		UPDATE polls
+		SET closed_at = NOW()
+		WHERE id IN (
+			SELECT id FROM polls
+			WHERE closed_at IS NULL AND expires_at > '0001-01-02' AND expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT 100
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, question, expires_at, notify_email;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("close expired polls: %w", err)
+	}
+	defer rows.Close()
+
+	var polls []*Poll
+
+	for rows.Next() {
+		var poll Poll
+		if err := rows.Scan(&poll.ID, &poll.Question, &poll.ExpiresAt.Time, &poll.NotifyEmail); err != nil {
+			return nil, fmt.Errorf("close expired polls - scan: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close expired polls: %w", err)
+	}
+
+	return polls, nil
+}
+
+// GetSeries returns the latest polls of a series of recurring polls, up to
+// limit, oldest first. A series' ID is the ID of its first poll. Hidden and
+// private polls are left out, as the series is shown to anyone. The polls'
+// options aren't included.
+func (p PollModel) GetSeries(seriesID uuid.UUID, limit int) ([]*Poll, error) {
+	query := `
+		SELECT id, question, created_at, expires_at, results_visibility, results_threshold
+		FROM (
+			SELECT * FROM polls p
+			WHERE p.series_id = $1 AND p.is_draft = false AND p.visibility <> 'private'
+			AND p.moderation_status NOT IN ('reported', 'rejected')
+			AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT $2
+		) latest
+		ORDER BY created_at ASC, id ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, seriesID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get series: %w", err)
+	}
+	defer rows.Close()
+
+	var polls []*Poll
+	for rows.Next() {
+		poll := Poll{SeriesID: &seriesID}
+		err := rows.Scan(
+			&poll.ID,
+			&poll.Question,
+			&poll.CreatedAt,
+			&poll.ExpiresAt.Time,
+			&poll.ResultsVisibility,
+			&poll.ResultsThreshold,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get series - scan: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get series: %w", err)
+	}
+
+	return polls, nil
+}
+
+// GetRelated returns up to limit public polls with questions similar to the
+// poll's, most similar first. Questions are compared by their words in the
+// text search configuration of their language, which leaves out common words
+// like "the", and polls sharing tags with the poll rank higher.
+func (p PollModel) GetRelated(pollID uuid.UUID, limit int) ([]*Poll, error) {
+	query := `
+		WITH source AS (
+			SELECT id, tags, to_tsquery('simple', array_to_string(ARRAY(
+				SELECT '''' || replace(replace(lexeme, '\', '\\'), '''', '''''') || ''''
+				FROM unnest(tsvector_to_array(to_tsvector(poll_search_config(language), search_unaccent(question)))) lexeme
+			), ' | ')) AS words
+			FROM polls
+			WHERE id = $1
+		)
+		SELECT p.id, p.question, p.created_at, p.expires_at, p.tags
+		FROM polls p, source s
+		WHERE p.id <> s.id
+		AND (to_tsvector(poll_search_config(p.language), search_unaccent(p.question)) @@ s.words OR p.tags && s.tags)
+		AND p.org_id IS NULL AND p.visibility = 'public' AND p.is_draft = false
+		AND p.moderation_status NOT IN ('flagged', 'reported', 'rejected')
+		AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)
+		ORDER BY ts_rank(to_tsvector(poll_search_config(p.language), search_unaccent(p.question)), s.words)
+			+ 0.1 * cardinality(ARRAY(SELECT unnest(p.tags) INTERSECT SELECT unnest(s.tags))) DESC,
+			p.created_at DESC, p.id
+		LIMIT $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, pollID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get related: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []*Poll{}
+	for rows.Next() {
+		var poll Poll
+		err := rows.Scan(
+			&poll.ID,
+			&poll.Question,
+			&poll.CreatedAt,
+			&poll.ExpiresAt.Time,
+			&poll.Tags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get related - scan: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get related: %w", err)
+	}
+
+	return polls, nil
+}
+
+// GetDeadlines returns the organization's open polls that have an expiry
+// time, soonest first.
+func (p PollModel) GetDeadlines(orgID uuid.UUID, limit int) ([]*Poll, error) {
+	query := `
+		SELECT id, question, description, created_at, updated_at, expires_at, is_draft
+		FROM polls p
+		WHERE org_id = $1 AND closed_at IS NULL AND expires_at > NOW()
+		AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)
+		ORDER BY expires_at ASC, id ASC
+		LIMIT $2;
+	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	rows, err := p.DB.Query(ctx, query, search, filters.limit(), filters.offset())
+	rows, err := p.DB.Query(ctx, query, orgID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get deadlines: %w", err)
+	}
+	defer rows.Close()
+
+	var polls []*Poll
+	for rows.Next() {
+		poll := Poll{OrgID: &orgID}
+		err := rows.Scan(
+			&poll.ID,
+			&poll.Question,
+			&poll.Description,
+			&poll.CreatedAt,
+			&poll.UpdatedAt,
+			&poll.ExpiresAt.Time,
+			&poll.IsDraft,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get deadlines - scan: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get deadlines: %w", err)
+	}
+
+	return polls, nil
+}
+
+// GetIDByExternalID returns the ID of the organization's poll with the
+// external ID. If orgID is nil it returns pollID if that poll has no
+// organization and has the external ID, as external IDs of polls without one
+// are only unique among the polls of each token.
+func (p PollModel) GetIDByExternalID(orgID uuid.UUID, pollID uuid.UUID, externalID string) (uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM polls
+		WHERE id = $1 AND org_id IS NULL AND external_id = $2;
+	`
+	args := []any{pollID, externalID}
+	if orgID != uuid.Nil {
+		query = `
+			SELECT id
+			FROM polls
+			WHERE org_id = $1 AND external_id = $2;
+		`
+		args = []any{orgID, externalID}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var id uuid.UUID
+	err := p.DB.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrRecordNotFound
+		}
+		return uuid.Nil, fmt.Errorf("get poll by external id: %w", err)
+	}
+
+	return id, nil
+}
+
+// listedPolls are the conditions of the public polls listed by GetAll. They
+// are the predicate of the partial polls_listed_* indexes, which are only
+// used by queries with the same conditions.
+const listedPolls = `p.org_id IS NULL AND p.visibility = 'public' AND p.is_draft = false
+		AND p.moderation_status NOT IN ('flagged', 'reported', 'rejected')`
+
+// getAllQuery returns the query of GetAll and its arguments. Conditions are
+// only added when they apply, as conditions that are switched off by their
+// arguments keep the planner from using indexes. Only the polls on the page
+// are joined with their options, and the total is counted once, so large
+// tables aren't read in full to list a page.
+func getAllQuery(search string, orgID uuid.UUID, metadata map[string]string, filters Filters) (string, []any) {
+	args := []any{filters.limit(), filters.offset()}
+	conditions := []string{"NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)"}
+
+	if orgID == uuid.Nil {
+		conditions = append(conditions, listedPolls)
+	} else {
+		args = append(args, orgID)
+		conditions = append(conditions, fmt.Sprintf("p.org_id = $%d", len(args)))
+	}
+	if search != "" {
+		args = append(args, search)
+		conditions = append(conditions, fmt.Sprintf(
+			"to_tsvector('simple', search_unaccent(p.question)) @@ plainto_tsquery('simple', search_unaccent($%d))",
+			len(args),
+		))
+	}
+	if len(metadata) > 0 {
+		args = append(args, metadata)
+		conditions = append(conditions, fmt.Sprintf("p.metadata @> $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT (SELECT count(*) FROM polls p WHERE %[1]s), p.id, p.question, p.description,
+		p.created_at, p.updated_at, p.expires_at, p.results_visibility, p.anonymity,
+		p.allowed_countries, p.denied_countries, p.results_threshold, p.tie_break, p.tags, p.metadata,
+		p.is_draft, p.paused_at, p.max_votes, p.votes_cast,
+		COALESCE((
+			SELECT jsonb_agg(jsonb_build_object(
+				'id', po.id, 'value', po.value, 'position', po.position,
+				'description', po.description, 'url', po.url, 'group_id', po.group_id,
+				'vote_count', po.vote_count, 'percent', %[5]s
+			) ORDER BY po.position)
+			FROM poll_options po
+			WHERE po.poll_id = p.id
+		), '[]') AS options,
+		%[4]s AS groups
+		FROM polls p
+		WHERE %[1]s
+		ORDER BY p.%[2]s %[3]s, p.id %[3]s
+		LIMIT $1 OFFSET $2;
+	`, strings.Join(conditions, "\n\t\tAND "), filters.sortColumn(), filters.sortDirection(), optionGroups,
+		optionPercent)
+
+	return query, args
+}
+
+// GetAll lists the organization's polls, or public polls that don't belong to
+// an organization if orgID is empty. Polls can be filtered by metadata, which
+// they must have all the keys and values of.
+func (p PollModel) GetAll(search string, orgID uuid.UUID, metadata map[string]string, filters Filters) ([]*Poll, Metadata, error) {
+	query, args := getAllQuery(search, orgID, metadata, filters)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, Metadata{}, fmt.Errorf("get all polls: %w", err)
 	}
@@ -273,7 +1290,7 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 
 	for rows.Next() {
 		var poll Poll
-		var optionsJson string
+		var optionsJson, groupsJson string
 		err := rows.Scan(
 			&totalRecords,
 			&poll.ID,
@@ -283,15 +1300,31 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 			&poll.UpdatedAt,
 			&poll.ExpiresAt.Time,
 			&poll.ResultsVisibility,
+			&poll.Anonymity,
+			&poll.AllowedCountries,
+			&poll.DeniedCountries,
+			&poll.ResultsThreshold,
+			&poll.TieBreak,
+			&poll.Tags,
+			&poll.Metadata,
+			&poll.IsDraft,
+			&poll.PausedAt,
+			&poll.MaxVotes,
+			&poll.VotesCast,
 			&optionsJson,
+			&groupsJson,
 		)
 		if err != nil {
 			return nil, Metadata{}, fmt.Errorf("get polls - scan: %w", err)
 		}
 
-		if err := json.Unmarshal([]byte(optionsJson), &poll.Options); err != nil {
+		poll.Options, err = unmarshalOptions(optionsJson)
+		if err != nil {
 			return nil, Metadata{}, fmt.Errorf("get polls - unmarshal options: %w", err)
 		}
+		if err := json.Unmarshal([]byte(groupsJson), &poll.Groups); err != nil {
+			return nil, Metadata{}, fmt.Errorf("get polls - unmarshal groups: %w", err)
+		}
 		polls = append(polls, &poll)
 	}
 
@@ -299,12 +1332,116 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 		return nil, Metadata{}, fmt.Errorf("get polls: %w", err)
 	}
 
-	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return polls, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// pollDocument is the text searched in polls, without accents and in the
+// text search configuration of their language, with the question ranked above
+// the description and the description above the options. It matches the
+// expression of the polls_search_idx index.
+const pollDocument = `(setweight(to_tsvector(poll_search_config(p.language), search_unaccent(p.question)), 'A')
+	|| setweight(to_tsvector(poll_search_config(p.language), search_unaccent(p.description)), 'B')
+	|| setweight(to_tsvector(poll_search_config(p.language), search_unaccent(p.option_text)), 'C'))`
+
+// searchQuery is the tsquery of the search in $1, parsed like pollDocument.
+const searchQuery = `to_tsquery(poll_search_config(p.language), search_unaccent($1))`
+
+// Search lists public polls matching the query, which can be sorted by
+// "-relevance", how well they match its terms.
+func (p PollModel) Search(q search.Query, filters Filters) ([]*Poll, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), p.id, p.question, p.description,
+		p.created_at, p.updated_at, p.expires_at, p.results_visibility, p.anonymity,
+		p.allowed_countries, p.denied_countries, p.results_threshold, p.tie_break, p.tags,
+		p.is_draft, p.paused_at, p.max_votes, p.votes_cast,
+		CASE WHEN $1 = '' THEN 0 ELSE ts_rank(%[1]s, %[2]s) END AS relevance,
+	    jsonb_agg(jsonb_build_object(
+			'id', po.id, 'value', po.value, 'position', po.position,
+			'description', po.description, 'url', po.url, 'group_id', po.group_id,
+			'vote_count', po.vote_count, 'percent', %[7]s
+			)) AS options,
+		%[6]s AS groups
+		FROM polls p
+		JOIN poll_options po ON po.poll_id = p.id 
+		WHERE ($1 = '' OR %[1]s @@ %[2]s)
+		AND (COALESCE(cardinality($6::text[]), 0) = 0 OR p.language = ANY($6))
+		AND p.tags @> $4
+		AND (COALESCE(cardinality($5::text[]), 0) = 0
+			OR ('closed' = ANY($5) AND p.expires_at > '0001-01-02' AND p.expires_at <= NOW())
+			OR ('paused' = ANY($5) AND p.paused_at IS NOT NULL)
+			OR ('open' = ANY($5) AND p.paused_at IS NULL
+				AND NOT (p.expires_at > '0001-01-02' AND p.expires_at <= NOW())))
+		AND %[5]s
+		AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)
+		GROUP BY p.id
+		ORDER BY %[3]s %[4]s, p.id ASC
+		LIMIT $2 OFFSET $3;
+	`, pollDocument, searchQuery, filters.sortColumn(), filters.sortDirection(), listedPolls, optionGroups,
+		optionPercent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(
+		ctx, query, q.TSQuery, filters.limit(), filters.offset(), tagsOrEmpty(q.Tags), q.Statuses, q.Languages,
+	)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("search polls: %w", err)
+	}
+	defer rows.Close()
+
+	var totalRecords int
+	polls := []*Poll{}
+
+	for rows.Next() {
+		var poll Poll
+		var relevance float32
+		var optionsJson, groupsJson string
+		err := rows.Scan(
+			&totalRecords,
+			&poll.ID,
+			&poll.Question,
+			&poll.Description,
+			&poll.CreatedAt,
+			&poll.UpdatedAt,
+			&poll.ExpiresAt.Time,
+			&poll.ResultsVisibility,
+			&poll.Anonymity,
+			&poll.AllowedCountries,
+			&poll.DeniedCountries,
+			&poll.ResultsThreshold,
+			&poll.TieBreak,
+			&poll.Tags,
+			&poll.IsDraft,
+			&poll.PausedAt,
+			&poll.MaxVotes,
+			&poll.VotesCast,
+			&relevance,
+			&optionsJson,
+			&groupsJson,
+		)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("search polls - scan: %w", err)
+		}
 
-	return polls, metadata, nil
+		poll.Options, err = unmarshalOptions(optionsJson)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("search polls - unmarshal options: %w", err)
+		}
+		if err := json.Unmarshal([]byte(groupsJson), &poll.Groups); err != nil {
+			return nil, Metadata{}, fmt.Errorf("search polls - unmarshal groups: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, fmt.Errorf("search polls: %w", err)
+	}
+
+	return polls, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
 }
 
-func (p PollModel) GetVotedIPs(pollID string) ([]*net.IP, error) {
+func (p PollModel) GetVotedIPs(pollID uuid.UUID) ([]*net.IP, error) {
 	query := `
 		SELECT ip
 		FROM ips
@@ -337,26 +1474,86 @@ func (p PollModel) GetVotedIPs(pollID string) ([]*net.IP, error) {
 	return ips, nil
 }
 
-func (p PollModel) CheckToken(tokenPlaintext string) (string, error) {
-	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
-
+// CheckToken returns the ID of the poll the token belongs to. The token must
+// not be expired and its scope must allow the given scope.
+// CheckToken returns the poll and scope of an unexpired token.
+func (p PollModel) CheckToken(tokenPlaintext string) (uuid.UUID, string, error) {
 	query := `
-			SELECT poll_id
+			SELECT poll_id, scope
 			FROM tokens
-			WHERE hash = $1;
+			WHERE hash = $1 AND (expires_at IS NULL OR expires_at > NOW());
 		`
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
-	row := p.DB.QueryRow(ctx, query, tokenHash[:])
+	row := p.DB.QueryRow(ctx, query, HashToken(tokenPlaintext))
 
-	var pollID string
-	err := row.Scan(&pollID)
+	var pollID uuid.UUID
+	var scope string
+	err := row.Scan(&pollID, &scope)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", ErrRecordNotFound
+			return uuid.Nil, "", ErrRecordNotFound
 		}
-		return "", fmt.Errorf("check token: %w", err)
+		return uuid.Nil, "", fmt.Errorf("check token: %w", err)
+	}
+
+	return pollID, scope, nil
+}
+
+// visibilityOrPublic stores polls without a visibility as public, as every
+// poll was before polls could be unlisted or private.
+func visibilityOrPublic(visibility string) string {
+	if visibility == "" {
+		return VisibilityPublic
+	}
+	return visibility
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
 	}
+	return &s
+}
 
-	return pollID, nil
+func demographicsOrEmpty(questions []DemographicQuestion) []DemographicQuestion {
+	if questions == nil {
+		return []DemographicQuestion{}
+	}
+	return questions
+}
+
+func countriesOrEmpty(countries []string) []string {
+	if countries == nil {
+		return []string{}
+	}
+	return countries
+}
+
+func variantsOrEmpty(variants []string) []string {
+	if variants == nil {
+		return []string{}
+	}
+	return variants
+}
+
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func metadataOrEmpty(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
+
+func termsOrEmpty(terms []string) []string {
+	if terms == nil {
+		return []string{}
+	}
+	return terms
 }
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PublicStats are aggregate numbers about public polls, for a public
+// dashboard. Days start at midnight UTC.
+type PublicStats struct {
+	PublicPolls    int         `json:"public_polls"`
+	VotesToday     int         `json:"votes_today"`
+	MostActivePoll *ActivePoll `json:"most_active_poll"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// ActivePoll is the public poll that received the most votes today.
+type ActivePoll struct {
+	ID         uuid.UUID `json:"id"`
+	Question   string    `json:"question"`
+	VotesToday int       `json:"votes_today"`
+}
+
+// GetPublicStats counts the public polls, the polls listed by GetAll, and
+// the accepted votes they received today. MostActivePoll is nil if there were
+// no votes today.
+func (p PollModel) GetPublicStats() (*PublicStats, error) {
+	query := `
+		WITH public AS (
+			SELECT p.id, p.question FROM polls p
+			WHERE p.org_id IS NULL AND p.visibility = 'public' AND p.is_draft = false
+			AND p.moderation_status NOT IN ('flagged', 'reported', 'rejected')
+			AND NOT EXISTS (SELECT 1 FROM takedowns t WHERE t.poll_id = p.id)
+		), today AS (
+			SELECT v.poll_id, count(*) AS votes FROM votes v
+			JOIN public ON public.id = v.poll_id
+			WHERE v.status = 'accepted'
+			AND v.created_at >= date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+			GROUP BY v.poll_id
+		)
+		SELECT
+			(SELECT count(*) FROM public),
+			(SELECT COALESCE(sum(votes), 0) FROM today),
+			top.id, top.question, top.votes
+		FROM (SELECT 1) AS one
+		LEFT JOIN LATERAL (
+			SELECT public.id, public.question, today.votes FROM today
+			JOIN public ON public.id = today.poll_id
+			ORDER BY today.votes DESC, public.id
+			LIMIT 1
+		) AS top ON true;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	stats := PublicStats{UpdatedAt: time.Now().UTC().Truncate(time.Second)}
+	var id *uuid.UUID
+	var question *string
+	var votes *int
+	err := p.DB.QueryRow(ctx, query).Scan(&stats.PublicPolls, &stats.VotesToday, &id, &question, &votes)
+	if err != nil {
+		return nil, fmt.Errorf("get public stats: %w", err)
+	}
+
+	if id != nil {
+		stats.MostActivePoll = &ActivePoll{ID: *id, Question: *question, VotesToday: *votes}
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,184 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReportedPoll sums up the open reports of a poll for review.
+type ReportedPoll struct {
+	PollID           uuid.UUID `json:"poll_id"`
+	Question         string    `json:"question"`
+	ModerationStatus string    `json:"moderation_status"`
+	Reports          int       `json:"reports"`
+	Reasons          []string  `json:"reasons"`
+	FirstReportedAt  time.Time `json:"first_reported_at"`
+}
+
+type ReportModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert reports the poll on behalf of the reporter, identified by the
+// HashVisitor of their IP. Each reporter is counted once until the reports
+// are resolved. Once the poll has threshold open reports it is hidden pending
+// review, which Insert reports by returning true. A threshold of 0 never
+// hides polls.
+func (m ReportModel) Insert(pollID uuid.UUID, reporter []byte, reason string, threshold int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("report poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the poll is locked first, so concurrent reports see each other when
+	// they are counted
+	queryPoll := `
+		SELECT moderation_status
+		FROM polls
+		WHERE id = $1
+		FOR UPDATE;
+	`
+
+	var status string
+	err = tx.QueryRow(ctx, queryPoll, pollID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrRecordNotFound
+		}
+		return false, fmt.Errorf("report poll - lock poll: %w", err)
+	}
+
+	queryReport := `
+		INSERT INTO poll_reports (poll_id, reporter, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id, reporter) WHERE resolved_at IS NULL DO NOTHING;
+	`
+
+	_, err = tx.Exec(ctx, queryReport, pollID, reporter, reason)
+	if err != nil {
+		return false, fmt.Errorf("report poll: %w", err)
+	}
+
+	hidden := false
+	if threshold > 0 && status != ModerationReported && status != ModerationRejected {
+		queryHide := `
+			UPDATE polls
+			SET moderation_status = $1
+			WHERE id = $2
+			AND (SELECT count(*) FROM poll_reports WHERE poll_id = $2 AND resolved_at IS NULL) >= $3;
+		`
+
+		result, err := tx.Exec(ctx, queryHide, ModerationReported, pollID, threshold)
+		if err != nil {
+			return false, fmt.Errorf("report poll - hide poll: %w", err)
+		}
+		hidden = result.RowsAffected() == 1
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("report poll: %w", err)
+	}
+
+	return hidden, nil
+}
+
+// GetOpen lists the polls with open reports, hidden polls first and then by
+// their number of reports.
+func (m ReportModel) GetOpen() ([]*ReportedPoll, error) {
+	query := `
+		SELECT p.id, p.question, p.moderation_status, count(*),
+		COALESCE(array_agg(r.reason ORDER BY r.created_at) FILTER (WHERE r.reason <> ''), '{}'),
+		min(r.created_at)
+		FROM poll_reports r
+		JOIN polls p ON p.id = r.poll_id
+		WHERE r.resolved_at IS NULL
+		GROUP BY p.id
+		ORDER BY p.moderation_status = $1 DESC, count(*) DESC, min(r.created_at);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query, ModerationReported)
+	if err != nil {
+		return nil, fmt.Errorf("get open reports: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []*ReportedPoll{}
+	for rows.Next() {
+		var poll ReportedPoll
+		err := rows.Scan(
+			&poll.PollID,
+			&poll.Question,
+			&poll.ModerationStatus,
+			&poll.Reports,
+			&poll.Reasons,
+			&poll.FirstReportedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get open reports - scan: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get open reports: %w", err)
+	}
+
+	return polls, nil
+}
+
+// Resolve closes the poll's open reports, approving or rejecting the poll.
+// Approved polls are shown again and can be reported anew.
+func (m ReportModel) Resolve(pollID uuid.UUID, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("resolve reports: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryPoll := `
+		UPDATE polls
+		SET moderation_status = $1
+		WHERE id = $2;
+	`
+
+	result, err := tx.Exec(ctx, queryPoll, status, pollID)
+	if err != nil {
+		return fmt.Errorf("resolve reports - moderate poll: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	queryReports := `
+		UPDATE poll_reports
+		SET resolved_at = NOW()
+		WHERE poll_id = $1 AND resolved_at IS NULL;
+	`
+
+	_, err = tx.Exec(ctx, queryReports, pollID)
+	if err != nil {
+		return fmt.Errorf("resolve reports: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("resolve reports: %w", err)
+	}
+
+	return nil
+}
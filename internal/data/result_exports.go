@@ -0,0 +1,159 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Formats of result exports.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// ResultExport schedules a poll's results to be exported to the server's
+// storage bucket, hourly or daily like digests.
+type ResultExport struct {
+	PollID         uuid.UUID  `json:"poll_id"`
+	Frequency      string     `json:"frequency"`
+	Format         string     `json:"format"`
+	LastExportedAt *time.Time `json:"last_exported_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ResultExportModel struct {
+	DB *pgxpool.Pool
+}
+
+// Schedule creates or replaces the poll's export schedule. The first export
+// is made once a period has passed.
+func (m ResultExportModel) Schedule(export *ResultExport) error {
+	query := `
+		INSERT INTO result_exports (poll_id, frequency, format)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id) DO UPDATE
+		SET frequency = EXCLUDED.frequency,
+			format = EXCLUDED.format
+		RETURNING last_exported_at, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, query, export.PollID, export.Frequency, export.Format).
+		Scan(&export.LastExportedAt, &export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("schedule result export: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the poll's export schedule. LastExportedAt is nil until the
+// first export.
+func (m ResultExportModel) Get(pollID uuid.UUID) (*ResultExport, error) {
+	query := `
+		SELECT poll_id, frequency, format, last_exported_at, created_at
+		FROM result_exports
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var export ResultExport
+	err := m.DB.QueryRow(ctx, query, pollID).Scan(
+		&export.PollID,
+		&export.Frequency,
+		&export.Format,
+		&export.LastExportedAt,
+		&export.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get result export: %w", err)
+	}
+
+	return &export, nil
+}
+
+func (m ResultExportModel) Delete(pollID uuid.UUID) error {
+	query := `
+		DELETE FROM result_exports
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, pollID)
+	if err != nil {
+		return fmt.Errorf("delete result export: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue returns the exports whose period has passed and starts their next
+// period. Each export is returned once, even with several servers exporting
+// concurrently.
+func (m ResultExportModel) ClaimDue() ([]*ResultExport, error) {
+	query := `
+		UPDATE result_exports
+		SET last_exported_at = NOW()
+		WHERE poll_id IN (
+			SELECT poll_id FROM result_exports
+			WHERE COALESCE(last_exported_at, created_at) <= NOW() - CASE frequency
+				WHEN 'hourly' THEN interval '1 hour'
+				ELSE interval '1 day'
+			END
+			LIMIT 100
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING poll_id, frequency, format, last_exported_at, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("claim due result exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*ResultExport
+
+	for rows.Next() {
+		var export ResultExport
+		err := rows.Scan(
+			&export.PollID,
+			&export.Frequency,
+			&export.Format,
+			&export.LastExportedAt,
+			&export.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("claim due result exports - scan: %w", err)
+		}
+		exports = append(exports, &export)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due result exports: %w", err)
+	}
+
+	return exports, nil
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	shortCodeAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	shortCodeLength   = 7
+	// attempts at finding an unused code before giving up
+	shortCodeAttempts = 5
+)
+
+var ShortCodeRX = regexp.MustCompile("^[" + shortCodeAlphabet + "]{7}$")
+
+type ShortLink struct {
+	Code      string    `json:"code"`
+	PollID    uuid.UUID `json:"poll_id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ShortLinkModel struct {
+	DB *pgxpool.Pool
+}
+
+// generateShortCode returns a random code without easily confused
+// characters (0/O, 1/l/I), so it can be read out loud or off a slide.
+func generateShortCode() (string, error) {
+	code := make([]byte, shortCodeLength)
+	alphabetLen := big.NewInt(int64(len(shortCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetLen)
+		if err != nil {
+			return "", err
+		}
+		code[i] = shortCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// Insert creates a short link for the poll. A poll has at most one short
+// link, so the existing one is returned if it was already created.
+func (s ShortLinkModel) Insert(pollID uuid.UUID) (*ShortLink, error) {
+	query := `
+		INSERT INTO short_links (code, poll_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING created_at;
+	`
+
+	for i := 0; i < shortCodeAttempts; i++ {
+		code, err := generateShortCode()
+		if err != nil {
+			return nil, fmt.Errorf("insert short link - generate code: %w", err)
+		}
+
+		link := ShortLink{Code: code, PollID: pollID}
+
+		ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+		err = s.DB.QueryRow(ctx, query, code, pollID).Scan(&link.CreatedAt)
+		cancel()
+		if err == nil {
+			return &link, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("insert short link: %w", err)
+		}
+
+		// nothing inserted: either the poll already has a link or the code
+		// is taken, in which case another one is tried
+		existing, err := s.GetForPoll(pollID)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("insert short link: could not generate unique code")
+}
+
+func (s ShortLinkModel) GetForPoll(pollID uuid.UUID) (*ShortLink, error) {
+	query := `
+		SELECT code, poll_id, created_at
+		FROM short_links
+		WHERE poll_id = $1;
+	`
+	return s.get(query, pollID)
+}
+
+func (s ShortLinkModel) GetByCode(code string) (*ShortLink, error) {
+	query := `
+		SELECT code, poll_id, created_at
+		FROM short_links
+		WHERE code = $1;
+	`
+	return s.get(query, code)
+}
+
+func (s ShortLinkModel) get(query string, arg any) (*ShortLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var link ShortLink
+	err := s.DB.QueryRow(ctx, query, arg).Scan(&link.Code, &link.PollID, &link.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get short link: %w", err)
+	}
+
+	return &link, nil
+}
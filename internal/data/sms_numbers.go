@@ -0,0 +1,151 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SMSNumber binds a phone number, rented from Twilio, to the poll text
+// messages sent to it vote on. A number takes votes for one poll at a time
+// and a poll can be bound to one number.
+type SMSNumber struct {
+	PollID      uuid.UUID `json:"poll_id"`
+	PhoneNumber string    `json:"phone_number"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SMSNumberModel struct {
+	DB *pgxpool.Pool
+}
+
+// Bind binds the number to the poll, replacing the poll's previous number. A
+// number bound to another poll is only taken over once that poll is closed,
+// otherwise ErrNumberTaken is returned.
+func (s SMSNumberModel) Bind(number *SMSNumber) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("bind sms number: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryUnbind := `
+		DELETE FROM sms_numbers
+		WHERE poll_id = $1 AND phone_number <> $2;
+	`
+
+	if _, err := tx.Exec(ctx, queryUnbind, number.PollID, number.PhoneNumber); err != nil {
+		return fmt.Errorf("bind sms number - unbind: %w", err)
+	}
+
+	query := `
+		INSERT INTO sms_numbers (phone_number, poll_id)
+		VALUES ($1, $2)
+		ON CONFLICT (phone_number) DO UPDATE
+		SET poll_id = EXCLUDED.poll_id,
+			created_at = CASE WHEN sms_numbers.poll_id = EXCLUDED.poll_id
+				THEN sms_numbers.created_at ELSE NOW() END
+		WHERE sms_numbers.poll_id = EXCLUDED.poll_id OR EXISTS (
+			SELECT 1 FROM polls p
+			WHERE p.id = sms_numbers.poll_id
+			AND (p.closed_at IS NOT NULL OR (p.expires_at > '0001-01-02' AND p.expires_at <= NOW()))
+		)
+		RETURNING created_at;
+	`
+
+	err = tx.QueryRow(ctx, query, number.PhoneNumber, number.PollID).Scan(&number.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNumberTaken
+		}
+		return fmt.Errorf("bind sms number: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s SMSNumberModel) GetForPoll(pollID uuid.UUID) (*SMSNumber, error) {
+	query := `
+		SELECT poll_id, phone_number, created_at
+		FROM sms_numbers
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var number SMSNumber
+	err := s.DB.QueryRow(ctx, query, pollID).Scan(&number.PollID, &number.PhoneNumber, &number.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get sms number: %w", err)
+	}
+
+	return &number, nil
+}
+
+// GetPollID returns the ID of the poll the number is bound to.
+func (s SMSNumberModel) GetPollID(phoneNumber string) (uuid.UUID, error) {
+	query := `
+		SELECT poll_id
+		FROM sms_numbers
+		WHERE phone_number = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var pollID uuid.UUID
+	err := s.DB.QueryRow(ctx, query, phoneNumber).Scan(&pollID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrRecordNotFound
+		}
+		return uuid.Nil, fmt.Errorf("get sms number poll: %w", err)
+	}
+
+	return pollID, nil
+}
+
+func (s SMSNumberModel) Unbind(pollID uuid.UUID) error {
+	query := `
+		DELETE FROM sms_numbers
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := s.DB.Exec(ctx, query, pollID)
+	if err != nil {
+		return fmt.Errorf("unbind sms number: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ValidateSMSNumber checks the number is in E.164 format, as Twilio sends it
+// in the To parameter of its webhooks.
+func ValidateSMSNumber(v *validator.Validator, number *SMSNumber) {
+	v.Check(number.PhoneNumber != "", "phone_number", "must be provided")
+	v.Check(
+		number.PhoneNumber == "" || validator.Matches(number.PhoneNumber, validator.PhoneNumberRX),
+		"phone_number",
+		"must be in E.164 format, e.g. +15551234567",
+	)
+}
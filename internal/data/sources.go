@@ -0,0 +1,149 @@
+package data
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var sourceRX = regexp.MustCompile("^[a-z0-9][a-z0-9._-]*$")
+
+// SourceCount is how often a poll was viewed and voted on from a source, e.g.
+// "slack" or "newsletter". Requests without a source are counted under "".
+type SourceCount struct {
+	Source string `json:"source"`
+	Views  int    `json:"views"`
+	Votes  int    `json:"votes"`
+}
+
+// NormalizeSource lowercases a source and trims surrounding space, so
+// "Slack" and "slack" are counted together.
+func NormalizeSource(source string) string {
+	return strings.ToLower(strings.TrimSpace(source))
+}
+
+func ValidateSource(v *validator.Validator, key string, source string) {
+	if source == "" {
+		return
+	}
+	v.Check(len(source) <= 50, key, "must not be more than 50 bytes long")
+	v.Check(validator.Matches(source, sourceRX), key, "must only contain letters, digits, dots, dashes and underscores")
+}
+
+// HashVisitor returns the HMAC-SHA256 of the IP with the server's visitor
+// key, which views and reports count visitors by. Unlike a plain hash, it
+// can't be reversed by hashing every IP without the key.
+func HashVisitor(key []byte, ip string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(NormalizeIP(ip)))
+	return mac.Sum(nil)
+}
+
+// ViewStats compares how often a poll was viewed with how often it was voted
+// on.
+type ViewStats struct {
+	Views int `json:"views"`
+	Votes int `json:"votes"`
+}
+
+type ViewModel struct {
+	DB *pgxpool.Pool
+}
+
+// Record counts a view of the poll from the source. Views are counted once
+// per visitor, identified by the HashVisitor of their IP, and hour. The hash
+// is only stored until the hour is over.
+func (m ViewModel) Record(pollID uuid.UUID, source string, visitor []byte) error {
+	query := `
+		WITH stale AS (
+			DELETE FROM poll_view_visitors
+			WHERE poll_id = $1 AND hour < date_trunc('hour', NOW())
+		), visit AS (
+			INSERT INTO poll_view_visitors (poll_id, visitor, hour)
+			VALUES ($1, $3, date_trunc('hour', NOW()))
+			ON CONFLICT DO NOTHING
+			RETURNING poll_id
+		)
+		INSERT INTO poll_source_views (poll_id, source, views)
+		SELECT poll_id, $2, 1 FROM visit
+		ON CONFLICT (poll_id, source) DO UPDATE SET views = poll_source_views.views + 1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	_, err := m.DB.Exec(ctx, query, pollID, source, visitor)
+	if err != nil {
+		return fmt.Errorf("record view: %w", err)
+	}
+
+	return nil
+}
+
+// GetSourceCounts counts the poll's views and accepted votes per source, most
+// votes first.
+func (m ViewModel) GetSourceCounts(pollID uuid.UUID) ([]*SourceCount, error) {
+	query := `
+		SELECT COALESCE(views.source, votes.source), COALESCE(views.views, 0), COALESCE(votes.votes, 0)
+		FROM (
+			SELECT source, views FROM poll_source_views WHERE poll_id = $1
+		) AS views
+		FULL OUTER JOIN (
+			SELECT source, count(*) AS votes FROM votes
+			WHERE poll_id = $1 AND status = 'accepted'
+			GROUP BY source
+		) AS votes ON views.source = votes.source
+		ORDER BY 3 DESC, 2 DESC, 1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get source counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []*SourceCount{}
+	for rows.Next() {
+		var count SourceCount
+		if err := rows.Scan(&count.Source, &count.Views, &count.Votes); err != nil {
+			return nil, fmt.Errorf("get source counts - scan: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get source counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetStats counts the poll's views and accepted votes.
+func (m ViewModel) GetStats(pollID uuid.UUID) (*ViewStats, error) {
+	query := `
+		SELECT
+			(SELECT COALESCE(sum(views), 0) FROM poll_source_views WHERE poll_id = $1),
+			(SELECT count(*) FROM votes WHERE poll_id = $1 AND status = 'accepted');
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var stats ViewStats
+	err := m.DB.QueryRow(ctx, query, pollID).Scan(&stats.Views, &stats.Votes)
+	if err != nil {
+		return nil, fmt.Errorf("get view stats: %w", err)
+	}
+
+	return &stats, nil
+}
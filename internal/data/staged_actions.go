@@ -0,0 +1,352 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Actions that are staged, so they can be undone for a while before they
+// take effect.
+const (
+	StagedPollDelete   = "poll.delete"
+	StagedOptionDelete = "option.delete"
+)
+
+// StagedAction is a delete of a poll, or of one of its options if OptionID
+// is set, that takes effect at UndoUntil unless it is undone before.
+type StagedAction struct {
+	ID        uuid.UUID  `json:"id"`
+	Action    string     `json:"action"`
+	PollID    uuid.UUID  `json:"poll_id"`
+	OptionID  *uuid.UUID `json:"option_id,omitempty"`
+	UndoUntil time.Time  `json:"undo_until"`
+	// Actor is who the change is recorded by in the audit log.
+	Actor string `json:"-"`
+}
+
+func (a *StagedAction) setAction() {
+	a.Action = StagedPollDelete
+	if a.OptionID != nil {
+		a.Action = StagedOptionDelete
+	}
+}
+
+// TrashedPoll is a poll staged to be deleted, which can be restored by
+// undoing its delete until UndoUntil.
+type TrashedPoll struct {
+	ActionID  uuid.UUID `json:"action_id"`
+	PollID    uuid.UUID `json:"poll_id"`
+	Question  string    `json:"question"`
+	DeletedBy string    `json:"deleted_by"`
+	DeletedAt time.Time `json:"deleted_at"`
+	UndoUntil time.Time `json:"undo_until"`
+}
+
+type StagedActionModel struct {
+	DB *pgxpool.Pool
+}
+
+// Stage stages the action to take effect after window. Staging an action
+// that is already staged returns the staged action instead.
+func (m StagedActionModel) Stage(action *StagedAction, window time.Duration) error {
+	query := `
+		INSERT INTO staged_actions (poll_id, option_id, actor, execute_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING id, execute_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	action.setAction()
+
+	err := m.DB.QueryRow(ctx, query, action.PollID, action.OptionID, action.Actor, time.Now().Add(window)).
+		Scan(&action.ID, &action.UndoUntil)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("stage action: %w", err)
+	}
+
+	queryStaged := `
+		SELECT id, execute_at
+		FROM staged_actions
+		WHERE poll_id = $1 AND option_id IS NOT DISTINCT FROM $2;
+	`
+
+	err = m.DB.QueryRow(ctx, queryStaged, action.PollID, action.OptionID).Scan(&action.ID, &action.UndoUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("stage action - get staged: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the action if it can still be undone.
+func (m StagedActionModel) Get(id uuid.UUID) (*StagedAction, error) {
+	query := `
+		SELECT id, poll_id, option_id, actor, execute_at
+		FROM staged_actions
+		WHERE id = $1 AND execute_at > NOW();
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var action StagedAction
+	err := m.DB.QueryRow(ctx, query, id).Scan(
+		&action.ID,
+		&action.PollID,
+		&action.OptionID,
+		&action.Actor,
+		&action.UndoUntil,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get staged action: %w", err)
+	}
+	action.setAction()
+
+	return &action, nil
+}
+
+// GetOptionDeletes returns the IDs of the poll's options staged to be
+// deleted.
+func (m StagedActionModel) GetOptionDeletes(pollID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT option_id
+		FROM staged_actions
+		WHERE poll_id = $1 AND option_id IS NOT NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get staged option deletes: %w", err)
+	}
+
+	defer rows.Close()
+
+	var optionIDs []uuid.UUID
+	for rows.Next() {
+		var optionID uuid.UUID
+		if err := rows.Scan(&optionID); err != nil {
+			return nil, fmt.Errorf("get staged option deletes - scan: %w", err)
+		}
+		optionIDs = append(optionIDs, optionID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get staged option deletes: %w", err)
+	}
+
+	return optionIDs, nil
+}
+
+// GetTrash returns the organization's polls staged to be deleted that can
+// still be restored, soonest purged first.
+func (m StagedActionModel) GetTrash(orgID uuid.UUID) ([]*TrashedPoll, error) {
+	query := `
+		SELECT s.id, p.id, p.question, s.actor, s.created_at, s.execute_at
+		FROM staged_actions s
+		JOIN polls p ON p.id = s.poll_id
+		WHERE p.org_id = $1 AND s.option_id IS NULL AND s.execute_at > NOW()
+		ORDER BY s.execute_at, s.id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("get trash: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []*TrashedPoll{}
+	for rows.Next() {
+		var poll TrashedPoll
+		err := rows.Scan(
+			&poll.ActionID,
+			&poll.PollID,
+			&poll.Question,
+			&poll.DeletedBy,
+			&poll.DeletedAt,
+			&poll.UndoUntil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get trash - scan: %w", err)
+		}
+		polls = append(polls, &poll)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get trash: %w", err)
+	}
+
+	return polls, nil
+}
+
+// Undo cancels the action, unless it has taken effect.
+func (m StagedActionModel) Undo(id uuid.UUID) error {
+	query := `
+		DELETE FROM staged_actions
+		WHERE id = $1 AND execute_at > NOW();
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("undo action: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ExecuteDue carries out the actions that can no longer be undone and
+// returns how many were. Option deletes that would leave the poll with fewer
+// than two options, e.g. after options were merged, are dropped.
+func (m StagedActionModel) ExecuteDue() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("execute staged actions: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// actions are claimed with their rows locked, so concurrent runs don't
+	// execute them twice
+	query := `
+		DELETE FROM staged_actions
+		WHERE id IN (
+			SELECT id FROM staged_actions
+			WHERE execute_at <= NOW()
+			ORDER BY execute_at
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, poll_id, option_id, actor, execute_at;
+	`
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("execute staged actions - claim: %w", err)
+	}
+
+	var actions []*StagedAction
+	for rows.Next() {
+		var action StagedAction
+		err := rows.Scan(&action.ID, &action.PollID, &action.OptionID, &action.Actor, &action.UndoUntil)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("execute staged actions - scan: %w", err)
+		}
+		actions = append(actions, &action)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("execute staged actions: %w", err)
+	}
+
+	for _, action := range actions {
+		if action.OptionID == nil {
+			err = deletePoll(ctx, tx, action.PollID)
+		} else {
+			err = deleteOption(ctx, tx, action)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("execute staged actions - %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("execute staged actions: %w", err)
+	}
+
+	return len(actions), nil
+}
+
+func deletePoll(ctx context.Context, tx pgx.Tx, pollID uuid.UUID) error {
+	query := `
+		DELETE FROM polls
+		WHERE id = $1;
+	`
+
+	if _, err := tx.Exec(ctx, query, pollID); err != nil {
+		return fmt.Errorf("delete poll: %w", err)
+	}
+
+	return nil
+}
+
+// deleteOption deletes the option of the staged action, keeping it with its
+// votes so it can be restored, and closes the gap it leaves in the positions,
+// recording the delete in the audit log.
+func deleteOption(ctx context.Context, tx pgx.Tx, action *StagedAction) error {
+	query := `
+		WITH deleted AS (
+			DELETE FROM poll_options
+			WHERE id = $1 AND poll_id = $2
+			AND (SELECT count(*) FROM poll_options WHERE poll_id = $2) > 2
+			RETURNING *
+		)` + archiveDeletedOption + `
+		RETURNING value, position;
+	`
+
+	var value string
+	var position int
+	err := tx.QueryRow(ctx, query, action.OptionID, action.PollID).Scan(&value, &position)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("delete option: %w", err)
+	}
+
+	queryPositions := `
+		UPDATE poll_options
+		SET position = position - 1
+		WHERE poll_id = $1 AND position > $2;
+	`
+	if _, err := tx.Exec(ctx, queryPositions, action.PollID, position); err != nil {
+		return fmt.Errorf("delete option - update positions: %w", err)
+	}
+
+	queryUpdatedAt := `
+		UPDATE polls
+		SET updated_at = NOW()
+		WHERE id = $1;
+	`
+	if _, err := tx.Exec(ctx, queryUpdatedAt, action.PollID); err != nil {
+		return fmt.Errorf("delete option - set updated_at: %w", err)
+	}
+
+	return insertActivity(ctx, tx, &Activity{
+		PollID:  action.PollID,
+		Action:  ActionOptionDeleted,
+		Actor:   action.Actor,
+		Details: map[string]any{"option_id": action.OptionID, "value": value},
+	})
+}
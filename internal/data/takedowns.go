@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Takedown removes a poll for everyone, including its owner, leaving a
+// tombstone in its place until it is restored.
+type Takedown struct {
+	PollID    uuid.UUID `json:"poll_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TakedownModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert takes the poll down. Taking down a poll again updates the reason.
+func (m TakedownModel) Insert(takedown *Takedown) error {
+	query := `
+		INSERT INTO takedowns (poll_id, reason)
+		SELECT id, $2 FROM polls WHERE id = $1
+		ON CONFLICT (poll_id) DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, query, takedown.PollID, takedown.Reason).Scan(&takedown.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("insert takedown: %w", err)
+	}
+
+	return nil
+}
+
+func (m TakedownModel) Get(pollID uuid.UUID) (*Takedown, error) {
+	query := `
+		SELECT poll_id, reason, created_at
+		FROM takedowns
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var takedown Takedown
+	err := m.DB.QueryRow(ctx, query, pollID).Scan(&takedown.PollID, &takedown.Reason, &takedown.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get takedown: %w", err)
+	}
+
+	return &takedown, nil
+}
+
+// Delete restores a poll that was taken down.
+func (m TakedownModel) Delete(pollID uuid.UUID) error {
+	query := `
+		DELETE FROM takedowns
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, query, pollID)
+	if err != nil {
+		return fmt.Errorf("delete takedown: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
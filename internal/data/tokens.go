@@ -1,20 +1,41 @@
 package data
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Token scopes. Manage tokens are given to a poll's creator and can do
+// everything results tokens can. Vote tokens let their holder vote once,
+// regardless of the IP they vote from. Kiosk tokens let a device shared by
+// many voters, like a tablet at a conference booth, vote any number of times.
+const (
+	ScopeManage  = "manage"
+	ScopeResults = "results"
+	ScopeVote    = "vote"
+	ScopePreview = "preview"
+	ScopeKiosk   = "kiosk"
 )
 
 type Token struct {
 	Plaintext string
 	Hash      []byte
+	Scope     string
+	// Expiry is zero for tokens that don't expire.
+	Expiry time.Time
 }
 
 func GenerateToken() (*Token, error) {
-	var token Token
+	token := Token{Scope: ScopeManage}
 
 	randomBytes := make([]byte, 16)
 
@@ -24,13 +45,115 @@ func GenerateToken() (*Token, error) {
 	}
 
 	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
-	hash := sha256.Sum256([]byte(token.Plaintext))
-	token.Hash = hash[:]
+	token.Hash = HashToken(token.Plaintext)
 
 	return &token, nil
 }
 
+// TokenVoterIdentity returns the identity votes made with a vote token are
+// deduplicated by.
+func TokenVoterIdentity(tokenPlaintext string) string {
+	return "token:" + hex.EncodeToString(HashToken(tokenPlaintext)[:8])
+}
+
+// HashToken returns the hash a token is stored as.
+func HashToken(tokenPlaintext string) []byte {
+	hash := sha256.Sum256([]byte(tokenPlaintext))
+	return hash[:]
+}
+
+func ValidateToken(v *validator.Validator, token *Token) {
+	v.Check(validator.PermittedValue(
+		token.Scope, ScopeManage, ScopeResults, ScopeVote, ScopePreview, ScopeKiosk,
+	), "scope", "must be manage, results, vote, preview or kiosk")
+	if !token.Expiry.IsZero() {
+		v.Check(token.Expiry.After(time.Now()), "expires_at", "must be in the future")
+	}
+}
+
 func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
 	v.Check(tokenPlaintext != "", "token", "must be provided")
 	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
 }
+
+type TokenModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert adds another token to the poll.
+func (t TokenModel) Insert(pollID uuid.UUID, token *Token) error {
+	query := `
+		INSERT INTO tokens (hash, poll_id, scope, expires_at)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	var expiry *time.Time
+	if !token.Expiry.IsZero() {
+		expiry = &token.Expiry
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	_, err := t.DB.Exec(ctx, query, token.Hash, pollID, token.Scope, expiry)
+	if err != nil {
+		return fmt.Errorf("insert token: %w", err)
+	}
+
+	return nil
+}
+
+// Rotate replaces a poll's token with a new one. ErrRecordNotFound is
+// returned if the old token doesn't belong to the poll, e.g. when it was
+// already rotated by a concurrent request.
+func (t TokenModel) Rotate(pollID uuid.UUID, oldHash, newHash []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := t.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("rotate token: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		DELETE FROM tokens
+		WHERE hash = $1 AND poll_id = $2;
+	`
+	result, err := tx.Exec(ctx, query, oldHash, pollID)
+	if err != nil {
+		return fmt.Errorf("rotate token - delete: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	queryInsert := `
+		INSERT INTO tokens (hash, poll_id)
+		VALUES ($1, $2);
+	`
+	if _, err := tx.Exec(ctx, queryInsert, newHash, pollID); err != nil {
+		return fmt.Errorf("rotate token - insert: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeAll deletes every token of the poll and returns how many there were.
+// The poll can't be managed afterwards.
+func (t TokenModel) RevokeAll(pollID uuid.UUID) (int64, error) {
+	query := `
+		DELETE FROM tokens
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := t.DB.Exec(ctx, query, pollID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
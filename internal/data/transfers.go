@@ -0,0 +1,118 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Transfer records a poll's token being replaced, handing the poll over to
+// whoever receives the new token.
+type Transfer struct {
+	ID        int64     `json:"id"`
+	PollID    uuid.UUID `json:"poll_id"`
+	Reason    string    `json:"reason,omitempty"`
+	IP        string    `json:"-"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TransferModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert replaces the poll's manage tokens with the one given and records the
+// transfer. Previous manage tokens stop working immediately, tokens with
+// other scopes are kept.
+func (t TransferModel) Insert(transfer *Transfer, tokenHash []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := t.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("transfer poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM tokens WHERE poll_id = $1 AND scope = $2;`, transfer.PollID, ScopeManage)
+	if err != nil {
+		return fmt.Errorf("transfer poll - delete tokens: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	queryToken := `
+		INSERT INTO tokens (hash, poll_id)
+		VALUES ($1, $2);
+	`
+	if _, err := tx.Exec(ctx, queryToken, tokenHash, transfer.PollID); err != nil {
+		return fmt.Errorf("transfer poll - insert token: %w", err)
+	}
+
+	paramIP := pgtype.Inet{Status: pgtype.Null}
+	if transfer.IP != "" {
+		if err := paramIP.Set(transfer.IP); err != nil {
+			return fmt.Errorf("transfer poll - set ip: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO poll_transfers (poll_id, reason, ip, user_agent)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+	args := []any{transfer.PollID, transfer.Reason, paramIP, transfer.UserAgent}
+	err = tx.QueryRow(ctx, query, args...).Scan(&transfer.ID, &transfer.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("transfer poll - insert transfer: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetForPoll returns the poll's transfers, oldest first.
+func (t TransferModel) GetForPoll(pollID uuid.UUID) ([]*Transfer, error) {
+	query := `
+		SELECT id, poll_id, reason, user_agent, created_at
+		FROM poll_transfers
+		WHERE poll_id = $1
+		ORDER BY created_at, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := t.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []*Transfer{}
+
+	for rows.Next() {
+		var transfer Transfer
+		err := rows.Scan(
+			&transfer.ID,
+			&transfer.PollID,
+			&transfer.Reason,
+			&transfer.UserAgent,
+			&transfer.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get transfers - scan: %w", err)
+		}
+		transfers = append(transfers, &transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get transfers: %w", err)
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UsageReport is an organization's usage in a calendar month. Polls created and
+// votes received keep being counted after the polls are deleted.
+type UsageReport struct {
+	Period        time.Time `json:"period"`
+	PollsCreated  int       `json:"polls_created"`
+	VotesReceived int       `json:"votes_received"`
+	ActivePolls   int       `json:"active_polls"`
+}
+
+type UsageModel struct {
+	DB *pgxpool.Pool
+}
+
+// Get returns the organization's usage in the current month.
+func (u UsageModel) Get(orgID uuid.UUID) (*UsageReport, error) {
+	query := `
+		SELECT date_trunc('month', NOW())::date,
+			COALESCE(polls_created, 0), COALESCE(votes_received, 0)
+		FROM (SELECT 1) AS one
+		LEFT JOIN org_usage ON org_id = $1 AND period = date_trunc('month', NOW())::date;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var usage UsageReport
+	err := u.DB.QueryRow(ctx, query, orgID).Scan(&usage.Period, &usage.PollsCreated, &usage.VotesReceived)
+	if err != nil {
+		return nil, fmt.Errorf("get usage: %w", err)
+	}
+
+	usage.ActivePolls, err = u.ActivePolls(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// activePollsQuery counts the polls of organization $1 that are still active,
+// which the quota of active polls limits.
+const activePollsQuery = `
+	SELECT count(*)
+	FROM polls
+	WHERE org_id = $1 AND closed_at IS NULL
+	AND (expires_at < '0001-01-02' OR expires_at > NOW());
+`
+
+// ActivePolls counts the organization's polls that haven't expired.
+func (u UsageModel) ActivePolls(orgID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var count int
+	err := u.DB.QueryRow(ctx, activePollsQuery, orgID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count active polls: %w", err)
+	}
+
+	return count, nil
+}
+
+// recordPollUsage counts a poll created by the organization.
+func recordPollUsage(ctx context.Context, db *pgxpool.Pool, orgID uuid.UUID) error {
+	query := `
+		INSERT INTO org_usage (org_id, period, polls_created)
+		VALUES ($1, date_trunc('month', NOW())::date, 1)
+		ON CONFLICT (org_id, period) DO UPDATE
+		SET polls_created = org_usage.polls_created + 1;
+	`
+
+	_, err := db.Exec(ctx, query, orgID)
+	if err != nil {
+		return fmt.Errorf("record poll usage: %w", err)
+	}
+
+	return nil
+}
+
+// recordVoteUsage counts a vote received by the poll's organization, if it
+// belongs to one.
+func recordVoteUsage(ctx context.Context, db querier, pollID uuid.UUID) error {
+	query := `
+		INSERT INTO org_usage (org_id, period, votes_received)
+		SELECT org_id, date_trunc('month', NOW())::date, 1
+		FROM polls
+		WHERE id = $1 AND org_id IS NOT NULL
+		ON CONFLICT (org_id, period) DO UPDATE
+		SET votes_received = org_usage.votes_received + 1;
+	`
+
+	_, err := db.Exec(ctx, query, pollID)
+	if err != nil {
+		return fmt.Errorf("record vote usage: %w", err)
+	}
+
+	return nil
+}
@@ -1,35 +1,78 @@
 package data
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/lang"
 	"github.com/ivcp/polls/internal/validator"
 )
 
 var resultsVisibilitySafelist = []string{"always", "after_vote", "after_deadline"}
 
+var visibilitySafelist = []string{VisibilityPublic, VisibilityUnlisted, VisibilityPrivate}
+
+var anonymitySafelist = []string{"anonymous", "names_visible_to_owner", "public"}
+
+var tieBreakSafelist = []string{"shared", "earliest", "random"}
+
+var tagRX = regexp.MustCompile(`^[\p{Ll}\p{Nd}]+(-[\p{Ll}\p{Nd}]+)*$`)
+
+// metadataKeyRX matches metadata keys, which can be used in query parameters
+// without escaping.
+var metadataKeyRX = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// externalIDRX matches the IDs integrators give polls in their own systems,
+// which are used in URL paths without escaping.
+var externalIDRX = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// MaxMetadataKeys is how many metadata keys a poll can have.
+const MaxMetadataKeys = 20
+
+// MaxOptions is how many options a poll can have. The database refuses more
+// too, see migration 00057.
+const MaxOptions = 100
+
+// MaxQuestionVariants is how many other wordings a poll's question can have.
+const MaxQuestionVariants = 5
+
+func ValidatePauseReason(v *validator.Validator, reason string) {
+	v.Check(len(reason) <= 200, "reason", "must not be more than 200 bytes long")
+}
+
 func ValidatePoll(v *validator.Validator, poll *Poll) {
 	v.Check(poll.Question != "", "question", "must not be empty")
 	v.Check(len(poll.Question) <= 500, "question", "must not be more than 500 bytes long")
+	validateQuestionVariants(v, poll)
 	v.Check(len(poll.Description) <= 1000, "description", "must not be more than 1000 bytes long")
 	v.Check(poll.Options != nil, "options", "must be provided")
 	v.Check(len(poll.Options) >= 2, "options", "must contain at least two options")
+	v.Check(len(poll.Options) <= MaxOptions, "options", "must not contain more than 100 options")
 	var optValues []string
 	var optPositions []int
 	for _, opt := range poll.Options {
 		optValues = append(optValues, opt.Value)
 		optPositions = append(optPositions, opt.Position)
 	}
-	v.Check(validator.Unique(optValues), "options", "must not contain duplicate values")
+	validateOptionValuesUnique(v, poll.Options)
 	v.Check(validator.Unique(optPositions), "options", "positions must be unique")
 	for _, o := range optValues {
 		v.Check(o != "", "options", "option values must not be empty")
 		v.Check(len(o) <= 500, "options", "option value must not be more than 500 bytes long")
 	}
+	for _, opt := range poll.Options {
+		v.Check(len(opt.Description) <= 1000, "options", "option description must not be more than 1000 bytes long")
+		v.Check(len(opt.URL) <= 2048, "options", "option url must not be more than 2048 bytes long")
+		v.Check(opt.URL == "" || absoluteHTTPURL(opt.URL), "options", "option url must be an absolute http or https URL")
+	}
 	for _, p := range optPositions {
 		v.Check(p >= 0, "options", "position must be greater or equal to 0")
 		v.Check(p <= len(poll.Options)-1, "options", "position must not excede the number of options")
 	}
+	validateOptionGroups(v, poll)
 	if !poll.ExpiresAt.IsZero() {
 		v.Check(poll.ExpiresAt.After(
 			time.Now().Add(time.Minute)),
@@ -40,4 +83,144 @@ func ValidatePoll(v *validator.Validator, poll *Poll) {
 	v.Check(validator.PermittedValue(
 		poll.ResultsVisibility, resultsVisibilitySafelist...,
 	), "results_visibility", "invalid results_visibility value")
+	v.Check(validator.PermittedValue(
+		poll.Visibility, visibilitySafelist...,
+	), "visibility", "invalid visibility value")
+	v.Check(poll.ResultsThreshold >= 0, "results_threshold", "must not be negative")
+	v.Check(poll.ResultsThreshold <= 1000, "results_threshold", "must be a maximum of 1000")
+	v.Check(poll.MaxVotes >= 0, "max_votes", "must not be negative")
+	v.Check(validator.PermittedValue(
+		poll.TieBreak, tieBreakSafelist...,
+	), "tie_break", "invalid tie_break value")
+	v.Check(validator.PermittedValue(
+		poll.Anonymity, anonymitySafelist...,
+	), "anonymity", "invalid anonymity value")
+	v.Check(
+		len(poll.AllowedCountries) == 0 || len(poll.DeniedCountries) == 0,
+		"allowed_countries",
+		"must not be set together with denied_countries",
+	)
+	validateCountries(v, poll.AllowedCountries, "allowed_countries")
+	validateCountries(v, poll.DeniedCountries, "denied_countries")
+	ValidateTags(v, poll.Tags)
+	ValidateMetadata(v, poll.Metadata)
+	if poll.ExternalID != "" {
+		v.Check(len(poll.ExternalID) <= 100, "external_id", "must not be more than 100 bytes long")
+		v.Check(
+			validator.Matches(poll.ExternalID, externalIDRX),
+			"external_id",
+			"must only contain letters, digits, periods, colons, hyphens and underscores",
+		)
+	}
+	if poll.Language != "" {
+		v.Check(validator.PermittedValue(poll.Language, lang.Supported...), "language", "must be de, en, es, fr, it, nl or pt")
+	}
+	ValidateDemographics(v, poll.Demographics)
+	if poll.NotifyEmail != "" {
+		ValidateEmail(v, poll.NotifyEmail, "notify_email")
+	}
+}
+
+// validateOptionValuesUnique rejects options whose values only differ in case
+// or surrounding space, keyed by the duplicate's position e.g. "options.2".
+func validateOptionValuesUnique(v *validator.Validator, options []*PollOption) {
+	seen := make(map[string]bool, len(options))
+	for _, opt := range options {
+		value := normalizeOptionValue(opt.Value)
+		if seen[value] {
+			v.AddError("options", "must not contain duplicate values")
+			v.AddError(fmt.Sprintf("options.%d", opt.Position), "must not duplicate another option's value")
+		}
+		seen[value] = true
+	}
+}
+
+// validateQuestionVariants checks the other wordings of the poll's question,
+// which must differ from it and each other.
+func validateQuestionVariants(v *validator.Validator, poll *Poll) {
+	v.Check(len(poll.QuestionVariants) <= MaxQuestionVariants, "question_variants", "must not contain more than 5 variants")
+
+	wordings := []string{normalizeOptionValue(poll.Question)}
+	for _, variant := range poll.QuestionVariants {
+		wordings = append(wordings, normalizeOptionValue(variant))
+
+		v.Check(variant != "", "question_variants", "variants must not be empty")
+		v.Check(len(variant) <= 500, "question_variants", "variant must not be more than 500 bytes long")
+	}
+	v.Check(validator.Unique(wordings), "question_variants", "must differ from the question and each other")
+}
+
+// validateOptionGroups checks the poll's option groups, and that options are
+// only in groups of the poll.
+func validateOptionGroups(v *validator.Validator, poll *Poll) {
+	v.Check(len(poll.Groups) <= MaxOptionGroups, "groups", "must not contain more than 20 groups")
+
+	var ids []uuid.UUID
+	var labels []string
+	var positions []int
+	for _, group := range poll.Groups {
+		if group.ID != uuid.Nil {
+			ids = append(ids, group.ID)
+		}
+		labels = append(labels, strings.ToLower(group.Label))
+		positions = append(positions, group.Position)
+
+		v.Check(group.Label != "", "groups", "group labels must not be empty")
+		v.Check(len(group.Label) <= 100, "groups", "group label must not be more than 100 bytes long")
+		v.Check(group.Position >= 0, "groups", "position must be greater or equal to 0")
+		v.Check(group.Position <= len(poll.Groups)-1, "groups", "group position must not exceed the number of groups")
+	}
+	v.Check(validator.Unique(positions), "groups", "positions must be unique")
+	v.Check(validator.Unique(labels), "groups", "must not contain duplicate values")
+	v.Check(validator.Unique(ids), "groups", "ids must be unique")
+
+	for _, opt := range poll.Options {
+		v.Check(
+			opt.GroupID == nil || validator.PermittedValue(*opt.GroupID, ids...),
+			"options",
+			"option group must be one of the poll's groups",
+		)
+	}
+}
+
+// normalizeOptionValue is what option values are compared by, like the
+// unique index on poll_options.
+func normalizeOptionValue(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func ValidateEmail(v *validator.Validator, email string, key string) {
+	v.Check(email != "", key, "must be provided")
+	v.Check(len(email) <= 254, key, "must not be more than 254 bytes long")
+	v.Check(validator.Matches(email, validator.EmailRX), key, "must be a valid email address")
+}
+
+// ValidateTags checks a poll's tags, which are lowercase letters, digits and
+// hyphens, so they can be searched for with tag: qualifiers.
+func ValidateTags(v *validator.Validator, tags []string) {
+	v.Check(len(tags) <= 10, "tags", "must not contain more than 10 tags")
+	v.Check(validator.Unique(tags), "tags", "must not contain duplicate values")
+	for _, tag := range tags {
+		v.Check(len(tag) <= 30, "tags", "tag must not be more than 30 bytes long")
+		v.Check(validator.Matches(tag, tagRX), "tags", "tag must only contain lowercase letters, digits and hyphens")
+	}
+}
+
+// ValidateMetadata checks a poll's metadata, whose keys can be filtered by
+// in query parameters.
+func ValidateMetadata(v *validator.Validator, metadata map[string]string) {
+	v.Check(len(metadata) <= MaxMetadataKeys, "metadata", "must not contain more than 20 keys")
+	for key, value := range metadata {
+		v.Check(len(key) <= 40, "metadata", "key must not be more than 40 bytes long")
+		v.Check(validator.Matches(key, metadataKeyRX), "metadata", "key must only contain letters, digits, hyphens and underscores")
+		v.Check(len(value) <= 500, "metadata", "value must not be more than 500 bytes long")
+	}
+}
+
+func validateCountries(v *validator.Validator, countries []string, key string) {
+	v.Check(len(countries) <= 250, key, "must not contain more than 250 countries")
+	v.Check(validator.Unique(countries), key, "must not contain duplicate values")
+	for _, c := range countries {
+		v.Check(validator.Matches(c, validator.CountryCodeRX), key, "must contain ISO 3166-1 alpha-2 country codes")
+	}
 }
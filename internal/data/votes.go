@@ -0,0 +1,412 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	VoteStatusAccepted = "accepted"
+	VoteStatusSuspect  = "suspect"
+	VoteStatusRejected = "rejected"
+)
+
+type Vote struct {
+	ID        int64     `json:"id"`
+	PollID    uuid.UUID `json:"poll_id"`
+	OptionID  uuid.UUID `json:"option_id"`
+	IP        string    `json:"-"`
+	UserAgent string    `json:"user_agent"`
+	VoterName string    `json:"voter_name,omitempty"`
+	// VoterIdentity identifies voters who don't vote by IP, e.g. "slack:T1:U1".
+	VoterIdentity string `json:"-"`
+	// Demographics are the voter's answers to the poll's demographic
+	// questions, by question key.
+	Demographics map[string]string `json:"demographics,omitempty"`
+	// Source is where the voter came from, e.g. "slack".
+	Source string `json:"source,omitempty"`
+	// Variant is the wording of the question the voter was shown, see
+	// Poll.VariantFor.
+	Variant int `json:"variant,omitempty"`
+	// Ballot is the hash committing to the vote on verifiable polls, see
+	// package ballots.
+	Ballot    string    `json:"ballot,omitempty"`
+	Score     int       `json:"score"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VoteModel struct {
+	DB *pgxpool.Pool
+}
+
+// GetVoterNames returns the names voters provided, grouped by option ID.
+// Votes cast without a name are left out.
+func (v VoteModel) GetVoterNames(pollID uuid.UUID) (map[uuid.UUID][]string, error) {
+	query := `
+		SELECT option_id, voter_name
+		FROM votes
+		WHERE poll_id = $1 AND voter_name <> ''
+		ORDER BY created_at, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get voter names: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[uuid.UUID][]string)
+
+	for rows.Next() {
+		var optionID uuid.UUID
+		var name string
+		if err := rows.Scan(&optionID, &name); err != nil {
+			return nil, fmt.Errorf("get voter names - scan: %w", err)
+		}
+		names[optionID] = append(names[optionID], name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get voter names: %w", err)
+	}
+
+	return names, nil
+}
+
+// HasVoted reports whether the voter identity already voted on the poll.
+func (v VoteModel) HasVoted(pollID uuid.UUID, voterIdentity string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM votes
+			WHERE poll_id = $1 AND voter_identity = $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var voted bool
+	err := v.DB.QueryRow(ctx, query, pollID, voterIdentity).Scan(&voted)
+	if err != nil {
+		return false, fmt.Errorf("has voted: %w", err)
+	}
+
+	return voted, nil
+}
+
+// Get returns a vote on a poll, without who cast it.
+func (v VoteModel) Get(pollID uuid.UUID, id int64) (*Vote, error) {
+	query := `
+		SELECT option_id, status, created_at
+		FROM votes
+		WHERE poll_id = $1 AND id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	vote := Vote{ID: id, PollID: pollID}
+	err := v.DB.QueryRow(ctx, query, pollID, id).Scan(&vote.OptionID, &vote.Status, &vote.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get vote: %w", err)
+	}
+
+	return &vote, nil
+}
+
+// GetBallots returns the ballots of the accepted votes on a poll, in the
+// order they were cast. Only their options and ballots are set.
+func (v VoteModel) GetBallots(pollID uuid.UUID) ([]*Vote, error) {
+	query := `
+		SELECT option_id, ballot
+		FROM votes
+		WHERE poll_id = $1 AND status = $2 AND ballot <> ''
+		ORDER BY created_at, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID, VoteStatusAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("get ballots: %w", err)
+	}
+	defer rows.Close()
+
+	votes := []*Vote{}
+
+	for rows.Next() {
+		vote := Vote{PollID: pollID}
+		if err := rows.Scan(&vote.OptionID, &vote.Ballot); err != nil {
+			return nil, fmt.Errorf("get ballots - scan: %w", err)
+		}
+		votes = append(votes, &vote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get ballots: %w", err)
+	}
+
+	return votes, nil
+}
+
+// VoteStats describes when the accepted votes on a poll were cast.
+type VoteStats struct {
+	// IssuedVoteTokens is the number of vote tokens created for the poll.
+	IssuedVoteTokens int
+	// VotesPerHour is the rate of votes since the poll was created, until it
+	// expired. Polls younger than an hour count as an hour old.
+	VotesPerHour float64
+	// PeakHour is the start of the hour most votes were cast in, nil without
+	// votes.
+	PeakHour      *time.Time
+	PeakHourVotes int
+}
+
+// GetStats computes the voting statistics of a poll.
+func (v VoteModel) GetStats(pollID uuid.UUID) (*VoteStats, error) {
+	query := `
+		WITH hours AS (
+			SELECT date_trunc('hour', created_at) AS hour, count(*) AS votes
+			FROM votes
+			WHERE poll_id = $1 AND status = 'accepted'
+			GROUP BY hour
+		)
+		SELECT
+			(SELECT count(*) FROM tokens WHERE poll_id = p.id AND scope = $2),
+			(COALESCE((SELECT sum(votes) FROM hours), 0) / GREATEST(EXTRACT(EPOCH FROM (
+				CASE WHEN p.expires_at > '0001-01-02' AND p.expires_at < NOW() THEN p.expires_at ELSE NOW() END
+				- p.created_at
+			)) / 3600, 1))::float8,
+			peak.hour, COALESCE(peak.votes, 0)
+		FROM polls p
+		LEFT JOIN LATERAL (
+			SELECT hour, votes FROM hours ORDER BY votes DESC, hour ASC LIMIT 1
+		) peak ON true
+		WHERE p.id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var stats VoteStats
+	err := v.DB.QueryRow(ctx, query, pollID, ScopeVote).Scan(
+		&stats.IssuedVoteTokens,
+		&stats.VotesPerHour,
+		&stats.PeakHour,
+		&stats.PeakHourVotes,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get vote stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// GetRecent returns the votes cast on a poll since the given time, used to
+// screen new votes for abuse.
+func (v VoteModel) GetRecent(pollID uuid.UUID, since time.Time) ([]*Vote, error) {
+	query := `
+		SELECT id, option_id, ip, user_agent, created_at
+		FROM votes
+		WHERE poll_id = $1 AND created_at >= $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1000;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID, since)
+	if err != nil {
+		return nil, fmt.Errorf("get recent votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []*Vote
+
+	for rows.Next() {
+		vote := Vote{PollID: pollID}
+		var ip pgtype.Inet
+		err := rows.Scan(&vote.ID, &vote.OptionID, &ip, &vote.UserAgent, &vote.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get recent votes - scan: %w", err)
+		}
+		if ip.IPNet != nil {
+			vote.IP = ip.IPNet.IP.String()
+		}
+		votes = append(votes, &vote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get recent votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+// GetFlagged returns the votes on a poll waiting for moderation.
+func (v VoteModel) GetFlagged(pollID uuid.UUID) ([]*Vote, error) {
+	query := `
+		SELECT id, option_id, user_agent, voter_name, score, status, created_at
+		FROM votes
+		WHERE poll_id = $1 AND status = $2
+		ORDER BY created_at, id;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID, VoteStatusSuspect)
+	if err != nil {
+		return nil, fmt.Errorf("get flagged votes: %w", err)
+	}
+	defer rows.Close()
+
+	votes := []*Vote{}
+
+	for rows.Next() {
+		vote := Vote{PollID: pollID}
+		err := rows.Scan(
+			&vote.ID,
+			&vote.OptionID,
+			&vote.UserAgent,
+			&vote.VoterName,
+			&vote.Score,
+			&vote.Status,
+			&vote.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get flagged votes - scan: %w", err)
+		}
+		votes = append(votes, &vote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get flagged votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+// Moderate accepts or rejects a suspect vote. Accepted votes are added to
+// the option's vote count.
+func (v VoteModel) Moderate(pollID uuid.UUID, voteID int64, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := v.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("moderate vote: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE votes
+		SET status = $1
+		WHERE id = $2 AND poll_id = $3 AND status = $4
+		RETURNING option_id;
+	`
+
+	var optionID string
+	err = tx.QueryRow(ctx, query, status, voteID, pollID, VoteStatusSuspect).Scan(&optionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("moderate vote: %w", err)
+	}
+
+	if status == VoteStatusAccepted {
+		queryCount := `
+			UPDATE poll_options
+			SET vote_count = vote_count + 1
+			WHERE id = $1;
+		`
+		if _, err := tx.Exec(ctx, queryCount, optionID); err != nil {
+			return fmt.Errorf("moderate vote - count: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func ValidateVote(v *validator.Validator, vote *Vote) {
+	v.Check(len(vote.VoterName) <= 100, "voter_name", "must not be more than 100 bytes long")
+}
+
+// GetVariantCounts counts the accepted votes on a poll per wording of the
+// question the voters were shown and option ID.
+func (v VoteModel) GetVariantCounts(pollID uuid.UUID) (map[int]map[uuid.UUID]int, error) {
+	query := `
+		SELECT variant, option_id, count(*)
+		FROM votes
+		WHERE poll_id = $1 AND status = 'accepted'
+		GROUP BY 1, 2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := v.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get variant counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[int]map[uuid.UUID]int{}
+	for rows.Next() {
+		var variant, count int
+		var optionID uuid.UUID
+		if err := rows.Scan(&variant, &optionID, &count); err != nil {
+			return nil, fmt.Errorf("get variant counts - scan: %w", err)
+		}
+		if counts[variant] == nil {
+			counts[variant] = map[uuid.UUID]int{}
+		}
+		counts[variant][optionID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get variant counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// analyzeTimeout is how long analyzing the votes table may take, as it
+// samples rows from every partition.
+const analyzeTimeout = time.Minute
+
+// Analyze updates the planner's statistics of the votes table as a whole.
+// Autovacuum keeps those of each partition up to date, but never of the
+// partitioned table, so queries over several polls would be planned from
+// stale statistics otherwise.
+func (v VoteModel) Analyze() error {
+	ctx, cancel := context.WithTimeout(context.Background(), analyzeTimeout)
+	defer cancel()
+
+	if _, err := v.DB.Exec(ctx, "ANALYZE votes;"); err != nil {
+		return fmt.Errorf("analyze votes: %w", err)
+	}
+
+	return nil
+}
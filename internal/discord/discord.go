@@ -0,0 +1,257 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+const maxBodyBytes = 1_048_576
+
+// APIURL is the base of Discord's HTTP API, used to send follow-up messages.
+var APIURL = "https://discord.com/api/v10"
+
+var ErrInvalidSignature = errors.New("invalid discord signature")
+
+// Interaction types
+const (
+	InteractionPing             = 1
+	InteractionApplicationCmd   = 2
+	InteractionMessageComponent = 3
+)
+
+// Interaction response types
+const (
+	ResponsePong           = 1
+	ResponseChannelMessage = 4
+	ResponseUpdateMessage  = 7
+)
+
+// MaxOptions is the number of vote buttons that fit in a message, which holds
+// at most 5 action rows of 5 buttons.
+const MaxOptions = 25
+
+// button labels are limited to 80 characters
+const maxLabelLength = 80
+
+// flagEphemeral makes a message visible only to the user who triggered it.
+const flagEphemeral = 1 << 6
+
+// ParsePublicKey decodes the hex encoded public key of a Discord application.
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	decoded, err := hex.DecodeString(key)
+	if err != nil || len(decoded) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid discord public key")
+	}
+	return ed25519.PublicKey(decoded), nil
+}
+
+// Verify checks the request signature as described in
+// https://discord.com/developers/docs/interactions/overview#setting-up-an-endpoint
+// and returns the raw body.
+func Verify(publicKey ed25519.PublicKey, r *http.Request) ([]byte, error) {
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, ErrInvalidSignature
+	}
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+	if timestamp == "" {
+		return nil, ErrInvalidSignature
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read discord request: %w", err)
+	}
+
+	if !ed25519.Verify(publicKey, append([]byte(timestamp), body...), signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	return body, nil
+}
+
+type User struct {
+	ID string `json:"id"`
+}
+
+// Interaction is the subset of an interaction object the API uses.
+type Interaction struct {
+	Type          int    `json:"type"`
+	ApplicationID string `json:"application_id"`
+	Token         string `json:"token"`
+	// Member is set for interactions in a guild, User for direct messages.
+	Member *struct {
+		User User `json:"user"`
+	} `json:"member"`
+	User *User `json:"user"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"options"`
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+}
+
+// Option returns the string value of a slash command option.
+func (i Interaction) Option(name string) string {
+	for _, opt := range i.Data.Options {
+		if opt.Name == name {
+			value, _ := opt.Value.(string)
+			return value
+		}
+	}
+	return ""
+}
+
+// UserID returns the ID of the user who triggered the interaction.
+func (i Interaction) UserID() string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// VoterIdentity maps a Discord user to the identity votes are deduplicated by.
+func VoterIdentity(userID string) string {
+	return "discord:" + userID
+}
+
+// ParseVoteID splits a vote button's custom ID into poll and option IDs.
+func ParseVoteID(customID string) (pollID, optionID uuid.UUID, ok bool) {
+	value, ok := strings.CutPrefix(customID, "vote:")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	poll, option, ok := strings.Cut(value, ":")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	pollID, err := uuid.Parse(poll)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	optionID, err = uuid.Parse(option)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return pollID, optionID, true
+}
+
+// ParseOptions splits "Option one | Option two" into options.
+func ParseOptions(text string) []string {
+	var options []string
+	for _, part := range strings.Split(text, "|") {
+		options = append(options, strings.TrimSpace(part))
+	}
+	return options
+}
+
+type Response struct {
+	Type int          `json:"type"`
+	Data *MessageData `json:"data,omitempty"`
+}
+
+type MessageData struct {
+	Content    string      `json:"content"`
+	Flags      int         `json:"flags,omitempty"`
+	Components []Component `json:"components"`
+}
+
+type Component map[string]any
+
+func Ephemeral(content string) Response {
+	return Response{
+		Type: ResponseChannelMessage,
+		Data: &MessageData{Content: content, Flags: flagEphemeral, Components: []Component{}},
+	}
+}
+
+// PollMessage renders a poll with a vote button per option. Results are
+// included when they are given.
+func PollMessage(poll *data.Poll, results []*data.PollOption) *MessageData {
+	content := "**" + poll.Question + "**"
+	if poll.Description != "" {
+		content += "\n" + poll.Description
+	}
+
+	if results != nil {
+		for _, opt := range results {
+			content += fmt.Sprintf("\n%s: **%d**", opt.Value, opt.VoteCount)
+		}
+	}
+
+	components := []Component{}
+	var buttons []Component
+	for i, opt := range poll.Options {
+		if i == MaxOptions {
+			break
+		}
+		label := []rune(opt.Value)
+		if len(label) > maxLabelLength {
+			label = append(label[:maxLabelLength-1], '…')
+		}
+		buttons = append(buttons, Component{
+			"type":      2,
+			"style":     1,
+			"label":     string(label),
+			"custom_id": "vote:" + poll.ID.String() + ":" + opt.ID.String(),
+		})
+		if len(buttons) == 5 || i == len(poll.Options)-1 || i == MaxOptions-1 {
+			components = append(components, Component{"type": 1, "components": buttons})
+			buttons = nil
+		}
+	}
+
+	return &MessageData{Content: content, Components: components}
+}
+
+// FollowUp sends an ephemeral follow-up message for an interaction.
+func FollowUp(client *http.Client, interaction Interaction, content string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(MessageData{Content: content, Flags: flagEphemeral, Components: []Component{}})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/webhooks/%s/%s", APIURL, interaction.ApplicationID, interaction.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("discord follow-up: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discord follow-up: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("discord follow-up: unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestVerify(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	body := `{"type":1}`
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			timestamp: "1700000000",
+			signature: hex.EncodeToString(ed25519.Sign(privateKey, []byte("1700000000"+body))),
+		},
+		{
+			name:      "different timestamp",
+			timestamp: "1700000001",
+			signature: hex.EncodeToString(ed25519.Sign(privateKey, []byte("1700000000"+body))),
+			wantErr:   true,
+		},
+		{
+			name:      "malformed signature",
+			timestamp: "1700000000",
+			signature: "abc",
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("X-Signature-Timestamp", test.timestamp)
+			req.Header.Set("X-Signature-Ed25519", test.signature)
+
+			got, err := Verify(publicKey, req)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, but got %v", test.wantErr, err)
+			}
+			if !test.wantErr && string(got) != body {
+				t.Errorf("expected body %q, but got %q", body, got)
+			}
+		})
+	}
+}
+
+func TestParseVoteID(t *testing.T) {
+	customID := "vote:" + data.ExamplePollIDValid.String() + ":" + data.ExampleOptionID1.String()
+	pollID, optionID, ok := ParseVoteID(customID)
+	if !ok || pollID != data.ExamplePollIDValid || optionID != data.ExampleOptionID1 {
+		t.Errorf("expected the poll and option IDs, but got %s, %s, %v", pollID, optionID, ok)
+	}
+
+	if _, _, ok := ParseVoteID("other:" + strings.TrimPrefix(customID, "vote:")); ok {
+		t.Error("expected custom ID without vote prefix to be rejected")
+	}
+	if _, _, ok := ParseVoteID("vote:p1:o1"); ok {
+		t.Error("expected custom ID without poll and option IDs to be rejected")
+	}
+}
+
+func TestPollMessage(t *testing.T) {
+	poll := &data.Poll{ID: data.ExamplePollIDValid, Question: "Lunch?"}
+	for i := 0; i < 7; i++ {
+		poll.Options = append(poll.Options, &data.PollOption{ID: uuid.New(), Value: strings.Repeat("a", 100)})
+	}
+
+	msg := PollMessage(poll, nil)
+	if len(msg.Components) != 2 {
+		t.Fatalf("expected 2 action rows, but got %d", len(msg.Components))
+	}
+
+	button := msg.Components[0]["components"].([]Component)[0]
+	if label := button["label"].(string); len([]rune(label)) != maxLabelLength {
+		t.Errorf("expected label to be truncated to %d characters, but got %d", maxLabelLength, len([]rune(label)))
+	}
+}
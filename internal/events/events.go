@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+const (
+	TypePollCreated = "poll.created"
+	TypeVoteCast    = "vote.cast"
+	TypePollClosed  = "poll.closed"
+)
+
+// Event describes something that happened to a poll. Data depends on the
+// event type.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	PollID     uuid.UUID `json:"poll_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Publisher sends events to a message broker.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+func newEvent(eventType string, pollID uuid.UUID, data any) Event {
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		PollID:     pollID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+type option struct {
+	ID          uuid.UUID  `json:"id"`
+	Value       string     `json:"value"`
+	Position    int        `json:"position"`
+	Description string     `json:"description,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	GroupID     *uuid.UUID `json:"group_id,omitempty"`
+	VoteCount   *int       `json:"vote_count,omitempty"`
+}
+
+func options(opts []*data.PollOption, withCounts bool) []option {
+	out := make([]option, 0, len(opts))
+	for _, opt := range opts {
+		o := option{
+			ID:          opt.ID,
+			Value:       opt.Value,
+			Position:    opt.Position,
+			Description: opt.Description,
+			URL:         opt.URL,
+			GroupID:     opt.GroupID,
+		}
+		if withCounts {
+			count := opt.VoteCount
+			o.VoteCount = &count
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// PollCreated is published when a poll is created. The poll's token is
+// never part of the event.
+func PollCreated(poll *data.Poll) Event {
+	return newEvent(TypePollCreated, poll.ID, struct {
+		Question          string              `json:"question"`
+		Description       string              `json:"description"`
+		Options           []option            `json:"options"`
+		Groups            []*data.OptionGroup `json:"groups,omitempty"`
+		ExpiresAt         data.ExpiresAt      `json:"expires_at"`
+		ResultsVisibility string              `json:"results_visibility"`
+		Visibility        string              `json:"visibility"`
+	}{
+		Question:          poll.Question,
+		Description:       poll.Description,
+		Options:           options(poll.Options, false),
+		Groups:            poll.Groups,
+		ExpiresAt:         poll.ExpiresAt,
+		ResultsVisibility: poll.ResultsVisibility,
+		Visibility:        poll.Visibility,
+	})
+}
+
+// VoteCast is published for every recorded vote, including votes flagged
+// for moderation. Voter details are left out.
+func VoteCast(vote *data.Vote) Event {
+	return newEvent(TypeVoteCast, vote.PollID, struct {
+		VoteID   int64     `json:"vote_id"`
+		OptionID uuid.UUID `json:"option_id"`
+		Status   string    `json:"status"`
+	}{
+		VoteID:   vote.ID,
+		OptionID: vote.OptionID,
+		Status:   vote.Status,
+	})
+}
+
+// PollClosed is published once a poll has expired, with its final results.
+func PollClosed(poll *data.Poll, results []*data.PollOption) Event {
+	return newEvent(TypePollClosed, poll.ID, struct {
+		Question  string         `json:"question"`
+		ExpiresAt data.ExpiresAt `json:"expires_at"`
+		Results   []option       `json:"results"`
+	}{
+		Question:  poll.Question,
+		ExpiresAt: poll.ExpiresAt,
+		Results:   options(results, true),
+	})
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestPollCreated(t *testing.T) {
+	poll := &data.Poll{
+		ID:       data.ExamplePollIDValid,
+		Question: "Lunch?",
+		Options:  []*data.PollOption{{ID: data.ExampleOptionID1, Value: "Pizza"}, {ID: data.ExampleOptionID2, Value: "Sushi", Position: 1}},
+		Token:    "ZLCQIKYQ4MT7K2NJCRQWC4KMMU",
+	}
+
+	event := PollCreated(poll)
+	if event.Type != TypePollCreated || event.PollID != data.ExamplePollIDValid || event.ID == "" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	js, _ := json.Marshal(event)
+	if strings.Contains(string(js), poll.Token) {
+		t.Errorf("expected event not to contain the poll token, but got %s", js)
+	}
+	if strings.Contains(string(js), "vote_count") {
+		t.Errorf("expected options without vote counts, but got %s", js)
+	}
+}
+
+func TestVoteCast(t *testing.T) {
+	vote := &data.Vote{ID: 7, PollID: data.ExamplePollIDValid, OptionID: data.ExampleOptionID1, IP: "1.2.3.4", Status: data.VoteStatusAccepted}
+
+	js, _ := json.Marshal(VoteCast(vote))
+	if strings.Contains(string(js), vote.IP) {
+		t.Errorf("expected event not to contain the voter's IP, but got %s", js)
+	}
+	if !strings.Contains(string(js), `"option_id":"`+data.ExampleOptionID1.String()+`"`) {
+		t.Errorf("expected event to contain the option, but got %s", js)
+	}
+}
+
+func TestPollClosed(t *testing.T) {
+	poll := &data.Poll{ID: data.ExamplePollIDValid, Question: "Lunch?"}
+	results := []*data.PollOption{{ID: data.ExampleOptionID1, Value: "Pizza", VoteCount: 0}}
+
+	js, _ := json.Marshal(PollClosed(poll, results))
+	if !strings.Contains(string(js), `"vote_count":0`) {
+		t.Errorf("expected results to contain zero counts, but got %s", js)
+	}
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes all events to a single topic, keyed by poll ID so
+// the events of a poll stay in order. The event type is set as the "type"
+// header.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		},
+	}
+}
+
+func (k *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	js, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = k.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(event.PollID.String()),
+		Value:   js,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(event.Type)}},
+	})
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+func (k *KafkaPublisher) Close() error {
+	return k.writer.Close()
+}
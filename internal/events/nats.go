@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes events to the subject "<prefix>.<event type>",
+// e.g. "polls.vote.cast".
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSPublisher(url, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("polls"))
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}, nil
+}
+
+func (n *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	js, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if err := n.conn.Publish(n.prefix+"."+event.Type, js); err != nil {
+		return fmt.Errorf("publish %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Close flushes pending events and closes the connection.
+func (n *NATSPublisher) Close() error {
+	return n.conn.Drain()
+}
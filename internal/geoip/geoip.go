@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrInvalidIP = errors.New("invalid ip address")
+
+// Provider resolves an IP address to an ISO 3166-1 alpha-2 country code.
+// An empty code is returned when the country could not be determined.
+type Provider interface {
+	Country(ip string) (string, error)
+}
+
+// MaxMindProvider looks up countries in a local MaxMind (GeoLite2/GeoIP2)
+// country or city database.
+type MaxMindProvider struct {
+	reader *maxminddb.Reader
+}
+
+func NewMaxMindProvider(path string) (*MaxMindProvider, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database: %w", err)
+	}
+	return &MaxMindProvider{reader: reader}, nil
+}
+
+func (m *MaxMindProvider) Country(ip string) (string, error) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return "", ErrInvalidIP
+	}
+
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+
+	if err := m.reader.Lookup(parsedIP, &record); err != nil {
+		return "", fmt.Errorf("geoip lookup: %w", err)
+	}
+
+	return strings.ToUpper(record.Country.ISOCode), nil
+}
+
+func (m *MaxMindProvider) Close() error {
+	return m.reader.Close()
+}
+
+// APIProvider queries an external HTTP service. The URL must contain a single
+// %s verb which is replaced with the IP, e.g. "https://ipapi.co/%s/country/",
+// and the service must respond with the plain text country code.
+type APIProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewAPIProvider(url string) *APIProvider {
+	return &APIProvider{
+		URL:    url,
+		Client: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+func (a *APIProvider) Country(ip string) (string, error) {
+	if net.ParseIP(ip) == nil {
+		return "", ErrInvalidIP
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.Client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(a.URL, ip), nil)
+	if err != nil {
+		return "", fmt.Errorf("geoip request: %w", err)
+	}
+
+	res, err := a.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("geoip request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geoip request: unexpected status %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64))
+	if err != nil {
+		return "", fmt.Errorf("geoip response: %w", err)
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(string(body)))
+	if len(code) != 2 {
+		return "", nil
+	}
+
+	return code, nil
+}
@@ -0,0 +1,52 @@
+package geoip
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIProviderCountry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "1.1.1.1"):
+			fmt.Fprint(w, "au\n")
+		case strings.Contains(r.URL.Path, "10.0.0.1"):
+			fmt.Fprint(w, "Undefined")
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	provider := NewAPIProvider(srv.URL + "/%s/country/")
+
+	tests := []struct {
+		name        string
+		ip          string
+		expected    string
+		expectError bool
+	}{
+		{"known country", "1.1.1.1", "AU", false},
+		{"unknown country", "10.0.0.1", "", false},
+		{"invalid ip", "test", "", true},
+		{"bad status", "8.8.8.8", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			country, err := provider.Country(test.ip)
+			if !test.expectError && err != nil {
+				t.Errorf("expected no err, but got one: %q", err)
+			}
+			if test.expectError && err == nil {
+				t.Error("expected err, but didn't get one")
+			}
+			if country != test.expected {
+				t.Errorf("expected country %q, but got %q", test.expected, country)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Package lang detects the language of poll text, so it can be searched with
+// the matching Postgres text search configuration.
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Supported are the ISO 639-1 codes of the languages that can be detected.
+// The poll_search_config function of the migrations maps them to text search
+// configurations, and other languages are searched with "simple".
+var Supported = []string{"de", "en", "es", "fr", "it", "nl", "pt"}
+
+// stopWords are frequent words of each language, most of which are rare in
+// the others.
+var stopWords = map[string][]string{
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "mit", "auf", "für", "ich", "wir", "sie",
+		"es", "du", "ihr", "welche", "welcher", "welches", "wie", "was", "wann", "wo", "oder", "soll", "sollen",
+		"sollten", "am", "zum", "zur", "im", "den", "dem", "des", "bei", "nach", "von", "beste", "heute"},
+	"en": {"the", "and", "is", "are", "of", "to", "in", "for", "on", "with", "what", "which", "who", "how", "when",
+		"where", "should", "do", "does", "you", "your", "we", "our", "or", "it", "this", "that", "best", "favorite",
+		"favourite", "will", "would", "can", "today", "be", "have"},
+	"es": {"el", "la", "los", "las", "y", "es", "de", "del", "que", "en", "un", "una", "por", "para", "con", "cuál",
+		"cual", "qué", "cómo", "cuándo", "dónde", "o", "mejor", "tu", "su", "deberíamos", "hoy", "se", "al", "lo"},
+	"fr": {"le", "la", "les", "et", "est", "de", "du", "des", "un", "une", "pour", "avec", "sur", "dans", "quel",
+		"quelle", "quels", "quelles", "que", "qui", "comment", "quand", "où", "ou", "vous", "nous", "votre", "notre",
+		"meilleur", "meilleure", "il", "ce", "au", "aux"},
+	"it": {"il", "lo", "la", "gli", "le", "e", "è", "di", "del", "della", "che", "un", "una", "per", "con", "su",
+		"quale", "quali", "come", "quando", "dove", "o", "migliore", "tuo", "vostro", "dovremmo", "oggi", "non", "si"},
+	"nl": {"de", "het", "een", "en", "is", "van", "op", "voor", "met", "welke", "wat", "wie", "hoe", "wanneer",
+		"waar", "of", "moeten", "we", "jij", "je", "jullie", "beste", "vandaag", "niet", "zijn", "dit", "dat"},
+	"pt": {"o", "a", "os", "as", "e", "é", "de", "do", "da", "dos", "das", "que", "em", "um", "uma", "para", "com",
+		"qual", "quais", "como", "quando", "onde", "ou", "melhor", "você", "vocês", "nosso", "devemos", "hoje", "não"},
+}
+
+// letters are characters only used by some of the languages.
+var letters = map[rune][]string{
+	'ß': {"de"}, 'ä': {"de"}, 'ö': {"de"}, 'ü': {"de"},
+	'ñ': {"es"}, '¿': {"es"}, '¡': {"es"},
+	'ç': {"fr", "pt"}, 'è': {"fr", "it"}, 'ê': {"fr", "pt"}, 'à': {"fr", "it"}, 'œ': {"fr"},
+	'ã': {"pt"}, 'õ': {"pt"},
+}
+
+var words = func() map[string][]string {
+	words := map[string][]string{}
+	for lang, list := range stopWords {
+		for _, word := range list {
+			words[word] = append(words[word], lang)
+		}
+	}
+	return words
+}()
+
+// Detect returns the ISO 639-1 code of the supported language the texts are
+// most likely written in, or "" if there is no clear winner, e.g. for texts
+// without any frequent words.
+func Detect(texts ...string) string {
+	scores := map[string]int{}
+	for _, text := range texts {
+		text = strings.ToLower(text)
+		for _, r := range text {
+			for _, lang := range letters[r] {
+				scores[lang]++
+			}
+		}
+		for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			for _, lang := range words[word] {
+				scores[lang]++
+			}
+		}
+	}
+
+	var best string
+	var bestScore, runnerUp int
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = lang, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,32 @@
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		texts    []string
+		expected string
+	}{
+		{"english", []string{"What is the best day for the team meeting?"}, "en"},
+		{"german", []string{"Welcher Tag ist der beste für das Teammeeting?"}, "de"},
+		{"french", []string{"Quel est le meilleur jour pour la réunion d'équipe ?"}, "fr"},
+		{"spanish", []string{"¿Cuál es el mejor día para la reunión del equipo?"}, "es"},
+		{"italian", []string{"Qual è il giorno migliore per la riunione della squadra?"}, "it"},
+		{"dutch", []string{"Wat is de beste dag voor het teamoverleg?"}, "nl"},
+		{"portuguese", []string{"Qual é o melhor dia para a reunião da equipe?"}, "pt"},
+		{"options", []string{"Lunch?", "Pizza", "Sushi or the salad bar"}, "en"},
+		{"umlauts", []string{"Grüße aus München"}, "de"},
+		{"no frequent words", []string{"Pizza?"}, ""},
+		{"tie", []string{"la"}, ""},
+		{"empty", nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Detect(test.texts...); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+// Mailer sends an email rendered from one of the embedded templates. Each
+// template defines "subject", "plainBody" and "htmlBody".
+type Mailer interface {
+	Send(recipient, templateFile string, data any) error
+}
+
+type SMTPMailer struct {
+	addr   string
+	auth   smtp.Auth
+	sender string
+}
+
+// NewSMTPMailer returns a mailer sending through the given SMTP server.
+// Authentication is skipped when username is empty.
+func NewSMTPMailer(host string, port int, username, password, sender string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr:   host + ":" + strconv.Itoa(port),
+		sender: sender,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(recipient, templateFile string, data any) error {
+	msg, err := Render(m.sender, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	// retry a few times, as SMTP servers are prone to temporary failures
+	for i := 1; i <= 3; i++ {
+		err = smtp.SendMail(m.addr, m.auth, m.sender, []string{recipient}, msg)
+		if err == nil {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return fmt.Errorf("send email: %w", err)
+}
+
+// Render builds a multipart message with plain text and HTML bodies.
+func Render(sender, recipient, templateFile string, data any) ([]byte, error) {
+	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	plainBody := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(plainBody, "plainBody", data); err != nil {
+		return nil, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlBody := new(bytes.Buffer)
+	if err := htmlTmpl.ExecuteTemplate(htmlBody, "htmlBody", data); err != nil {
+		return nil, err
+	}
+
+	msg := new(bytes.Buffer)
+	body := multipart.NewWriter(msg)
+
+	fmt.Fprintf(msg, "From: %s\r\n", sender)
+	fmt.Fprintf(msg, "To: %s\r\n", recipient)
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject.String()))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", body.Boundary())
+
+	parts := []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", plainBody.Bytes()},
+		{"text/html; charset=utf-8", htmlBody.Bytes()},
+	}
+	for _, p := range parts {
+		part, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(p.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		"Question":   "Lunch <today>?",
+		"ClosedAt":   time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+		"TotalVotes": 3,
+		"Results": []map[string]any{
+			{"Value": "Pizza", "VoteCount": 2, "Percent": 66.666},
+			{"Value": "Sushi", "VoteCount": 1, "Percent": 33.333},
+		},
+		"PollURL": "https://polls.example.com/p/abc2345",
+	}
+
+	msg, err := Render("Polls <no-reply@example.com>", "jane@example.com", "poll_closed.tmpl", data)
+	if err != nil {
+		t.Fatalf("render returned an error: %s", err)
+	}
+
+	for _, want := range []string{
+		"To: jane@example.com\r\n",
+		"Subject: Your poll \"Lunch <today>?\" has closed\r\n",
+		"Content-Type: multipart/alternative; boundary=",
+		"- Pizza: 2 (66.7%)",
+		"Lunch &lt;today&gt;?",
+		`<a href="https://polls.example.com/p/abc2345">`,
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("expected message to contain %q, but got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	data := map[string]any{
+		"Frequency": "daily",
+		"Since":     time.Date(2024, 2, 25, 17, 0, 0, 0, time.UTC),
+		"Until":     time.Date(2024, 2, 26, 17, 0, 0, 0, time.UTC),
+		"NewVotes":  3,
+		"Polls": []map[string]any{
+			{"Question": "Lunch?", "NewVotes": 3, "TotalVotes": 10, "URL": "https://polls.example.com/p/abc2345"},
+		},
+	}
+
+	msg, err := Render("Polls <no-reply@example.com>", "jane@example.com", "digest.tmpl", data)
+	if err != nil {
+		t.Fatalf("render returned an error: %s", err)
+	}
+
+	for _, want := range []string{
+		"Subject: Your daily poll digest: 3 new votes\r\n",
+		"- Lunch?: 3 new, 10 in total (https://polls.example.com/p/abc2345)",
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("expected message to contain %q, but got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestRenderVoteConfirmation(t *testing.T) {
+	data := map[string]any{
+		"Question":   "Lunch?",
+		"Option":     "Pizza",
+		"ConfirmURL": "https://polls.example.com/v1/votes/confirm?token=ABC",
+		"ExpiresAt":  time.Date(2024, 2, 27, 17, 0, 0, 0, time.UTC),
+	}
+
+	msg, err := Render("Polls <no-reply@example.com>", "jane@example.com", "vote_confirmation.tmpl", data)
+	if err != nil {
+		t.Fatalf("render returned an error: %s", err)
+	}
+
+	for _, want := range []string{
+		"Subject: Confirm your vote on \"Lunch?\"\r\n",
+		"https://polls.example.com/v1/votes/confirm?token=ABC",
+		"The link expires on Feb 27, 2024 at 17:00 UTC.",
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("expected message to contain %q, but got:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package moderation checks user content, such as poll questions and options,
+// for words that shouldn't be published.
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// Action is what happens to content a provider flags.
+type Action string
+
+const (
+	// Reject refuses the content.
+	Reject Action = "reject"
+	// Flag accepts the content but holds it for review.
+	Flag Action = "flag"
+	// Mask replaces the flagged terms with asterisks.
+	Mask Action = "mask"
+)
+
+// ParseAction returns the action with the name, e.g. "flag".
+func ParseAction(name string) (Action, error) {
+	if !validator.PermittedValue(name, string(Reject), string(Flag), string(Mask)) {
+		return "", fmt.Errorf("invalid moderation action %q, must be reject, flag or mask", name)
+	}
+	return Action(name), nil
+}
+
+// Result is the outcome of checking content. Terms are the words that got it
+// flagged, as they appear in the content.
+type Result struct {
+	Flagged bool     `json:"flagged"`
+	Terms   []string `json:"terms"`
+}
+
+// Provider checks a text.
+type Provider interface {
+	Check(text string) (Result, error)
+}
+
+// WordlistProvider flags texts containing any of its words as a whole word,
+// ignoring case.
+type WordlistProvider struct {
+	rx *regexp.Regexp
+}
+
+func NewWordlistProvider(words []string) *WordlistProvider {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return &WordlistProvider{}
+	}
+
+	return &WordlistProvider{
+		rx: regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(` + strings.Join(quoted, "|") + `)(?:$|[^\p{L}\p{N}])`),
+	}
+}
+
+func (p *WordlistProvider) Check(text string) (Result, error) {
+	var result Result
+	if p.rx == nil {
+		return result, nil
+	}
+
+	// matches consume their separators, so each search starts again on the
+	// separator after the last match to find neighbouring words
+	for offset := 0; offset < len(text); {
+		loc := p.rx.FindStringSubmatchIndex(text[offset:])
+		if loc == nil {
+			break
+		}
+		result.Terms = appendUnique(result.Terms, text[offset+loc[2]:offset+loc[3]])
+		offset += loc[3]
+	}
+
+	result.Flagged = len(result.Terms) > 0
+	return result, nil
+}
+
+func appendUnique(terms []string, term string) []string {
+	for _, t := range terms {
+		if t == term {
+			return terms
+		}
+	}
+	return append(terms, term)
+}
+
+// APIProvider sends texts to an external moderation service as
+// {"text": "..."} and expects a Result in response, e.g.
+// {"flagged": true, "terms": ["darn"]}.
+type APIProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewAPIProvider(url string) *APIProvider {
+	return &APIProvider{
+		URL:    url,
+		Client: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+func (a *APIProvider) Check(text string) (Result, error) {
+	var result Result
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return result, fmt.Errorf("moderation request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.Client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return result, fmt.Errorf("moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.Client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("moderation request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("moderation request: unexpected status %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&result); err != nil {
+		return result, fmt.Errorf("moderation response: %w", err)
+	}
+
+	return result, nil
+}
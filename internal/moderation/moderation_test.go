@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWordlistProviderCheck(t *testing.T) {
+	provider := NewWordlistProvider([]string{"darn", "heck", " "})
+
+	tests := []struct {
+		text     string
+		expected Result
+	}{
+		{"darn it", Result{Flagged: true, Terms: []string{"darn"}}},
+		{"What the HECK? Darn, heck!", Result{Flagged: true, Terms: []string{"HECK", "Darn", "heck"}}},
+		{"darn darn", Result{Flagged: true, Terms: []string{"darn"}}},
+		{"darnation and checkers", Result{}},
+		{"", Result{}},
+	}
+
+	for _, test := range tests {
+		result, err := provider.Check(test.text)
+		if err != nil {
+			t.Fatalf("check %q returned an error: %s", test.text, err)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("check %q: expected %+v, but got %+v", test.text, test.expected, result)
+		}
+	}
+
+	result, _ := NewWordlistProvider(nil).Check("darn")
+	if result.Flagged {
+		t.Error("expected an empty wordlist not to flag anything")
+	}
+}
+
+func TestAPIProviderCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case strings.Contains(input.Text, "darn"):
+			w.Write([]byte(`{"flagged": true, "terms": ["darn"]}`))
+		case strings.Contains(input.Text, "error"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"flagged": false}`))
+		}
+	}))
+	defer srv.Close()
+
+	provider := NewAPIProvider(srv.URL)
+
+	tests := []struct {
+		name        string
+		text        string
+		expected    Result
+		expectError bool
+	}{
+		{"flagged", "darn it", Result{Flagged: true, Terms: []string{"darn"}}, false},
+		{"clean", "fine", Result{}, false},
+		{"bad status", "error", Result{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := provider.Check(test.text)
+			if !test.expectError && err != nil {
+				t.Errorf("expected no err, but got one: %q", err)
+			}
+			if test.expectError && err == nil {
+				t.Error("expected err, but didn't get one")
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %+v, but got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	for _, name := range []string{"reject", "flag", "mask"} {
+		if action, err := ParseAction(name); err != nil || string(action) != name {
+			t.Errorf("parse %q: expected action, but got %q, %v", name, action, err)
+		}
+	}
+	if _, err := ParseAction("delete"); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
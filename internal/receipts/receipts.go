@@ -0,0 +1,96 @@
+// Package receipts signs and verifies vote receipts, which let voters prove
+// their vote was recorded without revealing anyone else's vote.
+package receipts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidReceipt = errors.New("invalid receipt")
+
+// Receipt is what a voter is given for a vote. Its ID carries the vote's
+// details and their signature, so it can be verified without trusting the
+// voter.
+type Receipt struct {
+	ID       string    `json:"id"`
+	PollID   uuid.UUID `json:"poll_id"`
+	OptionID uuid.UUID `json:"option_id"`
+	VoteID   int64     `json:"-"`
+	CastAt   time.Time `json:"cast_at"`
+}
+
+// Sign sets the receipt's ID, the receipt's details followed by their
+// HMAC-SHA256, both base64url encoded. Times are signed to the second.
+func Sign(key []byte, receipt *Receipt) {
+	receipt.CastAt = receipt.CastAt.UTC().Truncate(time.Second)
+
+	payload := strings.Join([]string{
+		receipt.PollID.String(),
+		receipt.OptionID.String(),
+		strconv.FormatInt(receipt.VoteID, 10),
+		strconv.FormatInt(receipt.CastAt.Unix(), 10),
+	}, ":")
+
+	receipt.ID = base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signature(key, payload))
+}
+
+// Verify checks the receipt ID's signature and returns the receipt it was
+// signed for.
+func Verify(key []byte, id string) (*Receipt, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(id, ".")
+	if !ok {
+		return nil, ErrInvalidReceipt
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidReceipt
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(sig, signature(key, string(payload))) {
+		return nil, ErrInvalidReceipt
+	}
+
+	fields := strings.Split(string(payload), ":")
+	if len(fields) != 4 {
+		return nil, ErrInvalidReceipt
+	}
+	pollID, err := uuid.Parse(fields[0])
+	if err != nil {
+		return nil, ErrInvalidReceipt
+	}
+	optionID, err := uuid.Parse(fields[1])
+	if err != nil {
+		return nil, ErrInvalidReceipt
+	}
+	voteID, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidReceipt
+	}
+	castAt, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidReceipt
+	}
+
+	return &Receipt{
+		ID:       id,
+		PollID:   pollID,
+		OptionID: optionID,
+		VoteID:   voteID,
+		CastAt:   time.Unix(castAt, 0).UTC(),
+	}, nil
+}
+
+func signature(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,66 @@
+package receipts
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestSignAndVerify(t *testing.T) {
+	receipt := &Receipt{
+		PollID:   data.ExamplePollIDValid,
+		OptionID: data.ExampleOptionID1,
+		VoteID:   42,
+		CastAt:   time.Date(2024, 2, 5, 14, 35, 29, 500, time.FixedZone("CET", 3600)),
+	}
+	Sign(testKey, receipt)
+
+	got, err := Verify(testKey, receipt.ID)
+	if err != nil {
+		t.Fatalf("verify returned an error: %s", err)
+	}
+	if *got != *receipt {
+		t.Errorf("expected %+v, but got %+v", receipt, got)
+	}
+	if !got.CastAt.Equal(time.Date(2024, 2, 5, 13, 35, 29, 0, time.UTC)) {
+		t.Errorf("expected the time in UTC to the second, but got %s", got.CastAt)
+	}
+}
+
+func TestVerify_invalid(t *testing.T) {
+	receipt := &Receipt{PollID: data.ExamplePollIDValid, OptionID: data.ExampleOptionID1, VoteID: 42, CastAt: time.Now()}
+	Sign(testKey, receipt)
+	other := &Receipt{PollID: data.ExamplePollIDValid, OptionID: data.ExampleOptionID2, VoteID: 42, CastAt: time.Now()}
+	Sign(testKey, other)
+
+	payload, _, _ := strings.Cut(receipt.ID, ".")
+	_, otherSignature, _ := strings.Cut(other.ID, ".")
+	notAVote := base64.RawURLEncoding.EncodeToString([]byte("p1:o1:42:0")) + "." +
+		base64.RawURLEncoding.EncodeToString(signature(testKey, "p1:o1:42:0"))
+
+	tests := []struct {
+		name string
+		key  []byte
+		id   string
+	}{
+		{"wrong key", []byte("another key, also 32 bytes long!"), receipt.ID},
+		{"another receipt's signature", testKey, payload + "." + otherSignature},
+		{"unsigned", testKey, payload},
+		{"malformed", testKey, "a.b"},
+		{"not a poll's option", testKey, notAVote},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Verify(test.key, test.id); !errors.Is(err, ErrInvalidReceipt) {
+				t.Errorf("expected %v, but got %v", ErrInvalidReceipt, err)
+			}
+		})
+	}
+}
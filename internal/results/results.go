@@ -0,0 +1,120 @@
+// Package results computes the percentages and winners of a poll's results.
+package results
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// Tie-break rules decide the winner when options are tied for the most votes.
+const (
+	// TieBreakShared makes every tied option a winner.
+	TieBreakShared = "shared"
+	// TieBreakEarliest picks the tied option with the lowest position.
+	TieBreakEarliest = "earliest"
+	// TieBreakRandom picks a tied option at random, using the seed.
+	TieBreakRandom = "random"
+)
+
+// Option is an option's share of the votes.
+type Option struct {
+	ID        uuid.UUID
+	VoteCount int
+	// Percent of all votes, rounded to two decimals.
+	Percent float64
+	Winner  bool
+}
+
+type Summary struct {
+	TotalVotes int
+	Options    []Option
+	// Winners are the IDs of the winning options, in position order. There
+	// are none until a vote is cast.
+	Winners []uuid.UUID
+	// Margin is the difference in votes between the two options with the
+	// most votes, and MarginPercent the difference in percentage points.
+	Margin        int
+	MarginPercent float64
+}
+
+// Calculate sums up the votes on the options. An unknown tie-break rule is
+// treated as TieBreakShared.
+func Calculate(options []*data.PollOption, tieBreak string, seed int64) Summary {
+	var summary Summary
+
+	most, second := 0, 0
+	for _, opt := range options {
+		summary.TotalVotes += opt.VoteCount
+		switch {
+		case opt.VoteCount > most:
+			most, second = opt.VoteCount, most
+		case opt.VoteCount > second:
+			second = opt.VoteCount
+		}
+	}
+	summary.Margin = most - second
+	summary.MarginPercent = percent(summary.Margin, summary.TotalVotes)
+
+	var tied []*data.PollOption
+	for _, opt := range options {
+		if most > 0 && opt.VoteCount == most {
+			tied = append(tied, opt)
+		}
+	}
+	sort.SliceStable(tied, func(i, j int) bool { return tied[i].Position < tied[j].Position })
+
+	if len(tied) > 1 {
+		switch tieBreak {
+		case TieBreakEarliest:
+			tied = tied[:1]
+		case TieBreakRandom:
+			pick := rand.New(rand.NewSource(seed)).Intn(len(tied))
+			tied = tied[pick : pick+1]
+		}
+	}
+
+	winners := make(map[uuid.UUID]bool, len(tied))
+	for _, opt := range tied {
+		summary.Winners = append(summary.Winners, opt.ID)
+		winners[opt.ID] = true
+	}
+
+	summary.Options = make([]Option, 0, len(options))
+	for _, opt := range options {
+		summary.Options = append(summary.Options, Option{
+			ID:        opt.ID,
+			VoteCount: opt.VoteCount,
+			Percent:   percent(opt.VoteCount, summary.TotalVotes),
+			Winner:    winners[opt.ID],
+		})
+	}
+
+	return summary
+}
+
+// percent returns part of total as a percentage rounded to two decimals, 0
+// if total is 0.
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return Round(float64(part) / float64(total) * 100)
+}
+
+// Round rounds to two decimals.
+func Round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// Seed derives a random tie-break seed from a poll ID, so the same poll
+// always has the same winner.
+func Seed(pollID uuid.UUID) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(pollID.String()))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,129 @@
+package results
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// optionID returns the ID of the option with the letter, from a to f.
+func optionID(letter rune) uuid.UUID {
+	return uuid.MustParse("00000000-0000-0000-0000-00000000000" + string(letter))
+}
+
+func options(counts ...int) []*data.PollOption {
+	opts := make([]*data.PollOption, 0, len(counts))
+	// positions are reversed to make sure winners are ordered by position
+	for i, count := range counts {
+		opts = append(opts, &data.PollOption{
+			ID:        optionID(rune('a' + i)),
+			Position:  len(counts) - 1 - i,
+			VoteCount: count,
+		})
+	}
+	return opts
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []*data.PollOption
+		tieBreak string
+		winners  []uuid.UUID
+	}{
+		{"single winner", options(1, 5, 2), TieBreakShared, []uuid.UUID{optionID('b')}},
+		{"no votes", options(0, 0), TieBreakShared, nil},
+		{"shared", options(3, 1, 3), TieBreakShared, []uuid.UUID{optionID('c'), optionID('a')}},
+		{"earliest", options(3, 1, 3), TieBreakEarliest, []uuid.UUID{optionID('c')}},
+		{"unknown rule", options(2, 2), "coin flip", []uuid.UUID{optionID('b'), optionID('a')}},
+		{"earliest without tie", options(4, 1), TieBreakEarliest, []uuid.UUID{optionID('a')}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary := Calculate(test.options, test.tieBreak, 1)
+			if !reflect.DeepEqual(summary.Winners, test.winners) {
+				t.Errorf("expected winners %v, but got %v", test.winners, summary.Winners)
+			}
+			for _, opt := range summary.Options {
+				if want := validator.PermittedValue(opt.ID, test.winners...); opt.Winner != want {
+					t.Errorf("expected option %s winner to be %t", opt.ID, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateRandom(t *testing.T) {
+	picked := map[uuid.UUID]bool{}
+	for seed := int64(0); seed < 50; seed++ {
+		summary := Calculate(options(3, 3, 1, 3), TieBreakRandom, seed)
+		if len(summary.Winners) != 1 {
+			t.Fatalf("expected one winner, but got %v", summary.Winners)
+		}
+		if summary.Winners[0] == optionID('c') {
+			t.Fatalf("option without the most votes won")
+		}
+		again := Calculate(options(3, 3, 1, 3), TieBreakRandom, seed)
+		if !reflect.DeepEqual(summary.Winners, again.Winners) {
+			t.Fatalf("expected the same winner for seed %d, but got %v and %v", seed, summary.Winners, again.Winners)
+		}
+		picked[summary.Winners[0]] = true
+	}
+	if len(picked) != 3 {
+		t.Errorf("expected every tied option to win with some seed, but got %v", picked)
+	}
+}
+
+func TestCalculatePercent(t *testing.T) {
+	summary := Calculate(options(1, 1, 1, 0), TieBreakShared, 0)
+	if summary.TotalVotes != 3 {
+		t.Errorf("expected 3 votes, but got %d", summary.TotalVotes)
+	}
+	expected := []float64{33.33, 33.33, 33.33, 0}
+	for i, opt := range summary.Options {
+		if opt.Percent != expected[i] {
+			t.Errorf("expected option %s to have %.2f%%, but got %.2f%%", opt.ID, expected[i], opt.Percent)
+		}
+	}
+
+	if summary := Calculate(options(0, 0), TieBreakShared, 0); summary.Options[0].Percent != 0 {
+		t.Errorf("expected 0%% without votes, but got %.2f%%", summary.Options[0].Percent)
+	}
+}
+
+func TestCalculateMargin(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       []*data.PollOption
+		margin        int
+		marginPercent float64
+	}{
+		{"clear lead", options(1, 6, 3), 3, 30},
+		{"tie", options(4, 4, 2), 0, 0},
+		{"lead in last option", options(2, 0, 5), 3, 42.86},
+		{"no votes", options(0, 0), 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary := Calculate(test.options, TieBreakShared, 0)
+			if summary.Margin != test.margin || summary.MarginPercent != test.marginPercent {
+				t.Errorf("expected margin %d (%.2f), but got %d (%.2f)",
+					test.margin, test.marginPercent, summary.Margin, summary.MarginPercent)
+			}
+		})
+	}
+}
+
+func TestSeed(t *testing.T) {
+	if Seed(data.ExamplePollIDValid) != Seed(data.ExamplePollIDValid) {
+		t.Error("expected the same seed for the same poll")
+	}
+	if Seed(data.ExamplePollIDValid) == Seed(data.ExamplePollIDOrg) {
+		t.Error("expected different seeds for different polls")
+	}
+}
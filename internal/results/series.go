@@ -0,0 +1,71 @@
+package results
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// SeriesOption is an option's results across the polls of a series.
+type SeriesOption struct {
+	Value string `json:"value"`
+	// Results has an entry per poll, nil for polls without the option.
+	Results []*SeriesResult `json:"results"`
+}
+
+type SeriesResult struct {
+	VoteCount int     `json:"vote_count"`
+	Percent   float64 `json:"percent"`
+	// The deltas are the change since the previous poll, nil if it didn't
+	// have the option.
+	DeltaVotes   *int     `json:"delta_votes"`
+	DeltaPercent *float64 `json:"delta_percent"`
+}
+
+// Align matches the options of a series' polls by their value, ignoring case
+// and surrounding space, and computes how their results changed from poll to
+// poll. polls holds the options of each poll, oldest first. Options are
+// ordered by their position in the latest poll that has them.
+func Align(polls [][]*data.PollOption) []SeriesOption {
+	var aligned []SeriesOption
+	index := map[string]int{}
+
+	for i := len(polls) - 1; i >= 0; i-- {
+		options := make([]*data.PollOption, len(polls[i]))
+		copy(options, polls[i])
+		sort.SliceStable(options, func(a, b int) bool { return options[a].Position < options[b].Position })
+
+		for _, opt := range options {
+			key := strings.ToLower(strings.TrimSpace(opt.Value))
+			if _, ok := index[key]; ok {
+				continue
+			}
+			index[key] = len(aligned)
+			aligned = append(aligned, SeriesOption{
+				Value:   strings.TrimSpace(opt.Value),
+				Results: make([]*SeriesResult, len(polls)),
+			})
+		}
+	}
+
+	for i, options := range polls {
+		total := 0
+		for _, opt := range options {
+			total += opt.VoteCount
+		}
+
+		for _, opt := range options {
+			results := aligned[index[strings.ToLower(strings.TrimSpace(opt.Value))]].Results
+			result := &SeriesResult{VoteCount: opt.VoteCount, Percent: percent(opt.VoteCount, total)}
+			if i > 0 && results[i-1] != nil {
+				deltaVotes := result.VoteCount - results[i-1].VoteCount
+				deltaPercent := Round(result.Percent - results[i-1].Percent)
+				result.DeltaVotes, result.DeltaPercent = &deltaVotes, &deltaPercent
+			}
+			results[i] = result
+		}
+	}
+
+	return aligned
+}
@@ -0,0 +1,56 @@
+package results
+
+import (
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestAlign(t *testing.T) {
+	polls := [][]*data.PollOption{
+		{
+			{Value: "Pizza", Position: 0, VoteCount: 3},
+			{Value: "Salad", Position: 1, VoteCount: 1},
+		},
+		{
+			{Value: "Sushi", Position: 1, VoteCount: 2},
+			{Value: " pizza ", Position: 0, VoteCount: 2},
+		},
+	}
+
+	aligned := Align(polls)
+
+	values := []string{"pizza", "Sushi", "Salad"}
+	if len(aligned) != len(values) {
+		t.Fatalf("expected %d options, but got %d", len(values), len(aligned))
+	}
+	for i, value := range values {
+		if aligned[i].Value != value {
+			t.Errorf("expected option %d to be %q, but got %q", i, value, aligned[i].Value)
+		}
+		if len(aligned[i].Results) != len(polls) {
+			t.Errorf("expected a result per poll for %q, but got %d", value, len(aligned[i].Results))
+		}
+	}
+
+	pizza := aligned[0].Results
+	if pizza[0].VoteCount != 3 || pizza[0].Percent != 75 || pizza[0].DeltaVotes != nil {
+		t.Errorf("unexpected first result %+v", pizza[0])
+	}
+	if pizza[1].VoteCount != 2 || pizza[1].Percent != 50 || *pizza[1].DeltaVotes != -1 || *pizza[1].DeltaPercent != -25 {
+		t.Errorf("unexpected second result %+v", pizza[1])
+	}
+
+	if sushi := aligned[1].Results; sushi[0] != nil || sushi[1].DeltaVotes != nil {
+		t.Errorf("expected sushi only in the second poll without a delta, but got %+v", sushi)
+	}
+	if salad := aligned[2].Results; salad[0].VoteCount != 1 || salad[1] != nil {
+		t.Errorf("expected salad only in the first poll, but got %+v", salad)
+	}
+}
+
+func TestAlignEmpty(t *testing.T) {
+	if aligned := Align(nil); len(aligned) != 0 {
+		t.Errorf("expected no options, but got %v", aligned)
+	}
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"reflect"
+	"time"
+)
+
+// Schema is a JSON Schema, as used by OpenAPI 3.1.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Describer is implemented by types with a custom JSON encoding to describe
+// it.
+type Describer interface {
+	JSONSchema() *Schema
+}
+
+var (
+	describerType = reflect.TypeOf((*Describer)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+// For returns the schema of the value's type.
+func For(value any) *Schema {
+	return schemaOf(reflect.TypeOf(value), map[reflect.Type]bool{})
+}
+
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	switch {
+	case t.Implements(describerType):
+		return reflect.Zero(t).Interface().(Describer).JSONSchema()
+	case reflect.PointerTo(t).Implements(describerType):
+		return reflect.New(t).Interface().(Describer).JSONSchema()
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), seen)
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		// recursive types are only described once
+		if seen[t] {
+			return &Schema{Type: "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addProperties(s, t, seen)
+		return s
+	}
+
+	// interfaces can hold any value
+	return &Schema{}
+}
+
+func addProperties(s *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			addProperties(s, f.Type, seen)
+			continue
+		}
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+
+		// copied, as described schemas may be shared
+		prop := *schemaOf(f.Type, seen)
+		prop.Description = f.Tag.Get("doc")
+
+		r := parseRules(f.Tag.Get("validate"))
+		if r.required {
+			s.Required = append(s.Required, name)
+			if prop.Type == "array" {
+				one := 1
+				prop.MinItems = &one
+			}
+		}
+		switch prop.Type {
+		case "string":
+			prop.MaxLength = r.max
+		case "integer":
+			prop.Minimum, prop.Maximum = r.min, r.max
+		}
+		if r.oneOf != nil {
+			prop.Enum = r.oneOf
+		}
+		switch r.format {
+		case "email":
+			prop.Format = "email"
+		case "url":
+			prop.Format = "uri"
+		case "ip":
+			prop.Format = "ip"
+		}
+
+		s.Properties[name] = &prop
+	}
+}
@@ -0,0 +1,181 @@
+// Package schema validates request bodies against rules declared in struct
+// tags and describes them as JSON Schema for the OpenAPI spec, so the rules
+// checked and the rules documented are the same.
+//
+// Rules are declared in a validate tag, separated by commas:
+//
+//	required     the field must be provided; strings must not be blank
+//	max=N        strings at most N bytes long, numbers at most N
+//	min=N        numbers at least N
+//	oneof=a b c  strings one of the values
+//	email        strings a valid email address
+//	url          strings an absolute http or https URL
+//	ip           strings a valid IP address
+//
+// Format rules only apply to strings that are set. A doc tag describes the
+// field in the schema. Fields are named by their json tag, and structs in
+// slices are validated with keys like "options.1.value".
+package schema
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// rules are the parsed rules of a field's validate tag.
+type rules struct {
+	required bool
+	min, max *int
+	oneOf    []string
+	format   string
+}
+
+func parseRules(tag string) rules {
+	var r rules
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			r.required = true
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				panic(fmt.Sprintf("schema: invalid %s rule %q", name, rule))
+			}
+			if name == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "oneof":
+			r.oneOf = strings.Fields(arg)
+		case "email", "url", "ip":
+			r.format = name
+		default:
+			panic(fmt.Sprintf("schema: unknown rule %q", rule))
+		}
+	}
+	return r
+}
+
+// fieldName returns the name of the field in JSON, and false if the field
+// isn't encoded.
+func fieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return f.Name, true
+	}
+	return name, true
+}
+
+// Validate checks the struct, or pointer to a struct, against the rules of
+// its fields, adding an error for each field that breaks one.
+func Validate(v *validator.Validator, input any) {
+	validateStruct(v, "", reflect.Indirect(reflect.ValueOf(input)))
+}
+
+func validateStruct(v *validator.Validator, prefix string, rv reflect.Value) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			validateStruct(v, prefix, rv.Field(i))
+			continue
+		}
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		validateValue(v, prefix+name, rv.Field(i), parseRules(f.Tag.Get("validate")))
+	}
+}
+
+func validateValue(v *validator.Validator, key string, rv reflect.Value, r rules) {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			v.Check(!r.required, key, "must be provided")
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		s := rv.String()
+		if strings.TrimSpace(s) == "" {
+			v.Check(!r.required, key, "must not be empty")
+			return
+		}
+		if r.max != nil {
+			v.Check(len(s) <= *r.max, key, fmt.Sprintf("must not be more than %d bytes long", *r.max))
+		}
+		if r.oneOf != nil {
+			v.Check(validator.PermittedValue(s, r.oneOf...), key, "must be "+list(r.oneOf))
+		}
+		switch r.format {
+		case "email":
+			v.Check(validator.Matches(s, validator.EmailRX), key, "must be a valid email address")
+		case "url":
+			u, err := url.Parse(s)
+			v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+				key, "must be an absolute http or https URL")
+		case "ip":
+			v.Check(net.ParseIP(s) != nil, key, "must be a valid IP address")
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int()
+		if r.min != nil && n < int64(*r.min) {
+			switch *r.min {
+			case 0:
+				v.AddError(key, "must not be negative")
+			case 1:
+				v.AddError(key, "must be greater than zero")
+			default:
+				v.AddError(key, fmt.Sprintf("must be at least %d", *r.min))
+			}
+		}
+		if r.max != nil {
+			v.Check(n <= int64(*r.max), key, fmt.Sprintf("must be a maximum of %d", *r.max))
+		}
+
+	case reflect.Slice:
+		if rv.Len() == 0 {
+			v.Check(!r.required, key, "must be provided")
+			return
+		}
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() == reflect.Struct {
+				validateStruct(v, key+"."+strconv.Itoa(i)+".", elem)
+			}
+		}
+
+	case reflect.Map:
+		v.Check(!r.required || rv.Len() > 0, key, "must be provided")
+
+	case reflect.Struct:
+		v.Check(!r.required || !rv.IsZero(), key, "must be provided")
+	}
+}
+
+// list joins the values like "a, b or c".
+func list(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+)
+
+type testItem struct {
+	ID       string `json:"id" validate:"required"`
+	Position int    `json:"position" validate:"min=0"`
+}
+
+type testInput struct {
+	Name     string     `json:"name" validate:"required,max=5" doc:"the name"`
+	Kind     string     `json:"kind" validate:"oneof=a b c"`
+	Email    string     `json:"email" validate:"email"`
+	Hook     string     `json:"hook" validate:"url"`
+	IP       string     `json:"ip" validate:"ip"`
+	Count    int        `json:"count" validate:"min=1,max=10"`
+	Into     *string    `json:"into" validate:"required"`
+	Items    []testItem `json:"items"`
+	At       time.Time  `json:"at"`
+	Internal string     `json:"-" validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	into := "x"
+	valid := func() testInput {
+		return testInput{Name: "Ann", Count: 1, Into: &into}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(*testInput)
+		expected map[string]string
+	}{
+		{"valid", func(in *testInput) {}, map[string]string{}},
+		{"blank", func(in *testInput) { in.Name = " " }, map[string]string{"name": "must not be empty"}},
+		{"too long", func(in *testInput) { in.Name = "Annabel" }, map[string]string{"name": "must not be more than 5 bytes long"}},
+		{"not one of", func(in *testInput) { in.Kind = "d" }, map[string]string{"kind": "must be a, b or c"}},
+		{"email", func(in *testInput) { in.Email = "ann" }, map[string]string{"email": "must be a valid email address"}},
+		{"url", func(in *testInput) { in.Hook = "ftp://example.com" }, map[string]string{"hook": "must be an absolute http or https URL"}},
+		{"ip", func(in *testInput) { in.IP = "1.2.3" }, map[string]string{"ip": "must be a valid IP address"}},
+		{"below min", func(in *testInput) { in.Count = 0 }, map[string]string{"count": "must be greater than zero"}},
+		{"above max", func(in *testInput) { in.Count = 11 }, map[string]string{"count": "must be a maximum of 10"}},
+		{"missing", func(in *testInput) { in.Into = nil }, map[string]string{"into": "must be provided"}},
+		{
+			"items",
+			func(in *testInput) { in.Items = []testItem{{ID: "1"}, {Position: -1}} },
+			map[string]string{"items.1.id": "must not be empty", "items.1.position": "must not be negative"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := valid()
+			test.change(&input)
+
+			v := validator.New()
+			Validate(v, &input)
+
+			got, _ := json.Marshal(v.Errors)
+			want, _ := json.Marshal(test.expected)
+			if string(got) != string(want) {
+				t.Errorf("expected errors %s, but got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	s := For(testInput{})
+
+	if strings.Join(s.Required, ",") != "name,into" {
+		t.Errorf("expected name and into to be required, but got %v", s.Required)
+	}
+	if _, ok := s.Properties["Internal"]; ok {
+		t.Error("expected fields left out of JSON to be left out")
+	}
+
+	name := s.Properties["name"]
+	if name.Type != "string" || *name.MaxLength != 5 || name.Description != "the name" {
+		t.Errorf("unexpected name schema %+v", name)
+	}
+	if strings.Join(s.Properties["kind"].Enum, " ") != "a b c" {
+		t.Errorf("expected kind's values, but got %v", s.Properties["kind"].Enum)
+	}
+	if s.Properties["email"].Format != "email" || s.Properties["at"].Format != "date-time" {
+		t.Error("expected formats of the email and time")
+	}
+	if count := s.Properties["count"]; *count.Minimum != 1 || *count.Maximum != 10 {
+		t.Errorf("unexpected count schema %+v", count)
+	}
+
+	items := s.Properties["items"]
+	if items.Type != "array" || items.Items.Properties["position"].Type != "integer" {
+		t.Errorf("unexpected items schema %+v", items)
+	}
+}
+
+type node struct {
+	Children []node `json:"children"`
+}
+
+type described struct{}
+
+func (described) JSONSchema() *Schema { return &Schema{Type: "string", Format: "custom"} }
+
+func TestForRecursiveAndDescribed(t *testing.T) {
+	s := For(struct {
+		Tree   node      `json:"tree"`
+		Custom described `json:"custom"`
+	}{})
+
+	if s.Properties["tree"].Properties["children"].Items.Type != "object" {
+		t.Error("expected the recursive type to be described once")
+	}
+	if s.Properties["custom"].Format != "custom" {
+		t.Error("expected the type to describe itself")
+	}
+}
@@ -0,0 +1,109 @@
+// Package search parses the query syntax of poll searches into a Postgres
+// tsquery and the qualifiers that filter the results.
+//
+// Terms are matched as whole words and all of them must match, unless they
+// are separated by OR. Quoted terms match as a phrase:
+//
+//	"team meeting" OR standup tag:work status:open
+//
+// tag: qualifiers limit results to polls with all of the tags, status:
+// qualifiers to polls with any of the statuses and lang: qualifiers to polls
+// in any of the languages.
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ivcp/polls/internal/lang"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// Statuses polls can be searched by.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+	StatusPaused = "paused"
+)
+
+var statuses = []string{StatusOpen, StatusClosed, StatusPaused}
+
+// Query is a parsed search.
+type Query struct {
+	// TSQuery is the terms as a tsquery, to be parsed with the text search
+	// configuration of each poll's language. It is empty if there are none.
+	TSQuery   string
+	Tags      []string
+	Statuses  []string
+	Languages []string
+}
+
+// Parse parses the search q, adding errors for invalid qualifiers to v
+// under key.
+func Parse(v *validator.Validator, key string, q string) Query {
+	var query Query
+	var terms strings.Builder
+	op := "&"
+
+	addTerm := func(term string) {
+		words := strings.FieldsFunc(strings.ToLower(term), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			return
+		}
+		if terms.Len() > 0 {
+			terms.WriteString(" " + op + " ")
+		}
+		terms.WriteString(strings.Join(words, " <-> "))
+		op = "&"
+	}
+
+	for rest := strings.TrimSpace(q); rest != ""; rest = strings.TrimSpace(rest) {
+		if rest[0] == '"' {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			addTerm(phrase)
+			rest = after
+			continue
+		}
+
+		end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end == -1 {
+			end = len(rest)
+		}
+		token := rest[:end]
+		rest = rest[end:]
+
+		name, value, qualified := strings.Cut(token, ":")
+		switch {
+		case token == "OR":
+			if terms.Len() > 0 {
+				op = "|"
+			}
+		case token == "AND":
+		case qualified && strings.EqualFold(name, "tag"):
+			if value != "" {
+				query.Tags = append(query.Tags, strings.ToLower(value))
+			}
+		case qualified && strings.EqualFold(name, "status"):
+			value = strings.ToLower(value)
+			if !validator.PermittedValue(value, statuses...) {
+				v.AddError(key, "status must be open, closed or paused")
+				continue
+			}
+			query.Statuses = append(query.Statuses, value)
+		case qualified && strings.EqualFold(name, "lang"):
+			value = strings.ToLower(value)
+			if !validator.PermittedValue(value, lang.Supported...) {
+				v.AddError(key, "lang must be de, en, es, fr, it, nl or pt")
+				continue
+			}
+			query.Languages = append(query.Languages, value)
+		default:
+			addTerm(token)
+		}
+	}
+
+	query.TSQuery = terms.String()
+	return query
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        string
+		expected Query
+		err      string
+	}{
+		{"empty", "  ", Query{}, ""},
+		{"words", "Team  meeting", Query{TSQuery: "team & meeting"}, ""},
+		{"phrase", `"team meeting" agenda`, Query{TSQuery: "team <-> meeting & agenda"}, ""},
+		{"unclosed phrase", `agenda "team meeting`, Query{TSQuery: "agenda & team <-> meeting"}, ""},
+		{"or", "lunch OR dinner AND drinks", Query{TSQuery: "lunch | dinner & drinks"}, ""},
+		{"dangling operators", "OR lunch OR", Query{TSQuery: "lunch"}, ""},
+		{"lowercase or is a word", "lunch or dinner", Query{TSQuery: "lunch & or & dinner"}, ""},
+		{"punctuation", "it's tabs/spaces & (go)!", Query{TSQuery: "it <-> s & tabs <-> spaces & go"}, ""},
+		{"operators are not injected", `a|b:* !c 'd'`, Query{TSQuery: "a <-> b & c & d"}, ""},
+		{
+			"qualifiers",
+			"tag:Work standup status:OPEN tag:team status:paused",
+			Query{TSQuery: "standup", Tags: []string{"work", "team"}, Statuses: []string{"open", "paused"}},
+			"",
+		},
+		{"languages", "lunch lang:DE lang:fr", Query{TSQuery: "lunch", Languages: []string{"de", "fr"}}, ""},
+		{"empty tag", "tag: lunch", Query{TSQuery: "lunch"}, ""},
+		{"invalid status", "status:deleted", Query{}, "status must be open, closed or paused"},
+		{"unsupported language", "lang:sv", Query{}, "lang must be de, en, es, fr, it, nl or pt"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := validator.New()
+			got := Parse(v, "q", test.q)
+
+			if test.err != "" {
+				if v.Errors["q"] != test.err {
+					t.Errorf("expected error %q, but got %v", test.err, v.Errors)
+				}
+				return
+			}
+			if !v.Valid() {
+				t.Fatalf("expected no errors, but got %v", v.Errors)
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %+v, but got %+v", test.expected, got)
+			}
+		})
+	}
+}
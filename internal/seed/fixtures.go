@@ -0,0 +1,41 @@
+package seed
+
+type template struct {
+	question    string
+	description string
+	options     []string
+}
+
+var templates = []template{
+	{"Favourite color?", "", []string{"Red", "Green", "Blue", "Yellow", "Purple", "Orange"}},
+	{"Best day for the team meeting?", "We'll keep it to 30 minutes.", []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}},
+	{"Tabs or spaces?", "", []string{"Tabs", "Spaces"}},
+	{"Where should we go for lunch?", "Friday team lunch, the company pays.", []string{"Pizza", "Sushi", "Burgers", "Tacos", "Thai", "Salad bar"}},
+	{"How did you hear about us?", "", []string{"Search engine", "Social media", "A friend", "Podcast", "Newsletter", "Other"}},
+	{"Which feature should we build next?", "Help us plan the next quarter.", []string{"Dark mode", "Mobile app", "Integrations", "Offline support", "Better search"}},
+	{"How would you rate the conference?", "", []string{"Excellent", "Good", "Average", "Poor", "Terrible"}},
+	{"Which programming language do you use most?", "", []string{"Go", "Python", "JavaScript", "Java", "Rust", "C#", "TypeScript"}},
+	{"Where should the offsite be?", "Three days in early spring.", []string{"Lisbon", "Berlin", "Prague", "Barcelona", "Split"}},
+	{"What time works for the weekly sync?", "All times are UTC.", []string{"08:00", "10:00", "13:00", "15:00", "17:00"}},
+	{"Cats or dogs?", "", []string{"Cats", "Dogs", "Both", "Neither"}},
+	{"How do you get to work?", "", []string{"Car", "Bike", "Public transport", "Walking", "I work from home"}},
+}
+
+var resultsVisibilities = []string{"always", "always", "always", "after_vote", "after_deadline"}
+
+var tieBreaks = []string{"shared", "shared", "earliest", "random"}
+
+var anonymities = []string{"anonymous", "anonymous", "anonymous", "names_visible_to_owner", "public"}
+
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+}
+
+var names = []string{
+	"Ana", "Ben", "Chloe", "David", "Elena", "Filip", "Grace", "Hugo", "Ivana", "Jack",
+	"Kate", "Luka", "Maria", "Nina", "Oscar", "Petra", "Sam", "Tara", "Vito", "Zoe",
+}
@@ -0,0 +1,200 @@
+// Package seed fills the database with generated polls and votes for demos
+// and load testing.
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// Config sets how much data is generated. Votes per poll follow a long tailed
+// distribution around VotesPerPoll, so most polls get a few votes and some get
+// many, and within a poll a few options get most of the votes.
+type Config struct {
+	Polls        int
+	MinOptions   int
+	MaxOptions   int
+	VotesPerPoll int
+	// ExpiredRatio is the share of polls whose deadline has already passed.
+	ExpiredRatio float64
+	// Seed makes the generated data reproducible.
+	Seed int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Polls:        10,
+		MinOptions:   2,
+		MaxOptions:   5,
+		VotesPerPoll: 50,
+		ExpiredRatio: 0.2,
+		Seed:         time.Now().UnixNano(),
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.Polls < 0:
+		return errors.New("polls must not be negative")
+	case c.MinOptions < 2:
+		return errors.New("min options must be at least 2")
+	case c.MaxOptions < c.MinOptions:
+		return errors.New("max options must not be less than min options")
+	case c.VotesPerPoll < 0:
+		return errors.New("votes per poll must not be negative")
+	case c.ExpiredRatio < 0 || c.ExpiredRatio > 1:
+		return errors.New("expired ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// Summary counts the records a run created.
+type Summary struct {
+	Polls   int
+	Options int
+	Votes   int
+}
+
+type Seeder struct {
+	models data.Models
+	config Config
+	rand   *rand.Rand
+}
+
+func New(models data.Models, config Config) *Seeder {
+	return &Seeder{
+		models: models,
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+	}
+}
+
+// Run generates and inserts the polls with their votes.
+func (s *Seeder) Run() (Summary, error) {
+	var summary Summary
+
+	if err := s.config.Validate(); err != nil {
+		return summary, err
+	}
+
+	for i := 0; i < s.config.Polls; i++ {
+		poll := s.Poll()
+
+		token, err := data.GenerateToken()
+		if err != nil {
+			return summary, err
+		}
+		if err := s.models.Polls.Insert(poll, token.Hash); err != nil {
+			return summary, fmt.Errorf("seed poll: %w", err)
+		}
+		summary.Polls++
+		summary.Options += len(poll.Options)
+
+		for _, vote := range s.Votes(poll) {
+			if err := s.models.PollOptions.Vote(vote); err != nil {
+				return summary, fmt.Errorf("seed vote: %w", err)
+			}
+			summary.Votes++
+		}
+	}
+
+	return summary, nil
+}
+
+// Poll generates a poll from a random template.
+func (s *Seeder) Poll() *data.Poll {
+	template := templates[s.rand.Intn(len(templates))]
+
+	count := s.config.MinOptions + s.rand.Intn(s.config.MaxOptions-s.config.MinOptions+1)
+	values := make([]string, len(template.options))
+	copy(values, template.options)
+	s.rand.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	for len(values) < count {
+		values = append(values, fmt.Sprintf("Option %d", len(values)+1))
+	}
+
+	options := make([]*data.PollOption, 0, count)
+	for position, value := range values[:count] {
+		options = append(options, &data.PollOption{Value: value, Position: position})
+	}
+
+	poll := &data.Poll{
+		Question:          template.question,
+		Description:       template.description,
+		Options:           options,
+		ResultsVisibility: s.pick(resultsVisibilities),
+		Visibility:        data.VisibilityPublic,
+		TieBreak:          s.pick(tieBreaks),
+		Anonymity:         s.pick(anonymities),
+	}
+
+	switch {
+	case s.rand.Float64() < s.config.ExpiredRatio:
+		poll.ExpiresAt = data.ExpiresAt{Time: time.Now().Add(-time.Minute - s.duration(30*24*time.Hour))}
+	case s.rand.Float64() < 0.5:
+		poll.ExpiresAt = data.ExpiresAt{Time: time.Now().Add(time.Hour + s.duration(14*24*time.Hour))}
+	}
+
+	return poll
+}
+
+// Votes generates the votes for an inserted poll. Their number is drawn from
+// an exponential distribution and options are picked by Zipf-like weights, so
+// results are lopsided like those of real polls.
+func (s *Seeder) Votes(poll *data.Poll) []*data.Vote {
+	count := int(s.rand.ExpFloat64() * float64(s.config.VotesPerPoll))
+
+	// the most popular option is not always the first one
+	ranks := s.rand.Perm(len(poll.Options))
+	exponent := 0.5 + s.rand.Float64()
+	weights := make([]float64, len(poll.Options))
+	var total float64
+	for i, rank := range ranks {
+		weights[i] = 1 / math.Pow(float64(rank+1), exponent)
+		total += weights[i]
+	}
+
+	votes := make([]*data.Vote, 0, count)
+	for i := 0; i < count; i++ {
+		option := poll.Options[s.weighted(weights, total)]
+
+		vote := &data.Vote{
+			PollID:    poll.ID,
+			OptionID:  option.ID,
+			IP:        fmt.Sprintf("10.%d.%d.%d", s.rand.Intn(256), s.rand.Intn(256), 1+s.rand.Intn(254)),
+			UserAgent: s.pick(userAgents),
+			Status:    data.VoteStatusAccepted,
+		}
+		if poll.Anonymity != "anonymous" {
+			vote.VoterName = fmt.Sprintf("%s %c.", s.pick(names), 'A'+rune(s.rand.Intn(26)))
+		}
+
+		votes = append(votes, vote)
+	}
+
+	return votes
+}
+
+func (s *Seeder) weighted(weights []float64, total float64) int {
+	r := s.rand.Float64() * total
+	for i, weight := range weights {
+		if r < weight {
+			return i
+		}
+		r -= weight
+	}
+	return len(weights) - 1
+}
+
+func (s *Seeder) pick(values []string) string {
+	return values[s.rand.Intn(len(values))]
+}
+
+func (s *Seeder) duration(max time.Duration) time.Duration {
+	return time.Duration(s.rand.Int63n(int64(max)))
+}
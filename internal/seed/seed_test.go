@@ -0,0 +1,150 @@
+package seed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+type recordingOptions struct {
+	data.MockPollOptionModel
+	votes []*data.Vote
+}
+
+func (r *recordingOptions) Vote(vote *data.Vote) error {
+	r.votes = append(r.votes, vote)
+	return nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Config)
+		expected bool
+	}{
+		{"default", func(c *Config) {}, true},
+		{"negative polls", func(c *Config) { c.Polls = -1 }, false},
+		{"one option", func(c *Config) { c.MinOptions = 1 }, false},
+		{"max below min", func(c *Config) { c.MinOptions, c.MaxOptions = 4, 3 }, false},
+		{"negative votes", func(c *Config) { c.VotesPerPoll = -1 }, false},
+		{"expired ratio above 1", func(c *Config) { c.ExpiredRatio = 1.5 }, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig()
+			test.modify(&config)
+			if err := config.Validate(); (err == nil) != test.expected {
+				t.Errorf("expected valid to be %t, but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestSeederPoll(t *testing.T) {
+	config := DefaultConfig()
+	config.MinOptions, config.MaxOptions = 3, 10
+	config.ExpiredRatio = 0
+	s := New(data.NewMockModels(), config)
+
+	for i := 0; i < 200; i++ {
+		poll := s.Poll()
+		if len(poll.Options) < 3 || len(poll.Options) > 10 {
+			t.Fatalf("expected 3 to 10 options, but got %d", len(poll.Options))
+		}
+
+		v := validator.New()
+		if data.ValidatePoll(v, poll); !v.Valid() {
+			t.Fatalf("expected generated poll to be valid, but got %v", v.Errors)
+		}
+	}
+}
+
+func TestSeederPollExpired(t *testing.T) {
+	config := DefaultConfig()
+	config.ExpiredRatio = 1
+	s := New(data.NewMockModels(), config)
+
+	for i := 0; i < 50; i++ {
+		poll := s.Poll()
+		if poll.ExpiresAt.IsZero() || poll.ExpiresAt.After(time.Now()) {
+			t.Fatalf("expected poll to have expired, but got %v", poll.ExpiresAt)
+		}
+	}
+}
+
+func TestSeederVotes(t *testing.T) {
+	config := DefaultConfig()
+	config.VotesPerPoll = 200
+	s := New(data.NewMockModels(), config)
+
+	// the most voted option of each poll should get well over an even share of
+	// its votes
+	var votes, topVotes int
+	for i := 0; i < 50; i++ {
+		poll := s.Poll()
+		counts := map[uuid.UUID]int{}
+		for _, option := range poll.Options {
+			option.ID = uuid.New()
+			counts[option.ID] = 0
+		}
+
+		for _, vote := range s.Votes(poll) {
+			if _, ok := counts[vote.OptionID]; !ok {
+				t.Fatalf("expected vote for an option of the poll, but got %s", vote.OptionID)
+			}
+			if (vote.VoterName != "") != (poll.Anonymity != "anonymous") {
+				t.Fatalf("unexpected voter name %q for %s poll", vote.VoterName, poll.Anonymity)
+			}
+			counts[vote.OptionID]++
+		}
+
+		top := 0
+		for _, count := range counts {
+			votes += count
+			top = max(top, count)
+		}
+		topVotes += top * len(poll.Options)
+	}
+
+	if votes == 0 {
+		t.Fatal("expected votes to be generated")
+	}
+	if float64(topVotes) < 1.3*float64(votes) {
+		t.Errorf("expected votes to be skewed towards a few options")
+	}
+}
+
+func TestSeederRun(t *testing.T) {
+	run := func() (Summary, int) {
+		options := &recordingOptions{}
+		models := data.NewMockModels()
+		models.PollOptions = options
+
+		config := DefaultConfig()
+		config.Polls = 5
+		config.Seed = 42
+
+		summary, err := New(models, config).Run()
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		return summary, len(options.votes)
+	}
+
+	summary, recorded := run()
+	if summary.Polls != 5 {
+		t.Errorf("expected 5 polls, but got %d", summary.Polls)
+	}
+	if summary.Votes != recorded {
+		t.Errorf("expected %d votes in summary, but got %d", recorded, summary.Votes)
+	}
+
+	again, _ := run()
+	if again != summary {
+		t.Errorf("expected the same seed to generate the same data, but got %+v and %+v", summary, again)
+	}
+}
@@ -0,0 +1,78 @@
+// Package service holds the rules of the API that don't depend on how a
+// request is made, so the HTTP handlers and the chat and SMS integrations
+// apply the same rules instead of each their own copy.
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// Errors of the voting rules, besides the data package's
+// ErrVoteQuotaReached, ErrPollExpired and ErrAlreadyVoted.
+var (
+	ErrPollUnavailable = errors.New("poll is no longer available")
+	ErrPollPaused      = errors.New("voting on the poll is paused")
+	ErrGeoRestricted   = errors.New("poll is restricted by country")
+)
+
+// Votable returns why votes can't be cast on the poll at now, or nil. Polls
+// that reached their vote cap are closed, but fail with ErrVoteQuotaReached
+// so voters are told why. Drafts are hidden rather than closed, so they are
+// left to the caller.
+func Votable(poll *data.Poll, now time.Time) error {
+	switch poll.Status(now) {
+	case data.StatusClosed:
+		return data.ErrVoteQuotaReached
+	case data.StatusExpired:
+		return data.ErrPollExpired
+	case data.StatusPaused:
+		return ErrPollPaused
+	}
+	return nil
+}
+
+// Voting casts the votes of voters identified by the platform they vote
+// from, e.g. a chat app or their phone number, rather than by their IP.
+type Voting struct {
+	Models data.Models
+	// Lock is held while checking whether the voter has voted and casting
+	// the vote, and is shared with the other ways of voting.
+	Lock sync.Locker
+}
+
+// Check returns why the poll can't be voted on, or nil. Hidden polls fail
+// with ErrPollUnavailable, and polls restricted by country with
+// ErrGeoRestricted, as there is no IP to check the voter's country.
+func (s Voting) Check(poll *data.Poll) error {
+	if poll.Hidden() {
+		return ErrPollUnavailable
+	}
+	if err := Votable(poll, time.Now()); err != nil {
+		return err
+	}
+	if poll.GeoRestricted() {
+		return ErrGeoRestricted
+	}
+	return nil
+}
+
+// Cast casts the vote once per voter identity, failing with ErrAlreadyVoted
+// for voters who have voted, and with the errors of PollOptions.Vote.
+func (s Voting) Cast(vote *data.Vote) error {
+	s.Lock.Lock()
+	defer s.Lock.Unlock()
+
+	voted, err := s.Models.Votes.HasVoted(vote.PollID, vote.VoterIdentity)
+	if err != nil {
+		return err
+	}
+	if voted {
+		return data.ErrAlreadyVoted
+	}
+
+	return s.Models.PollOptions.Vote(vote)
+}
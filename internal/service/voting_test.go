@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestVoting_Check(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		poll     data.Poll
+		expected error
+	}{
+		{"active", data.Poll{}, nil},
+		{"draft", data.Poll{IsDraft: true}, ErrPollUnavailable},
+		{"rejected", data.Poll{ModerationStatus: data.ModerationRejected}, ErrPollUnavailable},
+		{"vote cap reached", data.Poll{MaxVotes: 2, VotesCast: 2}, data.ErrVoteQuotaReached},
+		{"expired", data.Poll{ExpiresAt: data.ExpiresAt{Time: past}}, data.ErrPollExpired},
+		{"paused", data.Poll{PausedAt: &past}, ErrPollPaused},
+		{"expired while paused", data.Poll{PausedAt: &past, ExpiresAt: data.ExpiresAt{Time: past}}, data.ErrPollExpired},
+		{"geo restricted", data.Poll{AllowedCountries: []string{"DE"}}, ErrGeoRestricted},
+	}
+
+	voting := Voting{Models: data.NewMockModels(), Lock: &sync.Mutex{}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := voting.Check(&test.poll); !errors.Is(err, test.expected) {
+				t.Errorf("expected %v, but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestVoting_Cast(t *testing.T) {
+	tests := []struct {
+		name     string
+		vote     data.Vote
+		expected error
+	}{
+		{"vote", data.Vote{OptionID: data.ExampleOptionID1, VoterIdentity: "slack:T1:U1"}, nil},
+		{"voted", data.Vote{OptionID: data.ExampleOptionID1, VoterIdentity: data.ExampleVoterIdentity}, data.ErrAlreadyVoted},
+		{"other poll's option", data.Vote{OptionID: uuid.New(), VoterIdentity: "slack:T1:U1"}, data.ErrOptionNotInPoll},
+	}
+
+	voting := Voting{Models: data.NewMockModels(), Lock: &sync.Mutex{}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.vote.PollID = data.ExamplePollIDValid
+			if err := voting.Cast(&test.vote); !errors.Is(err, test.expected) {
+				t.Errorf("expected %v, but got %v", test.expected, err)
+			}
+		})
+	}
+}
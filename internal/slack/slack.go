@@ -0,0 +1,213 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+const maxBodyBytes = 1_048_576
+
+// requests older than this are rejected to prevent replays
+const maxRequestAge = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("invalid slack signature")
+
+// Verify checks the request signature as described in
+// https://api.slack.com/authentication/verifying-requests-from-slack and
+// returns the raw body. The request body is replaced so it can be read again.
+func Verify(signingSecret string, r *http.Request, now time.Time) ([]byte, error) {
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	signature := r.Header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return nil, ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return nil, ErrInvalidSignature
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read slack request: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(signingSecret, timestamp, body))) {
+		return nil, ErrInvalidSignature
+	}
+
+	return body, nil
+}
+
+// Sign returns the v0 signature Slack sends for a request.
+func Sign(signingSecret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseCommand splits "/poll Question? | Option one | Option two" text into
+// the question and the options.
+func ParseCommand(text string) (string, []string) {
+	parts := strings.Split(text, "|")
+	question := strings.TrimSpace(parts[0])
+
+	var options []string
+	for _, part := range parts[1:] {
+		options = append(options, strings.TrimSpace(part))
+	}
+
+	return question, options
+}
+
+// InteractionPayload is the subset of a block_actions payload the API uses.
+type InteractionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// VoterIdentity maps a Slack user to the identity votes are deduplicated by.
+func VoterIdentity(teamID, userID string) string {
+	return "slack:" + teamID + ":" + userID
+}
+
+// ParseVoteValue splits a vote button value into poll and option IDs.
+func ParseVoteValue(value string) (pollID, optionID uuid.UUID, ok bool) {
+	poll, option, ok := strings.Cut(value, "|")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	pollID, err := uuid.Parse(poll)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	optionID, err = uuid.Parse(option)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return pollID, optionID, true
+}
+
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"`
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+type Block map[string]any
+
+func Ephemeral(text string) Message {
+	return Message{ResponseType: "ephemeral", Text: text}
+}
+
+func text(kind, value string) map[string]string {
+	return map[string]string{"type": kind, "text": value}
+}
+
+// PollMessage renders a poll with a vote button per option. Results are
+// included when they are given.
+func PollMessage(poll *data.Poll, results []*data.PollOption) Message {
+	header := "*" + poll.Question + "*"
+	if poll.Description != "" {
+		header += "\n" + poll.Description
+	}
+
+	blocks := []Block{
+		{"type": "section", "text": text("mrkdwn", header)},
+	}
+
+	// an actions block holds at most 25 elements
+	var buttons []map[string]any
+	for i, opt := range poll.Options {
+		buttons = append(buttons, map[string]any{
+			"type":      "button",
+			"text":      text("plain_text", opt.Value),
+			"action_id": "vote:" + opt.ID.String(),
+			"value":     poll.ID.String() + "|" + opt.ID.String(),
+		})
+		if len(buttons) == 25 || i == len(poll.Options)-1 {
+			blocks = append(blocks, Block{"type": "actions", "elements": buttons})
+			buttons = nil
+		}
+	}
+
+	if results != nil {
+		var lines []string
+		for _, opt := range results {
+			lines = append(lines, fmt.Sprintf("%s: *%d*", opt.Value, opt.VoteCount))
+		}
+		blocks = append(blocks, Block{
+			"type":     "context",
+			"elements": []map[string]string{text("mrkdwn", strings.Join(lines, "  ·  "))},
+		})
+	}
+
+	return Message{
+		ResponseType: "in_channel",
+		Text:         poll.Question,
+		Blocks:       blocks,
+	}
+}
+
+// Respond posts a message to a response_url from a command or interaction.
+func Respond(client *http.Client, responseURL string, msg Message) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("slack respond: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack respond: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack respond: unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
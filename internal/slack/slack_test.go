@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := "command=%2Fpoll&text=Lunch%3F"
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: Sign("secret", strconv.FormatInt(now.Unix(), 10), []byte(body)),
+		},
+		{
+			name:      "wrong secret",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: Sign("other", strconv.FormatInt(now.Unix(), 10), []byte(body)),
+			wantErr:   true,
+		},
+		{
+			name:      "too old",
+			timestamp: strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10),
+			signature: Sign("secret", strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), []byte(body)),
+			wantErr:   true,
+		},
+		{
+			name:      "missing headers",
+			timestamp: "",
+			signature: "",
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("X-Slack-Request-Timestamp", test.timestamp)
+			req.Header.Set("X-Slack-Signature", test.signature)
+
+			got, err := Verify("secret", req, now)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, but got %v", test.wantErr, err)
+			}
+			if !test.wantErr && string(got) != body {
+				t.Errorf("expected body %q, but got %q", body, got)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	question, options := ParseCommand(" Lunch? | Pizza |Sushi ")
+	if question != "Lunch?" {
+		t.Errorf("expected question %q, but got %q", "Lunch?", question)
+	}
+	if len(options) != 2 || options[0] != "Pizza" || options[1] != "Sushi" {
+		t.Errorf("expected options [Pizza Sushi], but got %v", options)
+	}
+}
+
+func TestPollMessage(t *testing.T) {
+	poll := &data.Poll{ID: data.ExamplePollIDValid, Question: "Lunch?"}
+	for i := 0; i < 30; i++ {
+		poll.Options = append(poll.Options, &data.PollOption{ID: uuid.New(), Value: "Option"})
+	}
+
+	msg := PollMessage(poll, nil)
+	// a section and two actions blocks
+	if len(msg.Blocks) != 3 {
+		t.Fatalf("expected 3 blocks, but got %d", len(msg.Blocks))
+	}
+
+	msg = PollMessage(poll, poll.Options)
+	if msg.Blocks[len(msg.Blocks)-1]["type"] != "context" {
+		t.Errorf("expected results block, but got %v", msg.Blocks[len(msg.Blocks)-1])
+	}
+}
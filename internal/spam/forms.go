@@ -0,0 +1,103 @@
+package spam
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidFormToken = errors.New("invalid form token")
+
+// FormToken signs the time a form is shown, so how long it took to fill it in
+// can be told from the token it is submitted with. The token is the Unix time
+// followed by its HMAC-SHA256, both base64url encoded.
+func FormToken(key []byte, shownAt time.Time) string {
+	payload := strconv.FormatInt(shownAt.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(formSignature(key, payload))
+}
+
+// FormShownAt checks the token's signature and returns when its form was
+// shown.
+func FormShownAt(key []byte, token string) (time.Time, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return time.Time{}, ErrInvalidFormToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return time.Time{}, ErrInvalidFormToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(sig, formSignature(key, string(payload))) {
+		return time.Time{}, ErrInvalidFormToken
+	}
+
+	shownAt, err := strconv.ParseInt(string(payload), 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidFormToken
+	}
+
+	return time.Unix(shownAt, 0).UTC(), nil
+}
+
+func formSignature(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("form:" + payload))
+	return mac.Sum(nil)
+}
+
+// User agents of HTTP libraries and headless browsers, and the words crawlers
+// name themselves with, by how strongly they suggest a script.
+var userAgentMarkers = []struct {
+	marker string
+	weight int
+}{
+	{"headlesschrome", 50},
+	{"phantomjs", 50},
+	{"selenium", 50},
+	{"puppeteer", 50},
+	{"playwright", 50},
+	{"curl/", 30},
+	{"wget/", 30},
+	{"python-requests", 30},
+	{"python-urllib", 30},
+	{"aiohttp", 30},
+	{"go-http-client", 30},
+	{"java/", 30},
+	{"okhttp", 30},
+	{"libwww-perl", 30},
+	{"node-fetch", 30},
+	{"axios", 30},
+	{"scrapy", 30},
+	{"bot", 25},
+	{"crawler", 25},
+	{"spider", 25},
+}
+
+// UserAgentScore scores how likely the user agent is to be a script rather
+// than a browser. Missing user agents score 50, user agents naming an HTTP
+// library, a headless browser or a crawler score by their markers, and ones
+// that don't claim to be a browser at all score 10 more.
+func UserAgentScore(userAgent string) int {
+	if userAgent == "" {
+		return 50
+	}
+
+	ua := strings.ToLower(userAgent)
+	score := 0
+	for _, m := range userAgentMarkers {
+		if strings.Contains(ua, m.marker) {
+			score += m.weight
+		}
+	}
+	if !strings.HasPrefix(ua, "mozilla/") {
+		score += 10
+	}
+
+	return score
+}
@@ -0,0 +1,61 @@
+package spam
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFormToken(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	shownAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	token := FormToken(key, shownAt)
+
+	got, err := FormShownAt(key, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Equal(shownAt) {
+		t.Errorf("expected shown at %v, but got %v", shownAt, got)
+	}
+
+	tests := []struct {
+		name  string
+		key   []byte
+		token string
+	}{
+		{"other key", []byte("fedcba9876543210fedcba9876543210"), token},
+		{"tampered", key, "MTcwOTI5NjAwMA." + token[len(token)-43:]},
+		{"no signature", key, "MTcwOTI5NjAwMA"},
+		{"empty", key, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := FormShownAt(test.key, test.token); !errors.Is(err, ErrInvalidFormToken) {
+				t.Errorf("expected ErrInvalidFormToken, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestUserAgentScore(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  int
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64) Firefox/123.0", 0},
+		{"", 50},
+		{"curl/8.4.0", 40},
+		{"python-requests/2.31.0", 40},
+		{"Mozilla/5.0 (X11; Linux x86_64) HeadlessChrome/120.0.0.0", 50},
+		{"Mozilla/5.0 (compatible; ExampleBot/2.1)", 25},
+	}
+
+	for _, test := range tests {
+		if got := UserAgentScore(test.userAgent); got != test.expected {
+			t.Errorf("score of %q: expected %d, but got %d", test.userAgent, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package spam
+
+import (
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// Check scores a vote against the votes recently cast on the same poll.
+// A higher score means the vote is more likely to be automated.
+type Check func(vote *data.Vote, recent []*data.Vote) int
+
+// Pipeline runs every check and marks the vote as suspect once the summed
+// score reaches the threshold.
+type Pipeline struct {
+	Checks    []Check
+	Threshold int
+	// Window is how far back recent votes should be looked up.
+	Window time.Duration
+}
+
+func New(threshold int) Pipeline {
+	return Pipeline{
+		Checks: []Check{
+			SubnetBurst(5, 50),
+			SequentialUserAgents(3, 50),
+			MissingUserAgent(25),
+		},
+		Threshold: threshold,
+		Window:    10 * time.Minute,
+	}
+}
+
+// Screen sets the vote's score and status.
+func (p Pipeline) Screen(vote *data.Vote, recent []*data.Vote) {
+	score := 0
+	for _, check := range p.Checks {
+		score += check(vote, recent)
+	}
+
+	vote.Score = score
+	vote.Status = data.VoteStatusAccepted
+	if p.Threshold > 0 && score >= p.Threshold {
+		vote.Status = data.VoteStatusSuspect
+	}
+}
+
+// SubnetBurst scores a vote when at least limit recent votes came from the
+// same subnet.
+func SubnetBurst(limit, weight int) Check {
+	return func(vote *data.Vote, recent []*data.Vote) int {
+		subnet := Subnet(vote.IP)
+		if subnet == "" {
+			return 0
+		}
+
+		count := 0
+		for _, r := range recent {
+			if Subnet(r.IP) == subnet {
+				count++
+			}
+		}
+
+		if count >= limit {
+			return weight
+		}
+		return 0
+	}
+}
+
+var digitsRX = regexp.MustCompile(`[0-9]+`)
+
+// SequentialUserAgents scores a vote when the same subnet recently voted with
+// at least limit user agents that only differ in their numbers, which is
+// typical for scripts rotating through generated agent strings. Votes without
+// an IP, e.g. from kiosks and vote tokens, aren't grouped, as they have no
+// subnet in common.
+func SequentialUserAgents(limit, weight int) Check {
+	return func(vote *data.Vote, recent []*data.Vote) int {
+		subnet := Subnet(vote.IP)
+		if vote.UserAgent == "" || subnet == "" {
+			return 0
+		}
+
+		pattern := digitsRX.ReplaceAllString(vote.UserAgent, "#")
+
+		agents := map[string]bool{vote.UserAgent: true}
+		for _, r := range recent {
+			if Subnet(r.IP) != subnet {
+				continue
+			}
+			if digitsRX.ReplaceAllString(r.UserAgent, "#") == pattern {
+				agents[r.UserAgent] = true
+			}
+		}
+
+		if len(agents) >= limit {
+			return weight
+		}
+		return 0
+	}
+}
+
+// MissingUserAgent scores a vote sent without a User-Agent header.
+func MissingUserAgent(weight int) Check {
+	return func(vote *data.Vote, recent []*data.Vote) int {
+		if vote.UserAgent == "" {
+			return weight
+		}
+		return 0
+	}
+}
+
+// Subnet returns the /24 network of an IPv4 address or the /48 network of an
+// IPv6 address, or an empty string if ip is not valid.
+func Subnet(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
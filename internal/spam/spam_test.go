@@ -0,0 +1,109 @@
+package spam
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestSubnet(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"192.168.1.24", "192.168.1.0"},
+		{"2001:db8:abcd:12::1", "2001:db8:abcd::"},
+		{"invalid", ""},
+	}
+
+	for _, test := range tests {
+		if got := Subnet(test.ip); got != test.expected {
+			t.Errorf("subnet of %q: expected %q, but got %q", test.ip, test.expected, got)
+		}
+	}
+}
+
+func TestPipelineScreen(t *testing.T) {
+	browser := "Mozilla/5.0 (X11; Linux x86_64) Firefox/123.0"
+
+	burst := make([]*data.Vote, 0, 5)
+	for i := 1; i <= 5; i++ {
+		burst = append(burst, &data.Vote{IP: fmt.Sprintf("10.0.0.%d", i), UserAgent: browser})
+	}
+
+	rotating := make([]*data.Vote, 0, 2)
+	for i := 1; i <= 2; i++ {
+		rotating = append(rotating, &data.Vote{IP: "10.0.0.1", UserAgent: fmt.Sprintf("Agent/%d", i)})
+	}
+
+	// kiosk and token votes have no IP
+	withoutIP := make([]*data.Vote, 0, 2)
+	for i := 1; i <= 2; i++ {
+		withoutIP = append(withoutIP, &data.Vote{UserAgent: fmt.Sprintf("Agent/%d", i)})
+	}
+
+	tests := []struct {
+		name           string
+		vote           *data.Vote
+		recent         []*data.Vote
+		expectedStatus string
+	}{
+		{
+			name:           "regular vote",
+			vote:           &data.Vote{IP: "10.0.0.1", UserAgent: browser},
+			expectedStatus: data.VoteStatusAccepted,
+		},
+		{
+			name:           "burst from subnet",
+			vote:           &data.Vote{IP: "10.0.0.9", UserAgent: browser},
+			recent:         burst,
+			expectedStatus: data.VoteStatusSuspect,
+		},
+		{
+			name:           "burst from other subnet",
+			vote:           &data.Vote{IP: "10.0.1.9", UserAgent: browser},
+			recent:         burst,
+			expectedStatus: data.VoteStatusAccepted,
+		},
+		{
+			name:           "sequential user agents",
+			vote:           &data.Vote{IP: "10.0.0.2", UserAgent: "Agent/3"},
+			recent:         rotating,
+			expectedStatus: data.VoteStatusSuspect,
+		},
+		{
+			name:           "sequential user agents without ip",
+			vote:           &data.Vote{UserAgent: "Agent/3"},
+			recent:         withoutIP,
+			expectedStatus: data.VoteStatusAccepted,
+		},
+		{
+			name:           "missing user agent",
+			vote:           &data.Vote{IP: "10.0.0.1"},
+			expectedStatus: data.VoteStatusAccepted,
+		},
+		{
+			name:           "missing user agent in burst",
+			vote:           &data.Vote{IP: "10.0.0.9"},
+			recent:         burst[:1],
+			expectedStatus: data.VoteStatusAccepted,
+		},
+	}
+
+	pipeline := New(50)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pipeline.Screen(test.vote, test.recent)
+			if test.vote.Status != test.expectedStatus {
+				t.Errorf(
+					"expected status %q, but got %q (score %d)",
+					test.expectedStatus,
+					test.vote.Status,
+					test.vote.Score,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package storage uploads files to object storage, such as an S3 bucket or a
+// Google Cloud Storage bucket through its S3 compatible API.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/sigv4"
+)
+
+// Provider stores files under a key, replacing any file with the same key.
+type Provider interface {
+	Put(key string, contentType string, body []byte) error
+}
+
+// S3Provider stores files in a bucket of an S3 compatible service, e.g.
+// https://s3.eu-central-1.amazonaws.com or https://storage.googleapis.com
+// with HMAC keys. Requests are signed with AWS Signature Version 4 and
+// address the bucket by path, which every such service supports.
+type S3Provider struct {
+	Endpoint string
+	Bucket   string
+	Client   *http.Client
+	signer   sigv4.Signer
+}
+
+func NewS3Provider(endpoint, region, bucket, accessKey, secretKey string) (*S3Provider, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid storage endpoint %q, must be an http or https URL", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("storage bucket must be set")
+	}
+
+	return &S3Provider{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Bucket:   bucket,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		signer: sigv4.Signer{
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+			Region:    region,
+			Service:   "s3",
+		},
+	}, nil
+}
+
+func (s *S3Provider) Put(key string, contentType string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Client.Timeout)
+	defer cancel()
+
+	u := s.Endpoint + "/" + url.PathEscape(s.Bucket) + "/" + escapeKey(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("storage request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	payloadHash := sigv4.Hash(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	s.signer.Sign(req, payloadHash, time.Now())
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("storage request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("storage request: unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// escapeKey escapes the segments of an object key, keeping its slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestS3ProviderPut(t *testing.T) {
+	var path, contentType, auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, contentType, auth, body = r.URL.EscapedPath(), r.Header.Get("Content-Type"),
+			r.Header.Get("Authorization"), string(b)
+		if strings.Contains(path, "fail") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	provider, err := NewS3Provider(srv.URL+"/", "eu-central-1", "archive", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.Put("polls/a b/results.csv", "text/csv", []byte("option,votes\n")); err != nil {
+		t.Fatalf("put returned an error: %s", err)
+	}
+	if path != "/archive/polls/a%20b/results.csv" {
+		t.Errorf("expected the file to be put in the bucket, but got path %q", path)
+	}
+	if contentType != "text/csv" || body != "option,votes\n" {
+		t.Errorf("expected the file to be sent, but got %q: %q", contentType, body)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=key/") ||
+		!strings.Contains(auth, "/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, ") {
+		t.Errorf("expected a signed request, but got %q", auth)
+	}
+
+	if err := provider.Put("fail.csv", "text/csv", nil); err == nil {
+		t.Error("expected err for bad status, but didn't get one")
+	}
+
+	if _, err := NewS3Provider("storage.example.com", "auto", "archive", "key", "secret"); err == nil {
+		t.Error("expected err for endpoint without scheme")
+	}
+	if _, err := NewS3Provider("https://storage.example.com", "auto", "", "key", "secret"); err == nil {
+		t.Error("expected err for missing bucket")
+	}
+}
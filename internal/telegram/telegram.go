@@ -0,0 +1,189 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// APIURL is the base of the Bot API.
+var APIURL = "https://api.telegram.org"
+
+// VerifySecret checks the secret token Telegram sends with every webhook
+// request, set with the secret_token parameter of setWebhook.
+func VerifySecret(secret string, r *http.Request) bool {
+	got := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+type User struct {
+	ID int64 `json:"id"`
+}
+
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+// Update is the subset of an update object the API uses.
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+// ParseCommand splits "/poll Question? | Option one | Option two" into the
+// question and the options. The command may be addressed to the bot as
+// /poll@BotName.
+func ParseCommand(text string) (question string, options []string, ok bool) {
+	command, rest, _ := strings.Cut(text, " ")
+	command, _, _ = strings.Cut(command, "@")
+	if command != "/poll" {
+		return "", nil, false
+	}
+
+	parts := strings.Split(rest, "|")
+	question = strings.TrimSpace(parts[0])
+	for _, part := range parts[1:] {
+		options = append(options, strings.TrimSpace(part))
+	}
+
+	return question, options, true
+}
+
+// VoterIdentity maps a Telegram user to the identity votes are deduplicated
+// by. The chat is part of the identity, so a user can vote once in every chat
+// the poll is posted to.
+func VoterIdentity(chatID, userID int64) string {
+	return fmt.Sprintf("telegram:%d:%d", chatID, userID)
+}
+
+// Callback data is limited to 64 bytes, so vote buttons reference options by
+// position instead of ID.
+func callbackData(pollID uuid.UUID, position int) string {
+	return fmt.Sprintf("v:%s:%d", pollID, position)
+}
+
+// ParseCallbackData splits a vote button's callback data into the poll ID and
+// the option position.
+func ParseCallbackData(value string) (pollID uuid.UUID, position int, ok bool) {
+	value, ok = strings.CutPrefix(value, "v:")
+	if !ok {
+		return uuid.Nil, 0, false
+	}
+	poll, pos, ok := strings.Cut(value, ":")
+	if !ok {
+		return uuid.Nil, 0, false
+	}
+	pollID, err := uuid.Parse(poll)
+	if err != nil {
+		return uuid.Nil, 0, false
+	}
+	position, err = strconv.Atoi(pos)
+	if err != nil {
+		return uuid.Nil, 0, false
+	}
+	return pollID, position, true
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// PollMessage renders a poll as plain text with a vote button per option.
+// Results are included when they are given.
+func PollMessage(poll *data.Poll, results []*data.PollOption) (string, InlineKeyboardMarkup) {
+	lines := []string{poll.Question}
+	if poll.Description != "" {
+		lines = append(lines, poll.Description)
+	}
+
+	if results != nil {
+		lines = append(lines, "")
+		for _, opt := range results {
+			lines = append(lines, fmt.Sprintf("%s: %d", opt.Value, opt.VoteCount))
+		}
+	}
+
+	markup := InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	for _, opt := range poll.Options {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []InlineKeyboardButton{{
+			Text:         opt.Value,
+			CallbackData: callbackData(poll.ID, opt.Position),
+		}})
+	}
+
+	return strings.Join(lines, "\n"), markup
+}
+
+// Client calls Bot API methods.
+type Client struct {
+	Token string
+	HTTP  *http.Client
+}
+
+func NewClient(token string) *Client {
+	return &Client{
+		Token: token,
+		HTTP:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Call invokes a Bot API method with the given parameters.
+func (c *Client) Call(method string, params map[string]any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.HTTP.Timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/bot%s/%s", APIURL, c.Token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram %s: invalid request", method)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		// errors contain the URL, which contains the bot token
+		return fmt.Errorf("telegram %s: request failed", method)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram %s: unexpected status %d", method, res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		question string
+		options  int
+		ok       bool
+	}{
+		{"/poll Lunch? | Pizza | Sushi", "Lunch?", 2, true},
+		{"/poll@PollsBot Lunch? | Pizza", "Lunch?", 1, true},
+		{"/poll", "", 0, true},
+		{"/start", "", 0, false},
+		{"hello", "", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			question, options, ok := ParseCommand(test.text)
+			if ok != test.ok || question != test.question || len(options) != test.options {
+				t.Errorf(
+					"expected %q, %d options, %v, but got %q, %d options, %v",
+					test.question, test.options, test.ok, question, len(options), ok,
+				)
+			}
+		})
+	}
+}
+
+func TestCallbackData(t *testing.T) {
+	pollID := data.ExamplePollIDValid
+	value := callbackData(pollID, 12)
+	if len(value) > 64 {
+		t.Errorf("expected callback data to fit in 64 bytes, but got %d", len(value))
+	}
+
+	gotID, position, ok := ParseCallbackData(value)
+	if !ok || gotID != pollID || position != 12 {
+		t.Errorf("expected %s, 12, true, but got %s, %d, %v", pollID, gotID, position, ok)
+	}
+
+	if _, _, ok := ParseCallbackData("v:abc"); ok {
+		t.Error("expected callback data without position to be rejected")
+	}
+	if _, _, ok := ParseCallbackData("v:abc:12"); ok {
+		t.Error("expected callback data without a poll ID to be rejected")
+	}
+}
+
+func TestPollMessage(t *testing.T) {
+	poll := &data.Poll{
+		ID:       data.ExamplePollIDValid,
+		Question: "Lunch?",
+		Options: []*data.PollOption{
+			{ID: data.ExampleOptionID1, Value: "Pizza", Position: 0, VoteCount: 2},
+			{ID: data.ExampleOptionID2, Value: "Sushi", Position: 1, VoteCount: 1},
+		},
+	}
+
+	text, markup := PollMessage(poll, poll.Options)
+	if text != "Lunch?\n\nPizza: 2\nSushi: 1" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if len(markup.InlineKeyboard) != 2 || markup.InlineKeyboard[1][0].CallbackData != "v:"+data.ExamplePollIDValid.String()+":1" {
+		t.Errorf("unexpected keyboard %v", markup.InlineKeyboard)
+	}
+}
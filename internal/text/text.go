@@ -0,0 +1,149 @@
+// Package text normalizes the text users submit, such as poll questions,
+// descriptions and option values, before it is validated and stored.
+package text
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Filter rewrites text after it has been normalized.
+type Filter func(s string) string
+
+// Pipeline normalizes text and then runs every filter on it. The zero value
+// only normalizes.
+type Pipeline struct {
+	Filters []Filter
+}
+
+// Line normalizes and filters single line text like questions and option
+// values.
+func (p Pipeline) Line(s string) string {
+	return p.filter(NormalizeLine(s))
+}
+
+// Text normalizes and filters multi line text like descriptions.
+func (p Pipeline) Text(s string) string {
+	return p.filter(NormalizeText(s))
+}
+
+func (p Pipeline) filter(s string) string {
+	for _, filter := range p.Filters {
+		s = filter(s)
+	}
+	return s
+}
+
+// NormalizeLine drops invalid UTF-8 and control characters, collapses runs of
+// whitespace, line breaks included, into single spaces and trims the result.
+func NormalizeLine(s string) string {
+	return strings.Join(strings.Fields(clean(s, false)), " ")
+}
+
+// NormalizeText is like NormalizeLine but keeps line breaks. Lines are trimmed
+// and at most one empty line is kept between paragraphs.
+func NormalizeText(s string) string {
+	lines := strings.Split(clean(s, true), "\n")
+
+	normalized := make([]string, 0, len(lines))
+	empty := 0
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			empty++
+			if empty > 1 {
+				continue
+			}
+		} else {
+			empty = 0
+		}
+		normalized = append(normalized, line)
+	}
+
+	return strings.TrimSpace(strings.Join(normalized, "\n"))
+}
+
+// clean drops invalid UTF-8 and control and format characters, like zero
+// width spaces and bidi overrides. Tabs are turned into spaces and, if
+// newlines are kept, line endings into "\n".
+func clean(s string, newlines bool) string {
+	s = strings.ToValidUTF8(s, "")
+	if newlines {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r':
+			if newlines {
+				return '\n'
+			}
+			return ' '
+		case r == '\t':
+			return ' '
+		case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Profanity masks whole words of the list with asterisks, ignoring case.
+func Profanity(words []string) Filter {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return func(s string) string { return s }
+	}
+
+	rx := regexp.MustCompile(`(?i)(^|[^\p{L}\p{N}])(` + strings.Join(quoted, "|") + `)($|[^\p{L}\p{N}])`)
+
+	return func(s string) string {
+		// matches share their separators, so neighbouring words need
+		// another pass
+		for {
+			masked := rx.ReplaceAllStringFunc(s, func(match string) string {
+				parts := rx.FindStringSubmatch(match)
+				return parts[1] + strings.Repeat("*", utf8.RuneCountInString(parts[2])) + parts[3]
+			})
+			if masked == s {
+				return s
+			}
+			s = masked
+		}
+	}
+}
+
+// LoadWordlist reads a wordlist with one word per line. Empty lines and lines
+// starting with # are skipped.
+func LoadWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("load wordlist: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("load wordlist: %w", err)
+	}
+
+	return words, nil
+}
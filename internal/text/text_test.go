@@ -0,0 +1,111 @@
+package text
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"trim", "  Question?  ", "Question?"},
+		{"collapse whitespace", "a \t b\n\nc", "a b c"},
+		{"control characters", "a\x00b\x1bc\u200bd\u202ee", "abcde"},
+		{"invalid UTF-8", "caf\xc3\xa9 \xff\xfeok", "café ok"},
+		{"unicode spaces", "a\u00a0\u3000b", "a b"},
+		{"empty", " \n\t ", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeLine(test.input); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"keep line breaks", "first  line \r\n second\tline", "first line\nsecond line"},
+		{"limit empty lines", "a\n\n\n\n b", "a\n\nb"},
+		{"trim", "\n\n  text \n\n", "text"},
+		{"control characters", "a\x07b\n\u200dc", "ab\nc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeText(test.input); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestProfanity(t *testing.T) {
+	filter := Profanity([]string{"darn", "heck", "  "})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"darn it", "**** it"},
+		{"What the HECK?", "What the ****?"},
+		{"darn heck darn", "**** **** ****"},
+		{"darnation and checkers", "darnation and checkers"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := filter(test.input); got != test.expected {
+			t.Errorf("filter %q: expected %q, but got %q", test.input, test.expected, got)
+		}
+	}
+
+	if got := Profanity(nil)("darn"); got != "darn" {
+		t.Errorf("empty wordlist: expected text to be unchanged, but got %q", got)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	var zero Pipeline
+	if got := zero.Line("  a   b "); got != "a b" {
+		t.Errorf("zero pipeline: expected %q, but got %q", "a b", got)
+	}
+
+	p := Pipeline{Filters: []Filter{Profanity([]string{"darn"})}}
+	if got := p.Line(" darn \x00 it "); got != "**** it" {
+		t.Errorf("line: expected %q, but got %q", "**** it", got)
+	}
+	if got := p.Text("darn\n\n\nit"); got != "****\n\nit" {
+		t.Errorf("text: expected %q, but got %q", "****\n\nit", got)
+	}
+}
+
+func TestLoadWordlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# comment\ndarn\n\n  heck  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := LoadWordlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"darn", "heck"}; !reflect.DeepEqual(words, expected) {
+		t.Errorf("expected %v, but got %v", expected, words)
+	}
+
+	if _, err := LoadWordlist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected error for missing wordlist")
+	}
+}
@@ -0,0 +1,75 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Signature returns the signature Twilio sends with a webhook request in the
+// X-Twilio-Signature header, as described in
+// https://www.twilio.com/docs/usage/security#validating-requests. It signs the
+// URL Twilio requested followed by the POST parameters sorted by name.
+func Signature(authToken, url string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(url))
+	for _, key := range keys {
+		values := append([]string(nil), params[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			mac.Write([]byte(key + value))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature of a webhook request.
+func VerifySignature(authToken, url string, params url.Values, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Signature(authToken, url, params)))
+}
+
+// ParseVote reads the number of the option a text message votes for, counted
+// from 1, from "VOTE 2" or just "2". Case and spacing don't matter, as the
+// messages are typed on phone keypads.
+func ParseVote(body string) (number int, ok bool) {
+	body = strings.ToUpper(strings.TrimSpace(body))
+	body = strings.TrimSpace(strings.TrimPrefix(body, "VOTE"))
+
+	number, err := strconv.Atoi(body)
+	if err != nil || number < 1 {
+		return 0, false
+	}
+	return number, true
+}
+
+// VoterIdentity maps the sender's phone number to the identity votes are
+// deduplicated by. Numbers are hashed, so they aren't stored with the votes.
+func VoterIdentity(from string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(from)))
+	return "sms:" + hex.EncodeToString(sum[:8])
+}
+
+// Reply renders a TwiML response that answers the text message with another.
+func Reply(message string) []byte {
+	response := struct {
+		XMLName xml.Name `xml:"Response"`
+		Message string   `xml:"Message"`
+	}{Message: message}
+
+	// a struct of strings always marshals
+	body, _ := xml.Marshal(response)
+	return append([]byte(xml.Header), body...)
+}
@@ -0,0 +1,75 @@
+package twilio
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSignature(t *testing.T) {
+	// the example from Twilio's documentation
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	requestURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	expected := "0/KCTR6DLpKmkAf8muzZqo1nDgQ="
+
+	if got := Signature("12345", requestURL, params); got != expected {
+		t.Errorf("expected signature %q, but got %q", expected, got)
+	}
+	if !VerifySignature("12345", requestURL, params, expected) {
+		t.Error("expected the signature to be valid")
+	}
+
+	params.Set("Digits", "4321")
+	if VerifySignature("12345", requestURL, params, expected) {
+		t.Error("expected the signature of changed parameters to be invalid")
+	}
+}
+
+func TestParseVote(t *testing.T) {
+	tests := []struct {
+		body   string
+		number int
+		ok     bool
+	}{
+		{"VOTE 2", 2, true},
+		{" vote 3 ", 3, true},
+		{"Vote1", 1, true},
+		{"2", 2, true},
+		{"VOTE 0", 0, false},
+		{"VOTE", 0, false},
+		{"VOTE two", 0, false},
+		{"hello", 0, false},
+	}
+
+	for _, test := range tests {
+		number, ok := ParseVote(test.body)
+		if number != test.number || ok != test.ok {
+			t.Errorf("%q: expected %d, %t, but got %d, %t", test.body, test.number, test.ok, number, ok)
+		}
+	}
+}
+
+func TestVoterIdentity(t *testing.T) {
+	identity := VoterIdentity("+15550001111")
+	if identity != "sms:7a8e1bad42416f9a" {
+		t.Errorf("unexpected identity %q", identity)
+	}
+	if VoterIdentity(" +15550001111 ") != identity {
+		t.Error("expected surrounding spaces to be ignored")
+	}
+}
+
+func TestReply(t *testing.T) {
+	reply := string(Reply("Red & <Blue>"))
+
+	expected := "<Response><Message>Red &amp; &lt;Blue&gt;</Message></Response>"
+	if !strings.HasSuffix(reply, expected) {
+		t.Errorf("expected reply to end with %q, but got %q", expected, reply)
+	}
+}
@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language messages are written in. They are used as
+// the keys of their translations.
+const DefaultLanguage = "en"
+
+var (
+	mu           sync.RWMutex
+	translations = map[string]map[string]string{}
+)
+
+// RegisterTranslations adds translations of messages to a language, keyed by
+// the English message.
+func RegisterTranslations(lang string, messages map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	lang = strings.ToLower(lang)
+	if translations[lang] == nil {
+		translations[lang] = make(map[string]string, len(messages))
+	}
+	for message, translation := range messages {
+		translations[lang][message] = translation
+	}
+}
+
+// Languages returns the languages messages are available in.
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	langs := []string{DefaultLanguage}
+	for lang := range translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// Translate returns the message in the language, or the message itself if it
+// has no translation.
+func Translate(lang string, message string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if translation, ok := translations[lang][message]; ok {
+		return translation
+	}
+	return message
+}
+
+// TranslateErrors returns a copy of validation errors with their messages in
+// the language.
+func TranslateErrors(lang string, errs map[string]string) map[string]string {
+	translated := make(map[string]string, len(errs))
+	for key, message := range errs {
+		translated[key] = Translate(lang, message)
+	}
+	return translated
+}
+
+// NegotiateLanguage picks the available language preferred by an
+// Accept-Language header, e.g. "de-CH, fr;q=0.8, en;q=0.5". Regional
+// variants match their base language. DefaultLanguage is returned if none
+// of the header's languages is available.
+func NegotiateLanguage(acceptLanguage string) string {
+	available := Languages()
+
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q > bestQ && PermittedValue(lang, available...) {
+			best, bestQ = lang, q
+		}
+	}
+
+	return best
+}
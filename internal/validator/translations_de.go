@@ -0,0 +1,166 @@
+package validator
+
+func init() {
+	RegisterTranslations("de", map[string]string{
+		// validation
+		"choices must not be empty":                                                   "Antworten dürfen nicht leer sein",
+		"choices must not be more than 100 bytes long":                                "Antworten dürfen nicht länger als 100 Bytes sein",
+		"choices must not contain duplicate values":                                   "Antworten dürfen keine doppelten Werte enthalten",
+		"country restrictions are not supported by this server":                       "Länderbeschränkungen werden von diesem Server nicht unterstützt",
+		"email confirmation is not supported by this server":                          "E-Mail-Bestätigung wird von diesem Server nicht unterstützt",
+		"email digests are not supported by this server":                              "E-Mail-Zusammenfassungen werden von diesem Server nicht unterstützt",
+		"email notifications are not supported by this server":                        "E-Mail-Benachrichtigungen werden von diesem Server nicht unterstützt",
+		"email or webhook_url must be provided":                                       "email oder webhook_url muss angegeben werden",
+		"group label must not be more than 100 bytes long":                            "die Bezeichnung einer Gruppe darf nicht länger als 100 Bytes sein",
+		"group labels must not be empty":                                              "die Bezeichnungen der Gruppen dürfen nicht leer sein",
+		"group position must not exceed the number of groups":                         "die Position einer Gruppe darf die Anzahl der Gruppen nicht überschreiten",
+		"ids must be unique":                                                          "IDs müssen eindeutig sein",
+		"invalid anonymity value":                                                     "ungültiger Wert für anonymity",
+		"invalid results_visibility value":                                            "ungültiger Wert für results_visibility",
+		"invalid sort value":                                                          "ungültiger Wert für sort",
+		"invalid tie_break value":                                                     "ungültiger Wert für tie_break",
+		"invalid visibility value":                                                    "ungültiger Wert für visibility",
+		"invalid vote status":                                                         "ungültiger Stimmstatus",
+		"is already banned":                                                           "ist bereits gesperrt",
+		"is already exempt":                                                           "ist bereits ausgenommen",
+		"is bound to another poll that is still open":                                 "ist an eine andere Umfrage gebunden, die noch offen ist",
+		"is not a question of this poll":                                              "ist keine Frage dieser Umfrage",
+		"key must not be more than 40 bytes long":                                     "Schlüssel darf nicht länger als 40 Bytes sein",
+		"key must only contain letters, digits, hyphens and underscores":              "Schlüssel darf nur Buchstaben, Ziffern, Bindestriche und Unterstriche enthalten",
+		"keys must be lowercase letters, digits and underscores":                      "Schlüssel dürfen nur Kleinbuchstaben, Ziffern und Unterstriche enthalten",
+		"keys must be unique":                                                         "Schlüssel müssen eindeutig sein",
+		"keys must not be more than 50 bytes long":                                    "Schlüssel dürfen nicht länger als 50 Bytes sein",
+		"labels must not be more than 200 bytes long":                                 "Bezeichnungen dürfen nicht länger als 200 Bytes sein",
+		"lang must be de, en, es, fr, it, nl or pt":                                   "lang muss de, en, es, fr, it, nl oder pt sein",
+		"must be 26 bytes long":                                                       "muss 26 Bytes lang sein",
+		"must be a duration such as 2h or 7d":                                         "muss eine Dauer wie 2h oder 7d sein",
+		"must be a maximum of 10 million":                                             "darf höchstens 10 Millionen sein",
+		"must be a maximum of 100":                                                    "darf höchstens 100 sein",
+		"must be a maximum of 1000":                                                   "darf höchstens 1000 sein",
+		"must be a maximum of 1024":                                                   "darf höchstens 1024 sein",
+		"must be a maximum of 50":                                                     "darf höchstens 50 sein",
+		"must be a poll ID":                                                           "muss eine Umfrage-ID sein",
+		"must be a valid API key":                                                     "muss ein gültiger API-Schlüssel sein",
+		"must be a valid IANA time zone":                                              "muss eine gültige IANA-Zeitzone sein",
+		"must be a valid IP address":                                                  "muss eine gültige IP-Adresse sein",
+		"must be a valid email address":                                               "muss eine gültige E-Mail-Adresse sein",
+		"must be a valid form token, get one from /v1/polls/form-token":               "muss ein gültiges Formular-Token sein, hol dir eins von /v1/polls/form-token",
+		"must be accepted or rejected":                                                "muss accepted oder rejected sein",
+		"must be admin, editor or viewer":                                             "muss admin, editor oder viewer sein",
+		"must be an absolute http or https URL":                                       "muss eine absolute http- oder https-URL sein",
+		"must be an existing poll":                                                    "muss eine bestehende Umfrage sein",
+		"must be an integer value":                                                    "muss eine ganze Zahl sein",
+		"must be an organization ID":                                                  "muss eine Organisations-ID sein",
+		"must be approved or rejected":                                                "muss approved oder rejected sein",
+		"must be at least 2m":                                                         "muss mindestens 2m sein",
+		"must be at least 64":                                                         "muss mindestens 64 sein",
+		"must be csv or json":                                                         "muss csv oder json sein",
+		"must be de, en, es, fr, it, nl or pt":                                        "muss de, en, es, fr, it, nl oder pt sein",
+		"must be editor or viewer":                                                    "muss editor oder viewer sein",
+		"must be for the poll's options":                                              "müssen für Optionen der Umfrage sein",
+		"must be for the poll's question variants":                                    "muss für eine der Varianten der Frage sein",
+		"must be greater than zero":                                                   "muss größer als null sein",
+		"must be hourly or daily":                                                     "muss hourly oder daily sein",
+		"must be in E.164 format, e.g. +15551234567":                                  "muss im E.164-Format sein, z. B. +15551234567",
+		"must be in the future":                                                       "muss in der Zukunft liegen",
+		"must be manage or results":                                                   "muss manage oder results sein",
+		"must be manage, results, vote, preview or kiosk":                             "muss manage, results, vote, preview oder kiosk sein",
+		"must be more than a minute in the future":                                    "muss mehr als eine Minute in der Zukunft liegen",
+		"must be one of L, M, Q, H":                                                   "muss L, M, Q oder H sein",
+		"must be one of the poll's demographic questions":                             "muss eine der demografischen Fragen der Umfrage sein",
+		"must be one of the question's choices":                                       "muss eine der Antworten der Frage sein",
+		"must be png or svg":                                                          "muss png oder svg sein",
+		"must be provided":                                                            "muss angegeben werden",
+		"must be provided unless api_key is":                                          "muss angegeben werden, sofern api_key fehlt",
+		"must be the poll's slug to delete a poll with votes":                         "muss der Slug der Umfrage sein, um eine Umfrage mit Stimmen zu löschen",
+		"must contain ISO 3166-1 alpha-2 country codes":                               "muss Ländercodes nach ISO 3166-1 alpha-2 enthalten",
+		"must contain at least two options":                                           "muss mindestens zwei Optionen enthalten",
+		"must differ from the question and each other":                                "muss sich von der Frage und voneinander unterscheiden",
+		"must not be empty":                                                           "darf nicht leer sein",
+		"must not be more than 100 bytes long":                                        "darf nicht länger als 100 Bytes sein",
+		"must not be more than 1000 bytes long":                                       "darf nicht länger als 1000 Bytes sein",
+		"must not be more than 200 bytes long":                                        "darf nicht länger als 200 Bytes sein",
+		"must not be more than 2048 bytes long":                                       "darf nicht länger als 2048 Bytes sein",
+		"must not be more than 254 bytes long":                                        "darf nicht länger als 254 Bytes sein",
+		"must not be more than 50 bytes long":                                         "darf nicht länger als 50 Bytes sein",
+		"must not be more than 500 bytes long":                                        "darf nicht länger als 500 Bytes sein",
+		"must not be negative":                                                        "darf nicht negativ sein",
+		"must not be set together with denied_countries":                              "darf nicht zusammen mit denied_countries gesetzt werden",
+		"must not be set together with email":                                         "darf nicht zusammen mit email gesetzt werden",
+		"must not be set together with expires_at":                                    "darf nicht zusammen mit expires_at gesetzt werden",
+		"must not be set together with ip":                                            "darf nicht zusammen mit ip gesetzt werden",
+		"must not be the option being merged":                                         "darf nicht die zusammengeführte Option sein",
+		"must not contain duplicate values":                                           "darf keine doppelten Werte enthalten",
+		"must not contain more than 10 tags":                                          "darf nicht mehr als 10 Tags enthalten",
+		"must not contain more than 100 options":                                      "darf nicht mehr als 100 Optionen enthalten",
+		"must not contain more than 20 groups":                                        "darf nicht mehr als 20 Gruppen enthalten",
+		"must not contain more than 20 keys":                                          "darf nicht mehr als 20 Schlüssel enthalten",
+		"must not contain more than 250 countries":                                    "darf nicht mehr als 250 Länder enthalten",
+		"must not contain more than 5 questions":                                      "darf nicht mehr als 5 Fragen enthalten",
+		"must not contain more than 5 variants":                                       "darf nicht mehr als 5 Varianten enthalten",
+		"must not contain more than 50 IDs":                                           "darf nicht mehr als 50 IDs enthalten",
+		"must not contradict visibility":                                              "darf visibility nicht widersprechen",
+		"must not duplicate another option's value":                                   "darf den Wert einer anderen Option nicht wiederholen",
+		"must only contain letters, digits, dots, dashes and underscores":             "darf nur Buchstaben, Ziffern, Punkte, Bindestriche und Unterstriche enthalten",
+		"must only contain letters, digits, periods, colons, hyphens and underscores": "darf nur Buchstaben, Ziffern, Punkte, Doppelpunkte, Bindestriche und Unterstriche enthalten",
+		"must only contain valid IDs":                                                 "darf nur gültige IDs enthalten",
+		"option description must not be more than 1000 bytes long":                    "die Beschreibung einer Option darf nicht länger als 1000 Bytes sein",
+		"option group must be one of the poll's groups":                               "die Gruppe einer Option muss eine der Gruppen der Umfrage sein",
+		"option url must be an absolute http or https URL":                            "die URL einer Option muss eine absolute http- oder https-URL sein",
+		"option url must not be more than 2048 bytes long":                            "die URL einer Option darf nicht länger als 2048 Bytes sein",
+		"option value must not be more than 500 bytes long":                           "der Wert einer Option darf nicht länger als 500 Bytes sein",
+		"option values must not be empty":                                             "die Werte der Optionen dürfen nicht leer sein",
+		"position must be greater or equal to 0":                                      "die Position muss größer oder gleich 0 sein",
+		"position must not excede the number of options":                              "die Position darf die Anzahl der Optionen nicht überschreiten",
+		"positions must be unique":                                                    "die Positionen müssen eindeutig sein",
+		"questions must have at least two choices":                                    "Fragen müssen mindestens zwei Antworten haben",
+		"questions must not have more than 20 choices":                                "Fragen dürfen nicht mehr als 20 Antworten haben",
+		"result exports are not supported by this server":                             "Ergebnisexporte werden von diesem Server nicht unterstützt",
+		"sms voting is not supported by this server":                                  "SMS-Abstimmungen werden von diesem Server nicht unterstützt",
+		"status must be open, closed or paused":                                       "Status muss open, closed oder paused sein",
+		"tag must not be more than 30 bytes long":                                     "Tag darf nicht länger als 30 Bytes sein",
+		"tag must only contain lowercase letters, digits and hyphens":                 "Tag darf nur Kleinbuchstaben, Ziffern und Bindestriche enthalten",
+		"unsupported export version":                                                  "Exportversion wird nicht unterstützt",
+		"value must not be more than 500 bytes long":                                  "Wert darf nicht länger als 500 Bytes sein",
+		"variant must not be more than 500 bytes long":                                "eine Variante darf nicht länger als 500 Bytes sein",
+		"variants must not be empty":                                                  "die Varianten dürfen nicht leer sein",
+
+		// errors
+		"the server encountered a problem and could not process your request": "beim Server ist ein Problem aufgetreten, die Anfrage konnte nicht verarbeitet werden",
+		"the requested resource could not be found":                           "die angeforderte Ressource wurde nicht gefunden",
+		"rate limit exceeded": "zu viele Anfragen",
+		"too many vote attempts on this poll, please try again later":         "zu viele Abstimmungsversuche für diese Umfrage, bitte bitte versuche es später erneut",
+		"you have already voted on this poll":                                 "du hast bei dieser Umfrage bereits abgestimmt",
+		"editing the poll is not permitted once voting has begun":             "die Umfrage kann nicht mehr bearbeitet werden, sobald die Abstimmung begonnen hat",
+		"unable to update the poll due to an edit conflict, please try again": "die Umfrage konnte wegen eines Bearbeitungskonflikts nicht aktualisiert werden, bitte versuche es erneut",
+		"the option does not belong to this poll":                             "die Option gehört nicht zu dieser Umfrage",
+		"poll has expired":                                     "die Umfrage ist abgelaufen",
+		"poll is closed":                                       "die Umfrage ist geschlossen",
+		"results will be available after voting":               "die Ergebnisse sind nach der Abstimmung verfügbar",
+		"results will be available when poll expires":          "die Ergebnisse sind verfügbar, wenn die Umfrage abläuft",
+		"invalid or missing token":                             "ungültiges oder fehlendes Token",
+		"your role does not permit this action":                "deine Rolle erlaubt diese Aktion nicht",
+		"organization must keep at least one admin":            "die Organisation muss mindestens einen Admin behalten",
+		"quota of active polls exceeded":                       "das Kontingent an aktiven Umfragen ist ausgeschöpft",
+		"voting on this poll is not available in your country": "die Abstimmung bei dieser Umfrage ist in deinem Land nicht verfügbar",
+		"voting on this poll is paused":                        "die Abstimmung bei dieser Umfrage ist pausiert",
+		"this poll has reached its maximum number of votes":    "die Umfrage hat die maximale Anzahl an Stimmen erreicht",
+		"invalid or missing request signature":                 "ungültige oder fehlende Signatur der Anfrage",
+		"format not supported":                                 "Format wird nicht unterstützt",
+		"body contains badly-formed JSON":                      "der Inhalt enthält fehlerhaftes JSON",
+		"body must not be empty":                               "der Inhalt darf nicht leer sein",
+		"body must only contain a single JSON value":           "der Inhalt darf nur einen einzigen JSON-Wert enthalten",
+		"invalid id":                                "ungültige ID",
+		"no fields provided for update":             "keine Felder zum Aktualisieren angegeben",
+		"token not valid for this poll":             "das Token ist für diese Umfrage nicht gültig",
+		"token not valid for this organization":     "das Token ist für diese Organisation nicht gültig",
+		"the content was rejected by moderation":    "der Inhalt wurde von der Moderation abgelehnt",
+		"this poll was removed by an administrator": "diese Umfrage wurde von einem Administrator entfernt",
+		"you are banned from creating polls":        "du bist für das Erstellen von Umfragen gesperrt",
+		"too many confirmation emails were sent for this vote, please try again later":                       "für diese Stimme wurden zu viele Bestätigungs-E-Mails gesendet, bitte versuche es später erneut",
+		"this is not possible in the poll's current status":                                                  "das ist im aktuellen Status der Umfrage nicht möglich",
+		"you have created as many polls as you may today, please try again tomorrow":                         "du hast heute so viele Umfragen erstellt wie erlaubt, bitte versuche es morgen erneut",
+		"the request looks automated, please fill in the form yourself":                                      "die Anfrage sieht automatisiert aus, bitte fülle das Formular selbst aus",
+		"options of verifiable polls can't be merged, as voters' ballots are for the options they voted for": "Optionen überprüfbarer Umfragen können nicht zusammengeführt werden, da die Stimmzettel der Abstimmenden für die gewählten Optionen gelten",
+	})
+}
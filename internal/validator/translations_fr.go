@@ -0,0 +1,166 @@
+package validator
+
+func init() {
+	RegisterTranslations("fr", map[string]string{
+		// validation
+		"choices must not be empty":                                                   "les choix ne doivent pas être vides",
+		"choices must not be more than 100 bytes long":                                "les choix ne doivent pas dépasser 100 octets",
+		"choices must not contain duplicate values":                                   "les choix ne doivent pas contenir de doublons",
+		"country restrictions are not supported by this server":                       "les restrictions par pays ne sont pas prises en charge par ce serveur",
+		"email confirmation is not supported by this server":                          "la confirmation par e-mail n'est pas prise en charge par ce serveur",
+		"email digests are not supported by this server":                              "les résumés par e-mail ne sont pas pris en charge par ce serveur",
+		"email notifications are not supported by this server":                        "les notifications par e-mail ne sont pas prises en charge par ce serveur",
+		"email or webhook_url must be provided":                                       "email ou webhook_url doit être fourni",
+		"group label must not be more than 100 bytes long":                            "le libellé d'un groupe ne doit pas dépasser 100 octets",
+		"group labels must not be empty":                                              "les libellés des groupes ne doivent pas être vides",
+		"group position must not exceed the number of groups":                         "la position d'un groupe ne doit pas dépasser le nombre de groupes",
+		"ids must be unique":                                                          "les identifiants doivent être uniques",
+		"invalid anonymity value":                                                     "valeur de anonymity invalide",
+		"invalid results_visibility value":                                            "valeur de results_visibility invalide",
+		"invalid sort value":                                                          "valeur de sort invalide",
+		"invalid tie_break value":                                                     "valeur de tie_break invalide",
+		"invalid visibility value":                                                    "valeur de visibility invalide",
+		"invalid vote status":                                                         "statut de vote invalide",
+		"is already banned":                                                           "est déjà banni",
+		"is already exempt":                                                           "est déjà exempté",
+		"is bound to another poll that is still open":                                 "est lié à un autre sondage encore ouvert",
+		"is not a question of this poll":                                              "n'est pas une question de ce sondage",
+		"key must not be more than 40 bytes long":                                     "une clé ne doit pas dépasser 40 octets",
+		"key must only contain letters, digits, hyphens and underscores":              "une clé ne doit contenir que des lettres, des chiffres, des tirets et des tirets bas",
+		"keys must be lowercase letters, digits and underscores":                      "les clés doivent contenir uniquement des minuscules, des chiffres et des tirets bas",
+		"keys must be unique":                                                         "les clés doivent être uniques",
+		"keys must not be more than 50 bytes long":                                    "les clés ne doivent pas dépasser 50 octets",
+		"labels must not be more than 200 bytes long":                                 "les libellés ne doivent pas dépasser 200 octets",
+		"lang must be de, en, es, fr, it, nl or pt":                                   "lang doit être de, en, es, fr, it, nl ou pt",
+		"must be 26 bytes long":                                                       "doit faire 26 octets",
+		"must be a duration such as 2h or 7d":                                         "doit être une durée comme 2h ou 7d",
+		"must be a maximum of 10 million":                                             "doit être au maximum 10 millions",
+		"must be a maximum of 100":                                                    "doit être au maximum 100",
+		"must be a maximum of 1000":                                                   "doit être au maximum 1000",
+		"must be a maximum of 1024":                                                   "doit être au maximum 1024",
+		"must be a maximum of 50":                                                     "doit être au maximum 50",
+		"must be a poll ID":                                                           "doit être un identifiant de sondage",
+		"must be a valid API key":                                                     "doit être une clé API valide",
+		"must be a valid IANA time zone":                                              "doit être un fuseau horaire IANA valide",
+		"must be a valid IP address":                                                  "doit être une adresse IP valide",
+		"must be a valid email address":                                               "doit être une adresse e-mail valide",
+		"must be a valid form token, get one from /v1/polls/form-token":               "doit être un jeton de formulaire valide, obtenez-en un depuis /v1/polls/form-token",
+		"must be accepted or rejected":                                                "doit être accepted ou rejected",
+		"must be admin, editor or viewer":                                             "doit être admin, editor ou viewer",
+		"must be an absolute http or https URL":                                       "doit être une URL http ou https absolue",
+		"must be an existing poll":                                                    "doit être un sondage existant",
+		"must be an integer value":                                                    "doit être un nombre entier",
+		"must be an organization ID":                                                  "doit être un identifiant d'organisation",
+		"must be approved or rejected":                                                "doit être approved ou rejected",
+		"must be at least 2m":                                                         "doit être au moins 2m",
+		"must be at least 64":                                                         "doit être au moins 64",
+		"must be csv or json":                                                         "doit être csv ou json",
+		"must be de, en, es, fr, it, nl or pt":                                        "doit être de, en, es, fr, it, nl ou pt",
+		"must be editor or viewer":                                                    "doit être editor ou viewer",
+		"must be for the poll's options":                                              "doivent porter sur les options du sondage",
+		"must be for the poll's question variants":                                    "doit correspondre à l'une des variantes de la question",
+		"must be greater than zero":                                                   "doit être supérieur à zéro",
+		"must be hourly or daily":                                                     "doit être hourly ou daily",
+		"must be in E.164 format, e.g. +15551234567":                                  "doit être au format E.164, par exemple +15551234567",
+		"must be in the future":                                                       "doit être dans le futur",
+		"must be manage or results":                                                   "doit être manage ou results",
+		"must be manage, results, vote, preview or kiosk":                             "doit être manage, results, vote, preview ou kiosk",
+		"must be more than a minute in the future":                                    "doit être plus d'une minute dans le futur",
+		"must be one of L, M, Q, H":                                                   "doit être L, M, Q ou H",
+		"must be one of the poll's demographic questions":                             "doit être l'une des questions démographiques du sondage",
+		"must be one of the question's choices":                                       "doit être l'un des choix de la question",
+		"must be png or svg":                                                          "doit être png ou svg",
+		"must be provided":                                                            "doit être fourni",
+		"must be provided unless api_key is":                                          "doit être fourni sauf si api_key l'est",
+		"must be the poll's slug to delete a poll with votes":                         "doit être le slug du sondage pour supprimer un sondage avec des votes",
+		"must contain ISO 3166-1 alpha-2 country codes":                               "doit contenir des codes pays ISO 3166-1 alpha-2",
+		"must contain at least two options":                                           "doit contenir au moins deux options",
+		"must differ from the question and each other":                                "doivent être différentes de la question et entre elles",
+		"must not be empty":                                                           "ne doit pas être vide",
+		"must not be more than 100 bytes long":                                        "ne doit pas dépasser 100 octets",
+		"must not be more than 1000 bytes long":                                       "ne doit pas dépasser 1000 octets",
+		"must not be more than 200 bytes long":                                        "ne doit pas dépasser 200 octets",
+		"must not be more than 2048 bytes long":                                       "ne doit pas dépasser 2048 octets",
+		"must not be more than 254 bytes long":                                        "ne doit pas dépasser 254 octets",
+		"must not be more than 50 bytes long":                                         "ne doit pas dépasser 50 octets",
+		"must not be more than 500 bytes long":                                        "ne doit pas dépasser 500 octets",
+		"must not be negative":                                                        "ne doit pas être négatif",
+		"must not be set together with denied_countries":                              "ne doit pas être défini en même temps que denied_countries",
+		"must not be set together with email":                                         "ne doit pas être défini en même temps que email",
+		"must not be set together with expires_at":                                    "ne doit pas être défini en même temps que expires_at",
+		"must not be set together with ip":                                            "ne doit pas être défini avec ip",
+		"must not be the option being merged":                                         "ne doit pas être l'option fusionnée",
+		"must not contain duplicate values":                                           "ne doit pas contenir de valeurs en double",
+		"must not contain more than 10 tags":                                          "ne doit pas contenir plus de 10 tags",
+		"must not contain more than 100 options":                                      "ne doit pas contenir plus de 100 options",
+		"must not contain more than 20 groups":                                        "ne doit pas contenir plus de 20 groupes",
+		"must not contain more than 20 keys":                                          "ne doit pas contenir plus de 20 clés",
+		"must not contain more than 250 countries":                                    "ne doit pas contenir plus de 250 pays",
+		"must not contain more than 5 questions":                                      "ne doit pas contenir plus de 5 questions",
+		"must not contain more than 5 variants":                                       "ne doit pas contenir plus de 5 variantes",
+		"must not contain more than 50 IDs":                                           "ne doit pas contenir plus de 50 identifiants",
+		"must not contradict visibility":                                              "ne doit pas contredire visibility",
+		"must not duplicate another option's value":                                   "ne doit pas répéter la valeur d'une autre option",
+		"must only contain letters, digits, dots, dashes and underscores":             "ne doit contenir que des lettres, des chiffres, des points, des tirets et des tirets bas",
+		"must only contain letters, digits, periods, colons, hyphens and underscores": "ne doit contenir que des lettres, des chiffres, des points, des deux-points, des tirets et des tirets bas",
+		"must only contain valid IDs":                                                 "ne doit contenir que des identifiants valides",
+		"option description must not be more than 1000 bytes long":                    "la description d'une option ne doit pas dépasser 1000 octets",
+		"option group must be one of the poll's groups":                               "le groupe d'une option doit être l'un des groupes du sondage",
+		"option url must be an absolute http or https URL":                            "l'URL d'une option doit être une URL http ou https absolue",
+		"option url must not be more than 2048 bytes long":                            "l'URL d'une option ne doit pas dépasser 2048 octets",
+		"option value must not be more than 500 bytes long":                           "la valeur d'une option ne doit pas dépasser 500 octets",
+		"option values must not be empty":                                             "les valeurs des options ne doivent pas être vides",
+		"position must be greater or equal to 0":                                      "la position doit être supérieure ou égale à 0",
+		"position must not excede the number of options":                              "la position ne doit pas dépasser le nombre d'options",
+		"positions must be unique":                                                    "les positions doivent être uniques",
+		"questions must have at least two choices":                                    "les questions doivent avoir au moins deux choix",
+		"questions must not have more than 20 choices":                                "les questions ne doivent pas avoir plus de 20 choix",
+		"result exports are not supported by this server":                             "les exports de résultats ne sont pas pris en charge par ce serveur",
+		"sms voting is not supported by this server":                                  "le vote par SMS n'est pas pris en charge par ce serveur",
+		"status must be open, closed or paused":                                       "le statut doit être open, closed ou paused",
+		"tag must not be more than 30 bytes long":                                     "un tag ne doit pas dépasser 30 octets",
+		"tag must only contain lowercase letters, digits and hyphens":                 "un tag ne doit contenir que des lettres minuscules, des chiffres et des tirets",
+		"unsupported export version":                                                  "version d'export non prise en charge",
+		"value must not be more than 500 bytes long":                                  "une valeur ne doit pas dépasser 500 octets",
+		"variant must not be more than 500 bytes long":                                "une variante ne doit pas dépasser 500 octets",
+		"variants must not be empty":                                                  "les variantes ne doivent pas être vides",
+
+		// errors
+		"the server encountered a problem and could not process your request": "le serveur a rencontré un problème et n'a pas pu traiter votre requête",
+		"the requested resource could not be found":                           "la ressource demandée est introuvable",
+		"rate limit exceeded": "trop de requêtes",
+		"too many vote attempts on this poll, please try again later":         "trop de tentatives de vote sur ce sondage, veuillez réessayer plus tard",
+		"you have already voted on this poll":                                 "vous avez déjà voté pour ce sondage",
+		"editing the poll is not permitted once voting has begun":             "le sondage ne peut plus être modifié une fois le vote commencé",
+		"unable to update the poll due to an edit conflict, please try again": "impossible de mettre à jour le sondage en raison d'un conflit de modification, veuillez réessayer",
+		"the option does not belong to this poll":                             "l'option n'appartient pas à ce sondage",
+		"poll has expired":                                     "le sondage a expiré",
+		"poll is closed":                                       "le sondage est clos",
+		"results will be available after voting":               "les résultats seront disponibles après le vote",
+		"results will be available when poll expires":          "les résultats seront disponibles à l'expiration du sondage",
+		"invalid or missing token":                             "jeton invalide ou manquant",
+		"your role does not permit this action":                "votre rôle ne permet pas cette action",
+		"organization must keep at least one admin":            "l'organisation doit conserver au moins un admin",
+		"quota of active polls exceeded":                       "le quota de sondages actifs est dépassé",
+		"voting on this poll is not available in your country": "le vote pour ce sondage n'est pas disponible dans votre pays",
+		"voting on this poll is paused":                        "le vote sur ce sondage est suspendu",
+		"this poll has reached its maximum number of votes":    "le sondage a atteint le nombre maximum de votes",
+		"invalid or missing request signature":                 "signature de la requête invalide ou manquante",
+		"format not supported":                                 "format non pris en charge",
+		"body contains badly-formed JSON":                      "le corps contient du JSON mal formé",
+		"body must not be empty":                               "le corps ne doit pas être vide",
+		"body must only contain a single JSON value":           "le corps ne doit contenir qu'une seule valeur JSON",
+		"invalid id":                                "identifiant invalide",
+		"no fields provided for update":             "aucun champ fourni pour la mise à jour",
+		"token not valid for this poll":             "le jeton n'est pas valide pour ce sondage",
+		"token not valid for this organization":     "le jeton n'est pas valide pour cette organisation",
+		"the content was rejected by moderation":    "le contenu a été rejeté par la modération",
+		"this poll was removed by an administrator": "ce sondage a été supprimé par un administrateur",
+		"you are banned from creating polls":        "vous n'êtes pas autorisé à créer des sondages",
+		"too many confirmation emails were sent for this vote, please try again later":                       "trop d'e-mails de confirmation ont été envoyés pour ce vote, veuillez réessayer plus tard",
+		"this is not possible in the poll's current status":                                                  "ce n'est pas possible dans l'état actuel du sondage",
+		"you have created as many polls as you may today, please try again tomorrow":                         "vous avez créé autant de sondages que permis aujourd'hui, veuillez réessayer demain",
+		"the request looks automated, please fill in the form yourself":                                      "la requête semble automatisée, veuillez remplir le formulaire vous-même",
+		"options of verifiable polls can't be merged, as voters' ballots are for the options they voted for": "Les options des sondages vérifiables ne peuvent pas être fusionnées, car les bulletins des votants correspondent aux options pour lesquelles ils ont voté",
+	})
+}
@@ -0,0 +1,62 @@
+package validator
+
+import "testing"
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-CH", "de"},
+		{"FR-fr", "fr"},
+		{"es, fr;q=0.8", "fr"},
+		{"fr;q=0.5, de;q=0.9", "de"},
+		{"en, de;q=0.9", "en"},
+		{"es, it", "en"},
+		{"de;q=invalid, fr;q=0.1", "fr"},
+		{"*", "en"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			if got := NegotiateLanguage(test.header); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := Translate("de", "must not be empty"); got != "darf nicht leer sein" {
+		t.Errorf("expected German message, but got %q", got)
+	}
+	if got := Translate("de", "not a known message"); got != "not a known message" {
+		t.Errorf("expected untranslated message, but got %q", got)
+	}
+	if got := Translate("es", "must not be empty"); got != "must not be empty" {
+		t.Errorf("expected untranslated message, but got %q", got)
+	}
+
+	errs := TranslateErrors("fr", map[string]string{"question": "must not be empty"})
+	if errs["question"] != "ne doit pas être vide" {
+		t.Errorf("expected French message, but got %q", errs["question"])
+	}
+}
+
+// TestTranslationsComplete makes sure every language translates the same
+// messages.
+func TestTranslationsComplete(t *testing.T) {
+	reference := translations["de"]
+	for _, lang := range Languages()[1:] {
+		for message := range reference {
+			if _, ok := translations[lang][message]; !ok {
+				t.Errorf("%s is missing a translation of %q", lang, message)
+			}
+		}
+		if len(translations[lang]) != len(reference) {
+			t.Errorf("expected %s to have %d translations, but got %d", lang, len(reference), len(translations[lang]))
+		}
+	}
+}
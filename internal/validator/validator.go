@@ -1,5 +1,13 @@
 package validator
 
+import "regexp"
+
+var (
+	CountryCodeRX = regexp.MustCompile("^[A-Z]{2}$")
+	PhoneNumberRX = regexp.MustCompile("^\\+[1-9][0-9]{6,14}$")
+	EmailRX       = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+)
+
 type Validator struct {
 	Errors map[string]string
 }
@@ -42,3 +50,7 @@ func PermittedValue[T comparable](value T, permittedValues ...T) bool {
 	}
 	return false
 }
+
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
@@ -0,0 +1,33 @@
+#!/bin/bash
+#
+# Creates a poll and prints vegeta targets voting on it, each from a different
+# IP as every IP may only vote once:
+#
+#   bash loadtest/vegeta-targets.sh http://localhost:8080 100000 > targets.txt
+#   vegeta attack -targets targets.txt -rate 200 -duration 1m | vegeta report
+#
+# Start the server with -load-test so votes aren't rate limited.
+
+set -euo pipefail
+
+base_url=${1:-http://localhost:8080}
+count=${2:-10000}
+
+poll=$(curl -sf -X POST "$base_url/v1/polls" -d '{
+	"question": "Load test?",
+	"options": [
+		{"value": "One", "position": 0},
+		{"value": "Two", "position": 1},
+		{"value": "Three", "position": 2}
+	]
+}')
+
+poll_id=$(echo "$poll" | grep -o '"id":"[^"]*"' | head -1 | cut -d '"' -f 4)
+option_ids=($(echo "$poll" | grep -o '"id":"[^"]*"' | tail -n +2 | cut -d '"' -f 4))
+
+for ((i = 0; i < count; i++)); do
+	option_id=${option_ids[$((i % ${#option_ids[@]}))]}
+	echo "POST $base_url/v1/polls/$poll_id/options/$option_id"
+	echo "X-Forwarded-For: 10.$((i >> 16 & 255)).$((i >> 8 & 255)).$((i & 255))"
+	echo
+done
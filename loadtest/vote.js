@@ -0,0 +1,63 @@
+// k6 scenario for the vote path: creates a poll and votes on it from many
+// distinct IPs at a constant rate.
+//
+//   k6 run -e BASE_URL=http://localhost:8080 -e RATE=200 loadtest/vote.js
+//
+// Start the server with -load-test so votes aren't rate limited.
+import http from 'k6/http';
+import { check } from 'k6';
+
+const baseURL = __ENV.BASE_URL || 'http://localhost:8080';
+const rate = parseInt(__ENV.RATE || '100');
+const duration = __ENV.DURATION || '1m';
+
+export const options = {
+  scenarios: {
+    vote: {
+      executor: 'constant-arrival-rate',
+      rate: rate,
+      timeUnit: '1s',
+      duration: duration,
+      preAllocatedVUs: Math.max(10, rate),
+    },
+  },
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    http_req_duration: ['p(95)<200'],
+  },
+};
+
+export function setup() {
+  const res = http.post(
+    `${baseURL}/v1/polls`,
+    JSON.stringify({
+      question: 'Load test?',
+      options: [
+        { value: 'One', position: 0 },
+        { value: 'Two', position: 1 },
+        { value: 'Three', position: 2 },
+      ],
+    }),
+    { headers: { 'Content-Type': 'application/json' } },
+  );
+  check(res, { 'poll created': (r) => r.status === 201 });
+
+  const poll = res.json('poll');
+  return { pollID: poll.id, optionIDs: poll.options.map((o) => o.id) };
+}
+
+// every iteration votes from its own IP, as each IP may only vote once
+function ip(n) {
+  return `10.${(n >> 16) & 255}.${(n >> 8) & 255}.${n & 255}`;
+}
+
+export default function (data) {
+  const n = __VU * 1000000 + __ITER;
+  const optionID = data.optionIDs[n % data.optionIDs.length];
+
+  const res = http.post(`${baseURL}/v1/polls/${data.pollID}/options/${optionID}`, null, {
+    headers: { 'X-Forwarded-For': ip(n) },
+    tags: { name: 'vote' },
+  });
+  check(res, { 'vote counted': (r) => r.status === 200 });
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN anonymity text NOT NULL DEFAULT 'anonymous';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls DROP COLUMN anonymity;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS votes (
+    id bigserial PRIMARY KEY,
+    poll_id uuid REFERENCES polls (id) ON DELETE CASCADE,
+    option_id uuid REFERENCES poll_options (id) ON DELETE CASCADE,
+    voter_name text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS votes_poll_id_idx ON votes (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS votes;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN allowed_countries text[] NOT NULL DEFAULT '{}';
+ALTER TABLE polls ADD COLUMN denied_countries text[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls DROP COLUMN allowed_countries;
+ALTER TABLE polls DROP COLUMN denied_countries;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE votes ADD COLUMN ip inet;
+ALTER TABLE votes ADD COLUMN user_agent text NOT NULL DEFAULT '';
+ALTER TABLE votes ADD COLUMN score int NOT NULL DEFAULT 0;
+ALTER TABLE votes ADD COLUMN status text NOT NULL DEFAULT 'accepted';
+CREATE INDEX IF NOT EXISTS votes_poll_id_created_at_idx ON votes (poll_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS votes_poll_id_created_at_idx;
+ALTER TABLE votes DROP COLUMN ip;
+ALTER TABLE votes DROP COLUMN user_agent;
+ALTER TABLE votes DROP COLUMN score;
+ALTER TABLE votes DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE votes ADD COLUMN voter_identity text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS votes_poll_id_voter_identity_idx ON votes (poll_id, voter_identity) WHERE voter_identity <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS votes_poll_id_voter_identity_idx;
+ALTER TABLE votes DROP COLUMN voter_identity;
+-- +goose StatementEnd
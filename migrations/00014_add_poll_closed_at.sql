@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN closed_at timestamp(0) with time zone;
+UPDATE polls SET closed_at = expires_at WHERE expires_at > '0001-01-02' AND expires_at <= NOW();
+CREATE INDEX IF NOT EXISTS polls_expires_at_open_idx ON polls (expires_at) WHERE closed_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_expires_at_open_idx;
+ALTER TABLE polls DROP COLUMN closed_at;
+-- +goose StatementEnd
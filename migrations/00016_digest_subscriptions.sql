@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    poll_id uuid PRIMARY KEY REFERENCES polls (id) ON DELETE CASCADE,
+    frequency text NOT NULL,
+    email text NOT NULL DEFAULT '',
+    webhook_url text NOT NULL DEFAULT '',
+    last_sent_at timestamp with time zone NOT NULL DEFAULT NOW(),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS digest_subscriptions_recipient_idx ON digest_subscriptions (frequency, email, webhook_url);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS digest_subscriptions;
+-- +goose StatementEnd
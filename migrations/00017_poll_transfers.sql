@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS poll_transfers (
+    id bigserial PRIMARY KEY,
+    poll_id uuid REFERENCES polls (id) ON DELETE CASCADE,
+    reason text NOT NULL DEFAULT '',
+    ip inet,
+    user_agent text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS poll_transfers_poll_id_idx ON poll_transfers (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_transfers;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tokens ADD COLUMN scope text NOT NULL DEFAULT 'manage';
+ALTER TABLE tokens ADD COLUMN expires_at timestamp(0) with time zone;
+ALTER TABLE tokens ADD COLUMN created_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS tokens_poll_id_idx ON tokens (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS tokens_poll_id_idx;
+ALTER TABLE tokens DROP COLUMN scope;
+ALTER TABLE tokens DROP COLUMN expires_at;
+ALTER TABLE tokens DROP COLUMN created_at;
+-- +goose StatementEnd
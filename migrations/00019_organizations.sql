@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organizations (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE TABLE IF NOT EXISTS org_members (
+    id bigserial PRIMARY KEY,
+    org_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    role text NOT NULL,
+    token_hash bytea NOT NULL UNIQUE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS org_members_org_id_idx ON org_members (org_id);
+ALTER TABLE polls ADD COLUMN org_id uuid REFERENCES organizations (id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS polls_org_id_idx ON polls (org_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls DROP COLUMN org_id;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
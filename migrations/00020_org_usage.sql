@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS org_usage (
+    org_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    period date NOT NULL,
+    polls_created integer NOT NULL DEFAULT 0,
+    votes_received integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (org_id, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS org_usage;
+-- +goose StatementEnd
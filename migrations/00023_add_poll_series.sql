@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN series_id uuid;
+CREATE INDEX IF NOT EXISTS polls_series_id_idx ON polls (series_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_series_id_idx;
+ALTER TABLE polls DROP COLUMN series_id;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN demographics jsonb NOT NULL DEFAULT '[]';
+ALTER TABLE votes ADD COLUMN demographics jsonb NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls DROP COLUMN demographics;
+ALTER TABLE votes DROP COLUMN demographics;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE votes ADD COLUMN source text NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS poll_source_views (
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    source text NOT NULL,
+    views integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (poll_id, source)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_source_views;
+ALTER TABLE votes DROP COLUMN source;
+-- +goose StatementEnd
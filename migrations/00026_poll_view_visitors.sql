@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS poll_view_visitors (
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    visitor bytea NOT NULL,
+    hour timestamp(0) with time zone NOT NULL,
+    PRIMARY KEY (poll_id, visitor, hour)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_view_visitors;
+-- +goose StatementEnd
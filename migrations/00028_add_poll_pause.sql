@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN paused_at timestamp(0) with time zone;
+ALTER TABLE polls ADD COLUMN pause_reason text NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls DROP COLUMN paused_at;
+ALTER TABLE polls DROP COLUMN pause_reason;
+-- +goose StatementEnd
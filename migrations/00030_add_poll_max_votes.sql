@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN max_votes integer NOT NULL DEFAULT 0;
+ALTER TABLE polls ADD COLUMN votes_cast integer NOT NULL DEFAULT 0;
+UPDATE polls SET votes_cast = (SELECT count(*) FROM votes WHERE votes.poll_id = polls.id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls DROP COLUMN max_votes;
+ALTER TABLE polls DROP COLUMN votes_cast;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS votes_poll_id_voter_identity_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS votes_poll_id_voter_identity_key ON votes (poll_id, voter_identity) WHERE voter_identity <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS votes_poll_id_voter_identity_key;
+CREATE INDEX IF NOT EXISTS votes_poll_id_voter_identity_idx ON votes (poll_id, voter_identity) WHERE voter_identity <> '';
+-- +goose StatementEnd
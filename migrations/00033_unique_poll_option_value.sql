@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- options that only differ in case or surrounding space get a suffix, so the
+-- index can be created
+UPDATE poll_options po
+SET value = po.value || ' (' || d.n || ')'
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY poll_id, lower(btrim(value)) ORDER BY position) AS n
+    FROM poll_options
+) d
+WHERE po.id = d.id AND d.n > 1;
+CREATE UNIQUE INDEX IF NOT EXISTS poll_options_poll_id_value_key ON poll_options (poll_id, lower(btrim(value)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS poll_options_poll_id_value_key;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN moderation_status text NOT NULL DEFAULT '';
+ALTER TABLE polls ADD COLUMN moderation_terms text[] NOT NULL DEFAULT '{}';
+CREATE INDEX polls_moderation_flagged_idx ON polls (created_at) WHERE moderation_status = 'flagged';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_moderation_flagged_idx;
+ALTER TABLE polls DROP COLUMN moderation_status;
+ALTER TABLE polls DROP COLUMN moderation_terms;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS poll_reports (
+    id bigserial PRIMARY KEY,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    reporter bytea NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    resolved_at timestamp(0) with time zone
+);
+CREATE UNIQUE INDEX poll_reports_open_reporter_key ON poll_reports (poll_id, reporter) WHERE resolved_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_reports;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS takedowns (
+    poll_id uuid PRIMARY KEY REFERENCES polls (id) ON DELETE CASCADE,
+    reason text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE TABLE IF NOT EXISTS bans (
+    id bigserial PRIMARY KEY,
+    ip text UNIQUE,
+    key_hash bytea UNIQUE,
+    reason text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT bans_subject_check CHECK ((ip IS NULL) <> (key_hash IS NULL))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS bans;
+DROP TABLE IF EXISTS takedowns;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS staged_actions (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    option_id uuid REFERENCES poll_options (id) ON DELETE CASCADE,
+    actor text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    execute_at timestamp(0) with time zone NOT NULL
+);
+CREATE UNIQUE INDEX staged_actions_poll_delete_key ON staged_actions (poll_id) WHERE option_id IS NULL;
+CREATE UNIQUE INDEX staged_actions_option_delete_key ON staged_actions (option_id) WHERE option_id IS NOT NULL;
+CREATE INDEX staged_actions_execute_at_idx ON staged_actions (execute_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS staged_actions;
+-- +goose StatementEnd
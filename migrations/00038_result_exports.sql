@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS result_exports (
+    poll_id uuid PRIMARY KEY REFERENCES polls (id) ON DELETE CASCADE,
+    frequency text NOT NULL,
+    format text NOT NULL,
+    last_exported_at timestamp with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS result_exports;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN tags text[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS polls_tags_idx ON polls USING GIN (tags);
+CREATE INDEX IF NOT EXISTS polls_search_idx ON polls USING GIN ((
+    setweight(to_tsvector('simple', question), 'A') || setweight(to_tsvector('simple', description), 'B')
+));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_search_idx;
+DROP INDEX IF EXISTS polls_tags_idx;
+ALTER TABLE polls DROP COLUMN tags;
+-- +goose StatementEnd
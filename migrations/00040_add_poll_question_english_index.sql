@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS polls_question_english_idx ON polls USING GIN (to_tsvector('english', question));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_question_english_idx;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN language text NOT NULL DEFAULT '';
+
+-- poll_search_config returns the text search configuration of a poll's
+-- language, "simple" for languages without one
+CREATE OR REPLACE FUNCTION poll_search_config(language text) RETURNS regconfig
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT CASE language
+        WHEN 'de' THEN 'german'::regconfig
+        WHEN 'en' THEN 'english'::regconfig
+        WHEN 'es' THEN 'spanish'::regconfig
+        WHEN 'fr' THEN 'french'::regconfig
+        WHEN 'it' THEN 'italian'::regconfig
+        WHEN 'nl' THEN 'dutch'::regconfig
+        WHEN 'pt' THEN 'portuguese'::regconfig
+        ELSE 'simple'::regconfig
+    END
+$$;
+
+DROP INDEX IF EXISTS polls_search_idx;
+CREATE INDEX IF NOT EXISTS polls_search_idx ON polls USING GIN ((
+    setweight(to_tsvector(poll_search_config(language), question), 'A')
+    || setweight(to_tsvector(poll_search_config(language), description), 'B')
+));
+DROP INDEX IF EXISTS polls_question_english_idx;
+CREATE INDEX IF NOT EXISTS polls_question_language_idx ON polls USING GIN (
+    to_tsvector(poll_search_config(language), question)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_question_language_idx;
+CREATE INDEX IF NOT EXISTS polls_question_english_idx ON polls USING GIN (to_tsvector('english', question));
+DROP INDEX IF EXISTS polls_search_idx;
+CREATE INDEX IF NOT EXISTS polls_search_idx ON polls USING GIN ((
+    setweight(to_tsvector('simple', question), 'A') || setweight(to_tsvector('simple', description), 'B')
+));
+DROP FUNCTION IF EXISTS poll_search_config(text);
+ALTER TABLE polls DROP COLUMN language;
+-- +goose StatementEnd
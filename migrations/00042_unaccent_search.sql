@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- search_unaccent removes accents and diacritics, e.g. "café" becomes
+-- "cafe". unaccent itself isn't immutable, so it can't be used in indexes.
+CREATE OR REPLACE FUNCTION search_unaccent(value text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS $$
+    SELECT public.unaccent('public.unaccent'::regdictionary, value)
+$$;
+
+-- option_text is the poll's option values, for searches
+ALTER TABLE polls ADD COLUMN option_text text NOT NULL DEFAULT '';
+
+UPDATE polls p SET option_text = COALESCE((
+    SELECT string_agg(value, ' ' ORDER BY position) FROM poll_options WHERE poll_id = p.id
+), '');
+
+CREATE OR REPLACE FUNCTION polls_update_option_text() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    changed uuid;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD.poll_id;
+    ELSE
+        changed := NEW.poll_id;
+    END IF;
+
+    UPDATE polls SET option_text = COALESCE((
+        SELECT string_agg(value, ' ' ORDER BY position) FROM poll_options WHERE poll_id = changed
+    ), '')
+    WHERE id = changed;
+
+    RETURN NULL;
+END;
+$$;
+
+-- votes update the options' counts, which doesn't change the text
+CREATE TRIGGER poll_options_option_text
+AFTER INSERT OR DELETE OR UPDATE OF value, position ON poll_options
+FOR EACH ROW EXECUTE FUNCTION polls_update_option_text();
+
+DROP INDEX IF EXISTS polls_search_idx;
+CREATE INDEX IF NOT EXISTS polls_search_idx ON polls USING GIN ((
+    setweight(to_tsvector(poll_search_config(language), search_unaccent(question)), 'A')
+    || setweight(to_tsvector(poll_search_config(language), search_unaccent(description)), 'B')
+    || setweight(to_tsvector(poll_search_config(language), search_unaccent(option_text)), 'C')
+));
+DROP INDEX IF EXISTS polls_question_language_idx;
+CREATE INDEX IF NOT EXISTS polls_question_language_idx ON polls USING GIN (
+    to_tsvector(poll_search_config(language), search_unaccent(question))
+);
+DROP INDEX IF EXISTS polls_question_idx;
+CREATE INDEX IF NOT EXISTS polls_question_idx ON polls USING GIN (to_tsvector('simple', search_unaccent(question)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_question_idx;
+CREATE INDEX IF NOT EXISTS polls_question_idx ON polls USING GIN (to_tsvector('simple', question));
+DROP INDEX IF EXISTS polls_question_language_idx;
+CREATE INDEX IF NOT EXISTS polls_question_language_idx ON polls USING GIN (
+    to_tsvector(poll_search_config(language), question)
+);
+DROP INDEX IF EXISTS polls_search_idx;
+CREATE INDEX IF NOT EXISTS polls_search_idx ON polls USING GIN ((
+    setweight(to_tsvector(poll_search_config(language), question), 'A')
+    || setweight(to_tsvector(poll_search_config(language), description), 'B')
+));
+DROP TRIGGER IF EXISTS poll_options_option_text ON poll_options;
+DROP FUNCTION IF EXISTS polls_update_option_text();
+ALTER TABLE polls DROP COLUMN option_text;
+DROP FUNCTION IF EXISTS search_unaccent(text);
+DROP EXTENSION IF EXISTS unaccent;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the predicate is listedPolls in internal/data/polls.go
+CREATE INDEX IF NOT EXISTS polls_listed_created_at_idx ON polls (created_at, id)
+WHERE org_id IS NULL AND is_private = false AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+CREATE INDEX IF NOT EXISTS polls_listed_question_idx ON polls (question, id)
+WHERE org_id IS NULL AND is_private = false AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+CREATE INDEX IF NOT EXISTS polls_listed_search_idx ON polls USING GIN (to_tsvector('simple', search_unaccent(question)))
+WHERE org_id IS NULL AND is_private = false AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+
+DROP INDEX IF EXISTS polls_org_id_idx;
+CREATE INDEX IF NOT EXISTS polls_org_id_created_at_idx ON polls (org_id, created_at, id);
+
+CREATE INDEX IF NOT EXISTS poll_options_poll_id_position_idx ON poll_options (poll_id, position);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS poll_options_poll_id_position_idx;
+DROP INDEX IF EXISTS polls_org_id_created_at_idx;
+CREATE INDEX IF NOT EXISTS polls_org_id_idx ON polls (org_id);
+DROP INDEX IF EXISTS polls_listed_search_idx;
+DROP INDEX IF EXISTS polls_listed_question_idx;
+DROP INDEX IF EXISTS polls_listed_created_at_idx;
+-- +goose StatementEnd
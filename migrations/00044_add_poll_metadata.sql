@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN metadata jsonb NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS polls_metadata_idx ON polls USING GIN (metadata jsonb_path_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_metadata_idx;
+ALTER TABLE polls DROP COLUMN metadata;
+-- +goose StatementEnd
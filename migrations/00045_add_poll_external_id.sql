@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN external_id text;
+-- external IDs are unique within an organization, and among polls without one
+CREATE UNIQUE INDEX IF NOT EXISTS polls_external_id_idx ON polls (external_id) WHERE org_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS polls_org_id_external_id_idx ON polls (org_id, external_id) WHERE org_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_org_id_external_id_idx;
+DROP INDEX IF EXISTS polls_external_id_idx;
+ALTER TABLE polls DROP COLUMN external_id;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN visibility text NOT NULL DEFAULT 'public';
+-- private polls were only left out of listings, as unlisted polls are
+UPDATE polls SET visibility = 'unlisted' WHERE is_private;
+-- drops the polls_listed_* indexes, whose predicate used is_private
+ALTER TABLE polls DROP COLUMN is_private;
+
+-- the predicate is listedPolls in internal/data/polls.go
+CREATE INDEX IF NOT EXISTS polls_listed_created_at_idx ON polls (created_at, id)
+WHERE org_id IS NULL AND visibility = 'public' AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+CREATE INDEX IF NOT EXISTS polls_listed_question_idx ON polls (question, id)
+WHERE org_id IS NULL AND visibility = 'public' AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+CREATE INDEX IF NOT EXISTS polls_listed_search_idx ON polls USING GIN (to_tsvector('simple', search_unaccent(question)))
+WHERE org_id IS NULL AND visibility = 'public' AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT false;
+UPDATE polls SET is_private = true WHERE visibility <> 'public';
+ALTER TABLE polls ALTER COLUMN is_private DROP DEFAULT;
+ALTER TABLE polls DROP COLUMN visibility;
+
+CREATE INDEX IF NOT EXISTS polls_listed_created_at_idx ON polls (created_at, id)
+WHERE org_id IS NULL AND is_private = false AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+CREATE INDEX IF NOT EXISTS polls_listed_question_idx ON polls (question, id)
+WHERE org_id IS NULL AND is_private = false AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+CREATE INDEX IF NOT EXISTS polls_listed_search_idx ON polls USING GIN (to_tsvector('simple', search_unaccent(question)))
+WHERE org_id IS NULL AND is_private = false AND is_draft = false
+    AND moderation_status NOT IN ('flagged', 'reported', 'rejected');
+-- +goose StatementEnd
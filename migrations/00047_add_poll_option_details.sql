@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE poll_options ADD COLUMN description text NOT NULL DEFAULT '';
+ALTER TABLE poll_options ADD COLUMN url text NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE poll_options DROP COLUMN url;
+ALTER TABLE poll_options DROP COLUMN description;
+-- +goose StatementEnd
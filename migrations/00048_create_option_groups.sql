@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS option_groups (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    label text NOT NULL,
+    position int NOT NULL,
+    UNIQUE (poll_id, position)
+);
+ALTER TABLE poll_options ADD COLUMN group_id uuid REFERENCES option_groups (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE poll_options DROP COLUMN group_id;
+DROP TABLE IF EXISTS option_groups;
+-- +goose StatementEnd
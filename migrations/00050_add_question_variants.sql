@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN question_variants text[] NOT NULL DEFAULT '{}';
+ALTER TABLE votes ADD COLUMN variant int NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE votes DROP COLUMN variant;
+ALTER TABLE polls DROP COLUMN question_variants;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN verifiable boolean NOT NULL DEFAULT false;
+ALTER TABLE votes ADD COLUMN ballot text NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX votes_ballot_idx ON votes (poll_id, ballot) WHERE ballot <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX votes_ballot_idx;
+ALTER TABLE votes DROP COLUMN ballot;
+ALTER TABLE polls DROP COLUMN verifiable;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN confirm_votes boolean NOT NULL DEFAULT false;
+CREATE TABLE IF NOT EXISTS pending_votes (
+    id bigserial PRIMARY KEY,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    option_id uuid NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    voter_identity text NOT NULL,
+    voter_name text NOT NULL DEFAULT '',
+    user_agent text NOT NULL DEFAULT '',
+    demographics jsonb NOT NULL DEFAULT '{}',
+    source text NOT NULL DEFAULT '',
+    variant int NOT NULL DEFAULT 0,
+    token_hash bytea NOT NULL UNIQUE,
+    sends int NOT NULL DEFAULT 1,
+    sent_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    expires_at timestamp(0) with time zone NOT NULL,
+    UNIQUE (poll_id, voter_identity)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pending_votes;
+ALTER TABLE polls DROP COLUMN confirm_votes;
+-- +goose StatementEnd
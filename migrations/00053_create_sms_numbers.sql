@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sms_numbers (
+    phone_number text PRIMARY KEY,
+    poll_id uuid NOT NULL UNIQUE REFERENCES polls (id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sms_numbers;
+-- +goose StatementEnd
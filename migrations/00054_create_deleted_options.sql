@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS deleted_options (
+    id uuid PRIMARY KEY,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    value text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    url text NOT NULL DEFAULT '',
+    group_id uuid,
+    position int NOT NULL,
+    vote_count int NOT NULL,
+    votes jsonb NOT NULL DEFAULT '[]',
+    deleted_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS deleted_options_poll_id_idx ON deleted_options (poll_id);
+CREATE INDEX IF NOT EXISTS deleted_options_deleted_at_idx ON deleted_options (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS deleted_options;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- uuid_generate_v7 returns a version 7 UUID, whose first 48 bits are the
+-- milliseconds since the Unix epoch, so IDs sort in the order rows are
+-- created in and new rows are inserted at the end of the primary key index.
+-- The random bits and variant are those of gen_random_uuid, with its version
+-- 4 turned into 7 by setting bits 52 and 53.
+CREATE OR REPLACE FUNCTION uuid_generate_v7() RETURNS uuid AS $$
+    SELECT encode(
+        set_bit(
+            set_bit(
+                overlay(
+                    uuid_send(gen_random_uuid())
+                    PLACING substring(int8send(floor(extract(epoch FROM clock_timestamp()) * 1000)::bigint) FROM 3)
+                    FROM 1 FOR 6
+                ),
+                52, 1
+            ),
+            53, 1
+        ),
+        'hex'
+    )::uuid;
+$$ LANGUAGE sql VOLATILE;
+
+-- existing IDs are kept, as they are in shared links, tokens and other
+-- systems, so only new polls and options get time ordered IDs
+ALTER TABLE polls ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+ALTER TABLE poll_options ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE poll_options ALTER COLUMN id SET DEFAULT gen_random_uuid();
+ALTER TABLE polls ALTER COLUMN id SET DEFAULT gen_random_uuid();
+DROP FUNCTION IF EXISTS uuid_generate_v7();
+-- +goose StatementEnd
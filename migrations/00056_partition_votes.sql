@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+-- votes are spread over 16 partitions by a hash of their poll ID, so casting
+-- a vote and counting a poll's votes only touch the indexes of the poll's
+-- partition. Every query on votes filters by poll ID, so the partitions are
+-- pruned, and unlike partitions by month, the unique indexes of voters and
+-- ballots per poll still hold without including when the vote was cast.
+-- Votes without a poll can't be partitioned, and can't be seen anyway.
+DELETE FROM votes WHERE poll_id IS NULL;
+
+CREATE TABLE votes_partitioned (
+    LIKE votes INCLUDING DEFAULTS,
+    PRIMARY KEY (poll_id, id)
+) PARTITION BY HASH (poll_id);
+
+DO $$
+BEGIN
+    FOR i IN 0..15 LOOP
+        EXECUTE format(
+            'CREATE TABLE votes_p%s PARTITION OF votes_partitioned FOR VALUES WITH (MODULUS 16, REMAINDER %s)',
+            i, i
+        );
+    END LOOP;
+END;
+$$;
+
+INSERT INTO votes_partitioned SELECT * FROM votes;
+
+-- the sequence is kept, so vote IDs carry on from the last one
+ALTER SEQUENCE votes_id_seq OWNED BY votes_partitioned.id;
+DROP TABLE votes;
+ALTER TABLE votes_partitioned RENAME TO votes;
+ALTER TABLE votes RENAME CONSTRAINT votes_partitioned_pkey TO votes_pkey;
+
+ALTER TABLE votes ADD CONSTRAINT votes_poll_id_fkey
+    FOREIGN KEY (poll_id) REFERENCES polls (id) ON DELETE CASCADE;
+ALTER TABLE votes ADD CONSTRAINT votes_option_id_fkey
+    FOREIGN KEY (option_id) REFERENCES poll_options (id) ON DELETE CASCADE;
+
+-- the primary key serves the lookups by poll ID votes_poll_id_idx served
+CREATE INDEX IF NOT EXISTS votes_poll_id_created_at_idx ON votes (poll_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS votes_poll_id_voter_identity_key ON votes (poll_id, voter_identity) WHERE voter_identity <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS votes_ballot_idx ON votes (poll_id, ballot) WHERE ballot <> '';
+
+-- autovacuum analyzes the partitions but not the partitioned table, which is
+-- left to the cleanup worker
+ANALYZE votes;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE votes_unpartitioned (
+    LIKE votes INCLUDING DEFAULTS,
+    PRIMARY KEY (id)
+);
+ALTER TABLE votes_unpartitioned ALTER COLUMN poll_id DROP NOT NULL;
+
+INSERT INTO votes_unpartitioned SELECT * FROM votes;
+
+ALTER SEQUENCE votes_id_seq OWNED BY votes_unpartitioned.id;
+DROP TABLE votes;
+ALTER TABLE votes_unpartitioned RENAME TO votes;
+ALTER TABLE votes RENAME CONSTRAINT votes_unpartitioned_pkey TO votes_pkey;
+
+ALTER TABLE votes ADD CONSTRAINT votes_poll_id_fkey
+    FOREIGN KEY (poll_id) REFERENCES polls (id) ON DELETE CASCADE;
+ALTER TABLE votes ADD CONSTRAINT votes_option_id_fkey
+    FOREIGN KEY (option_id) REFERENCES poll_options (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS votes_poll_id_idx ON votes (poll_id);
+CREATE INDEX IF NOT EXISTS votes_poll_id_created_at_idx ON votes (poll_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS votes_poll_id_voter_identity_key ON votes (poll_id, voter_identity) WHERE voter_identity <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS votes_ballot_idx ON votes (poll_id, ballot) WHERE ballot <> '';
+-- +goose StatementEnd
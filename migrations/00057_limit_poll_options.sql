@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- limit_poll_options keeps polls from getting more than 100 options, the
+-- API's MaxOptions, however they are added. The poll is locked so options
+-- added at the same time are counted one after the other. Polls that already
+-- have more keep them, but can't get new ones.
+CREATE OR REPLACE FUNCTION limit_poll_options() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM 1 FROM polls WHERE id = NEW.poll_id FOR UPDATE;
+    IF (SELECT count(*) FROM poll_options WHERE poll_id = NEW.poll_id) >= 100 THEN
+        RAISE EXCEPTION 'poll % has reached its maximum of 100 options', NEW.poll_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'poll_options_max_options';
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER poll_options_max_options
+BEFORE INSERT ON poll_options
+FOR EACH ROW EXECUTE FUNCTION limit_poll_options();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS poll_options_max_options ON poll_options;
+DROP FUNCTION IF EXISTS limit_poll_options();
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- poll_creations counts the polls each IP or API key created a day, by UTC
+-- date. Subjects are "ip:" followed by the IP, or "key:" followed by the hex
+-- encoded hash of the API key.
+CREATE TABLE IF NOT EXISTS poll_creations (
+    day date NOT NULL,
+    subject text NOT NULL,
+    count integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, subject)
+);
+CREATE TABLE IF NOT EXISTS creation_exemptions (
+    id bigserial PRIMARY KEY,
+    ip text UNIQUE,
+    key_hash bytea UNIQUE,
+    reason text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT creation_exemptions_subject_check CHECK ((ip IS NULL) <> (key_hash IS NULL))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS creation_exemptions;
+DROP TABLE IF EXISTS poll_creations;
+-- +goose StatementEnd
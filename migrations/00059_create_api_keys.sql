@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- api_keys are looked up by their prefix, which is stored as is, and checked
+-- against the hash of the whole key. Revoked keys are kept for the activity
+-- feed entries that name them.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    org_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    role text NOT NULL,
+    prefix text NOT NULL UNIQUE,
+    hash bytea NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    last_used_at timestamp(0) with time zone,
+    revoked_at timestamp(0) with time zone
+);
+CREATE INDEX IF NOT EXISTS api_keys_org_id_idx ON api_keys (org_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- external IDs of polls without an organization are only looked up among
+-- the polls a token is for, so clients can give their polls the same ones
+DROP INDEX IF EXISTS polls_external_id_idx;
+CREATE INDEX IF NOT EXISTS polls_external_id_idx ON polls (external_id) WHERE org_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_external_id_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS polls_external_id_idx ON polls (external_id) WHERE org_id IS NULL;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- created_by is the poll_creations subject a poll was counted for, and is
+-- NULL for polls that weren't limited, e.g. ones created with the admin token
+ALTER TABLE polls ADD COLUMN created_by text;
+-- inserted counts the polls of the subject inserted that day, which can't be
+-- more than count, the creations the API let through within the daily limit
+ALTER TABLE poll_creations ADD COLUMN inserted integer NOT NULL DEFAULT 0;
+
+-- limit_daily_polls keeps subjects from getting more polls a day than were
+-- counted for them, however the polls are inserted. Exempt subjects aren't
+-- counted, so they aren't limited either.
+CREATE OR REPLACE FUNCTION limit_daily_polls() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM creation_exemptions
+        WHERE 'ip:' || ip = NEW.created_by OR 'key:' || encode(key_hash, 'hex') = NEW.created_by
+    ) THEN
+        RETURN NEW;
+    END IF;
+    UPDATE poll_creations
+    SET inserted = inserted + 1
+    WHERE day = (NOW() AT TIME ZONE 'UTC')::date AND subject = NEW.created_by AND inserted < count;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION '% has created as many polls as it may today', NEW.created_by
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'polls_daily_limit';
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER polls_daily_limit
+BEFORE INSERT ON polls
+FOR EACH ROW WHEN (NEW.created_by IS NOT NULL) EXECUTE FUNCTION limit_daily_polls();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS polls_daily_limit ON polls;
+DROP FUNCTION IF EXISTS limit_daily_polls();
+ALTER TABLE poll_creations DROP COLUMN inserted;
+ALTER TABLE polls DROP COLUMN created_by;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the goose database migrations, so the server can
+// run them without the SQL files being deployed alongside it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+)
+
+func TestFS(t *testing.T) {
+	files, err := filepath.Glob("*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goose.SetBaseFS(FS)
+	defer goose.SetBaseFS(nil)
+
+	collected, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		t.Fatalf("collect migrations returned an error: %s", err)
+	}
+	if len(collected) != len(files) {
+		t.Errorf("expected %d embedded migrations, but got %d", len(files), len(collected))
+	}
+}